@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"fmt"
+	"github.com/davidalpert/go-githooks/pkg/message"
+	"github.com/davidalpert/go-githooks/pkg/readonly"
+	"github.com/davidalpert/go-githooks/pkg/vcshost"
+	"github.com/go-git/go-git/v5/config"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var changeIdRe = regexp.MustCompile(`(?m)^Change-Id: I[0-9a-f]{40}\s*$`)
+
+/*
+ * appendChangeId replicates Gerrit's commit-msg hook: a Change-Id trailer is added
+ * when the message does not already carry one, so that amended and rebased commits
+ * (whose messages git carries over) keep the id they were first given.
+ *
+ * git checks for an empty message only after commit-msg runs, so like Gerrit's hook
+ * it leaves a message with nothing but comments and trailers alone, for git to abort.
+ *
+ * reference: https://gerrit-review.googlesource.com/Documentation/cmd-hook-commit-msg.html
+ */
+func (o *CommitMsgOptions) appendChangeId() error {
+	content, _ := message.SplitComments(o.CommitMessageBytes)
+	if changeIdRe.Match(content) || message.Effective(o.CommitMessageBytes, "") == "" {
+		return nil
+	}
+
+	if message.Autosquash(message.Subject(o.CommitMessageBytes)) != "" {
+		return nil
+	}
+
+	if !o.changeIdEnabledForRemotes() {
+		return nil
+	}
+
+	id, err := o.generateChangeId(content)
+	if err != nil {
+		return err
+	}
+
+	changed := message.AppendTrailer(o.CommitMessageBytes, "Change-Id: "+id)
+	if readonly.Enabled() {
+		readonly.Changes("commit-msg", "added Change-Id", o.CommitMessageBytes, changed)
+		return nil
+	}
+	o.CommitMessageBytes = changed
+	return o.writeCommitMessage()
+}
+
+// changeIdEnabledForRemotes reports whether the repo has one of the configured Gerrit
+// remotes, matched by remote name or by the host of its url, where * matches part of
+// a name, e.g. *.acme.com; no configured remotes enables it everywhere
+func (o *CommitMsgOptions) changeIdEnabledForRemotes() bool {
+	if len(o.ChangeIdRemotes) == 0 {
+		return true
+	}
+
+	remotes, err := o.Repo.Remotes()
+	if err != nil {
+		return false
+	}
+	for _, r := range remotes {
+		for _, want := range o.ChangeIdRemotes {
+			want = strings.TrimSpace(want)
+			if want == "" {
+				continue
+			}
+			if r.Config().Name == want {
+				return true
+			}
+			for _, u := range r.Config().URLs {
+				remote, _ := vcshost.Parse(u)
+				if ok, _ := path.Match(strings.ToLower(want), strings.ToLower(remote.Host)); ok && remote.Host != "" {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// generateChangeId hashes the same inputs Gerrit's hook does (parent, identity, time and message)
+func (o *CommitMsgOptions) generateChangeId(msg []byte) (string, error) {
+	var b bytes.Buffer
+	if head, err := o.Repo.Head(); err == nil {
+		fmt.Fprintf(&b, "parent %s\n", head.Hash())
+	}
+	if cfg, err := o.Repo.ConfigScoped(config.GlobalScope); err == nil {
+		fmt.Fprintf(&b, "author %s <%s>\n", cfg.User.Name, cfg.User.Email)
+	}
+	fmt.Fprintf(&b, "time %d\n\n", time.Now().UnixNano())
+	b.Write(msg)
+
+	return fmt.Sprintf("I%x", sha1.Sum(b.Bytes())), nil
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"fmt"
+	"github.com/davidalpert/go-githooks/pkg/message"
+	"github.com/davidalpert/go-githooks/pkg/rules"
+	"github.com/davidalpert/go-githooks/pkg/smartcommit"
+	"github.com/davidalpert/go-githooks/pkg/stack"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var conventionalHeaderRe = regexp.MustCompile(`^([a-z]+)(\([^)]+\))?!?: \S`)
+
+// check runs every commit-msg rule at its default severity
+func (o *CommitMsgOptions) check() []rules.Violation {
+	violations := make([]rules.Violation, 0)
+	violations = append(violations, o.timed("empty-message", o.checkEmptyMessage)...)
+	// a fixup!/squash!/amend! subject is the target's, copied by git for --autosquash
+	if message.Autosquash(o.subjectWithoutPrefix()) == "" {
+		violations = append(violations, o.timed("conventional-header", o.checkConventionalHeader)...)
+		violations = append(violations, o.timed("conventional-scope", o.checkConventionalScope)...)
+		violations = append(violations, o.timed("conventional-type", o.checkConventionalType)...)
+		violations = append(violations, o.timed("ticket-reference", o.checkTicketReference)...)
+	}
+	violations = append(violations, o.timed("dco-signoff", o.checkSignOff)...)
+	violations = append(violations, o.timed("link-domain", o.checkLinkDomains)...)
+	violations = append(violations, o.timed("link-resolves", o.checkLinksResolve)...)
+	violations = append(violations, o.timed("coauthor-email", o.checkCoauthors)...)
+	violations = append(violations, o.timed("sensitive-content", o.checkSensitiveContent)...)
+	violations = append(violations, o.timed("stack-metadata", o.checkStackMetadata)...)
+	violations = append(violations, o.timed("closing-keyword", o.checkClosingKeywords)...)
+	violations = append(violations, o.timed("smart-commit", o.checkSmartCommits)...)
+	violations = append(violations, o.timed("duplicate-subject", o.checkDuplicateSubject)...)
+	violations = append(violations, o.timed("policy-rules", o.checkPolicyRules)...)
+	return o.attachAutoFixes(violations)
+}
+
+// timed runs one rule check, recording how long it took when telemetry is enabled
+func (o *CommitMsgOptions) timed(rule string, check func() []rules.Violation) []rules.Violation {
+	start := time.Now()
+	violations := check()
+	o.Telemetry.RecordCheck(rule, time.Since(start), len(violations))
+	return violations
+}
+
+func violation(rule, msg string) []rules.Violation {
+	return []rules.Violation{{
+		Rule:     rule,
+		Severity: rules.Off,
+		Location: "message",
+		Message:  msg,
+	}}
+}
+
+// checkStackMetadata checks the Topic and Depends-On trailers used by stacked-diff
+// workflows; consistency across the whole stack is checked by pre-push
+func (o *CommitMsgOptions) checkStackMetadata() []rules.Violation {
+	violations := make([]rules.Violation, 0)
+	for _, p := range stack.Parse(o.CommitMessageBytes).Validate() {
+		violations = append(violations, violation("stack-metadata", p)...)
+	}
+	return violations
+}
+
+// checkSmartCommits catches Jira smart commit commands which the Jira integration
+// would silently skip, e.g. '#time soon' or a '#comment' with no text
+func (o *CommitMsgOptions) checkSmartCommits() []rules.Violation {
+	violations := make([]rules.Violation, 0)
+	for _, p := range smartcommit.Validate(o.CommitMessageBytes) {
+		violations = append(violations, violation("smart-commit", p)...)
+	}
+	return violations
+}
+
+// checkEmptyMessage catches messages which would record nothing but an automatic
+// branch prefix and trailers, e.g. a commit made by saving the editor untouched
+func (o *CommitMsgOptions) checkEmptyMessage() []rules.Violation {
+	if message.Effective(o.CommitMessageBytes, o.PrefixWithBranchTemplate) != "" {
+		return nil
+	}
+	return violation("empty-message", "the message has no subject, only a branch prefix and trailers")
+}
+
+// checkConventionalHeader expects 'type(scope)!: description' after any branch prefix
+func (o *CommitMsgOptions) checkConventionalHeader() []rules.Violation {
+	subject := o.subjectWithoutPrefix()
+	if subject == "" || strings.HasPrefix(subject, "Merge ") || strings.HasPrefix(subject, "Revert \"") {
+		return nil
+	}
+
+	m := conventionalHeaderRe.FindStringSubmatch(subject)
+	if m == nil {
+		return violation("conventional-header", fmt.Sprintf("subject '%s' is not in the form 'type(scope): description'", subject))
+	}
+	for _, t := range o.ConventionalTypes {
+		if strings.TrimSpace(t) == m[1] {
+			return nil
+		}
+	}
+	return violation("conventional-header", fmt.Sprintf("type '%s' is not one of: %s", m[1], strings.Join(o.ConventionalTypes, ", ")))
+}
+
+// checkTicketReference expects the message to mention a ticket matching TicketPattern
+func (o *CommitMsgOptions) checkTicketReference() []rules.Violation {
+	re, err := regexp.Compile(o.TicketPattern)
+	if err != nil {
+		return violation("ticket-reference", fmt.Sprintf("ticketPattern '%s' is not a valid regular expression: %v", o.TicketPattern, err))
+	}
+
+	content, _ := message.SplitComments(o.CommitMessageBytes)
+	if re.Match(content) {
+		return nil
+	}
+	return violation("ticket-reference", fmt.Sprintf("the message does not reference a ticket matching '%s'", o.TicketPattern))
+}
+
+// checkSignOff expects a Developer Certificate of Origin sign-off by the committer
+func (o *CommitMsgOptions) checkSignOff() []rules.Violation {
+	content, _ := message.SplitComments(o.CommitMessageBytes)
+	want := "Signed-off-by: "
+	if o.UserName != "" && o.UserEmail != "" {
+		want = fmt.Sprintf("Signed-off-by: %s <%s>", o.UserName, o.UserEmail)
+	}
+
+	for _, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, want) {
+			return nil
+		}
+		// a sign-off with the committer's canonical identity counts too
+		if v := strings.TrimPrefix(line, "Signed-off-by: "); v != line && o.UserEmail != "" &&
+			o.Mailmap.Identity(v) == o.Mailmap.Identity(strings.TrimPrefix(want, "Signed-off-by: ")) {
+			return nil
+		}
+	}
+	v := violation("dco-signoff", fmt.Sprintf("the message is missing '%s' (commit with -s)", want))
+	if o.UserName != "" && o.UserEmail != "" {
+		v[0].Fix = func(content []byte) []byte {
+			return message.AppendTrailer(content, want)
+		}
+	}
+	return v
+}
+
+func (o *CommitMsgOptions) subjectWithoutPrefix() string {
+	return message.StripPrefix(message.Subject(o.CommitMessageBytes), o.PrefixWithBranchTemplate)
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"fmt"
+	"github.com/davidalpert/go-githooks/pkg/gitconfig"
+	"github.com/davidalpert/go-githooks/pkg/message"
+	"github.com/davidalpert/go-githooks/pkg/repostate"
+	"github.com/davidalpert/go-githooks/pkg/rules"
+	"github.com/go-git/go-git/v5/config"
+	"path"
+	"regexp"
+	"strings"
+)
+
+const closingKeywordSubsectionPrefix = "closing-keyword."
+
+// closingKeywordRe finds the keywords GitHub and GitLab close issues with when
+// followed by an issue reference, e.g. 'Fixes #12' or 'closes org/repo#3'
+var closingKeywordRe = regexp.MustCompile(`(?i)\b(close[sd]?|fix(?:e[sd])?|resolve[sd]?):?[ \t]+(#\d+|[\w.-]+/[\w.-]+#\d+|[A-Z][A-Z0-9]+-\d+|https?://\S+/issues/\d+)`)
+
+// ClosingKeywordPolicy limits where one closing keyword may be used, configured in
+// a [go-githooks "closing-keyword.<keyword>"] section
+type ClosingKeywordPolicy struct {
+	Keyword    string         // closes, fixes or resolves; covers the other tenses too
+	Branches   []string       // globs; the keyword may only be used on these branches
+	References *regexp.Regexp // the references the keyword may be used with
+}
+
+// closingKeyword normalizes close, closed, fix, fixed, etc. to closes, fixes or resolves
+func closingKeyword(word string) string {
+	w := strings.ToLower(word)
+	switch {
+	case strings.HasPrefix(w, "close"):
+		return "closes"
+	case strings.HasPrefix(w, "fix"):
+		return "fixes"
+	case strings.HasPrefix(w, "resolve"):
+		return "resolves"
+	}
+	return ""
+}
+
+func parseClosingKeywordPolicies(cfg *config.Config) (map[string]ClosingKeywordPolicy, error) {
+	policies := map[string]ClosingKeywordPolicy{}
+	if !cfg.Raw.HasSection("go-githooks") {
+		return policies, nil
+	}
+	for _, sub := range cfg.Raw.Section("go-githooks").Subsections {
+		if !strings.HasPrefix(sub.Name, closingKeywordSubsectionPrefix) {
+			continue
+		}
+		name := strings.TrimPrefix(sub.Name, closingKeywordSubsectionPrefix)
+		keyword := closingKeyword(name)
+		if keyword == "" {
+			return nil, fmt.Errorf("unknown closing keyword '%s', expected one of: closes, fixes, resolves", name)
+		}
+
+		p := ClosingKeywordPolicy{Keyword: keyword}
+		if branches := gitconfig.List(sub.Options.GetAll("branches")); len(branches) > 0 {
+			for _, b := range branches {
+				p.Branches = append(p.Branches, strings.TrimSpace(b))
+			}
+		}
+		if refs := sub.Options.Get("references"); refs != "" {
+			re, err := regexp.Compile(refs)
+			if err != nil {
+				return nil, fmt.Errorf("closing keyword '%s': references '%s' is not a valid regular expression: %v", name, refs, err)
+			}
+			p.References = re
+		}
+		policies[keyword] = p
+	}
+	return policies, nil
+}
+
+// checkClosingKeywords keeps issues from being closed from the wrong place, e.g.
+// by a 'Closes #12' on a feature branch which a forge acts on as soon as it is
+// pushed rather than when it is merged
+func (o *CommitMsgOptions) checkClosingKeywords() []rules.Violation {
+	if len(o.ClosingKeywords) == 0 {
+		return nil
+	}
+
+	// the branch is unknown outside a repo, e.g. under 'go-githooks serve'
+	branch := ""
+	if o.Repo != nil {
+		if state, err := repostate.Detect(o.Repo); err == nil {
+			branch = state.Branch
+		}
+	}
+
+	content, _ := message.SplitComments(o.CommitMessageBytes)
+	violations := make([]rules.Violation, 0)
+	for _, m := range closingKeywordRe.FindAllStringSubmatch(string(content), -1) {
+		p, ok := o.ClosingKeywords[closingKeyword(m[1])]
+		if !ok {
+			continue
+		}
+		if len(p.Branches) > 0 && branch != "" && !matchesBranch(branch, p.Branches) {
+			v := violation("closing-keyword", fmt.Sprintf("'%s' may only be used on %s, not on %s; use 'Refs %s' to link the issue without closing it", strings.TrimSpace(m[0]), strings.Join(p.Branches, ", "), branch, m[2]))
+			v[0].Fix = replaceClosingKeyword(m[0], m[2])
+			violations = append(violations, v...)
+			continue
+		}
+		if p.References != nil && !p.References.MatchString(m[2]) {
+			violations = append(violations, violation("closing-keyword", fmt.Sprintf("'%s' may only close references matching '%s'", strings.TrimSpace(m[0]), p.References))...)
+		}
+	}
+	return violations
+}
+
+func matchesBranch(branch string, globs []string) bool {
+	for _, g := range globs {
+		if ok, _ := path.Match(g, branch); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func replaceClosingKeyword(found, ref string) func([]byte) []byte {
+	return func(content []byte) []byte {
+		return []byte(strings.Replace(string(content), found, "Refs "+ref, 1))
+	}
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/davidalpert/go-githooks/pkg/budget"
+	"github.com/davidalpert/go-githooks/pkg/directory"
+	"github.com/davidalpert/go-githooks/pkg/message"
+	"github.com/davidalpert/go-githooks/pkg/rules"
+	"github.com/davidalpert/go-githooks/pkg/secrets"
+	"strings"
+)
+
+// checkCoauthors catches the emails of coauthors, in whatever role, which would not
+// be attributed to anyone, e.g. a typo like @serentiy.com, by checking them against
+// the domains the org uses and, when configured, against its directory
+func (o *CommitMsgOptions) checkCoauthors() []rules.Violation {
+	if o.Severities.For("coauthor-email", rules.Off) == rules.Off {
+		return nil
+	}
+	if len(o.CoauthorDomains) == 0 && o.CoauthorDirectory == "" {
+		return nil
+	}
+	// the directory may be a server on the network
+	if o.CoauthorDirectory != "" && !o.Budget.Allows("coauthor-email", budget.Normal) {
+		return nil
+	}
+
+	var dir directory.Directory
+	if o.CoauthorDirectory != "" {
+		// resolved only now so that a keychain prompt is not shown while the rule is off
+		token, err := secrets.Resolve(o.CoauthorDirectoryToken)
+		if err != nil {
+			return violation("coauthor-email", fmt.Sprintf("could not resolve coauthorDirectoryToken: %v", err))
+		}
+		if dir, err = directory.Open(o.CoauthorDirectory, token); err != nil {
+			return violation("coauthor-email", err.Error())
+		}
+	}
+
+	violations := make([]rules.Violation, 0)
+	for _, written := range message.Credits(o.CommitMessageBytes) {
+		// an old address which the mailmap knows to be someone's is checked as
+		// their canonical one; fixes still apply to what was written
+		c := o.Mailmap.Coauthor(written)
+		if len(o.CoauthorDomains) > 0 && !matchesDomain(c.Domain(), o.CoauthorDomains) {
+			v := violation("coauthor-email", fmt.Sprintf("'%s' is not at one of: %s", c.Email, strings.Join(o.CoauthorDomains, ", ")))
+			if suggestion := o.closestDomain(c.Domain()); suggestion != "" && c.Email == written.Email {
+				v[0].Message += fmt.Sprintf("; did you mean @%s?", suggestion)
+				v[0].Fix = replaceCoauthorDomain(written, suggestion)
+			}
+			violations = append(violations, v...)
+			continue
+		}
+
+		if dir != nil {
+			found, err := dir.Contains(c.Email)
+			if err != nil {
+				violations = append(violations, violation("coauthor-email", fmt.Sprintf("could not look up '%s': %v", c.Email, err))...)
+			} else if !found {
+				violations = append(violations, violation("coauthor-email", fmt.Sprintf("'%s' is not in the directory", c.Email))...)
+			}
+		}
+	}
+	return violations
+}
+
+// closestDomain returns the known domain a typo most likely meant, if any is close enough
+func (o *CommitMsgOptions) closestDomain(domain string) string {
+	known := append([]string{}, o.CoauthorDomains...)
+	if i := strings.LastIndex(o.UserEmail, "@"); i >= 0 {
+		known = append(known, o.UserEmail[i+1:])
+	}
+
+	best, bestDistance := "", 3
+	for _, k := range known {
+		k = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(k), "*."))
+		if d := editDistance(domain, k); d > 0 && d < bestDistance {
+			best, bestDistance = k, d
+		}
+	}
+	return best
+}
+
+func replaceCoauthorDomain(c message.Coauthor, domain string) func([]byte) []byte {
+	fixed := c
+	fixed.Email = c.Email[:strings.LastIndex(c.Email, "@")+1] + domain
+	return func(content []byte) []byte {
+		return bytes.Replace(content, []byte("<"+c.Email+">"), []byte("<"+fixed.Email+">"), -1)
+	}
+}
+
+// editDistance is the Levenshtein distance between a and b
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = cur[j-1] + 1
+			if prev[j]+1 < cur[j] {
+				cur[j] = prev[j] + 1
+			}
+			if prev[j-1]+cost < cur[j] {
+				cur[j] = prev[j-1] + cost
+			}
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"github.com/davidalpert/go-githooks/pkg/rules"
+	"github.com/davidalpert/go-githooks/pkg/staged"
+	"strings"
+)
+
+// checkConventionalType warns when the type in a 'type(scope): description' subject
+// contradicts the staged files: 'docs', 'test', 'ci' and 'build' changes touch only
+// files of that kind, and a feat, fix or perf changes more than docs. Other types,
+// e.g. chore or refactor, fit any change
+func (o *CommitMsgOptions) checkConventionalType() []rules.Violation {
+	if o.Severities.For("conventional-type", rules.Off) == rules.Off {
+		return nil
+	}
+
+	m := conventionalHeaderRe.FindStringSubmatch(o.subjectWithoutPrefix())
+	if m == nil {
+		return nil
+	}
+	files, err := staged.Files(o.Repo)
+	if err != nil {
+		return violation("conventional-type", fmt.Sprintf("could not read the staged files: %v", err))
+	}
+	inferred := staged.Classify(files)
+	if inferred == "" {
+		return nil
+	}
+
+	declared := m[1]
+	var v []rules.Violation
+	switch declared {
+	case staged.DocsKind, staged.TestKind, staged.CIKind, staged.BuildKind:
+		for _, f := range files {
+			if k := staged.Kind(f.Path); k != declared && !(declared == staged.BuildKind && k == staged.CIKind) {
+				v = violation("conventional-type", fmt.Sprintf("type '%s' but '%s' is not %s; this looks like '%s'", declared, f.Path, kindName(declared), inferred))
+				break
+			}
+		}
+	case "feat", "fix", "perf":
+		if inferred == staged.DocsKind {
+			v = violation("conventional-type", fmt.Sprintf("type '%s' but only docs are staged; this looks like 'docs'", declared))
+		}
+	}
+	if len(v) > 0 && inferred != staged.CodeKind {
+		v[0].Fix = replaceType(m[1]+m[2], inferred+m[2])
+	}
+	return v
+}
+
+func kindName(kind string) string {
+	switch kind {
+	case staged.DocsKind:
+		return "documentation"
+	case staged.TestKind:
+		return "a test"
+	case staged.CIKind:
+		return "CI config"
+	}
+	return "part of the build"
+}
+
+func replaceType(found, typed string) func([]byte) []byte {
+	return func(content []byte) []byte {
+		return []byte(strings.Replace(string(content), found, typed, 1))
+	}
+}
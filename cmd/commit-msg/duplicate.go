@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"github.com/davidalpert/go-githooks/pkg/budget"
+	"github.com/davidalpert/go-githooks/pkg/message"
+	"github.com/davidalpert/go-githooks/pkg/rules"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"regexp"
+	"strings"
+)
+
+// checkDuplicateSubject warns when the subject repeats one of the last
+// DuplicateSubjectDepth subjects on the branch, e.g. a reflexive 'fix' or 'wip',
+// unless it matches one of DuplicateSubjectAllow
+func (o *CommitMsgOptions) checkDuplicateSubject() []rules.Violation {
+	if o.DuplicateSubjectDepth <= 0 || o.Severities.For("duplicate-subject", rules.Warning) == rules.Off || !o.Budget.Allows("duplicate-subject", budget.Low) {
+		return nil
+	}
+	subject := o.subjectWithoutPrefix()
+	if subject == "" || matchesAnySubject(o.DuplicateSubjectAllow, subject) {
+		return nil
+	}
+	head, err := o.Repo.Head()
+	if err != nil {
+		// nothing committed yet
+		return nil
+	}
+	c, err := o.Repo.CommitObject(head.Hash())
+	for n := 1; err == nil && n <= o.DuplicateSubjectDepth; n++ {
+		if !o.isThisCommit(c) && message.StripPrefix(message.Subject([]byte(c.Message)), o.PrefixWithBranchTemplate) == subject {
+			v := violation("duplicate-subject", fmt.Sprintf("subject '%s' repeats %s, %d commits back; say what this commit changes", subject, c.Hash.String()[:7], n))
+			v[0].Severity = rules.Warning
+			return v
+		}
+		if c.NumParents() == 0 {
+			break
+		}
+		c, err = c.Parent(0)
+	}
+	return nil
+}
+
+// isThisCommit recognizes the commit being amended, or verified: git keeps the
+// author date through an amend and passes it to the hook in GIT_AUTHOR_DATE
+func (o *CommitMsgOptions) isThisCommit(c *object.Commit) bool {
+	return !o.AuthorDate.IsZero() && c.Author.When.Unix() == o.AuthorDate.Unix() && strings.EqualFold(c.Author.Email, o.Author)
+}
+
+// matchesAnySubject matches subject against patterns where * stands for anything
+func matchesAnySubject(patterns []string, subject string) bool {
+	for _, p := range patterns {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		re := "^" + strings.Replace(regexp.QuoteMeta(p), `\*`, ".*", -1) + "$"
+		if matched, _ := regexp.MatchString(re, subject); matched {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"fmt"
+	"github.com/davidalpert/go-githooks/pkg/fixmemory"
+	"github.com/davidalpert/go-githooks/pkg/gitconfig"
+	"github.com/davidalpert/go-githooks/pkg/readonly"
+	"github.com/davidalpert/go-githooks/pkg/rules"
+	"github.com/go-git/go-git/v5/config"
+	"strings"
+	"time"
+)
+
+func autoFixesFromConfig(cfg *config.Config) ([]fixmemory.Fix, error) {
+	fixes := make([]fixmemory.Fix, 0)
+	for _, v := range gitconfig.GetAll(cfg, "go-githooks", "commit-message", "autoFix") {
+		f, err := fixmemory.Parse(v)
+		if err != nil {
+			return nil, err
+		}
+		fixes = append(fixes, f)
+	}
+	return fixes, nil
+}
+
+// attachAutoFixes offers each recorded auto-fix as the fix of the first of its rules'
+// violations which has none, when all of its rules are violated
+func (o *CommitMsgOptions) attachAutoFixes(violations []rules.Violation) []rules.Violation {
+	if len(o.AutoFixes) == 0 {
+		return violations
+	}
+	violated := make([]string, 0, len(violations))
+	for _, v := range violations {
+		violated = append(violated, v.Rule)
+	}
+	subject := o.subjectWithoutPrefix()
+	for _, f := range o.AutoFixes {
+		if !f.Applies(violated) || f.Apply(subject) == subject {
+			continue
+		}
+		for i := range violations {
+			if violations[i].Fix == nil && f.Fixes(violations[i].Rule) {
+				violations[i].Fix = o.autoFix(f)
+				break
+			}
+		}
+	}
+	return violations
+}
+
+// autoFix applies f to the subject after its branch prefix
+func (o *CommitMsgOptions) autoFix(f fixmemory.Fix) func([]byte) []byte {
+	return func(content []byte) []byte {
+		subject := o.subjectWithoutPrefix()
+		fixed := f.Apply(subject)
+		if subject == "" || fixed == subject {
+			return content
+		}
+		return []byte(strings.Replace(string(content), subject, fixed, 1))
+	}
+}
+
+// rememberAttempt keeps a blocked message, or learns from the change which let the
+// next one pass, offering to record a change made the same way often enough
+func (o *CommitMsgOptions) rememberAttempt(result rules.Result) {
+	if o.FixMemory == nil || readonly.Enabled() {
+		return
+	}
+	if result.Failed() {
+		failed := make([]string, 0)
+		for _, v := range result.Reported {
+			if v.Severity == rules.Error {
+				failed = append(failed, v.Rule)
+			}
+		}
+		o.FixMemory.Fail(failed, o.subjectWithoutPrefix(), time.Now())
+	} else if f, ok := o.FixMemory.Pass(o.subjectWithoutPrefix(), time.Now()); ok {
+		o.offerAutoFix(f)
+	}
+	if err := o.FixMemory.Save(); err != nil {
+		fmt.Printf("could not remember the attempt: %v\n", err)
+	}
+}
+
+// forgetAttempt drops the blocked message when it was not fixed by hand
+func (o *CommitMsgOptions) forgetAttempt() {
+	if o.FixMemory == nil || readonly.Enabled() {
+		return
+	}
+	o.FixMemory.Forget()
+	if err := o.FixMemory.Save(); err != nil {
+		fmt.Printf("could not remember the attempt: %v\n", err)
+	}
+}
+
+// offerAutoFix asks, on a terminal, to record f as an auto-fix in the repo's config;
+// without one it is offered again the next time
+func (o *CommitMsgOptions) offerAutoFix(f fixmemory.Fix) {
+	if o.Prompter == nil {
+		return
+	}
+	fmt.Fprintf(o.Prompter.Out, "you fixed %s the same way more than once: %s\n", strings.Join(f.Rules, ", "), f)
+	if !o.Prompter.Confirm("record it as an auto-fix, offered with the other fixes next time?") {
+		o.FixMemory.Decline(f)
+		return
+	}
+	if err := o.recordAutoFix(f); err != nil {
+		fmt.Fprintf(o.Prompter.Out, "could not record the auto-fix: %v\n", err)
+		return
+	}
+	o.FixMemory.Recorded(f)
+	o.AutoFixes = append(o.AutoFixes, f)
+}
+
+func (o *CommitMsgOptions) recordAutoFix(f fixmemory.Fix) error {
+	cfg, err := o.Repo.Config()
+	if err != nil {
+		return err
+	}
+	cfg.Raw.AddOption("go-githooks", "commit-message", "autoFix", f.String())
+	return o.Repo.SetConfig(cfg)
+}
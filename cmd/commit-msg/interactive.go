@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"github.com/davidalpert/go-githooks/pkg/exitcode"
+	"github.com/davidalpert/go-githooks/pkg/fileio"
+	"github.com/davidalpert/go-githooks/pkg/gitconfig"
+	"github.com/davidalpert/go-githooks/pkg/message"
+	"github.com/davidalpert/go-githooks/pkg/msgbackup"
+	"github.com/davidalpert/go-githooks/pkg/prompt"
+	"github.com/davidalpert/go-githooks/pkg/rules"
+	"github.com/go-git/go-git/v5/config"
+	"os"
+	"os/exec"
+	"time"
+)
+
+// resolveInteractively offers to fix, edit or bypass a failing message instead of
+// aborting the commit outright; it asks again until the message passes
+func (o *CommitMsgOptions) resolveInteractively() error {
+	edited := false
+	for {
+		result := rules.Evaluate(o.check(), o.Severities, o.Baseline)
+		if !result.Failed() {
+			// a message fixed by hand in the editor is learned from like the next commit
+			if edited {
+				o.rememberAttempt(result)
+			} else {
+				o.forgetAttempt()
+			}
+			return o.writeCommitMessage()
+		}
+
+		fixes := make([]func([]byte) []byte, 0)
+		for _, v := range result.Reported {
+			if v.Fix != nil {
+				fixes = append(fixes, v.Fix)
+			}
+		}
+
+		choices := make([]prompt.Choice, 0)
+		if len(fixes) > 0 {
+			choices = append(choices, prompt.Choice{Key: "a", Description: fmt.Sprintf("accept %d fix(es)", len(fixes))})
+		}
+		choices = append(choices,
+			prompt.Choice{Key: "e", Description: "edit the message"},
+			prompt.Choice{Key: "b", Description: "bypass with a reason (recorded as a Hook-Bypass trailer)"},
+			prompt.Choice{Key: "q", Description: "abort the commit"},
+		)
+
+		switch o.Prompter.Choose("the commit message does not meet this repo's rules:", choices) {
+		case "a":
+			for _, fix := range fixes {
+				o.CommitMessageBytes = fix(o.CommitMessageBytes)
+			}
+			edited = false
+		case "e":
+			if err := o.editCommitMessage(); err != nil {
+				return err
+			}
+			edited = true
+		case "b":
+			reason := o.Prompter.Line("reason for bypassing:")
+			if reason == "" {
+				continue
+			}
+			o.CommitMessageBytes = message.AppendTrailer(o.CommitMessageBytes, "Hook-Bypass: "+reason)
+			o.forgetAttempt()
+			return o.writeCommitMessage()
+		default:
+			return exitcode.Wrap(exitcode.Violation, fmt.Errorf("commit aborted"))
+		}
+
+		rules.Evaluate(o.check(), o.Severities, o.Baseline).Print(o.Prompter.Out)
+	}
+}
+
+func (o *CommitMsgOptions) writeCommitMessage() error {
+	if err := msgbackup.Save(msgbackup.Dir(o.Repo), "commit-msg", o.CommitMessageFile, time.Now()); err != nil {
+		fmt.Printf("could not back up the message: %v\n", err)
+	}
+	formatted := o.messageEncoding.Format(o.CommitMessageBytes, o.LineEndings, o.KeepBOM)
+	if err := fileio.ReplaceFile(o.CommitMessageFile, formatted, 0644); err != nil {
+		return fmt.Errorf("could not write commit message '%s': %v", o.CommitMessageFile, err)
+	}
+	return nil
+}
+
+// editCommitMessage opens the message in the user's editor on the terminal
+func (o *CommitMsgOptions) editCommitMessage() error {
+	if err := o.writeCommitMessage(); err != nil {
+		return err
+	}
+
+	cmd := exec.Command("sh", "-c", o.Editor+` "$@"`, o.Editor, o.CommitMessageFile)
+	if tty, ok := o.Prompter.TTY(); ok {
+		cmd.Stdin, cmd.Stdout, cmd.Stderr = tty, tty, tty
+	}
+	if err := cmd.Run(); err != nil {
+		return exitcode.Wrap(exitcode.Of(err), fmt.Errorf("could not run editor '%s': %v", o.Editor, err))
+	}
+	return o.readCommitMessageFromDisk()
+}
+
+// editor picks the editor the way git does
+func editor(cfg *config.Config) string {
+	if e := os.Getenv("GIT_EDITOR"); e != "" {
+		return e
+	}
+	if e := gitconfig.GetString(cfg, "core", "", "editor", ""); e != "" {
+		return e
+	}
+	for _, name := range []string{"VISUAL", "EDITOR"} {
+		if e := os.Getenv(name); e != "" {
+			return e
+		}
+	}
+	return "vi"
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"github.com/davidalpert/go-githooks/pkg/budget"
+	"github.com/davidalpert/go-githooks/pkg/cienv"
+	"github.com/davidalpert/go-githooks/pkg/message"
+	"github.com/davidalpert/go-githooks/pkg/rules"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+)
+
+var urlRe = regexp.MustCompile(`https?://[^\s<>()"'` + "`" + `]+`)
+
+// links returns each distinct URL in the message, ignoring git's comment lines
+func (o *CommitMsgOptions) links() []string {
+	content, _ := message.SplitComments(o.CommitMessageBytes)
+
+	seen := map[string]bool{}
+	links := make([]string, 0)
+	for _, l := range urlRe.FindAllString(string(content), -1) {
+		l = strings.TrimRight(l, ".,;:!?")
+		if !seen[l] {
+			seen[l] = true
+			links = append(links, l)
+		}
+	}
+	return links
+}
+
+// checkLinkDomains keeps links to the domains the repo allows, e.g. no internal-only
+// links in an open source repo; a domain also matches its subdomains
+func (o *CommitMsgOptions) checkLinkDomains() []rules.Violation {
+	if len(o.AllowedLinkDomains) == 0 && len(o.BlockedLinkDomains) == 0 {
+		return nil
+	}
+
+	violations := make([]rules.Violation, 0)
+	for _, l := range o.links() {
+		u, err := url.Parse(l)
+		if err != nil {
+			violations = append(violations, violation("link-domain", fmt.Sprintf("'%s' is not a valid URL", l))...)
+			continue
+		}
+		host := strings.ToLower(u.Hostname())
+		if matchesDomain(host, o.BlockedLinkDomains) {
+			violations = append(violations, violation("link-domain", fmt.Sprintf("'%s' links to a blocked domain", l))...)
+		} else if len(o.AllowedLinkDomains) > 0 && !matchesDomain(host, o.AllowedLinkDomains) {
+			violations = append(violations, violation("link-domain", fmt.Sprintf("'%s' links outside of: %s", l, strings.Join(o.AllowedLinkDomains, ", ")))...)
+		}
+	}
+	return violations
+}
+
+func matchesDomain(host string, domains []string) bool {
+	for _, d := range domains {
+		d = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(d), "*."))
+		if d != "" && (host == d || strings.HasSuffix(host, "."+d)) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkLinksResolve sends a HEAD request to each link (falling back to GET for servers
+// which do not support HEAD); set GIT_HOOKS_OFFLINE to skip it when there is no network
+func (o *CommitMsgOptions) checkLinksResolve() []rules.Violation {
+	if o.Severities.For("link-resolves", rules.Off) == rules.Off || os.Getenv("GIT_HOOKS_OFFLINE") != "" || !o.Budget.Allows("link-resolves", budget.Low) {
+		return nil
+	}
+
+	client := o.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: o.Budget.Remaining(cienv.Timeout(o.LinkTimeout))}
+	}
+
+	violations := make([]rules.Violation, 0)
+	for _, l := range o.links() {
+		res, err := client.Head(l)
+		if err == nil && (res.StatusCode == http.StatusMethodNotAllowed || res.StatusCode == http.StatusNotImplemented) {
+			res.Body.Close()
+			res, err = client.Get(l)
+		}
+		if err != nil {
+			violations = append(violations, violation("link-resolves", fmt.Sprintf("'%s' could not be reached: %v", l, err))...)
+			continue
+		}
+		res.Body.Close()
+		if res.StatusCode >= 400 {
+			violations = append(violations, violation("link-resolves", fmt.Sprintf("'%s' returned %s", l, res.Status))...)
+		}
+	}
+	return violations
+}
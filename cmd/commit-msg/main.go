@@ -0,0 +1,478 @@
+package main
+
+import (
+	"fmt"
+	"github.com/davidalpert/go-githooks/pkg/budget"
+	"github.com/davidalpert/go-githooks/pkg/bypass"
+	"github.com/davidalpert/go-githooks/pkg/crash"
+	"github.com/davidalpert/go-githooks/pkg/exitcode"
+	"github.com/davidalpert/go-githooks/pkg/fileio"
+	"github.com/davidalpert/go-githooks/pkg/fixmemory"
+	"github.com/davidalpert/go-githooks/pkg/gitconfig"
+	"github.com/davidalpert/go-githooks/pkg/guilog"
+	"github.com/davidalpert/go-githooks/pkg/mailmap"
+	"github.com/davidalpert/go-githooks/pkg/message"
+	"github.com/davidalpert/go-githooks/pkg/notify"
+	"github.com/davidalpert/go-githooks/pkg/policyexpr"
+	"github.com/davidalpert/go-githooks/pkg/presets"
+	"github.com/davidalpert/go-githooks/pkg/prompt"
+	"github.com/davidalpert/go-githooks/pkg/readonly"
+	"github.com/davidalpert/go-githooks/pkg/rules"
+	"github.com/davidalpert/go-githooks/pkg/scripts"
+	"github.com/davidalpert/go-githooks/pkg/staged"
+	"github.com/davidalpert/go-githooks/pkg/telemetry"
+	"github.com/davidalpert/go-githooks/pkg/updatecheck"
+	"github.com/davidalpert/go-githooks/pkg/vcshost"
+	"github.com/go-git/go-git/v5"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+var (
+	Version = "n/a"
+)
+
+/*
+ * The commit-msg hook takes one parameter, the path to a temporary file that
+ * contains the commit message written by the developer. If this script exits
+ * non-zero, git aborts the commit process, so you can use it to validate your
+ * project state or commit message before allowing a commit to go through.
+ *
+ * reference: https://git-scm.com/docs/githooks#_commit_msg
+ */
+type CommitMsgOptions struct {
+	// 1 positional arg provided by git
+	CommitMessageFile string
+
+	Repo *git.Repository
+
+	// these are configuration options, set through git config
+	PrefixWithBranchTemplate string
+	ConventionalTypes        []string
+	TicketPattern            string
+	AllowedLinkDomains       []string
+	BlockedLinkDomains       []string
+	LinkTimeout              time.Duration
+	InteractiveFixes         bool
+	CoauthorDomains          []string
+	CoauthorDirectory        string
+	CoauthorDirectoryToken   string
+	RedactMode               RedactMode
+	RedactCredentials        bool
+	RedactTerms              []string
+	RedactDomains            []string
+	RedactPatterns           []string
+	ClosingKeywords          map[string]ClosingKeywordPolicy
+	ScopeMap                 staged.ScopeMap
+	DuplicateSubjectDepth    int
+	DuplicateSubjectAllow    []string
+	CreateChangeId           bool
+	ChangeIdRemotes          []string
+	PolicyRules              []policyexpr.Rule
+	AutoFixes                []fixmemory.Fix
+	FixMemory                *fixmemory.Store // nil unless learning from the fixes made to blocked commits
+	ScriptsEnabled           bool
+	PreviewMessage           bool
+	LineEndings              message.LineEndings
+	KeepBOM                  bool
+	CleanupMode              message.CleanupMode // commit.cleanup
+	CommentChar              string              // core.commentChar
+	Severities               rules.Severities
+	Baseline                 *rules.Baseline
+	Enforcement              *rules.Enforcement // nil unless progressive enforcement is configured
+	Notifier                 *notify.Notifier
+	Budget                   *budget.Budget
+	Telemetry                *telemetry.Recorder // nil unless go-githooks.telemetry.enabled
+
+	UserName   string
+	UserEmail  string
+	Author     string           // the author's email, whose grace warnings Enforcement counts
+	AuthorDate time.Time        // from GIT_AUTHOR_DATE, which git keeps through an amend; zero when unset
+	Mailmap    *mailmap.Mailmap // nil when go-githooks.mailmap is off
+
+	HTTPClient *http.Client
+	Prompter   *prompt.Prompter // nil when no terminal is attached
+	Editor     string
+
+	CommitMessageBytes []byte
+	messageEncoding    message.Encoding
+}
+
+func NewOptions(repo *git.Repository) *CommitMsgOptions {
+	return &CommitMsgOptions{
+		Repo:   repo,
+		Budget: budget.New("commit-msg"),
+	}
+}
+
+func (o *CommitMsgOptions) Prepare(args []string) error {
+	if len(args) != 1 {
+		return exitcode.Wrap(exitcode.Usage, fmt.Errorf("expected 'version' or 1 arg, got %d: %v", len(args), args))
+	}
+
+	o.CommitMessageFile = args[0]
+
+	o.setDefaultOptions()
+	return exitcode.Wrap(exitcode.Config, o.overrideFromRepo())
+}
+
+func (o *CommitMsgOptions) setDefaultOptions() {
+	o.PrefixWithBranchTemplate = "[%s]"
+	o.ConventionalTypes = []string{"feat", "fix", "docs", "style", "refactor", "perf", "test", "build", "ci", "chore", "revert"}
+	o.TicketPattern = `[A-Z][A-Z0-9]+-[0-9]+`
+	o.AllowedLinkDomains = []string{}
+	o.BlockedLinkDomains = []string{}
+	o.LinkTimeout = 3 * time.Second
+	o.InteractiveFixes = true
+	o.CoauthorDomains = []string{}
+	o.RedactMode = RedactBlock
+	o.RedactCredentials = true
+	o.RedactTerms = []string{}
+	o.ClosingKeywords = map[string]ClosingKeywordPolicy{}
+	o.ScopeMap = staged.ScopeMap{}
+	o.DuplicateSubjectDepth = 10
+	o.DuplicateSubjectAllow = []string{"Merge *", "fixup! *", "squash! *", "amend! *"}
+	o.CreateChangeId = false
+	o.ChangeIdRemotes = []string{}
+	o.RedactDomains = []string{}
+	o.RedactPatterns = []string{}
+	o.PreviewMessage = false
+	o.LineEndings = message.PreserveLineEndings
+	o.KeepBOM = false
+	o.CleanupMode = message.CleanupDefault
+	o.CommentChar = "#"
+	o.Severities = rules.Severities{}
+	o.Baseline = &rules.Baseline{}
+	o.Notifier = notify.New("commit-msg")
+}
+
+func (o *CommitMsgOptions) overrideFromRepo() error {
+	cfg, err := gitconfig.Load(o.Repo)
+	if err != nil {
+		return nil
+	}
+	if err = presets.Apply(cfg); err != nil {
+		return err
+	}
+
+	o.PrefixWithBranchTemplate = gitconfig.GetString(cfg, "go-githooks", "prepare-commit-message", "prefixWithBranchTemplate", o.PrefixWithBranchTemplate)
+	o.PrefixWithBranchTemplate = vcshost.Detect(cfg).Track(o.Repo, cfg).Expand(o.PrefixWithBranchTemplate)
+	o.ScopeMap = staged.ParseScopeMap(gitconfig.GetSlice(cfg, "go-githooks", "scope", "map", nil))
+	if staged.UsesVars(o.PrefixWithBranchTemplate) {
+		summary, err := staged.Summarize(o.Repo, o.ScopeMap)
+		if err != nil {
+			return err
+		}
+		o.PrefixWithBranchTemplate = summary.Expand(o.PrefixWithBranchTemplate)
+	}
+	o.ConventionalTypes = gitconfig.GetSlice(cfg, "go-githooks", "commit-message", "conventionalTypes", o.ConventionalTypes)
+	o.TicketPattern = gitconfig.GetString(cfg, "go-githooks", "commit-message", "ticketPattern", o.TicketPattern)
+	o.AllowedLinkDomains = gitconfig.GetSlice(cfg, "go-githooks", "commit-message", "allowedLinkDomains", o.AllowedLinkDomains)
+	o.BlockedLinkDomains = gitconfig.GetSlice(cfg, "go-githooks", "commit-message", "blockedLinkDomains", o.BlockedLinkDomains)
+	if t := gitconfig.GetString(cfg, "go-githooks", "commit-message", "linkTimeout", ""); t != "" {
+		if o.LinkTimeout, err = time.ParseDuration(t); err != nil {
+			return fmt.Errorf("could not parse linkTimeout '%s': %v", t, err)
+		}
+	}
+	o.InteractiveFixes = gitconfig.GetBool(cfg, "go-githooks", "commit-message", "interactiveFixes", o.InteractiveFixes)
+	if o.DuplicateSubjectDepth, err = gitconfig.GetInt(cfg, "go-githooks", "commit-message", "duplicateSubjectDepth", o.DuplicateSubjectDepth); err != nil {
+		return err
+	}
+	o.DuplicateSubjectAllow = gitconfig.GetSlice(cfg, "go-githooks", "commit-message", "duplicateSubjectAllow", o.DuplicateSubjectAllow)
+	// the Change-Id options were first read by prepare-commit-msg; its keys still work
+	o.CreateChangeId = gitconfig.GetBool(cfg, "go-githooks", "prepare-commit-message", "createChangeId", o.CreateChangeId)
+	o.CreateChangeId = gitconfig.GetBool(cfg, "go-githooks", "commit-message", "createChangeId", o.CreateChangeId)
+	if v := os.Getenv("GIT_COMMIT_MSG_CREATE_CHANGE_ID"); v != "" {
+		if o.CreateChangeId, err = strconv.ParseBool(v); err != nil {
+			return fmt.Errorf("could not parse GIT_COMMIT_MSG_CREATE_CHANGE_ID '%s' as a bool: %v", v, err)
+		}
+	}
+	o.ChangeIdRemotes = gitconfig.GetSlice(cfg, "go-githooks", "prepare-commit-message", "changeIdRemotes", o.ChangeIdRemotes)
+	o.ChangeIdRemotes = gitconfig.GetSlice(cfg, "go-githooks", "commit-message", "changeIdRemotes", o.ChangeIdRemotes)
+	o.Editor = editor(cfg)
+	o.PreviewMessage = gitconfig.GetBool(cfg, "go-githooks", "commit-message", "previewMessage", o.PreviewMessage)
+	o.LineEndings = message.LineEndingsFromString(gitconfig.GetString(cfg, "go-githooks", "commit-message", "lineEndings", string(o.LineEndings)))
+	o.KeepBOM = gitconfig.GetBool(cfg, "go-githooks", "commit-message", "keepByteOrderMark", o.KeepBOM)
+	o.CleanupMode = message.CleanupModeFromString(gitconfig.GetString(cfg, "commit", "", "cleanup", string(o.CleanupMode)))
+	if c := gitconfig.GetString(cfg, "core", "", "commentChar", o.CommentChar); c != "auto" {
+		// git picks a character the message does not start a line with for auto; # is its first choice
+		o.CommentChar = c
+	}
+	o.CoauthorDomains = gitconfig.GetSlice(cfg, "go-githooks", "commit-message", "coauthorDomains", o.CoauthorDomains)
+	o.CoauthorDirectory = gitconfig.GetString(cfg, "go-githooks", "commit-message", "coauthorDirectory", o.CoauthorDirectory)
+	o.CoauthorDirectoryToken = gitconfig.GetString(cfg, "go-githooks", "commit-message", "coauthorDirectoryToken", o.CoauthorDirectoryToken)
+	o.RedactMode = RedactModeFromString(gitconfig.GetString(cfg, "go-githooks", "commit-message", "redactMode", string(o.RedactMode)))
+	o.RedactCredentials = gitconfig.GetBool(cfg, "go-githooks", "commit-message", "redactCredentials", o.RedactCredentials)
+	o.RedactTerms = gitconfig.GetSlice(cfg, "go-githooks", "commit-message", "redactTerms", o.RedactTerms)
+	o.RedactDomains = gitconfig.GetSlice(cfg, "go-githooks", "commit-message", "redactDomains", o.RedactDomains)
+	if patterns := gitconfig.GetAll(cfg, "go-githooks", "commit-message", "redactPattern"); len(patterns) > 0 {
+		// each pattern is its own value since patterns may contain commas
+		o.RedactPatterns = patterns
+	}
+	if err = o.Notifier.Configure(cfg); err != nil {
+		return err
+	}
+	if err = o.Budget.Configure(cfg); err != nil {
+		return err
+	}
+	o.Telemetry = telemetry.NewRecorder("commit-msg", gitconfig.GetBool(cfg, "go-githooks", "telemetry", "enabled", false))
+	if o.ClosingKeywords, err = parseClosingKeywordPolicies(cfg); err != nil {
+		return err
+	}
+	if o.PolicyRules, err = policyexpr.RulesFromConfig(cfg); err != nil {
+		return err
+	}
+	if o.AutoFixes, err = autoFixesFromConfig(cfg); err != nil {
+		return err
+	}
+	// only a real commit's attempts are remembered, not messages linted by go-githooks
+	if gitconfig.GetBool(cfg, "go-githooks", "commit-message", "learnFixes", true) && filepath.Base(o.CommitMessageFile) == "COMMIT_EDITMSG" {
+		if o.FixMemory, err = fixmemory.Load(fixmemory.Path(o.Repo)); err != nil {
+			fmt.Printf("not learning from fixes: %v\n", err)
+			o.FixMemory = nil
+		}
+	}
+	o.ScriptsEnabled = scripts.Enabled(o.Repo)
+	// like git, an identity in the environment wins over config
+	o.UserName = getenvOr("GIT_COMMITTER_NAME", cfg.User.Name)
+	o.UserEmail = getenvOr("GIT_COMMITTER_EMAIL", cfg.User.Email)
+	o.Author = getenvOr("GIT_AUTHOR_EMAIL", cfg.User.Email)
+	o.AuthorDate = rules.AuthorDate(os.Getenv("GIT_AUTHOR_DATE"), time.Time{})
+	if gitconfig.GetBool(cfg, "go-githooks", "", "mailmap", true) {
+		if o.Mailmap, err = mailmap.Load(o.Repo, cfg); err != nil {
+			return err
+		}
+	}
+
+	if o.Severities, err = rules.SeveritiesFromConfig(cfg); err != nil {
+		return err
+	}
+	if o.Enforcement, err = rules.EnforcementFromConfig(cfg, bypass.Dir(o.Repo)); err != nil {
+		return err
+	}
+
+	if w, err := o.Repo.Worktree(); err == nil {
+		if o.Baseline, err = rules.LoadWorktreeBaseline(w.Filesystem); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (o *CommitMsgOptions) Execute() error {
+	if o.ScriptsEnabled {
+		if err := o.runScripts(); err != nil {
+			return exitcode.Wrap(exitcode.Violation, err)
+		}
+	}
+	if err := o.maskSensitiveContent(); err != nil {
+		return exitcode.Wrap(exitcode.Config, err)
+	}
+	if o.CreateChangeId {
+		if err := o.appendChangeId(); err != nil {
+			return err
+		}
+	}
+
+	result := rules.Evaluate(o.check(), o.Severities, o.Baseline)
+	authored := o.AuthorDate
+	if authored.IsZero() {
+		authored = time.Now()
+	}
+	result = o.Enforcement.Apply(result, o.Author, authored)
+	if !readonly.Enabled() {
+		if err := o.Enforcement.Save(); err != nil {
+			fmt.Printf("could not count the warning: %v\n", err)
+		}
+	}
+	result.Print(os.Stdout)
+	if err := rules.Record(result); err != nil {
+		fmt.Printf("could not record the baseline: %v\n", err)
+	}
+	if note := o.Budget.Note(); note != "" {
+		fmt.Printf("%s\n", note)
+	}
+
+	o.rememberAttempt(result)
+
+	if result.Failed() && o.Prompter != nil {
+		if err := o.resolveInteractively(); err != nil {
+			return err
+		}
+	} else if result.Failed() {
+		return exitcode.Wrap(exitcode.Violation, fmt.Errorf("the commit message does not meet this repo's rules; fix it and commit again, or skip these checks with --no-verify"))
+	}
+	o.printPreview(os.Stderr)
+	return nil
+}
+
+func (o *CommitMsgOptions) readCommitMessageFromDisk() error {
+	msg, err := fileio.ReadFile(o.CommitMessageFile)
+	if err != nil {
+		return fmt.Errorf("could not read '%s': %v", o.CommitMessageFile, err)
+	}
+	o.CommitMessageBytes, o.messageEncoding = message.Normalize(msg)
+	return nil
+}
+
+func main() {
+	if updatecheck.Requested() {
+		updatecheck.Run()
+		return
+	}
+
+	argsWithoutProg := os.Args[1:]
+
+	if len(argsWithoutProg) == 1 {
+		switch argsWithoutProg[0] {
+		case "version":
+			printVersion()
+			return
+		case "help":
+			printHelp()
+			return
+		}
+	}
+
+	repo, err := git.PlainOpenWithOptions(".", &git.PlainOpenOptions{DetectDotGit: true})
+	checkError("read git repo", exitcode.Wrap(exitcode.Usage, err))
+	defer crash.Recover(crash.Dir(repo), "commit-msg", Version)
+	if err := guilog.Start(repo, "commit-msg"); err != nil {
+		fmt.Printf("could not keep the output for GUI clients: %v\n", err)
+	}
+	defer guilog.Stop()
+	updatecheck.Start(repo, Version)
+
+	o := NewOptions(repo)
+
+	err = o.Prepare(argsWithoutProg)
+	checkError("prepare options", err)
+
+	// there is nothing to fix when nothing is blocked
+	if o.InteractiveFixes && !readonly.Enabled() {
+		o.Prompter = prompt.Terminal()
+		defer o.Prompter.Close()
+	}
+
+	err = o.readCommitMessageFromDisk()
+	checkError("readCommitMessage", err)
+
+	err = o.Execute()
+	err = readonly.Allow("commit-msg", err)
+	err = bypass.Allow(bypass.Dir(repo), "commit-msg", err)
+	o.Notifier.Done(err)
+	if err := o.Telemetry.Flush(); err != nil {
+		fmt.Printf("could not save telemetry: %v\n", err)
+	}
+	if err := guilog.Block(o.CommitMessageFile, err); err != nil {
+		fmt.Printf("could not add the reason to the message: %v\n", err)
+	}
+	checkError("commit-msg", err)
+}
+
+func printVersion() {
+	fmt.Printf("version: %s\n", Version)
+}
+
+func printHelp() {
+	fmt.Printf("help: %s\n", Version)
+	fmt.Printf(`
+configure the severity of each commit-msg rule per-repo in .git/config; lists are comma-separated, or the key
+repeated once per item (git config --add) for items containing commas:
+
+[go-githooks "rules"]
+    empty-message = error        # error | warning | info | off (default: off)
+    conventional-header = error  # subject is 'type(scope)!: description' (default: off)
+    ticket-reference = error     # message references a ticket (default: off)
+    dco-signoff = error          # message is signed off by user.name and user.email (default: off)
+    link-domain = warning        # links only go to allowed domains and none go to blocked ones (default: off)
+    link-resolves = warning      # links answer a HEAD request; skipped when GIT_HOOKS_OFFLINE is set (default: off)
+    coauthor-email = error       # Co-authored-by emails are at an org domain and/or in the org directory (default: off)
+    sensitive-content = error    # message has no credentials, sensitive terms or internal hostnames (default: off)
+    stack-metadata = error       # at most one single-word Topic; Depends-On is a Change-Id, hash or url (default: off)
+    closing-keyword = error      # Closes/Fixes/Resolves are only used where their closing-keyword section allows (default: off)
+    duplicate-subject = warning  # the subject does not repeat one of the last duplicateSubjectDepth on the branch (default: warning)
+    smart-commit = error         # Jira smart commit commands are well formed, e.g. '#time 1d 2h' (default: off)
+    conventional-scope = error   # the scope in 'type(scope): ...' is one of the staged paths' scopes (default: off)
+    conventional-type = warning  # the type fits the staged files, e.g. not 'docs:' when .go files changed (default: off)
+
+[go-githooks "enforcement"]
+    adoptionDate =               # e.g. 2026-11-01: errors in commits authored before this only warn
+    graceWarnings = 0            # then warn each author this many times per rule before blocking; counted
+                                 # in .git/go-githooks/enforcement.json
+
+[go-githooks "commit-message"]
+    conventionalTypes = feat,fix,docs,style,refactor,perf,test,build,ci,chore,revert
+    ticketPattern = [A-Z][A-Z0-9]+-[0-9]+
+    allowedLinkDomains = github.com,example.org     # a domain also allows its subdomains
+    blockedLinkDomains = corp.internal
+    linkTimeout = 3s
+    interactiveFixes = true       # on a terminal, offer to fix, edit or bypass instead of failing (not in CI)
+    learnFixes = true             # when a blocked subject is changed the same way twice before it passes, offer to
+                                  # record the change as an autoFix (attempts are kept in .git/go-githooks/fix-memory.json)
+    autoFix = conventional-header: s/^fix /fix: /
+                                  # a fix offered with the others for those rules: s/<regexp>/<replacement>/ on the
+                                  # subject, or capitalize | lowercase its first letter; repeat the key for more than one
+    previewMessage = false        # print the message as git will record it to stderr, cleaned up as commit.cleanup
+                                  # and core.commentChar say ('git commit --cleanup' is not passed to hooks)
+    lineEndings = preserve        # preserve | lf | crlf: how the lines of a message the hooks rewrite end; the hooks
+                                  # always see LF, with a byte order mark and extra trailing blank lines stripped
+    keepByteOrderMark = false     # write a byte order mark the editor added back to a rewritten message
+    duplicateSubjectDepth = 10    # how many commits back duplicate-subject looks (0: off)
+    duplicateSubjectAllow = Merge *,fixup! *,squash! *,amend! *   # subjects which may repeat; * matches anything
+    coauthorDomains = serenity.com                 # a domain also allows its subdomains
+    coauthorDirectory = csv:.github/people.csv     # or scim:<url>, or command:<command taking the email>
+    coauthorDirectoryToken = keyring:scim-token    # bearer token for scim, see 'go-githooks secret'; the token
+                                                   # and scim: or command: directories are only read from
+                                                   # .git/config or ~/.gitconfig, never shared config
+    createChangeId = false        # add a Gerrit Change-Id trailer when missing, as Gerrit's own commit-msg hook does;
+                                  # not to a message with nothing but comments and trailers, which git then aborts
+    changeIdRemotes =             # only for repos with one of these remotes, by name or url host (*.acme.com);
+                                  # both are also read from [go-githooks "prepare-commit-message"], where they began
+    redactMode = block            # block | mask: replace sensitive content with [REDACTED] instead of failing
+    redactCredentials = true      # look for access keys, tokens, private keys and password=... assignments
+    redactTerms = Acme Corp,Globex                 # e.g. customer names, matched as whole words
+    redactDomains = corp.internal                  # internal hostnames, including subdomains
+    redactPattern = ticket-[0-9]{4,}               # a regular expression; repeat the key for more than one
+
+[go-githooks "closing-keyword.closes"]   # also closing-keyword.fixes and closing-keyword.resolves; each covers every
+    branches = main,release/*                # tense (close, closed, ...). Keywords without a section are allowed anywhere;
+    references = "^#[0-9]+$"                 # one on another branch can be fixed to 'Refs #12'
+
+[go-githooks "scope"]
+    map = services/billing=billing,web=frontend    # path prefix=scope; other paths are scoped by their top-level directory
+
+[go-githooks "policy-rules"]     # the repo's own rules, each a CEL expression over message (subject, summary, body, text,
+                                 # trailers, tickets), branch, files (path, added, kind), author and committer (name,
+                                 # email) which must be true; severity in [go-githooks "rules"] (default: error)
+    no-wip-on-main = !(branch == 'main' && message.summary.startsWith('WIP'))
+    migrations-need-ticket = !files.exists(f, f.path.startsWith('db/migrate/')) || size(message.tickets) > 0
+
+[go-githooks]
+    mailmap = true                # check coauthors and the sign-off by their identities in the repo's .mailmap
+    guiLog = false                # keep the output in .git/go-githooks/last-run.log and, when blocking, add it to
+                                  # the message as comments, for GUI clients which do not show hook output
+
+[go-githooks "scripts"]
+    enabled = false               # run the repo's .githooks/commit-msg.d/* first, piping the message through them;
+                                  # only read from .git/config or ~/.gitconfig, never shared config
+
+[go-githooks "budget"]
+    commit-msg =                  # e.g. 2s: as the hook uses up this budget it skips duplicate-subject and
+                                  # link-resolves, then checking coauthors against coauthorDirectory; the
+                                  # checks which need no network or history always run
+
+[go-githooks "notify"]
+    enabled = false              # a desktop notification when a commit-msg which took longer than 'after' blocks the commit,
+    after = 10s                  # e.g. while its terminal is in the background
+
+[go-githooks "telemetry"]
+    enabled = false              # opt in to local, anonymous usage stats of which rules run, how long they take and
+                                 # how often they fail (see: go-githooks telemetry status); only read from
+                                 # .git/config or ~/.gitconfig
+
+`)
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"github.com/davidalpert/go-githooks/pkg/message"
+	"github.com/davidalpert/go-githooks/pkg/repostate"
+	"github.com/davidalpert/go-githooks/pkg/rules"
+	"github.com/davidalpert/go-githooks/pkg/staged"
+	"regexp"
+	"strings"
+)
+
+// checkPolicyRules evaluates the repo's own [go-githooks "policy-rules"] over the
+// commit; each is an error unless its severity is configured otherwise
+func (o *CommitMsgOptions) checkPolicyRules() []rules.Violation {
+	violations := make([]rules.Violation, 0)
+	if len(o.PolicyRules) == 0 {
+		return violations
+	}
+	vars := o.policyContext()
+	for _, r := range o.PolicyRules {
+		holds, err := r.Program.Holds(vars)
+		msg := ""
+		if err != nil {
+			msg = fmt.Sprintf("could not evaluate '%s': %v", r.Program.Source, err)
+		} else if !holds {
+			msg = fmt.Sprintf("the commit does not satisfy '%s'", r.Program.Source)
+		}
+		if msg != "" {
+			violations = append(violations, rules.Violation{Rule: r.Name, Severity: rules.Error, Location: "message", Message: msg})
+		}
+	}
+	return violations
+}
+
+// policyContext is what policy rules can refer to: message, branch, files, author
+// and committer; files is empty when nothing is staged, e.g. when verifying past commits
+func (o *CommitMsgOptions) policyContext() map[string]interface{} {
+	content, _ := message.SplitComments(o.CommitMessageBytes)
+	body := ""
+	if parts := strings.SplitN(strings.TrimSpace(string(content)), "\n", 2); len(parts) == 2 {
+		body = strings.TrimSpace(parts[1])
+	}
+	trailers := map[string]interface{}{}
+	for _, t := range message.Trailers(o.CommitMessageBytes) {
+		key := strings.ToLower(t.Key)
+		values, _ := trailers[key].([]interface{})
+		trailers[key] = append(values, t.Value)
+	}
+	tickets := make([]interface{}, 0)
+	if re, err := regexp.Compile(o.TicketPattern); err == nil && o.TicketPattern != "" {
+		for _, t := range message.Tickets(o.CommitMessageBytes, re) {
+			tickets = append(tickets, t)
+		}
+	}
+
+	branch := ""
+	if state, err := repostate.Detect(o.Repo); err == nil {
+		branch = state.Branch
+	}
+	files := make([]interface{}, 0)
+	if stagedFiles, err := staged.Files(o.Repo); err == nil {
+		for _, f := range stagedFiles {
+			files = append(files, map[string]interface{}{"path": f.Path, "added": f.Added, "kind": staged.Kind(f.Path)})
+		}
+	}
+
+	return map[string]interface{}{
+		"message": map[string]interface{}{
+			"text":     strings.TrimSpace(string(content)),
+			"subject":  message.Subject(o.CommitMessageBytes),
+			"summary":  o.subjectWithoutPrefix(),
+			"body":     body,
+			"trailers": trailers,
+			"tickets":  tickets,
+		},
+		"branch":    branch,
+		"files":     files,
+		"author":    map[string]interface{}{"name": getenvOr("GIT_AUTHOR_NAME", o.UserName), "email": o.Author},
+		"committer": map[string]interface{}{"name": o.UserName, "email": o.UserEmail},
+	}
+}
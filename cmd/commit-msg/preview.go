@@ -0,0 +1,25 @@
+package main
+
+import (
+	"fmt"
+	"github.com/davidalpert/go-githooks/pkg/message"
+	"io"
+	"strings"
+)
+
+// printPreview shows the message as git will record it once commit.cleanup has been
+// applied, which is not always what the editor showed when hooks edited the file
+func (o *CommitMsgOptions) printPreview(w io.Writer) {
+	if !o.PreviewMessage {
+		return
+	}
+	cleaned := message.Cleanup(o.CommitMessageBytes, o.CleanupMode, o.CommentChar)
+	if len(cleaned) == 0 {
+		fmt.Fprintf(w, "the message is empty once cleaned up (%s), so git will abort the commit\n", o.CleanupMode)
+		return
+	}
+	fmt.Fprintf(w, "git will record this message (cleanup: %s):\n", o.CleanupMode)
+	for _, l := range strings.Split(strings.TrimSuffix(string(cleaned), "\n"), "\n") {
+		fmt.Fprintf(w, "  | %s\n", l)
+	}
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"fmt"
+	"github.com/davidalpert/go-githooks/pkg/message"
+	"github.com/davidalpert/go-githooks/pkg/readonly"
+	"github.com/davidalpert/go-githooks/pkg/rules"
+	"regexp"
+	"strings"
+)
+
+const redactedText = "[REDACTED]"
+
+// RedactMode chooses what happens to sensitive content found in the message
+type RedactMode string
+
+const (
+	RedactBlock RedactMode = "block" // report it through the sensitive-content rule
+	RedactMask  RedactMode = "mask"  // replace it with [REDACTED] before the commit is made
+)
+
+func RedactModeFromString(s string) RedactMode {
+	if RedactMode(strings.ToLower(s)) == RedactMask {
+		return RedactMask
+	}
+	return RedactBlock
+}
+
+// sensitivePattern is one kind of content which should not end up in history
+type sensitivePattern struct {
+	Kind string
+	Re   *regexp.Regexp
+}
+
+// credentialPatterns recognize the credential formats most often pasted by accident
+var credentialPatterns = []sensitivePattern{
+	{Kind: "AWS access key", Re: regexp.MustCompile(`\b(AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{Kind: "GitHub token", Re: regexp.MustCompile(`\bgh[pousr]_[A-Za-z0-9]{36,}\b`)},
+	{Kind: "Slack token", Re: regexp.MustCompile(`\bxox[abprs]-[A-Za-z0-9-]{10,}`)},
+	{Kind: "private key", Re: regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----`)},
+	{Kind: "JSON web token", Re: regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{8,}\.[A-Za-z0-9_-]{8,}\.[A-Za-z0-9_-]+`)},
+	{Kind: "credential", Re: regexp.MustCompile(`(?i)\b(password|passwd|secret|token|api[_-]?key)\s*[:=]\s*\S+`)},
+}
+
+// sensitivePatterns combines the built-in credential patterns with the configured
+// terms (e.g. customer names), internal domains and patterns
+func (o *CommitMsgOptions) sensitivePatterns() ([]sensitivePattern, error) {
+	patterns := make([]sensitivePattern, 0)
+	if o.RedactCredentials {
+		patterns = append(patterns, credentialPatterns...)
+	}
+	for _, t := range o.RedactTerms {
+		if t = strings.TrimSpace(t); t != "" {
+			patterns = append(patterns, sensitivePattern{Kind: "sensitive term", Re: regexp.MustCompile(`(?i)\b` + regexp.QuoteMeta(t) + `\b`)})
+		}
+	}
+	for _, d := range o.RedactDomains {
+		if d = strings.Trim(strings.TrimSpace(d), "."); d != "" {
+			patterns = append(patterns, sensitivePattern{Kind: "internal hostname", Re: regexp.MustCompile(`(?i)\b([a-z0-9-]+\.)*` + regexp.QuoteMeta(d) + `\b`)})
+		}
+	}
+	for _, p := range o.RedactPatterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("redactPattern '%s' is not a valid regular expression: %v", p, err)
+		}
+		patterns = append(patterns, sensitivePattern{Kind: "sensitive pattern", Re: re})
+	}
+	return patterns, nil
+}
+
+// checkSensitiveContent finds content the security team does not want in commit
+// history; the message only names the kind of content so it is not echoed to logs
+func (o *CommitMsgOptions) checkSensitiveContent() []rules.Violation {
+	if o.Severities.For("sensitive-content", rules.Off) == rules.Off {
+		return nil
+	}
+
+	patterns, err := o.sensitivePatterns()
+	if err != nil {
+		return violation("sensitive-content", err.Error())
+	}
+
+	content, _ := message.SplitComments(o.CommitMessageBytes)
+	violations := make([]rules.Violation, 0)
+	for i, line := range strings.Split(string(content), "\n") {
+		for _, p := range patterns {
+			if !p.Re.MatchString(line) {
+				continue
+			}
+			v := violation("sensitive-content", fmt.Sprintf("line %d contains what looks like a %s", i+1, p.Kind))
+			v[0].Fix = func(msg []byte) []byte {
+				return redact(msg, patterns)
+			}
+			violations = append(violations, v...)
+			break
+		}
+	}
+	return violations
+}
+
+// redact masks every match in the message, leaving git comments alone since git
+// strips them anyway
+func redact(msg []byte, patterns []sensitivePattern) []byte {
+	content, comments := message.SplitComments(msg)
+	for _, p := range patterns {
+		content = p.Re.ReplaceAll(content, []byte(redactedText))
+	}
+	return append(append([]byte{}, content...), comments...)
+}
+
+// maskSensitiveContent redacts the message in place when redactMode is mask
+func (o *CommitMsgOptions) maskSensitiveContent() error {
+	if o.RedactMode != RedactMask || o.Severities.For("sensitive-content", rules.Off) == rules.Off {
+		return nil
+	}
+
+	patterns, err := o.sensitivePatterns()
+	if err != nil || len(patterns) == 0 {
+		return err
+	}
+
+	masked := redact(o.CommitMessageBytes, patterns)
+	if string(masked) == string(o.CommitMessageBytes) {
+		return nil
+	}
+	if readonly.Enabled() {
+		readonly.Changes("commit-msg", "masked sensitive content", o.CommitMessageBytes, masked)
+		return nil
+	}
+	fmt.Printf("masked sensitive content in the commit message with %s\n", redactedText)
+	o.CommitMessageBytes = masked
+	return o.writeCommitMessage()
+}
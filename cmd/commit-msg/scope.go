@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"github.com/davidalpert/go-githooks/pkg/rules"
+	"github.com/davidalpert/go-githooks/pkg/staged"
+	"strings"
+)
+
+// checkConventionalScope expects the scope in a 'type(scope): description' subject
+// to be one of the scopes of the staged paths (see ScopeMap); nothing is checked when
+// the subject has no scope or nothing is staged, e.g. when verifying past commits
+func (o *CommitMsgOptions) checkConventionalScope() []rules.Violation {
+	if o.Severities.For("conventional-scope", rules.Off) == rules.Off {
+		return nil
+	}
+
+	m := conventionalHeaderRe.FindStringSubmatch(o.subjectWithoutPrefix())
+	if m == nil || m[2] == "" {
+		return nil
+	}
+	files, err := staged.Files(o.Repo)
+	if err != nil {
+		return violation("conventional-scope", fmt.Sprintf("could not read the staged files: %v", err))
+	}
+	inferred := o.ScopeMap.Scopes(files)
+	if len(inferred) == 0 {
+		return nil
+	}
+
+	allowed := map[string]bool{}
+	for _, s := range inferred {
+		allowed[s] = true
+	}
+	for _, s := range strings.Split(strings.Trim(m[2], "()"), ",") {
+		if s = strings.TrimSpace(s); !allowed[s] {
+			v := violation("conventional-scope", fmt.Sprintf("scope '%s' does not match the staged paths, which are in: %s", s, strings.Join(inferred, ", ")))
+			if len(inferred) == 1 {
+				v[0].Fix = replaceScope(m[1]+m[2], m[1]+"("+inferred[0]+")")
+			}
+			return v
+		}
+	}
+	return nil
+}
+
+func replaceScope(found, scoped string) func([]byte) []byte {
+	return func(content []byte) []byte {
+		return []byte(strings.Replace(string(content), found, scoped, 1))
+	}
+}
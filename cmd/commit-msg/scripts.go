@@ -0,0 +1,31 @@
+package main
+
+import (
+	"bytes"
+	"github.com/davidalpert/go-githooks/pkg/scripts"
+)
+
+// runScripts passes the message through the repo's .githooks/commit-msg.d scripts,
+// if the user has enabled them; a script rejects the message by exiting non-zero
+func (o *CommitMsgOptions) runScripts() error {
+	w, err := o.Repo.Worktree()
+	if err != nil {
+		return nil
+	}
+	root := w.Filesystem.Root()
+
+	found, err := scripts.Discover(root, "commit-msg")
+	if err != nil || len(found) == 0 {
+		return err
+	}
+
+	msg, err := scripts.Pipe(root, "commit-msg", found, o.CommitMessageBytes, o.CommitMessageFile)
+	if err != nil {
+		return err
+	}
+	if bytes.Equal(msg, o.CommitMessageBytes) {
+		return nil
+	}
+	o.CommitMessageBytes = msg
+	return o.writeCommitMessage()
+}
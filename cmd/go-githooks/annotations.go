@@ -0,0 +1,121 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/davidalpert/go-githooks/pkg/exitcode"
+	"github.com/davidalpert/go-githooks/pkg/gitconfig"
+	"github.com/davidalpert/go-githooks/pkg/presets"
+	"github.com/davidalpert/go-githooks/pkg/push"
+	"github.com/go-git/go-git/v5"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// AnnotationReport is the annotation trailers of one commit, for release and risk
+// tooling; a key given more than once has its values joined with ", "
+type AnnotationReport struct {
+	Commit      string            `json:"commit"`
+	Subject     string            `json:"subject"`
+	Annotations map[string]string `json:"annotations"`
+}
+
+func runAnnotations(args []string) error {
+	fs := flag.NewFlagSet("annotations", flag.ContinueOnError)
+	format := fs.String("format", "json", "json (one object per commit) or csv (one row per annotation)")
+	keys := fs.String("key", "", "comma-separated trailer keys to extract (default: the keys of prepare-commit-message.annotations, or every X- trailer)")
+	limit := fs.Int("limit", push.DefaultLimit, "the most commits to read")
+	if err := fs.Parse(args); err != nil {
+		return exitcode.Wrap(exitcode.Usage, err)
+	}
+	if fs.NArg() != 1 || (*format != "json" && *format != "csv") {
+		return exitcode.Wrap(exitcode.Usage, fmt.Errorf("expected: annotations [--format json|csv] [--key <keys>] <rev-range>"))
+	}
+
+	repo, err := git.PlainOpenWithOptions(".", &git.PlainOpenOptions{DetectDotGit: true})
+	if err != nil {
+		return exitcode.Wrap(exitcode.Usage, err)
+	}
+	wanted := splitKeys(*keys)
+	if len(wanted) == 0 {
+		if wanted, err = annotationKeys(repo); err != nil {
+			return exitcode.Wrap(exitcode.Config, err)
+		}
+	}
+
+	reports, err := annotationReports(repo, fs.Arg(0), *limit, wanted)
+	if err != nil {
+		return exitcode.Wrap(exitcode.Usage, err)
+	}
+	if *format == "csv" {
+		return writeAnnotationsCSV(os.Stdout, reports)
+	}
+	e := json.NewEncoder(os.Stdout)
+	e.SetIndent("", "  ")
+	return e.Encode(reports)
+}
+
+// annotationKeys are the lower-cased keys the repo's prepare-commit-message.annotations
+// add, or none when it has none
+func annotationKeys(repo *git.Repository) ([]string, error) {
+	keys := make([]string, 0)
+	cfg, err := gitconfig.Load(repo)
+	if err != nil {
+		return keys, nil
+	}
+	if err := presets.Apply(cfg); err != nil {
+		return nil, err
+	}
+	for _, a := range gitconfig.GetSlice(cfg, "go-githooks", "prepare-commit-message", "annotations", nil) {
+		if k := strings.ToLower(strings.TrimSpace(strings.SplitN(a, ":", 2)[0])); k != "" && !stringInSlice(keys, k) {
+			keys = append(keys, k)
+		}
+	}
+	return keys, nil
+}
+
+// annotationReports extracts the trailers with the given keys from the commits in
+// revRange, or every X- trailer when keys is empty
+func annotationReports(repo *git.Repository, revRange string, limit int, keys []string) ([]AnnotationReport, error) {
+	trailers, err := trailerReports(repo, revRange, limit, keys, nil)
+	if err != nil {
+		return nil, err
+	}
+	reports := make([]AnnotationReport, 0, len(trailers))
+	for _, t := range trailers {
+		r := AnnotationReport{Commit: t.Commit, Subject: t.Subject, Annotations: map[string]string{}}
+		for _, tr := range t.Trailers {
+			if len(keys) == 0 && !strings.HasPrefix(strings.ToLower(tr.Key), "x-") {
+				continue
+			}
+			if v, ok := r.Annotations[tr.Key]; ok {
+				r.Annotations[tr.Key] = v + ", " + tr.Value
+				continue
+			}
+			r.Annotations[tr.Key] = tr.Value
+		}
+		reports = append(reports, r)
+	}
+	return reports, nil
+}
+
+func writeAnnotationsCSV(w io.Writer, reports []AnnotationReport) error {
+	out := csv.NewWriter(w)
+	_ = out.Write([]string{"commit", "subject", "key", "value"})
+	for _, r := range reports {
+		keys := make([]string, 0, len(r.Annotations))
+		for k := range r.Annotations {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			_ = out.Write([]string{r.Commit, r.Subject, k, r.Annotations[k]})
+		}
+	}
+	out.Flush()
+	return out.Error()
+}
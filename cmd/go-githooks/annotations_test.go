@@ -0,0 +1,49 @@
+package main
+
+import (
+	"bytes"
+	"github.com/go-git/go-billy/v5/memfs"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/storage/memory"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAnnotationReports(t *testing.T) {
+	r, _ := git.Init(memory.NewStorage(), memfs.New())
+	w, _ := r.Worktree()
+	for _, msg := range []string{
+		"[FEAT-1] add login\n\nX-Change-Type: feat\nX-Risk: low\n",
+		"[FEAT-2] migrate users\n\nX-Risk: high\nRefs: FEAT-1\n",
+	} {
+		if _, err := w.Commit(msg, &git.CommitOptions{Author: &object.Signature{Name: "Mal Reynolds", Email: "mal@serenity.com", When: time.Now()}}); err != nil {
+			t.Fatalf("committing: %v", err)
+		}
+	}
+
+	reports, err := annotationReports(r, "HEAD", 100, nil)
+	assert.NoError(t, err)
+	if assert.Len(t, reports, 2) {
+		assert.Equal(t, map[string]string{"X-Risk": "high"}, reports[0].Annotations)
+		assert.Equal(t, map[string]string{"X-Change-Type": "feat", "X-Risk": "low"}, reports[1].Annotations)
+	}
+
+	reports, err = annotationReports(r, "HEAD", 100, splitKeys("refs"))
+	assert.NoError(t, err)
+	if assert.Len(t, reports, 2) {
+		assert.Equal(t, map[string]string{"Refs": "FEAT-1"}, reports[0].Annotations)
+		assert.Empty(t, reports[1].Annotations)
+	}
+
+	var out bytes.Buffer
+	reports, _ = annotationReports(r, "HEAD", 100, nil)
+	assert.NoError(t, writeAnnotationsCSV(&out, reports[1:]))
+	assert.Equal(t, []string{
+		"commit,subject,key,value",
+		reports[1].Commit + ",[FEAT-1] add login,X-Change-Type,feat",
+		reports[1].Commit + ",[FEAT-1] add login,X-Risk,low",
+	}, strings.Split(strings.TrimSpace(out.String()), "\n"))
+}
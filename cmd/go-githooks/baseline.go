@@ -0,0 +1,68 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"github.com/davidalpert/go-githooks/pkg/exitcode"
+	"github.com/davidalpert/go-githooks/pkg/rules"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+)
+
+// runBaseline runs one of the repo's installed hooks, pre-commit unless another is
+// named, adding the violations it reports to the repo's baseline so that they stop
+// failing it, e.g. when adopting a rule in a legacy repo
+func runBaseline(args []string) error {
+	hook := "pre-commit"
+	if len(args) > 0 {
+		hook, args = args[0], args[1:]
+	}
+	repo, err := gitOutput(".", "rev-parse", "--show-toplevel")
+	if err != nil {
+		return exitcode.Wrap(exitcode.Usage, err)
+	}
+	return recordBaseline(repo, hook, args, os.Stdin, os.Stdout)
+}
+
+func recordBaseline(repo, hook string, args []string, stdin io.Reader, out io.Writer) error {
+	path, err := hookPath(repo, hook)
+	if err != nil {
+		return exitcode.Wrap(exitcode.Usage, err)
+	}
+	file := filepath.Join(repo, rules.BaselineFile)
+	before, err := rules.LoadBaseline(file)
+	if err != nil {
+		return exitcode.Wrap(exitcode.Config, err)
+	}
+
+	cmd := exec.Command(path, args...)
+	cmd.Dir = repo
+	cmd.Env = append(os.Environ(), rules.RecordEnv+"="+file)
+	cmd.Stdin = stdin
+	cmd.Stdout = out
+	cmd.Stderr = out
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			return exitcode.Wrap(exitcode.Internal, fmt.Errorf("could not run %s: %v", path, err))
+		}
+		// the violations being recorded fail the hook this once
+		if code := exitcode.Code(exitErr.ExitCode()); code != exitcode.Violation {
+			return exitcode.Wrap(code, fmt.Errorf("%s failed", hook))
+		}
+	}
+
+	after, err := rules.LoadBaseline(file)
+	if err != nil {
+		return exitcode.Wrap(exitcode.Config, err)
+	}
+	added := len(after.Violations) - len(before.Violations)
+	if added == 0 {
+		fmt.Fprintf(out, "%s reported nothing new for %s\n", hook, rules.BaselineFile)
+		return nil
+	}
+	fmt.Fprintf(out, "recorded %d violations in %s (%d in all); commit it so they stop failing %s for everyone\n", added, rules.BaselineFile, len(after.Violations), hook)
+	return nil
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"bytes"
+	"github.com/davidalpert/go-githooks/pkg/rules"
+	"github.com/go-git/go-git/v5"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRecordBaseline(t *testing.T) {
+	root := t.TempDir()
+	if _, err := git.PlainInit(root, false); err != nil {
+		t.Fatalf("init: %v", err)
+	}
+	hooks := filepath.Join(root, ".git", "hooks")
+	_ = os.MkdirAll(hooks, 0755)
+	// stands in for a hook recording what it reports, as rules.Record does
+	legacy := rules.Violation{Rule: "todo-comment", Location: "main.go:12", Message: "TODO without a ticket"}
+	recorded := rules.NewBaseline([]rules.Violation{legacy})
+	data := filepath.Join(root, "recorded.json")
+	assert.NoError(t, recorded.Write(data))
+	_ = ioutil.WriteFile(filepath.Join(hooks, "pre-commit"), []byte("#!/bin/sh\necho 'error [todo-comment] main.go:12: TODO without a ticket'\ncp recorded.json \"$"+rules.RecordEnv+"\"\nexit 4\n"), 0755)
+	_ = ioutil.WriteFile(filepath.Join(hooks, "pre-push"), []byte("#!/bin/sh\nexit 3\n"), 0755)
+
+	var out bytes.Buffer
+	assert.NoError(t, recordBaseline(root, "pre-commit", nil, strings.NewReader(""), &out))
+	assert.Contains(t, out.String(), "recorded 1 violations in .githooks-baseline.json")
+	baseline, err := rules.LoadBaseline(filepath.Join(root, rules.BaselineFile))
+	assert.NoError(t, err)
+	assert.True(t, baseline.Contains(legacy))
+
+	out.Reset()
+	assert.NoError(t, recordBaseline(root, "pre-commit", nil, strings.NewReader(""), &out))
+	assert.Contains(t, out.String(), "nothing new")
+
+	assert.Error(t, recordBaseline(root, "pre-push", nil, strings.NewReader(""), &out), "the hook could not run its checks")
+	assert.Error(t, recordBaseline(root, "commit-msg", nil, strings.NewReader(""), &out), "not installed")
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"flag"
+	"fmt"
+	"github.com/davidalpert/go-githooks/pkg/crash"
+	"github.com/davidalpert/go-githooks/pkg/exitcode"
+	"github.com/davidalpert/go-githooks/pkg/fileio"
+	"github.com/davidalpert/go-githooks/pkg/guilog"
+	"github.com/davidalpert/go-githooks/pkg/secrets"
+	"github.com/go-git/go-git/v5"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// bugReportConfig matches the config keys which change how the hooks behave
+const bugReportConfig = `^(go-githooks\.|core\.hookspath|core\.commentchar|commit\.|init\.defaultbranch|gpg\.|mob\.)`
+
+// runBugReport gathers what an issue about the hooks needs into one archive to attach
+func runBugReport(args []string) error {
+	fs := flag.NewFlagSet("bugreport", flag.ContinueOnError)
+	out := fs.String("out", "", "the archive to write (default: go-githooks-bugreport-<time>.tar.gz)")
+	noLog := fs.Bool("no-log", false, "leave out the last run's log and crash report, which may quote commit messages")
+	if err := fs.Parse(args); err != nil {
+		return exitcode.Wrap(exitcode.Usage, err)
+	}
+	now := time.Now()
+	if *out == "" {
+		*out = fmt.Sprintf("go-githooks-bugreport-%s.tar.gz", now.Format("20060102-150405"))
+	}
+
+	files := bugReport(".", now)
+	if !*noLog {
+		if repo, err := git.PlainOpenWithOptions(".", &git.PlainOpenOptions{DetectDotGit: true}); err == nil {
+			for _, p := range []string{guilog.Path(repo), crash.Latest(crash.Dir(repo))} {
+				if p == "" {
+					continue
+				}
+				if data, err := fileio.ReadFile(p); err == nil {
+					files = append(files, reportFile{Name: filepath.Base(p), Data: data})
+				}
+			}
+		}
+	}
+
+	if err := writeArchive(*out, files, now); err != nil {
+		return exitcode.Wrap(exitcode.Internal, err)
+	}
+	names := make([]string, 0, len(files))
+	for _, f := range files {
+		names = append(names, f.Name)
+	}
+	fmt.Printf("wrote %s (%s)\n", *out, strings.Join(names, ", "))
+	fmt.Printf("secrets in config and the environment are redacted; check it holds nothing else you would not share,\nthen attach it to an issue at %s\n", crash.IssuesURL)
+	return nil
+}
+
+// reportFile is one file of the archive
+type reportFile struct {
+	Name string
+	Data []byte
+}
+
+// bugReport describes the environment the hooks run in from dir, their install state
+// and the config they read
+func bugReport(dir string, now time.Time) []reportFile {
+	var env strings.Builder
+	fmt.Fprintf(&env, "go-githooks: %s\n", Version)
+	fmt.Fprintf(&env, "go: %s %s/%s\n", runtime.Version(), runtime.GOOS, runtime.GOARCH)
+	fmt.Fprintf(&env, "time: %s\n", now.Format(time.RFC3339))
+	if v, err := gitOutput(dir, "version"); err == nil {
+		fmt.Fprintf(&env, "git: %s\n", v)
+	} else {
+		fmt.Fprintf(&env, "git: %v\n", err)
+	}
+	root, err := gitOutput(dir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		root = ""
+		fmt.Fprintf(&env, "repo: none\n")
+	} else {
+		fmt.Fprintf(&env, "repo: %s\n", root)
+	}
+	env.WriteString("\nenvironment:\n")
+	for _, kv := range crash.Environ(os.Environ()) {
+		if strings.HasPrefix(kv, "GIT_") || strings.HasPrefix(kv, "CI=") || strings.HasPrefix(kv, "TERM=") {
+			fmt.Fprintf(&env, "    %s\n", kv)
+		}
+	}
+
+	var hooks strings.Builder
+	if hooksDirPath, err := hooksDir(dir); err != nil {
+		fmt.Fprintf(&hooks, "%v\n", err)
+	} else {
+		fmt.Fprintf(&hooks, "hooks dir: %s\n", hooksDirPath)
+		for _, hook := range Hooks {
+			fmt.Fprintf(&hooks, "    %-18s %s\n", hook, hookState(hookFile(hooksDirPath, hook)))
+		}
+	}
+
+	config, _ := gitOutput(dir, "config", "--show-origin", "--get-regexp", bugReportConfig)
+	return []reportFile{
+		{Name: "environment.txt", Data: []byte(env.String())},
+		{Name: "hooks.txt", Data: []byte(hooks.String())},
+		{Name: "config.txt", Data: []byte(redactConfig(config))},
+	}
+}
+
+// hookState says whether path is installed, and by what; only go-githooks binaries
+// are run, to ask their version
+func hookState(path string) string {
+	info, err := os.Lstat(path)
+	if os.IsNotExist(err) {
+		return "not installed"
+	} else if err != nil {
+		return err.Error()
+	}
+	state := "installed"
+	target := path
+	if info.Mode()&os.ModeSymlink != 0 {
+		target, _ = filepath.EvalSymlinks(path)
+		state = "-> " + target
+	}
+	f, err := os.Open(target)
+	if err != nil {
+		return state + " (missing: " + err.Error() + ")"
+	}
+	magic := make([]byte, 2)
+	_, _ = f.Read(magic)
+	f.Close()
+	if string(magic) == "#!" {
+		return state + " (a script, not go-githooks)"
+	}
+	v, err := exec.Command(target, "version").Output()
+	if err != nil || !strings.HasPrefix(string(v), "version: ") {
+		return state + " (not go-githooks)"
+	}
+	return state + " (" + strings.TrimSpace(string(v)) + ")"
+}
+
+// redactConfig redacts the values of secrets in 'git config --show-origin
+// --get-regexp' output, keeping references such as env:JIRA_TOKEN
+func redactConfig(config string) string {
+	var b strings.Builder
+	for _, line := range strings.Split(strings.TrimSpace(config), "\n") {
+		if line == "" {
+			continue
+		}
+		origin, entry := "", line
+		if i := strings.Index(line, "\t"); i >= 0 {
+			origin, entry = line[:i], line[i+1:]
+		}
+		parts := strings.SplitN(entry, " ", 2)
+		if len(parts) == 2 && !strings.HasPrefix(parts[1], secrets.EnvScheme) && !strings.HasPrefix(parts[1], secrets.KeyringScheme) {
+			parts[1] = crash.Redact(parts[0], parts[1])
+		}
+		fmt.Fprintf(&b, "%s\t%s\n", origin, strings.Join(parts, " "))
+	}
+	return b.String()
+}
+
+// writeArchive writes files to a gzipped tarball at path
+func writeArchive(path string, files []reportFile, now time.Time) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("could not write '%s': %v", path, err)
+	}
+	defer f.Close()
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	for _, rf := range files {
+		if err := tw.WriteHeader(&tar.Header{Name: rf.Name, Mode: 0600, Size: int64(len(rf.Data)), ModTime: now}); err != nil {
+			return fmt.Errorf("could not write '%s': %v", path, err)
+		}
+		if _, err := tw.Write(rf.Data); err != nil {
+			return fmt.Errorf("could not write '%s': %v", path, err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return fmt.Errorf("could not write '%s': %v", path, err)
+	}
+	if err := gz.Close(); err != nil {
+		return fmt.Errorf("could not write '%s': %v", path, err)
+	}
+	return f.Close()
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/davidalpert/go-githooks/pkg/bypass"
+	"github.com/davidalpert/go-githooks/pkg/exitcode"
+	"github.com/davidalpert/go-githooks/pkg/gitconfig"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+func runBypass(args []string) error {
+	gitDir, err := gitOutput(".", "rev-parse", "--absolute-git-dir")
+	if err != nil {
+		return exitcode.Wrap(exitcode.Usage, err)
+	}
+	dir := filepath.Join(gitDir, "go-githooks")
+
+	if len(args) > 0 {
+		switch args[0] {
+		case "status":
+			return bypassStatus(os.Stdout, dir, time.Now())
+		case "revoke":
+			if err := bypass.Revoke(dir); err != nil {
+				return exitcode.Wrap(exitcode.Internal, err)
+			}
+			fmt.Println("the hooks enforce every rule again")
+			return nil
+		}
+	}
+
+	fs := flag.NewFlagSet("bypass", flag.ContinueOnError)
+	reason := fs.String("reason", "", "why the hooks are bypassed; every commit meanwhile records it in a Hook-Bypass trailer")
+	ttl := fs.Duration("ttl", time.Hour, "how long the bypass lasts")
+	if err := fs.Parse(args); err != nil {
+		return exitcode.Wrap(exitcode.Usage, err)
+	}
+
+	maxTTL := 24 * time.Hour
+	if cfg, err := loadConfig(); err == nil {
+		if m := gitconfig.GetString(cfg, "go-githooks", "bypass", "maxTTL", ""); m != "" {
+			if maxTTL, err = time.ParseDuration(m); err != nil {
+				return exitcode.Wrap(exitcode.Config, fmt.Errorf("could not parse bypass maxTTL '%s': %v", m, err))
+			}
+		}
+	}
+	if *ttl > maxTTL {
+		return exitcode.Wrap(exitcode.Usage, fmt.Errorf("a bypass lasts at most %s (go-githooks.bypass.maxTTL), not %s", maxTTL, *ttl))
+	}
+
+	user, _ := gitOutput(".", "config", "user.email")
+	t, err := bypass.Grant(dir, *reason, user, *ttl, time.Now())
+	if err != nil {
+		return exitcode.Wrap(exitcode.Usage, err)
+	}
+	fmt.Printf("the hooks report instead of blocking until %s; commits meanwhile get '%s: %s'\n", t.Expires.Local().Format(time.RFC1123), bypass.Trailer, t.Reason)
+	fmt.Printf("end it early with '%s bypass revoke'\n", progName())
+	return nil
+}
+
+func bypassStatus(w io.Writer, dir string, now time.Time) error {
+	t, err := bypass.Active(dir, now)
+	if err != nil {
+		return exitcode.Wrap(exitcode.Config, err)
+	}
+	if t == nil {
+		fmt.Fprintln(w, "no bypass is active")
+		return nil
+	}
+	fmt.Fprintf(w, "bypassed by %s until %s (%s left): %s\n", t.User, t.Expires.Local().Format(time.RFC1123), t.Expires.Sub(now).Round(time.Minute), t.Reason)
+	return nil
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/davidalpert/go-githooks/pkg/exitcode"
+	"github.com/davidalpert/go-githooks/pkg/fileio"
+	"github.com/davidalpert/go-githooks/pkg/push"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// CIContext is what ci learns about the pipeline it runs in from its environment
+type CIContext struct {
+	Platform string // github, gitlab, or "" elsewhere
+	Range    string // the commits the pipeline is for, e.g. origin/main..HEAD
+	Branch   string
+}
+
+func runCI(args []string) error {
+	fs := flag.NewFlagSet("ci", flag.ContinueOnError)
+	revRange := fs.String("range", "", "the commits to check (default: from the CI environment, else origin/<default branch>..HEAD)")
+	format := fs.String("format", "", "text, github or gitlab (default: from the CI environment)")
+	report := fs.String("report", "gl-code-quality-report.json", "where the gitlab format writes its code quality report")
+	limit := fs.Int("limit", push.DefaultLimit, "the most commits to check")
+	if err := fs.Parse(args); err != nil {
+		return exitcode.Wrap(exitcode.Usage, err)
+	}
+
+	ci, err := detectCI(os.Getenv)
+	if err != nil {
+		return exitcode.Wrap(exitcode.Usage, err)
+	}
+	if *revRange != "" {
+		ci.Range = *revRange
+	}
+	if *format == "" {
+		*format = ci.Platform
+	}
+	if *format == "" {
+		*format = "text"
+	}
+	if !stringInSlice([]string{"text", "github", "gitlab"}, *format) {
+		return exitcode.Wrap(exitcode.Usage, fmt.Errorf("unknown format '%s', expected text, github or gitlab", *format))
+	}
+
+	repo, err := git.PlainOpenWithOptions(".", &git.PlainOpenOptions{DetectDotGit: true})
+	if err != nil {
+		return exitcode.Wrap(exitcode.Usage, err)
+	}
+	w, err := repo.Worktree()
+	if err != nil {
+		return exitcode.Wrap(exitcode.Usage, err)
+	}
+	root := w.Filesystem.Root()
+
+	commits, err := revisionRange(repo, ci.Range, *limit)
+	if err != nil {
+		return exitcode.Wrap(exitcode.Usage, fmt.Errorf("%v; CI checkouts are often shallow, so fetch the full history (e.g. fetch-depth: 0, or GIT_DEPTH: 0)", err))
+	}
+	fmt.Fprintf(os.Stderr, "checking %d commits in %s\n", len(commits), ci.Range)
+
+	results := make([]VerifyResult, 0)
+	if hook, ok := ciHook(root, "commit-msg"); ok {
+		if results, err = verifyCommits(hook, root, commits, false); err != nil {
+			return err
+		}
+	} else {
+		return exitcode.Wrap(exitcode.Dependency, fmt.Errorf("no commit-msg hook is installed or on PATH"))
+	}
+	if hook, ok := ciHook(root, "pre-push"); ok && len(commits) > 0 {
+		r, err := prePushResult(repo, hook, root, ci)
+		if err != nil {
+			return err
+		}
+		results = append(results, r)
+	}
+
+	switch *format {
+	case "github":
+		writeVerifyGitHub(os.Stdout, results)
+		writeVerifyText(os.Stderr, results)
+	case "gitlab":
+		writeVerifyText(os.Stdout, results)
+		f, err := os.Create(*report)
+		if err != nil {
+			return exitcode.Wrap(exitcode.Internal, err)
+		}
+		err = writeVerifyGitLab(f, results)
+		f.Close()
+		if err != nil {
+			return exitcode.Wrap(exitcode.Internal, err)
+		}
+	default:
+		writeVerifyText(os.Stdout, results)
+	}
+
+	failed := 0
+	for _, r := range results {
+		if r.ExitCode != int(exitcode.OK) {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return exitcode.Wrap(exitcode.Violation, fmt.Errorf("%d of %d checks do not meet this repo's rules", failed, len(results)))
+	}
+	return nil
+}
+
+// detectCI reads the range of commits a GitHub Actions or GitLab CI pipeline is
+// for: a pull or merge request's commits, else those a push added to its branch
+func detectCI(getenv func(string) string) (CIContext, error) {
+	ci := CIContext{}
+	defaultBranch := "main"
+	switch {
+	case getenv("GITHUB_ACTIONS") == "true":
+		ci.Platform = "github"
+		ci.Branch = getenv("GITHUB_HEAD_REF")
+		if ci.Branch == "" {
+			ci.Branch = strings.TrimPrefix(getenv("GITHUB_REF"), "refs/heads/")
+		}
+		if base := getenv("GITHUB_BASE_REF"); base != "" {
+			ci.Range = "origin/" + base + "..HEAD"
+		} else if p := getenv("GITHUB_EVENT_PATH"); p != "" {
+			before, err := githubPushBefore(p)
+			if err != nil {
+				return ci, err
+			}
+			if before != "" {
+				ci.Range = before + "..HEAD"
+			}
+		}
+	case getenv("GITLAB_CI") == "true":
+		ci.Platform = "gitlab"
+		ci.Branch = getenv("CI_MERGE_REQUEST_SOURCE_BRANCH_NAME")
+		if ci.Branch == "" {
+			ci.Branch = getenv("CI_COMMIT_REF_NAME")
+		}
+		if d := getenv("CI_DEFAULT_BRANCH"); d != "" {
+			defaultBranch = d
+		}
+		if base := getenv("CI_MERGE_REQUEST_DIFF_BASE_SHA"); base != "" {
+			ci.Range = base + "..HEAD"
+		} else if before := getenv("CI_COMMIT_BEFORE_SHA"); before != "" && !plumbing.NewHash(before).IsZero() {
+			ci.Range = before + "..HEAD"
+		}
+	}
+	if ci.Range == "" {
+		// a new branch, or not in CI: everything not yet on the default branch
+		ci.Range = "origin/" + defaultBranch + "..HEAD"
+	}
+	return ci, nil
+}
+
+// githubPushBefore reads the commit a push event's branch pointed at before the
+// push, or "" for a new branch
+func githubPushBefore(eventPath string) (string, error) {
+	data, err := fileio.ReadFile(eventPath)
+	if err != nil {
+		return "", fmt.Errorf("could not read the GitHub event: %v", err)
+	}
+	var event struct {
+		Before string `json:"before"`
+	}
+	if err := json.Unmarshal(data, &event); err != nil {
+		return "", fmt.Errorf("could not parse the GitHub event: %v", err)
+	}
+	if event.Before == "" || plumbing.NewHash(event.Before).IsZero() {
+		return "", nil
+	}
+	return event.Before, nil
+}
+
+// ciHook finds a hook installed in the repo, else its binary on PATH as CI images
+// which install go-githooks have it
+func ciHook(root, hook string) (string, bool) {
+	if p, err := hookPath(root, hook); err == nil {
+		return p, true
+	}
+	if p, err := exec.LookPath(hook); err == nil {
+		return p, true
+	}
+	return "", false
+}
+
+// prePushResult runs the pre-push hook as if the range were being pushed, so its
+// rules over whole commits (LFS pointers, stacks, merges from main) apply in CI too
+func prePushResult(repo *git.Repository, hook, root string, ci CIContext) (VerifyResult, error) {
+	head, err := repo.ResolveRevision(plumbing.Revision("HEAD"))
+	if err != nil {
+		return VerifyResult{}, fmt.Errorf("could not resolve HEAD: %v", err)
+	}
+	base := plumbing.ZeroHash
+	if i := strings.Index(ci.Range, ".."); i > 0 {
+		if h, err := repo.ResolveRevision(plumbing.Revision(ci.Range[:i])); err == nil {
+			base = *h
+		}
+	}
+	branch := ci.Branch
+	if branch == "" {
+		branch = "HEAD"
+	}
+
+	stdin := fmt.Sprintf("HEAD %s refs/heads/%s %s\n", head, branch, base)
+	code, out, err := execHookInput(hook, root, stdin, []string{"origin", "ci"})
+	if err != nil {
+		return VerifyResult{}, err
+	}
+	if code != exitcode.OK && code != exitcode.Violation {
+		return VerifyResult{}, exitcode.Wrap(code, fmt.Errorf("pre-push could not check the commits: %s", strings.TrimSpace(out)))
+	}
+	return VerifyResult{
+		Commit:     head.String(),
+		Subject:    "(pre-push checks over " + ci.Range + ")",
+		ExitCode:   int(code),
+		Category:   code.Name(),
+		Violations: parseViolations(out),
+	}, nil
+}
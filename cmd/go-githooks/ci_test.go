@@ -0,0 +1,59 @@
+package main
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestDetectCI(t *testing.T) {
+	event := filepath.Join(t.TempDir(), "event.json")
+	_ = ioutil.WriteFile(event, []byte(`{"before":"3b18e512dba79e4c8300dd08aeb37f8e728b8dad"}`), 0644)
+	newBranch := filepath.Join(t.TempDir(), "event.json")
+	_ = ioutil.WriteFile(newBranch, []byte(`{"before":"0000000000000000000000000000000000000000"}`), 0644)
+
+	tests := []struct {
+		name string
+		env  map[string]string
+		want CIContext
+	}{
+		{
+			name: "not in CI",
+			env:  map[string]string{},
+			want: CIContext{Range: "origin/main..HEAD"},
+		},
+		{
+			name: "github pull request",
+			env:  map[string]string{"GITHUB_ACTIONS": "true", "GITHUB_BASE_REF": "develop", "GITHUB_HEAD_REF": "feature/x"},
+			want: CIContext{Platform: "github", Range: "origin/develop..HEAD", Branch: "feature/x"},
+		},
+		{
+			name: "github push",
+			env:  map[string]string{"GITHUB_ACTIONS": "true", "GITHUB_REF": "refs/heads/feature/x", "GITHUB_EVENT_PATH": event},
+			want: CIContext{Platform: "github", Range: "3b18e512dba79e4c8300dd08aeb37f8e728b8dad..HEAD", Branch: "feature/x"},
+		},
+		{
+			name: "github push of a new branch",
+			env:  map[string]string{"GITHUB_ACTIONS": "true", "GITHUB_REF": "refs/heads/feature/x", "GITHUB_EVENT_PATH": newBranch},
+			want: CIContext{Platform: "github", Range: "origin/main..HEAD", Branch: "feature/x"},
+		},
+		{
+			name: "gitlab merge request",
+			env:  map[string]string{"GITLAB_CI": "true", "CI_MERGE_REQUEST_DIFF_BASE_SHA": "abc123", "CI_MERGE_REQUEST_SOURCE_BRANCH_NAME": "feature/x"},
+			want: CIContext{Platform: "gitlab", Range: "abc123..HEAD", Branch: "feature/x"},
+		},
+		{
+			name: "gitlab push of a new branch",
+			env:  map[string]string{"GITLAB_CI": "true", "CI_COMMIT_BEFORE_SHA": "0000000000000000000000000000000000000000", "CI_COMMIT_REF_NAME": "feature/x", "CI_DEFAULT_BRANCH": "trunk"},
+			want: CIContext{Platform: "gitlab", Range: "origin/trunk..HEAD", Branch: "feature/x"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := detectCI(func(k string) string { return tt.env[k] })
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
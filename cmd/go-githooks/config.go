@@ -0,0 +1,21 @@
+package main
+
+import (
+	"github.com/davidalpert/go-githooks/pkg/gitconfig"
+	"github.com/davidalpert/go-githooks/pkg/presets"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+)
+
+// loadConfig reads the global config, merged with the config of the current repo when there is one
+func loadConfig() (*config.Config, error) {
+	repo, err := git.PlainOpenWithOptions(".", &git.PlainOpenOptions{DetectDotGit: true})
+	if err != nil {
+		return config.LoadConfig(config.GlobalScope)
+	}
+	cfg, err := gitconfig.Load(repo)
+	if err != nil {
+		return nil, err
+	}
+	return cfg, presets.Apply(cfg)
+}
@@ -0,0 +1,313 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/davidalpert/go-githooks/pkg/exitcode"
+	"github.com/davidalpert/go-githooks/pkg/prompt"
+	"github.com/davidalpert/go-githooks/pkg/settings"
+	"io"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+/*
+ * 'go-githooks config edit' browses every setting by section with its documentation,
+ * default and the value set at one scope, validates new values as they are typed, and
+ * shows the pending changes as a diff before writing any of them with git config.
+ */
+
+// configStore reads and writes the go-githooks settings at one scope
+type configStore interface {
+	// Values returns every go-githooks value, by lower-cased name as git reports it
+	Values() (map[string][]string, error)
+	Set(name string, values []string) error
+	Unset(name string) error
+}
+
+type gitConfigStore struct {
+	scope string // local, global or system
+}
+
+func (s gitConfigStore) Values() (map[string][]string, error) {
+	out, err := exec.Command("git", "config", "--"+s.scope, "--get-regexp", `^go-githooks\.`).Output()
+	if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 1 {
+		// nothing is set
+		return map[string][]string{}, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("could not read the %s config: %v", s.scope, err)
+	}
+	values := map[string][]string{}
+	for _, line := range strings.Split(strings.TrimRight(string(out), "\n"), "\n") {
+		kv := strings.SplitN(line, " ", 2)
+		if len(kv) == 2 {
+			values[kv[0]] = append(values[kv[0]], kv[1])
+		} else if kv[0] != "" {
+			values[kv[0]] = append(values[kv[0]], "")
+		}
+	}
+	return values, nil
+}
+
+func (s gitConfigStore) Set(name string, values []string) error {
+	if err := s.Unset(name); err != nil {
+		return err
+	}
+	for _, v := range values {
+		if _, err := gitOutput(".", "config", "--"+s.scope, "--add", name, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s gitConfigStore) Unset(name string) error {
+	err := exec.Command("git", "config", "--"+s.scope, "--unset-all", name).Run()
+	if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 5 {
+		// it was not set
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("could not unset %s in the %s config: %v", name, s.scope, err)
+	}
+	return nil
+}
+
+func runConfig(args []string) error {
+	if len(args) == 0 {
+		return exitcode.Wrap(exitcode.Usage, fmt.Errorf("expected 'edit'"))
+	}
+	switch args[0] {
+	case "edit":
+		return runConfigEdit(args[1:])
+	}
+	return exitcode.Wrap(exitcode.Usage, fmt.Errorf("unknown config command '%s'", args[0]))
+}
+
+func runConfigEdit(args []string) error {
+	fs := flag.NewFlagSet("config edit", flag.ContinueOnError)
+	scope := fs.String("scope", "local", "the config to edit: local, global or system")
+	if err := fs.Parse(args); err != nil {
+		return exitcode.Wrap(exitcode.Usage, err)
+	}
+	if *scope != "local" && *scope != "global" && *scope != "system" {
+		return exitcode.Wrap(exitcode.Usage, fmt.Errorf("unknown scope '%s', expected local, global or system", *scope))
+	}
+
+	p := prompt.Terminal()
+	if p == nil {
+		return exitcode.Wrap(exitcode.Usage, fmt.Errorf("config edit needs a terminal"))
+	}
+	defer p.Close()
+	return newConfigEditor(gitConfigStore{scope: *scope}, *scope, p).run()
+}
+
+type configEditor struct {
+	store   configStore
+	scope   string
+	p       *prompt.Prompter
+	current map[string][]string
+	// pending holds the new values by setting name; nil unsets
+	pending map[string][]string
+}
+
+func newConfigEditor(store configStore, scope string, p *prompt.Prompter) *configEditor {
+	return &configEditor{store: store, scope: scope, p: p, pending: map[string][]string{}}
+}
+
+func (e *configEditor) run() error {
+	var err error
+	if e.current, err = e.store.Values(); err != nil {
+		return exitcode.Wrap(exitcode.Config, err)
+	}
+
+	sections := settings.Sections()
+	for {
+		choices := make([]prompt.Choice, 0, len(sections)+3)
+		for i, s := range sections {
+			choices = append(choices, prompt.Choice{Key: strconv.Itoa(i + 1), Description: sectionTitle(s)})
+		}
+		choices = append(choices,
+			prompt.Choice{Key: "d", Description: fmt.Sprintf("show the %d pending changes", len(e.pending))},
+			prompt.Choice{Key: "w", Description: fmt.Sprintf("write the pending changes to the %s config", e.scope)},
+			prompt.Choice{Key: "q", Description: "quit"},
+		)
+
+		switch answer := e.p.Choose(fmt.Sprintf("\ngo-githooks settings (%s config):", e.scope), choices); answer {
+		case "d":
+			e.printDiff(e.p.Out)
+		case "w":
+			if e.write() {
+				return nil
+			}
+		case "q", "":
+			if answer == "" || len(e.pending) == 0 || e.p.Confirm(fmt.Sprintf("discard %d pending changes?", len(e.pending))) {
+				return nil
+			}
+		default:
+			n, _ := strconv.Atoi(answer)
+			e.editSection(sections[n-1])
+		}
+	}
+}
+
+func sectionTitle(section string) string {
+	if section == "" {
+		return "[go-githooks]"
+	}
+	return fmt.Sprintf("[go-githooks \"%s\"]", section)
+}
+
+func (e *configEditor) editSection(section string) {
+	inSection := make([]settings.Setting, 0)
+	for _, s := range settings.All() {
+		if s.Section == section {
+			inSection = append(inSection, s)
+		}
+	}
+
+	for {
+		choices := make([]prompt.Choice, 0, len(inSection)+1)
+		for i, s := range inSection {
+			choices = append(choices, prompt.Choice{Key: strconv.Itoa(i + 1), Description: fmt.Sprintf("%-28s %s", s.Key, e.describe(s))})
+		}
+		choices = append(choices, prompt.Choice{Key: "b", Description: "back"})
+
+		answer := e.p.Choose("\n"+sectionTitle(section), choices)
+		if answer == "b" || answer == "" {
+			return
+		}
+		n, _ := strconv.Atoi(answer)
+		e.editSetting(inSection[n-1])
+	}
+}
+
+// describe shows the value s will have once the pending changes are written
+func (e *configEditor) describe(s settings.Setting) string {
+	values, changed := e.pending[s.Name()]
+	if !changed {
+		values = e.current[strings.ToLower(s.Name())]
+	}
+	mark := ""
+	if changed {
+		mark = " *"
+	}
+	if len(values) == 0 {
+		if s.Default == "" {
+			return "(unset)" + mark
+		}
+		return fmt.Sprintf("(default: %s)", s.Default) + mark
+	}
+	return "= " + strings.Join(values, "; ") + mark
+}
+
+func (e *configEditor) editSetting(s settings.Setting) {
+	fmt.Fprintf(e.p.Out, "\n%s (%s)\n    %s\n", s.Name(), s.Type(), s.Doc)
+	if s.Default != "" {
+		fmt.Fprintf(e.p.Out, "    default: %s\n", s.Default)
+	}
+	fmt.Fprintf(e.p.Out, "    now: %s\n", e.describe(s))
+
+	if s.Kind == settings.Multi {
+		e.editMulti(s)
+		return
+	}
+	for {
+		v := e.p.Line("new value (empty keeps it, - unsets it):")
+		switch v {
+		case "":
+			return
+		case "-":
+			e.change(s, nil)
+			return
+		}
+		if err := s.Validate(v); err != nil {
+			fmt.Fprintf(e.p.Out, "%v\n", err)
+			continue
+		}
+		e.change(s, []string{v})
+		return
+	}
+}
+
+// editMulti reads one value per line for a key git repeats once per value
+func (e *configEditor) editMulti(s settings.Setting) {
+	fmt.Fprintf(e.p.Out, "enter one value per line and an empty line to finish (an empty first line keeps it, - unsets it)\n")
+	values := make([]string, 0)
+	for {
+		v := e.p.Line(fmt.Sprintf("value %d:", len(values)+1))
+		if v == "" {
+			break
+		}
+		if v == "-" && len(values) == 0 {
+			e.change(s, nil)
+			return
+		}
+		if err := s.Validate(v); err != nil {
+			fmt.Fprintf(e.p.Out, "%v\n", err)
+			continue
+		}
+		values = append(values, v)
+	}
+	if len(values) > 0 {
+		e.change(s, values)
+	}
+}
+
+// change records values as pending, or forgets a change back to the current values
+func (e *configEditor) change(s settings.Setting, values []string) {
+	if strings.Join(values, "\n") == strings.Join(e.current[strings.ToLower(s.Name())], "\n") {
+		delete(e.pending, s.Name())
+		return
+	}
+	e.pending[s.Name()] = values
+}
+
+func (e *configEditor) pendingNames() []string {
+	names := make([]string, 0, len(e.pending))
+	for name := range e.pending {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (e *configEditor) printDiff(w io.Writer) {
+	if len(e.pending) == 0 {
+		fmt.Fprintf(w, "no pending changes\n")
+		return
+	}
+	for _, name := range e.pendingNames() {
+		for _, v := range e.current[strings.ToLower(name)] {
+			fmt.Fprintf(w, "- %s = %s\n", name, v)
+		}
+		for _, v := range e.pending[name] {
+			fmt.Fprintf(w, "+ %s = %s\n", name, v)
+		}
+	}
+}
+
+// write shows the diff and writes it once confirmed; it reports whether the editor is done
+func (e *configEditor) write() bool {
+	e.printDiff(e.p.Out)
+	if len(e.pending) == 0 || !e.p.Confirm(fmt.Sprintf("write these changes to the %s config?", e.scope)) {
+		return false
+	}
+	for _, name := range e.pendingNames() {
+		var err error
+		if values := e.pending[name]; values == nil {
+			err = e.store.Unset(name)
+		} else {
+			err = e.store.Set(name, values)
+		}
+		if err != nil {
+			fmt.Fprintf(e.p.Out, "%v\n", err)
+			return false
+		}
+		e.current[strings.ToLower(name)] = e.pending[name]
+		delete(e.pending, name)
+	}
+	fmt.Fprintf(e.p.Out, "written\n")
+	return true
+}
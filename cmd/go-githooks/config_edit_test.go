@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/davidalpert/go-githooks/pkg/prompt"
+	"github.com/davidalpert/go-githooks/pkg/settings"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+type memoryConfigStore map[string][]string
+
+func (s memoryConfigStore) Values() (map[string][]string, error) {
+	values := map[string][]string{}
+	for k, v := range s {
+		values[strings.ToLower(k)] = v
+	}
+	return values, nil
+}
+
+func (s memoryConfigStore) Set(name string, values []string) error {
+	s[strings.ToLower(name)] = values
+	return nil
+}
+
+func (s memoryConfigStore) Unset(name string) error {
+	delete(s, strings.ToLower(name))
+	return nil
+}
+
+func sectionNumber(t *testing.T, section string) int {
+	for i, s := range settings.Sections() {
+		if s == section {
+			return i + 1
+		}
+	}
+	t.Fatalf("no section '%s'", section)
+	return 0
+}
+
+func keyNumber(t *testing.T, section, key string) int {
+	n := 0
+	for _, s := range settings.All() {
+		if s.Section != section {
+			continue
+		}
+		n++
+		if s.Key == key {
+			return n
+		}
+	}
+	t.Fatalf("no key '%s' in section '%s'", key, section)
+	return 0
+}
+
+func TestConfigEdit(t *testing.T) {
+	store := memoryConfigStore{
+		"go-githooks.pre-push.commitlimit":  {"1000"},
+		"go-githooks.pre-push.maxcommitage": {"720h"},
+		"go-githooks.identity.policy":       {"github.com/acme/*=*@acme.com"},
+	}
+	input := strings.Join([]string{
+		fmt.Sprint(sectionNumber(t, "pre-push")),
+		fmt.Sprint(keyNumber(t, "pre-push", "commitLimit")), "lots", "800", // re-asked until valid
+		fmt.Sprint(keyNumber(t, "pre-push", "maxCommitAge")), "-", // unset
+		"b",
+		fmt.Sprint(sectionNumber(t, "identity")),
+		fmt.Sprint(keyNumber(t, "identity", "policy")), "gitlab.com/*=*@acme.io", "",
+		"b",
+		"w", "y",
+	}, "\n") + "\n"
+	var out bytes.Buffer
+
+	err := newConfigEditor(store, "local", prompt.New(strings.NewReader(input), &out)).run()
+
+	assert.NoError(t, err)
+	assert.Contains(t, out.String(), "'lots' is not a valid int for go-githooks.pre-push.commitLimit")
+	assert.Contains(t, out.String(), "- go-githooks.pre-push.commitLimit = 1000\n+ go-githooks.pre-push.commitLimit = 800\n")
+	assert.Contains(t, out.String(), "- go-githooks.pre-push.maxCommitAge = 720h\n")
+	assert.Equal(t, memoryConfigStore{
+		"go-githooks.pre-push.commitlimit": {"800"},
+		"go-githooks.identity.policy":      {"gitlab.com/*=*@acme.io"},
+	}, store)
+}
+
+func TestConfigEditDiscards(t *testing.T) {
+	store := memoryConfigStore{}
+	input := fmt.Sprintf("%d\n2\ntrue\nb\nw\nn\nq\ny\n", sectionNumber(t, "notify"))
+	var out bytes.Buffer
+
+	assert.NoError(t, newConfigEditor(store, "global", prompt.New(strings.NewReader(input), &out)).run())
+	assert.Contains(t, out.String(), "+ go-githooks.notify.enabled = true")
+	assert.Empty(t, store)
+}
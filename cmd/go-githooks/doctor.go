@@ -0,0 +1,31 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+)
+
+// runDoctor reports the environment the hooks run in and runs each installed hook
+// in a throwaway repo, like 'install --verify'
+func runDoctor(args []string) error {
+	fmt.Printf("go-githooks %s (%s/%s)\n", Version, runtime.GOOS, runtime.GOARCH)
+	if v, err := gitOutput(".", "version"); err == nil {
+		fmt.Println(v)
+	} else {
+		fmt.Printf("git: %v\n", err)
+	}
+
+	repo, err := gitOutput(".", "rev-parse", "--show-toplevel")
+	if err != nil {
+		repo = ""
+		fmt.Println("not in a repo; checking the global hooks only")
+	} else {
+		fmt.Printf("repo: %s\n", repo)
+	}
+	if p, _ := gitOutput(".", "config", "core.hooksPath"); p != "" {
+		fmt.Printf("core.hooksPath: %s\n", p)
+	}
+	fmt.Println()
+	return verifyInstall(os.Stdout, repo)
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/davidalpert/go-githooks/pkg/exitcode"
+	"github.com/davidalpert/go-githooks/pkg/output"
+	"os"
+)
+
+func runExitCodes(args []string) error {
+	fs := flag.NewFlagSet("exitcodes", flag.ContinueOnError)
+	asJSON := fs.Bool("json", false, "print the codes as JSON for scripts and IDE integrations")
+	if err := fs.Parse(args); err != nil {
+		return exitcode.Wrap(exitcode.Usage, err)
+	}
+
+	if *asJSON {
+		e := json.NewEncoder(os.Stdout)
+		e.SetIndent("", "  ")
+		return e.Encode(exitcode.Categories)
+	}
+
+	fmt.Printf("exit codes used by every go-githooks hook and command:\n\n")
+	rows := make([][]string, 0, len(exitcode.Categories))
+	for _, c := range exitcode.Categories {
+		rows = append(rows, []string{fmt.Sprint(c.Code), c.Name, c.Description})
+	}
+	return output.Columns(os.Stdout, "  ", rows)
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"github.com/davidalpert/go-githooks/pkg/exitcode"
+	"github.com/davidalpert/go-githooks/pkg/gitconfig"
+	"github.com/davidalpert/go-githooks/pkg/rules"
+	"github.com/go-git/go-git/v5"
+	"io"
+	"os"
+	"strconv"
+)
+
+// runExplain describes a rule, with its severity in the current repo, or an exit code
+func runExplain(args []string) error {
+	if len(args) != 1 {
+		return exitcode.Wrap(exitcode.Usage, fmt.Errorf("expected a rule or an exit code, e.g. 'explain ticket-reference' or 'explain 5'"))
+	}
+	return explain(os.Stdout, args[0], repoSeverities())
+}
+
+func explain(w io.Writer, topic string, severities rules.Severities) error {
+	for _, c := range exitcode.Categories {
+		if topic == c.Name || topic == strconv.Itoa(int(c.Code)) {
+			fmt.Fprintf(w, "exit code %d (%s): %s\n", c.Code, c.Name, c.Description)
+			return nil
+		}
+	}
+
+	entries := rules.Lookup(topic)
+	if len(entries) == 0 {
+		return exitcode.Wrap(exitcode.Usage, fmt.Errorf("'%s' is not a rule or an exit code; see 'help' for the hooks and 'exitcodes'", topic))
+	}
+	for _, e := range entries {
+		fmt.Fprintf(w, "%s (%s): %s\n", e.Rule, e.Hook, e.Description)
+		fmt.Fprintf(w, "  severity: %s (default: %s)\n", severities.For(e.Rule, e.Default), e.Default)
+	}
+	fmt.Fprintf(w, "  configure with: git config go-githooks.rules.%s <error|warning|info|off>\n", topic)
+	return nil
+}
+
+// repoSeverities reads the rule severities of the repo in the working directory,
+// or none outside a repo
+func repoSeverities() rules.Severities {
+	repo, err := git.PlainOpenWithOptions(".", &git.PlainOpenOptions{DetectDotGit: true})
+	if err != nil {
+		return rules.Severities{}
+	}
+	cfg, err := gitconfig.Load(repo)
+	if err != nil {
+		return rules.Severities{}
+	}
+	severities, err := rules.SeveritiesFromConfig(cfg)
+	if err != nil {
+		return rules.Severities{}
+	}
+	return severities
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"bytes"
+	"github.com/davidalpert/go-githooks/pkg/exitcode"
+	"github.com/davidalpert/go-githooks/pkg/rules"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestExplain(t *testing.T) {
+	var out bytes.Buffer
+	assert.NoError(t, explain(&out, "ticket-reference", rules.Severities{"ticket-reference": rules.Warning}))
+	assert.Contains(t, out.String(), "ticket-reference (commit-msg):")
+	assert.Contains(t, out.String(), "severity: warning (default: off)")
+
+	out.Reset()
+	assert.NoError(t, explain(&out, "lfs-pointer", rules.Severities{}))
+	assert.Contains(t, out.String(), "lfs-pointer (pre-commit):")
+	assert.Contains(t, out.String(), "lfs-pointer (pre-push):")
+
+	out.Reset()
+	assert.NoError(t, explain(&out, "5", rules.Severities{}))
+	assert.Contains(t, out.String(), "exit code 5 (dependency)")
+
+	err := explain(&out, "no-such-rule", rules.Severities{})
+	assert.Equal(t, exitcode.Usage, exitcode.Of(err))
+}
+
+func TestCommitMsgSpec(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.Raw.Section("go-githooks").Subsection("rules").SetOption("ticket-reference", "error")
+	cfg.Raw.Section("go-githooks").Subsection("rules").SetOption("duplicate-subject", "off")
+	cfg.Raw.Section("go-githooks").Subsection("commit-message").SetOption("ticketPattern", "FEAT-[0-9]+")
+
+	spec, err := commitMsgSpec(cfg, false)
+	assert.NoError(t, err)
+	assert.Equal(t, "commit-msg", spec.Hook)
+	assert.Len(t, spec.Rules, 1, "rules which are off are left out")
+	assert.Equal(t, "ticket-reference", spec.Rules[0].Rule)
+	assert.Equal(t, "error", spec.Rules[0].Severity)
+	assert.Equal(t, "FEAT-[0-9]+", spec.Settings.TicketPattern)
+	assert.Equal(t, "[%s]", spec.Settings.PrefixTemplate)
+
+	var out bytes.Buffer
+	assert.NoError(t, exportRules(&out, spec))
+	assert.Contains(t, out.String(), `"rule": "ticket-reference"`)
+	assert.Contains(t, out.String(), `"severity": "error"`)
+
+	spec, err = commitMsgSpec(cfg, true)
+	assert.NoError(t, err)
+	assert.Greater(t, len(spec.Rules), 1)
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/davidalpert/go-githooks/pkg/exitcode"
+	"github.com/davidalpert/go-githooks/pkg/output"
+	"github.com/davidalpert/go-githooks/pkg/presets"
+	"os"
+	"strings"
+)
+
+func runInit(args []string) error {
+	fs := flag.NewFlagSet("init", flag.ContinueOnError)
+	preset := fs.String("preset", "", "comma-separated presets to enable: "+strings.Join(presets.Names(), ", "))
+	global := fs.Bool("global", false, "configure every repo (~/.gitconfig) instead of the current one")
+	if err := fs.Parse(args); err != nil {
+		return exitcode.Wrap(exitcode.Usage, err)
+	}
+
+	if *preset == "" {
+		printPresets()
+		return nil
+	}
+
+	for _, name := range strings.Split(*preset, ",") {
+		if _, err := presets.Get(name); err != nil {
+			return exitcode.Wrap(exitcode.Usage, err)
+		}
+	}
+
+	if err := gitConfig(*global, "go-githooks.preset", *preset); err != nil {
+		return err
+	}
+
+	fmt.Printf("enabled preset '%s'; override any of its options with git config go-githooks.<hook>.<option>\n", *preset)
+	return nil
+}
+
+func printPresets() {
+	fmt.Printf("available presets (go-githooks init --preset <name>[,<name>]):\n\n")
+	rows := make([][]string, 0)
+	for _, name := range presets.Names() {
+		p, _ := presets.Get(name)
+		rows = append(rows, []string{p.Name, p.Description})
+		for _, o := range p.Options {
+			rows = append(rows, []string{"", fmt.Sprintf("  go-githooks.%s.%s = %s", o.Subsection, o.Key, o.Value)})
+		}
+	}
+	_ = output.Columns(os.Stdout, "  ", rows)
+}
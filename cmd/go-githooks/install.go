@@ -0,0 +1,106 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/davidalpert/go-githooks/pkg/exitcode"
+	"github.com/davidalpert/go-githooks/pkg/gitconfig"
+	"github.com/davidalpert/go-githooks/pkg/remoteconfig"
+	"os"
+	"os/exec"
+	"time"
+)
+
+func runInstall(args []string) error {
+	fs := flag.NewFlagSet("install", flag.ContinueOnError)
+	configURL := fs.String("config-url", "", "fetch shared hook policy (TOML) from this URL")
+	publicKey := fs.String("public-key", "", "base64 ed25519 key the config must be signed with (<url>.sig)")
+	refresh := fs.Duration("refresh", remoteconfig.DefaultRefresh, "how long a fetched copy of the config is used")
+	global := fs.Bool("global", false, "configure every repo (~/.gitconfig) instead of the current one")
+	system := fs.Bool("system", false, "install the hooks for every repo and point core.hooksPath at them")
+	hooksDir := fs.String("hooks-dir", "", "where --system installs the hooks (default: a standard location)")
+	force := fs.Bool("force", false, "with --system, replace a core.hooksPath which is already set")
+	verify := fs.Bool("verify", false, "run each installed hook in a throwaway repo to check it works")
+	if err := fs.Parse(args); err != nil {
+		return exitcode.Wrap(exitcode.Usage, err)
+	}
+
+	if *system {
+		dir := *hooksDir
+		if dir == "" {
+			var err error
+			if dir, err = defaultHooksDir(); err != nil {
+				return exitcode.Wrap(exitcode.Internal, err)
+			}
+		}
+		if err := installSystem(dir, *force); err != nil {
+			return err
+		}
+		if *configURL == "" {
+			return verifyIfAsked(*verify)
+		}
+		// the policy applies to every repo along with the hooks
+		*global = true
+	}
+
+	if *configURL == "" {
+		if *verify {
+			return verifyIfAsked(true)
+		}
+		return exitcode.Wrap(exitcode.Usage, fmt.Errorf("expected --config-url <url>, --system or --verify"))
+	}
+
+	// fetch before saving anything so a bad url or signature is reported now
+	src := remoteconfig.Source{URL: *configURL, PublicKey: *publicKey, Refresh: *refresh, Policy: gitconfig.Policy()}
+	if _, err := src.Fetch(nil); err != nil {
+		return exitcode.Wrap(exitcode.Config, err)
+	}
+	if _, err := src.Load(nil); err != nil {
+		return exitcode.Wrap(exitcode.Config, err)
+	}
+
+	options := [][]string{
+		{"go-githooks.configUrl", *configURL},
+		{"go-githooks.configRefresh", refresh.String()},
+	}
+	if *publicKey != "" {
+		options = append(options, []string{"go-githooks.configPublicKey", *publicKey})
+	}
+	for _, o := range options {
+		if err := gitConfig(*global, o[0], o[1]); err != nil {
+			return err
+		}
+	}
+
+	fmt.Printf("installed config from %s; it is refreshed every %s\n", *configURL, refresh.Round(time.Second))
+	return verifyIfAsked(*verify)
+}
+
+// verifyIfAsked checks the hooks of the current repo, or only the global ones
+// when not run inside a repo
+func verifyIfAsked(verify bool) error {
+	if !verify {
+		return nil
+	}
+	repo, err := gitOutput(".", "rev-parse", "--show-toplevel")
+	if err != nil {
+		repo = ""
+	}
+	return verifyInstall(os.Stdout, repo)
+}
+
+func gitConfig(global bool, key, value string) error {
+	gitArgs := []string{"config"}
+	if global {
+		gitArgs = append(gitArgs, "--global")
+	}
+	gitArgs = append(gitArgs, key, value)
+
+	cmd := exec.Command("git", gitArgs...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return exitcode.Wrap(exitcode.Of(err), fmt.Errorf("could not set %s: %v", key, err))
+	}
+	return nil
+}
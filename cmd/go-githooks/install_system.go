@@ -0,0 +1,155 @@
+package main
+
+import (
+	"fmt"
+	"github.com/davidalpert/go-githooks/pkg/exitcode"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// Hooks are the hook binaries built alongside go-githooks
+var Hooks = []string{"prepare-commit-msg", "commit-msg", "pre-commit", "pre-push", "post-checkout", "post-rewrite", "post-commit"}
+
+// defaultHooksDir is where install --system puts the hooks: a shared location when
+// run as root (e.g. by a package's post-install step), else the user's config dir
+func defaultHooksDir() (string, error) {
+	if runtime.GOOS != "windows" && os.Geteuid() == 0 {
+		return "/usr/local/libexec/go-githooks/hooks", nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "go-githooks", "hooks"), nil
+}
+
+// findHookBinaries looks for each hook next to the go-githooks executable, as a
+// package installs them, then on PATH; builds from the Makefile carry a
+// -go-<os> suffix
+func findHookBinaries(exeDir string) (map[string]string, error) {
+	found := map[string]string{}
+	missing := make([]string, 0)
+	for _, hook := range Hooks {
+		candidates := []string{hook, hook + "-go-" + runtime.GOOS}
+		if runtime.GOOS == "windows" {
+			candidates = []string{hook + ".exe", hook + "-go-windows.exe"}
+		}
+		for _, c := range candidates {
+			if p := filepath.Join(exeDir, c); isFile(p) {
+				found[hook] = p
+				break
+			}
+		}
+		if _, ok := found[hook]; !ok {
+			if p, err := exec.LookPath(hook); err == nil {
+				found[hook] = p
+			} else {
+				missing = append(missing, hook)
+			}
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("could not find %s next to %s or on PATH", strings.Join(missing, ", "), exeDir)
+	}
+	return found, nil
+}
+
+func isFile(p string) bool {
+	info, err := os.Stat(p)
+	return err == nil && !info.IsDir()
+}
+
+// installHooks links each hook binary into dir under the name git runs it by, so
+// upgrading the package upgrades the hooks; Windows gets copies instead
+func installHooks(dir string, binaries map[string]string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("could not create %s: %v", dir, err)
+	}
+	for _, hook := range Hooks {
+		src, ok := binaries[hook]
+		if !ok {
+			continue
+		}
+		if err := linkHook(hookFile(dir, hook), src); err != nil {
+			return fmt.Errorf("could not install %s: %v", hook, err)
+		}
+	}
+	return nil
+}
+
+// hookFile is the file git runs for hook from the hooks directory dir
+func hookFile(dir, hook string) string {
+	if runtime.GOOS == "windows" {
+		return filepath.Join(dir, hook+".exe")
+	}
+	return filepath.Join(dir, hook)
+}
+
+// linkHook replaces dst with a link to the hook binary src, or a copy on Windows
+func linkHook(dst, src string) error {
+	if err := os.Remove(dst); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("could not replace %s: %v", dst, err)
+	}
+	if runtime.GOOS == "windows" {
+		return copyFile(src, dst)
+	}
+	return os.Symlink(src, dst)
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0755)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// installSystem installs the hooks into dir and points core.hooksPath at it, for
+// every repo of this user (or, as root, of every user)
+func installSystem(dir string, force bool) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return exitcode.Wrap(exitcode.Internal, err)
+	}
+	if resolved, err := filepath.EvalSymlinks(exe); err == nil {
+		exe = resolved
+	}
+	binaries, err := findHookBinaries(filepath.Dir(exe))
+	if err != nil {
+		return exitcode.Wrap(exitcode.Dependency, err)
+	}
+
+	scope := "--global"
+	if runtime.GOOS != "windows" && os.Geteuid() == 0 {
+		scope = "--system"
+	}
+	out, _ := exec.Command("git", "config", scope, "core.hooksPath").Output()
+	if current := strings.TrimSpace(string(out)); current != "" && current != dir && !force {
+		return exitcode.Wrap(exitcode.Config, fmt.Errorf("core.hooksPath is already %s; pass --force to replace it", current))
+	}
+
+	if err := installHooks(dir, binaries); err != nil {
+		return exitcode.Wrap(exitcode.Internal, err)
+	}
+	cmd := exec.Command("git", "config", scope, "core.hooksPath", dir)
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return exitcode.Wrap(exitcode.Of(err), fmt.Errorf("could not set core.hooksPath: %v", err))
+	}
+
+	fmt.Printf("installed %d hooks in %s and set core.hooksPath (%s)\n", len(binaries), dir, strings.TrimPrefix(scope, "--"))
+	return nil
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestInstallHooks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hooks are copied rather than linked on windows")
+	}
+	bin := t.TempDir()
+	for i, hook := range Hooks {
+		name := hook
+		if i%2 == 0 {
+			name += "-go-" + runtime.GOOS
+		}
+		if err := ioutil.WriteFile(filepath.Join(bin, name), []byte("#!/bin/sh\n"), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	binaries, err := findHookBinaries(bin)
+	if assert.NoError(t, err) {
+		assert.Equal(t, filepath.Join(bin, "prepare-commit-msg-go-"+runtime.GOOS), binaries["prepare-commit-msg"])
+		assert.Equal(t, filepath.Join(bin, "commit-msg"), binaries["commit-msg"])
+	}
+
+	dir := filepath.Join(t.TempDir(), "hooks")
+	assert.NoError(t, installHooks(dir, binaries))
+	// reinstalling replaces the existing links
+	assert.NoError(t, installHooks(dir, binaries))
+	for _, hook := range Hooks {
+		target, err := os.Readlink(filepath.Join(dir, hook))
+		assert.NoError(t, err)
+		assert.Equal(t, binaries[hook], target)
+	}
+
+	path := os.Getenv("PATH")
+	defer os.Setenv("PATH", path)
+	os.Setenv("PATH", t.TempDir())
+	_ = os.Remove(filepath.Join(bin, "pre-push"))
+	_, err = findHookBinaries(bin)
+	assert.EqualError(t, err, "could not find pre-push next to "+bin+" or on PATH")
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"fmt"
+	"github.com/davidalpert/go-githooks/pkg/exitcode"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// hookCheck is the outcome of running one installed hook against the scratch repo
+type hookCheck struct {
+	Hook    string
+	Code    exitcode.Code
+	Detail  string
+	Missing bool
+}
+
+// Failed is true for problems the user would otherwise hit mid-commit; a hook
+// reporting violations for the sample commit still works
+func (c hookCheck) Failed() bool {
+	return !c.Missing && c.Code != exitcode.OK && c.Code != exitcode.Violation
+}
+
+// verifyInstall runs every installed hook end to end in a throwaway repo which
+// shares repo's configuration, so a missing tool or a config which does not parse
+// shows up now rather than in the middle of a commit
+func verifyInstall(w io.Writer, repo string) error {
+	scratch, err := ioutil.TempDir("", "go-githooks-verify")
+	if err != nil {
+		return exitcode.Wrap(exitcode.Internal, err)
+	}
+	defer os.RemoveAll(scratch)
+
+	if err := prepareScratchRepo(scratch, repo); err != nil {
+		return exitcode.Wrap(exitcode.Internal, err)
+	}
+
+	// hooks installed in repo's .git/hooks are not in the scratch repo, whereas a
+	// global core.hooksPath applies to both
+	source := scratch
+	if repo != "" {
+		source = repo
+	}
+	dir, err := hooksDir(source)
+	if err != nil {
+		return exitcode.Wrap(exitcode.Usage, err)
+	}
+
+	checks := verifyHooks(dir, scratch)
+	worst := exitcode.OK
+	for _, c := range checks {
+		switch {
+		case c.Missing:
+			fmt.Fprintf(w, "  -    %-18s not installed\n", c.Hook)
+		case c.Failed():
+			fmt.Fprintf(w, "  FAIL %-18s %s: %s\n", c.Hook, c.Code.Name(), c.Detail)
+			if worst == exitcode.OK {
+				worst = c.Code
+			}
+		case c.Code == exitcode.Violation:
+			fmt.Fprintf(w, "  ok   %-18s (reported violations for the sample commit)\n", c.Hook)
+		default:
+			fmt.Fprintf(w, "  ok   %s\n", c.Hook)
+		}
+	}
+
+	if worst != exitcode.OK {
+		return exitcode.Wrap(worst, fmt.Errorf("some hooks could not run; see above"))
+	}
+	fmt.Fprintf(w, "hooks in %s ran cleanly\n", dir)
+	return nil
+}
+
+// prepareScratchRepo creates a repo in dir which includes repo's config, on a
+// feature branch with one file staged
+func prepareScratchRepo(dir, repo string) error {
+	steps := [][]string{{"init", "--quiet"}}
+	if repo != "" {
+		gitDir, err := gitOutput(repo, "rev-parse", "--absolute-git-dir")
+		if err != nil {
+			return err
+		}
+		steps = append(steps, []string{"config", "include.path", filepath.Join(gitDir, "config")})
+	}
+	steps = append(steps, []string{"checkout", "--quiet", "-b", "feature/VERIFY-1"})
+	for _, args := range steps {
+		if _, err := gitOutput(dir, args...); err != nil {
+			return err
+		}
+	}
+
+	// the sample commit needs an identity even on a machine without one
+	for key, value := range map[string]string{"user.name": "go-githooks verify", "user.email": "verify@go-githooks.invalid"} {
+		if v, _ := gitOutput(dir, "config", key); v == "" {
+			if _, err := gitOutput(dir, "config", key, value); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(dir, "verify.txt"), []byte("go-githooks install --verify\n"), 0644); err != nil {
+		return err
+	}
+	_, err := gitOutput(dir, "add", "verify.txt")
+	return err
+}
+
+// verifyHooks runs the hooks in dir in the order a commit and a push would
+func verifyHooks(dir, scratch string) []hookCheck {
+	msgFile := filepath.Join(scratch, ".git", "COMMIT_EDITMSG")
+	checks := make([]hookCheck, 0)
+	run := func(hook string, args ...string) {
+		path := filepath.Join(dir, hook)
+		if _, err := os.Stat(path); err != nil {
+			checks = append(checks, hookCheck{Hook: hook, Missing: true})
+			return
+		}
+		code, out, err := execHook(path, scratch, args)
+		c := hookCheck{Hook: hook, Code: code, Detail: lastLine(out)}
+		if err != nil {
+			c.Detail = err.Error()
+		}
+		checks = append(checks, c)
+	}
+
+	_ = ioutil.WriteFile(msgFile, []byte("verify the go-githooks install\n"), 0644)
+	run("pre-commit")
+	run("prepare-commit-msg", msgFile, "message")
+	run("commit-msg", msgFile)
+
+	// the hooks which run after a commit need one to exist
+	if _, err := gitOutput(scratch, "commit", "--quiet", "--no-verify", "-F", msgFile); err != nil {
+		checks = append(checks, hookCheck{Hook: "commit", Code: exitcode.Internal, Detail: err.Error()})
+		return checks
+	}
+	run("post-commit")
+	head, _ := gitOutput(scratch, "rev-parse", "HEAD")
+	run("post-checkout", head, head, "1")
+	run("post-rewrite", "amend")
+	run("pre-push", "origin", scratch)
+	return checks
+}
+
+func gitOutput(dir string, args ...string) (string, error) {
+	return gitOutputEnv(dir, nil, args...)
+}
+
+// gitOutputEnv is gitOutput with env added to the environment
+func gitOutputEnv(dir string, env []string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), env...)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("git %s: %v: %s", strings.Join(args, " "), err, strings.TrimSpace(string(out)))
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// lastLine is usually where a hook says why it failed
+func lastLine(out string) string {
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"bytes"
+	"github.com/davidalpert/go-githooks/pkg/exitcode"
+	"github.com/go-git/go-git/v5"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestVerifyInstall(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake hooks are shell scripts")
+	}
+	root := t.TempDir()
+	if _, err := git.PlainInit(root, false); err != nil {
+		t.Fatalf("init: %v", err)
+	}
+	_ = os.MkdirAll(filepath.Join(root, ".git", "hooks"), 0755)
+	hook := func(name, script string) {
+		path := filepath.Join(root, ".git", "hooks", name)
+		if err := ioutil.WriteFile(path, []byte("#!/bin/sh\n"+script+"\n"), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	hook("pre-commit", "exit 0")
+	hook("commit-msg", "echo 'error [no-wip] message: the subject says WIP'; exit 4")
+	hook("post-checkout", "git rev-parse --abbrev-ref HEAD | grep -q feature/VERIFY-1")
+
+	var out bytes.Buffer
+	assert.NoError(t, verifyInstall(&out, root))
+	assert.Contains(t, out.String(), "  ok   pre-commit\n")
+	assert.Contains(t, out.String(), "  -    prepare-commit-msg not installed\n")
+	assert.Contains(t, out.String(), "  ok   commit-msg         (reported violations for the sample commit)\n")
+	assert.Contains(t, out.String(), "  ok   post-checkout\n")
+
+	hook("pre-push", "echo 'could not run gitleaks: not installed'; exit 5")
+	out.Reset()
+	err := verifyInstall(&out, root)
+	assert.Equal(t, exitcode.Dependency, exitcode.Of(err))
+	assert.Contains(t, out.String(), "  FAIL pre-push           dependency: could not run gitleaks: not installed\n")
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"fmt"
+	"github.com/davidalpert/go-githooks/pkg/exitcode"
+	"io/ioutil"
+	"os"
+)
+
+// runLint runs the repo's commit-msg hook over a message from a file or stdin, as
+// git would at the end of a commit, without making one
+func runLint(args []string) error {
+	if len(args) > 1 {
+		return exitcode.Wrap(exitcode.Usage, fmt.Errorf("expected at most one message file ('-' for stdin)"))
+	}
+
+	var msg []byte
+	var err error
+	if len(args) == 0 || args[0] == "-" {
+		msg, err = ioutil.ReadAll(os.Stdin)
+	} else {
+		msg, err = ioutil.ReadFile(args[0])
+	}
+	if err != nil {
+		return exitcode.Wrap(exitcode.Usage, err)
+	}
+
+	repo, err := gitOutput(".", "rev-parse", "--show-toplevel")
+	if err != nil {
+		return exitcode.Wrap(exitcode.Usage, err)
+	}
+	resp, err := runHook("commit-msg", ServeRequest{Repo: repo, Message: string(msg)})
+	if err != nil {
+		return exitcode.Wrap(exitcode.Usage, err)
+	}
+
+	fmt.Print(resp.Output)
+	if !resp.OK {
+		return exitcode.Wrap(exitcode.Code(resp.ExitCode), fmt.Errorf("the message would not pass commit-msg"))
+	}
+	return nil
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"fmt"
+	"github.com/davidalpert/go-githooks/pkg/exitcode"
+	"github.com/davidalpert/go-githooks/pkg/msgbackup"
+	"github.com/davidalpert/go-githooks/pkg/policysig"
+	"github.com/davidalpert/go-githooks/pkg/updatecheck"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var (
+	Version = "n/a"
+)
+
+/*
+ * go-githooks is the management command for the hooks in this repo; it holds the
+ * commands which are run by hand (or by scripts) rather than invoked by git.
+ *
+ * It is also shipped as git-githooks so that, on the PATH, git runs it as an
+ * external command: 'git githooks doctor'.
+ */
+func main() {
+	args := os.Args[1:]
+	if len(args) > 0 && args[0] == "--strict-policy" {
+		// the env var carries it on to the hooks any command runs
+		os.Setenv(policysig.StrictEnv, "1")
+		args = args[1:]
+	}
+	if len(args) == 0 {
+		printHelp()
+		return
+	}
+
+	var err error
+	switch args[0] {
+	case "version":
+		printVersion()
+	case "help":
+		printHelp()
+	case "annotations":
+		err = runAnnotations(args[1:])
+	case "baseline":
+		err = runBaseline(args[1:])
+	case "bugreport":
+		err = runBugReport(args[1:])
+	case "bypass":
+		err = runBypass(args[1:])
+	case "ci":
+		err = runCI(args[1:])
+	case "config":
+		err = runConfig(args[1:])
+	case "doctor":
+		err = runDoctor(args[1:])
+	case "exitcodes":
+		err = runExitCodes(args[1:])
+	case "explain":
+		err = runExplain(args[1:])
+	case "init":
+		err = runInit(args[1:])
+	case "install":
+		err = runInstall(args[1:])
+	case "lint":
+		err = runLint(args[1:])
+	case "msg":
+		err = runMsg(args[1:])
+	case "replay":
+		err = runReplay(args[1:])
+	case "rules":
+		err = runRules(args[1:])
+	case "run":
+		err = runRun(args[1:])
+	case "serve":
+		err = runServe(args[1:])
+	case "secret":
+		err = runSecret(args[1:])
+	case "sync":
+		err = runSync(args[1:])
+	case "tag":
+		err = runTag(args[1:])
+	case "telemetry":
+		err = runTelemetry(args[1:])
+	case "templates":
+		err = runTemplates(args[1:])
+	case "trailers":
+		err = runTrailers(args[1:])
+	case "vcs":
+		err = runVCS(args[1:])
+	case "verify":
+		err = runVerify(args[1:])
+	default:
+		err = exitcode.Wrap(exitcode.Usage, fmt.Errorf("unknown command '%s'", args[0]))
+	}
+	if err == nil {
+		updatecheck.ShowNotice(Version)
+	}
+	checkError(args[0], err)
+}
+
+func printVersion() {
+	fmt.Printf("version: %s\n", Version)
+}
+
+// progName is how the command was invoked: 'git githooks' when run by git as
+// git-githooks, otherwise go-githooks
+func progName() string {
+	if strings.HasPrefix(filepath.Base(os.Args[0]), "git-githooks") {
+		return "git githooks"
+	}
+	return "go-githooks"
+}
+
+func printHelp() {
+	fmt.Printf("go-githooks: %s\n", Version)
+	fmt.Printf(`
+usage: %s [--strict-policy] <command> [args]
+
+commands:
+    annotations [--format json|csv] [--key <keys>] <rev-range>
+                                            extract the machine-readable trailers prepare-commit-message.annotations adds,
+                                            e.g. X-Risk, from commits for release and risk tooling, e.g. v1.4.0..HEAD
+    baseline [<hook> [args]]                run an installed hook (default: pre-commit) and add the violations it reports
+                                            to .githooks-baseline.json, so that only new ones fail it from then on
+    bugreport [--out <file>] [--no-log]     gather the version, OS, git version, install state of each hook, config (with
+                                            secrets redacted), and the last run's log and crash report into one archive
+                                            to attach to an issue
+    bypass --reason <why> [--ttl 1h]        for an emergency: until the ttl passes, hooks report what they would block and
+                                            let it through, and commits get a 'Hook-Bypass: <why>' trailer; the ttl is at
+                                            most go-githooks.bypass.maxTTL (default: 24h)
+    bypass status|revoke                    show the active bypass, or end it early
+    ci [--format text|github|gitlab] [--range <rev-range>] [--report <file>]
+                                            in GitHub Actions or GitLab CI, run the commit-msg and pre-push rules over
+                                            the pull/merge request's or push's commits, as annotations or a code quality
+                                            report (default: gl-code-quality-report.json); needs the full history
+    config edit [--scope local|global|system]
+                                            browse and edit every setting with its documentation, validating values as
+                                            they are typed, and write them to git config after showing the changes
+    doctor                                  show the git version and hooks dir, then run each installed hook
+                                            in a throwaway repo (as 'install --verify')
+    exitcodes [--json]                      describe the exit codes every hook and command uses
+    explain <rule|exit code>                describe a rule and its severity in this repo, or an exit code
+    init [--preset <name>] [--global]       enable a bundle of options (lists presets when none given)
+    install --config-url <url> [--public-key <key>] [--refresh <duration>] [--global]
+                                            use hook policy published at a url, refreshed periodically
+    install --system [--hooks-dir <dir>] [--force] [--config-url <url>]
+                                            install the hooks for every repo, setting core.hooksPath globally
+    install --verify                        (alone or with the above) run each installed hook in a throwaway
+                                            repo to report missing tools or broken config before a commit does
+    lint [<file>|-]                         run the repo's commit-msg hook over a message from a file or stdin
+    msg prepare [--stdin|--clipboard|<file>] [--source message] [--lint]
+                                            run the repo's prepare-commit-msg hook over a message composed elsewhere and
+                                            print it as git would record it; --clipboard puts the result back on the
+                                            clipboard, and --lint also runs commit-msg over it
+    msg undo [--print] [--to <file>]        restore the message as it was before a hook last rewrote it; hooks keep
+                                            the last %d in .git/go-githooks/msg-backups (again to go further back)
+    msg list                                list the backed up messages, newest first
+    replay [--hook <path>] [--corpus testdata/corpus] [--approve] [<case>...]
+                                            for hook development: run a prepare-commit-msg hook (default: the installed
+                                            one) over a corpus of messages and diff what it wrote against the approved
+                                            output; --approve accepts the changes
+    rules export [--format json] [--all]    describe the commit-msg rules active in this repo, with the settings they
+                                            check against, for editor extensions to highlight violations as you type
+    run <hook> [args]                       run one of the repo's installed hooks, e.g. 'run pre-commit'
+    secret set <name> [--age <recipient>]   store a secret (read from stdin) and print its config reference
+    secret get <reference>                  print the value a config reference resolves to
+    serve [--addr <host:port>] [--socket <path>] [--token-file <path>]
+                                            run the repo's commit-msg and prepare-commit-msg hooks over HTTP
+                                            for editor integrations (POST /v1/lint, /v1/prepare {repo, message});
+                                            clients send 'Authorization: Bearer <token>' with the token in
+                                            --token-file, written fresh and readable only by you on each start
+    sync [--preset <name>] [--config-url <url>] [--jobs <n>] [--depth 4] [--force] [--dry-run] <dir>...
+                                            install or update the hooks in every repo under the dirs, e.g. ~/src, several
+                                            at once, and report what changed in each; hooks which something else installed
+                                            are kept unless --force, and repos using core.hooksPath only get the config
+    tag [--since <rev>] [--subject {tag}] [--signoff] [--sign] [--no-edit] [--dry-run] <name> [<commit>]
+                                            make an annotated tag (git tag -a) whose message lists the tickets and other
+                                            changes since the previous tag, opening the editor on it unless --no-edit
+    telemetry status                        show whether telemetry is enabled and what it has recorded
+    telemetry export <file>                 write the recorded summary to a file to share
+    telemetry reset                         delete everything recorded
+    templates add [--name <name>] [--force] <url|name>
+                                            import a shared template of prefix/body templates and rule severities from a
+                                            url, a git repo (<repo>[#<file>]) or the registry (go-githooks.templateRegistry)
+                                            into your config, below your own git config
+    templates list [--available]            list the imported templates, or the registry's
+    templates show <url|name>               preview the options a template sets
+    templates remove <name>                 remove an imported template and its options
+    trailers [--format json|csv] [--key <keys>] <rev-range>
+                                            report the trailers and tickets of commits, e.g. v1.4.0..HEAD
+    vcs [--json]                            show the host, org, repo, provider and upstream detected from the remotes
+    verify [--format text|github|gitlab|json] [--hook <path>] [--merges] <rev-range>
+                                            run the commit-msg rules over commits, e.g. in CI over origin/main..HEAD
+    version                                 print the version
+    help                                    print this help
+
+environment:
+    GIT_HOOKS_PLAIN=1                       linear plain text without columns or decoration, for screen readers and logs
+    GIT_HOOKS_NONINTERACTIVE=1              never prompt, as in CI
+    GIT_HOOKS_OFFLINE=1                     skip checks which need the network
+    GIT_HOOKS_CI=0|1                        override CI detection (CI, GITHUB_ACTIONS, GITLAB_CI, ...); in CI hooks never
+                                            prompt, skip coauthors, require signed policy when trustedKeys are set,
+                                            and give up on the network sooner
+    GIT_HOOKS_STRICT_POLICY=1               (or --strict-policy before the command) refuse policy files which are not
+                                            signed by a [go-githooks "policy"] trustedKey
+    GIT_HOOKS_ENV=<name>                    e.g. staging; config values may refer to it as ${GIT_HOOKS_ENV}
+    GIT_HOOKS_READONLY=1                    report on stderr what hooks would change or block, without doing it,
+                                            to trial a policy before enforcing it
+    GIT_HOOKS_GUI_LOG=1                     (or git config go-githooks.guiLog true) keep each hook's output in
+                                            .git/go-githooks/last-run.log, and add why commit-msg blocked a commit to
+                                            the message as comments, for GUI clients which do not show hook output
+
+`, progName(), msgbackup.Limit)
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/davidalpert/go-githooks/pkg/exitcode"
+	"github.com/davidalpert/go-githooks/pkg/fileio"
+	"github.com/davidalpert/go-githooks/pkg/message"
+	"github.com/davidalpert/go-githooks/pkg/msgbackup"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+func runMsg(args []string) error {
+	if len(args) == 0 {
+		return exitcode.Wrap(exitcode.Usage, fmt.Errorf("expected 'prepare', 'undo [--print] [--to <file>]' or 'list'"))
+	}
+	if args[0] == "prepare" {
+		return runMsgPrepare(args[1:])
+	}
+
+	dir, err := msgBackupDir()
+	if err != nil {
+		return exitcode.Wrap(exitcode.Usage, err)
+	}
+	switch args[0] {
+	case "undo":
+		fs := flag.NewFlagSet("msg undo", flag.ContinueOnError)
+		printOnly := fs.Bool("print", false, "print the message instead of restoring it")
+		to := fs.String("to", "", "restore the message to this file instead of the one it was read from")
+		if err := fs.Parse(args[1:]); err != nil {
+			return exitcode.Wrap(exitcode.Usage, err)
+		}
+		return undoMsg(os.Stdout, dir, *printOnly, *to)
+	case "list":
+		return listMsgBackups(os.Stdout, dir)
+	}
+	return exitcode.Wrap(exitcode.Usage, fmt.Errorf("unknown msg command '%s'", args[0]))
+}
+
+// msgBackupDir is where the hooks of the repo in the working directory back up
+// messages before rewriting them
+func msgBackupDir() (string, error) {
+	gitDir, err := gitOutput(".", "rev-parse", "--absolute-git-dir")
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(gitDir, "go-githooks", "msg-backups"), nil
+}
+
+// undoMsg restores the newest backup and removes it, so undoing again goes one
+// rewrite further back
+func undoMsg(w io.Writer, dir string, printOnly bool, to string) error {
+	b, err := msgbackup.Latest(dir)
+	if err != nil {
+		return exitcode.Wrap(exitcode.Internal, err)
+	}
+	if b == nil {
+		return exitcode.Wrap(exitcode.Usage, fmt.Errorf("there is no message to restore in %s", dir))
+	}
+	if printOnly {
+		fmt.Fprint(w, b.Message)
+		return nil
+	}
+
+	if to == "" {
+		to = b.File
+	}
+	if err := fileio.ReplaceFile(to, []byte(b.Message), 0644); err != nil {
+		return exitcode.Wrap(exitcode.Internal, fmt.Errorf("could not restore the message to '%s': %v", to, err))
+	}
+	if err := b.Remove(); err != nil {
+		return exitcode.Wrap(exitcode.Internal, err)
+	}
+	fmt.Fprintf(w, "restored the message from before %s rewrote it at %s to %s\n", b.Hook, b.SavedAt.Local().Format("15:04:05"), to)
+	fmt.Fprintf(w, "if it was already committed, apply it with: git commit --amend --cleanup=strip -F '%s'\n", to)
+	return nil
+}
+
+func listMsgBackups(w io.Writer, dir string) error {
+	backups, err := msgbackup.List(dir)
+	if err != nil {
+		return exitcode.Wrap(exitcode.Internal, err)
+	}
+	if len(backups) == 0 {
+		fmt.Fprintf(w, "no messages backed up in %s\n", dir)
+		return nil
+	}
+	for _, b := range backups {
+		fmt.Fprintf(w, "%s  %-18s  %s\n", b.SavedAt.Local().Format("2006-01-02 15:04:05"), b.Hook, message.Subject([]byte(b.Message)))
+	}
+	return nil
+}
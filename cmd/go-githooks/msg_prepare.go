@@ -0,0 +1,142 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"github.com/davidalpert/go-githooks/pkg/exitcode"
+	"github.com/davidalpert/go-githooks/pkg/message"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// clipboardCommands read and write the clipboard on each OS; on linux the first one
+// installed is used, so both Wayland and X11 work
+var clipboardCommands = map[string][][2][]string{
+	"darwin":  {{{"pbpaste"}, {"pbcopy"}}},
+	"windows": {{{"powershell", "-NoProfile", "-Command", "Get-Clipboard -Raw"}, {"clip"}}},
+	"linux": {
+		{{"wl-paste", "--no-newline"}, {"wl-copy"}},
+		{{"xclip", "-selection", "clipboard", "-o"}, {"xclip", "-selection", "clipboard"}},
+		{{"xsel", "--clipboard", "--output"}, {"xsel", "--clipboard", "--input"}},
+	},
+}
+
+// runMsgPrepare runs the repo's prepare-commit-msg hook over a message composed
+// elsewhere and prints what git would offer in the editor, without the comments
+func runMsgPrepare(args []string) error {
+	fs := flag.NewFlagSet("msg prepare", flag.ContinueOnError)
+	stdin := fs.Bool("stdin", false, "read the message from stdin (the default without a file or --clipboard)")
+	clipboard := fs.Bool("clipboard", false, "read the message from the clipboard, and put the prepared message back on it")
+	source := fs.String("source", "message", "what git would say the message came from: message, template, merge, squash or commit")
+	lint := fs.Bool("lint", false, "also run the commit-msg hook over the prepared message")
+	if err := fs.Parse(args); err != nil {
+		return exitcode.Wrap(exitcode.Usage, err)
+	}
+	if *stdin && *clipboard || fs.NArg() > 1 || fs.NArg() == 1 && (*stdin || *clipboard) {
+		return exitcode.Wrap(exitcode.Usage, fmt.Errorf("expected one of --stdin, --clipboard or a message file"))
+	}
+
+	var msg []byte
+	var err error
+	switch {
+	case *clipboard:
+		msg, err = readClipboard()
+	case fs.NArg() == 1 && fs.Arg(0) != "-":
+		msg, err = ioutil.ReadFile(fs.Arg(0))
+	default:
+		msg, err = ioutil.ReadAll(os.Stdin)
+	}
+	if err != nil {
+		return exitcode.Wrap(exitcode.Usage, err)
+	}
+
+	repo, err := gitOutput(".", "rev-parse", "--show-toplevel")
+	if err != nil {
+		return exitcode.Wrap(exitcode.Usage, err)
+	}
+	resp, err := runHook("prepare-commit-msg", ServeRequest{Repo: repo, Message: string(msg), Source: *source})
+	if err != nil {
+		return exitcode.Wrap(exitcode.Dependency, err)
+	}
+	fmt.Fprint(os.Stderr, resp.Output)
+	if !resp.OK {
+		return exitcode.Wrap(exitcode.Code(resp.ExitCode), fmt.Errorf("prepare-commit-msg could not prepare the message"))
+	}
+
+	prepared := withoutComments([]byte(resp.Message))
+	os.Stdout.Write(prepared)
+	if *clipboard {
+		if err := writeClipboard(prepared); err != nil {
+			return exitcode.Wrap(exitcode.Dependency, err)
+		}
+	}
+
+	if *lint {
+		resp, err := runHook("commit-msg", ServeRequest{Repo: repo, Message: string(prepared)})
+		if err != nil {
+			return exitcode.Wrap(exitcode.Dependency, err)
+		}
+		fmt.Fprint(os.Stderr, resp.Output)
+		if !resp.OK {
+			return exitcode.Wrap(exitcode.Code(resp.ExitCode), fmt.Errorf("the prepared message would not pass commit-msg"))
+		}
+	}
+	return nil
+}
+
+// withoutComments is the message as git records it with the default cleanup, which
+// drops the comments an editor would show
+func withoutComments(msg []byte) []byte {
+	content, _ := message.SplitComments(msg)
+	lines := bytes.Split(content, []byte("\n"))
+	kept := make([][]byte, 0, len(lines))
+	for _, l := range lines {
+		if !bytes.HasPrefix(l, []byte("#")) {
+			kept = append(kept, bytes.TrimRight(l, " \t\r"))
+		}
+	}
+	content = bytes.TrimSpace(bytes.Join(kept, []byte("\n")))
+	if len(content) == 0 {
+		return content
+	}
+	return append(content, '\n')
+}
+
+// clipboardCommand finds an installed command to read (0) or write (1) the clipboard
+func clipboardCommand(direction int) ([]string, error) {
+	for _, c := range clipboardCommands[runtime.GOOS] {
+		if _, err := exec.LookPath(c[direction][0]); err == nil {
+			return c[direction], nil
+		}
+	}
+	return nil, fmt.Errorf("found no clipboard command for %s; install one of pbcopy, wl-clipboard, xclip or xsel, or use --stdin", runtime.GOOS)
+}
+
+func readClipboard() ([]byte, error) {
+	c, err := clipboardCommand(0)
+	if err != nil {
+		return nil, err
+	}
+	out, err := exec.Command(c[0], c[1:]...).Output()
+	if err != nil {
+		return nil, fmt.Errorf("could not read the clipboard with %s: %v", strings.Join(c, " "), err)
+	}
+	return out, nil
+}
+
+func writeClipboard(text []byte) error {
+	c, err := clipboardCommand(1)
+	if err != nil {
+		return err
+	}
+	cmd := exec.Command(c[0], c[1:]...)
+	cmd.Stdin = bytes.NewReader(text)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("could not write the clipboard with %s: %v: %s", strings.Join(c, " "), err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
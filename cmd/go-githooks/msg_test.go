@@ -0,0 +1,44 @@
+package main
+
+import (
+	"bytes"
+	"github.com/davidalpert/go-githooks/pkg/exitcode"
+	"github.com/davidalpert/go-githooks/pkg/msgbackup"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestUndoMsg(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(t.TempDir(), "COMMIT_EDITMSG")
+	now := time.Now()
+	for i, msg := range []string{"original\n", "rewritten once\n"} {
+		_ = ioutil.WriteFile(file, []byte(msg), 0644)
+		assert.NoError(t, msgbackup.Save(dir, "commit-msg", file, now.Add(time.Duration(i)*time.Second)))
+	}
+	_ = ioutil.WriteFile(file, []byte("rewritten twice\n"), 0644)
+
+	var out bytes.Buffer
+	assert.NoError(t, undoMsg(&out, dir, true, ""))
+	assert.Equal(t, "rewritten once\n", out.String())
+
+	assert.NoError(t, undoMsg(&out, dir, false, ""))
+	content, _ := ioutil.ReadFile(file)
+	assert.Equal(t, "rewritten once\n", string(content))
+
+	assert.NoError(t, undoMsg(&out, dir, false, ""))
+	content, _ = ioutil.ReadFile(file)
+	assert.Equal(t, "original\n", string(content), "undoing again goes further back")
+
+	err := undoMsg(&out, dir, false, "")
+	assert.Equal(t, exitcode.Usage, exitcode.Of(err))
+}
+
+func TestWithoutComments(t *testing.T) {
+	prepared := "[FEAT-1] fix login  \n\nCo-authored-by: Zoe Washburne <zoe@serenity.com>\n\n# prepared by go-githooks\n# Please enter the commit message\n"
+	assert.Equal(t, "[FEAT-1] fix login\n\nCo-authored-by: Zoe Washburne <zoe@serenity.com>\n", string(withoutComments([]byte(prepared))))
+	assert.Equal(t, "", string(withoutComments([]byte("# only comments\n"))))
+}
@@ -0,0 +1,249 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/davidalpert/go-githooks/pkg/exitcode"
+	"github.com/go-git/go-git/v5/utils/diff"
+	"github.com/sergi/go-diff/diffmatchpatch"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+/*
+ * 'go-githooks replay' guards the message transformations against regressions: it
+ * runs a prepare-commit-msg hook over each case in a corpus of real-world messages
+ * and compares what the hook wrote with the approved output. Each case is a directory:
+ *
+ *   testdata/corpus/<case>/
+ *       message      the message git hands the hook
+ *       approved     the message the hook should leave; written by --approve
+ *       args         optional: the hook's args after the file, e.g. 'merge' or 'commit HEAD'
+ *       branch       optional: the branch being committed to (default: feature/ABC-123)
+ *       config       optional: git config for the case, e.g. a [go-githooks ...] section
+ *
+ * testdata/corpus/config, when present, is git config shared by every case.
+ * Each case runs in its own throwaway repo with HOME pointing into it, so neither the
+ * repo's nor the user's config leaks into the output.
+ */
+
+// DefaultCorpus is where replay looks for cases, from the repo root
+const DefaultCorpus = "testdata/corpus"
+
+const defaultReplayBranch = "feature/ABC-123"
+
+// replayCase is one corpus case and what replaying it produced
+type replayCase struct {
+	Name     string
+	Dir      string
+	Approved string
+	Received string
+	Output   string // what the hook printed
+	Code     exitcode.Code
+	Missing  bool // there is no approved output yet
+}
+
+// Changed is true when the hook's output differs from the approved one
+func (c replayCase) Changed() bool {
+	return c.Missing || c.Received != c.Approved || c.Code != exitcode.OK
+}
+
+func runReplay(args []string) error {
+	fs := flag.NewFlagSet("replay", flag.ContinueOnError)
+	corpus := fs.String("corpus", DefaultCorpus, "the corpus directory")
+	hook := fs.String("hook", "", "the prepare-commit-msg hook to replay (default: the one installed in this repo)")
+	approve := fs.Bool("approve", false, "write each case's output as its approved output")
+	if err := fs.Parse(args); err != nil {
+		return exitcode.Wrap(exitcode.Usage, err)
+	}
+
+	if *hook == "" {
+		dir, err := hooksDir(".")
+		if err != nil {
+			return exitcode.Wrap(exitcode.Usage, err)
+		}
+		*hook = filepath.Join(dir, "prepare-commit-msg")
+	}
+	path, err := filepath.Abs(*hook)
+	if err != nil {
+		return exitcode.Wrap(exitcode.Usage, err)
+	}
+	if _, err := os.Stat(path); err != nil {
+		return exitcode.Wrap(exitcode.Usage, fmt.Errorf("could not find the hook to replay: %v", err))
+	}
+
+	cases, err := replayCorpus(path, *corpus, fs.Args())
+	if err != nil {
+		return err
+	}
+	return reportReplay(os.Stdout, cases, *approve)
+}
+
+// replayCorpus runs hook over the cases in corpus, or only the named ones
+func replayCorpus(hook, corpus string, names []string) ([]replayCase, error) {
+	if len(names) == 0 {
+		entries, err := ioutil.ReadDir(corpus)
+		if err != nil {
+			return nil, exitcode.Wrap(exitcode.Usage, fmt.Errorf("could not read the corpus: %v", err))
+		}
+		for _, e := range entries {
+			if e.IsDir() {
+				names = append(names, e.Name())
+			}
+		}
+	}
+	sort.Strings(names)
+
+	cases := make([]replayCase, 0, len(names))
+	for _, name := range names {
+		c, err := replayOne(hook, filepath.Join(corpus, name))
+		if err != nil {
+			return nil, exitcode.Wrap(exitcode.Internal, fmt.Errorf("could not replay '%s': %v", name, err))
+		}
+		cases = append(cases, c)
+	}
+	return cases, nil
+}
+
+func replayOne(hook, dir string) (replayCase, error) {
+	c := replayCase{Name: filepath.Base(dir), Dir: dir}
+	msg, err := ioutil.ReadFile(filepath.Join(dir, "message"))
+	if err != nil {
+		return c, err
+	}
+	approved, err := ioutil.ReadFile(filepath.Join(dir, "approved"))
+	if os.IsNotExist(err) {
+		c.Missing = true
+	} else if err != nil {
+		return c, err
+	}
+	c.Approved = string(approved)
+
+	scratch, err := ioutil.TempDir("", "go-githooks-replay")
+	if err != nil {
+		return c, err
+	}
+	defer os.RemoveAll(scratch)
+	repo := filepath.Join(scratch, "repo")
+	if err := os.MkdirAll(repo, 0755); err != nil {
+		return c, err
+	}
+
+	branch := readCaseFile(dir, "branch", defaultReplayBranch)
+	steps := [][]string{
+		{"init", "--quiet"},
+		{"checkout", "--quiet", "-b", branch},
+		{"config", "user.name", "Mal Reynolds"},
+		{"config", "user.email", "mal@serenity.com"},
+	}
+	env := []string{"HOME=" + scratch, "XDG_CONFIG_HOME=" + scratch, "GIT_CONFIG_NOSYSTEM=1"}
+	for _, args := range steps {
+		if _, err := gitOutputEnv(repo, env, args...); err != nil {
+			return c, err
+		}
+	}
+	// copied rather than included, since the hooks read config without following includes
+	for _, config := range []string{filepath.Join(filepath.Dir(dir), "config"), filepath.Join(dir, "config")} {
+		if err := appendFile(filepath.Join(repo, ".git", "config"), config); err != nil {
+			return c, err
+		}
+	}
+
+	msgFile := filepath.Join(repo, ".git", "COMMIT_EDITMSG")
+	if err := ioutil.WriteFile(msgFile, msg, 0644); err != nil {
+		return c, err
+	}
+	hookArgs := append([]string{msgFile}, strings.Fields(readCaseFile(dir, "args", ""))...)
+	if c.Code, c.Output, err = execHook(hook, repo, hookArgs, env...); err != nil {
+		return c, err
+	}
+	received, err := ioutil.ReadFile(msgFile)
+	c.Received = string(received)
+	return c, err
+}
+
+// readCaseFile reads a one-line setting of a case, or returns def
+func readCaseFile(dir, name, def string) string {
+	b, err := ioutil.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		return def
+	}
+	return strings.TrimSpace(string(b))
+}
+
+// appendFile appends the contents of from, if it exists, to path
+func appendFile(path, from string) error {
+	b, err := ioutil.ReadFile(from)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.Write(append([]byte("\n"), b...))
+	return err
+}
+
+// reportReplay prints each case with a diff of any change, and approves the
+// received output when approve is set
+func reportReplay(w io.Writer, cases []replayCase, approve bool) error {
+	changed := 0
+	for _, c := range cases {
+		switch {
+		case !c.Changed():
+			fmt.Fprintf(w, "  ok   %s\n", c.Name)
+			continue
+		case c.Missing:
+			fmt.Fprintf(w, "  new  %s (no approved output yet)\n", c.Name)
+		default:
+			fmt.Fprintf(w, "  FAIL %s\n", c.Name)
+		}
+		if c.Code != exitcode.OK {
+			fmt.Fprintf(w, "       the hook exited with %d (%s): %s\n", c.Code, c.Code.Name(), lastLine(c.Output))
+		}
+		if !c.Missing {
+			writeLineDiff(w, c.Approved, c.Received)
+		}
+
+		if approve && c.Code == exitcode.OK {
+			if err := ioutil.WriteFile(filepath.Join(c.Dir, "approved"), []byte(c.Received), 0644); err != nil {
+				return exitcode.Wrap(exitcode.Internal, fmt.Errorf("could not approve '%s': %v", c.Name, err))
+			}
+			fmt.Fprintf(w, "       approved\n")
+			continue
+		}
+		changed++
+	}
+
+	if changed > 0 {
+		return exitcode.Wrap(exitcode.Violation, fmt.Errorf("%d of %d cases differ from their approved output; if the changes are intended, replay again with --approve", changed, len(cases)))
+	}
+	fmt.Fprintf(w, "%d cases match their approved output\n", len(cases))
+	return nil
+}
+
+// writeLineDiff prints the lines removed from approved and added in received
+func writeLineDiff(w io.Writer, approved, received string) {
+	for _, d := range diff.Do(approved, received) {
+		var prefix string
+		switch d.Type {
+		case diffmatchpatch.DiffDelete:
+			prefix = "     - "
+		case diffmatchpatch.DiffInsert:
+			prefix = "     + "
+		default:
+			continue
+		}
+		for _, line := range strings.Split(strings.TrimSuffix(d.Text, "\n"), "\n") {
+			fmt.Fprintf(w, "%s%s\n", prefix, line)
+		}
+	}
+}
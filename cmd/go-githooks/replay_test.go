@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bytes"
+	"github.com/davidalpert/go-githooks/pkg/exitcode"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestReplay(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake hook is a shell script")
+	}
+	root := t.TempDir()
+	hook := filepath.Join(root, "prepare-commit-msg")
+	// prefixes the message with the branch, and the source when there is one
+	script := "#!/bin/sh\nb=$(git symbolic-ref --short HEAD)\nprintf '[%s]%s ' \"$b\" \"${2:+ $2}\" | cat - \"$1\" > \"$1.new\" && mv \"$1.new\" \"$1\"\n"
+	if err := ioutil.WriteFile(hook, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	corpus := filepath.Join(root, "corpus")
+	write := func(name, contents string) {
+		_ = os.MkdirAll(filepath.Dir(filepath.Join(corpus, name)), 0755)
+		if err := ioutil.WriteFile(filepath.Join(corpus, name), []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("plain/message", "Add the manifest\n")
+	write("plain/approved", "[feature/ABC-123] Add the manifest\n")
+	write("merge/message", "Merge branch 'x'\n")
+	write("merge/args", "merge\n")
+	write("merge/branch", "main\n")
+	write("merge/approved", "[main] Merge branch 'x'\n")
+	write("new/message", "Fix the build\n")
+
+	cases, err := replayCorpus(hook, corpus, nil)
+	assert.NoError(t, err)
+	var out bytes.Buffer
+	err = reportReplay(&out, cases, false)
+	assert.Equal(t, exitcode.Violation, exitcode.Of(err))
+	assert.Equal(t, "  FAIL merge\n     - [main] Merge branch 'x'\n     + [main] merge Merge branch 'x'\n  new  new (no approved output yet)\n  ok   plain\n", out.String())
+
+	out.Reset()
+	assert.NoError(t, reportReplay(&out, cases, true))
+	approved, _ := ioutil.ReadFile(filepath.Join(corpus, "new", "approved"))
+	assert.Equal(t, "[feature/ABC-123] Fix the build\n", string(approved))
+
+	cases, err = replayCorpus(hook, corpus, []string{"new", "merge"})
+	assert.NoError(t, err)
+	out.Reset()
+	assert.NoError(t, reportReplay(&out, cases, false))
+	assert.Equal(t, "  ok   merge\n  ok   new\n2 cases match their approved output\n", out.String())
+}
+
+// TestReplayCorpus replays testdata/corpus against the prepare-commit-msg hook built
+// from this tree, as 'make replay' does
+func TestReplayCorpus(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds the prepare-commit-msg hook")
+	}
+	hook := filepath.Join(t.TempDir(), "prepare-commit-msg")
+	if out, err := exec.Command("go", "build", "-o", hook, "../prepare-commit-msg").CombinedOutput(); err != nil {
+		t.Fatalf("building the hook: %v: %s", err, out)
+	}
+
+	cases, err := replayCorpus(hook, filepath.Join("..", "..", DefaultCorpus), nil)
+	assert.NoError(t, err)
+	var out bytes.Buffer
+	assert.NoError(t, reportReplay(&out, cases, false), out.String())
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/davidalpert/go-githooks/pkg/exitcode"
+	"github.com/davidalpert/go-githooks/pkg/gitconfig"
+	"github.com/davidalpert/go-githooks/pkg/rules"
+	"github.com/davidalpert/go-githooks/pkg/vcshost"
+	"github.com/go-git/go-git/v5/config"
+	"io"
+	"os"
+)
+
+// RuleSpecVersion changes whenever a field of RuleSpec changes meaning, so editor
+// extensions can tell a spec they do not understand
+const RuleSpecVersion = 1
+
+// RuleSpec describes the rules a hook enforces in a repo, with the settings they
+// check against, for editor extensions which highlight violations as a message is typed
+type RuleSpec struct {
+	Version  int              `json:"version"`
+	Hook     string           `json:"hook"`
+	Rules    []ActiveRule     `json:"rules"`
+	Settings CommitMsgSetting `json:"settings"`
+}
+
+// ActiveRule is a catalog entry at the severity configured in the repo
+type ActiveRule struct {
+	rules.Entry
+	Severity string `json:"severity"`
+}
+
+// CommitMsgSetting holds the config the commit-msg rules read, after presets and defaults
+type CommitMsgSetting struct {
+	PrefixTemplate     string   `json:"prefixTemplate"`
+	ConventionalTypes  []string `json:"conventionalTypes"`
+	TicketPattern      string   `json:"ticketPattern"`
+	AllowedLinkDomains []string `json:"allowedLinkDomains"`
+	BlockedLinkDomains []string `json:"blockedLinkDomains"`
+	CoauthorDomains    []string `json:"coauthorDomains"`
+}
+
+func runRules(args []string) error {
+	if len(args) == 0 || args[0] != "export" {
+		return exitcode.Wrap(exitcode.Usage, fmt.Errorf("expected 'export [--format json] [--all]'"))
+	}
+
+	fs := flag.NewFlagSet("rules export", flag.ContinueOnError)
+	format := fs.String("format", "json", "json")
+	all := fs.Bool("all", false, "include rules which are switched off")
+	if err := fs.Parse(args[1:]); err != nil {
+		return exitcode.Wrap(exitcode.Usage, err)
+	}
+	if *format != "json" {
+		return exitcode.Wrap(exitcode.Usage, fmt.Errorf("unknown format '%s', expected json", *format))
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return exitcode.Wrap(exitcode.Config, fmt.Errorf("could not read config: %v", err))
+	}
+	spec, err := commitMsgSpec(cfg, *all)
+	if err != nil {
+		return exitcode.Wrap(exitcode.Config, err)
+	}
+	return exportRules(os.Stdout, spec)
+}
+
+// commitMsgSpec reads the commit-msg rules and settings as the hook would; the
+// defaults are the hook's own
+func commitMsgSpec(cfg *config.Config, all bool) (RuleSpec, error) {
+	severities, err := rules.SeveritiesFromConfig(cfg)
+	if err != nil {
+		return RuleSpec{}, err
+	}
+
+	spec := RuleSpec{Version: RuleSpecVersion, Hook: "commit-msg", Rules: make([]ActiveRule, 0)}
+	for _, e := range rules.Catalog {
+		if e.Hook != spec.Hook {
+			continue
+		}
+		s := severities.For(e.Rule, e.Default)
+		if s == rules.Off && !all {
+			continue
+		}
+		spec.Rules = append(spec.Rules, ActiveRule{Entry: e, Severity: s.String()})
+	}
+
+	template := gitconfig.GetString(cfg, "go-githooks", "prepare-commit-message", "prefixWithBranchTemplate", "[%s]")
+	spec.Settings = CommitMsgSetting{
+		PrefixTemplate:     vcshost.Detect(cfg).Expand(template),
+		ConventionalTypes:  gitconfig.GetSlice(cfg, "go-githooks", "commit-message", "conventionalTypes", []string{"feat", "fix", "docs", "style", "refactor", "perf", "test", "build", "ci", "chore", "revert"}),
+		TicketPattern:      gitconfig.GetString(cfg, "go-githooks", "commit-message", "ticketPattern", `[A-Z][A-Z0-9]+-[0-9]+`),
+		AllowedLinkDomains: gitconfig.GetSlice(cfg, "go-githooks", "commit-message", "allowedLinkDomains", []string{}),
+		BlockedLinkDomains: gitconfig.GetSlice(cfg, "go-githooks", "commit-message", "blockedLinkDomains", []string{}),
+		CoauthorDomains:    gitconfig.GetSlice(cfg, "go-githooks", "commit-message", "coauthorDomains", []string{}),
+	}
+	return spec, nil
+}
+
+func exportRules(w io.Writer, spec RuleSpec) error {
+	e := json.NewEncoder(w)
+	e.SetIndent("", "  ")
+	return e.Encode(spec)
+}
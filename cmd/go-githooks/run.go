@@ -0,0 +1,40 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"github.com/davidalpert/go-githooks/pkg/exitcode"
+	"os"
+	"os/exec"
+)
+
+// runRun runs one of the repo's installed hooks with the given arguments, the way
+// git would, e.g. 'run pre-commit' to check what is staged before committing
+func runRun(args []string) error {
+	if len(args) == 0 {
+		return exitcode.Wrap(exitcode.Usage, fmt.Errorf("expected a hook to run, e.g. 'run pre-commit'"))
+	}
+
+	repo, err := gitOutput(".", "rev-parse", "--show-toplevel")
+	if err != nil {
+		return exitcode.Wrap(exitcode.Usage, err)
+	}
+	path, err := hookPath(repo, args[0])
+	if err != nil {
+		return exitcode.Wrap(exitcode.Usage, err)
+	}
+
+	cmd := exec.Command(path, args[1:]...)
+	cmd.Dir = repo
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return exitcode.Wrap(exitcode.Code(exitErr.ExitCode()), fmt.Errorf("%s failed", args[0]))
+		}
+		return exitcode.Wrap(exitcode.Internal, fmt.Errorf("could not run %s: %v", path, err))
+	}
+	return nil
+}
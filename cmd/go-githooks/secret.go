@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"github.com/davidalpert/go-githooks/pkg/exitcode"
+	"github.com/davidalpert/go-githooks/pkg/secrets"
+	"os"
+	"strings"
+)
+
+func runSecret(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("expected 'set' or 'get'")
+	}
+
+	switch args[0] {
+	case "set":
+		return runSecretSet(args[1:])
+	case "get":
+		return runSecretGet(args[1:])
+	}
+	return fmt.Errorf("unknown secret command '%s'", args[0])
+}
+
+func runSecretSet(args []string) error {
+	fs := flag.NewFlagSet("secret set", flag.ContinueOnError)
+	recipient := fs.String("age", "", "encrypt to this age recipient instead of storing in the OS keychain")
+	if err := fs.Parse(args); err != nil {
+		return exitcode.Wrap(exitcode.Usage, err)
+	}
+	if fs.NArg() != 1 && *recipient == "" {
+		return fmt.Errorf("expected a secret name")
+	}
+
+	fmt.Fprintf(os.Stderr, "enter secret value: ")
+	value, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return fmt.Errorf("could not read secret from stdin: %v", err)
+	}
+
+	var ref string
+	if *recipient != "" {
+		ref, err = secrets.EncryptAge(*recipient, value)
+	} else {
+		ref, err = secrets.SetKeyring(fs.Arg(0), value)
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%s\n", ref)
+	return nil
+}
+
+func runSecretGet(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("expected a secret reference, e.g. keyring:<name>, env:<VAR> or age:<ciphertext>")
+	}
+
+	ref := args[0]
+	if !secrets.IsReference(ref) {
+		ref = secrets.KeyringScheme + ref
+	}
+
+	v, err := secrets.Resolve(ref)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s\n", v)
+	return nil
+}
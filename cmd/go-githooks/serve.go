@@ -0,0 +1,314 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/davidalpert/go-githooks/pkg/exitcode"
+	"github.com/davidalpert/go-githooks/pkg/fileio"
+	"io/ioutil"
+	"mime"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// violationLineRe parses the lines hooks print for reported violations
+var violationLineRe = regexp.MustCompile(`^(error|warning|info) \[([^\]]+)\] (?:([^\s:]+): )?(.*?)( \(fixable\))?$`)
+
+// ServeRequest is the body of the lint and prepare endpoints
+type ServeRequest struct {
+	Repo    string `json:"repo"`
+	Message string `json:"message"`
+	Source  string `json:"source,omitempty"` // prepare only: message, template, merge, squash or commit
+}
+
+// ServeViolation is a violation reported by the commit-msg hook
+type ServeViolation struct {
+	Severity string `json:"severity"`
+	Rule     string `json:"rule"`
+	Location string `json:"location,omitempty"`
+	Message  string `json:"message"`
+	Fixable  bool   `json:"fixable"`
+}
+
+// ServeResponse reports what the hook did with the message
+type ServeResponse struct {
+	OK         bool             `json:"ok"`
+	ExitCode   int              `json:"exitCode"`
+	Category   string           `json:"category"`
+	Message    string           `json:"message,omitempty"`
+	Violations []ServeViolation `json:"violations,omitempty"`
+	Output     string           `json:"output,omitempty"`
+}
+
+func runServe(args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	addr := fs.String("addr", "127.0.0.1:7469", "localhost address to listen on")
+	socket := fs.String("socket", "", "listen on this unix socket instead of --addr")
+	tokenFile := fs.String("token-file", serveTokenPath(), "write the token clients must send here")
+	if err := fs.Parse(args); err != nil {
+		return exitcode.Wrap(exitcode.Usage, err)
+	}
+
+	token, err := newServeToken(*tokenFile)
+	if err != nil {
+		return exitcode.Wrap(exitcode.Internal, err)
+	}
+
+	var l net.Listener
+	if *socket != "" {
+		_ = os.Remove(*socket)
+		if l, err = net.Listen("unix", *socket); err == nil {
+			if err = os.Chmod(*socket, 0600); err != nil {
+				l.Close()
+			}
+		}
+	} else {
+		if err = requireLoopback(*addr); err != nil {
+			return exitcode.Wrap(exitcode.Usage, err)
+		}
+		l, err = net.Listen("tcp", *addr)
+	}
+	if err != nil {
+		return exitcode.Wrap(exitcode.Usage, fmt.Errorf("could not listen: %v", err))
+	}
+
+	fmt.Printf("serving on %s (POST /v1/lint, POST /v1/prepare, GET /v1/health)\n", l.Addr())
+	fmt.Printf("send 'Authorization: Bearer <token>' with the token in %s\n", *tokenFile)
+	return http.Serve(l, newServeMux(token, *socket == ""))
+}
+
+// serveTokenPath is where serve writes its token by default
+func serveTokenPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		dir = "."
+	}
+	return filepath.Join(dir, "go-githooks", "serve-token")
+}
+
+// newServeToken makes a random token for this run of serve and writes it to a file
+// only the user can read, for their editor to pick up
+func newServeToken(path string) (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("could not create a token: %v", err)
+	}
+	token := hex.EncodeToString(b)
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return "", fmt.Errorf("could not write '%s': %v", path, err)
+	}
+	// a file left by an earlier run may be readable by others
+	_ = os.Remove(path)
+	if err := fileio.WriteFile(path, []byte(token+"\n"), 0600); err != nil {
+		return "", fmt.Errorf("could not write '%s': %v", path, err)
+	}
+	return token, nil
+}
+
+// requireLoopback refuses addresses other machines could reach, since the API runs
+// the repo's hooks on whatever repo path it is given
+func requireLoopback(addr string) error {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return err
+	}
+	if !isLoopbackHost(host) {
+		return fmt.Errorf("'%s' is not a loopback address; serve only listens on localhost", addr)
+	}
+	return nil
+}
+
+func isLoopbackHost(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// newServeMux serves the API to clients sending token; over TCP checkHost also
+// turns away requests for any Host but a loopback one
+func newServeMux(token string, checkHost bool) *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/health", guard(token, checkHost, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{"version": Version})
+	}))
+	mux.HandleFunc("/v1/lint", guard(token, checkHost, serveHook("commit-msg")))
+	mux.HandleFunc("/v1/prepare", guard(token, checkHost, serveHook("prepare-commit-msg")))
+	return mux
+}
+
+// guard turns away what a web page could send: any page can post to a localhost
+// port, and DNS rebinding gives it a name of its own which resolves to one, so
+// requests need the token, must not come with an Origin and must name a loopback Host
+func guard(token string, checkHost bool, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Origin") != "" {
+			writeJSON(w, http.StatusForbidden, map[string]string{"error": "requests from web pages are not allowed"})
+			return
+		}
+		if checkHost {
+			host := r.Host
+			if h, _, err := net.SplitHostPort(r.Host); err == nil {
+				host = h
+			}
+			if !isLoopbackHost(host) {
+				writeJSON(w, http.StatusForbidden, map[string]string{"error": fmt.Sprintf("'%s' is not a loopback host", r.Host)})
+				return
+			}
+		}
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), []byte("Bearer "+token)) != 1 {
+			writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "expected 'Authorization: Bearer <token>' with the token serve wrote"})
+			return
+		}
+		next(w, r)
+	}
+}
+
+// serveHook runs the repo's installed hook over the message, so editors see exactly
+// what git will do when the commit is made
+func serveHook(hook string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "use POST"})
+			return
+		}
+		// a page can send text/plain without a preflight, but not application/json
+		if mt, _, err := mime.ParseMediaType(r.Header.Get("Content-Type")); err != nil || mt != "application/json" {
+			writeJSON(w, http.StatusUnsupportedMediaType, map[string]string{"error": "expected Content-Type: application/json"})
+			return
+		}
+		var req ServeRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Repo == "" {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "expected a JSON body with 'repo' and 'message'"})
+			return
+		}
+
+		resp, err := runHook(hook, req)
+		if err != nil {
+			writeJSON(w, http.StatusUnprocessableEntity, map[string]string{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, resp)
+	}
+}
+
+func runHook(hook string, req ServeRequest) (*ServeResponse, error) {
+	path, err := hookPath(req.Repo, hook)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := ioutil.TempFile("", "go-githooks-serve-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.WriteString(req.Message); err != nil {
+		f.Close()
+		return nil, err
+	}
+	f.Close()
+
+	args := []string{f.Name()}
+	if hook == "prepare-commit-msg" && req.Source != "" {
+		args = append(args, req.Source)
+	}
+	code, out, err := execHook(path, req.Repo, args)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &ServeResponse{OK: code == exitcode.OK, ExitCode: int(code), Category: code.Name(), Output: out, Violations: parseViolations(out)}
+	if hook == "prepare-commit-msg" {
+		prepared, err := ioutil.ReadFile(f.Name())
+		if err != nil {
+			return nil, err
+		}
+		resp.Message = string(prepared)
+	}
+	return resp, nil
+}
+
+// execHook runs a hook binary in repo without prompts or colors, returning its exit
+// code and everything it printed
+func execHook(path, repo string, args []string, env ...string) (exitcode.Code, string, error) {
+	return execHookInput(path, repo, "", args, env...)
+}
+
+// execHookInput is execHook for hooks which read stdin, e.g. pre-push
+func execHookInput(path, repo, stdin string, args []string, env ...string) (exitcode.Code, string, error) {
+	var out bytes.Buffer
+	cmd := exec.Command(path, args...)
+	cmd.Dir = repo
+	cmd.Stdin = strings.NewReader(stdin)
+	cmd.Env = append(append(os.Environ(), "GIT_HOOKS_NONINTERACTIVE=1", "GIT_HOOKS_PLAIN=1", "PREPARE_COMMIT_MESSAGE_REPO_DIR="+repo), env...)
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			return exitcode.Internal, "", fmt.Errorf("could not run %s: %v", path, err)
+		}
+		return exitcode.Code(exitErr.ExitCode()), out.String(), nil
+	}
+	return exitcode.OK, out.String(), nil
+}
+
+// parseViolations picks the reported violations out of a hook's output
+func parseViolations(out string) []ServeViolation {
+	violations := make([]ServeViolation, 0)
+	for _, line := range strings.Split(out, "\n") {
+		if m := violationLineRe.FindStringSubmatch(line); m != nil {
+			violations = append(violations, ServeViolation{Severity: m[1], Rule: m[2], Location: m[3], Message: m[4], Fixable: m[5] != ""})
+		}
+	}
+	return violations
+}
+
+// hookPath finds the hook git would run in repo, honoring core.hooksPath
+func hookPath(repo, hook string) (string, error) {
+	dir, err := hooksDir(repo)
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, hook)
+	if _, err := os.Stat(path); err != nil {
+		return "", fmt.Errorf("no %s hook is installed in '%s'", hook, repo)
+	}
+	return path, nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// hooksDir is the directory git runs repo's hooks from
+func hooksDir(repo string) (string, error) {
+	cmd := exec.Command("git", "rev-parse", "--git-path", "hooks")
+	cmd.Dir = repo
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("'%s' is not a git repo: %v", repo, err)
+	}
+
+	dir := strings.TrimSpace(string(out))
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(repo, dir)
+	}
+	return dir, nil
+}
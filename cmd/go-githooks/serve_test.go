@@ -0,0 +1,125 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/go-git/go-git/v5"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestServe(t *testing.T) {
+	root := t.TempDir()
+	if _, err := git.PlainInit(root, false); err != nil {
+		t.Fatalf("init: %v", err)
+	}
+	hooks := filepath.Join(root, ".git", "hooks")
+	_ = os.MkdirAll(hooks, 0755)
+	_ = ioutil.WriteFile(filepath.Join(hooks, "commit-msg"), []byte("#!/bin/sh\nif grep -q WIP \"$1\"; then\n  echo 'error [no-wip] message: the subject says WIP (fixable)'\n  exit 4\nfi\n"), 0755)
+	_ = ioutil.WriteFile(filepath.Join(hooks, "prepare-commit-msg"), []byte("#!/bin/sh\nsed -i.bak 's/^/[FEAT-1] /' \"$1\"\n"), 0755)
+
+	server := httptest.NewServer(newServeMux("s3cr3t", true))
+	defer server.Close()
+
+	post := func(path, msg string) (int, ServeResponse) {
+		body, _ := json.Marshal(ServeRequest{Repo: root, Message: msg})
+		req, _ := http.NewRequest(http.MethodPost, server.URL+path, bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer s3cr3t")
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("posting: %v", err)
+		}
+		defer res.Body.Close()
+		var resp ServeResponse
+		_ = json.NewDecoder(res.Body).Decode(&resp)
+		return res.StatusCode, resp
+	}
+
+	status, resp := post("/v1/lint", "WIP fix login\n")
+	assert.Equal(t, http.StatusOK, status)
+	assert.False(t, resp.OK)
+	assert.Equal(t, "violation", resp.Category)
+	assert.Equal(t, []ServeViolation{{Severity: "error", Rule: "no-wip", Location: "message", Message: "the subject says WIP", Fixable: true}}, resp.Violations)
+
+	_, resp = post("/v1/lint", "fix login\n")
+	assert.True(t, resp.OK)
+
+	_, resp = post("/v1/prepare", "fix login\n")
+	assert.True(t, resp.OK)
+	assert.Equal(t, "[FEAT-1] fix login\n", resp.Message)
+
+	body, _ := json.Marshal(ServeRequest{Repo: t.TempDir(), Message: "fix login\n"})
+	req, _ := http.NewRequest(http.MethodPost, server.URL+"/v1/lint", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer s3cr3t")
+	res, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusUnprocessableEntity, res.StatusCode, "not a repo")
+}
+
+func TestServeRefusesWebPages(t *testing.T) {
+	server := httptest.NewServer(newServeMux("s3cr3t", true))
+	defer server.Close()
+
+	tests := []struct {
+		name   string
+		header map[string]string
+		host   string
+		want   int
+	}{
+		{name: "no token", header: map[string]string{"Content-Type": "application/json"}, want: http.StatusUnauthorized},
+		{name: "wrong token", header: map[string]string{"Content-Type": "application/json", "Authorization": "Bearer guess"}, want: http.StatusUnauthorized},
+		{name: "from a page", header: map[string]string{"Content-Type": "application/json", "Authorization": "Bearer s3cr3t", "Origin": "https://evil.example"}, want: http.StatusForbidden},
+		{name: "rebound name", header: map[string]string{"Content-Type": "application/json", "Authorization": "Bearer s3cr3t"}, host: "evil.example:7469", want: http.StatusForbidden},
+		{name: "a simple form post", header: map[string]string{"Content-Type": "text/plain", "Authorization": "Bearer s3cr3t"}, want: http.StatusUnsupportedMediaType},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, _ := json.Marshal(ServeRequest{Repo: t.TempDir(), Message: "fix login\n"})
+			req, _ := http.NewRequest(http.MethodPost, server.URL+"/v1/lint", bytes.NewReader(body))
+			for k, v := range tt.header {
+				req.Header.Set(k, v)
+			}
+			if tt.host != "" {
+				req.Host = tt.host
+			}
+			res, err := http.DefaultClient.Do(req)
+			if assert.NoError(t, err) {
+				res.Body.Close()
+				assert.Equal(t, tt.want, res.StatusCode)
+			}
+		})
+	}
+}
+
+func TestNewServeToken(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "go-githooks", "serve-token")
+	_ = os.MkdirAll(filepath.Dir(path), 0755)
+	_ = ioutil.WriteFile(path, []byte("old\n"), 0644)
+
+	token, err := newServeToken(path)
+	assert.NoError(t, err)
+	assert.Len(t, token, 64)
+	written, _ := ioutil.ReadFile(path)
+	assert.Equal(t, token+"\n", string(written))
+	if fi, err := os.Stat(path); assert.NoError(t, err) {
+		assert.Equal(t, os.FileMode(0600), fi.Mode().Perm())
+	}
+
+	again, _ := newServeToken(path)
+	assert.NotEqual(t, token, again)
+}
+
+func TestRequireLoopback(t *testing.T) {
+	assert.NoError(t, requireLoopback("127.0.0.1:7469"))
+	assert.NoError(t, requireLoopback("localhost:7469"))
+	assert.NoError(t, requireLoopback("[::1]:7469"))
+	assert.Error(t, requireLoopback("0.0.0.0:7469"))
+	assert.Error(t, requireLoopback(":7469"))
+}
@@ -0,0 +1,296 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/davidalpert/go-githooks/pkg/exitcode"
+	"github.com/davidalpert/go-githooks/pkg/output"
+	"github.com/davidalpert/go-githooks/pkg/presets"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// skippedDirs are never searched for repos: they are large and only hold dependencies
+var skippedDirs = map[string]bool{"node_modules": true, "vendor": true, ".venv": true, "target": true}
+
+// SyncOptions says what sync does to each repo it finds
+type SyncOptions struct {
+	Binaries  map[string]string // hook name to the binary to link
+	Preset    string
+	ConfigURL string
+	Force     bool // replace hooks go-githooks did not install
+	DryRun    bool
+}
+
+// SyncResult is what happened to one repo
+type SyncResult struct {
+	Repo   string
+	Status string // installed, updated, unchanged or failed
+	Detail string
+	Err    error
+}
+
+func runSync(args []string) error {
+	fs := flag.NewFlagSet("sync", flag.ContinueOnError)
+	preset := fs.String("preset", "", "also set go-githooks.preset in each repo")
+	configURL := fs.String("config-url", "", "also set go-githooks.configUrl in each repo")
+	jobs := fs.Int("jobs", runtime.NumCPU(), "how many repos to sync at once")
+	depth := fs.Int("depth", 4, "how many directories deep to look for repos under each root")
+	force := fs.Bool("force", false, "replace hooks which go-githooks did not install, e.g. husky's")
+	dryRun := fs.Bool("dry-run", false, "report what would change without changing it")
+	if err := fs.Parse(args); err != nil {
+		return exitcode.Wrap(exitcode.Usage, err)
+	}
+	if fs.NArg() == 0 {
+		return exitcode.Wrap(exitcode.Usage, fmt.Errorf("expected one or more directories to look for repos in, e.g. 'sync ~/src'"))
+	}
+	if *preset != "" {
+		for _, name := range strings.Split(*preset, ",") {
+			if _, err := presets.Get(name); err != nil {
+				return exitcode.Wrap(exitcode.Usage, err)
+			}
+		}
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		return exitcode.Wrap(exitcode.Internal, err)
+	}
+	if resolved, err := filepath.EvalSymlinks(exe); err == nil {
+		exe = resolved
+	}
+	binaries, err := findHookBinaries(filepath.Dir(exe))
+	if err != nil {
+		return exitcode.Wrap(exitcode.Dependency, err)
+	}
+
+	repos := make([]string, 0)
+	for _, root := range fs.Args() {
+		// a shell which does not expand ** passes it through
+		found, err := findRepos(strings.TrimSuffix(strings.TrimSuffix(root, "**"), string(filepath.Separator)), *depth)
+		if err != nil {
+			return exitcode.Wrap(exitcode.Usage, err)
+		}
+		repos = append(repos, found...)
+	}
+
+	o := SyncOptions{Binaries: binaries, Preset: *preset, ConfigURL: *configURL, Force: *force, DryRun: *dryRun}
+	results := syncRepos(repos, o, *jobs)
+	return reportSync(os.Stdout, results)
+}
+
+// findRepos lists the working trees under root, up to depth directories down; a
+// repo's own subdirectories are not searched, so submodules are left to their parent
+func findRepos(root string, depth int) ([]string, error) {
+	if root == "" {
+		root = "."
+	}
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+	if info, err := os.Stat(root); err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("'%s' is not a directory", root)
+	}
+
+	repos := make([]string, 0)
+	var walk func(dir string, level int)
+	walk = func(dir string, level int) {
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			repos = append(repos, dir)
+			return
+		}
+		if level >= depth {
+			return
+		}
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return
+		}
+		for _, e := range entries {
+			if e.IsDir() && !skippedDirs[e.Name()] && !strings.HasPrefix(e.Name(), ".") {
+				walk(filepath.Join(dir, e.Name()), level+1)
+			}
+		}
+	}
+	walk(root, 0)
+	return repos, nil
+}
+
+// syncRepos syncs up to jobs repos at a time and returns the results in repo order
+func syncRepos(repos []string, o SyncOptions, jobs int) []SyncResult {
+	if jobs < 1 {
+		jobs = 1
+	}
+	results := make([]SyncResult, len(repos))
+	work := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < jobs; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range work {
+				results[i] = syncRepo(repos[i], o)
+			}
+		}()
+	}
+	for i := range repos {
+		work <- i
+	}
+	close(work)
+	wg.Wait()
+
+	sort.SliceStable(results, func(i, j int) bool { return results[i].Repo < results[j].Repo })
+	return results
+}
+
+// syncRepo links the hooks into repo's hooks directory and sets the requested config;
+// repos whose hooks come from core.hooksPath are configured but their hooks left alone
+func syncRepo(repo string, o SyncOptions) SyncResult {
+	r := SyncResult{Repo: repo, Status: "unchanged"}
+	fail := func(err error) SyncResult {
+		r.Status, r.Err = "failed", err
+		return r
+	}
+
+	changes := make([]string, 0)
+	note := ""
+	if hooksPath, _ := gitOutput(repo, "config", "core.hooksPath"); hooksPath != "" {
+		note = "hooks come from core.hooksPath " + hooksPath
+	} else {
+		dir, err := hooksDir(repo)
+		if err != nil {
+			return fail(err)
+		}
+		installed, updated, kept, err := syncHooks(dir, o)
+		if err != nil {
+			return fail(err)
+		}
+		if installed > 0 && updated == 0 {
+			r.Status = "installed"
+		} else if installed+updated > 0 {
+			r.Status = "updated"
+		}
+		if n := installed + updated; n == 1 {
+			changes = append(changes, "1 hook")
+		} else if n > 1 {
+			changes = append(changes, fmt.Sprintf("%d hooks", n))
+		}
+		if len(kept) > 0 {
+			note = "kept existing " + strings.Join(kept, ", ") + " (--force to replace)"
+		}
+	}
+
+	for _, kv := range [][]string{{"go-githooks.preset", o.Preset}, {"go-githooks.configUrl", o.ConfigURL}} {
+		if kv[1] == "" {
+			continue
+		}
+		if current, _ := gitOutput(repo, "config", kv[0]); current == kv[1] {
+			continue
+		}
+		if !o.DryRun {
+			if _, err := gitOutput(repo, "config", kv[0], kv[1]); err != nil {
+				return fail(err)
+			}
+		}
+		changes = append(changes, kv[0])
+		if r.Status == "unchanged" {
+			r.Status = "updated"
+		}
+	}
+
+	parts := make([]string, 0)
+	if len(changes) > 0 && o.DryRun {
+		parts = append(parts, "would set "+strings.Join(changes, ", "))
+	} else if len(changes) > 0 {
+		parts = append(parts, "set "+strings.Join(changes, ", "))
+	}
+	if note != "" {
+		parts = append(parts, note)
+	}
+	r.Detail = strings.Join(parts, "; ")
+	return r
+}
+
+// syncHooks links each hook binary into dir, counting new and replaced links and
+// listing the hooks left alone because something else installed them
+func syncHooks(dir string, o SyncOptions) (int, int, []string, error) {
+	installed, updated := 0, 0
+	kept := make([]string, 0)
+	if !o.DryRun {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return 0, 0, nil, fmt.Errorf("could not create %s: %v", dir, err)
+		}
+	}
+	for _, hook := range Hooks {
+		src, ok := o.Binaries[hook]
+		if !ok {
+			continue
+		}
+		dst := hookFile(dir, hook)
+		info, err := os.Lstat(dst)
+		switch {
+		case os.IsNotExist(err):
+			installed++
+		case err != nil:
+			return 0, 0, nil, err
+		case info.Mode()&os.ModeSymlink != 0:
+			if target, _ := os.Readlink(dst); target == src {
+				continue
+			}
+			updated++
+		case !o.Force:
+			kept = append(kept, hook)
+			continue
+		default:
+			updated++
+		}
+		if o.DryRun {
+			continue
+		}
+		if err := linkHook(dst, src); err != nil {
+			return 0, 0, nil, fmt.Errorf("could not install %s: %v", hook, err)
+		}
+	}
+	return installed, updated, kept, nil
+}
+
+// reportSync prints one row per repo and fails when any repo did
+func reportSync(w io.Writer, results []SyncResult) error {
+	rows := make([][]string, 0, len(results))
+	counts := map[string]int{}
+	var firstErr error
+	for _, r := range results {
+		detail := r.Detail
+		if r.Err != nil {
+			detail = r.Err.Error()
+			if firstErr == nil {
+				firstErr = r.Err
+			}
+		}
+		counts[r.Status]++
+		rows = append(rows, []string{r.Status, r.Repo, detail})
+	}
+	_ = output.Columns(w, "  ", rows)
+
+	summary := make([]string, 0)
+	for _, s := range []string{"installed", "updated", "unchanged", "failed"} {
+		if counts[s] > 0 {
+			summary = append(summary, fmt.Sprintf("%d %s", counts[s], s))
+		}
+	}
+	if len(summary) == 0 {
+		summary = append(summary, "no repos found")
+	}
+	fmt.Fprintf(w, "\n%d repos: %s\n", len(results), strings.Join(summary, ", "))
+
+	if firstErr != nil {
+		return exitcode.Wrap(exitcode.Of(firstErr), fmt.Errorf("%d of %d repos could not be synced", counts["failed"], len(results)))
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"bytes"
+	"github.com/go-git/go-git/v5"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestSyncRepos(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hooks are copied rather than linked on windows")
+	}
+	bin := t.TempDir()
+	binaries := map[string]string{}
+	for _, hook := range Hooks {
+		binaries[hook] = filepath.Join(bin, hook)
+		_ = ioutil.WriteFile(binaries[hook], []byte("#!/bin/sh\n"), 0755)
+	}
+
+	root := t.TempDir()
+	for _, dir := range []string{"api", "team/web", "team/web/node_modules/dep", "husky"} {
+		if _, err := git.PlainInit(filepath.Join(root, dir), false); err != nil {
+			t.Fatalf("init: %v", err)
+		}
+	}
+	_ = os.MkdirAll(filepath.Join(root, "husky", ".git", "hooks"), 0755)
+	_ = ioutil.WriteFile(filepath.Join(root, "husky", ".git", "hooks", "pre-commit"), []byte("#!/bin/sh\nnpx lint-staged\n"), 0755)
+
+	repos, err := findRepos(root, 4)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{filepath.Join(root, "api"), filepath.Join(root, "team", "web"), filepath.Join(root, "husky")}, repos, "a repo's own directories are not searched")
+
+	results := syncRepos(repos, SyncOptions{Binaries: binaries, Preset: "conventional"}, 2)
+	var out bytes.Buffer
+	assert.NoError(t, reportSync(&out, results))
+	assert.Contains(t, out.String(), "3 repos: 3 installed")
+	assert.Contains(t, out.String(), "kept existing pre-commit")
+
+	target, err := os.Readlink(filepath.Join(root, "api", ".git", "hooks", "commit-msg"))
+	assert.NoError(t, err)
+	assert.Equal(t, binaries["commit-msg"], target)
+	preset, _ := gitOutput(filepath.Join(root, "api"), "config", "go-githooks.preset")
+	assert.Equal(t, "conventional", preset)
+
+	out.Reset()
+	assert.NoError(t, reportSync(&out, syncRepos(repos, SyncOptions{Binaries: binaries, Preset: "conventional"}, 2)))
+	assert.Contains(t, out.String(), "3 repos: 3 unchanged")
+
+	out.Reset()
+	assert.NoError(t, reportSync(&out, syncRepos(repos, SyncOptions{Binaries: binaries, Force: true, DryRun: true}, 2)))
+	assert.Contains(t, out.String(), "would set 1 hook")
+	content, _ := ioutil.ReadFile(filepath.Join(root, "husky", ".git", "hooks", "pre-commit"))
+	assert.Contains(t, string(content), "lint-staged", "a dry run changes nothing")
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/davidalpert/go-githooks/pkg/changes"
+	"github.com/davidalpert/go-githooks/pkg/describe"
+	"github.com/davidalpert/go-githooks/pkg/exitcode"
+	"github.com/davidalpert/go-githooks/pkg/gitconfig"
+	"github.com/davidalpert/go-githooks/pkg/message"
+	"github.com/davidalpert/go-githooks/pkg/push"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+)
+
+// runTag makes an annotated tag whose message lists the tickets and other changes
+// since the previous tag; git has no hook to prepare a tag message, so this wraps
+// 'git tag -a' instead
+func runTag(args []string) error {
+	fs := flag.NewFlagSet("tag", flag.ContinueOnError)
+	since := fs.String("since", "", "the tag or commit to list the changes from (default: the nearest tag below the commit)")
+	subject := fs.String("subject", "{tag}", "the first line of the message, with {tag} and {previous} replaced")
+	signOff := fs.Bool("signoff", false, "add a Signed-off-by trailer for user.name and user.email")
+	sign := fs.Bool("sign", false, "make a signed tag, as git tag -s")
+	noEdit := fs.Bool("no-edit", false, "tag without opening the editor on the message")
+	dryRun := fs.Bool("dry-run", false, "print the message instead of tagging")
+	if err := fs.Parse(args); err != nil {
+		return exitcode.Wrap(exitcode.Usage, err)
+	}
+	if fs.NArg() < 1 || fs.NArg() > 2 {
+		return exitcode.Wrap(exitcode.Usage, fmt.Errorf("expected: tag [--since <rev>] [--subject <template>] [--no-edit] [--dry-run] <name> [<commit>]"))
+	}
+	name, target := fs.Arg(0), "HEAD"
+	if fs.NArg() == 2 {
+		target = fs.Arg(1)
+	}
+
+	repo, err := git.PlainOpenWithOptions(".", &git.PlainOpenOptions{DetectDotGit: true})
+	if err != nil {
+		return exitcode.Wrap(exitcode.Usage, err)
+	}
+	ticketRe, err := ticketPattern(repo)
+	if err != nil {
+		return exitcode.Wrap(exitcode.Config, err)
+	}
+	prefixTemplate := "[%s]"
+	cfg, err := loadConfig()
+	if err == nil {
+		prefixTemplate = gitconfig.GetString(cfg, "go-githooks", "prepare-commit-message", "prefixWithBranchTemplate", prefixTemplate)
+	}
+
+	msg, err := tagMessage(repo, name, target, *since, *subject, prefixTemplate, ticketRe)
+	if err != nil {
+		return exitcode.Wrap(exitcode.Usage, err)
+	}
+	if *signOff && cfg != nil && cfg.User.Email != "" {
+		msg = message.AppendTrailer(msg, fmt.Sprintf("Signed-off-by: %s <%s>", cfg.User.Name, cfg.User.Email))
+	}
+	if *dryRun {
+		os.Stdout.Write(msg)
+		return nil
+	}
+
+	f, err := ioutil.TempFile("", "TAG_EDITMSG")
+	if err != nil {
+		return exitcode.Wrap(exitcode.Internal, fmt.Errorf("could not write the tag message: %v", err))
+	}
+	defer os.Remove(f.Name())
+	_, err = f.Write(msg)
+	f.Close()
+	if err != nil {
+		return exitcode.Wrap(exitcode.Internal, fmt.Errorf("could not write the tag message: %v", err))
+	}
+
+	gitArgs := []string{"tag", "-a", "-F", f.Name()}
+	if *sign {
+		gitArgs[1] = "-s"
+	}
+	if !*noEdit {
+		gitArgs = append(gitArgs, "--edit")
+	}
+	cmd := exec.Command("git", append(gitArgs, name, target)...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return exitcode.Wrap(exitcode.Of(err), fmt.Errorf("could not tag: %v", err))
+	}
+	return nil
+}
+
+// tagMessage summarizes the commits target adds to since, or to the nearest tag below
+// target when since is empty, under a subject rendered from subjectTemplate
+func tagMessage(repo *git.Repository, name, target, since, subjectTemplate, prefixTemplate string, ticketRe *regexp.Regexp) ([]byte, error) {
+	tip, err := repo.ResolveRevision(plumbing.Revision(target))
+	if err != nil {
+		return nil, fmt.Errorf("could not resolve '%s': %v", target, err)
+	}
+
+	exclude := make([]plumbing.Hash, 0)
+	previous := since
+	if since != "" {
+		from, err := repo.ResolveRevision(plumbing.Revision(since))
+		if err != nil {
+			return nil, fmt.Errorf("could not resolve '%s': %v", since, err)
+		}
+		exclude = append(exclude, *from)
+	} else if c, err := repo.CommitObject(*tip); err == nil && c.NumParents() > 0 {
+		// below the commit, so that re-tagging a tagged commit still lists its changes
+		if d, err := describe.Describe(repo, c.ParentHashes[0]); err == nil {
+			previous = d.Tag
+			if from, err := repo.ResolveRevision(plumbing.Revision(d.Tag)); err == nil {
+				exclude = append(exclude, *from)
+			}
+		}
+	}
+
+	commits, err := push.Range(repo, *tip, exclude, push.DefaultLimit)
+	if err != nil {
+		return nil, err
+	}
+	subject := strings.NewReplacer("{tag}", name, "{previous}", previous).Replace(subjectTemplate)
+	msg := []byte(strings.TrimSpace(subject) + "\n")
+	if lines := changes.Summarize(commits, ticketRe, prefixTemplate).Lines(); len(lines) > 0 {
+		msg = message.AppendParagraph(msg, lines...)
+	}
+	return msg, nil
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"github.com/go-git/go-billy/v5/memfs"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/storage/memory"
+	"github.com/stretchr/testify/assert"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestTagMessage(t *testing.T) {
+	r, _ := git.Init(memory.NewStorage(), memfs.New())
+	w, _ := r.Worktree()
+	sig := &object.Signature{Name: "Mal Reynolds", Email: "mal@serenity.com", When: time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)}
+	commit := func(msg string) {
+		sig.When = sig.When.Add(time.Hour)
+		if _, err := w.Commit(msg, &git.CommitOptions{Author: sig}); err != nil {
+			t.Fatalf("committing: %v", err)
+		}
+	}
+
+	commit("[FEAT-1] first\n")
+	_, err := r.CreateTag("v1.0.0", mustHead(t, r), &git.CreateTagOptions{Tagger: sig, Message: "v1.0.0\n"})
+	assert.NoError(t, err)
+	commit("[FEAT-2] second\n")
+	commit("bump the go version\n")
+	commit("[FEAT-2] handle expired sessions\n")
+
+	re := regexp.MustCompile(`[A-Z]+-[0-9]+`)
+	msg, err := tagMessage(r, "v1.1.0", "HEAD", "", "Release {tag}", "[%s]", re)
+	assert.NoError(t, err)
+	assert.Equal(t, "Release v1.1.0\n\nTickets:\n- FEAT-2 second (2 commits)\n\nOther changes:\n- bump the go version\n", string(msg))
+
+	_, err = r.CreateTag("v1.1.0", mustHead(t, r), nil)
+	assert.NoError(t, err)
+	msg, err = tagMessage(r, "v1.1.0", "HEAD", "", "{tag} (since {previous})", "[%s]", re)
+	assert.NoError(t, err)
+	assert.Contains(t, string(msg), "v1.1.0 (since v1.0.0)\n\nTickets:\n- FEAT-2 second (2 commits)\n", "a tagged commit lists the changes since the tag below it")
+
+	msg, err = tagMessage(r, "v1.1.0", "HEAD", "HEAD~1", "{tag}", "[%s]", re)
+	assert.NoError(t, err)
+	assert.Equal(t, "v1.1.0\n\nTickets:\n- FEAT-2 handle expired sessions\n", string(msg))
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"github.com/davidalpert/go-githooks/pkg/gitconfig"
+	"github.com/davidalpert/go-githooks/pkg/telemetry"
+	"os"
+)
+
+func runTelemetry(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("expected 'status', 'export <file>' or 'reset'")
+	}
+
+	switch args[0] {
+	case "status":
+		return runTelemetryStatus()
+	case "export":
+		if len(args) != 2 {
+			return fmt.Errorf("expected a file to export to")
+		}
+		return runTelemetryExport(args[1])
+	case "reset":
+		if err := os.Remove(telemetry.StorePath()); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		fmt.Printf("removed %s\n", telemetry.StorePath())
+		return nil
+	}
+	return fmt.Errorf("unknown telemetry command '%s'", args[0])
+}
+
+func runTelemetryStatus() error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	enabled := gitconfig.GetBool(cfg, "go-githooks", "telemetry", "enabled", false)
+	if enabled {
+		fmt.Printf("telemetry: enabled\n")
+	} else {
+		fmt.Printf("telemetry: disabled (enable with: git config --global go-githooks.telemetry.enabled true)\n")
+	}
+	fmt.Printf("stored at: %s\n", telemetry.StorePath())
+
+	s, err := telemetry.Load(telemetry.StorePath())
+	if err != nil {
+		return err
+	}
+	lines := s.Lines()
+	if len(lines) == 0 {
+		fmt.Printf("nothing recorded\n")
+		return nil
+	}
+
+	fmt.Printf("recorded since %s:\n", s.Since.Format("2006-01-02"))
+	for _, l := range lines {
+		fmt.Printf("  %s\n", l)
+	}
+	return nil
+}
+
+func runTelemetryExport(path string) error {
+	s, err := telemetry.Load(telemetry.StorePath())
+	if err != nil {
+		return err
+	}
+	if err := s.Write(path); err != nil {
+		return err
+	}
+	fmt.Printf("exported to %s\n", path)
+	return nil
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/davidalpert/go-githooks/pkg/exitcode"
+	"github.com/davidalpert/go-githooks/pkg/gitconfig"
+	"github.com/davidalpert/go-githooks/pkg/output"
+	"github.com/davidalpert/go-githooks/pkg/templates"
+	config2 "github.com/go-git/go-git/v5/plumbing/format/config"
+	"io"
+	"os"
+)
+
+func runTemplates(args []string) error {
+	if len(args) == 0 {
+		return exitcode.Wrap(exitcode.Usage, fmt.Errorf("expected 'add', 'list', 'show' or 'remove'"))
+	}
+
+	fs := flag.NewFlagSet("templates "+args[0], flag.ContinueOnError)
+	registry := fs.String("registry", "", "the registry index to look names up in (default: go-githooks.templateRegistry)")
+	name := fs.String("name", "", "add the template under this name instead of its own")
+	force := fs.Bool("force", false, "replace an installed template of the same name")
+	available := fs.Bool("available", false, "list the registry's templates instead of the installed ones")
+	if err := fs.Parse(args[1:]); err != nil {
+		return exitcode.Wrap(exitcode.Usage, err)
+	}
+	if *registry == "" {
+		if cfg, err := loadConfig(); err == nil {
+			*registry = gitconfig.GetString(cfg, "go-githooks", "", "templateRegistry", "")
+		}
+	}
+
+	switch args[0] {
+	case "add":
+		if fs.NArg() != 1 {
+			return exitcode.Wrap(exitcode.Usage, fmt.Errorf("expected: templates add [--name <name>] [--force] <url|name>"))
+		}
+		return addTemplate(os.Stdout, fs.Arg(0), *registry, *name, *force)
+	case "list":
+		if *available {
+			return listRegistry(os.Stdout, *registry)
+		}
+		return listTemplates(os.Stdout)
+	case "show":
+		if fs.NArg() != 1 {
+			return exitcode.Wrap(exitcode.Usage, fmt.Errorf("expected: templates show <url|name>"))
+		}
+		return showTemplate(os.Stdout, fs.Arg(0), *registry)
+	case "remove":
+		if fs.NArg() != 1 {
+			return exitcode.Wrap(exitcode.Usage, fmt.Errorf("expected: templates remove <name>"))
+		}
+		if err := templates.Remove(fs.Arg(0)); err != nil {
+			return exitcode.Wrap(exitcode.Usage, err)
+		}
+		fmt.Printf("removed template %s\n", fs.Arg(0))
+		return nil
+	}
+	return exitcode.Wrap(exitcode.Usage, fmt.Errorf("unknown templates command '%s'", args[0]))
+}
+
+func addTemplate(w io.Writer, source, registry, name string, force bool) error {
+	t, data, err := templates.Resolve(nil, source, registry)
+	if err != nil {
+		return exitcode.Wrap(exitcode.Config, err)
+	}
+	if name != "" {
+		t.Name = name
+	}
+	if existing, ok, err := templates.Get(t.Name); err != nil {
+		return exitcode.Wrap(exitcode.Config, err)
+	} else if ok && !force {
+		return exitcode.Wrap(exitcode.Usage, fmt.Errorf("template '%s' is already installed from %s; use --force to replace it, or --name to add it under another name", t.Name, existing.Source))
+	}
+	if err := templates.Install(t, data); err != nil {
+		return exitcode.Wrap(exitcode.Config, err)
+	}
+	_, options, err := templates.Parse(t.File, data)
+	if err != nil {
+		return exitcode.Wrap(exitcode.Config, err)
+	}
+	fmt.Fprintf(w, "added template %s from %s:\n", t.Name, t.Source)
+	writeTemplateOptions(w, options)
+	fmt.Fprintf(w, "\nyour own git config still overrides these; undo with: %s templates remove %s\n", progName(), t.Name)
+	return nil
+}
+
+func listTemplates(w io.Writer) error {
+	list, err := templates.List()
+	if err != nil {
+		return exitcode.Wrap(exitcode.Config, err)
+	}
+	if len(list) == 0 {
+		fmt.Fprintf(w, "no templates installed (add one with: %s templates add <url|name>)\n", progName())
+		return nil
+	}
+	rows := make([][]string, 0, len(list))
+	for _, t := range list {
+		rows = append(rows, []string{t.Name, t.Description, t.Source})
+	}
+	return output.Columns(w, "  ", rows)
+}
+
+func listRegistry(w io.Writer, registry string) error {
+	entries, err := templates.Registry(nil, registry)
+	if err != nil {
+		return exitcode.Wrap(exitcode.Config, err)
+	}
+	rows := make([][]string, 0, len(entries))
+	for _, e := range entries {
+		installed := ""
+		if _, ok, _ := templates.Get(e.Name); ok {
+			installed = "(installed)"
+		}
+		rows = append(rows, []string{e.Name, e.Description, installed})
+	}
+	return output.Columns(w, "  ", rows)
+}
+
+// showTemplate previews the options a template sets: an installed one by name, or
+// any source add accepts
+func showTemplate(w io.Writer, source, registry string) error {
+	var options *config2.Config
+	t, ok, err := templates.Get(source)
+	if err != nil {
+		return exitcode.Wrap(exitcode.Config, err)
+	}
+	if ok {
+		if options, err = templates.Options(t); err != nil {
+			return exitcode.Wrap(exitcode.Config, err)
+		}
+	} else {
+		var data []byte
+		if t, data, err = templates.Resolve(nil, source, registry); err != nil {
+			return exitcode.Wrap(exitcode.Config, err)
+		}
+		if _, options, err = templates.Parse(t.File, data); err != nil {
+			return exitcode.Wrap(exitcode.Config, err)
+		}
+	}
+
+	fmt.Fprintf(w, "%s", t.Name)
+	if t.Description != "" {
+		fmt.Fprintf(w, ": %s", t.Description)
+	}
+	fmt.Fprintf(w, "\nsource: %s\n", t.Source)
+	if ok {
+		fmt.Fprintf(w, "installed: %s\n", t.Added.Format("2006-01-02"))
+	}
+	fmt.Fprintln(w)
+	writeTemplateOptions(w, options)
+	return nil
+}
+
+// writeTemplateOptions prints each option as the git config key it sets
+func writeTemplateOptions(w io.Writer, options *config2.Config) {
+	rows := make([][]string, 0)
+	for _, s := range options.Sections {
+		for _, o := range s.Options {
+			rows = append(rows, []string{s.Name + "." + o.Key, o.Value})
+		}
+		for _, ss := range s.Subsections {
+			for _, o := range ss.Options {
+				rows = append(rows, []string{s.Name + "." + ss.Name + "." + o.Key, o.Value})
+			}
+		}
+	}
+	if len(rows) == 0 {
+		fmt.Fprintf(w, "    (no options)\n")
+		return
+	}
+	_ = output.Columns(w, "    ", rows)
+}
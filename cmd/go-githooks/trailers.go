@@ -0,0 +1,181 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/davidalpert/go-githooks/pkg/exitcode"
+	"github.com/davidalpert/go-githooks/pkg/gitconfig"
+	"github.com/davidalpert/go-githooks/pkg/message"
+	"github.com/davidalpert/go-githooks/pkg/presets"
+	"github.com/davidalpert/go-githooks/pkg/push"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// TrailerReport is what one commit says about who worked on it and what for
+type TrailerReport struct {
+	Commit   string          `json:"commit"`
+	Author   string          `json:"author"`
+	Date     time.Time       `json:"date"`
+	Subject  string          `json:"subject"`
+	Trailers []TrailerRecord `json:"trailers"`
+	Tickets  []string        `json:"tickets"`
+}
+
+// TrailerRecord is one trailer line of a commit message
+type TrailerRecord struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+func runTrailers(args []string) error {
+	fs := flag.NewFlagSet("trailers", flag.ContinueOnError)
+	format := fs.String("format", "json", "json (one object per commit) or csv (one row per trailer or ticket)")
+	keys := fs.String("key", "", "comma-separated trailer keys to include, e.g. Co-authored-by (default: all)")
+	limit := fs.Int("limit", push.DefaultLimit, "the most commits to read")
+	if err := fs.Parse(args); err != nil {
+		return exitcode.Wrap(exitcode.Usage, err)
+	}
+	if fs.NArg() != 1 || (*format != "json" && *format != "csv") {
+		return exitcode.Wrap(exitcode.Usage, fmt.Errorf("expected: trailers [--format json|csv] [--key <keys>] <rev-range>"))
+	}
+
+	repo, err := git.PlainOpenWithOptions(".", &git.PlainOpenOptions{DetectDotGit: true})
+	if err != nil {
+		return exitcode.Wrap(exitcode.Usage, err)
+	}
+	ticketRe, err := ticketPattern(repo)
+	if err != nil {
+		return exitcode.Wrap(exitcode.Config, err)
+	}
+
+	reports, err := trailerReports(repo, fs.Arg(0), *limit, splitKeys(*keys), ticketRe)
+	if err != nil {
+		return exitcode.Wrap(exitcode.Usage, err)
+	}
+
+	if *format == "csv" {
+		return writeTrailersCSV(os.Stdout, reports)
+	}
+	e := json.NewEncoder(os.Stdout)
+	e.SetIndent("", "  ")
+	return e.Encode(reports)
+}
+
+// ticketPattern is the commit-message.ticketPattern the commit-msg hook checks for
+func ticketPattern(repo *git.Repository) (*regexp.Regexp, error) {
+	pattern := `[A-Z][A-Z0-9]+-[0-9]+`
+	if cfg, err := gitconfig.Load(repo); err == nil {
+		if err := presets.Apply(cfg); err != nil {
+			return nil, err
+		}
+		pattern = gitconfig.GetString(cfg, "go-githooks", "commit-message", "ticketPattern", pattern)
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("ticketPattern '%s' is not a valid regular expression: %v", pattern, err)
+	}
+	return re, nil
+}
+
+func splitKeys(s string) []string {
+	keys := make([]string, 0)
+	for _, k := range strings.Split(s, ",") {
+		if k = strings.TrimSpace(k); k != "" {
+			keys = append(keys, strings.ToLower(k))
+		}
+	}
+	return keys
+}
+
+// revisionRange reads the commits in revRange, newest first: either <rev> for
+// everything reachable from it, or <from>..<to> for what <to> adds to <from>
+func revisionRange(repo *git.Repository, revRange string, limit int) ([]*object.Commit, error) {
+	tipRev, fromRev := revRange, ""
+	if i := strings.Index(revRange, ".."); i >= 0 {
+		fromRev, tipRev = revRange[:i], revRange[i+2:]
+		if tipRev == "" {
+			tipRev = "HEAD"
+		}
+	}
+
+	tip, err := repo.ResolveRevision(plumbing.Revision(tipRev))
+	if err != nil {
+		return nil, fmt.Errorf("could not resolve '%s': %v", tipRev, err)
+	}
+	exclude := make([]plumbing.Hash, 0)
+	if fromRev != "" {
+		from, err := repo.ResolveRevision(plumbing.Revision(fromRev))
+		if err != nil {
+			return nil, fmt.Errorf("could not resolve '%s': %v", fromRev, err)
+		}
+		exclude = append(exclude, *from)
+	}
+
+	return push.Range(repo, *tip, exclude, limit)
+}
+
+// trailerReports reports the trailers and tickets of the commits in revRange; tickets
+// are left out when ticketRe is nil
+func trailerReports(repo *git.Repository, revRange string, limit int, keys []string, ticketRe *regexp.Regexp) ([]TrailerReport, error) {
+	commits, err := revisionRange(repo, revRange, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	reports := make([]TrailerReport, 0, len(commits))
+	for _, c := range commits {
+		msg := []byte(c.Message)
+		r := TrailerReport{
+			Commit:   c.Hash.String(),
+			Author:   fmt.Sprintf("%s <%s>", c.Author.Name, c.Author.Email),
+			Date:     c.Author.When,
+			Subject:  message.Subject(msg),
+			Trailers: make([]TrailerRecord, 0),
+			Tickets:  make([]string, 0),
+		}
+		for _, t := range message.Trailers(msg) {
+			if len(keys) == 0 || stringInSlice(keys, strings.ToLower(t.Key)) {
+				r.Trailers = append(r.Trailers, TrailerRecord{Key: t.Key, Value: t.Value})
+			}
+		}
+		if ticketRe != nil {
+			r.Tickets = message.Tickets(msg, ticketRe)
+		}
+		reports = append(reports, r)
+	}
+	return reports, nil
+}
+
+func writeTrailersCSV(w io.Writer, reports []TrailerReport) error {
+	out := csv.NewWriter(w)
+	_ = out.Write([]string{"commit", "author", "date", "subject", "key", "value"})
+	for _, r := range reports {
+		row := []string{r.Commit, r.Author, r.Date.Format(time.RFC3339), r.Subject}
+		for _, t := range r.Trailers {
+			_ = out.Write(append(row, t.Key, t.Value))
+		}
+		for _, ticket := range r.Tickets {
+			_ = out.Write(append(row, "Ticket", ticket))
+		}
+	}
+	out.Flush()
+	return out.Error()
+}
+
+func stringInSlice(s []string, v string) bool {
+	for _, a := range s {
+		if a == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"bytes"
+	"github.com/go-git/go-billy/v5/memfs"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/storage/memory"
+	"github.com/stretchr/testify/assert"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTrailerReports(t *testing.T) {
+	r, _ := git.Init(memory.NewStorage(), memfs.New())
+	w, _ := r.Worktree()
+	when := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+	commit := func(msg string) {
+		f, _ := w.Filesystem.Create(msg[:6])
+		_ = f.Close()
+		_, _ = w.Add(msg[:6])
+		when = when.Add(time.Hour)
+		if _, err := w.Commit(msg, &git.CommitOptions{Author: &object.Signature{Name: "Mal Reynolds", Email: "mal@serenity.com", When: when}}); err != nil {
+			t.Fatalf("committing: %v", err)
+		}
+	}
+
+	commit("[FEAT-1] first\n")
+	_, _ = r.CreateTag("v1.0.0", mustHead(t, r), nil)
+	commit("[FEAT-2] second\n\nRefs: FEAT-1\nCo-authored-by: Zoe Washburne <zoe@serenity.com>\n")
+	commit("third\n\nSigned-off-by: Mal Reynolds <mal@serenity.com>\n")
+
+	reports, err := trailerReports(r, "v1.0.0..HEAD", 100, splitKeys("co-authored-by"), regexp.MustCompile(`[A-Z]+-[0-9]+`))
+	assert.NoError(t, err)
+	if assert.Len(t, reports, 2) {
+		assert.Equal(t, "third", reports[0].Subject)
+		assert.Empty(t, reports[0].Trailers)
+		assert.Equal(t, []TrailerRecord{{Key: "Co-authored-by", Value: "Zoe Washburne <zoe@serenity.com>"}}, reports[1].Trailers)
+		assert.Equal(t, []string{"FEAT-2", "FEAT-1"}, reports[1].Tickets)
+	}
+
+	var out bytes.Buffer
+	assert.NoError(t, writeTrailersCSV(&out, reports[1:]))
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	assert.Equal(t, []string{
+		"commit,author,date,subject,key,value",
+		reports[1].Commit + ",Mal Reynolds <mal@serenity.com>,2021-03-01T14:00:00Z,[FEAT-2] second,Co-authored-by,Zoe Washburne <zoe@serenity.com>",
+		reports[1].Commit + ",Mal Reynolds <mal@serenity.com>,2021-03-01T14:00:00Z,[FEAT-2] second,Ticket,FEAT-2",
+		reports[1].Commit + ",Mal Reynolds <mal@serenity.com>,2021-03-01T14:00:00Z,[FEAT-2] second,Ticket,FEAT-1",
+	}, lines)
+
+	reports, err = trailerReports(r, "HEAD", 100, nil, regexp.MustCompile(`[A-Z]+-[0-9]+`))
+	assert.NoError(t, err)
+	assert.Len(t, reports, 3)
+}
+
+func mustHead(t *testing.T, r *git.Repository) plumbing.Hash {
+	head, err := r.Head()
+	if err != nil {
+		t.Fatalf("reading HEAD: %v", err)
+	}
+	return head.Hash()
+}
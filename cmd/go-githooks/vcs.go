@@ -0,0 +1,55 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/davidalpert/go-githooks/pkg/exitcode"
+	"github.com/davidalpert/go-githooks/pkg/output"
+	"github.com/davidalpert/go-githooks/pkg/vcshost"
+	"github.com/go-git/go-git/v5"
+	"os"
+	"sort"
+)
+
+func runVCS(args []string) error {
+	fs := flag.NewFlagSet("vcs", flag.ContinueOnError)
+	asJSON := fs.Bool("json", false, "print the template variables as JSON for scripts")
+	if err := fs.Parse(args); err != nil {
+		return exitcode.Wrap(exitcode.Usage, err)
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return exitcode.Wrap(exitcode.Config, fmt.Errorf("could not read config: %v", err))
+	}
+	r := vcshost.Detect(cfg)
+	if repo, err := git.PlainOpenWithOptions(".", &git.PlainOpenOptions{DetectDotGit: true}); err == nil {
+		r = r.Track(repo, cfg)
+	}
+
+	if *asJSON {
+		e := json.NewEncoder(os.Stdout)
+		e.SetIndent("", "  ")
+		return e.Encode(r.Vars())
+	}
+
+	if r == nil {
+		fmt.Printf("no remote found; set go-githooks.vcs.remote, or the host, org and repo overrides\n")
+		return nil
+	}
+	if r.Name != "" {
+		fmt.Printf("remote %s: %s\n\n", r.Name, r.URL)
+	}
+	vars := r.Vars()
+	keys := make([]string, 0, len(vars))
+	for k := range vars {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	rows := make([][]string, 0, len(keys))
+	for _, k := range keys {
+		rows = append(rows, []string{"{" + k + "}", vars[k]})
+	}
+	return output.Columns(os.Stdout, "  ", rows)
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"crypto/md5"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/davidalpert/go-githooks/pkg/exitcode"
+	"github.com/davidalpert/go-githooks/pkg/message"
+	"github.com/davidalpert/go-githooks/pkg/push"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// VerifyResult is what the commit-msg hook said about one commit's message
+type VerifyResult struct {
+	Commit     string           `json:"commit"`
+	Subject    string           `json:"subject"`
+	ExitCode   int              `json:"exitCode"`
+	Category   string           `json:"category"`
+	Violations []ServeViolation `json:"violations"`
+}
+
+// codeQualityIssue is one entry of a GitLab code quality report
+type codeQualityIssue struct {
+	Description string              `json:"description"`
+	CheckName   string              `json:"check_name"`
+	Fingerprint string              `json:"fingerprint"`
+	Severity    string              `json:"severity"`
+	Location    codeQualityLocation `json:"location"`
+}
+
+type codeQualityLocation struct {
+	Path  string         `json:"path"`
+	Lines map[string]int `json:"lines"`
+}
+
+func runVerify(args []string) error {
+	fs := flag.NewFlagSet("verify", flag.ContinueOnError)
+	format := fs.String("format", "text", "text, github (workflow annotations), gitlab (code quality JSON) or json")
+	hook := fs.String("hook", "", "the commit-msg hook to run (default: the installed hook, else commit-msg on PATH)")
+	limit := fs.Int("limit", push.DefaultLimit, "the most commits to verify")
+	merges := fs.Bool("merges", false, "also verify merge commits")
+	if err := fs.Parse(args); err != nil {
+		return exitcode.Wrap(exitcode.Usage, err)
+	}
+	if fs.NArg() != 1 || !stringInSlice([]string{"text", "github", "gitlab", "json"}, *format) {
+		return exitcode.Wrap(exitcode.Usage, fmt.Errorf("expected: verify [--format text|github|gitlab|json] [--hook <path>] <rev-range>"))
+	}
+
+	repo, err := git.PlainOpenWithOptions(".", &git.PlainOpenOptions{DetectDotGit: true})
+	if err != nil {
+		return exitcode.Wrap(exitcode.Usage, err)
+	}
+	w, err := repo.Worktree()
+	if err != nil {
+		return exitcode.Wrap(exitcode.Usage, err)
+	}
+	root := w.Filesystem.Root()
+
+	if *hook == "" {
+		if *hook, err = hookPath(root, "commit-msg"); err != nil {
+			if *hook, err = exec.LookPath("commit-msg"); err != nil {
+				return exitcode.Wrap(exitcode.Dependency, fmt.Errorf("no commit-msg hook is installed or on PATH; pass one with --hook"))
+			}
+		}
+	}
+
+	commits, err := revisionRange(repo, fs.Arg(0), *limit)
+	if err != nil {
+		return exitcode.Wrap(exitcode.Usage, err)
+	}
+	results, err := verifyCommits(*hook, root, commits, *merges)
+	if err != nil {
+		return err
+	}
+
+	switch *format {
+	case "github":
+		writeVerifyGitHub(os.Stdout, results)
+	case "gitlab":
+		err = writeVerifyGitLab(os.Stdout, results)
+	case "json":
+		e := json.NewEncoder(os.Stdout)
+		e.SetIndent("", "  ")
+		err = e.Encode(results)
+	default:
+		writeVerifyText(os.Stdout, results)
+	}
+	if err != nil {
+		return err
+	}
+
+	failed := 0
+	for _, r := range results {
+		if r.ExitCode != int(exitcode.OK) {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return exitcode.Wrap(exitcode.Violation, fmt.Errorf("%d of %d commits do not meet this repo's commit message rules", failed, len(results)))
+	}
+	return nil
+}
+
+// verifyCommits runs the hook over each commit's message, oldest first, as the
+// commit's committer and author so identity rules such as dco-signoff judge the right
+// person, and progressive enforcement the right date
+func verifyCommits(hook, root string, commits []*object.Commit, merges bool) ([]VerifyResult, error) {
+	results := make([]VerifyResult, 0, len(commits))
+	for i := len(commits) - 1; i >= 0; i-- {
+		c := commits[i]
+		if c.NumParents() > 1 && !merges {
+			continue
+		}
+
+		f, err := ioutil.TempFile("", "go-githooks-verify-")
+		if err != nil {
+			return nil, err
+		}
+		_, err = f.WriteString(c.Message)
+		f.Close()
+		if err != nil {
+			os.Remove(f.Name())
+			return nil, err
+		}
+
+		code, out, err := execHook(hook, root, []string{f.Name()},
+			"GIT_COMMITTER_NAME="+c.Committer.Name, "GIT_COMMITTER_EMAIL="+c.Committer.Email,
+			"GIT_AUTHOR_NAME="+c.Author.Name, "GIT_AUTHOR_EMAIL="+c.Author.Email, "GIT_AUTHOR_DATE="+c.Author.When.Format(time.RFC3339))
+		os.Remove(f.Name())
+		if err != nil {
+			return nil, err
+		}
+		if code != exitcode.OK && code != exitcode.Violation {
+			return nil, exitcode.Wrap(code, fmt.Errorf("commit-msg could not check %s: %s", c.Hash.String()[:7], strings.TrimSpace(out)))
+		}
+
+		results = append(results, VerifyResult{
+			Commit:     c.Hash.String(),
+			Subject:    message.Subject([]byte(c.Message)),
+			ExitCode:   int(code),
+			Category:   code.Name(),
+			Violations: parseViolations(out),
+		})
+	}
+	return results, nil
+}
+
+func writeVerifyText(w io.Writer, results []VerifyResult) {
+	for _, r := range results {
+		status := "ok"
+		if r.ExitCode != int(exitcode.OK) {
+			status = "FAIL"
+		}
+		fmt.Fprintf(w, "%s %s %s\n", status, r.Commit[:7], r.Subject)
+		for _, v := range r.Violations {
+			fmt.Fprintf(w, "    %s [%s] %s\n", v.Severity, v.Rule, v.Message)
+		}
+	}
+}
+
+// writeVerifyGitHub prints workflow commands, which GitHub Actions shows as
+// annotations on the run without needing a problem matcher
+func writeVerifyGitHub(w io.Writer, results []VerifyResult) {
+	level := map[string]string{"error": "error", "warning": "warning", "info": "notice"}
+	for _, r := range results {
+		for _, v := range r.Violations {
+			fmt.Fprintf(w, "::%s title=%s::%s\n", level[v.Severity], githubEscape(v.Rule), githubEscape(fmt.Sprintf("%s %s: %s", r.Commit[:7], r.Subject, v.Message)))
+		}
+	}
+}
+
+func githubEscape(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+// writeVerifyGitLab writes a code quality report for the codequality artifact
+func writeVerifyGitLab(w io.Writer, results []VerifyResult) error {
+	severity := map[string]string{"error": "major", "warning": "minor", "info": "info"}
+	issues := make([]codeQualityIssue, 0)
+	for _, r := range results {
+		for _, v := range r.Violations {
+			issues = append(issues, codeQualityIssue{
+				Description: fmt.Sprintf("%s %s: %s", r.Commit[:7], r.Subject, v.Message),
+				CheckName:   v.Rule,
+				Fingerprint: fmt.Sprintf("%x", md5.Sum([]byte(r.Commit+v.Rule+v.Message))),
+				Severity:    severity[v.Severity],
+				Location:    codeQualityLocation{Path: "commit/" + r.Commit, Lines: map[string]int{"begin": 1}},
+			})
+		}
+	}
+	e := json.NewEncoder(w)
+	e.SetIndent("", "  ")
+	return e.Encode(issues)
+}
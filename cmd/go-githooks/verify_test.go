@@ -0,0 +1,60 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestVerifyCommits(t *testing.T) {
+	root := t.TempDir()
+	r, err := git.PlainInit(root, false)
+	if err != nil {
+		t.Fatalf("init: %v", err)
+	}
+	w, _ := r.Worktree()
+	commit := func(name, msg string) {
+		_ = ioutil.WriteFile(filepath.Join(root, name), []byte(name), 0644)
+		_, _ = w.Add(name)
+		sig := &object.Signature{Name: "Mal Reynolds", Email: "mal@serenity.com", When: time.Now()}
+		if _, err := w.Commit(msg, &git.CommitOptions{Author: sig, Committer: sig}); err != nil {
+			t.Fatalf("committing: %v", err)
+		}
+	}
+	commit("a", "first\n")
+	commit("b", "WIP second\n")
+	commit("c", "third\n")
+
+	hook := filepath.Join(t.TempDir(), "commit-msg")
+	_ = ioutil.WriteFile(hook, []byte("#!/bin/sh\ntest \"$GIT_COMMITTER_EMAIL\" = mal@serenity.com || exit 1\nif grep -q WIP \"$1\"; then\n  echo 'error [no-wip] message: the subject says WIP'\n  exit 4\nfi\n"), 0755)
+
+	commits, err := revisionRange(r, "HEAD", 100)
+	assert.NoError(t, err)
+	results, err := verifyCommits(hook, root, commits, false)
+	assert.NoError(t, err)
+	if assert.Len(t, results, 3) {
+		assert.Equal(t, "first", results[0].Subject, "oldest first")
+		assert.Equal(t, "violation", results[1].Category)
+		assert.Equal(t, []ServeViolation{{Severity: "error", Rule: "no-wip", Location: "message", Message: "the subject says WIP"}}, results[1].Violations)
+		assert.Empty(t, results[2].Violations)
+	}
+
+	var out bytes.Buffer
+	writeVerifyGitHub(&out, results)
+	assert.Equal(t, "::error title=no-wip::"+results[1].Commit[:7]+" WIP second: the subject says WIP\n", out.String())
+
+	out.Reset()
+	assert.NoError(t, writeVerifyGitLab(&out, results))
+	var issues []codeQualityIssue
+	assert.NoError(t, json.Unmarshal(out.Bytes(), &issues))
+	if assert.Len(t, issues, 1) {
+		assert.Equal(t, "no-wip", issues[0].CheckName)
+		assert.Equal(t, "major", issues[0].Severity)
+	}
+}
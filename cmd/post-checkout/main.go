@@ -0,0 +1,274 @@
+package main
+
+import (
+	"fmt"
+	"github.com/davidalpert/go-githooks/pkg/crash"
+	"github.com/davidalpert/go-githooks/pkg/exitcode"
+	"github.com/davidalpert/go-githooks/pkg/gitconfig"
+	"github.com/davidalpert/go-githooks/pkg/guilog"
+	"github.com/davidalpert/go-githooks/pkg/prompt"
+	"github.com/davidalpert/go-githooks/pkg/readonly"
+	"github.com/davidalpert/go-githooks/pkg/updatecheck"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+)
+
+var (
+	Version = "n/a"
+
+	goDirectiveRe = regexp.MustCompile(`(?m)^go\s+(\S+)`)
+)
+
+/*
+ * The post-checkout hook is run after a successful git checkout. It takes three
+ * parameters: the ref of the previous HEAD, the ref of the new HEAD, and a flag
+ * indicating whether the checkout was a branch checkout (1) or a file checkout (0).
+ * It cannot affect the outcome of the checkout.
+ *
+ * reference: https://git-scm.com/docs/githooks#_post_checkout
+ */
+type PostCheckoutOptions struct {
+	// 3 positional args provided by git
+	PreviousHead   plumbing.Hash
+	NewHead        plumbing.Hash
+	BranchCheckout bool
+
+	Repo *git.Repository
+
+	// these are configuration options, set through git config
+	ToolchainFiles  []ToolchainFile
+	RunCommands     bool
+	PromptBeforeRun bool
+}
+
+// ToolchainFile is a file which declares the version of a tool the repo expects
+type ToolchainFile struct {
+	Path    string
+	Key     string // config key holding the switch command
+	Command string // run (or printed) when the declared version changes
+	Hint    string // printed when there is no command; %s is the new version
+}
+
+// ToolchainChange describes a toolchain file which differs between the two checkouts
+type ToolchainChange struct {
+	File   ToolchainFile
+	Before string
+	After  string
+}
+
+// Hint is what to do about a change which has no command, or "" when the file was removed
+func (c ToolchainChange) Hint() string {
+	if c.After == "" {
+		return ""
+	}
+	return strings.Replace(c.File.Hint, "%s", c.After, 1)
+}
+
+func NewOptions(repo *git.Repository) *PostCheckoutOptions {
+	return &PostCheckoutOptions{
+		Repo: repo,
+	}
+}
+
+func (o *PostCheckoutOptions) Prepare(args []string) error {
+	if len(args) != 3 {
+		return exitcode.Wrap(exitcode.Usage, fmt.Errorf("expected 'version' or 3 args, got %d: %v", len(args), args))
+	}
+
+	o.PreviousHead = plumbing.NewHash(args[0])
+	o.NewHead = plumbing.NewHash(args[1])
+	o.BranchCheckout = args[2] == "1"
+
+	o.setDefaultOptions()
+	o.overrideFromRepo()
+
+	return nil
+}
+
+func (o *PostCheckoutOptions) setDefaultOptions() {
+	o.ToolchainFiles = []ToolchainFile{
+		{Path: ".tool-versions", Key: "toolVersionsCommand", Command: "asdf install"},
+		// nvm is a shell function, which the hook's non-interactive sh does not load
+		{Path: ".nvmrc", Key: "nvmrcCommand", Command: "", Hint: "to switch, run in your shell: nvm use"},
+		{Path: "go.mod", Key: "goVersionCommand", Command: "", Hint: "to switch, install go %s, or let go 1.21+ fetch it with GOTOOLCHAIN=auto"},
+	}
+	o.RunCommands = false
+	o.PromptBeforeRun = true
+}
+
+func (o *PostCheckoutOptions) overrideFromRepo() {
+	cfg, err := gitconfig.Load(o.Repo)
+	if err != nil {
+		return
+	}
+
+	for i, f := range o.ToolchainFiles {
+		o.ToolchainFiles[i].Command = gitconfig.GetString(cfg, "go-githooks", "post-checkout", f.Key, f.Command)
+	}
+	o.RunCommands = gitconfig.GetBool(cfg, "go-githooks", "post-checkout", "runCommands", o.RunCommands)
+	o.PromptBeforeRun = gitconfig.GetBool(cfg, "go-githooks", "post-checkout", "promptBeforeRun", o.PromptBeforeRun)
+}
+
+func (o *PostCheckoutOptions) Execute() error {
+	if !o.BranchCheckout || o.PreviousHead == o.NewHead {
+		return nil
+	}
+
+	changes, err := o.toolchainChanges()
+	if err != nil {
+		return err
+	}
+
+	for _, c := range changes {
+		fmt.Printf("%s changed: '%s' -> '%s'\n", c.File.Path, c.Before, c.After)
+		if c.File.Command == "" {
+			if h := c.Hint(); h != "" {
+				fmt.Printf("  %s\n", h)
+			}
+			continue
+		}
+
+		if !o.RunCommands || readonly.Enabled() {
+			fmt.Printf("  to switch, run: %s\n", c.File.Command)
+			continue
+		}
+
+		if o.PromptBeforeRun && !confirm(fmt.Sprintf("  run '%s'?", c.File.Command)) {
+			continue
+		}
+		if err := runCommand(c.File.Command); err != nil {
+			fmt.Printf("  '%s' failed: %v\n", c.File.Command, err)
+		}
+	}
+
+	return nil
+}
+
+func (o *PostCheckoutOptions) toolchainChanges() ([]ToolchainChange, error) {
+	// git passes a zero hash as the previous HEAD on clone, which had no tree before
+	var before *object.Commit
+	var err error
+	if !o.PreviousHead.IsZero() {
+		if before, err = o.Repo.CommitObject(o.PreviousHead); err != nil {
+			return nil, fmt.Errorf("could not read previous HEAD %s: %v", o.PreviousHead, err)
+		}
+	}
+	after, err := o.Repo.CommitObject(o.NewHead)
+	if err != nil {
+		return nil, fmt.Errorf("could not read new HEAD %s: %v", o.NewHead, err)
+	}
+
+	changes := make([]ToolchainChange, 0)
+	for _, f := range o.ToolchainFiles {
+		b := declaredVersion(before, f.Path)
+		a := declaredVersion(after, f.Path)
+		if b != a {
+			changes = append(changes, ToolchainChange{File: f, Before: b, After: a})
+		}
+	}
+	return changes, nil
+}
+
+// declaredVersion returns the toolchain version declared by path in commit c, or "" if it is
+// missing or there is no commit
+func declaredVersion(c *object.Commit, path string) string {
+	if c == nil {
+		return ""
+	}
+	f, err := c.File(path)
+	if err != nil {
+		return ""
+	}
+	contents, err := f.Contents()
+	if err != nil {
+		return ""
+	}
+
+	if path == "go.mod" {
+		if m := goDirectiveRe.FindStringSubmatch(contents); len(m) > 1 {
+			return m[1]
+		}
+		return ""
+	}
+	return strings.TrimSpace(contents)
+}
+
+// confirm asks on the terminal since git does not connect the hook's stdin to it
+func confirm(question string) bool {
+	p := prompt.Terminal()
+	defer p.Close()
+	return p.Confirm(question)
+}
+
+func runCommand(command string) error {
+	cmd := exec.Command("sh", "-c", command)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+func main() {
+	if updatecheck.Requested() {
+		updatecheck.Run()
+		return
+	}
+
+	argsWithoutProg := os.Args[1:]
+
+	if len(argsWithoutProg) == 1 {
+		switch argsWithoutProg[0] {
+		case "version":
+			printVersion()
+			return
+		case "help":
+			printHelp()
+			return
+		}
+	}
+
+	repo, err := git.PlainOpenWithOptions(".", &git.PlainOpenOptions{DetectDotGit: true})
+	checkError("read git repo", exitcode.Wrap(exitcode.Usage, err))
+	defer crash.Recover(crash.Dir(repo), "post-checkout", Version)
+	if err := guilog.Start(repo, "post-checkout"); err != nil {
+		fmt.Printf("could not keep the output for GUI clients: %v\n", err)
+	}
+	defer guilog.Stop()
+	updatecheck.Start(repo, Version)
+
+	o := NewOptions(repo)
+
+	err = o.Prepare(argsWithoutProg)
+	checkError("prepare options", err)
+
+	err = o.Execute()
+	checkError("executing", readonly.Allow("post-checkout", err))
+}
+
+func printVersion() {
+	fmt.Printf("version: %s\n", Version)
+}
+
+func printHelp() {
+	fmt.Printf("help: %s\n", Version)
+	fmt.Printf(`
+configure go-githooks per-repo in .git/config:
+
+[go-githooks "post-checkout"]
+    toolVersionsCommand = asdf install   # run when .tool-versions changes
+    nvmrcCommand =                       # run when .nvmrc changes; by default only says to run 'nvm use',
+                                         # a shell function the hook cannot call
+    goVersionCommand =                   # run when the go directive in go.mod changes; by default only
+                                         # prints the version to install
+    runCommands = false                  # false only prints the commands
+    promptBeforeRun = true
+
+these are only read from .git/config or ~/.gitconfig, never from .githooks.yml,
+templates or remote config, so cloning a repo cannot run its commands
+
+`)
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"github.com/go-git/go-billy/v5/memfs"
+	"github.com/go-git/go-billy/v5/util"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/storage/memory"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func commitFiles(t *testing.T, r *git.Repository, files map[string]string) plumbing.Hash {
+	w, err := r.Worktree()
+	if err != nil {
+		t.Fatalf("getting worktree: %v", err)
+	}
+	for path, contents := range files {
+		if err := util.WriteFile(w.Filesystem, path, []byte(contents), 0644); err != nil {
+			t.Fatalf("writing %s: %v", path, err)
+		}
+		if _, err := w.Add(path); err != nil {
+			t.Fatalf("adding %s: %v", path, err)
+		}
+	}
+	h, err := w.Commit("update toolchain", &git.CommitOptions{
+		Author: &object.Signature{Name: "Mal Reynolds", Email: "mal@serenity.com", When: time.Now()},
+	})
+	if err != nil {
+		t.Fatalf("committing: %v", err)
+	}
+	return h
+}
+
+func TestToolchainChanges(t *testing.T) {
+	r, _ := git.Init(memory.NewStorage(), memfs.New())
+
+	before := commitFiles(t, r, map[string]string{
+		"go.mod":         "module example.com/m\n\ngo 1.16\n",
+		".nvmrc":         "14\n",
+		".tool-versions": "golang 1.16.5\n",
+	})
+	after := commitFiles(t, r, map[string]string{
+		"go.mod": "module example.com/m\n\ngo 1.17\n\nrequire example.com/x v1.0.0\n",
+		".nvmrc": "16\n",
+	})
+
+	o := NewOptions(r)
+	err := o.Prepare([]string{before.String(), after.String(), "1"})
+	assert.NoError(t, err)
+
+	changes, err := o.toolchainChanges()
+	assert.NoError(t, err)
+	assert.Equal(t, []ToolchainChange{
+		{File: o.ToolchainFiles[1], Before: "14", After: "16"},
+		{File: o.ToolchainFiles[2], Before: "1.16", After: "1.17"},
+	}, changes)
+}
+
+func TestToolchainChangesOnClone(t *testing.T) {
+	r, _ := git.Init(memory.NewStorage(), memfs.New())
+	cloned := commitFiles(t, r, map[string]string{
+		"go.mod": "module example.com/m\n\ngo 1.17\n",
+	})
+
+	o := NewOptions(r)
+	assert.NoError(t, o.Prepare([]string{plumbing.ZeroHash.String(), cloned.String(), "1"}))
+
+	changes, err := o.toolchainChanges()
+	assert.NoError(t, err, "git passes a zero previous HEAD on clone")
+	assert.Equal(t, []ToolchainChange{
+		{File: o.ToolchainFiles[2], Before: "", After: "1.17"},
+	}, changes)
+}
+
+func TestToolchainChangeHint(t *testing.T) {
+	o := NewOptions(nil)
+	o.setDefaultOptions()
+
+	assert.Equal(t, "to switch, run in your shell: nvm use", ToolchainChange{File: o.ToolchainFiles[1], Before: "14", After: "16"}.Hint())
+	assert.Equal(t, "to switch, install go 1.17, or let go 1.21+ fetch it with GOTOOLCHAIN=auto", ToolchainChange{File: o.ToolchainFiles[2], Before: "1.16", After: "1.17"}.Hint())
+	assert.Equal(t, "", ToolchainChange{File: o.ToolchainFiles[2], Before: "1.16", After: ""}.Hint(), "go.mod was removed")
+	assert.Equal(t, "", ToolchainChange{File: o.ToolchainFiles[0], Before: "golang 1.16.5", After: "golang 1.17"}.Hint(), "asdf install is run instead")
+
+	for _, f := range o.ToolchainFiles {
+		assert.NotEqual(t, ".envrc", f.Path, "direnv allow must stay the user's own review step")
+	}
+}
@@ -0,0 +1,380 @@
+package main
+
+import (
+	"fmt"
+	"github.com/davidalpert/go-githooks/pkg/bypass"
+	"github.com/davidalpert/go-githooks/pkg/crash"
+	"github.com/davidalpert/go-githooks/pkg/exitcode"
+	"github.com/davidalpert/go-githooks/pkg/gitconfig"
+	"github.com/davidalpert/go-githooks/pkg/guilog"
+	"github.com/davidalpert/go-githooks/pkg/message"
+	"github.com/davidalpert/go-githooks/pkg/pairing"
+	"github.com/davidalpert/go-githooks/pkg/presets"
+	"github.com/davidalpert/go-githooks/pkg/prompt"
+	"github.com/davidalpert/go-githooks/pkg/readonly"
+	"github.com/davidalpert/go-githooks/pkg/secrets"
+	"github.com/davidalpert/go-githooks/pkg/smartcommit"
+	"github.com/davidalpert/go-githooks/pkg/updatecheck"
+	"github.com/davidalpert/go-githooks/pkg/worklog"
+	"github.com/go-git/go-git/v5"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var (
+	Version = "n/a"
+)
+
+/*
+ * The post-commit hook is run after a commit is made. It takes no parameters and
+ * cannot affect the outcome of the commit.
+ *
+ * When work-logging is enabled it queues an entry for each issue the new commit
+ * references and starts '<hook> flush' in the background to post the queue to the
+ * tracker, so the commit never waits on the network.
+ *
+ * In a mob which rotates, it counts the commit towards the driver's turn and, when
+ * the turn is over, hands the keyboard to the next member of the mob.
+ *
+ * reference: https://git-scm.com/docs/githooks#_post_commit
+ */
+type PostCommitOptions struct {
+	// 'flush' posts the queue instead of queueing HEAD
+	Flush bool
+
+	Repo *git.Repository
+
+	// these are configuration options, set through git config
+	Enabled       bool
+	Provider      worklog.Config
+	Template      string
+	TicketPattern string
+	Mode          WorklogMode
+	Async         bool
+	Delay         time.Duration
+
+	MobRotate         bool
+	MobMembers        []message.Coauthor // in driving order
+	MobEvery          int
+	MobSession        MobSession
+	MobSwitchIdentity bool
+	MobHandoffCommand string
+	MobRotationFile   string
+	PairingFile       string
+	Prompter          *prompt.Prompter // nil when no terminal is attached
+}
+
+// WorklogMode chooses which commits are logged
+type WorklogMode string
+
+const (
+	CommentMode WorklogMode = "comment" // every commit which references an issue
+	TimeMode    WorklogMode = "time"    // only commits which record time, e.g. ABC-123 #time 2h
+)
+
+func WorklogModeFromString(s string) WorklogMode {
+	if WorklogMode(s) == TimeMode {
+		return TimeMode
+	}
+	return CommentMode
+}
+
+func NewOptions(repo *git.Repository) *PostCommitOptions {
+	return &PostCommitOptions{
+		Repo: repo,
+	}
+}
+
+func (o *PostCommitOptions) Prepare(args []string) error {
+	switch {
+	case len(args) == 1 && args[0] == "flush":
+		o.Flush = true
+	case len(args) != 0:
+		return exitcode.Wrap(exitcode.Usage, fmt.Errorf("expected 'version', 'flush' or no args, got %d: %v", len(args), args))
+	}
+
+	o.setDefaultOptions()
+	return o.overrideFromRepo()
+}
+
+func (o *PostCommitOptions) setDefaultOptions() {
+	o.Enabled = false
+	o.Template = worklog.DefaultTemplate
+	o.TicketPattern = `[A-Z][A-Z0-9]+-[0-9]+`
+	o.Mode = CommentMode
+	o.Async = true
+	o.Delay = 2 * time.Second
+	o.MobEvery = 1
+	o.MobSession = PairingSession
+	if dir := bypass.Dir(o.Repo); dir != "" {
+		o.MobRotationFile = filepath.Join(dir, "mob-rotation.json")
+	}
+	if w, err := o.Repo.Worktree(); err == nil {
+		o.PairingFile = filepath.Join(w.Filesystem.Root(), pairing.DefaultFile)
+	}
+}
+
+func (o *PostCommitOptions) overrideFromRepo() error {
+	cfg, err := gitconfig.Load(o.Repo)
+	if err != nil {
+		return nil
+	}
+	if err = presets.Apply(cfg); err != nil {
+		return err
+	}
+
+	o.Enabled = gitconfig.GetBool(cfg, "go-githooks", "worklog", "enabled", o.Enabled)
+	o.Provider.Provider = gitconfig.GetString(cfg, "go-githooks", "worklog", "provider", o.Provider.Provider)
+	o.Provider.URL = gitconfig.GetString(cfg, "go-githooks", "worklog", "url", o.Provider.URL)
+	o.Provider.User = gitconfig.GetString(cfg, "go-githooks", "worklog", "user", o.Provider.User)
+	o.Provider.Token = gitconfig.GetString(cfg, "go-githooks", "worklog", "token", o.Provider.Token)
+	o.Provider.Command = gitconfig.GetString(cfg, "go-githooks", "worklog", "command", o.Provider.Command)
+	o.Template = gitconfig.GetString(cfg, "go-githooks", "worklog", "template", o.Template)
+	o.TicketPattern = gitconfig.GetString(cfg, "go-githooks", "worklog", "ticketPattern", o.TicketPattern)
+	o.Mode = WorklogModeFromString(gitconfig.GetString(cfg, "go-githooks", "worklog", "mode", string(o.Mode)))
+	o.Async = gitconfig.GetBool(cfg, "go-githooks", "worklog", "async", o.Async)
+	if d := gitconfig.GetString(cfg, "go-githooks", "worklog", "delay", ""); d != "" {
+		if o.Delay, err = time.ParseDuration(d); err != nil {
+			return exitcode.Wrap(exitcode.Config, fmt.Errorf("could not parse worklog delay '%s': %v", d, err))
+		}
+	}
+
+	o.MobRotate = gitconfig.GetBool(cfg, "go-githooks", "mob", "rotate", o.MobRotate)
+	if o.MobMembers, err = parseMembers(gitconfig.GetAll(cfg, "go-githooks", "mob", "member")); err != nil {
+		return exitcode.Wrap(exitcode.Config, err)
+	}
+	if o.MobEvery, err = gitconfig.GetInt(cfg, "go-githooks", "mob", "every", o.MobEvery); err != nil {
+		return exitcode.Wrap(exitcode.Config, err)
+	}
+	o.MobSession = MobSessionFromString(gitconfig.GetString(cfg, "go-githooks", "mob", "session", string(o.MobSession)))
+	o.MobSwitchIdentity = gitconfig.GetBool(cfg, "go-githooks", "mob", "switchIdentity", o.MobSwitchIdentity)
+	o.MobHandoffCommand = gitconfig.GetString(cfg, "go-githooks", "mob", "handoffCommand", o.MobHandoffCommand)
+	if f := gitconfig.GetString(cfg, "go-githooks", "pairing", "file", ""); f != "" && o.PairingFile != "" {
+		if !filepath.IsAbs(f) {
+			f = filepath.Join(filepath.Dir(o.PairingFile), f)
+		}
+		o.PairingFile = f
+	}
+	return nil
+}
+
+func (o *PostCommitOptions) Execute() error {
+	if !o.Flush {
+		if err := o.handOff(); err != nil {
+			return err
+		}
+	}
+	if !o.Enabled {
+		return nil
+	}
+	q := worklog.Queue{Dir: bypass.Dir(o.Repo)}
+	if q.Dir == "" {
+		return nil
+	}
+
+	if o.Flush {
+		// give the other commits of a rebase or a quick series time to join the batch
+		time.Sleep(o.Delay)
+		return o.flush(q)
+	}
+
+	entries, err := o.entries()
+	if err != nil || len(entries) == 0 {
+		return err
+	}
+	if readonly.Enabled() {
+		for _, e := range entries {
+			readonly.Report("post-commit", "would log %s to %s: %s", e.ShortSha(), e.Issue, e.Comment)
+		}
+		return nil
+	}
+	if err := q.Append(entries...); err != nil {
+		return err
+	}
+
+	switch {
+	case os.Getenv("GIT_HOOKS_OFFLINE") != "":
+		fmt.Printf("queued %d worklog entries; they are logged by the next commit made online\n", len(entries))
+		return nil
+	case o.Async:
+		return flushInBackground(q.Dir)
+	}
+	return o.flush(q)
+}
+
+func (o *PostCommitOptions) flush(q worklog.Queue) error {
+	token, err := secrets.Resolve(o.Provider.Token)
+	if err != nil {
+		return exitcode.Wrap(exitcode.Config, fmt.Errorf("could not resolve the worklog token: %v", err))
+	}
+	c := o.Provider
+	c.Token = token
+	p, err := worklog.Open(c)
+	if err != nil {
+		return exitcode.Wrap(exitcode.Config, err)
+	}
+
+	sent, err := q.Flush(p)
+	if sent > 0 {
+		fmt.Printf("logged %d worklog entries\n", sent)
+	}
+	if err != nil {
+		return exitcode.Wrap(exitcode.Dependency, err)
+	}
+	return nil
+}
+
+// entries returns what to log for HEAD, one entry per issue it references
+func (o *PostCommitOptions) entries() ([]worklog.Entry, error) {
+	ticket, err := regexp.Compile(o.TicketPattern)
+	if err != nil {
+		return nil, exitcode.Wrap(exitcode.Config, fmt.Errorf("could not compile worklog ticketPattern: %v", err))
+	}
+	head, err := o.Repo.Head()
+	if err != nil {
+		return nil, fmt.Errorf("could not read HEAD: %v", err)
+	}
+	c, err := o.Repo.CommitObject(head.Hash())
+	if err != nil {
+		return nil, fmt.Errorf("could not read commit %s: %v", head.Hash(), err)
+	}
+
+	branch := ""
+	if head.Name().IsBranch() {
+		branch = head.Name().Short()
+	}
+	subject := strings.SplitN(strings.TrimSpace(c.Message), "\n", 2)[0]
+
+	entries := make([]worklog.Entry, 0)
+	seen := map[string]bool{}
+	for _, issue := range ticket.FindAllString(c.Message, -1) {
+		if seen[issue] {
+			continue
+		}
+		seen[issue] = true
+
+		e := worklog.Entry{
+			Issue:     issue,
+			Sha:       c.Hash.String(),
+			Subject:   subject,
+			Branch:    branch,
+			Author:    c.Author.Name,
+			When:      c.Author.When,
+			TimeSpent: smartcommit.TimeSpent([]byte(c.Message), issue),
+		}
+		if o.Mode == TimeMode && e.TimeSpent == "" {
+			continue
+		}
+		e.Comment = e.Expand(o.Template)
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+// flushInBackground starts '<this hook> flush' without waiting for it; what it
+// prints goes to worklog.log next to the queue
+func flushInBackground(dir string) error {
+	self, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("could not find the post-commit hook to flush the worklog: %v", err)
+	}
+	logFile, err := os.OpenFile(filepath.Join(dir, "worklog.log"), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("could not open the worklog log: %v", err)
+	}
+	defer logFile.Close()
+
+	cmd := exec.Command(self, "flush")
+	cmd.Stdout = logFile
+	cmd.Stderr = logFile
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("could not start the worklog flush: %v", err)
+	}
+	return cmd.Process.Release()
+}
+
+func main() {
+	if updatecheck.Requested() {
+		updatecheck.Run()
+		return
+	}
+
+	argsWithoutProg := os.Args[1:]
+
+	if len(argsWithoutProg) == 1 {
+		switch argsWithoutProg[0] {
+		case "version":
+			printVersion()
+			return
+		case "help":
+			printHelp()
+			return
+		}
+	}
+
+	repo, err := git.PlainOpenWithOptions(".", &git.PlainOpenOptions{DetectDotGit: true})
+	checkError("read git repo", exitcode.Wrap(exitcode.Usage, err))
+	defer crash.Recover(crash.Dir(repo), "post-commit", Version)
+	if err := guilog.Start(repo, "post-commit"); err != nil {
+		fmt.Printf("could not keep the output for GUI clients: %v\n", err)
+	}
+	defer guilog.Stop()
+	updatecheck.Start(repo, Version)
+
+	o := NewOptions(repo)
+
+	err = o.Prepare(argsWithoutProg)
+	checkError("prepare options", err)
+
+	// only asked when there is a handoff command to run
+	if o.MobRotate && o.MobHandoffCommand != "" && !readonly.Enabled() {
+		o.Prompter = prompt.Terminal()
+		defer o.Prompter.Close()
+	}
+
+	err = o.Execute()
+	checkError("executing", readonly.Allow("post-commit", err))
+}
+
+func printVersion() {
+	fmt.Printf("version: %s\n", Version)
+}
+
+func printHelp() {
+	fmt.Printf("help: %s\n", Version)
+	fmt.Printf(`
+configure go-githooks per-repo in .git/config:
+
+[go-githooks "worklog"]
+    enabled = false                    # log each commit to the issues it references
+    provider = jira                    # jira | github | gitlab | command
+    url = https://example.atlassian.net  # jira: the site; github/gitlab: the repo's or project's API url
+    user = mal@serenity.com            # jira cloud: the account the token belongs to
+    token = env:JIRA_TOKEN             # or keyring:<name>, age:<...>; see 'go-githooks secret'
+    command =                          # command provider: run with {"issue", "entries"} as JSON on stdin
+                                       # url, user, token and command are only read from .git/config or
+                                       # ~/.gitconfig, never from .githooks.yml or other shared config
+    template = {shortSha} {subject} (on {branch})  # also {sha}, {author}, {issue}, {time}
+    ticketPattern = [A-Z][A-Z0-9]+-[0-9]+  # e.g. #[0-9]+ for github and gitlab issues
+    mode = comment                     # comment | time: only log commits with e.g. 'ABC-123 #time 2h'
+    async = true                       # false waits for the tracker before the commit returns
+    delay = 2s                         # how long a background flush waits for more commits to batch
+
+entries which could not be logged stay queued in .git/go-githooks/worklog.jsonl and
+are retried by later flushes; run 'post-commit flush' to retry them now. Nothing is
+sent while GIT_HOOKS_OFFLINE is set.
+
+[go-githooks "mob"]
+    rotate = false                     # hand the keyboard to the next member of the mob after each turn
+    member = Zoe Washburne <zoe@serenity.com>  # one per member, in driving order (default: the commit's author, then its coauthors)
+    every = 1                          # the commits each driver makes in a turn
+    session = pairing                  # pairing: rewrite the pairing file | git-mob: rewrite git-mob's coauthors
+    switchIdentity = false             # commit as the next driver from this repo, for a mob sharing one keyboard
+    handoffCommand =                   # e.g. git push && say done; run once confirmed, with GIT_HOOKS_MOB_DRIVER set
+
+`)
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"github.com/davidalpert/go-githooks/pkg/prompt"
+	"github.com/davidalpert/go-githooks/pkg/worklog"
+	"github.com/go-git/go-billy/v5/memfs"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/storage/memory"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func commit(t *testing.T, r *git.Repository, msg string) {
+	w, _ := r.Worktree()
+	_, err := w.Commit(msg, &git.CommitOptions{
+		Author: &object.Signature{Name: "Mal Reynolds", Email: "mal@serenity.com", When: time.Now()},
+	})
+	if err != nil {
+		t.Fatalf("committing: %v", err)
+	}
+}
+
+func TestEntries(t *testing.T) {
+	tests := []struct {
+		name       string
+		msg        string
+		mode       WorklogMode
+		wantIssues []string
+		wantTime   []string
+	}{
+		{
+			name: "no issue",
+			msg:  "fix login\n",
+		},
+		{
+			name:       "each issue once",
+			msg:        "ABC-1 fix login\n\nalso fixes ABC-2, see ABC-1\n",
+			wantIssues: []string{"ABC-1", "ABC-2"},
+			wantTime:   []string{"", ""},
+		},
+		{
+			name:       "time",
+			msg:        "ABC-1 fix login\n\nABC-1 #time 2h\n",
+			wantIssues: []string{"ABC-1"},
+			wantTime:   []string{"2h"},
+		},
+		{
+			name:       "time mode skips issues without time",
+			msg:        "ABC-1 fix login\n\nABC-2 #time 1d\n",
+			mode:       TimeMode,
+			wantIssues: []string{"ABC-2"},
+			wantTime:   []string{"1d"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, _ := git.Init(memory.NewStorage(), memfs.New())
+			commit(t, r, tt.msg)
+
+			o := NewOptions(r)
+			o.setDefaultOptions()
+			if tt.mode != "" {
+				o.Mode = tt.mode
+			}
+			entries, err := o.entries()
+			assert.NoError(t, err)
+
+			issues := make([]string, 0)
+			times := make([]string, 0)
+			for _, e := range entries {
+				issues = append(issues, e.Issue)
+				times = append(times, e.TimeSpent)
+				assert.Equal(t, e.ShortSha()+" "+strings.SplitN(tt.msg, "\n", 2)[0]+" (on master)", e.Comment)
+			}
+			if tt.wantIssues == nil {
+				assert.Empty(t, entries)
+				return
+			}
+			assert.Equal(t, tt.wantIssues, issues)
+			assert.Equal(t, tt.wantTime, times)
+		})
+	}
+}
+
+func TestExecute(t *testing.T) {
+	dir, err := ioutil.TempDir("", "post-commit")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	r, err := git.PlainInit(dir, false)
+	assert.NoError(t, err)
+	commit(t, r, "ABC-1 fix login\n")
+
+	logged := filepath.Join(dir, "logged.json")
+	o := NewOptions(r)
+	o.setDefaultOptions()
+	o.Enabled = true
+	o.Async = false
+	o.Provider = worklog.Config{Provider: "command", Command: "cat >> " + logged}
+
+	assert.NoError(t, o.Execute())
+	out, err := ioutil.ReadFile(logged)
+	assert.NoError(t, err)
+	assert.Contains(t, string(out), `"issue":"ABC-1"`)
+	assert.Contains(t, string(out), `fix login (on master)`)
+
+	pending, err := worklog.Queue{Dir: filepath.Join(dir, ".git", "go-githooks")}.Pending()
+	assert.NoError(t, err)
+	assert.Empty(t, pending)
+}
+
+func TestExecuteKeepsFailures(t *testing.T) {
+	dir, err := ioutil.TempDir("", "post-commit")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	r, err := git.PlainInit(dir, false)
+	assert.NoError(t, err)
+	commit(t, r, "ABC-1 fix login\n")
+
+	o := NewOptions(r)
+	o.setDefaultOptions()
+	o.Enabled = true
+	o.Async = false
+	o.Provider = worklog.Config{Provider: "command", Command: "exit 1"}
+
+	assert.Error(t, o.Execute())
+	pending, err := worklog.Queue{Dir: filepath.Join(dir, ".git", "go-githooks")}.Pending()
+	assert.NoError(t, err)
+	assert.Len(t, pending, 1)
+}
+
+func TestHandOff(t *testing.T) {
+	dir, err := ioutil.TempDir("", "post-commit")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	r, err := git.PlainInit(dir, false)
+	assert.NoError(t, err)
+	commit(t, r, "ABC-1 fix login\n\nCo-authored-by: Zoe Washburne <zoe@serenity.com>\nCo-authored-by: Hoban Washburne <wash@serenity.com>\n")
+
+	handedOff := filepath.Join(dir, "handed-off")
+	o := NewOptions(r)
+	o.setDefaultOptions()
+	o.MobRotate = true
+	o.MobSwitchIdentity = true
+	o.MobHandoffCommand = `echo "$GIT_HOOKS_MOB_DRIVER" > ` + handedOff
+	o.Prompter = prompt.New(strings.NewReader("y\n"), ioutil.Discard)
+
+	assert.NoError(t, o.Execute())
+	pairingFile, err := ioutil.ReadFile(filepath.Join(dir, ".pairing"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(pairingFile), "Mal Reynolds <mal@serenity.com>\nHoban Washburne <wash@serenity.com>\n")
+	assert.NotContains(t, string(pairingFile), "zoe@serenity.com")
+
+	cfg, _ := r.Config()
+	assert.Equal(t, "zoe@serenity.com", cfg.User.Email)
+	out, err := ioutil.ReadFile(handedOff)
+	assert.NoError(t, err)
+	assert.Equal(t, "Zoe Washburne <zoe@serenity.com>\n", string(out))
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"fmt"
+	"github.com/davidalpert/go-githooks/pkg/fileio"
+	"github.com/davidalpert/go-githooks/pkg/message"
+	"github.com/davidalpert/go-githooks/pkg/mobsession"
+	"github.com/davidalpert/go-githooks/pkg/readonly"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// MobSession chooses where the coauthors of the next commits are kept
+type MobSession string
+
+const (
+	PairingSession MobSession = "pairing" // the pairing file read by the 'file' pairing provider
+	GitMobSession  MobSession = "git-mob" // git-mob's git-mob.co-author entries in the global config
+)
+
+func MobSessionFromString(s string) MobSession {
+	if MobSession(strings.ToLower(s)) == GitMobSession {
+		return GitMobSession
+	}
+	return PairingSession
+}
+
+var memberRe = regexp.MustCompile(`^\s*([^<]*?)\s*<([^>]+)>\s*$`)
+
+// parseMembers reads 'Name <email>' entries
+func parseMembers(entries []string) ([]message.Coauthor, error) {
+	members := make([]message.Coauthor, 0, len(entries))
+	for _, e := range entries {
+		m := memberRe.FindStringSubmatch(e)
+		if m == nil {
+			return nil, fmt.Errorf("expected mob member 'Name <email>', got '%s'", e)
+		}
+		members = append(members, message.Coauthor{Name: m[1], Email: m[2]})
+	}
+	return members, nil
+}
+
+// handOff counts HEAD towards its author's turn at the keyboard and, once the turn
+// is over, makes the next member of the mob the driver: their coauthors become the
+// rest of the mob, and the handoff command runs once the mob agrees to it
+func (o *PostCommitOptions) handOff() error {
+	if !o.MobRotate || o.MobRotationFile == "" {
+		return nil
+	}
+	head, err := o.Repo.Head()
+	if err != nil {
+		return fmt.Errorf("could not read HEAD: %v", err)
+	}
+	c, err := o.Repo.CommitObject(head.Hash())
+	if err != nil {
+		return fmt.Errorf("could not read commit %s: %v", head.Hash(), err)
+	}
+
+	members := o.MobMembers
+	if len(members) == 0 {
+		// the mob which made this commit, driver first
+		members = append([]message.Coauthor{{Name: c.Author.Name, Email: c.Author.Email}}, message.Coauthors([]byte(c.Message))...)
+	}
+	emails := make([]string, 0, len(members))
+	for _, m := range members {
+		emails = append(emails, m.Email)
+	}
+	next, handoff, err := mobsession.Advance(o.MobRotationFile, emails, c.Author.Email, o.MobEvery)
+	if err != nil || !handoff {
+		return err
+	}
+
+	driver := members[next]
+	others := make([]message.Coauthor, 0, len(members)-1)
+	for i, m := range members {
+		if i != next {
+			others = append(others, m)
+		}
+	}
+	if readonly.Enabled() {
+		readonly.Report("post-commit", "would hand off to %s <%s>", driver.Name, driver.Email)
+		return nil
+	}
+
+	fmt.Printf("go-githooks: handoff! %s <%s> drives next\n", driver.Name, driver.Email)
+	if err := o.updateMobSession(driver, others); err != nil {
+		return err
+	}
+	if o.MobHandoffCommand == "" || !o.Prompter.Confirm(fmt.Sprintf("run '%s' to hand off?", o.MobHandoffCommand)) {
+		return nil
+	}
+	cmd := exec.Command("sh", "-c", o.MobHandoffCommand)
+	cmd.Env = append(os.Environ(), fmt.Sprintf("GIT_HOOKS_MOB_DRIVER=%s <%s>", driver.Name, driver.Email))
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("could not run the mob handoff command: %v", err)
+	}
+	return nil
+}
+
+// updateMobSession credits others on the next commits and, for a mob sharing one
+// keyboard, commits as driver
+func (o *PostCommitOptions) updateMobSession(driver message.Coauthor, others []message.Coauthor) error {
+	switch o.MobSession {
+	case GitMobSession:
+		_ = exec.Command("git", "config", "--global", "--unset-all", "git-mob.co-author").Run()
+		for _, m := range others {
+			if out, err := exec.Command("git", "config", "--global", "--add", "git-mob.co-author", fmt.Sprintf("%s <%s>", m.Name, m.Email)).CombinedOutput(); err != nil {
+				return fmt.Errorf("could not update the git-mob coauthors: %v: %s", err, strings.TrimSpace(string(out)))
+			}
+		}
+	default:
+		if o.PairingFile == "" {
+			break
+		}
+		lines := []string{"# the mob, as of the last handoff; the driver commits as themselves"}
+		for _, m := range others {
+			lines = append(lines, fmt.Sprintf("%s <%s>", m.Name, m.Email))
+		}
+		if err := fileio.ReplaceFile(o.PairingFile, []byte(strings.Join(lines, "\n")+"\n"), 0644); err != nil {
+			return fmt.Errorf("could not write '%s': %v", filepath.Base(o.PairingFile), err)
+		}
+	}
+
+	if !o.MobSwitchIdentity {
+		return nil
+	}
+	cfg, err := o.Repo.Config()
+	if err != nil {
+		return err
+	}
+	cfg.User.Name = driver.Name
+	cfg.User.Email = driver.Email
+	return o.Repo.SetConfig(cfg)
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/davidalpert/go-githooks/pkg/crash"
+	"github.com/davidalpert/go-githooks/pkg/exitcode"
+	"github.com/davidalpert/go-githooks/pkg/gitconfig"
+	"github.com/davidalpert/go-githooks/pkg/guilog"
+	"github.com/davidalpert/go-githooks/pkg/mailmap"
+	"github.com/davidalpert/go-githooks/pkg/presets"
+	"github.com/davidalpert/go-githooks/pkg/readonly"
+	"github.com/davidalpert/go-githooks/pkg/updatecheck"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"io"
+	"os"
+	"strings"
+)
+
+var (
+	Version = "n/a"
+)
+
+/*
+ * The post-rewrite hook is run by commands that replace commits, such as git commit
+ * --amend and git rebase. Its first argument denotes the command it was invoked by:
+ * currently one of amend or rebase. Git passes the list of rewritten commits on stdin,
+ * one '<old-sha> <new-sha>' pair per line; a squash maps several old commits to one.
+ * It cannot affect the outcome of the rewrite.
+ *
+ * reference: https://git-scm.com/docs/githooks#_post_rewrite
+ */
+type PostRewriteOptions struct {
+	// 1 positional arg provided by git
+	Command string
+
+	Repo     *git.Repository
+	Rewrites []Rewrite
+
+	// these are configuration options, set through git config
+	TrailerPolicy TrailerPolicy
+	TrailerKeys   []string
+
+	Mailmap *mailmap.Mailmap
+}
+
+// Rewrite is one line of what git passes to the post-rewrite hook on stdin
+type Rewrite struct {
+	Old plumbing.Hash
+	New plumbing.Hash
+}
+
+func NewOptions(repo *git.Repository) *PostRewriteOptions {
+	return &PostRewriteOptions{
+		Repo: repo,
+	}
+}
+
+func (o *PostRewriteOptions) Prepare(args []string, stdin io.Reader) error {
+	if len(args) != 1 {
+		return exitcode.Wrap(exitcode.Usage, fmt.Errorf("expected 'version' or 1 arg (amend or rebase), got %d: %v", len(args), args))
+	}
+	o.Command = args[0]
+
+	o.setDefaultOptions()
+	if err := o.overrideFromRepo(); err != nil {
+		return exitcode.Wrap(exitcode.Config, err)
+	}
+
+	var err error
+	o.Rewrites, err = parseRewrites(stdin)
+	return err
+}
+
+func (o *PostRewriteOptions) setDefaultOptions() {
+	o.TrailerPolicy = TrailersOff
+	o.TrailerKeys = []string{"Co-authored-by", "Signed-off-by"}
+}
+
+func (o *PostRewriteOptions) overrideFromRepo() error {
+	cfg, err := gitconfig.Load(o.Repo)
+	if err != nil {
+		return nil
+	}
+	if err = presets.Apply(cfg); err != nil {
+		return err
+	}
+
+	if o.TrailerPolicy, err = TrailerPolicyFromString(gitconfig.GetString(cfg, "go-githooks", "post-rewrite", "trailerPolicy", string(o.TrailerPolicy))); err != nil {
+		return err
+	}
+	o.TrailerKeys = gitconfig.GetSlice(cfg, "go-githooks", "post-rewrite", "trailerKeys", o.TrailerKeys)
+	if gitconfig.GetBool(cfg, "go-githooks", "", "mailmap", true) {
+		if o.Mailmap, err = mailmap.Load(o.Repo, cfg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (o *PostRewriteOptions) Execute() error {
+	if o.TrailerPolicy == TrailersOff || len(o.Rewrites) == 0 {
+		return nil
+	}
+
+	n, err := o.reconcileTrailers()
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		fmt.Printf("go-githooks: reconciled trailers (%s) on %d rewritten commit(s)\n", o.TrailerPolicy, n)
+	}
+	return nil
+}
+
+func parseRewrites(r io.Reader) ([]Rewrite, error) {
+	rewrites := make([]Rewrite, 0)
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		fields := strings.Fields(s.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) < 2 {
+			return nil, fmt.Errorf("could not parse post-rewrite line '%s'", s.Text())
+		}
+		rewrites = append(rewrites, Rewrite{Old: plumbing.NewHash(fields[0]), New: plumbing.NewHash(fields[1])})
+	}
+	return rewrites, s.Err()
+}
+
+func main() {
+	if updatecheck.Requested() {
+		updatecheck.Run()
+		return
+	}
+
+	argsWithoutProg := os.Args[1:]
+
+	if len(argsWithoutProg) == 1 {
+		switch argsWithoutProg[0] {
+		case "version":
+			printVersion()
+			return
+		case "help":
+			printHelp()
+			return
+		}
+	}
+
+	repo, err := git.PlainOpenWithOptions(".", &git.PlainOpenOptions{DetectDotGit: true})
+	checkError("read git repo", exitcode.Wrap(exitcode.Usage, err))
+	defer crash.Recover(crash.Dir(repo), "post-rewrite", Version)
+	if err := guilog.Start(repo, "post-rewrite"); err != nil {
+		fmt.Printf("could not keep the output for GUI clients: %v\n", err)
+	}
+	defer guilog.Stop()
+	updatecheck.Start(repo, Version)
+
+	o := NewOptions(repo)
+
+	err = o.Prepare(argsWithoutProg, os.Stdin)
+	checkError("prepare options", err)
+
+	err = o.Execute()
+	checkError("post-rewrite", readonly.Allow("post-rewrite", err))
+}
+
+func printVersion() {
+	fmt.Printf("version: %s\n", Version)
+}
+
+func printHelp() {
+	fmt.Printf("help: %s\n", Version)
+	fmt.Printf(`
+configure go-githooks per-repo in .git/config:
+
+[go-githooks "post-rewrite"]
+    trailerPolicy = off          # off | dedupe | preserve | merge (see below)
+    trailerKeys = Co-authored-by,Signed-off-by
+
+trailer policies, applied to commits rewritten by 'commit --amend' and 'rebase':
+    dedupe     remove repeated trailer lines
+    preserve   also restore trailerKeys lost from the commit that was rewritten (the first, when squashing)
+    merge      also collect trailerKeys from every commit squashed together
+
+identities in trailers are compared through the repo's .mailmap (and mailmap.file), so
+the same person committing under two emails is one trailer; set go-githooks.mailmap =
+false to compare them as written.
+
+rewritten commits are re-created with the reconciled message, along with any commits
+on top of them, and the current branch is moved to the result.
+
+`)
+}
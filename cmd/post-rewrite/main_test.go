@@ -0,0 +1,110 @@
+package main
+
+import (
+	"fmt"
+	"github.com/davidalpert/go-githooks/pkg/mailmap"
+	"github.com/go-git/go-billy/v5/memfs"
+	"github.com/go-git/go-billy/v5/util"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/storage/memory"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+	"time"
+)
+
+func commit(t *testing.T, r *git.Repository, file, msg string) plumbing.Hash {
+	w, _ := r.Worktree()
+	if err := util.WriteFile(w.Filesystem, file, []byte(msg), 0644); err != nil {
+		t.Fatalf("writing %s: %v", file, err)
+	}
+	if _, err := w.Add(file); err != nil {
+		t.Fatalf("adding %s: %v", file, err)
+	}
+	h, err := w.Commit(msg, &git.CommitOptions{
+		Author: &object.Signature{Name: "Mal Reynolds", Email: "mal@serenity.com", When: time.Now()},
+	})
+	if err != nil {
+		t.Fatalf("committing: %v", err)
+	}
+	return h
+}
+
+func TestExecuteSquash(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  string
+		wantMsg string
+	}{
+		{
+			name:    "off",
+			policy:  "off",
+			wantMsg: "squashed\n\nCo-authored-by: Zoe Washburne <zoe@serenity.com>\nCo-authored-by: Zoe Washburne <zoe@serenity.com>\n",
+		},
+		{
+			name:    "dedupe",
+			policy:  "dedupe",
+			wantMsg: "squashed\n\nCo-authored-by: Zoe Washburne <zoe@serenity.com>\n",
+		},
+		{
+			name:    "preserve",
+			policy:  "preserve",
+			wantMsg: "squashed\n\nCo-authored-by: Zoe Washburne <zoe@serenity.com>\nCo-authored-by: Wash <wash@serenity.com>\n",
+		},
+		{
+			name:    "merge",
+			policy:  "merge",
+			wantMsg: "squashed\n\nCo-authored-by: Zoe Washburne <zoe@serenity.com>\nCo-authored-by: Wash <wash@serenity.com>\nCo-authored-by: River Tam <river@serenity.com>\nSigned-off-by: Mal Reynolds <mal@serenity.com>\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, _ := git.Init(memory.NewStorage(), memfs.New())
+			cfg, _ := r.Config()
+			_ = cfg.Unmarshal([]byte(fmt.Sprintf("[go-githooks \"post-rewrite\"]\n    trailerPolicy = %s\n", tt.policy)))
+
+			root := commit(t, r, "a.txt", "root")
+			old1 := commit(t, r, "b.txt", "first\n\nCo-authored-by: Wash <wash@serenity.com>\n")
+			old2 := commit(t, r, "c.txt", "second\n\nCo-authored-by: River Tam <river@serenity.com>\nSigned-off-by: Mal Reynolds <mal@serenity.com>\nRefs: FEAT-1\n")
+
+			// the squash, as rebase would leave it: one commit on root with both changes
+			w, _ := r.Worktree()
+			_ = w.Reset(&git.ResetOptions{Commit: root, Mode: git.SoftReset})
+			squashed := commit(t, r, "d.txt", "squashed\n\nCo-authored-by: Zoe Washburne <zoe@serenity.com>\nCo-authored-by: Zoe Washburne <zoe@serenity.com>\n")
+			// a later commit which was rebased on top of the squash
+			tip := commit(t, r, "e.txt", "on top")
+
+			o := NewOptions(r)
+			stdin := fmt.Sprintf("%s %s\n%s %s\n", old1, squashed, old2, squashed)
+			if err := o.Prepare([]string{"rebase"}, strings.NewReader(stdin)); err != nil {
+				t.Fatalf("prepare: %v", err)
+			}
+			if err := o.Execute(); err != nil {
+				t.Fatalf("execute: %v", err)
+			}
+
+			head, _ := r.Head()
+			headCommit, _ := r.CommitObject(head.Hash())
+			assert.Equal(t, "on top", headCommit.Message)
+			parent, _ := headCommit.Parent(0)
+			assert.Equal(t, tt.wantMsg, parent.Message)
+			assert.Equal(t, root, parent.ParentHashes[0])
+			if tt.policy == "off" {
+				assert.Equal(t, tip, head.Hash(), "nothing is rewritten when the policy is off")
+			}
+		})
+	}
+}
+
+func TestReconcileMailmap(t *testing.T) {
+	o := NewOptions(nil)
+	o.setDefaultOptions()
+	o.TrailerPolicy = TrailersMerge
+	o.Mailmap = mailmap.Parse([]byte("Hoban Washburne <wash@serenity.com> <pilot@serenity.com>\n"))
+
+	olds := [][]byte{[]byte("first\n\nCo-authored-by: Wash <pilot@serenity.com>\n")}
+	current := []byte("squashed\n\nCo-authored-by: Hoban Washburne <wash@serenity.com>\nCo-authored-by: Wash <pilot@serenity.com>\n")
+	assert.Equal(t, "squashed\n\nCo-authored-by: Hoban Washburne <wash@serenity.com>\n", string(o.reconcile(olds, current)))
+}
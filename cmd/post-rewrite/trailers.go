@@ -0,0 +1,191 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/davidalpert/go-githooks/pkg/message"
+	"github.com/davidalpert/go-githooks/pkg/readonly"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"strings"
+)
+
+// TrailerPolicy decides how trailers of the old commits carry over to the new ones
+type TrailerPolicy string
+
+const (
+	TrailersOff      TrailerPolicy = "off"
+	TrailersDedupe   TrailerPolicy = "dedupe"
+	TrailersPreserve TrailerPolicy = "preserve"
+	TrailersMerge    TrailerPolicy = "merge"
+)
+
+func TrailerPolicyFromString(s string) (TrailerPolicy, error) {
+	switch p := TrailerPolicy(strings.ToLower(strings.TrimSpace(s))); p {
+	case TrailersOff, TrailersDedupe, TrailersPreserve, TrailersMerge:
+		return p, nil
+	case "":
+		return TrailersOff, nil
+	}
+	return TrailersOff, fmt.Errorf("unknown trailerPolicy '%s', expected one of: off, dedupe, preserve, merge", s)
+}
+
+// reconcile returns the message current should have given the messages of the
+// commits it replaced
+func (o *PostRewriteOptions) reconcile(olds [][]byte, current []byte) []byte {
+	if o.TrailerPolicy == TrailersPreserve && len(olds) > 1 {
+		olds = olds[:1]
+	}
+	if o.TrailerPolicy == TrailersDedupe {
+		olds = nil
+	}
+
+	updated := current
+	for _, old := range olds {
+		for _, t := range message.Trailers(old) {
+			if o.isTrailerKey(t.Key) && !o.hasTrailer(updated, t) {
+				updated = message.AppendTrailer(updated, t.String())
+			}
+		}
+	}
+	updated = message.DedupeTrailersBy(updated, func(t message.Trailer) string {
+		return o.Mailmap.Identity(t.Value)
+	})
+	return append(bytes.TrimSpace(updated), '\n')
+}
+
+func (o *PostRewriteOptions) isTrailerKey(key string) bool {
+	for _, k := range o.TrailerKeys {
+		if strings.EqualFold(strings.TrimSpace(k), key) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasTrailer compares identities by their canonical form in the mailmap
+func (o *PostRewriteOptions) hasTrailer(msg []byte, t message.Trailer) bool {
+	for _, existing := range message.Trailers(msg) {
+		if strings.EqualFold(existing.Key, t.Key) && o.Mailmap.Identity(existing.Value) == o.Mailmap.Identity(t.Value) {
+			return true
+		}
+	}
+	return false
+}
+
+// reconcileTrailers fixes the messages of the rewritten commits, re-creating them and
+// every commit on top of them up to HEAD, and returns how many messages changed
+func (o *PostRewriteOptions) reconcileTrailers() (int, error) {
+	olds := map[plumbing.Hash][][]byte{}
+	for _, r := range o.Rewrites {
+		c, err := o.Repo.CommitObject(r.Old)
+		if err != nil {
+			// the old commit may already be gone, e.g. after a gc
+			continue
+		}
+		olds[r.New] = append(olds[r.New], []byte(c.Message))
+	}
+
+	messages := map[plumbing.Hash]string{}
+	for newHash, oldMessages := range olds {
+		c, err := o.Repo.CommitObject(newHash)
+		if err != nil {
+			return 0, fmt.Errorf("could not read rewritten commit %s: %v", newHash, err)
+		}
+		if updated := string(o.reconcile(oldMessages, []byte(c.Message))); updated != c.Message {
+			messages[newHash] = updated
+		}
+	}
+	if len(messages) == 0 {
+		return 0, nil
+	}
+	if readonly.Enabled() {
+		for h, msg := range messages {
+			c, _ := o.Repo.CommitObject(h)
+			readonly.Changes("post-rewrite", "reworded "+h.String()[:7], []byte(c.Message), []byte(msg))
+		}
+		return 0, nil
+	}
+
+	head, err := o.Repo.Head()
+	if err != nil {
+		return 0, fmt.Errorf("could not read HEAD: %v", err)
+	}
+
+	// only commits on the current branch can be replaced without leaving a copy behind
+	chain := make([]*object.Commit, 0)
+	pending := len(messages)
+	c, err := o.Repo.CommitObject(head.Hash())
+	for err == nil && pending > 0 {
+		chain = append([]*object.Commit{c}, chain...)
+		if _, ok := messages[c.Hash]; ok {
+			pending--
+		}
+		if c.NumParents() == 0 {
+			break
+		}
+		c, err = c.Parent(0)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("could not walk the rewritten history: %v", err)
+	}
+
+	replaced := map[plumbing.Hash]plumbing.Hash{}
+	changed := 0
+	for _, c := range chain {
+		msg, reworded := messages[c.Hash]
+		parents := make([]plumbing.Hash, 0, len(c.ParentHashes))
+		reparented := false
+		for _, p := range c.ParentHashes {
+			if r, ok := replaced[p]; ok {
+				p, reparented = r, true
+			}
+			parents = append(parents, p)
+		}
+		if !reworded && !reparented {
+			continue
+		}
+		if reworded {
+			changed++
+		} else {
+			msg = c.Message
+		}
+
+		h, err := o.storeCommit(c, parents, msg)
+		if err != nil {
+			return 0, err
+		}
+		replaced[c.Hash] = h
+	}
+
+	newHead, ok := replaced[head.Hash()]
+	if !ok {
+		return 0, nil
+	}
+	// head.Name() is the branch, or HEAD itself when detached
+	if err := o.Repo.Storer.SetReference(plumbing.NewHashReference(head.Name(), newHead)); err != nil {
+		return 0, fmt.Errorf("could not update %s: %v", head.Name(), err)
+	}
+	return changed, nil
+}
+
+// storeCommit writes a copy of c with new parents and message; any signature on c
+// no longer matches and is dropped
+func (o *PostRewriteOptions) storeCommit(c *object.Commit, parents []plumbing.Hash, msg string) (plumbing.Hash, error) {
+	commit := &object.Commit{
+		Author:       c.Author,
+		Committer:    c.Committer,
+		Message:      msg,
+		TreeHash:     c.TreeHash,
+		ParentHashes: parents,
+	}
+	obj := o.Repo.Storer.NewEncodedObject()
+	if err := commit.Encode(obj); err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("could not encode commit: %v", err)
+	}
+	h, err := o.Repo.Storer.SetEncodedObject(obj)
+	if err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("could not store commit: %v", err)
+	}
+	return h, nil
+}
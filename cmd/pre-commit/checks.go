@@ -0,0 +1,179 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"github.com/davidalpert/go-githooks/pkg/container"
+	"github.com/davidalpert/go-githooks/pkg/exitcode"
+	"github.com/davidalpert/go-githooks/pkg/gitconfig"
+	"github.com/davidalpert/go-githooks/pkg/rules"
+	"github.com/davidalpert/go-githooks/pkg/staged"
+	"github.com/go-git/go-git/v5/config"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+	"strings"
+)
+
+const (
+	CheckRule        = "check"
+	CheckRuntimeRule = "check-runtime"
+
+	checkSubsectionPrefix = "check."
+)
+
+// OfflineFallback chooses what a check does when its container image is unavailable
+type OfflineFallback string
+
+const (
+	OfflineHost OfflineFallback = "host" // run the command on the host instead
+	OfflineSkip OfflineFallback = "skip" // skip the check with a warning
+	OfflineFail OfflineFallback = "fail" // fail the commit
+)
+
+func OfflineFallbackFromString(s string) OfflineFallback {
+	switch OfflineFallback(strings.ToLower(s)) {
+	case OfflineSkip:
+		return OfflineSkip
+	case OfflineFail:
+		return OfflineFail
+	}
+	return OfflineHost
+}
+
+// Check is a command run over the staged tree, configured in a
+// [go-githooks "check.<name>"] section of .git/config or ~/.gitconfig; checks in
+// .githooks.yml, templates or remote config are ignored, see gitconfig.UserOnly
+type Check struct {
+	Name    string
+	Run     []string
+	Files   []string // globs; the check only runs when a staged file matches one
+	Runtime container.Runtime
+	Offline OfflineFallback
+}
+
+func parseChecks(cfg *config.Config) ([]Check, error) {
+	checks := make([]Check, 0)
+	if !cfg.Raw.HasSection("go-githooks") {
+		return checks, nil
+	}
+	for _, sub := range cfg.Raw.Section("go-githooks").Subsections {
+		if !strings.HasPrefix(sub.Name, checkSubsectionPrefix) {
+			continue
+		}
+		c := Check{
+			Name:    strings.TrimPrefix(sub.Name, checkSubsectionPrefix),
+			Run:     strings.Fields(sub.Options.Get("run")),
+			Offline: OfflineFallbackFromString(sub.Options.Get("offline")),
+		}
+		if len(c.Run) == 0 {
+			return nil, fmt.Errorf("check '%s' has no command to run", c.Name)
+		}
+		if files := gitconfig.List(sub.Options.GetAll("files")); len(files) > 0 {
+			c.Files = files
+		}
+		var err error
+		if c.Runtime, err = container.Parse(sub.Options.Get("runtime")); err != nil {
+			return nil, fmt.Errorf("check '%s': %v", c.Name, err)
+		}
+		checks = append(checks, c)
+	}
+	sort.Slice(checks, func(i, j int) bool {
+		return checks[i].Name < checks[j].Name
+	})
+	return checks, nil
+}
+
+// checkCommands runs each configured check over an export of the staged tree, so
+// the result does not depend on unstaged or untracked files
+func (o *PreCommitOptions) checkCommands() []rules.Violation {
+	checks := make([]Check, 0)
+	for _, c := range o.Checks {
+		if o.checkApplies(c) {
+			checks = append(checks, c)
+		}
+	}
+	if len(checks) == 0 {
+		return nil
+	}
+
+	dir, err := ioutil.TempDir("", "go-githooks-checks")
+	if err != nil {
+		return []rules.Violation{{Rule: CheckRule, Severity: rules.Error, Message: err.Error()}}
+	}
+	defer os.RemoveAll(dir)
+	if err := staged.Export(o.Repo, dir); err != nil {
+		return []rules.Violation{{Rule: CheckRule, Severity: rules.Error, Message: err.Error()}}
+	}
+
+	violations := make([]rules.Violation, 0)
+	for _, c := range checks {
+		runtime := c.Runtime
+		if err := runtime.Ensure(); err != nil {
+			if !errors.Is(err, container.ErrUnavailable) || c.Offline == OfflineFail {
+				violations = append(violations, rules.Violation{Rule: CheckRule, Severity: rules.Error, Location: c.Name, Message: err.Error()})
+				continue
+			}
+			if c.Offline == OfflineSkip {
+				violations = append(violations, rules.Violation{Rule: CheckRuntimeRule, Severity: rules.Warning, Location: c.Name, Message: fmt.Sprintf("was skipped: %v", err)})
+				continue
+			}
+			violations = append(violations, rules.Violation{Rule: CheckRuntimeRule, Severity: rules.Warning, Location: c.Name, Message: fmt.Sprintf("ran on the host instead: %v", err)})
+			runtime = container.Runtime{}
+		}
+
+		out, err := runtime.Command(dir, c.Run).CombinedOutput()
+		if err != nil {
+			violations = append(violations, rules.Violation{
+				Rule:     CheckRule,
+				Severity: rules.Error,
+				Location: c.Name,
+				Message:  fmt.Sprintf("'%s' failed on %s: %v\n%s", strings.Join(c.Run, " "), runtime, err, strings.TrimSpace(string(out))),
+			})
+		}
+	}
+	return violations
+}
+
+// checkApplies is true for checks without file globs, or when a staged file which
+// is not generated matches one of them by path or by name
+func (o *PreCommitOptions) checkApplies(c Check) bool {
+	if len(c.Files) == 0 {
+		return true
+	}
+	for _, f := range o.filesFor(CheckRule) {
+		if o.Generated[f.Path] {
+			continue
+		}
+		for _, glob := range c.Files {
+			glob = strings.TrimSpace(glob)
+			if ok, _ := path.Match(glob, f.Path); ok {
+				return true
+			}
+			if ok, _ := path.Match(glob, path.Base(f.Path)); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// PullImages pre-pulls the image of every check which runs in a container, e.g.
+// before going offline or after bumping an image tag
+func (o *PreCommitOptions) PullImages() error {
+	o.setDefaultOptions()
+	if err := o.overrideFromRepo(); err != nil {
+		return exitcode.Wrap(exitcode.Config, err)
+	}
+	for _, c := range o.Checks {
+		if c.Runtime.IsHost() {
+			continue
+		}
+		fmt.Printf("pulling %s for %s\n", c.Runtime.Image, c.Name)
+		if err := c.Runtime.Pull(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
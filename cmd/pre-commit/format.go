@@ -0,0 +1,205 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/davidalpert/go-githooks/pkg/budget"
+	"github.com/davidalpert/go-githooks/pkg/readonly"
+	"github.com/davidalpert/go-githooks/pkg/rules"
+	"github.com/davidalpert/go-githooks/pkg/staged"
+	"github.com/go-git/go-billy/v5/util"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	FormatRule           = "formatting"
+	FormatterMissingRule = "formatter-missing"
+)
+
+// FormatMode chooses whether unformatted staged files are reported or rewritten
+type FormatMode string
+
+const (
+	FormatCheck FormatMode = "check" // report files the formatter would change
+	FormatFix   FormatMode = "fix"   // format and re-stage them
+)
+
+func FormatModeFromString(s string) FormatMode {
+	if FormatMode(strings.ToLower(s)) == FormatFix {
+		return FormatFix
+	}
+	return FormatCheck
+}
+
+// Formatter is a tool which rewrites a file in place when given its path
+type Formatter struct {
+	Name        string
+	Extensions  []string
+	Command     []string // the path of the file to format is appended
+	InstallHint string
+}
+
+// KnownFormatters are the formatters which can be enabled by name; where several
+// handle an extension the first one found on PATH is used
+var KnownFormatters = []Formatter{
+	{Name: "goimports", Extensions: []string{".go"}, Command: []string{"goimports", "-w"}, InstallHint: "go install golang.org/x/tools/cmd/goimports@latest"},
+	{Name: "gofmt", Extensions: []string{".go"}, Command: []string{"gofmt", "-w"}, InstallHint: "install go from https://go.dev/dl/"},
+	{Name: "prettier", Extensions: []string{".js", ".jsx", ".ts", ".tsx", ".css", ".scss", ".md", ".html"}, Command: []string{"prettier", "--write", "--log-level", "warn"}, InstallHint: "npm install --global prettier"},
+	{Name: "black", Extensions: []string{".py"}, Command: []string{"black", "--quiet"}, InstallHint: "pip install black"},
+	{Name: "rustfmt", Extensions: []string{".rs"}, Command: []string{"rustfmt"}, InstallHint: "rustup component add rustfmt"},
+}
+
+func formattersByName(names []string) ([]Formatter, error) {
+	formatters := make([]Formatter, 0, len(names))
+	for _, n := range names {
+		n = strings.TrimSpace(n)
+		found := false
+		for _, f := range KnownFormatters {
+			if f.Name == n {
+				formatters = append(formatters, f)
+				found = true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown formatter '%s'", n)
+		}
+	}
+	return formatters, nil
+}
+
+// checkFormatting runs each staged file through the first available formatter for
+// its extension; the staged content is formatted in a scratch copy so files which
+// are only partly staged are judged by what will be committed
+func (o *PreCommitOptions) checkFormatting() []rules.Violation {
+	if o.Severities.For(FormatRule, rules.Off) == rules.Off || !o.Budget.Allows(FormatRule, budget.Normal) {
+		return nil
+	}
+
+	violations := make([]rules.Violation, 0)
+	missing := map[string][]string{}
+	for _, f := range o.filesFor(FormatRule, FormatterMissingRule) {
+		if o.Generated[f.Path] {
+			continue
+		}
+		ext := path.Ext(f.Path)
+		formatter, ok := o.formatterFor(ext, missing)
+		if !ok {
+			continue
+		}
+
+		before, err := f.Contents(o.Repo)
+		if err != nil {
+			violations = append(violations, formatViolation(f.Path, err.Error()))
+			continue
+		}
+		after, err := format(formatter, f.Path, before)
+		if err != nil {
+			violations = append(violations, formatViolation(f.Path, fmt.Sprintf("%s failed: %v", formatter.Name, err)))
+			continue
+		}
+		if bytes.Equal(before, after) {
+			continue
+		}
+
+		if o.FormatMode == FormatFix && readonly.Enabled() {
+			readonly.Report("pre-commit", "formatted and restaged %s with %s", f.Path, formatter.Name)
+			continue
+		}
+		if o.FormatMode == FormatFix {
+			if err := o.restage(f, before, after); err != nil {
+				violations = append(violations, formatViolation(f.Path, fmt.Sprintf("could not apply %s: %v", formatter.Name, err)))
+			} else {
+				fmt.Printf("formatted %s with %s\n", f.Path, formatter.Name)
+			}
+			continue
+		}
+		violations = append(violations, formatViolation(f.Path, fmt.Sprintf("is not formatted; run: %s %s", strings.Join(formatter.Command, " "), f.Path)))
+	}
+
+	for _, f := range o.Formatters {
+		if exts, ok := missing[f.Name]; ok {
+			violations = append(violations, rules.Violation{
+				Rule:     FormatterMissingRule,
+				Severity: rules.Warning,
+				Message:  fmt.Sprintf("%s is not on PATH, so staged %s files were not checked; install it with: %s", f.Command[0], strings.Join(exts, ", "), f.InstallHint),
+			})
+		}
+	}
+	return violations
+}
+
+// formatterFor picks the first formatter for ext which is installed, noting the
+// ones which are not when none is
+func (o *PreCommitOptions) formatterFor(ext string, missing map[string][]string) (Formatter, bool) {
+	candidates := make([]Formatter, 0)
+	for _, f := range o.Formatters {
+		if stringInSlice(f.Extensions, ext) {
+			candidates = append(candidates, f)
+		}
+	}
+	for _, f := range candidates {
+		if _, err := exec.LookPath(f.Command[0]); err == nil {
+			return f, true
+		}
+	}
+	for _, f := range candidates {
+		if !stringInSlice(missing[f.Name], ext) {
+			missing[f.Name] = append(missing[f.Name], ext)
+		}
+	}
+	return Formatter{}, false
+}
+
+// format runs formatter over a scratch copy of contents named like p, since most
+// formatters pick their rules from the file name
+func format(formatter Formatter, p string, contents []byte) ([]byte, error) {
+	dir, err := ioutil.TempDir("", "go-githooks-format")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	scratch := filepath.Join(dir, filepath.Base(p))
+	if err := ioutil.WriteFile(scratch, contents, 0644); err != nil {
+		return nil, err
+	}
+
+	args := append(append([]string{}, formatter.Command[1:]...), scratch)
+	cmd := exec.Command(formatter.Command[0], args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("%v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return ioutil.ReadFile(scratch)
+}
+
+// restage writes the formatted content to the working tree and the index; a file
+// with unstaged changes is left alone so those changes are not committed by accident
+func (o *PreCommitOptions) restage(f staged.File, before, after []byte) error {
+	w, err := o.Repo.Worktree()
+	if err != nil {
+		return err
+	}
+	current, err := util.ReadFile(w.Filesystem, f.Path)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(current, before) {
+		return fmt.Errorf("it has unstaged changes; stage or stash them first")
+	}
+	if err := util.WriteFile(w.Filesystem, f.Path, after, 0644); err != nil {
+		return err
+	}
+	_, err = w.Add(f.Path)
+	return err
+}
+
+func formatViolation(p, msg string) rules.Violation {
+	return rules.Violation{Rule: FormatRule, Severity: rules.Off, Location: p, Message: msg}
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"github.com/davidalpert/go-githooks/pkg/generated"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+)
+
+// detectGenerated finds the staged files which are generated code, by attribute,
+// path or a marker at the top of their staged content
+func (o *PreCommitOptions) detectGenerated() (map[string]bool, error) {
+	d := generated.Detector{Attributes: o.Attributes, Paths: o.GeneratedPaths, Markers: o.GeneratedMarkers}
+	found := map[string]bool{}
+	for _, f := range o.StagedFiles {
+		// a submodule's commit is not in this repo, so there is nothing to read
+		if f.Mode == filemode.Submodule {
+			continue
+		}
+		header, err := f.Head(o.Repo, generated.HeaderSize)
+		if err != nil {
+			return nil, err
+		}
+		if d.Is(f.Path, header) {
+			found[f.Path] = true
+		}
+	}
+	return found, nil
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"fmt"
+	"github.com/davidalpert/go-githooks/pkg/rules"
+	"github.com/davidalpert/go-githooks/pkg/vcshost"
+	"github.com/go-git/go-git/v5/config"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const IdentityRule = "identity"
+
+// IdentityPolicy requires the author of commits to repos whose remote matches Remote,
+// e.g. github.com/acme/*, to use an email matching one of Emails, e.g. *@acme.com
+type IdentityPolicy struct {
+	Remote string
+	Emails []string
+}
+
+// parseIdentityPolicies reads 'remote=email' values, where both sides are globs in
+// which * stands for anything and the email may list alternatives with |
+func parseIdentityPolicies(values []string) ([]IdentityPolicy, error) {
+	policies := make([]IdentityPolicy, 0, len(values))
+	for _, v := range values {
+		parts := strings.SplitN(v, "=", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" || strings.TrimSpace(parts[1]) == "" {
+			return nil, fmt.Errorf("could not parse identity policy '%s', expected e.g. github.com/acme/*=*@acme.com", v)
+		}
+		p := IdentityPolicy{Remote: strings.TrimSpace(parts[0])}
+		for _, e := range strings.Split(parts[1], "|") {
+			p.Emails = append(p.Emails, strings.ToLower(strings.TrimSpace(e)))
+		}
+		policies = append(policies, p)
+	}
+	return policies, nil
+}
+
+// remotePaths are the repo's remotes as host/org/repo, which identity policies match
+func remotePaths(cfg *config.Config) []string {
+	paths := make([]string, 0, len(cfg.Remotes))
+	for _, rc := range cfg.Remotes {
+		for _, u := range rc.URLs {
+			if r, err := vcshost.Parse(u); err == nil {
+				paths = append(paths, strings.ToLower(strings.Trim(r.Host+"/"+r.Org+"/"+r.Repo, "/")))
+			}
+		}
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// checkIdentity catches a commit authored with the wrong email for the repo, e.g. a
+// personal address on a work repo, under the first policy matching one of its
+// remotes. Git has settled the author by the time pre-commit runs, so the fix sets
+// the repo-local identity for the next attempt
+func (o *PreCommitOptions) checkIdentity() []rules.Violation {
+	var policy *IdentityPolicy
+	for i, p := range o.IdentityPolicies {
+		for _, r := range o.RemotePaths {
+			if matchesGlob(strings.ToLower(p.Remote), r) {
+				policy = &o.IdentityPolicies[i]
+				break
+			}
+		}
+		if policy != nil {
+			break
+		}
+	}
+	if policy == nil {
+		return nil
+	}
+	for _, e := range policy.Emails {
+		if matchesGlob(e, strings.ToLower(o.Author)) {
+			return nil
+		}
+	}
+
+	v := rules.Violation{
+		Rule:     IdentityRule,
+		Severity: rules.Error,
+		Location: "user.email",
+		Message:  fmt.Sprintf("commits to %s are authored as %s, not '%s'", policy.Remote, strings.Join(policy.Emails, " or "), o.Author),
+	}
+	if o.IdentityEmail == "" {
+		v.Message += "; set this repo's identity with 'git config user.email <email>' and commit again"
+		return []rules.Violation{v}
+	}
+
+	identity := o.IdentityEmail
+	if o.IdentityName != "" {
+		identity = fmt.Sprintf("%s <%s>", o.IdentityName, o.IdentityEmail)
+	}
+	if !o.Prompter.Confirm(fmt.Sprintf("set this repo's identity to %s?", identity)) {
+		v.Message += fmt.Sprintf("; set this repo's identity with 'git config user.email %s' and commit again", o.IdentityEmail)
+		return []rules.Violation{v}
+	}
+	if err := o.setLocalIdentity(); err != nil {
+		v.Message += fmt.Sprintf("; could not set this repo's identity: %v", err)
+		return []rules.Violation{v}
+	}
+	v.Message += fmt.Sprintf("; this repo's identity is now %s, commit again", identity)
+	return []rules.Violation{v}
+}
+
+// setLocalIdentity writes identity.name and identity.email to the repo's own config
+func (o *PreCommitOptions) setLocalIdentity() error {
+	cfg, err := o.Repo.Config()
+	if err != nil {
+		return err
+	}
+	cfg.User.Email = o.IdentityEmail
+	if o.IdentityName != "" {
+		cfg.User.Name = o.IdentityName
+	}
+	return o.Repo.SetConfig(cfg)
+}
+
+// matchesGlob matches s against pattern where * stands for anything
+func matchesGlob(pattern, s string) bool {
+	re := "^" + strings.Replace(regexp.QuoteMeta(pattern), `\*`, ".*", -1) + "$"
+	matched, _ := regexp.MatchString(re, s)
+	return matched
+}
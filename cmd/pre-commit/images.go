@@ -0,0 +1,213 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/davidalpert/go-githooks/pkg/lfs"
+	"github.com/davidalpert/go-githooks/pkg/readonly"
+	"github.com/davidalpert/go-githooks/pkg/rules"
+	"github.com/davidalpert/go-githooks/pkg/staged"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"os/exec"
+	"path"
+	"strconv"
+	"strings"
+)
+
+const ImageRule = "image-budget"
+
+// ImageExtensions are the staged files checked against the image budget
+var ImageExtensions = []string{".png", ".jpg", ".jpeg", ".gif", ".svg", ".webp"}
+
+// KnownOptimizers rewrite an image in place, losslessly or close to it, when imageMode
+// is fix; where several handle an extension the first one found on PATH is used
+var KnownOptimizers = []Formatter{
+	{Name: "pngquant", Extensions: []string{".png"}, Command: []string{"pngquant", "--force", "--skip-if-larger", "--ext", ".png"}, InstallHint: "brew install pngquant, or apt install pngquant"},
+	{Name: "oxipng", Extensions: []string{".png"}, Command: []string{"oxipng", "--quiet"}, InstallHint: "cargo install oxipng"},
+	{Name: "jpegoptim", Extensions: []string{".jpg", ".jpeg"}, Command: []string{"jpegoptim", "--quiet", "--strip-all"}, InstallHint: "brew install jpegoptim, or apt install jpegoptim"},
+	{Name: "svgo", Extensions: []string{".svg"}, Command: []string{"svgo", "--quiet"}, InstallHint: "npm install --global svgo"},
+}
+
+func optimizersByName(names []string) ([]Formatter, error) {
+	optimizers := make([]Formatter, 0, len(names))
+	for _, n := range names {
+		n = strings.TrimSpace(n)
+		found := false
+		for _, f := range KnownOptimizers {
+			if f.Name == n {
+				optimizers = append(optimizers, f)
+				found = true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown image optimizer '%s'", n)
+		}
+	}
+	return optimizers, nil
+}
+
+// Dimensions is a width and height in pixels; 0 leaves that side unlimited
+type Dimensions struct {
+	Width  int
+	Height int
+}
+
+// ParseDimensions reads 'WIDTHxHEIGHT', e.g. 4096x4096; "" means no limit
+func ParseDimensions(s string) (Dimensions, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return Dimensions{}, nil
+	}
+	parts := strings.Split(strings.ToLower(s), "x")
+	if len(parts) != 2 {
+		return Dimensions{}, fmt.Errorf("could not parse '%s' as WIDTHxHEIGHT", s)
+	}
+	w, err := strconv.Atoi(strings.TrimSpace(parts[0]))
+	if err != nil {
+		return Dimensions{}, fmt.Errorf("could not parse '%s' as WIDTHxHEIGHT: %v", s, err)
+	}
+	h, err := strconv.Atoi(strings.TrimSpace(parts[1]))
+	if err != nil {
+		return Dimensions{}, fmt.Errorf("could not parse '%s' as WIDTHxHEIGHT: %v", s, err)
+	}
+	return Dimensions{Width: w, Height: h}, nil
+}
+
+func (d Dimensions) String() string {
+	return fmt.Sprintf("%dx%d", d.Width, d.Height)
+}
+
+// ParseByteSize reads a size such as 1048576, 500k or 2MB, in powers of 1024
+func ParseByteSize(s string) (int64, error) {
+	v := strings.ToUpper(strings.TrimSpace(s))
+	v = strings.TrimSuffix(v, "B")
+	multiplier := int64(1)
+	for suffix, m := range map[string]int64{"K": 1 << 10, "M": 1 << 20, "G": 1 << 30} {
+		if strings.HasSuffix(v, suffix) {
+			multiplier = m
+			v = strings.TrimSuffix(v, suffix)
+		}
+	}
+	n, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("could not parse '%s' as a size, e.g. 500k or 2MB", s)
+	}
+	return int64(n * float64(multiplier)), nil
+}
+
+// formatBytes renders n the way sizes are configured, e.g. 1.5MB
+func formatBytes(n int64) string {
+	switch {
+	case n >= 1<<20:
+		return strconv.FormatFloat(float64(n)/(1<<20), 'f', 1, 64) + "MB"
+	case n >= 1<<10:
+		return strconv.FormatFloat(float64(n)/(1<<10), 'f', 1, 64) + "KB"
+	}
+	return fmt.Sprintf("%dB", n)
+}
+
+// checkImages keeps large screenshots and exports out of history: staged images must
+// fit imageMaxSize and imageMaxDimensions, after running them through an optimizer
+// when imageMode is fix; LFS pointers are left alone since their content lives elsewhere
+func (o *PreCommitOptions) checkImages() []rules.Violation {
+	if o.ImageMaxSize <= 0 && o.ImageMaxDimensions == (Dimensions{}) {
+		return nil
+	}
+
+	violations := make([]rules.Violation, 0)
+	for _, f := range o.filesFor(ImageRule) {
+		ext := strings.ToLower(path.Ext(f.Path))
+		if !stringInSlice(ImageExtensions, ext) {
+			continue
+		}
+		contents, err := f.Contents(o.Repo)
+		if err != nil {
+			violations = append(violations, imageViolation(f.Path, err.Error()))
+			continue
+		}
+		if lfs.IsPointer(contents) {
+			continue
+		}
+
+		optimizer, hasOptimizer := o.optimizerFor(ext)
+		if o.ImageMode == FormatFix && hasOptimizer {
+			if optimized, ok := o.optimize(f, optimizer, contents); ok {
+				contents = optimized
+			}
+		}
+
+		problems := o.overBudget(contents)
+		if len(problems) == 0 {
+			continue
+		}
+		advice := "track it with Git LFS, or resize it"
+		if hasOptimizer && o.ImageMode != FormatFix {
+			advice = fmt.Sprintf("optimize it (%s %s), track it with Git LFS, or resize it", strings.Join(optimizer.Command, " "), f.Path)
+		}
+		violations = append(violations, imageViolation(f.Path, fmt.Sprintf("is %s; %s", strings.Join(problems, " and "), advice)))
+	}
+	return violations
+}
+
+// overBudget describes how contents exceed the image budget, if they do
+func (o *PreCommitOptions) overBudget(contents []byte) []string {
+	problems := make([]string, 0)
+	if size := int64(len(contents)); o.ImageMaxSize > 0 && size > o.ImageMaxSize {
+		problems = append(problems, fmt.Sprintf("%s, over imageMaxSize %s", formatBytes(size), formatBytes(o.ImageMaxSize)))
+	}
+	if o.ImageMaxDimensions == (Dimensions{}) {
+		return problems
+	}
+	// formats Go cannot decode, e.g. svg and webp, are only held to the size
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(contents))
+	if err != nil {
+		return problems
+	}
+	if exceeds(cfg.Width, o.ImageMaxDimensions.Width) || exceeds(cfg.Height, o.ImageMaxDimensions.Height) {
+		problems = append(problems, fmt.Sprintf("%s pixels, over imageMaxDimensions %s", Dimensions{Width: cfg.Width, Height: cfg.Height}, o.ImageMaxDimensions))
+	}
+	return problems
+}
+
+// optimizerFor picks the first optimizer for ext which is installed
+func (o *PreCommitOptions) optimizerFor(ext string) (Formatter, bool) {
+	for _, f := range o.ImageOptimizers {
+		if !stringInSlice(f.Extensions, ext) {
+			continue
+		}
+		if _, err := exec.LookPath(f.Command[0]); err == nil {
+			return f, true
+		}
+	}
+	return Formatter{}, false
+}
+
+// optimize runs optimizer over the staged image and re-stages the result when it is
+// smaller, returning what is now staged
+func (o *PreCommitOptions) optimize(f staged.File, optimizer Formatter, contents []byte) ([]byte, bool) {
+	after, err := format(optimizer, f.Path, contents)
+	if err != nil {
+		fmt.Printf("could not optimize %s with %s: %v\n", f.Path, optimizer.Name, err)
+		return nil, false
+	}
+	if len(after) >= len(contents) {
+		return nil, false
+	}
+	if readonly.Enabled() {
+		readonly.Report("pre-commit", "optimized and restaged %s with %s (%s to %s)", f.Path, optimizer.Name, formatBytes(int64(len(contents))), formatBytes(int64(len(after))))
+		return nil, false
+	}
+	if err := o.restage(f, contents, after); err != nil {
+		fmt.Printf("could not restage %s after optimizing it: %v\n", f.Path, err)
+		return nil, false
+	}
+	fmt.Printf("optimized %s with %s (%s to %s)\n", f.Path, optimizer.Name, formatBytes(int64(len(contents))), formatBytes(int64(len(after))))
+	return after, true
+}
+
+func imageViolation(p, msg string) rules.Violation {
+	return rules.Violation{Rule: ImageRule, Severity: rules.Error, Location: p, Message: msg}
+}
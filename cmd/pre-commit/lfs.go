@@ -0,0 +1,21 @@
+package main
+
+import (
+	"github.com/davidalpert/go-githooks/pkg/lfs"
+	"github.com/davidalpert/go-githooks/pkg/rules"
+)
+
+// checkLFS catches files which .gitattributes routes through LFS but which were
+// staged as full content (e.g. on a machine without git-lfs installed), and vice versa
+func (o *PreCommitOptions) checkLFS() []rules.Violation {
+	violations := make([]rules.Violation, 0)
+	for _, f := range o.filesFor(lfs.Rule) {
+		v, err := lfs.CheckBlob(o.Repo, o.Attributes, f.Path, f.Hash)
+		if err != nil {
+			violations = append(violations, rules.Violation{Rule: lfs.Rule, Severity: rules.Error, Location: f.Path, Message: err.Error()})
+		} else if v != nil {
+			violations = append(violations, *v)
+		}
+	}
+	return violations
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"fmt"
+	"github.com/davidalpert/go-githooks/pkg/rules"
+	"github.com/go-git/go-git/v5/config"
+	"path"
+	"sort"
+	"strings"
+)
+
+const (
+	LockfileRule     = "lockfile"
+	LockfileOnlyRule = "lockfile-only"
+
+	lockfileSubsectionPrefix = "lockfile."
+)
+
+// Lockfile pairs a dependency manifest with the lockfile generated from it; both
+// are matched by base name, and a manifest is only checked in directories where its
+// lockfile is tracked
+type Lockfile struct {
+	Ecosystem string
+	Manifest  string
+	Lockfile  string
+	Command   string // regenerates the lockfile, run from its directory
+}
+
+// KnownLockfiles are the ecosystems which can be enabled by name
+var KnownLockfiles = []Lockfile{
+	{Ecosystem: "go", Manifest: "go.mod", Lockfile: "go.sum", Command: "go mod tidy"},
+	{Ecosystem: "npm", Manifest: "package.json", Lockfile: "package-lock.json", Command: "npm install"},
+	{Ecosystem: "yarn", Manifest: "package.json", Lockfile: "yarn.lock", Command: "yarn install"},
+	{Ecosystem: "pnpm", Manifest: "package.json", Lockfile: "pnpm-lock.yaml", Command: "pnpm install"},
+	{Ecosystem: "cargo", Manifest: "Cargo.toml", Lockfile: "Cargo.lock", Command: "cargo generate-lockfile"},
+	{Ecosystem: "bundler", Manifest: "Gemfile", Lockfile: "Gemfile.lock", Command: "bundle install"},
+	{Ecosystem: "composer", Manifest: "composer.json", Lockfile: "composer.lock", Command: "composer update --lock"},
+	{Ecosystem: "poetry", Manifest: "pyproject.toml", Lockfile: "poetry.lock", Command: "poetry lock --no-update"},
+	{Ecosystem: "pipenv", Manifest: "Pipfile", Lockfile: "Pipfile.lock", Command: "pipenv lock"},
+}
+
+// parseLockfiles enables the named ecosystems, then adds or overrides ecosystems
+// from [go-githooks "lockfile.<ecosystem>"] sections
+func parseLockfiles(cfg *config.Config, names []string) ([]Lockfile, error) {
+	byName := map[string]Lockfile{}
+	for _, n := range names {
+		n = strings.TrimSpace(n)
+		if n == "" || n == "none" {
+			continue
+		}
+		found := false
+		for _, l := range KnownLockfiles {
+			if l.Ecosystem == n {
+				byName[n] = l
+				found = true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown lockfile ecosystem '%s'; configure it in a [go-githooks \"lockfile.%s\"] section", n, n)
+		}
+	}
+
+	if cfg.Raw.HasSection("go-githooks") {
+		for _, sub := range cfg.Raw.Section("go-githooks").Subsections {
+			if !strings.HasPrefix(sub.Name, lockfileSubsectionPrefix) {
+				continue
+			}
+			name := strings.TrimPrefix(sub.Name, lockfileSubsectionPrefix)
+			l := byName[name]
+			l.Ecosystem = name
+			if v := sub.Options.Get("manifest"); v != "" {
+				l.Manifest = v
+			}
+			if v := sub.Options.Get("lockfile"); v != "" {
+				l.Lockfile = v
+			}
+			if v := sub.Options.Get("command"); v != "" {
+				l.Command = v
+			}
+			if l.Manifest == "" || l.Lockfile == "" || l.Command == "" {
+				return nil, fmt.Errorf("lockfile '%s' needs a manifest, a lockfile and a command", name)
+			}
+			byName[name] = l
+		}
+	}
+
+	lockfiles := make([]Lockfile, 0, len(byName))
+	for _, l := range byName {
+		lockfiles = append(lockfiles, l)
+	}
+	sort.Slice(lockfiles, func(i, j int) bool {
+		return lockfiles[i].Ecosystem < lockfiles[j].Ecosystem
+	})
+	return lockfiles, nil
+}
+
+// checkLockfiles catches a manifest staged without its lockfile, e.g. a dependency
+// bumped by hand in go.mod, and a lockfile staged without its manifest, which is
+// more often an accident than a deliberate update of indirect dependencies
+func (o *PreCommitOptions) checkLockfiles() []rules.Violation {
+	if len(o.Lockfiles) == 0 {
+		return nil
+	}
+	idx, err := o.Repo.Storer.Index()
+	if err != nil {
+		return []rules.Violation{{Rule: LockfileRule, Severity: rules.Error, Location: "index", Message: fmt.Sprintf("could not read the index: %v", err)}}
+	}
+	tracked := map[string]bool{}
+	for _, e := range idx.Entries {
+		tracked[e.Name] = true
+	}
+	isStaged := map[string]bool{}
+	for _, f := range o.StagedFiles {
+		isStaged[f.Path] = true
+	}
+
+	violations := make([]rules.Violation, 0)
+	for _, l := range o.Lockfiles {
+		for _, f := range o.filesFor(LockfileRule, LockfileOnlyRule) {
+			dir, base := path.Split(f.Path)
+			manifest, lockfile := dir+l.Manifest, dir+l.Lockfile
+			if !tracked[manifest] || !tracked[lockfile] {
+				continue
+			}
+			switch {
+			case base == l.Manifest && !isStaged[lockfile]:
+				violations = append(violations, rules.Violation{
+					Rule:     LockfileRule,
+					Severity: rules.Error,
+					Location: f.Path,
+					Message:  fmt.Sprintf("%s changed but %s did not; regenerate it with '%s' and stage it", manifest, lockfile, l.commandIn(dir)),
+				})
+			case base == l.Lockfile && !isStaged[manifest]:
+				violations = append(violations, rules.Violation{
+					Rule:     LockfileOnlyRule,
+					Severity: rules.Warning,
+					Location: f.Path,
+					Message:  fmt.Sprintf("%s changed but %s did not; if that was not deliberate, regenerate it with '%s' or restore it with 'git restore --staged --worktree %s'", lockfile, manifest, l.commandIn(dir), lockfile),
+				})
+			}
+		}
+	}
+	return violations
+}
+
+func (l Lockfile) commandIn(dir string) string {
+	if dir == "" {
+		return l.Command
+	}
+	return fmt.Sprintf("cd %s && %s", strings.TrimSuffix(dir, "/"), l.Command)
+}
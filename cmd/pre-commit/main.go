@@ -0,0 +1,449 @@
+package main
+
+import (
+	"fmt"
+	"github.com/davidalpert/go-githooks/pkg/attributes"
+	"github.com/davidalpert/go-githooks/pkg/budget"
+	"github.com/davidalpert/go-githooks/pkg/bypass"
+	"github.com/davidalpert/go-githooks/pkg/crash"
+	"github.com/davidalpert/go-githooks/pkg/exitcode"
+	"github.com/davidalpert/go-githooks/pkg/generated"
+	"github.com/davidalpert/go-githooks/pkg/gitconfig"
+	"github.com/davidalpert/go-githooks/pkg/guilog"
+	"github.com/davidalpert/go-githooks/pkg/lfs"
+	"github.com/davidalpert/go-githooks/pkg/notify"
+	"github.com/davidalpert/go-githooks/pkg/presets"
+	"github.com/davidalpert/go-githooks/pkg/prompt"
+	"github.com/davidalpert/go-githooks/pkg/readonly"
+	"github.com/davidalpert/go-githooks/pkg/rules"
+	"github.com/davidalpert/go-githooks/pkg/scripts"
+	"github.com/davidalpert/go-githooks/pkg/staged"
+	"github.com/davidalpert/go-githooks/pkg/telemetry"
+	"github.com/davidalpert/go-githooks/pkg/updatecheck"
+	"github.com/go-git/go-git/v5"
+	"os"
+	"time"
+)
+
+var (
+	Version = "n/a"
+)
+
+/*
+ * The pre-commit hook is run first, before you even type in a commit message. It’s
+ * used to inspect the snapshot that’s about to be committed. Exiting non-zero from
+ * this hook aborts the commit, although you can bypass it with git commit --no-verify.
+ *
+ * Checks only look at staged content, which is what will be committed, rather than
+ * at the working tree.
+ *
+ * reference: https://git-scm.com/docs/githooks#_pre_commit
+ */
+type PreCommitOptions struct {
+	Repo *git.Repository
+
+	// these are configuration options, set through git config
+	JSONSchemas         []SchemaMapping
+	SizeMaxFiles        int
+	SizeMaxInsertions   int
+	SizeBlockFiles      int
+	SizeBlockInsertions int
+	SizeExclusions      []string
+	GeneratedPaths      []string
+	GeneratedMarkers    []string
+	Formatters          []Formatter
+	FormatMode          FormatMode
+	Lockfiles           []Lockfile
+	ImageMaxSize        int64
+	ImageMaxDimensions  Dimensions
+	ImageOptimizers     []Formatter
+	ImageMode           FormatMode
+	TodoKeywords        []string
+	TodoTicketPattern   string
+	TodoPaths           rules.PathSeverities
+	ScriptsEnabled      bool
+	Checks              []Check
+	IdentityPolicies    []IdentityPolicy
+	IdentityName        string // the identity to set for the repo when its author breaks a policy
+	IdentityEmail       string
+	Severities          rules.Severities
+	Baseline            *rules.Baseline
+	Enforcement         *rules.Enforcement // nil unless progressive enforcement is configured
+	Notifier            *notify.Notifier
+	Budget              *budget.Budget
+	Telemetry           *telemetry.Recorder // nil unless go-githooks.telemetry.enabled
+	Author              string              // the author's email, whose grace warnings Enforcement counts
+	RemotePaths         []string
+	Prompter            *prompt.Prompter // nil when no terminal is attached
+
+	NothingStaged bool
+	StagedFiles   []staged.File
+	Attributes    *attributes.Attributes
+	Generated     map[string]bool // staged paths which are generated code
+	// attributes which exclude paths from the file checks, see attributes.SkipAttribute
+	SkipAttributes []string
+}
+
+func NewOptions(repo *git.Repository) *PreCommitOptions {
+	return &PreCommitOptions{
+		Repo:   repo,
+		Budget: budget.New("pre-commit"),
+	}
+}
+
+func (o *PreCommitOptions) Prepare(args []string) error {
+	if len(args) != 0 {
+		return exitcode.Wrap(exitcode.Usage, fmt.Errorf("expected 'version' or no args, got %d: %v", len(args), args))
+	}
+
+	o.setDefaultOptions()
+	if err := o.overrideFromRepo(); err != nil {
+		return exitcode.Wrap(exitcode.Config, err)
+	}
+
+	var err error
+	if o.NothingStaged, err = staged.Nothing(o.Repo); err != nil || o.NothingStaged {
+		// e.g. an empty commit, or an amend which only rewords the message
+		return err
+	}
+	if o.StagedFiles, err = staged.Files(o.Repo); err != nil {
+		return err
+	}
+	if o.Attributes, err = attributes.FromIndex(o.Repo); err != nil {
+		return err
+	}
+	o.Generated, err = o.detectGenerated()
+	return err
+}
+
+func (o *PreCommitOptions) setDefaultOptions() {
+	o.JSONSchemas = []SchemaMapping{}
+	o.SizeMaxFiles = 30
+	o.SizeMaxInsertions = 500
+	o.SizeBlockFiles = 0
+	o.SizeBlockInsertions = 0
+	o.SizeExclusions = []string{"vendor", "node_modules", "*.lock", "go.sum"}
+	o.GeneratedPaths = []string{}
+	o.GeneratedMarkers = generated.DefaultMarkers
+	o.SkipAttributes = []string{attributes.SkipAttribute}
+	o.Formatters = KnownFormatters
+	o.FormatMode = FormatCheck
+	o.Lockfiles = KnownLockfiles
+	o.ImageMaxSize = 1 << 20
+	o.ImageMaxDimensions = Dimensions{}
+	o.ImageOptimizers = []Formatter{}
+	o.ImageMode = FormatCheck
+	o.TodoKeywords = []string{"TODO", "FIXME"}
+	o.TodoTicketPattern = `[A-Z][A-Z0-9]+-[0-9]+|#[0-9]+`
+	o.TodoPaths = rules.PathSeverities{}
+	o.Checks = []Check{}
+	o.IdentityPolicies = []IdentityPolicy{}
+	o.Severities = rules.Severities{}
+	o.Baseline = &rules.Baseline{}
+	o.Notifier = notify.New("pre-commit")
+}
+
+func (o *PreCommitOptions) overrideFromRepo() error {
+	cfg, err := gitconfig.Load(o.Repo)
+	if err != nil {
+		return nil
+	}
+	if err = presets.Apply(cfg); err != nil {
+		return err
+	}
+
+	if o.JSONSchemas, err = parseSchemaMappings(gitconfig.GetSlice(cfg, "go-githooks", "pre-commit", "jsonSchemas", []string{})); err != nil {
+		return err
+	}
+
+	if o.SizeMaxFiles, err = gitconfig.GetInt(cfg, "go-githooks", "pre-commit", "sizeMaxFiles", o.SizeMaxFiles); err != nil {
+		return err
+	}
+	if o.SizeMaxInsertions, err = gitconfig.GetInt(cfg, "go-githooks", "pre-commit", "sizeMaxInsertions", o.SizeMaxInsertions); err != nil {
+		return err
+	}
+	if o.SizeBlockFiles, err = gitconfig.GetInt(cfg, "go-githooks", "pre-commit", "sizeBlockFiles", o.SizeBlockFiles); err != nil {
+		return err
+	}
+	if o.SizeBlockInsertions, err = gitconfig.GetInt(cfg, "go-githooks", "pre-commit", "sizeBlockInsertions", o.SizeBlockInsertions); err != nil {
+		return err
+	}
+	o.SizeExclusions = gitconfig.GetSlice(cfg, "go-githooks", "pre-commit", "sizeExclusions", o.SizeExclusions)
+	o.GeneratedPaths = gitconfig.GetSlice(cfg, "go-githooks", "pre-commit", "generatedPaths", o.GeneratedPaths)
+	o.GeneratedMarkers = gitconfig.GetSlice(cfg, "go-githooks", "pre-commit", "generatedMarkers", o.GeneratedMarkers)
+	o.SkipAttributes = gitconfig.GetSlice(cfg, "go-githooks", "pre-commit", "skipAttributes", o.SkipAttributes)
+	if names := gitconfig.GetSlice(cfg, "go-githooks", "pre-commit", "formatters", nil); names != nil {
+		if o.Formatters, err = formattersByName(names); err != nil {
+			return err
+		}
+	}
+	o.FormatMode = FormatModeFromString(gitconfig.GetString(cfg, "go-githooks", "pre-commit", "formatMode", string(o.FormatMode)))
+	ecosystems := make([]string, 0, len(o.Lockfiles))
+	for _, l := range o.Lockfiles {
+		ecosystems = append(ecosystems, l.Ecosystem)
+	}
+	if o.Lockfiles, err = parseLockfiles(cfg, gitconfig.GetSlice(cfg, "go-githooks", "pre-commit", "lockfiles", ecosystems)); err != nil {
+		return err
+	}
+
+	if s := gitconfig.GetString(cfg, "go-githooks", "pre-commit", "imageMaxSize", ""); s != "" {
+		if o.ImageMaxSize, err = ParseByteSize(s); err != nil {
+			return fmt.Errorf("could not parse imageMaxSize: %v", err)
+		}
+	}
+	if d := gitconfig.GetString(cfg, "go-githooks", "pre-commit", "imageMaxDimensions", ""); d != "" {
+		if o.ImageMaxDimensions, err = ParseDimensions(d); err != nil {
+			return fmt.Errorf("could not parse imageMaxDimensions: %v", err)
+		}
+	}
+	if o.ImageOptimizers, err = optimizersByName(gitconfig.GetSlice(cfg, "go-githooks", "pre-commit", "imageOptimizers", nil)); err != nil {
+		return err
+	}
+	o.ImageMode = FormatModeFromString(gitconfig.GetString(cfg, "go-githooks", "pre-commit", "imageMode", string(o.ImageMode)))
+
+	o.TodoKeywords = gitconfig.GetSlice(cfg, "go-githooks", "todo", "keywords", o.TodoKeywords)
+	o.TodoTicketPattern = gitconfig.GetString(cfg, "go-githooks", "todo", "ticketPattern", o.TodoTicketPattern)
+	if o.TodoPaths, err = rules.ParsePathSeverities(gitconfig.GetSlice(cfg, "go-githooks", "todo", "paths", nil)); err != nil {
+		return fmt.Errorf("could not read todo paths: %v", err)
+	}
+
+	if err = o.Notifier.Configure(cfg); err != nil {
+		return err
+	}
+	if err = o.Budget.Configure(cfg); err != nil {
+		return err
+	}
+	o.Telemetry = telemetry.NewRecorder("pre-commit", gitconfig.GetBool(cfg, "go-githooks", "telemetry", "enabled", false))
+	o.ScriptsEnabled = scripts.Enabled(o.Repo)
+	if o.Checks, err = parseChecks(cfg); err != nil {
+		return err
+	}
+	if o.IdentityPolicies, err = parseIdentityPolicies(gitconfig.GetAll(cfg, "go-githooks", "identity", "policy")); err != nil {
+		return err
+	}
+	o.IdentityName = gitconfig.GetString(cfg, "go-githooks", "identity", "name", o.IdentityName)
+	o.IdentityEmail = gitconfig.GetString(cfg, "go-githooks", "identity", "email", o.IdentityEmail)
+	o.RemotePaths = remotePaths(cfg)
+
+	if o.Severities, err = rules.SeveritiesFromConfig(cfg); err != nil {
+		return err
+	}
+	if o.Enforcement, err = rules.EnforcementFromConfig(cfg, bypass.Dir(o.Repo)); err != nil {
+		return err
+	}
+	o.Author = cfg.User.Email
+	if a := os.Getenv("GIT_AUTHOR_EMAIL"); a != "" {
+		o.Author = a
+	}
+
+	if w, err := o.Repo.Worktree(); err == nil {
+		if o.Baseline, err = rules.LoadWorktreeBaseline(w.Filesystem); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (o *PreCommitOptions) Execute() error {
+	if o.NothingStaged {
+		return nil
+	}
+
+	result := rules.Evaluate(o.check(), o.Severities, o.Baseline)
+	result = o.Enforcement.Apply(result, o.Author, rules.AuthorDate(os.Getenv("GIT_AUTHOR_DATE"), time.Now()))
+	if !readonly.Enabled() {
+		if err := o.Enforcement.Save(); err != nil {
+			fmt.Printf("could not count the warning: %v\n", err)
+		}
+	}
+	result.Print(os.Stdout)
+	if err := rules.Record(result); err != nil {
+		fmt.Printf("could not record the baseline: %v\n", err)
+	}
+	if note := o.Budget.Note(); note != "" {
+		fmt.Printf("%s\n", note)
+	}
+
+	if result.Failed() {
+		return exitcode.Wrap(exitcode.Violation, fmt.Errorf("the staged changes do not meet this repo's rules; fix them and commit again, or skip these checks with --no-verify"))
+	}
+	return nil
+}
+
+// check runs every pre-commit rule at its default severity
+func (o *PreCommitOptions) check() []rules.Violation {
+	violations := make([]rules.Violation, 0)
+	violations = append(violations, o.timed(IdentityRule, o.checkIdentity)...)
+	violations = append(violations, o.timed("config-syntax", o.checkSyntax)...)
+	violations = append(violations, o.timed(lfs.Rule, o.checkLFS)...)
+	violations = append(violations, o.timed(SizeRule, o.checkSize)...)
+	violations = append(violations, o.timed(LockfileRule, o.checkLockfiles)...)
+	violations = append(violations, o.timed(ImageRule, o.checkImages)...)
+	violations = append(violations, o.timed(TodoRule, o.checkTodos)...)
+	violations = append(violations, o.timed(ScriptRule, o.checkScripts)...)
+	violations = append(violations, o.timed(CheckRule, o.checkCommands)...)
+	// last, since fixing re-stages files the other checks have read
+	violations = append(violations, o.timed(FormatRule, o.checkFormatting)...)
+	return violations
+}
+
+// timed runs one rule check, recording how long it took when telemetry is enabled
+func (o *PreCommitOptions) timed(rule string, check func() []rules.Violation) []rules.Violation {
+	start := time.Now()
+	violations := check()
+	o.Telemetry.RecordCheck(rule, time.Since(start), len(violations))
+	return violations
+}
+
+func main() {
+	if updatecheck.Requested() {
+		updatecheck.Run()
+		return
+	}
+
+	argsWithoutProg := os.Args[1:]
+
+	if len(argsWithoutProg) == 1 {
+		switch argsWithoutProg[0] {
+		case "version":
+			printVersion()
+			return
+		case "help":
+			printHelp()
+			return
+		}
+	}
+
+	repo, err := git.PlainOpenWithOptions(".", &git.PlainOpenOptions{DetectDotGit: true})
+	checkError("read git repo", exitcode.Wrap(exitcode.Usage, err))
+	defer crash.Recover(crash.Dir(repo), "pre-commit", Version)
+	if err := guilog.Start(repo, "pre-commit"); err != nil {
+		fmt.Printf("could not keep the output for GUI clients: %v\n", err)
+	}
+	defer guilog.Stop()
+	updatecheck.Start(repo, Version)
+
+	o := NewOptions(repo)
+
+	if len(argsWithoutProg) == 1 && argsWithoutProg[0] == "pull" {
+		checkError("pull check images", exitcode.Wrap(exitcode.Dependency, o.PullImages()))
+		return
+	}
+
+	err = o.Prepare(argsWithoutProg)
+	checkError("prepare options", err)
+
+	// only asked when there is an identity to offer
+	if len(o.IdentityPolicies) > 0 && o.IdentityEmail != "" && !readonly.Enabled() {
+		o.Prompter = prompt.Terminal()
+		defer o.Prompter.Close()
+	}
+
+	err = o.Execute()
+	err = readonly.Allow("pre-commit", err)
+	err = bypass.Allow(bypass.Dir(repo), "pre-commit", err)
+	o.Notifier.Done(err)
+	if err := o.Telemetry.Flush(); err != nil {
+		fmt.Printf("could not save telemetry: %v\n", err)
+	}
+	checkError("pre-commit", err)
+}
+
+func printVersion() {
+	fmt.Printf("version: %s\n", Version)
+}
+
+func printHelp() {
+	fmt.Printf("help: %s\n", Version)
+	fmt.Printf(`
+configure go-githooks per-repo in .git/config; lists are comma-separated, or the key repeated once per item
+(git config --add) for items containing commas:
+
+[go-githooks "pre-commit"]
+    jsonSchemas = config/*.yml=schemas/config.json   # validate staged files matching a glob against a JSON Schema
+                                                     # (not in slim builds, see: make build-slim)
+    sizeMaxFiles = 30                                 # commit-size suggests splitting beyond these
+    sizeMaxInsertions = 500
+    sizeBlockFiles = 0                                # commit-size-limit blocks beyond these (0: no limit)
+    sizeBlockInsertions = 0
+    sizeExclusions = vendor,node_modules,*.lock,go.sum  # globs for paths (or their directories) left out of the size
+    generatedPaths =                                  # globs for generated paths, besides linguist-generated in .gitattributes
+    generatedMarkers = Code generated,@generated,<auto-generated  # text near the top of generated files
+                                                      # generated files are left out of the size, syntax, formatting and checks
+    skipAttributes = githooks-skip                    # .gitattributes attributes whose paths every file check skips, e.g. add
+                                                      # export-ignore; 'githooks-skip=todo-ticket,formatting' skips only those rules
+    formatters = goimports,gofmt,prettier,black,rustfmt  # the first one on PATH for each extension is used
+    formatMode = check                                # check | fix (format and re-stage fully staged files)
+    lockfiles = go,npm,yarn,pnpm,cargo,bundler,composer,poetry,pipenv  # ecosystems whose manifest and lockfile
+                                                      # are staged together ('none' to check none)
+    imageMaxSize = 1MB                                # image-budget blocks staged .png, .jpg, .gif, .svg and .webp files
+    imageMaxDimensions =                              # larger than these, e.g. 4096x4096 (LFS pointers are skipped)
+    imageOptimizers =                                 # any of pngquant,oxipng,jpegoptim,svgo: suggested in check mode
+    imageMode = check                                 # check | fix (optimize and re-stage fully staged images, then measure)
+
+[go-githooks "todo"]
+    keywords = TODO,FIXME                             # todo-ticket flags these in comments on added lines...
+    ticketPattern = [A-Z][A-Z0-9]+-[0-9]+|#[0-9]+     # ...unless the line also matches this, e.g. TODO(ABC-123)
+    paths = prototypes=off,internal/core=error        # glob=severity: the rule's severity under those paths,
+                                                      # over the one in [go-githooks "rules"]; the last match wins
+
+[go-githooks "lockfile.gradle"]                       # add an ecosystem, or change one of the above
+    manifest = build.gradle
+    lockfile = gradle.lockfile
+    command = ./gradlew dependencies --write-locks
+
+[go-githooks "check.golangci-lint"]                   # a command run over the staged tree; one section per check, never read from .githooks.yml
+    run = golangci-lint run ./...
+    files = *.go                                      # only when a staged file matches one of these globs (default: always)
+    runtime = docker:golangci/golangci-lint:v1.55     # host | docker:<image> | podman:<image>, mounting the tree read-only
+    offline = host                                    # host | skip | fail: when the image can't be found or pulled
+                                                      # ('pre-commit pull' fetches every check's image ahead of time)
+
+[go-githooks "rules"]
+    config-syntax = error        # staged .json, .yaml/.yml and .toml files parse (default: error)
+    json-schema = error          # staged files match the schema mapped to them (default: error)
+    lfs-pointer = error          # files with filter=lfs are staged as LFS pointers, and only they are (default: error)
+    commit-size = warning        # staged changes are within sizeMaxFiles and sizeMaxInsertions (default: off)
+    commit-size-limit = error    # staged changes are within sizeBlockFiles and sizeBlockInsertions (default: error)
+    lockfile = error             # a staged manifest, e.g. go.mod, is staged with its lockfile (default: error)
+    lockfile-only = warning      # a staged lockfile is staged with its manifest (default: warning)
+    image-budget = error         # staged images are within imageMaxSize and imageMaxDimensions (default: error)
+    todo-ticket = warning        # added TODO/FIXME comments reference a ticket (default: off; see the todo section)
+    formatting = error           # staged files are formatted by their formatter (default: off)
+    formatter-missing = warning  # a formatter for staged files is installed, with a hint on how to (default: warning)
+    script = error               # the repo's .githooks/pre-commit.d/* scripts pass (default: error)
+    check = error                # each configured check's command succeeds (default: error)
+    check-runtime = warning      # each check ran in its container rather than falling back (default: warning)
+    identity = error             # the author's email fits the identity policy for the repo's remotes (default: error)
+
+[go-githooks "identity"]                              # usually in ~/.gitconfig, to cover every work repo
+    policy = github.com/acme/*=*@acme.com             # remote=email: commits to repos with a remote matching the glob
+                                                      # are authored as a matching email (alternatives split by |);
+                                                      # repeat the key per policy, the first match applies
+    name = Mal Reynolds                               # the identity offered, when a terminal is attached, as this
+    email = mal@acme.com                              # repo's local user.name and user.email when a commit breaks it
+
+[go-githooks "enforcement"]
+    adoptionDate =               # e.g. 2026-11-01: errors in commits authored before this only warn
+    graceWarnings = 0            # then warn each author this many times per rule before blocking; counted
+                                 # in .git/go-githooks/enforcement.json
+
+[go-githooks "scripts"]
+    enabled = false              # run the repo's .githooks/pre-commit.d/* in order; only read from .git/config
+                                 # or ~/.gitconfig, never shared config
+
+[go-githooks "budget"]
+    pre-commit =                                      # e.g. 5s: as the hook uses up this budget it skips todo-ticket,
+                                                      # then formatting; the other checks always run
+
+[go-githooks "notify"]
+    enabled = false              # a desktop notification when a pre-commit which took longer than 'after' blocks the commit,
+    after = 10s                  # e.g. while its terminal is in the background
+
+[go-githooks "telemetry"]
+    enabled = false              # opt in to local, anonymous usage stats of which rules run, how long they take and
+                                 # how often they fail (see: go-githooks telemetry status); only read from
+                                 # .git/config or ~/.gitconfig
+
+`)
+}
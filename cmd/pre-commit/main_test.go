@@ -0,0 +1,642 @@
+package main
+
+import (
+	"bytes"
+	"github.com/davidalpert/go-githooks/pkg/exitcode"
+	"github.com/davidalpert/go-githooks/pkg/prompt"
+	"github.com/davidalpert/go-githooks/pkg/rules"
+	"github.com/davidalpert/go-githooks/pkg/staged"
+	"github.com/go-git/go-billy/v5/memfs"
+	"github.com/go-git/go-billy/v5/util"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/format/index"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/storage/memory"
+	"github.com/stretchr/testify/assert"
+	"image"
+	"image/png"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestRepo(t *testing.T, configText string, files map[string]string) *git.Repository {
+	r, _ := git.Init(memory.NewStorage(), memfs.New())
+	cfg, _ := r.Config()
+	if err := cfg.Unmarshal([]byte(configText)); err != nil {
+		t.Fatalf("unmarshalling sample config: %v", err)
+	}
+
+	w, _ := r.Worktree()
+	for path, contents := range files {
+		if err := util.WriteFile(w.Filesystem, path, []byte(contents), 0644); err != nil {
+			t.Fatalf("writing %s: %v", path, err)
+		}
+		if _, err := w.Add(path); err != nil {
+			t.Fatalf("adding %s: %v", path, err)
+		}
+	}
+	return r
+}
+
+func TestExecuteSyntax(t *testing.T) {
+	schemaConfig := `
+[go-githooks "pre-commit"]
+    jsonSchemas = config/*.yml=schemas/service.json
+`
+	schema := `{"type": "object", "required": ["name"], "properties": {"port": {"type": "integer"}}}`
+
+	tests := []struct {
+		name       string
+		configText string
+		files      map[string]string
+		wantErr    bool
+	}{
+		{
+			name:    "valid files",
+			files:   map[string]string{"a.json": `{"a": 1}`, "b.yaml": "a: 1\n---\nb: 2\n", "c.toml": "[a]\nb = 1\n", "README.md": "{"},
+			wantErr: false,
+		},
+		{
+			name:    "broken json",
+			files:   map[string]string{"a.json": `{"a": 1,}`},
+			wantErr: true,
+		},
+		{
+			name:    "broken yaml",
+			files:   map[string]string{"b.yml": "a: [1, 2\n"},
+			wantErr: true,
+		},
+		{
+			name:    "broken toml",
+			files:   map[string]string{"c.toml": "[a\nb = 1\n"},
+			wantErr: true,
+		},
+		{
+			name:       "broken yaml allowed when rule is off",
+			configText: "[go-githooks \"rules\"]\n    config-syntax = off\n",
+			files:      map[string]string{"b.yml": "a: [1, 2\n"},
+			wantErr:    false,
+		},
+		{
+			name:    "broken fixtures skipped by attribute",
+			files:   map[string]string{".gitattributes": "fixtures/** githooks-skip\n", "fixtures/bad.json": `{"a": 1,}`},
+			wantErr: false,
+		},
+		{
+			name:    "broken file skipped only by other rules",
+			files:   map[string]string{".gitattributes": "fixtures/** githooks-skip=todo-ticket\n", "fixtures/bad.json": `{"a": 1,}`},
+			wantErr: true,
+		},
+		{
+			name:       "export-ignore skips when configured",
+			configText: "[go-githooks \"pre-commit\"]\n    skipAttributes = githooks-skip,export-ignore\n",
+			files:      map[string]string{".gitattributes": "site/** export-ignore\n", "site/bad.json": `{"a": 1,}`},
+			wantErr:    false,
+		},
+		{
+			name:       "matches schema",
+			configText: schemaConfig,
+			files:      map[string]string{"schemas/service.json": schema, "config/api.yml": "name: api\nport: 8080\n"},
+			wantErr:    false,
+		},
+		{
+			name:       "does not match schema",
+			configText: schemaConfig,
+			files:      map[string]string{"schemas/service.json": schema, "config/api.yml": "port: eighty\n"},
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.configText == schemaConfig && !jsonSchemaSupported {
+				t.Skip("slim builds do not validate against JSON Schemas")
+			}
+			o := NewOptions(newTestRepo(t, tt.configText, tt.files))
+			if err := o.Prepare([]string{}); err != nil {
+				t.Errorf("prepare: %v", err)
+				return
+			}
+
+			if err := o.Execute(); (err != nil) != tt.wantErr {
+				t.Errorf("Execute() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestExecuteLFS(t *testing.T) {
+	pointer := "version https://git-lfs.github.com/spec/v1\noid sha256:4d7a214614ab2935c943f9e0ff69d22eadbb8f32b1258daaa5e2ca24d17e2393\nsize 12345\n"
+	attributes := "*.bin filter=lfs diff=lfs merge=lfs -text\n"
+
+	tests := []struct {
+		name    string
+		files   map[string]string
+		wantErr bool
+	}{
+		{
+			name:    "pointer for tracked file",
+			files:   map[string]string{".gitattributes": attributes, "assets/model.bin": pointer},
+			wantErr: false,
+		},
+		{
+			name:    "full content for tracked file",
+			files:   map[string]string{".gitattributes": attributes, "assets/model.bin": "\x00\x01 not a pointer"},
+			wantErr: true,
+		},
+		{
+			name:    "pointer for untracked file",
+			files:   map[string]string{"assets/model.dat": pointer},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := NewOptions(newTestRepo(t, "", tt.files))
+			if err := o.Prepare([]string{}); err != nil {
+				t.Errorf("prepare: %v", err)
+				return
+			}
+
+			err := o.Execute()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Execute() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr && exitcode.Of(err) != exitcode.Violation {
+				t.Errorf("Execute() exit code = %d, want %d", exitcode.Of(err), exitcode.Violation)
+			}
+		})
+	}
+}
+
+func TestExecuteSize(t *testing.T) {
+	tests := []struct {
+		name       string
+		configText string
+		files      map[string]string
+		wantErr    bool
+		wantRules  []string
+	}{
+		{
+			name:    "advisory is off by default",
+			files:   map[string]string{"a.go": "1\n2\n3\n", "b.go": "1\n"},
+			wantErr: false,
+		},
+		{
+			name:       "advisory warns beyond the thresholds",
+			configText: "[go-githooks \"pre-commit\"]\n    sizeMaxInsertions = 2\n[go-githooks \"rules\"]\n    commit-size = warning\n",
+			files:      map[string]string{"a.go": "1\n2\n3\n"},
+			wantErr:    false,
+			wantRules:  []string{SizeRule},
+		},
+		{
+			name:       "blocks beyond the hard limit",
+			configText: "[go-githooks \"pre-commit\"]\n    sizeBlockFiles = 1\n",
+			files:      map[string]string{"a.go": "1\n", "b.go": "1\n"},
+			wantErr:    true,
+			wantRules:  []string{SizeLimitRule},
+		},
+		{
+			name:       "excluded paths do not count",
+			configText: "[go-githooks \"pre-commit\"]\n    sizeBlockFiles = 1\n",
+			files:      map[string]string{"a.go": "1\n", "vendor/github.com/x/b.go": "1\n", "go.sum": "x\n"},
+			wantErr:    false,
+		},
+		{
+			name:       "generated files do not count",
+			configText: "[go-githooks \"pre-commit\"]\n    sizeBlockFiles = 2\n    generatedPaths = gen\n",
+			files:      map[string]string{"a.go": "1\n", "api.pb.go": "1\n", ".gitattributes": "*.pb.go linguist-generated\n", "gen/client.go": "1\n", "kind_string.go": "// Code generated by \"stringer\"; DO NOT EDIT.\n"},
+			wantErr:    false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := NewOptions(newTestRepo(t, tt.configText, tt.files))
+			if err := o.Prepare([]string{}); err != nil {
+				t.Errorf("prepare: %v", err)
+				return
+			}
+
+			if err := o.Execute(); (err != nil) != tt.wantErr {
+				t.Errorf("Execute() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			reported := make([]string, 0)
+			for _, v := range rules.Evaluate(o.checkSize(), o.Severities, o.Baseline).Reported {
+				reported = append(reported, v.Rule)
+			}
+			assert.ElementsMatch(t, tt.wantRules, reported)
+		})
+	}
+}
+
+func TestExecuteSubmodule(t *testing.T) {
+	r := newTestRepo(t, "", map[string]string{"a.go": "package a\n", "kind_string.go": "// Code generated by \"stringer\"; DO NOT EDIT.\n"})
+	idx, _ := r.Storer.Index()
+	idx.Entries = append(idx.Entries, &index.Entry{Name: "sub", Hash: plumbing.NewHash("f0d7ed2d4e8e5c3a9e1e8f1f3e0b1c2d3e4f5a6b"), Mode: filemode.Submodule})
+	if err := r.Storer.SetIndex(idx); err != nil {
+		t.Fatalf("staging the submodule: %v", err)
+	}
+
+	o := NewOptions(r)
+	if err := o.Prepare([]string{}); err != nil {
+		t.Fatalf("prepare: %v", err)
+	}
+	assert.NoError(t, o.Execute())
+
+	// a gitlink's commit is not in this repo's objects, so it must not be read
+	o.StagedFiles = append(o.StagedFiles, staged.File{Path: "sub", Hash: plumbing.NewHash("f0d7ed2d4e8e5c3a9e1e8f1f3e0b1c2d3e4f5a6b"), Mode: filemode.Submodule})
+	found, err := o.detectGenerated()
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]bool{"kind_string.go": true}, found)
+}
+
+func Test_insertions(t *testing.T) {
+	assert.Equal(t, 3, insertions("", "a\nb\nc"))
+	assert.Equal(t, 1, insertions("a\nb\n", "a\nx\nb\n"))
+	assert.Equal(t, 0, insertions("a\nb\n", "a\n"))
+}
+
+func TestExecuteNothingStaged(t *testing.T) {
+	r := newTestRepo(t, "", map[string]string{"a.json": `{"a": 1,}`})
+	w, _ := r.Worktree()
+	if _, err := w.Commit("broken json from before the hook", &git.CommitOptions{Author: &object.Signature{Name: "Mal Reynolds", Email: "mal@serenity.com", When: time.Now()}}); err != nil {
+		t.Fatalf("committing: %v", err)
+	}
+
+	o := NewOptions(r)
+	if err := o.Prepare([]string{}); err != nil {
+		t.Fatalf("prepare: %v", err)
+	}
+	assert.True(t, o.NothingStaged)
+	assert.Nil(t, o.StagedFiles)
+	assert.NoError(t, o.Execute())
+}
+
+func TestExecuteFormatting(t *testing.T) {
+	if _, err := exec.LookPath("gofmt"); err != nil {
+		t.Skip("gofmt is not on PATH")
+	}
+	unformatted := "package main\nfunc main( ) {}\n"
+	formatted := "package main\n\nfunc main() {}\n"
+
+	tests := []struct {
+		name       string
+		configText string
+		wantErr    bool
+		wantStaged string
+	}{
+		{
+			name:       "off by default",
+			wantErr:    false,
+			wantStaged: unformatted,
+		},
+		{
+			name:       "check reports unformatted files",
+			configText: "[go-githooks \"pre-commit\"]\n    formatters = gofmt\n[go-githooks \"rules\"]\n    formatting = error\n",
+			wantErr:    true,
+			wantStaged: unformatted,
+		},
+		{
+			name:       "fix formats and re-stages",
+			configText: "[go-githooks \"pre-commit\"]\n    formatters = gofmt\n    formatMode = fix\n[go-githooks \"rules\"]\n    formatting = error\n",
+			wantErr:    false,
+			wantStaged: formatted,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newTestRepo(t, tt.configText, map[string]string{"main.go": unformatted})
+			o := NewOptions(r)
+			if err := o.Prepare([]string{}); err != nil {
+				t.Fatalf("prepare: %v", err)
+			}
+
+			if err := o.Execute(); (err != nil) != tt.wantErr {
+				t.Errorf("Execute() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			files, _ := staged.Files(r)
+			contents, _ := files[0].Contents(r)
+			assert.Equal(t, tt.wantStaged, string(contents))
+		})
+	}
+}
+
+func TestCheckFormattingMissingTool(t *testing.T) {
+	o := NewOptions(newTestRepo(t, "[go-githooks \"rules\"]\n    formatting = error\n", map[string]string{"main.rs": "fn main() {}\n"}))
+	if err := o.Prepare([]string{}); err != nil {
+		t.Fatalf("prepare: %v", err)
+	}
+	o.Formatters = []Formatter{{Name: "rustfmt", Extensions: []string{".rs"}, Command: []string{"go-githooks-no-such-rustfmt"}, InstallHint: "rustup component add rustfmt"}}
+
+	violations := o.checkFormatting()
+	if assert.Len(t, violations, 1) {
+		assert.Equal(t, FormatterMissingRule, violations[0].Rule)
+		assert.Contains(t, violations[0].Message, "install it with: rustup component add rustfmt")
+	}
+	assert.NoError(t, o.Execute(), "a missing formatter only warns")
+}
+
+func TestExecuteChecks(t *testing.T) {
+	tests := []struct {
+		name        string
+		configText  string
+		githooksYml string
+		wantErr     bool
+		wantRules   []string
+	}{
+		{
+			name:       "passes on the staged tree",
+			configText: "[go-githooks \"check.staged\"]\n    run = test -f a.go\n",
+			wantErr:    false,
+		},
+		{
+			name:       "does not see untracked files",
+			configText: "[go-githooks \"check.untracked\"]\n    run = test -f untracked.go\n",
+			wantErr:    true,
+			wantRules:  []string{CheckRule},
+		},
+		{
+			name:       "only runs for matching files",
+			configText: "[go-githooks \"check.python\"]\n    run = false\n    files = *.py\n",
+			wantErr:    false,
+		},
+		{
+			name:       "skips when the image is unavailable",
+			configText: "[go-githooks \"check.lint\"]\n    run = false\n    runtime = podman:example.invalid/none:0\n    offline = skip\n",
+			wantErr:    false,
+			wantRules:  []string{CheckRuntimeRule},
+		},
+		{
+			name:       "falls back to the host when the image is unavailable",
+			configText: "[go-githooks \"check.lint\"]\n    run = test -f a.go\n    runtime = podman:example.invalid/none:0\n",
+			wantErr:    false,
+			wantRules:  []string{CheckRuntimeRule},
+		},
+		{
+			name:        "ignores checks committed in .githooks.yml",
+			githooksYml: "check.shared:\n  run: false\n",
+			wantErr:     false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := git.PlainInit(t.TempDir(), false)
+			if err != nil {
+				t.Fatalf("init: %v", err)
+			}
+			cfg, _ := r.Config()
+			if err := cfg.Unmarshal([]byte(tt.configText)); err != nil {
+				t.Fatalf("unmarshalling sample config: %v", err)
+			}
+			_ = r.SetConfig(cfg)
+			w, _ := r.Worktree()
+			_ = util.WriteFile(w.Filesystem, "a.go", []byte("package a\n"), 0644)
+			_, _ = w.Add("a.go")
+			_ = util.WriteFile(w.Filesystem, "untracked.go", []byte("package a\n"), 0644)
+			if tt.githooksYml != "" {
+				_ = util.WriteFile(w.Filesystem, ".githooks.yml", []byte(tt.githooksYml), 0644)
+			}
+
+			o := NewOptions(r)
+			if err := o.Prepare([]string{}); err != nil {
+				t.Errorf("prepare: %v", err)
+				return
+			}
+
+			if err := o.Execute(); (err != nil) != tt.wantErr {
+				t.Errorf("Execute() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			reported := make([]string, 0)
+			for _, v := range rules.Evaluate(o.checkCommands(), o.Severities, o.Baseline).Reported {
+				reported = append(reported, v.Rule)
+			}
+			assert.ElementsMatch(t, tt.wantRules, reported)
+		})
+	}
+}
+
+func TestExecuteLockfiles(t *testing.T) {
+	r := newTestRepo(t, "", map[string]string{
+		"go.mod":                "module x\n",
+		"go.sum":                "",
+		"web/package.json":      "{}\n",
+		"web/package-lock.json": "{}\n",
+		"tools/package.json":    "{}\n",
+	})
+	w, _ := r.Worktree()
+	if _, err := w.Commit("first", &git.CommitOptions{Author: &object.Signature{Name: "Mal Reynolds", Email: "mal@serenity.com", When: time.Now()}}); err != nil {
+		t.Fatalf("committing: %v", err)
+	}
+	for path, contents := range map[string]string{
+		"go.mod":                "module x\n\nrequire github.com/x/y v1.0.0\n",
+		"web/package-lock.json": "{\"lockfileVersion\": 3}\n",
+		"tools/package.json":    "{\"private\": true}\n",
+	} {
+		_ = util.WriteFile(w.Filesystem, path, []byte(contents), 0644)
+		_, _ = w.Add(path)
+	}
+
+	o := NewOptions(r)
+	if err := o.Prepare([]string{}); err != nil {
+		t.Fatalf("prepare: %v", err)
+	}
+	assert.Error(t, o.Execute())
+
+	violations := o.checkLockfiles()
+	if assert.Len(t, violations, 2, "tools/ has no lockfile to keep in step") {
+		assert.Equal(t, LockfileRule, violations[0].Rule)
+		assert.Equal(t, "go.mod changed but go.sum did not; regenerate it with 'go mod tidy' and stage it", violations[0].Message)
+		assert.Equal(t, LockfileOnlyRule, violations[1].Rule)
+		assert.Equal(t, "web/package-lock.json", violations[1].Location)
+		assert.Contains(t, violations[1].Message, "'cd web && npm install'")
+	}
+
+	cfg, _ := r.Config()
+	_ = cfg.Unmarshal([]byte("[go-githooks \"pre-commit\"]\n    lockfiles = npm\n[go-githooks \"lockfile.npm\"]\n    command = npm install --package-lock-only\n"))
+	o.Lockfiles, _ = parseLockfiles(cfg, []string{"npm"})
+	if violations := o.checkLockfiles(); assert.Len(t, violations, 1) {
+		assert.Contains(t, violations[0].Message, "'cd web && npm install --package-lock-only'")
+	}
+
+	_, err := parseLockfiles(cfg, []string{"gradle"})
+	assert.EqualError(t, err, "unknown lockfile ecosystem 'gradle'; configure it in a [go-githooks \"lockfile.gradle\"] section")
+}
+
+func TestCheckIdentity(t *testing.T) {
+	r := newTestRepo(t, `
+[remote "origin"]
+    url = git@github.com:acme/billing.git
+[go-githooks "identity"]
+    policy = gitlab.com/acme/*=*@acme.com
+    policy = github.com/acme/*=*@acme.com|*@acme.io
+`, map[string]string{"main.go": "package main\n"})
+
+	o := NewOptions(r)
+	if err := o.Prepare([]string{}); err != nil {
+		t.Fatalf("prepare: %v", err)
+	}
+	assert.Equal(t, []string{"github.com/acme/billing"}, o.RemotePaths)
+
+	o.Author = "mal@acme.io"
+	assert.Empty(t, o.checkIdentity())
+
+	o.Author = "mal@serenity.com"
+	violations := o.checkIdentity()
+	if assert.Len(t, violations, 1) {
+		assert.Equal(t, IdentityRule, violations[0].Rule)
+		assert.Equal(t, "commits to github.com/acme/* are authored as *@acme.com or *@acme.io, not 'mal@serenity.com'; set this repo's identity with 'git config user.email <email>' and commit again", violations[0].Message)
+	}
+
+	o.IdentityName, o.IdentityEmail = "Mal Reynolds", "mal@acme.com"
+	var out bytes.Buffer
+	o.Prompter = prompt.New(strings.NewReader("y\n"), &out)
+	violations = o.checkIdentity()
+	if assert.Len(t, violations, 1, "the commit in progress keeps its author") {
+		assert.Contains(t, violations[0].Message, "this repo's identity is now Mal Reynolds <mal@acme.com>, commit again")
+	}
+	assert.Contains(t, out.String(), "set this repo's identity to Mal Reynolds <mal@acme.com>? [y/N]")
+	cfg, _ := r.Config()
+	assert.Equal(t, "mal@acme.com", cfg.User.Email)
+	assert.Equal(t, "Mal Reynolds", cfg.User.Name)
+
+	_, err := parseIdentityPolicies([]string{"github.com/acme/*"})
+	assert.Error(t, err)
+}
+
+func TestExecuteImages(t *testing.T) {
+	var screenshot bytes.Buffer
+	_ = png.Encode(&screenshot, image.NewRGBA(image.Rect(0, 0, 300, 200)))
+
+	tests := []struct {
+		name       string
+		configText string
+		files      map[string]string
+		wantErr    bool
+	}{
+		{
+			name:    "within the default budget",
+			files:   map[string]string{"docs/screenshot.png": screenshot.String()},
+			wantErr: false,
+		},
+		{
+			name:       "over the size",
+			configText: "[go-githooks \"pre-commit\"]\n    imageMaxSize = 0.01k\n",
+			files:      map[string]string{"docs/screenshot.png": screenshot.String()},
+			wantErr:    true,
+		},
+		{
+			name:       "over the dimensions",
+			configText: "[go-githooks \"pre-commit\"]\n    imageMaxDimensions = 256x256\n",
+			files:      map[string]string{"docs/screenshot.png": screenshot.String()},
+			wantErr:    true,
+		},
+		{
+			name:       "svg held to the size only",
+			configText: "[go-githooks \"pre-commit\"]\n    imageMaxDimensions = 1x1\n",
+			files:      map[string]string{"logo.svg": "<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"300\" height=\"200\"/>\n"},
+			wantErr:    false,
+		},
+		{
+			name:       "switched off",
+			configText: "[go-githooks \"pre-commit\"]\n    imageMaxSize = 10\n[go-githooks \"rules\"]\n    image-budget = off\n",
+			files:      map[string]string{"docs/screenshot.png": screenshot.String()},
+			wantErr:    false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := NewOptions(newTestRepo(t, tt.configText, tt.files))
+			if err := o.Prepare([]string{}); err != nil {
+				t.Errorf("prepare: %v", err)
+				return
+			}
+
+			if err := o.Execute(); (err != nil) != tt.wantErr {
+				t.Errorf("Execute() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseByteSize(t *testing.T) {
+	for in, want := range map[string]int64{"1048576": 1 << 20, "500k": 500 << 10, "2MB": 2 << 20, "1.5 M": 3 << 19} {
+		got, err := ParseByteSize(in)
+		assert.NoError(t, err, in)
+		assert.Equal(t, want, got, in)
+	}
+	_, err := ParseByteSize("big")
+	assert.Error(t, err)
+}
+
+func TestExecuteTodos(t *testing.T) {
+	code := "package main\n\n// TODO handle errors\n// FIXME(ABC-12): retry\nvar s = \"TODO\"\n"
+	tests := []struct {
+		name       string
+		configText string
+		files      map[string]string
+		wantErr    bool
+		want       []string
+	}{
+		{
+			name:  "off by default",
+			files: map[string]string{"main.go": code},
+		},
+		{
+			name:       "comments without a ticket",
+			configText: "[go-githooks \"rules\"]\n    todo-ticket = warning\n",
+			files:      map[string]string{"main.go": code, "deploy.sh": "# TODO: #42 tidy up\necho TODO\n"},
+			want:       []string{"main.go:3"},
+		},
+		{
+			name:       "severity by path",
+			configText: "[go-githooks \"rules\"]\n    todo-ticket = error\n[go-githooks \"todo\"]\n    paths = prototypes=off\n",
+			files:      map[string]string{"prototypes/spike.go": code, "core/main.go": code},
+			wantErr:    true,
+			want:       []string{"core/main.go:3"},
+		},
+		{
+			name:       "skipped by attribute",
+			configText: "[go-githooks \"rules\"]\n    todo-ticket = error\n",
+			files:      map[string]string{".gitattributes": "vendor/** githooks-skip=todo-ticket\n", "vendor/lib.go": code, "core/main.go": code},
+			wantErr:    true,
+			want:       []string{"core/main.go:3"},
+		},
+		{
+			name:       "strict paths only",
+			configText: "[go-githooks \"todo\"]\n    paths = core=error\n",
+			files:      map[string]string{"prototypes/spike.go": code, "core/main.go": code},
+			wantErr:    true,
+			want:       []string{"core/main.go:3"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := NewOptions(newTestRepo(t, tt.configText, tt.files))
+			if err := o.Prepare([]string{}); err != nil {
+				t.Errorf("prepare: %v", err)
+				return
+			}
+
+			if err := o.Execute(); (err != nil) != tt.wantErr {
+				t.Errorf("Execute() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			reported := make([]string, 0)
+			for _, v := range rules.Evaluate(o.checkTodos(), o.Severities, o.Baseline).Reported {
+				reported = append(reported, v.Location)
+			}
+			assert.ElementsMatch(t, tt.want, reported)
+		})
+	}
+}
+
+func Test_addedLines(t *testing.T) {
+	added := addedLines("a\n// TODO old\nb\n", "a\n// TODO old\nnew\nb\n// TODO new\n")
+	assert.Equal(t, []addedLine{{number: 3, text: "new"}, {number: 5, text: "// TODO new"}}, added)
+}
@@ -0,0 +1,48 @@
+//go:build !slim
+// +build !slim
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/go-git/go-billy/v5/util"
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+// jsonSchemaSupported is false in slim builds
+const jsonSchemaSupported = true
+
+func (o *PreCommitOptions) validateSchema(schemaPath string, docs [][]byte) error {
+	w, err := o.Repo.Worktree()
+	if err != nil {
+		return err
+	}
+	schemaBytes, err := util.ReadFile(w.Filesystem, schemaPath)
+	if err != nil {
+		return fmt.Errorf("could not read schema: %v", err)
+	}
+
+	c := jsonschema.NewCompiler()
+	if err := c.AddResource(schemaPath, bytes.NewReader(schemaBytes)); err != nil {
+		return fmt.Errorf("could not load schema: %v", err)
+	}
+	schema, err := c.Compile(schemaPath)
+	if err != nil {
+		return fmt.Errorf("could not compile schema: %v", err)
+	}
+
+	for _, doc := range docs {
+		var v interface{}
+		d := json.NewDecoder(bytes.NewReader(doc))
+		d.UseNumber()
+		if err := d.Decode(&v); err != nil {
+			return err
+		}
+		if err := schema.Validate(v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
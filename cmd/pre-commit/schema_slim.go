@@ -0,0 +1,18 @@
+//go:build slim
+// +build slim
+
+package main
+
+import (
+	"fmt"
+)
+
+const jsonSchemaSupported = false
+
+// validateSchema is left out of slim builds, which do without a JSON Schema library;
+// it is the only optional feature with a third-party dependency, since the tracker,
+// worklog and notification clients use the standard library and plugins run as
+// separate processes
+func (o *PreCommitOptions) validateSchema(schemaPath string, docs [][]byte) error {
+	return fmt.Errorf("cannot validate against %s: this pre-commit is a slim build without JSON Schema support", schemaPath)
+}
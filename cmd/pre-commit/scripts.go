@@ -0,0 +1,30 @@
+package main
+
+import (
+	"github.com/davidalpert/go-githooks/pkg/rules"
+	"github.com/davidalpert/go-githooks/pkg/scripts"
+)
+
+const ScriptRule = "script"
+
+// checkScripts runs the repo's .githooks/pre-commit.d scripts, if the user has
+// enabled them; a script fails the check by exiting non-zero
+func (o *PreCommitOptions) checkScripts() []rules.Violation {
+	if !o.ScriptsEnabled {
+		return nil
+	}
+	w, err := o.Repo.Worktree()
+	if err != nil {
+		return nil
+	}
+	root := w.Filesystem.Root()
+
+	found, err := scripts.Discover(root, "pre-commit")
+	if err == nil {
+		err = scripts.Run(root, "pre-commit", found)
+	}
+	if err != nil {
+		return []rules.Violation{{Rule: ScriptRule, Severity: rules.Error, Message: err.Error()}}
+	}
+	return nil
+}
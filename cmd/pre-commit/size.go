@@ -0,0 +1,129 @@
+package main
+
+import (
+	"fmt"
+	"github.com/davidalpert/go-githooks/pkg/rules"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/utils/diff"
+	"github.com/sergi/go-diff/diffmatchpatch"
+	"path"
+	"strings"
+)
+
+const (
+	SizeRule      = "commit-size"
+	SizeLimitRule = "commit-size-limit"
+)
+
+// DiffSize summarizes the staged changes which count towards the size of a commit
+type DiffSize struct {
+	Files      int
+	Insertions int
+}
+
+// checkSize suggests splitting commits which are too large to review well, and blocks
+// ones beyond any configured hard limits; the advisory is off until its rule is enabled
+func (o *PreCommitOptions) checkSize() []rules.Violation {
+	advise := o.Severities.For(SizeRule, rules.Off) != rules.Off
+	block := o.SizeBlockFiles > 0 || o.SizeBlockInsertions > 0
+	if !advise && !block {
+		return nil
+	}
+
+	size, err := o.diffSize()
+	if err != nil {
+		return []rules.Violation{{Rule: SizeRule, Severity: rules.Warning, Message: err.Error()}}
+	}
+
+	violations := make([]rules.Violation, 0)
+	if exceeds(size.Files, o.SizeBlockFiles) || exceeds(size.Insertions, o.SizeBlockInsertions) {
+		violations = append(violations, rules.Violation{
+			Rule:     SizeLimitRule,
+			Severity: rules.Error,
+			Message:  fmt.Sprintf("%s is beyond this repo's limit of %s; split it into smaller commits", size, DiffSize{Files: o.SizeBlockFiles, Insertions: o.SizeBlockInsertions}),
+		})
+	} else if exceeds(size.Files, o.SizeMaxFiles) || exceeds(size.Insertions, o.SizeMaxInsertions) {
+		violations = append(violations, rules.Violation{
+			Rule:     SizeRule,
+			Severity: rules.Off,
+			Message:  fmt.Sprintf("%s is more than %s; consider splitting it into smaller commits", size, DiffSize{Files: o.SizeMaxFiles, Insertions: o.SizeMaxInsertions}),
+		})
+	}
+	return violations
+}
+
+// String renders only the measures which have a value, so limits left at 0 are not shown
+func (s DiffSize) String() string {
+	parts := make([]string, 0)
+	if s.Files > 0 {
+		parts = append(parts, fmt.Sprintf("%d files", s.Files))
+	}
+	if s.Insertions > 0 {
+		parts = append(parts, fmt.Sprintf("%d insertions", s.Insertions))
+	}
+	return strings.Join(parts, " or ")
+}
+
+// exceeds treats a limit of 0 as no limit
+func exceeds(n, limit int) bool {
+	return limit > 0 && n > limit
+}
+
+// diffSize counts the staged files and inserted lines, leaving out excluded paths
+func (o *PreCommitOptions) diffSize() (DiffSize, error) {
+	var tree *object.Tree
+	if head, err := o.Repo.Head(); err == nil {
+		if c, err := o.Repo.CommitObject(head.Hash()); err == nil {
+			tree, _ = c.Tree()
+		}
+	}
+
+	size := DiffSize{}
+	for _, f := range o.filesFor(SizeRule, SizeLimitRule) {
+		if o.sizeExcluded(f.Path) || o.Generated[f.Path] {
+			continue
+		}
+		size.Files++
+
+		after, err := f.Contents(o.Repo)
+		if err != nil {
+			return size, err
+		}
+		before := ""
+		if !f.Added && tree != nil {
+			if file, err := tree.File(f.Path); err == nil {
+				before, _ = file.Contents()
+			}
+		}
+		size.Insertions += insertions(before, string(after))
+	}
+	return size, nil
+}
+
+// insertions counts the lines added going from before to after
+func insertions(before, after string) int {
+	n := 0
+	for _, d := range diff.Do(before, after) {
+		if d.Type != diffmatchpatch.DiffInsert {
+			continue
+		}
+		n += strings.Count(d.Text, "\n")
+		if !strings.HasSuffix(d.Text, "\n") {
+			n++
+		}
+	}
+	return n
+}
+
+// sizeExcluded matches each exclusion glob against the path and each of its parent
+// directories, so 'vendor' excludes everything under vendor/
+func (o *PreCommitOptions) sizeExcluded(p string) bool {
+	for _, glob := range o.SizeExclusions {
+		for candidate := p; candidate != "." && candidate != "/"; candidate = path.Dir(candidate) {
+			if ok, _ := path.Match(glob, candidate); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,25 @@
+package main
+
+import (
+	"github.com/davidalpert/go-githooks/pkg/staged"
+)
+
+// skips reports whether the attributes in SkipAttributes exclude p from rule
+func (o *PreCommitOptions) skips(p, rule string) bool {
+	return o.Attributes.Skips(p, rule, o.SkipAttributes...)
+}
+
+// filesFor lists the staged files which are not excluded from any of rules
+func (o *PreCommitOptions) filesFor(rules ...string) []staged.File {
+	files := make([]staged.File, 0, len(o.StagedFiles))
+	for _, f := range o.StagedFiles {
+		skipped := false
+		for _, r := range rules {
+			skipped = skipped || o.skips(f.Path, r)
+		}
+		if !skipped {
+			files = append(files, f)
+		}
+	}
+	return files
+}
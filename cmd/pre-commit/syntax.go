@@ -0,0 +1,135 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/BurntSushi/toml"
+	"github.com/davidalpert/go-githooks/pkg/rules"
+	"github.com/davidalpert/go-githooks/pkg/staged"
+	"gopkg.in/yaml.v3"
+	"io"
+	"path"
+	"strings"
+)
+
+// SchemaMapping validates staged files matching Glob against the JSON Schema at Schema
+type SchemaMapping struct {
+	Glob   string
+	Schema string
+}
+
+func parseSchemaMappings(values []string) ([]SchemaMapping, error) {
+	mappings := make([]SchemaMapping, 0, len(values))
+	for _, v := range values {
+		parts := strings.SplitN(strings.TrimSpace(v), "=", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("jsonSchemas entry '%s' should be <glob>=<schema path>", v)
+		}
+		if _, err := path.Match(parts[0], ""); err != nil {
+			return nil, fmt.Errorf("jsonSchemas glob '%s': %v", parts[0], err)
+		}
+		mappings = append(mappings, SchemaMapping{Glob: parts[0], Schema: parts[1]})
+	}
+	return mappings, nil
+}
+
+// checkSyntax parses staged config files and validates any with a mapped schema
+func (o *PreCommitOptions) checkSyntax() []rules.Violation {
+	violations := make([]rules.Violation, 0)
+	for _, f := range o.filesFor("config-syntax") {
+		format := configFormat(f.Path)
+		if format == "" || o.Generated[f.Path] {
+			continue
+		}
+
+		contents, err := f.Contents(o.Repo)
+		if err != nil {
+			violations = append(violations, syntaxViolation(f, err))
+			continue
+		}
+
+		docs, err := parseConfig(format, contents)
+		if err != nil {
+			violations = append(violations, syntaxViolation(f, err))
+			continue
+		}
+
+		for _, m := range o.JSONSchemas {
+			if ok, _ := path.Match(m.Glob, f.Path); !ok || o.skips(f.Path, "json-schema") {
+				continue
+			}
+			if err := o.validateSchema(m.Schema, docs); err != nil {
+				violations = append(violations, rules.Violation{
+					Rule:     "json-schema",
+					Severity: rules.Error,
+					Location: f.Path,
+					Message:  fmt.Sprintf("does not match %s: %v", m.Schema, err),
+				})
+			}
+		}
+	}
+	return violations
+}
+
+func syntaxViolation(f staged.File, err error) rules.Violation {
+	return rules.Violation{
+		Rule:     "config-syntax",
+		Severity: rules.Error,
+		Location: f.Path,
+		Message:  err.Error(),
+	}
+}
+
+func configFormat(p string) string {
+	switch strings.ToLower(path.Ext(p)) {
+	case ".json":
+		return "json"
+	case ".yaml", ".yml":
+		return "yaml"
+	case ".toml":
+		return "toml"
+	}
+	return ""
+}
+
+// parseConfig returns each document in contents as JSON, ready for schema validation
+func parseConfig(format string, contents []byte) ([][]byte, error) {
+	docs := make([][]byte, 0)
+	switch format {
+	case "json":
+		if !json.Valid(contents) {
+			var v interface{}
+			return nil, fmt.Errorf("invalid JSON: %v", json.Unmarshal(contents, &v))
+		}
+		docs = append(docs, contents)
+	case "yaml":
+		d := yaml.NewDecoder(bytes.NewReader(contents))
+		for {
+			var v interface{}
+			err := d.Decode(&v)
+			if err == io.EOF {
+				break
+			} else if err != nil {
+				return nil, fmt.Errorf("invalid YAML: %v", err)
+			}
+			doc, err := json.Marshal(v)
+			if err != nil {
+				// e.g. maps with non-string keys, which JSON Schema cannot describe anyway
+				continue
+			}
+			docs = append(docs, doc)
+		}
+	case "toml":
+		var v map[string]interface{}
+		if _, err := toml.Decode(string(contents), &v); err != nil {
+			return nil, fmt.Errorf("invalid TOML: %v", err)
+		}
+		doc, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, doc)
+	}
+	return docs, nil
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"fmt"
+	"github.com/davidalpert/go-githooks/pkg/budget"
+	"github.com/davidalpert/go-githooks/pkg/rules"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/utils/diff"
+	"github.com/sergi/go-diff/diffmatchpatch"
+	"path"
+	"regexp"
+	"strings"
+)
+
+const TodoRule = "todo-ticket"
+
+// commentMarkers are how comments start in each language; a keyword only counts
+// after one of them, so TODO in a string or identifier is left alone
+var commentMarkers = map[string][]string{
+	".go": {"//", "/*"}, ".js": {"//", "/*"}, ".jsx": {"//", "/*"}, ".ts": {"//", "/*"}, ".tsx": {"//", "/*"},
+	".java": {"//", "/*"}, ".kt": {"//", "/*"}, ".scala": {"//", "/*"}, ".swift": {"//", "/*"}, ".rs": {"//", "/*"},
+	".c": {"//", "/*"}, ".h": {"//", "/*"}, ".cc": {"//", "/*"}, ".cpp": {"//", "/*"}, ".hpp": {"//", "/*"},
+	".cs": {"//", "/*"}, ".php": {"//", "/*", "#"}, ".css": {"/*"}, ".scss": {"//", "/*"},
+	".py": {"#"}, ".rb": {"#"}, ".sh": {"#"}, ".bash": {"#"}, ".pl": {"#"}, ".r": {"#"},
+	".yml": {"#"}, ".yaml": {"#"}, ".toml": {"#"}, ".tf": {"#", "//", "/*"},
+	".sql": {"--", "/*"}, ".lua": {"--"}, ".hs": {"--"},
+	".html": {"<!--"}, ".xml": {"<!--"}, ".md": {"<!--"}, ".vue": {"//", "/*", "<!--"},
+}
+
+// defaultCommentMarkers are used for extensions not listed above
+var defaultCommentMarkers = []string{"//", "/*", "#", "--", "<!--"}
+
+// checkTodos flags TODO and FIXME comments added by the staged changes which do not
+// reference a ticket, so deferred work is tracked somewhere; lines already committed
+// are left alone, and the severity can differ by path
+func (o *PreCommitOptions) checkTodos() []rules.Violation {
+	defaultSeverity := o.Severities.For(TodoRule, rules.Off)
+	if defaultSeverity == rules.Off && len(o.TodoPaths) == 0 || !o.Budget.Allows(TodoRule, budget.Low) {
+		return nil
+	}
+	keyword, err := todoKeywordRegexp(o.TodoKeywords)
+	if err != nil {
+		return []rules.Violation{{Rule: TodoRule, Severity: rules.Error, Message: err.Error()}}
+	}
+	ticket, err := regexp.Compile(o.TodoTicketPattern)
+	if err != nil {
+		return []rules.Violation{{Rule: TodoRule, Severity: rules.Error, Message: fmt.Sprintf("could not compile todo ticketPattern: %v", err)}}
+	}
+
+	var tree *object.Tree
+	if head, err := o.Repo.Head(); err == nil {
+		if c, err := o.Repo.CommitObject(head.Hash()); err == nil {
+			tree, _ = c.Tree()
+		}
+	}
+
+	violations := make([]rules.Violation, 0)
+	for _, f := range o.filesFor(TodoRule) {
+		if o.Generated[f.Path] {
+			continue
+		}
+		severity, scoped := o.TodoPaths.For(f.Path)
+		if !scoped {
+			severity = defaultSeverity
+		}
+		if severity == rules.Off {
+			continue
+		}
+
+		after, err := f.Contents(o.Repo)
+		if err != nil {
+			violations = append(violations, rules.Violation{Rule: TodoRule, Severity: severity, Location: f.Path, Message: err.Error(), PathScoped: scoped})
+			continue
+		}
+		before := ""
+		if !f.Added && tree != nil {
+			if file, err := tree.File(f.Path); err == nil {
+				before, _ = file.Contents()
+			}
+		}
+
+		markers, ok := commentMarkers[strings.ToLower(path.Ext(f.Path))]
+		if !ok {
+			markers = defaultCommentMarkers
+		}
+		for _, l := range addedLines(before, string(after)) {
+			k := todoIn(l.text, markers, keyword)
+			if k == "" || ticket.MatchString(l.text) {
+				continue
+			}
+			violations = append(violations, rules.Violation{
+				Rule:       TodoRule,
+				Severity:   severity,
+				Location:   fmt.Sprintf("%s:%d", f.Path, l.number),
+				Message:    fmt.Sprintf("%s without a ticket reference matching %s; e.g. %s(ABC-123): ...", k, o.TodoTicketPattern, k),
+				PathScoped: scoped,
+			})
+		}
+	}
+	return violations
+}
+
+func todoKeywordRegexp(keywords []string) (*regexp.Regexp, error) {
+	quoted := make([]string, 0, len(keywords))
+	for _, k := range keywords {
+		if k = strings.TrimSpace(k); k != "" {
+			quoted = append(quoted, regexp.QuoteMeta(k))
+		}
+	}
+	if len(quoted) == 0 {
+		return nil, fmt.Errorf("todo keywords is empty")
+	}
+	return regexp.Compile(`\b(` + strings.Join(quoted, "|") + `)\b`)
+}
+
+// todoIn returns the keyword in the comment part of line, if any
+func todoIn(line string, markers []string, keyword *regexp.Regexp) string {
+	start := -1
+	for _, m := range markers {
+		if i := strings.Index(line, m); i >= 0 && (start < 0 || i < start) {
+			start = i
+		}
+	}
+	trimmed := strings.TrimSpace(line)
+	if start < 0 && (strings.HasPrefix(trimmed, "*") || strings.HasPrefix(trimmed, "//")) {
+		// inside a block comment
+		start = 0
+	}
+	if start < 0 {
+		return ""
+	}
+	return keyword.FindString(line[start:])
+}
+
+type addedLine struct {
+	number int
+	text   string
+}
+
+// addedLines returns the lines of after which are not in before, with their line numbers
+func addedLines(before, after string) []addedLine {
+	added := make([]addedLine, 0)
+	number := 1
+	for _, d := range diff.Do(before, after) {
+		if d.Type == diffmatchpatch.DiffDelete {
+			continue
+		}
+		lines := strings.SplitAfter(d.Text, "\n")
+		if lines[len(lines)-1] == "" {
+			lines = lines[:len(lines)-1]
+		}
+		for _, l := range lines {
+			if d.Type == diffmatchpatch.DiffInsert {
+				added = append(added, addedLine{number: number, text: strings.TrimRight(l, "\r\n")})
+			}
+			number++
+		}
+	}
+	return added
+}
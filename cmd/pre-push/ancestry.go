@@ -0,0 +1,161 @@
+package main
+
+import (
+	"fmt"
+	"github.com/davidalpert/go-githooks/pkg/push"
+	"github.com/davidalpert/go-githooks/pkg/rules"
+	"github.com/go-git/go-git/v5/plumbing"
+	graphformat "github.com/go-git/go-git/v5/plumbing/format/commitgraph"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/object/commitgraph"
+	"github.com/go-git/go-git/v5/storage/filesystem"
+	"math"
+	"time"
+)
+
+const (
+	mergeFromMainRule = "merge-from-main"
+	commitAgeRule     = "commit-age"
+)
+
+// mainTips returns the tips of the main branches, preferring the remote's tracking
+// ref over the local branch since that is what the pushed branch will be merged into
+func (o *PrePushOptions) mainTips() map[string]plumbing.Hash {
+	tips := map[string]plumbing.Hash{}
+	for _, b := range o.MainBranches {
+		for _, name := range []string{"refs/remotes/" + o.RemoteName + "/" + b, "refs/heads/" + b} {
+			if ref, err := o.Repo.Reference(plumbing.ReferenceName(name), true); err == nil {
+				tips[b] = ref.Hash()
+				break
+			}
+		}
+	}
+	return tips
+}
+
+// checkMergeFromMain flags pushed merge commits which bring a main branch into the
+// pushed branch, for teams which rebase instead; pushes to a main branch itself are
+// skipped, since merging topic branches there is expected
+func (o *PrePushOptions) checkMergeFromMain(u push.Update, commits []*object.Commit) ([]rules.Violation, error) {
+	violations := make([]rules.Violation, 0)
+	if o.Severities.For(mergeFromMainRule, rules.Off) == rules.Off {
+		return violations, nil
+	}
+	for _, b := range o.MainBranches {
+		if u.RemoteRef == plumbing.NewBranchReferenceName(b) {
+			return violations, nil
+		}
+	}
+
+	tips := o.mainTips()
+	nodes, done := o.commitNodes()
+	defer done()
+	for _, c := range commits {
+		if c.NumParents() < 2 {
+			continue
+		}
+		for _, p := range c.ParentHashes[1:] {
+			for b, tip := range tips {
+				onMain, err := o.reachable(nodes, p, tip)
+				if err != nil {
+					return nil, err
+				}
+				if onMain {
+					violations = append(violations, rules.Violation{
+						Rule:     mergeFromMainRule,
+						Severity: rules.Off,
+						Location: c.Hash.String()[:7],
+						Message:  fmt.Sprintf("merges %s into the branch; rebase onto %s instead", b, b),
+					})
+					break
+				}
+			}
+		}
+	}
+	return violations, nil
+}
+
+// commitNodes reads history through the repo's commit-graph file when git has written
+// one (git gc and git commit-graph write do), falling back to the commit objects
+func (o *PrePushOptions) commitNodes() (commitgraph.CommitNodeIndex, func()) {
+	objects := commitgraph.NewObjectCommitNodeIndex(o.Repo.Storer)
+	fs, ok := o.Repo.Storer.(*filesystem.Storage)
+	if !ok {
+		return objects, func() {}
+	}
+	f, err := fs.Filesystem().Open(fs.Filesystem().Join("objects", "info", "commit-graph"))
+	if err != nil {
+		return objects, func() {}
+	}
+	index, err := graphformat.OpenFileIndex(f)
+	if err != nil {
+		f.Close()
+		return objects, func() {}
+	}
+	return commitgraph.NewGraphCommitNodeIndex(index, o.Repo.Storer), func() { f.Close() }
+}
+
+// reachable reports whether h is tip or one of its ancestors, walking back from tip
+// through at most CommitLimit commits; the walk skips history older than h, by
+// generation number when the commit-graph has one and by commit time otherwise
+func (o *PrePushOptions) reachable(nodes commitgraph.CommitNodeIndex, h, tip plumbing.Hash) (bool, error) {
+	target, err := nodes.Get(h)
+	if err != nil {
+		return false, fmt.Errorf("could not read commit %s: %v", h, err)
+	}
+
+	seen := map[plumbing.Hash]bool{tip: true}
+	queue := []plumbing.Hash{tip}
+	for visited := 0; len(queue) > 0 && visited < o.CommitLimit; visited++ {
+		id := queue[0]
+		queue = queue[1:]
+		if id == h {
+			return true, nil
+		}
+
+		n, err := nodes.Get(id)
+		if err == plumbing.ErrObjectNotFound {
+			continue
+		} else if err != nil {
+			return false, fmt.Errorf("could not read commit %s: %v", id, err)
+		}
+		if older(n, target) {
+			continue
+		}
+		for _, p := range n.ParentHashes() {
+			if !seen[p] {
+				seen[p] = true
+				queue = append(queue, p)
+			}
+		}
+	}
+	return false, nil
+}
+
+// older reports whether n cannot have target as an ancestor
+func older(n, target commitgraph.CommitNode) bool {
+	if n.Generation() != math.MaxUint64 && target.Generation() != math.MaxUint64 {
+		return n.Generation() <= target.Generation()
+	}
+	return n.CommitTime().Before(target.CommitTime())
+}
+
+// checkCommitAge flags pushed commits committed longer ago than MaxCommitAge, which
+// usually means a stale branch is being pushed without a rebase
+func (o *PrePushOptions) checkCommitAge(commits []*object.Commit, now time.Time) []rules.Violation {
+	violations := make([]rules.Violation, 0)
+	if o.MaxCommitAge <= 0 {
+		return violations
+	}
+	for _, c := range commits {
+		if age := now.Sub(c.Committer.When); age > o.MaxCommitAge {
+			violations = append(violations, rules.Violation{
+				Rule:     commitAgeRule,
+				Severity: rules.Off,
+				Location: c.Hash.String()[:7],
+				Message:  fmt.Sprintf("was committed %s ago, more than maxCommitAge (%s); rebase it onto the current main branch", age.Round(time.Hour), o.MaxCommitAge),
+			})
+		}
+	}
+	return violations
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"github.com/davidalpert/go-githooks/pkg/message"
+	"github.com/davidalpert/go-githooks/pkg/push"
+	"github.com/davidalpert/go-githooks/pkg/rules"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"path"
+)
+
+const fixupCommitsRule = "fixup-commits"
+
+// checkFixups flags fixup!, squash! and amend! commits pushed to a shared branch,
+// where nobody will run git rebase --autosquash to fold them into their targets;
+// pushing them to a topic branch under review is left alone
+func (o *PrePushOptions) checkFixups(u push.Update, commits []*object.Commit) []rules.Violation {
+	violations := make([]rules.Violation, 0)
+	if !o.sharedBranch(u.RemoteRef.Short()) {
+		return violations
+	}
+	for _, c := range commits {
+		marker := message.Autosquash(message.Subject([]byte(c.Message)))
+		if marker == "" {
+			continue
+		}
+		violations = append(violations, rules.Violation{
+			Rule:     fixupCommitsRule,
+			Severity: rules.Off,
+			Location: c.Hash.String()[:7],
+			Message:  fmt.Sprintf("is a '%s' commit pushed to %s; fold it in with git rebase -i --autosquash first", marker, u.RemoteRef.Short()),
+		})
+	}
+	return violations
+}
+
+// sharedBranch reports whether branch matches one of SharedBranches, or of the main
+// branches when none are configured
+func (o *PrePushOptions) sharedBranch(branch string) bool {
+	patterns := o.SharedBranches
+	if len(patterns) == 0 {
+		patterns = o.MainBranches
+	}
+	for _, p := range patterns {
+		if ok, _ := path.Match(p, branch); ok {
+			return true
+		}
+	}
+	return false
+}
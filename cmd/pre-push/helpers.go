@@ -0,0 +1,23 @@
+package main
+
+import (
+	"fmt"
+	"github.com/apex/log"
+	"github.com/davidalpert/go-githooks/pkg/exitcode"
+	"github.com/davidalpert/go-githooks/pkg/guilog"
+	"github.com/davidalpert/go-githooks/pkg/output"
+	"os"
+)
+
+func checkError(msg string, err error) {
+	if err == nil {
+		return
+	}
+
+	code := exitcode.Of(err)
+	output.ConfigureLog()
+	log.WithError(err).WithField("category", code.Name()).Error(msg)
+	fmt.Printf("%s: %v\n", msg, err)
+	guilog.Stop()
+	os.Exit(int(code))
+}
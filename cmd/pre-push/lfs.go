@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"github.com/davidalpert/go-githooks/pkg/attributes"
+	"github.com/davidalpert/go-githooks/pkg/lfs"
+	"github.com/davidalpert/go-githooks/pkg/rules"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// checkLFS checks the files each commit adds or changes against the .gitattributes
+// of that same commit, so a fix in a later commit does not hide an earlier mistake
+func (o *PrePushOptions) checkLFS(commits []*object.Commit) ([]rules.Violation, error) {
+	violations := make([]rules.Violation, 0)
+	for _, c := range commits {
+		tree, err := c.Tree()
+		if err != nil {
+			return nil, fmt.Errorf("could not read tree of %s: %v", c.Hash, err)
+		}
+
+		parentTree := &object.Tree{}
+		if c.NumParents() > 0 {
+			parent, err := c.Parent(0)
+			if err != nil {
+				return nil, fmt.Errorf("could not read parent of %s: %v", c.Hash, err)
+			}
+			if parentTree, err = parent.Tree(); err != nil {
+				return nil, fmt.Errorf("could not read tree of %s: %v", parent.Hash, err)
+			}
+		}
+
+		changes, err := object.DiffTree(parentTree, tree)
+		if err != nil {
+			return nil, fmt.Errorf("could not diff %s: %v", c.Hash, err)
+		}
+		if len(changes) == 0 {
+			continue
+		}
+
+		attrs, err := attributes.FromTree(tree)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, change := range changes {
+			if change.To.Name == "" {
+				continue // deleted
+			}
+			if attrs.Skips(change.To.Name, lfs.Rule, attributes.SkipAttribute) {
+				continue
+			}
+			v, err := lfs.CheckBlob(o.Repo, attrs, change.To.Name, change.To.TreeEntry.Hash)
+			if err != nil {
+				return nil, err
+			}
+			if v != nil {
+				v.Location = fmt.Sprintf("%s:%s", c.Hash.String()[:7], v.Location)
+				violations = append(violations, *v)
+			}
+		}
+	}
+	return violations, nil
+}
@@ -0,0 +1,274 @@
+package main
+
+import (
+	"fmt"
+	"github.com/davidalpert/go-githooks/pkg/bypass"
+	"github.com/davidalpert/go-githooks/pkg/commitsig"
+	"github.com/davidalpert/go-githooks/pkg/crash"
+	"github.com/davidalpert/go-githooks/pkg/exitcode"
+	"github.com/davidalpert/go-githooks/pkg/gitconfig"
+	"github.com/davidalpert/go-githooks/pkg/guilog"
+	"github.com/davidalpert/go-githooks/pkg/lfs"
+	"github.com/davidalpert/go-githooks/pkg/notify"
+	"github.com/davidalpert/go-githooks/pkg/presets"
+	"github.com/davidalpert/go-githooks/pkg/push"
+	"github.com/davidalpert/go-githooks/pkg/readonly"
+	"github.com/davidalpert/go-githooks/pkg/rules"
+	"github.com/davidalpert/go-githooks/pkg/telemetry"
+	"github.com/davidalpert/go-githooks/pkg/updatecheck"
+	"github.com/go-git/go-git/v5"
+	"io"
+	"os"
+	"time"
+)
+
+var (
+	Version = "n/a"
+)
+
+/*
+ * The pre-push hook runs during git push, after the remote refs have been updated but
+ * before any objects have been transferred. It receives the name and location of the
+ * remote as parameters, and a list of to-be-updated refs through stdin. Exiting non-zero
+ * from this hook aborts the push, although you can bypass it with git push --no-verify.
+ *
+ * Checks look at every commit being pushed, not only the tip, so that problems
+ * committed with --no-verify (or before the hooks were installed) are caught before
+ * they reach the remote.
+ *
+ * reference: https://git-scm.com/docs/githooks#_pre_push
+ */
+type PrePushOptions struct {
+	Repo *git.Repository
+
+	// these are passed in as arguments by git
+	RemoteName string
+	RemoteURL  string
+	Updates    []push.Update
+
+	// these are configuration options, set through git config
+	CommitLimit  int
+	MainBranches []string
+	MaxCommitAge time.Duration
+	Severities   rules.Severities
+	Baseline     *rules.Baseline
+	Notifier     *notify.Notifier
+	Telemetry    *telemetry.Recorder // nil unless go-githooks.telemetry.enabled
+
+	// the branches fixup-commits warns about pushing to; the main branches when empty
+	SharedBranches []string
+
+	// the trusted keys signed-commits verifies signatures with
+	SigningKeyring string
+	AllowedSigners string
+	verifier       *commitsig.Verifier
+
+	// whether signed-commits skips merges without a signature
+	AllowUnsignedMerges bool
+}
+
+func NewOptions(repo *git.Repository) *PrePushOptions {
+	return &PrePushOptions{
+		Repo: repo,
+	}
+}
+
+func (o *PrePushOptions) Prepare(args []string, stdin io.Reader) error {
+	if len(args) != 2 {
+		return exitcode.Wrap(exitcode.Usage, fmt.Errorf("expected 'version' or two args (remote name and url), got %d: %v", len(args), args))
+	}
+	o.RemoteName = args[0]
+	o.RemoteURL = args[1]
+
+	o.setDefaultOptions()
+	if err := o.overrideFromRepo(); err != nil {
+		return exitcode.Wrap(exitcode.Config, err)
+	}
+
+	var err error
+	o.Updates, err = push.ParseUpdates(stdin)
+	return err
+}
+
+func (o *PrePushOptions) setDefaultOptions() {
+	o.CommitLimit = push.DefaultLimit
+	o.MainBranches = []string{"main", "master"}
+	o.MaxCommitAge = 30 * 24 * time.Hour
+	o.AllowUnsignedMerges = false
+	o.Severities = rules.Severities{}
+	o.Baseline = &rules.Baseline{}
+	o.Notifier = notify.New("pre-push")
+	o.Notifier.OnSuccess = true
+}
+
+func (o *PrePushOptions) overrideFromRepo() error {
+	cfg, err := gitconfig.Load(o.Repo)
+	if err != nil {
+		return nil
+	}
+	if err = presets.Apply(cfg); err != nil {
+		return err
+	}
+
+	if err = o.Notifier.Configure(cfg); err != nil {
+		return err
+	}
+	o.Telemetry = telemetry.NewRecorder("pre-push", gitconfig.GetBool(cfg, "go-githooks", "telemetry", "enabled", false))
+
+	if o.Severities, err = rules.SeveritiesFromConfig(cfg); err != nil {
+		return err
+	}
+
+	if o.CommitLimit, err = gitconfig.GetInt(cfg, "go-githooks", "pre-push", "commitLimit", o.CommitLimit); err != nil {
+		return err
+	}
+	o.MainBranches = gitconfig.GetSlice(cfg, "go-githooks", "pre-push", "mainBranches", o.MainBranches)
+	o.SharedBranches = gitconfig.GetSlice(cfg, "go-githooks", "pre-push", "sharedBranches", o.SharedBranches)
+	if a := gitconfig.GetString(cfg, "go-githooks", "pre-push", "maxCommitAge", ""); a != "" {
+		if o.MaxCommitAge, err = time.ParseDuration(a); err != nil {
+			return fmt.Errorf("could not parse maxCommitAge '%s': %v", a, err)
+		}
+	}
+	o.SigningKeyring = gitconfig.GetString(cfg, "go-githooks", "pre-push", "signingKeyring", o.SigningKeyring)
+	o.AllowedSigners = gitconfig.GetString(cfg, "go-githooks", "pre-push", "sshAllowedSigners", gitconfig.GetString(cfg, "gpg", "ssh", "allowedSignersFile", o.AllowedSigners))
+	o.AllowUnsignedMerges = gitconfig.GetBool(cfg, "go-githooks", "pre-push", "allowUnsignedMerges", o.AllowUnsignedMerges)
+
+	if w, err := o.Repo.Worktree(); err == nil {
+		if o.Baseline, err = rules.LoadWorktreeBaseline(w.Filesystem); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (o *PrePushOptions) Execute() error {
+	violations, err := o.check()
+	if err != nil {
+		return err
+	}
+
+	result := rules.Evaluate(violations, o.Severities, o.Baseline)
+	result.Print(os.Stdout)
+	if err := rules.Record(result); err != nil {
+		fmt.Printf("could not record the baseline: %v\n", err)
+	}
+
+	if result.Failed() {
+		return exitcode.Wrap(exitcode.Violation, fmt.Errorf("the pushed commits do not meet this repo's rules; fix them and push again, or skip these checks with --no-verify"))
+	}
+	return nil
+}
+
+// check runs every pre-push rule at its default severity
+func (o *PrePushOptions) check() ([]rules.Violation, error) {
+	violations := make([]rules.Violation, 0)
+	for _, u := range o.Updates {
+		commits, err := u.Commits(o.Repo, o.RemoteName, o.CommitLimit)
+		if err != nil {
+			return nil, fmt.Errorf("could not list the commits pushed to %s: %v", u.RemoteRef, err)
+		}
+
+		checks := []struct {
+			rule  string
+			check func() ([]rules.Violation, error)
+		}{
+			{lfs.Rule, func() ([]rules.Violation, error) { return o.checkLFS(commits) }},
+			{stackRule, func() ([]rules.Violation, error) { return o.checkStack(commits), nil }},
+			{mergeFromMainRule, func() ([]rules.Violation, error) { return o.checkMergeFromMain(u, commits) }},
+			{commitAgeRule, func() ([]rules.Violation, error) { return o.checkCommitAge(commits, time.Now()), nil }},
+			{fixupCommitsRule, func() ([]rules.Violation, error) { return o.checkFixups(u, commits), nil }},
+			{signedCommitsRule, func() ([]rules.Violation, error) { return o.checkSignatures(commits) }},
+		}
+		for _, c := range checks {
+			start := time.Now()
+			v, err := c.check()
+			if err != nil {
+				return nil, err
+			}
+			o.Telemetry.RecordCheck(c.rule, time.Since(start), len(v))
+			violations = append(violations, v...)
+		}
+	}
+	return violations, nil
+}
+
+func main() {
+	if updatecheck.Requested() {
+		updatecheck.Run()
+		return
+	}
+
+	argsWithoutProg := os.Args[1:]
+
+	if len(argsWithoutProg) == 1 {
+		switch argsWithoutProg[0] {
+		case "version":
+			printVersion()
+			return
+		case "help":
+			printHelp()
+			return
+		}
+	}
+
+	repo, err := git.PlainOpenWithOptions(".", &git.PlainOpenOptions{DetectDotGit: true})
+	checkError("read git repo", exitcode.Wrap(exitcode.Usage, err))
+	defer crash.Recover(crash.Dir(repo), "pre-push", Version)
+	if err := guilog.Start(repo, "pre-push"); err != nil {
+		fmt.Printf("could not keep the output for GUI clients: %v\n", err)
+	}
+	defer guilog.Stop()
+	updatecheck.Start(repo, Version)
+
+	o := NewOptions(repo)
+
+	err = o.Prepare(argsWithoutProg, os.Stdin)
+	checkError("prepare options", err)
+
+	err = o.Execute()
+	err = readonly.Allow("pre-push", err)
+	err = bypass.Allow(bypass.Dir(repo), "pre-push", err)
+	o.Notifier.Done(err)
+	if err := o.Telemetry.Flush(); err != nil {
+		fmt.Printf("could not save telemetry: %v\n", err)
+	}
+	checkError("pre-push", err)
+}
+
+func printVersion() {
+	fmt.Printf("version: %s\n", Version)
+}
+
+func printHelp() {
+	fmt.Printf("help: %s\n", Version)
+	fmt.Printf(`
+configure go-githooks per-repo in .git/config:
+
+[go-githooks "rules"]
+    lfs-pointer = error          # files with filter=lfs are pushed as LFS pointers, and only they are (default: error)
+    stack-metadata = warning     # pushed commits share one Topic and only depend on changes below them (default: warning)
+    merge-from-main = off        # pushed branches do not merge a main branch in, for rebase workflows (default: off)
+    commit-age = off             # pushed commits were committed within maxCommitAge (default: off)
+    signed-commits = off         # pushed commits carry a GPG or SSH signature one of the trusted keys verifies (default: off)
+    fixup-commits = warning      # no fixup!/squash!/amend! commits are pushed to shared branches un-squashed (default: warning)
+
+[go-githooks "pre-push"]
+    commitLimit = 1000           # the most commits checked per pushed ref, bounding history walks
+    mainBranches = main,master   # branches merge-from-main treats as main, read from <remote>/<branch> or the local branch
+    maxCommitAge = 720h          # how old a pushed commit may be before commit-age flags it
+    sharedBranches =             # branch globs fixup-commits warns about pushing to, e.g. main,release/* (default: mainBranches)
+    signingKeyring =             # armored OpenPGP public keys signed-commits trusts, relative to the worktree root
+    sshAllowedSigners =          # an allowed signers file of trusted SSH keys (default: gpg.ssh.allowedSignersFile)
+    allowUnsignedMerges = false  # signed-commits skips unsigned merges, for remotes whose branch protection accepts them
+
+[go-githooks "notify"]
+    enabled = false              # a desktop notification when a push which took longer than 'after' passes or is blocked
+    after = 10s
+
+[go-githooks "telemetry"]
+    enabled = false              # opt in to local, anonymous usage stats of which rules run, how long they take and
+                                 # how often they fail (see: go-githooks telemetry status); only read from
+                                 # .git/config or ~/.gitconfig
+
+`)
+}
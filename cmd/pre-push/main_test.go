@@ -0,0 +1,223 @@
+package main
+
+import (
+	"fmt"
+	"github.com/davidalpert/go-githooks/pkg/push"
+	"github.com/davidalpert/go-githooks/pkg/rules"
+	"github.com/go-git/go-billy/v5/memfs"
+	"github.com/go-git/go-billy/v5/util"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/storage/memory"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestRepo makes one commit per entry in commits, each adding the given files
+func newTestRepo(t *testing.T, commits ...map[string]string) (*git.Repository, []plumbing.Hash) {
+	r, _ := git.Init(memory.NewStorage(), memfs.New())
+	w, _ := r.Worktree()
+
+	hashes := make([]plumbing.Hash, 0)
+	for i, files := range commits {
+		for path, contents := range files {
+			if err := util.WriteFile(w.Filesystem, path, []byte(contents), 0644); err != nil {
+				t.Fatalf("writing %s: %v", path, err)
+			}
+			if _, err := w.Add(path); err != nil {
+				t.Fatalf("adding %s: %v", path, err)
+			}
+		}
+		h, err := w.Commit(fmt.Sprintf("commit %d", i), &git.CommitOptions{
+			Author: &object.Signature{Name: "Mal Reynolds", Email: "mal@serenity.com", When: time.Now().Add(time.Duration(i) * time.Minute)},
+		})
+		if err != nil {
+			t.Fatalf("committing: %v", err)
+		}
+		hashes = append(hashes, h)
+	}
+	return r, hashes
+}
+
+func TestExecuteLFS(t *testing.T) {
+	pointer := "version https://git-lfs.github.com/spec/v1\noid sha256:4d7a214614ab2935c943f9e0ff69d22eadbb8f32b1258daaa5e2ca24d17e2393\nsize 12345\n"
+	attributes := "*.bin filter=lfs diff=lfs merge=lfs -text\n"
+
+	tests := []struct {
+		name     string
+		commits  []map[string]string
+		onRemote int
+		wantErr  bool
+	}{
+		{
+			name:     "pointers only",
+			commits:  []map[string]string{{".gitattributes": attributes}, {"model.bin": pointer}},
+			onRemote: -1,
+			wantErr:  false,
+		},
+		{
+			name:     "content in an earlier pushed commit",
+			commits:  []map[string]string{{".gitattributes": attributes}, {"model.bin": "not a pointer"}, {"model.bin": pointer}},
+			onRemote: 0,
+			wantErr:  true,
+		},
+		{
+			name:     "content already on the remote",
+			commits:  []map[string]string{{".gitattributes": attributes, "model.bin": "not a pointer"}, {"README.md": "hi"}},
+			onRemote: 0,
+			wantErr:  false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, hashes := newTestRepo(t, tt.commits...)
+			remoteSha := plumbing.ZeroHash
+			if tt.onRemote >= 0 {
+				remoteSha = hashes[tt.onRemote]
+			}
+			stdin := fmt.Sprintf("refs/heads/main %s refs/heads/main %s\n", hashes[len(hashes)-1], remoteSha)
+
+			o := NewOptions(r)
+			if err := o.Prepare([]string{"origin", "git@example.com:serenity/firefly.git"}, strings.NewReader(stdin)); err != nil {
+				t.Errorf("prepare: %v", err)
+				return
+			}
+
+			if err := o.Execute(); (err != nil) != tt.wantErr {
+				t.Errorf("Execute() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheckMergeFromMain(t *testing.T) {
+	r, _ := git.Init(memory.NewStorage(), memfs.New())
+	w, _ := r.Worktree()
+	commit := func(msg string, parents ...plumbing.Hash) plumbing.Hash {
+		_ = util.WriteFile(w.Filesystem, "log.txt", []byte(msg), 0644)
+		_, _ = w.Add("log.txt")
+		h, err := w.Commit(msg, &git.CommitOptions{
+			Author:  &object.Signature{Name: "Mal Reynolds", Email: "mal@serenity.com", When: time.Now()},
+			Parents: parents,
+		})
+		if err != nil {
+			t.Fatalf("committing: %v", err)
+		}
+		return h
+	}
+
+	base := commit("base")
+	onMain := commit("on main", base)
+	feature := commit("feature", base)
+	topic := commit("topic", base)
+	fromMain := commit("merge main", feature, onMain)
+	fromTopic := commit("merge topic", feature, topic)
+	_ = r.Storer.SetReference(plumbing.NewHashReference(plumbing.NewBranchReferenceName("main"), onMain))
+
+	tests := []struct {
+		name      string
+		tip       plumbing.Hash
+		remoteRef string
+		want      int
+	}{
+		{name: "merges main in", tip: fromMain, remoteRef: "refs/heads/feature", want: 1},
+		{name: "merges another branch in", tip: fromTopic, remoteRef: "refs/heads/feature", want: 0},
+		{name: "pushes main itself", tip: fromMain, remoteRef: "refs/heads/main", want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stdin := fmt.Sprintf("refs/heads/feature %s %s %s\n", tt.tip, tt.remoteRef, plumbing.ZeroHash)
+			o := NewOptions(r)
+			if err := o.Prepare([]string{"origin", "git@example.com:serenity/firefly.git"}, strings.NewReader(stdin)); err != nil {
+				t.Fatalf("prepare: %v", err)
+			}
+			o.Severities = rules.Severities{mergeFromMainRule: rules.Error}
+			// committing moves HEAD's branch, master, so only main is left as it was set
+			o.MainBranches = []string{"main"}
+
+			violations, err := o.check()
+			if err != nil {
+				t.Fatalf("check: %v", err)
+			}
+			got := 0
+			for _, v := range violations {
+				if v.Rule == mergeFromMainRule {
+					got++
+				}
+			}
+			if got != tt.want {
+				t.Errorf("got %d %s violations, want %d: %v", got, mergeFromMainRule, tt.want, violations)
+			}
+		})
+	}
+}
+
+func TestCheckCommitAge(t *testing.T) {
+	r, hashes := newTestRepo(t, map[string]string{"README.md": "hi"})
+	c, _ := r.CommitObject(hashes[0])
+	o := NewOptions(r)
+	o.setDefaultOptions()
+
+	if v := o.checkCommitAge([]*object.Commit{c}, c.Committer.When.Add(24*time.Hour)); len(v) != 0 {
+		t.Errorf("a day old commit: got %v, want none", v)
+	}
+	if v := o.checkCommitAge([]*object.Commit{c}, c.Committer.When.Add(60*24*time.Hour)); len(v) != 1 {
+		t.Errorf("two month old commit: got %v, want one violation", v)
+	}
+}
+
+func TestCheckFixups(t *testing.T) {
+	o := NewOptions(nil)
+	o.setDefaultOptions()
+	commits := []*object.Commit{
+		{Hash: plumbing.NewHash("1"), Message: "fixup! [FEAT-1] add login\n"},
+		{Hash: plumbing.NewHash("2"), Message: "[FEAT-1] add login\n"},
+	}
+	toMain := push.Update{RemoteRef: plumbing.NewBranchReferenceName("main")}
+	toTopic := push.Update{RemoteRef: plumbing.NewBranchReferenceName("FEAT-1")}
+
+	if v := o.checkFixups(toMain, commits); len(v) != 1 || v[0].Rule != fixupCommitsRule {
+		t.Errorf("pushed to main: got %v, want one %s violation", v, fixupCommitsRule)
+	}
+	if v := o.checkFixups(toTopic, commits); len(v) != 0 {
+		t.Errorf("pushed to a topic branch: got %v, want none", v)
+	}
+	o.SharedBranches = []string{"release/*"}
+	if v := o.checkFixups(push.Update{RemoteRef: plumbing.NewBranchReferenceName("release/1.0")}, commits); len(v) != 1 {
+		t.Errorf("pushed to a shared release branch: got %v, want one violation", v)
+	}
+}
+
+func TestCheckSignatures(t *testing.T) {
+	r, hashes := newTestRepo(t, map[string]string{"README.md": "hi"}, map[string]string{"README.md": "hello"})
+	c, _ := r.CommitObject(hashes[0])
+	o := NewOptions(r)
+	o.setDefaultOptions()
+
+	if v, err := o.checkSignatures([]*object.Commit{c}); err != nil || len(v) != 0 {
+		t.Errorf("with the rule off: got %v, %v, want none", v, err)
+	}
+	o.Severities = rules.Severities{signedCommitsRule: rules.Error}
+	v, err := o.checkSignatures([]*object.Commit{c})
+	if err != nil || len(v) != 1 || !strings.HasPrefix(v[0].Message, "is not signed") {
+		t.Errorf("an unsigned commit: got %v, %v, want one violation", v, err)
+	}
+	w, _ := r.Worktree()
+	mh, err := w.Commit("Merge branch 'feature'", &git.CommitOptions{
+		Author:  &object.Signature{Name: "Mal Reynolds", Email: "mal@serenity.com", When: time.Now()},
+		Parents: []plumbing.Hash{hashes[0], hashes[1]},
+	})
+	if err != nil {
+		t.Fatalf("committing the merge: %v", err)
+	}
+	merge, _ := r.CommitObject(mh)
+	if v, err := o.checkSignatures([]*object.Commit{merge}); err != nil || len(v) != 1 {
+		t.Errorf("an unsigned merge: got %v, %v, want one violation, since branch protection rejects it too", v, err)
+	}
+	o.AllowUnsignedMerges = true
+	if v, err := o.checkSignatures([]*object.Commit{merge}); err != nil || len(v) != 0 {
+		t.Errorf("an unsigned merge with allowUnsignedMerges: got %v, %v, want none", v, err)
+	}
+}
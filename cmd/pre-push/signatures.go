@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"github.com/davidalpert/go-githooks/pkg/commitsig"
+	"github.com/davidalpert/go-githooks/pkg/rules"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const signedCommitsRule = "signed-commits"
+
+// checkSignatures flags pushed commits which are not signed, or whose signature none
+// of the team's trusted keys verifies, for repos whose remote requires signed commits;
+// unsigned merges too, unless allowUnsignedMerges says the remote accepts them
+func (o *PrePushOptions) checkSignatures(commits []*object.Commit) ([]rules.Violation, error) {
+	violations := make([]rules.Violation, 0)
+	if o.Severities.For(signedCommitsRule, rules.Off) == rules.Off || len(commits) == 0 {
+		return violations, nil
+	}
+	if o.verifier == nil {
+		v, err := commitsig.Load(o.resolvePath(o.SigningKeyring), o.resolvePath(o.AllowedSigners))
+		if err != nil {
+			return nil, err
+		}
+		o.verifier = v
+	}
+
+	for _, c := range commits {
+		if o.AllowUnsignedMerges && c.NumParents() > 1 && c.PGPSignature == "" {
+			continue
+		}
+		if err := o.verifier.Verify(c); err != nil {
+			violations = append(violations, rules.Violation{
+				Rule:     signedCommitsRule,
+				Severity: rules.Off,
+				Location: c.Hash.String()[:7],
+				Message:  fmt.Sprintf("%v; re-sign it with git rebase --exec 'git commit --amend --no-edit -S'", err),
+			})
+		}
+	}
+	return violations, nil
+}
+
+// resolvePath expands ~/ and reads paths relative to the root of the worktree
+func (o *PrePushOptions) resolvePath(p string) string {
+	if p == "" || filepath.IsAbs(p) {
+		return p
+	}
+	if strings.HasPrefix(p, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, p[2:])
+		}
+	}
+	if w, err := o.Repo.Worktree(); err == nil {
+		return filepath.Join(w.Filesystem.Root(), p)
+	}
+	return p
+}
@@ -0,0 +1,24 @@
+package main
+
+import (
+	"github.com/davidalpert/go-githooks/pkg/rules"
+	"github.com/davidalpert/go-githooks/pkg/stack"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+const stackRule = "stack-metadata"
+
+// checkStack compares the Topic, Depends-On and Change-Id trailers across the commits
+// of one pushed ref, which stacked-diff tools treat as a single ordered series
+func (o *PrePushOptions) checkStack(commits []*object.Commit) []rules.Violation {
+	violations := make([]rules.Violation, 0)
+	for _, p := range stack.Check(commits) {
+		violations = append(violations, rules.Violation{
+			Rule:     stackRule,
+			Severity: rules.Warning,
+			Location: p.Commit[:7],
+			Message:  p.Message,
+		})
+	}
+	return violations
+}
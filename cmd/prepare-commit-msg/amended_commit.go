@@ -0,0 +1,66 @@
+package main
+
+import (
+	"github.com/davidalpert/go-githooks/pkg/message"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"strings"
+)
+
+// AmendPrefix chooses what to prefix the message of a -c, -C or --amend commit with
+type AmendPrefix string
+
+const (
+	AmendOriginal AmendPrefix = "original" // the prefix of the commit whose message is reused, when it has one
+	AmendBranch   AmendPrefix = "branch"   // the current branch, as for any other commit
+)
+
+func AmendPrefixFromString(s string) AmendPrefix {
+	if AmendPrefix(strings.ToLower(s)) == AmendBranch {
+		return AmendBranch
+	}
+	return AmendOriginal
+}
+
+// amendedCommit is the commit git named after the 'commit' source, whose message is
+// being reused; nil for any other source, or when it cannot be read, since the
+// message git offered still has everything the transformers need
+func (o *PrepareCommitMsgOptions) amendedCommit() *object.Commit {
+	if o.Source != CommitSource || o.CommitObject == "" || o.Repo == nil {
+		return nil
+	}
+	if o.amended != nil {
+		return o.amended
+	}
+	h, err := o.Repo.ResolveRevision(plumbing.Revision(o.CommitObject))
+	if err != nil {
+		return nil
+	}
+	o.amended, _ = o.Repo.CommitObject(*h)
+	return o.amended
+}
+
+// amendedTicket is the branch prefix the amended commit's subject was created with
+func (o *PrepareCommitMsgOptions) amendedTicket() string {
+	c := o.amendedCommit()
+	if c == nil {
+		return ""
+	}
+	if m := message.FindPrefix(message.Subject([]byte(c.Message)), o.PrefixWithBranchTemplate); len(m) > 1 {
+		return m[1]
+	}
+	return ""
+}
+
+// expandAmendedVars fills in {amendedSubject} and {amendedTicket} from the amended
+// commit; both are empty for any other commit
+func (o *PrepareCommitMsgOptions) expandAmendedVars(template string) string {
+	if !strings.Contains(template, "{amendedSubject}") && !strings.Contains(template, "{amendedTicket}") {
+		return template
+	}
+	subject := ""
+	if c := o.amendedCommit(); c != nil {
+		subject = message.Subject([]byte(c.Message))
+	}
+	return strings.NewReplacer("{amendedSubject}", subject, "{amendedTicket}", o.amendedTicket()).Replace(template)
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/davidalpert/go-githooks/pkg/message"
+	"strings"
+)
+
+// annotateChanges adds a comment describing what the transformers changed, e.g.
+// '# go-githooks: added prefix [FEAT-1]; added 2 co-authors', so an unwanted edit can
+// be spotted and undone in the editor. Like the prepared marker, it is only added to
+// messages which already have git comments, since git keeps comments otherwise
+func (o *PrepareCommitMsgOptions) annotateChanges() error {
+	if _, comments := message.SplitComments(o.CommitMessageBytes); len(comments) == 0 {
+		return nil
+	}
+	changes := describeChanges(o.originalMessageBytes, o.CommitMessageBytes, o.PrefixWithBranchTemplate)
+	if len(changes) == 0 {
+		return nil
+	}
+	o.CommitMessageBytes = message.InsertComment(o.CommitMessageBytes, "go-githooks: "+strings.Join(changes, "; "))
+	return nil
+}
+
+// describeChanges lists, in plain words, how after differs from before in its
+// subject, body and trailers; git comments are ignored
+func describeChanges(before, after []byte, prefixTemplate string) []string {
+	changes := make([]string, 0)
+
+	if s0, s1 := message.Subject(before), message.Subject(after); s0 != s1 {
+		if m := message.FindPrefix(s1, prefixTemplate); m != nil && message.FindPrefix(s0, prefixTemplate) == nil {
+			changes = append(changes, "added prefix "+m[0])
+		} else {
+			changes = append(changes, "changed the subject")
+		}
+	}
+
+	if !bytes.Equal(body(before), body(after)) {
+		changes = append(changes, "edited the body")
+	}
+
+	added, removed := trailerChanges(message.Trailers(before), message.Trailers(after))
+	for _, c := range added {
+		changes = append(changes, "added "+c)
+	}
+	for _, c := range removed {
+		changes = append(changes, "removed "+c)
+	}
+	return changes
+}
+
+// body is what the message says between its subject and trailer block
+func body(msg []byte) []byte {
+	content, _ := message.SplitComments(msg)
+	content = bytes.TrimSpace(content)
+	paragraphs := bytes.Split(content, []byte("\n\n"))
+	if message.EndsWithTrailerBlock(content) {
+		paragraphs = paragraphs[:len(paragraphs)-1]
+	}
+	return bytes.TrimSpace(bytes.Join(paragraphs[1:], []byte("\n\n")))
+}
+
+// trailerChanges counts the trailers only in after (added) and only in before
+// (removed), by key in the order they first appear
+func trailerChanges(before, after []message.Trailer) ([]string, []string) {
+	return trailerDifference(after, before), trailerDifference(before, after)
+}
+
+func trailerDifference(a, b []message.Trailer) []string {
+	inB := map[string]int{}
+	for _, t := range b {
+		inB[strings.ToLower(t.String())]++
+	}
+
+	keys := make([]string, 0)
+	counts := map[string]int{}
+	for _, t := range a {
+		line := strings.ToLower(t.String())
+		if inB[line] > 0 {
+			inB[line]--
+			continue
+		}
+		key := strings.ToLower(t.Key)
+		if counts[key] == 0 {
+			keys = append(keys, t.Key)
+		}
+		counts[key]++
+	}
+
+	described := make([]string, 0, len(keys))
+	for _, k := range keys {
+		n := counts[strings.ToLower(k)]
+		switch {
+		case strings.EqualFold(k, "Co-authored-by") && n == 1:
+			described = append(described, "1 co-author")
+		case strings.EqualFold(k, "Co-authored-by"):
+			described = append(described, fmt.Sprintf("%d co-authors", n))
+		case n == 1:
+			described = append(described, k)
+		default:
+			described = append(described, fmt.Sprintf("%d %s trailers", n, k))
+		}
+	}
+	return described
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"github.com/davidalpert/go-githooks/pkg/message"
+	"github.com/davidalpert/go-githooks/pkg/repostate"
+	"github.com/davidalpert/go-githooks/pkg/staged"
+	"strings"
+)
+
+/*
+ * Annotations are machine-readable trailers for release and risk tooling downstream,
+ * e.g. X-Change-Type and X-Risk, which 'go-githooks annotations <range>' extracts.
+ * Each entry of the annotations list is 'Key: value [when <conditions>]', where the
+ * value may use the staged summary's variables ({inferredType}, {scope}, ...) and the
+ * conditions are those of prefixSkip:
+ *
+ *   X-Change-Type: {inferredType}
+ *   X-Risk: high when paths=db/migrate/|infra/
+ *   X-Risk: low
+ *
+ * The first entry whose conditions hold sets a key; a key already in the message,
+ * as when amending, is left as it is.
+ */
+
+// Annotation is one annotations entry
+type Annotation struct {
+	Key        string
+	Template   string
+	conditions map[string][]string
+}
+
+func parseAnnotations(entries []string) ([]Annotation, error) {
+	annotations := make([]Annotation, 0, len(entries))
+	for _, e := range entries {
+		parts := strings.SplitN(e, ":", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" || strings.ContainsAny(strings.TrimSpace(parts[0]), " \t") {
+			return nil, fmt.Errorf("could not parse annotation '%s': expected 'Key: value [when <conditions>]'", e)
+		}
+		a := Annotation{Key: strings.TrimSpace(parts[0]), Template: strings.TrimSpace(parts[1])}
+		if i := strings.Index(a.Template, " when "); i >= 0 {
+			var err error
+			if a.conditions, err = parseConditions(a.Template[i+len(" when "):]); err != nil {
+				return nil, fmt.Errorf("could not parse annotation '%s': %v", e, err)
+			}
+			a.Template = strings.TrimSpace(a.Template[:i])
+		}
+		annotations = append(annotations, a)
+	}
+	return annotations, nil
+}
+
+// appendAnnotations adds a trailer for each annotation key, from the first entry
+// for it whose conditions this commit meets
+func (o *PrepareCommitMsgOptions) appendAnnotations() error {
+	branch := ""
+	if state, err := repostate.Detect(o.Repo); err == nil {
+		branch = state.Branch
+	}
+	present := map[string]bool{}
+	for _, t := range message.Trailers(o.CommitMessageBytes) {
+		present[strings.ToLower(t.Key)] = true
+	}
+
+	var files []staged.File
+	for _, a := range o.Annotations {
+		if present[strings.ToLower(a.Key)] {
+			continue
+		}
+		met, err := o.conditionsMet(a.conditions, branch, &files)
+		if err != nil {
+			return err
+		}
+		if !met {
+			continue
+		}
+		present[strings.ToLower(a.Key)] = true
+		value, err := o.expandStagedVars(a.Template)
+		if err != nil {
+			return err
+		}
+		if value = strings.TrimSpace(value); value != "" {
+			o.CommitMessageBytes = message.AppendTrailer(o.CommitMessageBytes, a.Key+": "+value)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"github.com/davidalpert/go-githooks/pkg/message"
+	"github.com/davidalpert/go-githooks/pkg/repostate"
+	"github.com/davidalpert/go-githooks/pkg/vcshost"
+	"os"
+	"strings"
+)
+
+// BranchCheck chooses how to make sure the branch a commit is prefixed with is still
+// being worked on, to catch work landing on a stale branch
+type BranchCheck string
+
+const (
+	BranchCheckOff    BranchCheck = "off"
+	BranchCheckRemote BranchCheck = "remote" // the branch is on the remote, as of the last fetch
+	BranchCheckPull   BranchCheck = "pr"     // as remote, and its pull request is not merged or closed; asks the provider's API
+)
+
+func BranchCheckFromString(s string) BranchCheck {
+	switch c := BranchCheck(strings.ToLower(s)); c {
+	case BranchCheckRemote, BranchCheckPull:
+		return c
+	}
+	return BranchCheckOff
+}
+
+// checkBranch warns, without blocking, when the branch is not on the remote, or when
+// its pull request was already merged or closed; the API is not asked while GIT_HOOKS_OFFLINE is set
+func (o *PrepareCommitMsgOptions) checkBranch() error {
+	// merges, squashes and reused messages describe work done elsewhere
+	if o.Source == MergeSource || o.Source == SquashSource || o.Source == CommitSource {
+		return nil
+	}
+	state, err := repostate.Detect(o.Repo)
+	if err != nil || state.Unborn || state.Branch == "" || stringInSlice(o.PrefixWithBranchExclusions, state.Branch) {
+		return nil
+	}
+	r := o.Remote
+	if r == nil || r.RemoteName == "" || state.Branch == r.DefaultBranch {
+		return nil
+	}
+	branch := state.Branch
+	if r.Upstream != "" {
+		branch = strings.TrimPrefix(r.Upstream, r.RemoteName+"/")
+	}
+	onRemote := vcshost.RemoteBranchExists(o.Repo, r.RemoteName, branch)
+
+	var warning string
+	if o.BranchCheck == BranchCheckPull && os.Getenv("GIT_HOOKS_OFFLINE") == "" {
+		pull, err := o.VCSAPI.PullForBranch(r, branch)
+		if err != nil {
+			return err
+		}
+		switch {
+		case pull != nil && pull.State != vcshost.PullOpen:
+			warning = fmt.Sprintf("go-githooks: the pull request from '%s' (#%d) is already %s; this commit will not land with it", branch, pull.Number, pull.State)
+		case pull == nil && !onRemote:
+			warning = fmt.Sprintf("go-githooks: '%s' is not on %s and has no pull request; is it the branch you meant?", branch, r.RemoteName)
+		}
+	} else if !onRemote && r.Upstream != "" {
+		warning = fmt.Sprintf("go-githooks: '%s' is gone from %s, as happens once its pull request is merged; is the branch stale?", branch, r.RemoteName)
+	} else if !onRemote {
+		warning = fmt.Sprintf("go-githooks: '%s' is not on %s; is it the branch you meant?", branch, r.RemoteName)
+	}
+	if warning == "" {
+		return nil
+	}
+
+	if _, comments := message.SplitComments(o.CommitMessageBytes); len(comments) == 0 {
+		fmt.Println(warning)
+		return nil
+	}
+	o.CommitMessageBytes = message.InsertComment(o.CommitMessageBytes, warning)
+	return nil
+}
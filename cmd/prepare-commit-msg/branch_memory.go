@@ -0,0 +1,59 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/davidalpert/go-githooks/pkg/gitconfig"
+	"github.com/davidalpert/go-githooks/pkg/readonly"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	format "github.com/go-git/go-git/v5/plumbing/format/config"
+)
+
+// branchTicketKey is where the prefix a branch was first committed with is kept;
+// 'git branch -m' moves the branch.<name> section along with the branch, so commits
+// made after a rename keep the prefix of those made before it
+const branchTicketKey = "githooksTicket"
+
+// rememberedBranchPrefix returns the prefix stored for branch, storing branch
+// itself when there is none yet
+func (o *PrepareCommitMsgOptions) rememberedBranchPrefix(branch string) (string, error) {
+	cfg, err := o.Repo.Config()
+	if err != nil {
+		return "", fmt.Errorf("could not read the repo config: %v", err)
+	}
+	if ticket := gitconfig.GetString(cfg, "branch", branch, branchTicketKey, ""); ticket != "" {
+		return ticket, nil
+	}
+	if stringInSlice(o.PrefixWithBranchExclusions, branch) {
+		return branch, nil
+	}
+
+	if readonly.Enabled() {
+		readonly.Report("prepare-commit-msg", "stored branch.%s.%s = %s", branch, branchTicketKey, branch)
+		return branch, nil
+	}
+	if err := storeBranchTicket(o.Repo, cfg, branch, branch); err != nil {
+		return "", err
+	}
+	return branch, nil
+}
+
+func storeBranchTicket(repo *git.Repository, cfg *config.Config, branch, ticket string) error {
+	cfg.Raw.SetOption("branch", branch, branchTicketKey, ticket)
+
+	// go-git only writes the branch sections it knows about, so re-read the raw
+	// config for it to pick up a section holding nothing but the ticket
+	var b bytes.Buffer
+	if err := format.NewEncoder(&b).Encode(cfg.Raw); err != nil {
+		return fmt.Errorf("could not encode the repo config: %v", err)
+	}
+	updated := config.NewConfig()
+	if err := updated.Unmarshal(b.Bytes()); err != nil {
+		return fmt.Errorf("could not decode the repo config: %v", err)
+	}
+	if err := repo.SetConfig(updated); err != nil {
+		return fmt.Errorf("could not store branch.%s.%s: %v", branch, branchTicketKey, err)
+	}
+	return nil
+}
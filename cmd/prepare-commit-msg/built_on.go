@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"github.com/davidalpert/go-githooks/pkg/describe"
+	"github.com/davidalpert/go-githooks/pkg/message"
+	"regexp"
+)
+
+var builtOnRe = regexp.MustCompile(`(?m)^Built-on:`)
+
+// BuiltOnStamp chooses how the Built-on trailer describes the commit being built on
+type BuiltOnStamp string
+
+const (
+	BuiltOnOff      BuiltOnStamp = "off"
+	BuiltOnDescribe BuiltOnStamp = "describe" // like 'git describe --tags', e.g. v1.4.2-12-gabc1234
+	BuiltOnTag      BuiltOnStamp = "tag"      // the nearest tag alone, e.g. v1.4.2
+)
+
+func BuiltOnStampFromString(s string) BuiltOnStamp {
+	switch BuiltOnStamp(s) {
+	case BuiltOnDescribe, BuiltOnTag:
+		return BuiltOnStamp(s)
+	default:
+		return BuiltOnOff
+	}
+}
+
+// appendBuiltOn stamps the message with the baseline HEAD was built from, so commits
+// can be correlated with deployed versions; repos without tags get nothing
+func (o *PrepareCommitMsgOptions) appendBuiltOn() error {
+	content, _ := message.SplitComments(o.CommitMessageBytes)
+	if builtOnRe.Match(content) {
+		return nil
+	}
+
+	head, err := o.Repo.Head()
+	if err != nil {
+		// nothing to build on before the first commit
+		return nil
+	}
+
+	d, err := describe.Describe(o.Repo, head.Hash())
+	if err == describe.ErrNoTags {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("could not describe HEAD: %v", err)
+	}
+
+	stamp := d.String()
+	if o.BuiltOn == BuiltOnTag {
+		stamp = d.Tag
+	}
+	o.CommitMessageBytes = message.AppendTrailer(o.CommitMessageBytes, "Built-on: "+stamp)
+	return nil
+}
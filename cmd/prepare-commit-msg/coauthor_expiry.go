@@ -0,0 +1,47 @@
+package main
+
+import (
+	"fmt"
+	"github.com/davidalpert/go-githooks/pkg/message"
+	"github.com/davidalpert/go-githooks/pkg/mobsession"
+	"strings"
+	"time"
+)
+
+// checkCoauthorExpiry asks, in a comment, whether a mob which has not changed for
+// longer than CoauthorsTTL is still accurate, rather than silently crediting
+// partners from a session which ended hours ago. A message without git comments
+// (e.g. from -m) gets a printed warning instead, since git would keep the comment
+func (o *PrepareCommitMsgOptions) checkCoauthorExpiry() error {
+	coauthors := message.Credits(o.CoauthorsMarkupBytes)
+	if len(coauthors) == 0 {
+		return nil
+	}
+
+	emails := make([]string, 0, len(coauthors))
+	names := make([]string, 0, len(coauthors))
+	for _, c := range coauthors {
+		emails = append(emails, c.Email)
+		names = append(names, c.Name)
+	}
+	now := time.Now()
+	since, err := mobsession.Touch(o.MobSessionFile, emails, now)
+	if err != nil {
+		return err
+	}
+	age := now.Sub(since)
+	if age <= o.CoauthorsTTL {
+		return nil
+	}
+
+	question := fmt.Sprintf("go-githooks: the mob (%s) has not changed for %s; is it still accurate?", strings.Join(names, ", "), age.Round(time.Minute))
+	if _, comments := message.SplitComments(o.CommitMessageBytes); len(comments) == 0 {
+		fmt.Println(question)
+		return nil
+	}
+	o.CommitMessageBytes = message.InsertComment(o.CommitMessageBytes,
+		question,
+		"go-githooks: if not, delete the Co-authored-by lines below and update it with 'git mob'.",
+	)
+	return nil
+}
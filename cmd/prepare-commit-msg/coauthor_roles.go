@@ -0,0 +1,62 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/davidalpert/go-githooks/pkg/message"
+	"strings"
+)
+
+// parseCoauthorRoles reads pairing.role values, 'Reviewed-by zoe@serenity.com' for
+// one person or 'Paired-with' for everyone else; the result is keyed by lower-cased
+// email, with "" for everyone else
+func parseCoauthorRoles(values []string) (map[string]string, error) {
+	roles := map[string]string{}
+	for _, v := range values {
+		fields := strings.Fields(v)
+		if len(fields) == 0 {
+			continue
+		}
+		role := message.RoleFromString(fields[0])
+		if role == "" || len(fields) > 2 {
+			return nil, fmt.Errorf("could not parse coauthor role '%s', expected '<role> [email]' with a role of: %s", v, strings.Join(message.Roles, ", "))
+		}
+		email := ""
+		if len(fields) == 2 {
+			email = strings.ToLower(strings.Trim(fields[1], "<>"))
+		}
+		roles[email] = role
+	}
+	return roles, nil
+}
+
+// assignCoauthorRoles credits each coauthor with the trailer for their role: the one
+// given on their line (e.g. in the pairing file), else the one configured for them,
+// else the session's, else the configured default
+func (o *PrepareCommitMsgOptions) assignCoauthorRoles() {
+	if len(o.CoauthorRoles) == 0 && o.SessionCoauthorRole == "" || len(o.CoauthorsMarkupBytes) == 0 {
+		return
+	}
+
+	lines := make([][]byte, 0)
+	for _, line := range bytes.Split(bytes.TrimSpace(o.CoauthorsMarkupBytes), nl) {
+		found := message.Credits(line)
+		if len(found) == 0 || found[0].Role != "" {
+			lines = append(lines, line)
+			continue
+		}
+		c := found[0]
+		if role, ok := o.CoauthorRoles[strings.ToLower(c.Email)]; ok {
+			c.Role = role
+		} else if o.SessionCoauthorRole != "" {
+			c.Role = o.SessionCoauthorRole
+		} else {
+			c.Role = o.CoauthorRoles[""]
+		}
+		if c.Role == message.CoauthoredBy {
+			c.Role = ""
+		}
+		lines = append(lines, []byte(c.String()))
+	}
+	o.CoauthorsMarkupBytes = bytes.Join(lines, nl)
+}
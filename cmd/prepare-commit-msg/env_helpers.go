@@ -2,6 +2,7 @@ package main
 
 import (
 	"fmt"
+	"github.com/davidalpert/go-githooks/pkg/exitcode"
 	"os"
 	"strconv"
 	"strings"
@@ -20,7 +21,7 @@ func getEnvOrDefaultBool(envKey string, defaultValue bool) bool {
 	if v != "" {
 		b, err := strconv.ParseBool(v)
 		if err != nil {
-			panic(fmt.Errorf("failed parsing '%s' as a bool: %v", v, err))
+			panic(exitcode.Wrap(exitcode.Config, fmt.Errorf("failed parsing %s '%s' as a bool: %v", envKey, v, err)))
 		}
 		return b
 	}
@@ -34,4 +35,3 @@ func getEnvOrDefaultStringSlice(envKey string, defaults ...string) []string {
 	}
 	return defaults
 }
-
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"github.com/davidalpert/go-githooks/pkg/repostate"
+	"strings"
+)
+
+// FirstCommitPrefix chooses what to prefix the first commit of a repo with, made
+// while HEAD names a branch which does not exist yet
+type FirstCommitPrefix string
+
+const (
+	FirstCommitBranch        FirstCommitPrefix = "branch"         // the branch HEAD names, as for any other commit
+	FirstCommitSkip          FirstCommitPrefix = "skip"           // leave the message without a prefix
+	FirstCommitDefaultBranch FirstCommitPrefix = "default-branch" // init.defaultBranch, when it is set
+)
+
+func FirstCommitPrefixFromString(s string) FirstCommitPrefix {
+	switch FirstCommitPrefix(strings.ToLower(s)) {
+	case FirstCommitSkip:
+		return FirstCommitSkip
+	case FirstCommitDefaultBranch:
+		return FirstCommitDefaultBranch
+	}
+	return FirstCommitBranch
+}
+
+// firstCommitBranchName is the branch to prefix the first commit with, given the
+// branch HEAD names; "" means no prefix
+func (o *PrepareCommitMsgOptions) firstCommitBranchName(branch string) string {
+	switch o.FirstCommitPrefix {
+	case FirstCommitSkip:
+		return ""
+	case FirstCommitDefaultBranch:
+		if o.InitialBranch != "" {
+			return o.InitialBranch
+		}
+	}
+	return branch
+}
+
+// applyRootCommitTemplate starts an empty message for the first commit of a repo with
+// the root commit template, e.g. 'chore: initial commit'
+func (o *PrepareCommitMsgOptions) applyRootCommitTemplate() error {
+	if !o.isFirstCommit() {
+		return nil
+	}
+	return o.startMessageWith(o.RootCommitTemplate)
+}
+
+// isFirstCommit is true while HEAD names a branch with no commits yet
+func (o *PrepareCommitMsgOptions) isFirstCommit() bool {
+	state, err := repostate.Detect(o.Repo)
+	return err == nil && state.Unborn
+}
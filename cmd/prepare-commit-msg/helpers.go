@@ -4,6 +4,9 @@ import (
 	"bytes"
 	"fmt"
 	"github.com/apex/log"
+	"github.com/davidalpert/go-githooks/pkg/exitcode"
+	"github.com/davidalpert/go-githooks/pkg/guilog"
+	"github.com/davidalpert/go-githooks/pkg/output"
 	"os"
 	"os/exec"
 	"strings"
@@ -14,25 +17,28 @@ func checkError(msg string, err error) {
 		return
 	}
 
-	log.WithError(err).Error(msg)
+	code := exitcode.Of(err)
+	output.ConfigureLog()
+	log.WithError(err).WithField("category", code.Name()).Error(msg)
 	fmt.Printf("%s: %#v\n", msg, err)
-	os.Exit(1)
+	guilog.Stop()
+	os.Exit(int(code))
 }
 
 /*
-	cmd := exec.Command("tr", "a-z", "A-Z")
-	cmd.Stdin = strings.NewReader("some input")
-	var out bytes.Buffer
-	cmd.Stdout = &out
-	err := cmd.Run()
- */
+cmd := exec.Command("tr", "a-z", "A-Z")
+cmd.Stdin = strings.NewReader("some input")
+var out bytes.Buffer
+cmd.Stdout = &out
+err := cmd.Run()
+*/
 func execAndCaptureOutput(cmdDescription string, cmdName string, arg ...string) (string, error) {
 	cmd := exec.Command(cmdName, arg...)
 	var out bytes.Buffer
 	cmd.Stdout = &out
 	err := cmd.Run()
 	if err != nil {
-		return "", fmt.Errorf("%s failed: %v", cmdDescription, err)
+		return "", exitcode.Wrap(exitcode.Internal, fmt.Errorf("%s failed: %v", cmdDescription, err))
 	}
 
 	return strings.TrimSpace(out.String()), nil
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"bytes"
+	"github.com/davidalpert/go-githooks/pkg/message"
+	"strings"
+)
+
+// canonicalCoauthors rewrites the coauthors to their identities in the mailmap and
+// drops anyone listed twice, e.g. by git-mob under an old email and by a pairing file
+func (o *PrepareCommitMsgOptions) canonicalCoauthors() {
+	if o.Mailmap == nil || len(o.CoauthorsMarkupBytes) == 0 {
+		return
+	}
+
+	seen := map[string]bool{}
+	lines := make([][]byte, 0)
+	for _, line := range bytes.Split(bytes.TrimSpace(o.CoauthorsMarkupBytes), nl) {
+		found := message.Credits(line)
+		if len(found) == 0 {
+			lines = append(lines, line)
+			continue
+		}
+		c := o.Mailmap.Coauthor(found[0])
+		if seen[strings.ToLower(c.Email)] {
+			continue
+		}
+		seen[strings.ToLower(c.Email)] = true
+		lines = append(lines, []byte(c.String()))
+	}
+	o.CoauthorsMarkupBytes = bytes.Join(lines, nl)
+}
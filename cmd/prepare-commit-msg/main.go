@@ -3,20 +3,43 @@ package main
 import (
 	"bytes"
 	"fmt"
+	"github.com/davidalpert/go-githooks/pkg/branchname"
+	"github.com/davidalpert/go-githooks/pkg/budget"
+	"github.com/davidalpert/go-githooks/pkg/bypass"
+	"github.com/davidalpert/go-githooks/pkg/cienv"
+	"github.com/davidalpert/go-githooks/pkg/crash"
+	"github.com/davidalpert/go-githooks/pkg/exitcode"
+	"github.com/davidalpert/go-githooks/pkg/fileio"
+	"github.com/davidalpert/go-githooks/pkg/gitconfig"
+	"github.com/davidalpert/go-githooks/pkg/guilog"
+	"github.com/davidalpert/go-githooks/pkg/mailmap"
+	"github.com/davidalpert/go-githooks/pkg/message"
+	"github.com/davidalpert/go-githooks/pkg/mobsession"
+	"github.com/davidalpert/go-githooks/pkg/msgbackup"
+	"github.com/davidalpert/go-githooks/pkg/pairing"
+	"github.com/davidalpert/go-githooks/pkg/presets"
+	"github.com/davidalpert/go-githooks/pkg/readonly"
+	"github.com/davidalpert/go-githooks/pkg/scripts"
+	"github.com/davidalpert/go-githooks/pkg/secrets"
+	"github.com/davidalpert/go-githooks/pkg/staged"
+	"github.com/davidalpert/go-githooks/pkg/telemetry"
+	"github.com/davidalpert/go-githooks/pkg/updatecheck"
+	"github.com/davidalpert/go-githooks/pkg/vcshost"
 	"github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/config"
-	"io/ioutil"
+	"github.com/go-git/go-git/v5/plumbing/object"
 	"os"
 	"path/filepath"
 	"regexp"
 	"strings"
+	"time"
 )
 
 var (
 	Version = "n/a"
-	empty = []byte("")
-	space = []byte(" ")
-	nl = []byte("\n")
+	empty   = []byte("")
+	space   = []byte(" ")
+	nl      = []byte("\n")
 )
 
 /*
@@ -43,15 +66,75 @@ type PrepareCommitMsgOptions struct {
 	// these are configuration options, set through env vars
 	PrefixWithBranch           bool
 	PrefixWithBranchExclusions []string
+	PrefixSkip                 []PrefixSkipRule
 	PrefixWithBranchTemplate   string
+	PrefixPlacement            PrefixPlacement
+	SubjectTemplate            string
+	Annotations                []Annotation
+	RootCommitTemplate         string // starts the message of a repo's first commit instead
+	FirstCommitPrefix          FirstCommitPrefix
+	AmendPrefix                AmendPrefix
+	BranchCheck                BranchCheck
+	InitialBranch              string // init.defaultBranch
+	ScopeMap                   staged.ScopeMap
+	DetachedHeadPrefix         DetachedHeadPrefix
+	RememberBranchPrefix       bool
+	BranchName                 branchname.Options
+	RevertBehavior             ReplayBehavior
+	CherryPickBehavior         ReplayBehavior
+	BuiltOn                    BuiltOnStamp
+	Topic                      TopicSource
+	WarnOnEmptyMessage         bool
+	SignOff                    bool
+	MarkPrepared               bool
+	AnnotateChanges            bool
+	ScriptsEnabled             bool
+	PairingProviders           []string
+	PairingFile                string
+	PairingLiveShareSession    string
+	PairingMaxAge              time.Duration
+	PathCoauthors              pairing.Owners
+	CodeOwnersFile             string
+	PathCoauthorRotation       string
+	CoauthorsTTL               time.Duration
+	SquashCoauthors            bool
+	CoauthorRoles              map[string]string // role by lower-cased email, "" for everyone else
+	SessionCoauthorRole        string
+	SmartCommits               bool
+	MergeTickets               bool
+	MergeStripPrefix           []string
+	TicketPattern              string
+	MobSessionFile             string
+	LineEndings                message.LineEndings
+	KeepBOM                    bool
+	CleanupMode                message.CleanupMode
+	TelemetryEnabled           bool
+
+	Telemetry *telemetry.Recorder
+	Budget    *budget.Budget
+	Bypass    *bypass.Token    // nil unless 'go-githooks bypass' is in effect
+	Mailmap   *mailmap.Mailmap // nil when go-githooks.mailmap is off
+	Remote    *vcshost.Remote  // where the repo is hosted and the branch goes; nil without a remote
+	VCSAPI    vcshost.API
 
 	CommitMessageBytes   []byte
 	CoauthorsMarkupBytes []byte
+
+	originalMessageBytes []byte // as git offered it, before any transformer ran
+	messageFileBytes     []byte // as read from the file, before it was normalized
+	messageEncoding      message.Encoding
+	stagedSummary        *staged.Summary // analysed when a template first needs it
+	amended              *object.Commit  // read when the amended commit is first needed
+
+	options          []Option // given to Build, applied again over env vars and config
+	coauthorProvider CoauthorProvider
+	configSource     ConfigSource
 }
 
 func NewOptions(repo *git.Repository) *PrepareCommitMsgOptions {
 	return &PrepareCommitMsgOptions{
-		Repo: repo,
+		Repo:   repo,
+		Budget: budget.New("prepare-commit-msg"),
 	}
 }
 
@@ -59,7 +142,7 @@ func (o *PrepareCommitMsgOptions) Prepare(args []string) error {
 	// parse positional args
 	numArgs := len(args)
 	if !(1 <= numArgs && numArgs <= 3) {
-		return fmt.Errorf("expected 'version' or 2 args or 3 args, got %d: %v", numArgs, args)
+		return exitcode.Wrap(exitcode.Usage, fmt.Errorf("expected 'version' or 2 args or 3 args, got %d: %v", numArgs, args))
 	}
 
 	o.CommitMessageFile = args[0]
@@ -73,11 +156,18 @@ func (o *PrepareCommitMsgOptions) Prepare(args []string) error {
 	}
 
 	_, err := o.Repo.ConfigScoped(config.GlobalScope)
-	checkError("repoConfig", err)
+	checkError("repoConfig", exitcode.Wrap(exitcode.Config, err))
 
 	o.setDefaultOptions()
-	o.overrideFromEnv() // TODO: replace with global .gitonfig
+	o.overrideFromEnv()  // TODO: replace with global .gitonfig
 	o.overrideFromRepo() // HACK: for now, allow local repo config to override default config
+	for _, opt := range o.options {
+		if err := opt(o); err != nil {
+			return exitcode.Wrap(exitcode.Config, err)
+		}
+	}
+
+	o.Telemetry = telemetry.NewRecorder("prepare-commit-msg", o.TelemetryEnabled)
 
 	return nil
 }
@@ -86,104 +176,246 @@ func (o *PrepareCommitMsgOptions) setDefaultOptions() {
 	o.PrefixWithBranch = false
 	o.PrefixWithBranchExclusions = []string{"main", "develop"}
 	o.PrefixWithBranchTemplate = "[%s]"
+	o.PrefixPlacement = PrefixAtStart
+	o.SubjectTemplate = ""
+	o.RootCommitTemplate = ""
+	o.FirstCommitPrefix = FirstCommitBranch
+	o.AmendPrefix = AmendOriginal
+	o.BranchCheck = BranchCheckOff
+	o.InitialBranch = ""
+	o.ScopeMap = staged.ScopeMap{}
+	o.DetachedHeadPrefix = DetachedSkip
+	o.RememberBranchPrefix = false
+	o.BranchName = branchname.Options{Form: branchname.NFC}
+	o.RevertBehavior = ReplayRefs
+	o.CherryPickBehavior = ReplayRefs
+	o.BuiltOn = BuiltOnOff
+	o.Topic = TopicOff
+	o.WarnOnEmptyMessage = false
+	o.SignOff = false
+	o.MarkPrepared = false
+	o.CleanupMode = message.CleanupDefault
+	o.AnnotateChanges = false
+	o.PairingProviders = []string{}
+	o.PairingFile = pairing.DefaultFile
+	o.PairingLiveShareSession = pairing.DefaultLiveShareSession
+	o.PairingMaxAge = pairing.DefaultMaxAge
+	o.PathCoauthors = pairing.Owners{}
+	o.CodeOwnersFile = ""
+	o.PathCoauthorRotation = pairing.RotateAll
+	o.CoauthorsTTL = 0
+	o.SquashCoauthors = true
+	o.CoauthorRoles = map[string]string{}
+	o.SessionCoauthorRole = ""
+	o.SmartCommits = false
+	o.MergeTickets = false
+	o.MergeStripPrefix = []string{}
+	o.TicketPattern = `[A-Z][A-Z0-9]+-[0-9]+`
+	o.LineEndings = message.PreserveLineEndings
+	o.KeepBOM = false
+	o.MobSessionFile = mobsession.StorePath()
 }
 
 func (o *PrepareCommitMsgOptions) overrideFromEnv() {
 	o.PrefixWithBranch = getEnvOrDefaultBool("GIT_COMMIT_MSG_PREFIX_WITH_BRANCH_NAME", o.PrefixWithBranch)
 	o.PrefixWithBranchExclusions = getEnvOrDefaultStringSlice("GIT_COMMIT_MSG_PREFIX_WITH_BRANCH_NAME_EXCLUSIONS", o.PrefixWithBranchExclusions...)
 	o.PrefixWithBranchTemplate = getEnvOrDefaultString("GIT_COMMIT_MSG_PREFIX_WITH_BRANCH_NAME_TEMPLATE", o.PrefixWithBranchTemplate)
+	o.RevertBehavior = ReplayBehaviorFromString(getEnvOrDefaultString("GIT_COMMIT_MSG_REVERT_BEHAVIOR", string(o.RevertBehavior)))
+	o.CherryPickBehavior = ReplayBehaviorFromString(getEnvOrDefaultString("GIT_COMMIT_MSG_CHERRY_PICK_BEHAVIOR", string(o.CherryPickBehavior)))
+	if r := os.Getenv("GIT_COMMIT_MSG_COAUTHOR_ROLE"); r != "" {
+		if o.SessionCoauthorRole = message.RoleFromString(r); o.SessionCoauthorRole == "" {
+			fmt.Printf("could not parse GIT_COMMIT_MSG_COAUTHOR_ROLE '%s', expected one of: %s\n", r, strings.Join(message.Roles, ", "))
+		}
+	}
 }
 
 func (o *PrepareCommitMsgOptions) overrideFromRepo() {
-	cfg, err := o.Repo.ConfigScoped(config.GlobalScope)
+	load := o.configSource
+	if load == nil {
+		load = gitconfig.Load
+	}
+	cfg, err := load(o.Repo)
 	if err != nil {
 		return
 	}
+	if err = presets.Apply(cfg); err != nil {
+		fmt.Printf("could not apply preset: %v\n", err)
+	}
 
-	o.PrefixWithBranch = getRepoConfigOptionOrDefaultBool(cfg, "go-githooks", "prepare-commit-message", "prefixWithBranch", o.PrefixWithBranch)
-	o.PrefixWithBranchExclusions = getRepoConfigOptionOrDefaultSlice(cfg, "go-githooks", "prepare-commit-message", "prefixBranchExclusions", o.PrefixWithBranchExclusions)
-	o.PrefixWithBranchTemplate = getRepoConfigOptionOrDefaultString(cfg, "go-githooks", "prepare-commit-message", "prefixWithBranchTemplate", o.PrefixWithBranchTemplate)
+	o.PrefixWithBranch = gitconfig.GetBool(cfg, "go-githooks", "prepare-commit-message", "prefixWithBranch", o.PrefixWithBranch)
+	o.PrefixWithBranchExclusions = gitconfig.GetSlice(cfg, "go-githooks", "prepare-commit-message", "prefixBranchExclusions", o.PrefixWithBranchExclusions)
+	if o.PrefixSkip, err = parsePrefixSkipRules(gitconfig.GetSlice(cfg, "go-githooks", "prepare-commit-message", "prefixSkip", nil)); err != nil {
+		fmt.Printf("%v\n", err)
+	}
+	if o.Annotations, err = parseAnnotations(gitconfig.GetSlice(cfg, "go-githooks", "prepare-commit-message", "annotations", nil)); err != nil {
+		fmt.Printf("%v\n", err)
+	}
+	o.PrefixWithBranchTemplate = gitconfig.GetString(cfg, "go-githooks", "prepare-commit-message", "prefixWithBranchTemplate", o.PrefixWithBranchTemplate)
+	o.Remote = vcshost.Detect(cfg).Track(o.Repo, cfg)
+	o.PrefixWithBranchTemplate = o.Remote.Expand(o.PrefixWithBranchTemplate)
+	o.PrefixPlacement = PrefixPlacementFromString(gitconfig.GetString(cfg, "go-githooks", "prepare-commit-message", "prefixPlacement", string(o.PrefixPlacement)))
+	o.SubjectTemplate = gitconfig.GetString(cfg, "go-githooks", "prepare-commit-message", "subjectTemplate", o.SubjectTemplate)
+	o.RootCommitTemplate = gitconfig.GetString(cfg, "go-githooks", "prepare-commit-message", "rootCommitTemplate", o.RootCommitTemplate)
+	o.FirstCommitPrefix = FirstCommitPrefixFromString(gitconfig.GetString(cfg, "go-githooks", "prepare-commit-message", "firstCommitPrefix", string(o.FirstCommitPrefix)))
+	o.AmendPrefix = AmendPrefixFromString(gitconfig.GetString(cfg, "go-githooks", "prepare-commit-message", "amendPrefix", string(o.AmendPrefix)))
+	o.BranchCheck = BranchCheckFromString(gitconfig.GetString(cfg, "go-githooks", "prepare-commit-message", "branchCheck", string(o.BranchCheck)))
+	o.VCSAPI.BaseURL = gitconfig.GetString(cfg, "go-githooks", "vcs", "apiUrl", o.VCSAPI.BaseURL)
+	if o.BranchCheck == BranchCheckPull {
+		// apiUrl and token are only read from the user's own config, so with both set
+		// the user chose which server gets the token, rather than whoever wrote the repo's
+		token := gitconfig.GetString(cfg, "go-githooks", "vcs", "token", "")
+		if o.VCSAPI.BaseURL == "" || token == "" {
+			fmt.Printf("go-githooks: branchCheck = pr needs vcs.apiUrl and vcs.token in .git/config or ~/.gitconfig; checking the remote only\n")
+			o.BranchCheck = BranchCheckRemote
+		} else if o.VCSAPI.Token, err = secrets.Resolve(token); err != nil {
+			fmt.Printf("could not resolve the vcs token: %v\n", err)
+		}
+	}
+	o.InitialBranch = cfg.Init.DefaultBranch
+	o.ScopeMap = staged.ParseScopeMap(gitconfig.GetSlice(cfg, "go-githooks", "scope", "map", nil))
+	o.DetachedHeadPrefix = DetachedHeadPrefixFromString(gitconfig.GetString(cfg, "go-githooks", "prepare-commit-message", "detachedHeadPrefix", string(o.DetachedHeadPrefix)))
+	o.RememberBranchPrefix = gitconfig.GetBool(cfg, "go-githooks", "prepare-commit-message", "rememberBranchPrefix", o.RememberBranchPrefix)
+	form := gitconfig.GetString(cfg, "go-githooks", "prepare-commit-message", "branchNormalization", o.BranchName.Form)
+	if err := branchname.ValidForm(form); err == nil {
+		o.BranchName.Form = form
+	} else {
+		fmt.Println(err)
+	}
+	o.BranchName.Transliterate = gitconfig.GetBool(cfg, "go-githooks", "prepare-commit-message", "branchTransliterate", o.BranchName.Transliterate)
+	if n, err := gitconfig.GetInt(cfg, "go-githooks", "prepare-commit-message", "branchMaxLength", o.BranchName.MaxLength); err == nil {
+		o.BranchName.MaxLength = n
+	} else {
+		fmt.Println(err)
+	}
+	o.RevertBehavior = ReplayBehaviorFromString(gitconfig.GetString(cfg, "go-githooks", "prepare-commit-message", "revertBehavior", string(o.RevertBehavior)))
+	o.CherryPickBehavior = ReplayBehaviorFromString(gitconfig.GetString(cfg, "go-githooks", "prepare-commit-message", "cherryPickBehavior", string(o.CherryPickBehavior)))
+	o.BuiltOn = BuiltOnStampFromString(gitconfig.GetString(cfg, "go-githooks", "prepare-commit-message", "builtOn", string(o.BuiltOn)))
+	o.Topic = TopicSourceFromString(gitconfig.GetString(cfg, "go-githooks", "prepare-commit-message", "topic", string(o.Topic)))
+	o.WarnOnEmptyMessage = gitconfig.GetBool(cfg, "go-githooks", "prepare-commit-message", "warnOnEmptyMessage", o.WarnOnEmptyMessage)
+	o.SignOff = gitconfig.GetBool(cfg, "go-githooks", "prepare-commit-message", "signOff", o.SignOff)
+	o.MarkPrepared = gitconfig.GetBool(cfg, "go-githooks", "prepare-commit-message", "markPrepared", o.MarkPrepared)
+	o.AnnotateChanges = gitconfig.GetBool(cfg, "go-githooks", "prepare-commit-message", "annotateChanges", o.AnnotateChanges)
+	o.SquashCoauthors = gitconfig.GetBool(cfg, "go-githooks", "prepare-commit-message", "squashCoauthors", o.SquashCoauthors)
+	o.SmartCommits = gitconfig.GetBool(cfg, "go-githooks", "prepare-commit-message", "smartCommits", o.SmartCommits)
+	o.MergeTickets = gitconfig.GetBool(cfg, "go-githooks", "prepare-commit-message", "mergeTickets", o.MergeTickets)
+	o.MergeStripPrefix = gitconfig.GetSlice(cfg, "go-githooks", "prepare-commit-message", "mergeStripPrefix", o.MergeStripPrefix)
+	o.TicketPattern = gitconfig.GetString(cfg, "go-githooks", "commit-message", "ticketPattern", o.TicketPattern)
+	o.LineEndings = message.LineEndingsFromString(gitconfig.GetString(cfg, "go-githooks", "commit-message", "lineEndings", string(o.LineEndings)))
+	o.KeepBOM = gitconfig.GetBool(cfg, "go-githooks", "commit-message", "keepByteOrderMark", o.KeepBOM)
+	o.CleanupMode = message.CleanupModeFromString(gitconfig.GetString(cfg, "commit", "", "cleanup", string(o.CleanupMode)))
+	o.TelemetryEnabled = gitconfig.GetBool(cfg, "go-githooks", "telemetry", "enabled", o.TelemetryEnabled)
+	o.ScriptsEnabled = scripts.Enabled(o.Repo)
+	if o.Bypass, err = bypass.Active(bypass.Dir(o.Repo), time.Now()); err != nil {
+		fmt.Printf("could not read the bypass: %v\n", err)
+	}
+	if gitconfig.GetBool(cfg, "go-githooks", "", "mailmap", true) {
+		if o.Mailmap, err = mailmap.Load(o.Repo, cfg); err != nil {
+			fmt.Printf("could not read the mailmap: %v\n", err)
+		}
+	}
+	o.PairingProviders = gitconfig.GetSlice(cfg, "go-githooks", "pairing", "providers", o.PairingProviders)
+	o.PairingFile = gitconfig.GetString(cfg, "go-githooks", "pairing", "file", o.PairingFile)
+	o.PairingLiveShareSession = gitconfig.GetString(cfg, "go-githooks", "pairing", "liveShareSession", o.PairingLiveShareSession)
+	if entries := gitconfig.GetAll(cfg, "go-githooks", "pairing", "pathCoauthors"); len(entries) > 0 {
+		if owners, err := pairing.ParseOwners(entries); err == nil {
+			o.PathCoauthors = owners
+		} else {
+			fmt.Println(err)
+		}
+	}
+	o.CodeOwnersFile = gitconfig.GetString(cfg, "go-githooks", "pairing", "codeowners", o.CodeOwnersFile)
+	o.PathCoauthorRotation = gitconfig.GetString(cfg, "go-githooks", "pairing", "pathRotation", o.PathCoauthorRotation)
+	if roles := gitconfig.GetAll(cfg, "go-githooks", "pairing", "role"); len(roles) > 0 {
+		if r, err := parseCoauthorRoles(roles); err == nil {
+			o.CoauthorRoles = r
+		} else {
+			fmt.Println(err)
+		}
+	}
+	if t := gitconfig.GetString(cfg, "go-githooks", "prepare-commit-message", "coauthorsTTL", ""); t != "" {
+		if d, err := time.ParseDuration(t); err == nil {
+			o.CoauthorsTTL = d
+		} else {
+			fmt.Printf("could not parse coauthorsTTL '%s': %v\n", t, err)
+		}
+	}
+	if a := gitconfig.GetString(cfg, "go-githooks", "pairing", "maxAge", ""); a != "" {
+		if d, err := time.ParseDuration(a); err == nil {
+			o.PairingMaxAge = d
+		} else {
+			fmt.Printf("could not parse pairing maxAge '%s': %v\n", a, err)
+		}
+	}
+	if err := o.Budget.Configure(cfg); err != nil {
+		fmt.Printf("%v\n", err)
+	}
 }
 
 func (o *PrepareCommitMsgOptions) Execute() error {
-	if o.PrefixWithBranch {
-		if err := o.prependBranchName(); err != nil {
-			fmt.Printf("error prefixing branch name: %v\n", err)
-		}
+	if o.MarkPrepared && o.alreadyPrepared() {
+		return nil
 	}
 
-	if len(o.CoauthorsMarkupBytes) > 0 {
-		if err := o.appendCoauthorMarkup(); err != nil {
-			fmt.Printf("error prefixing branch name: %v\n", err)
+	replayBehavior := o.replayBehavior(o.detectReplayedCommit())
+	if replayBehavior == ReplaySkip {
+		return nil
+	}
+
+	o.originalMessageBytes = o.CommitMessageBytes
+	for _, t := range o.transformers(replayBehavior) {
+		if err := o.runTransformer(t); err != nil {
+			fmt.Printf("error %s: %v\n", t.description, err)
 		}
 	}
+	if note := o.Budget.Note(); note != "" {
+		fmt.Printf("%s\n", note)
+	}
 
 	return nil
 }
 
 func (o *PrepareCommitMsgOptions) prependBranchName() error {
-	head, err := o.Repo.Head()
+	// git rebase --autosquash matches the subject after fixup! to its target verbatim
+	if message.Autosquash(message.Subject(o.CommitMessageBytes)) != "" {
+		return nil
+	}
+	branchName, err := o.prefixBranchName()
 	if err != nil {
 		return err
 	}
-
-	//fmt.Printf("repo: %#v\n", o.Repo)
-	//fmt.Printf("head: %#v\n", head)
-	//fmt.Printf("name: %#v\n", head.Name())
-
-	branchName := head.Name().Short()
 	if branchName == "" {
 		return nil
-	} else if branchName == "HEAD" {
-		baseBranchName, err := resolveHeadDuringRebase()
-		if err != nil {
-			fmt.Printf("could not fine branch name: %v", err)
-			return nil
-		}
-
-		branchName = baseBranchName
+	}
+	skipped, err := o.prefixSkipped(branchName)
+	if err != nil {
+		return err
+	}
+	if skipped != "" {
+		return nil
 	}
 
-	updated := make([]byte, 0)
+	template, err := o.expandStagedVars(o.PrefixWithBranchTemplate)
+	if err != nil {
+		return err
+	}
 
-	branchPrefix := strings.TrimSpace(fmt.Sprintf(o.PrefixWithBranchTemplate, branchName))
+	prefix := fmt.Sprintf(template, o.BranchName.Prepare(branchName))
 	trimmedMsg := bytes.TrimSpace(o.CommitMessageBytes)
 	if bytes.HasPrefix(trimmedMsg, []byte("#")) {
-		// inject to separate git comments from the prefix
-		trimmedMsg = append(empty, bytes.Join([][]byte{ nl,
-			nl,
-			trimmedMsg,
-		},empty)...)
-	}
-	if !bytes.HasPrefix(trimmedMsg, []byte(branchPrefix)) {
-		updated = append(updated, bytes.Join([][]byte{
-			[]byte(fmt.Sprintf(o.PrefixWithBranchTemplate, branchName)), []byte(" "), trimmedMsg, nl,
-			nl,
-		}, empty)...)
-	} else {
-		updated = append(updated, bytes.Join([][]byte{
-			trimmedMsg, nl,
-			nl,
-		}, empty)...)
-	}
-	o.CommitMessageBytes = updated
-
-	return nil
-}
-
-func resolveHeadDuringRebase() (string, error) {
-	branchList, err := execAndCaptureOutput("list branches", "git", "branch", "--list")
-	if err != nil {
-		return "", err
+		// a blank line separates git comments from the prefix
+		o.CommitMessageBytes = join([]byte(prefix), space, nl, nl, trimmedMsg, nl, nl)
+		return nil
 	}
-	// * (no branch, rebasing feature/super-awesome-4)
-	re := regexp.MustCompile("\\* \\(no branch, rebasing ([^)]+)\\)")
-	match := re.FindStringSubmatch(branchList)
-	if len(match) > 1 {
-		return match[1], nil
+	// the prefix may already be there in the other normalization form, e.g. typed on another machine
+	if hasBranchPrefix([]byte(branchname.Normalize(string(trimmedMsg), o.BranchName.Form)), strings.TrimSpace(prefix)) {
+		if !isNormalized(o.CommitMessageBytes, trimmedMsg) {
+			o.CommitMessageBytes = join(trimmedMsg, nl, nl)
+		}
+		return nil
 	}
+	o.CommitMessageBytes = join(append(o.placeBranchPrefix(trimmedMsg, prefix), nl, nl)...)
 
-	return "", fmt.Errorf("could not find the current branch")
+	return nil
 }
 
 func (o *PrepareCommitMsgOptions) appendCoauthorMarkup() error {
@@ -192,55 +424,105 @@ func (o *PrepareCommitMsgOptions) appendCoauthorMarkup() error {
 		return nil
 	}
 	//fmt.Printf("adding coauthors\n---\n%s\n---\n", string(o.CoauthorsMarkupBytes))
-	re := regexp.MustCompile(`(?im)^co-authored-by: [^>]+>`)
-	cleanedB := bytes.TrimSpace(re.ReplaceAll(o.CommitMessageBytes, empty))
+	cleanedB := o.CommitMessageBytes
+	if hasLinePrefixFold(cleanedB, "co-authored-by: ") {
+		cleanedB = coauthorLineRe.ReplaceAll(cleanedB, empty)
+	}
 	coauthorsB := bytes.TrimSpace(o.CoauthorsMarkupBytes)
+	for _, c := range message.Credits(coauthorsB) {
+		if c.Role != "" {
+			// credited in another role already, e.g. when amending
+			cleanedB = regexp.MustCompile(`(?im)^`+regexp.QuoteMeta(c.String())+`[ \t]*$`).ReplaceAll(cleanedB, empty)
+		}
+	}
+	cleanedB = bytes.TrimSpace(cleanedB)
 
-	updated := make([]byte, 0)
-	if commentPos := strings.Index(string(cleanedB), "# "); commentPos > -1 {
+	if commentPos := bytes.Index(cleanedB, []byte("# ")); commentPos > -1 {
 		gitMessage := bytes.TrimSpace(cleanedB[0:commentPos])
 		gitComments := cleanedB[commentPos:]
-		updated = append(updated, bytes.Join([][]byte{
-			gitMessage, nl,
-			nl,
-			coauthorsB, nl,
-			nl, gitComments,
-		}, empty)...)
+		o.CommitMessageBytes = join(gitMessage, nl, nl, coauthorsB, nl, nl, gitComments)
 	} else {
-		updated = append(updated, bytes.Join([][]byte{
-			cleanedB, nl,
-			nl,
-			coauthorsB, nl,
-			nl,
-		}, empty)...)
+		o.CommitMessageBytes = join(cleanedB, nl, nl, coauthorsB, nl, nl)
+	}
+
+	return nil
+}
+
+func (o *PrepareCommitMsgOptions) appendSignOff() error {
+	cfg, err := o.Repo.ConfigScoped(config.GlobalScope)
+	if err != nil {
+		return err
+	}
+	if cfg.User.Name == "" || cfg.User.Email == "" {
+		return exitcode.Wrap(exitcode.Config, fmt.Errorf("user.name and user.email must be set to sign off"))
 	}
-	//fmt.Printf("udpated:\n---\n%s\n---\n", string(updated))
-	o.CommitMessageBytes = updated
 
+	name, email := o.Mailmap.Map(cfg.User.Name, cfg.User.Email)
+	o.CommitMessageBytes = message.AppendTrailer(o.CommitMessageBytes, fmt.Sprintf("Signed-off-by: %s <%s>", name, email))
+	return nil
+}
+
+func (o *PrepareCommitMsgOptions) warnOnEmptyMessage() error {
+	if message.Effective(o.CommitMessageBytes, o.PrefixWithBranchTemplate) != "" {
+		return nil
+	}
+
+	o.CommitMessageBytes = message.InsertComment(o.CommitMessageBytes,
+		"go-githooks: this message has no subject yet, only a branch prefix and trailers;",
+		"go-githooks: write a subject on the first line or the commit records only those.",
+	)
 	return nil
 }
 
 func (o *PrepareCommitMsgOptions) readCommitMessageFromDisk() error {
-	msg, err := ioutil.ReadFile(o.CommitMessageFile)
+	msg, err := fileio.ReadFile(o.CommitMessageFile)
 	if os.IsNotExist(err) {
 		msg = empty
 	} else if err != nil {
 		return fmt.Errorf("could not read '%s': %v", o.CommitMessageFile, err)
 	}
-	o.CommitMessageBytes = msg
+	o.messageFileBytes = msg
+	o.CommitMessageBytes, o.messageEncoding = message.Normalize(msg)
 	return nil
 }
 
+// formattedMessage is the message as it is written back, with the line endings and
+// byte order mark the file was read with unless lineEndings or keepByteOrderMark say otherwise
+func (o *PrepareCommitMsgOptions) formattedMessage() []byte {
+	return o.messageEncoding.Format(o.CommitMessageBytes, o.LineEndings, o.KeepBOM)
+}
+
 func (o *PrepareCommitMsgOptions) readCoauthorsMessage() error {
-	coauthorMarkup, err := execAndCaptureOutput("list mob coauthors", "git", "mob-print")
+	// a bot committing in CI has no mob to credit
+	if cienv.Detected() {
+		return nil
+	}
+	provider := o.coauthorProvider
+	if provider == nil {
+		provider = GitMob
+	}
+	coauthorMarkup, err := provider.CoauthorMarkup()
 	if err != nil {
 		fmt.Printf("could not list the mob: %v\n", err)
 	}
-	o.CoauthorsMarkupBytes = []byte(coauthorMarkup)
+	o.CoauthorsMarkupBytes = coauthorMarkup
+	if err := o.addPairingCoauthors(); err != nil {
+		return err
+	}
+	if err := o.addPathCoauthors(); err != nil {
+		return err
+	}
+	o.canonicalCoauthors()
+	o.assignCoauthorRoles()
 	return nil
 }
 
 func main() {
+	if updatecheck.Requested() {
+		updatecheck.Run()
+		return
+	}
+
 	argsWithoutProg := os.Args[1:]
 	numArgs := len(argsWithoutProg)
 	//fmt.Printf("args: %#v\n", argsWithoutProg)
@@ -263,9 +545,16 @@ func main() {
 	if err == git.ErrRepositoryNotExists {
 		err = fmt.Errorf("could not find repo at '%s' (resovled to: %s): %v", repoDir, absDir, err)
 	}
-	checkError("read git repo", err)
+	checkError("read git repo", exitcode.Wrap(exitcode.Usage, err))
+	defer crash.Recover(crash.Dir(repo), "prepare-commit-msg", Version)
+	if err := guilog.Start(repo, "prepare-commit-msg"); err != nil {
+		fmt.Printf("could not keep the output for GUI clients: %v\n", err)
+	}
+	defer guilog.Stop()
+	updatecheck.Start(repo, Version)
 
-	o := NewOptions(repo)
+	o, err := Build(repo)
+	checkError("build options", err)
 
 	err = o.Prepare(argsWithoutProg)
 	checkError("prepare options", err)
@@ -273,20 +562,42 @@ func main() {
 	err = o.readCommitMessageFromDisk()
 	checkError("readCommitMessage", err)
 
+	// skip listing the mob (and everything else) when this message was prepared before
+	if o.MarkPrepared && o.alreadyPrepared() {
+		return
+	}
+
+	original := o.CommitMessageBytes
+
 	err = o.readCoauthorsMessage()
-	checkError("readCoauthorsMessage", err)
+	checkError("readCoauthorsMessage", readonly.Allow("prepare-commit-msg", err))
 
 	err = o.Execute()
-	checkError("executing", err)
+	checkError("executing", readonly.Allow("prepare-commit-msg", err))
+
+	if readonly.Enabled() {
+		readonly.Changes("prepare-commit-msg", "the message", original, o.CommitMessageBytes)
+		return
+	}
 
 	//o.CommitMessageBytes = append(o.CommitMessageBytes, bytes.Join([][]byte{
 	//	space, []byte("foo"), nl,
 	//}, empty)...)
 
-	err = os.WriteFile(o.CommitMessageFile, o.CommitMessageBytes, os.ModePerm)
+	formatted := o.formattedMessage()
+	if !bytes.Equal(o.messageFileBytes, formatted) {
+		if err := msgbackup.Save(msgbackup.Dir(o.Repo), "prepare-commit-msg", o.CommitMessageFile, time.Now()); err != nil {
+			fmt.Printf("could not back up the message: %v\n", err)
+		}
+	}
+	err = fileio.ReplaceFile(o.CommitMessageFile, formatted, os.ModePerm)
 	if err != nil {
 		checkError("writing file", fmt.Errorf("could not write commit message '%s': %v", o.CommitMessageFile, err))
 	}
+
+	if err = o.Telemetry.Flush(); err != nil {
+		fmt.Printf("could not save telemetry: %v\n", err)
+	}
 }
 
 func printVersion(errs ...error) {
@@ -299,12 +610,119 @@ func printVersion(errs ...error) {
 func printHelp() {
 	fmt.Printf("help: %s\n", Version)
 	fmt.Printf(`
-configure go-githooks per-repo in .git/config:
+configure go-githooks per-repo in .git/config; lists are comma-separated, or the key repeated once per item
+(git config --add) for items containing commas:
 
 [go-githooks "prepare-commit-message"]
     prefixWithBranch = false
-    prefixWithBranchTemplate = [%%s]
+    prefixWithBranchTemplate = [%%s]   # may use {host}, {org}, {repo} and {provider} (see: go-githooks vcs), the
+                                 # branch's {upstream} and {remoteName}, the remote's {defaultBranch}
+                                 # and {filesChanged}, {primaryPackage}, {languagesTouched}, {testsTouched}, {scope}
+                                 # and {inferredType} (docs, test, ci or build when only those files are staged, else feat)
+    subjectTemplate =            # start an empty message with this, e.g. 'feat({scope}): '; may use the staged
+                                 # variables above ({primaryPackage} is the most-touched directory, {scope} the one
+                                 # scope of every staged path, if they share one); when reusing a commit's
+                                 # message (-c, -C, --amend), {amendedSubject} and {amendedTicket} are its own
+    rootCommitTemplate =         # start the empty message of a repo's first commit with this instead, e.g.
+                                 # 'chore: initial commit'
+    firstCommitPrefix = branch   # branch | skip | default-branch: prefix a repo's first commit with the branch HEAD
+                                 # names, nothing, or init.defaultBranch
+    amendPrefix = original       # original | branch: keep the prefix of the commit whose message -c, -C or --amend
+                                 # reuse, even on another branch, or prefix with the current branch
+    branchCheck = off            # off | remote | pr: warn when the branch is not on the remote (as of the last fetch),
+                                 # or also when its pull request is already merged or closed, asking the provider's
+                                 # API at [go-githooks "vcs"] apiUrl with its token, both from your own config;
+                                 # catches work landing on a stale branch
+    prefixPlacement = start      # start | after-type: '[%%s] feat: subject' or 'feat(scope): [%%s] subject'
     prefixBranchExclusions = main,develop
+    prefixSkip =                 # rules for commits to leave unprefixed, e.g. 'source=merge|squash', 'paths=docs/|*.md'
+                                 # or 'author=*[bot]@* branch=release/*'; a rule skips when all its conditions hold,
+                                 # on source, paths (every staged file), branch, author or committer
+    annotations =                # machine-readable trailers for downstream tooling, each 'Key: value [when <conditions>]',
+                                 # e.g. 'X-Change-Type: {inferredType}', 'X-Risk: high when paths=db/migrate/' then
+                                 # 'X-Risk: low'; the first entry per key whose prefixSkip-style conditions hold is added
+    detachedHeadPrefix = skip    # skip | sha | detached: what to prefix with on a detached HEAD (not during a
+                                 # rebase or bisect, which use the branch they started from)
+    rememberBranchPrefix = false # store the branch name in branch.<name>.githooksTicket at its first commit and
+                                 # prefix with that from then on, so a renamed branch keeps its ticket
+    branchNormalization = nfc    # nfc | nfd | none: the unicode normalization form of the branch name in the prefix
+    branchTransliterate = false  # spell the branch name in the prefix in ASCII, e.g. função/ABC-1 as funcao/ABC-1
+    branchMaxLength = 0          # shorten the branch name in the prefix to this many characters, never splitting one
+    revertBehavior = refs        # skip | refs | default
+    cherryPickBehavior = refs    # skip | refs | default
+    builtOn = off                # off | describe | tag: add a Built-on trailer naming the nearest tag
+    topic = off                  # off | branch | inherit: add a Topic trailer for stacked diffs, from the branch
+                                 # or from the commit below (not on prefixBranchExclusions)
+    warnOnEmptyMessage = false   # add a warning comment while the message has nothing but a prefix and trailers
+    signOff = false              # add a Signed-off-by trailer for user.name and user.email
+    markPrepared = false         # add a comment so a message offered again (e.g. after an aborted editor) is left as is;
+                                 # only while commit.cleanup strips comments, so it never reaches the commit
+    annotateChanges = false      # add a comment saying what the hook changed, e.g. 'added prefix [FEAT-1]; added 2 co-authors'
+    coauthorsTTL =               # e.g. 4h: ask whether the mob is still accurate once it has not changed for this long
+    squashCoauthors = true       # after git merge --squash, credit the squashed commits' authors and coauthors
+                                 # as Co-authored-by, leaving out whoever is committing
+    smartCommits = false         # turn '@time 2h', '@comment text' and '@transition done' into Jira smart commit
+                                 # commands for the issue key in the subject or branch, e.g. 'ABC-123 #time 2h'
+    mergeTickets = false         # list the tickets of the commits being merged in MERGE_MSG, found with
+                                 # [go-githooks "commit-message"] ticketPattern
+    mergeStripPrefix =           # target branches, e.g. main,release/*, whose merge and squash subjects have the
+                                 # branch prefix taken off, its tickets kept in a Refs trailer
+
+[go-githooks "commit-message"]
+    lineEndings = preserve       # preserve | lf | crlf: how the lines of the message end once it is written back;
+                                 # transformers always see LF, with a byte order mark and extra trailing blank lines stripped
+    keepByteOrderMark = false    # write a byte order mark the editor added back with the message
+
+[go-githooks "scope"]
+    map =                        # e.g. services/billing=billing,web=frontend: the {scope} of paths under each prefix;
+                                 # other paths are scoped by their top-level directory
+
+[go-githooks "pairing"]
+    providers =                  # file | liveshare: add the coauthors of the current pairing session to git-mob's
+    file = .pairing              # one 'Name <email>' per line, or e.g. 'Tested-by: Name <email>' for their role this
+                                 # session; keep it out of version control
+    role =                       # e.g. 'Reviewed-by zoe@serenity.com' (repeat the key for each person), or 'Paired-with'
+                                 # for everyone else: credit them with that trailer instead of Co-authored-by; one of
+                                 # Co-authored-by, Reviewed-by, Tested-by, Paired-with. GIT_COMMIT_MSG_COAUTHOR_ROLE
+                                 # sets the role of everyone without their own for the session
+    liveShareSession = .vscode/liveshare-peers.json   # {"peers": [{"name": ..., "email": ...}]}
+    maxAge = 12h                 # session files untouched for longer are ignored
+    pathCoauthors =              # e.g. 'payments/ = Zoe Washburne <zoe@serenity.com>' (repeat the key for each pattern):
+                                 # credit them on commits touching matching paths; patterns follow CODEOWNERS
+                                 # and the last one matching a file wins
+    codeowners =                 # e.g. .github/CODEOWNERS: also credit the owners it gives as emails
+    pathRotation = all           # all | weekly: credit every owner of a path, or one of them in turn each week
+
+[go-githooks]
+    preset =                     # one or more of: conventional, jira, mob, oss-dco (see: go-githooks init)
+    mailmap = true               # use the repo's .mailmap (and mailmap.file) for coauthors and the sign-off
+    expandEnv =                  # variables besides USER, USERNAME, LOGNAME and GIT_HOOKS_ENV which values may refer to,
+                                 # as ${VAR}, ${VAR:-default} or ${VAR:+if set} ($${ for a literal ${); only read
+                                 # from .git/config or ~/.gitconfig, never shared config
+
+[go-githooks "vcs"]
+    remote = origin,upstream     # remotes tried, in order, for {host}, {org}, {repo} and {provider}
+    providerHosts =              # e.g. code.corp.internal=gitlab for self-hosted servers (github, gitlab, bitbucket, gerrit)
+    host =                       # override what the remote url says
+    org =
+    repo =
+    provider =
+    apiUrl =                     # the provider's REST API, e.g. https://api.github.com or https://git.corp.internal/api/v3
+    token =                      # for the API; may be a secret reference (see: go-githooks secret); apiUrl and
+                                 # token are only read from .git/config or ~/.gitconfig, never shared config
+
+[go-githooks "scripts"]
+    enabled = false              # run the repo's .githooks/prepare-commit-msg.d/* in order, piping the message
+                                 # through them; only read from .git/config or ~/.gitconfig, never shared config
+
+[go-githooks "budget"]
+    prepare-commit-msg =         # e.g. 300ms: as the hook uses up this budget it skips optional steps, lowest
+                                 # priority first (built-on, topic, annotateChanges, ...), then squash coauthors and
+                                 # scripts; the branch prefix, coauthors and required trailers always run
+
+[go-githooks "telemetry"]
+    enabled = false              # opt in to local, anonymous usage stats (see: go-githooks telemetry status);
+                                 # only read from .git/config or ~/.gitconfig
 
 `)
 }
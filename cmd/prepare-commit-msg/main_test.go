@@ -5,13 +5,28 @@ import (
 	"github.com/apex/log"
 	"github.com/apex/log/handlers/text"
 	approvals "github.com/approvals/go-approval-tests"
+	"github.com/davidalpert/go-githooks/pkg/branchname"
+	"github.com/davidalpert/go-githooks/pkg/budget"
+	"github.com/davidalpert/go-githooks/pkg/exitcode"
+	"github.com/davidalpert/go-githooks/pkg/message"
+	"github.com/davidalpert/go-githooks/pkg/pairing"
+	"github.com/davidalpert/go-githooks/pkg/vcshost"
 	"github.com/go-git/go-billy/v5/memfs"
+	"github.com/go-git/go-billy/v5/util"
 	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
 	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
 	"github.com/go-git/go-git/v5/storage/memory"
 	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestRepoConfigUnmarshall(t *testing.T) {
@@ -81,6 +96,18 @@ func Test_overrideFromRepo(t *testing.T) {
 				PrefixWithBranch: true,
 			},
 		},
+		{
+			name: "template variables from the remote",
+			configText: `
+[remote "origin"]
+    url = git@github.com:davidalpert/go-githooks.git
+[go-githooks "prepare-commit-message"]
+    prefixWithBranchTemplate = "[{repo}#%s]"
+`,
+			want: PrepareCommitMsgOptions{
+				PrefixWithBranchTemplate: "[go-githooks#%s]",
+			},
+		},
 	}
 
 	for _, tt := range testcases {
@@ -122,18 +149,18 @@ func Test_appendCoauthorMarkup(t *testing.T) {
 			wantErr:         false,
 		},
 		{
-			name:            "empty message two coauthors",
-			rawMessage:      ``,
+			name:       "empty message two coauthors",
+			rawMessage: ``,
 			coauthorsMarkup: `Co-authored-by: Mal Reynolds <mal@serenity.com>
 Co-authored-by: Zoe Washburne <zoe@serenity.com>`,
-			wantErr:         false,
+			wantErr: false,
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			o := &PrepareCommitMsgOptions{
-				CommitMessageBytes:         []byte(tt.rawMessage),
-				CoauthorsMarkupBytes:       []byte(tt.coauthorsMarkup),
+				CommitMessageBytes:   []byte(tt.rawMessage),
+				CoauthorsMarkupBytes: []byte(tt.coauthorsMarkup),
 			}
 			if err := o.appendCoauthorMarkup(); (err != nil) != tt.wantErr {
 				t.Errorf("appendCoauthorMarkup() error = %v, wantErr %v", err, tt.wantErr)
@@ -203,12 +230,111 @@ Co-authored-by: Mal Reynolds <mal@serentiy.com>
 					coauthors:  "",
 				},
 				{
-					name:       "no message no coauthors git comments",
-					args:       ".git/COMMIT_MSG",
+					name: "no message no coauthors git comments",
+					args: ".git/COMMIT_MSG",
 					rawMessage: `# git comments
 # git comments
 # git comments
 `,
+					branch:    "FEAT-5",
+					coauthors: "",
+				},
+				{
+					name: "revert keeps subject and refs original ticket",
+					args: ".git/COMMIT_MSG message",
+					rawMessage: `Revert "[FEAT-0] do something awesome"
+
+This reverts commit 5d1b2b3a4c5d6e7f8091a2b3c4d5e6f708192a3b.
+`,
+					branch: "FEAT-6",
+					coauthors: `
+Co-authored-by: Mal Reynolds <mal@serentiy.com>
+`,
+				},
+				{
+					name: "cherry pick keeps subject and refs original ticket",
+					args: ".git/COMMIT_MSG message",
+					rawMessage: `[FEAT-0] do something awesome
+
+(cherry picked from commit 5d1b2b3a4c5d6e7f8091a2b3c4d5e6f708192a3b)
+`,
+					branch:    "FEAT-7",
+					coauthors: "",
+				},
+				{
+					name:       "fixup keeps the target subject verbatim",
+					args:       ".git/COMMIT_MSG message",
+					rawMessage: "fixup! [FEAT-0] do something awesome\n",
+					branch:     "FEAT-11",
+					coauthors:  "",
+				},
+			},
+		},
+		{
+			name: "r4",
+			configText: `
+[go-githooks "prepare-commit-message"]
+        prefixWithBranch = true
+        warnOnEmptyMessage = true
+`,
+			testCases: []testCaseArgs{
+				{
+					name:       "warns on empty msg",
+					args:       ".git/COMMIT_MSG",
+					rawMessage: "\n# git comments\n",
+					branch:     "FEAT-8",
+					coauthors: `
+Co-authored-by: Mal Reynolds <mal@serentiy.com>
+`,
+				},
+				{
+					name:       "no warning with subject",
+					args:       ".git/COMMIT_MSG message",
+					rawMessage: "do something awesome",
+					branch:     "FEAT-9",
+				},
+			},
+		},
+		{
+			name: "r5",
+			configText: `
+[go-githooks "prepare-commit-message"]
+        prefixWithBranch = true
+        markPrepared = true
+`,
+			testCases: []testCaseArgs{
+				{
+					name:       "marks a message with git comments",
+					args:       ".git/COMMIT_MSG",
+					rawMessage: "# git comments\n",
+					branch:     "FEAT-5",
+					coauthors:  "",
+				},
+				{
+					name:       "already prepared message left as is",
+					args:       ".git/COMMIT_MSG",
+					rawMessage: "[FEAT-5] \n\n# go-githooks: prepared 002bb05a4241\n# git comments\n",
+					branch:     "FEAT-10",
+					coauthors: `
+Co-authored-by: Mal Reynolds <mal@serentiy.com>
+`,
+				},
+			},
+		},
+		{
+			name: "r6",
+			configText: `
+[commit]
+        cleanup = whitespace
+[go-githooks "prepare-commit-message"]
+        prefixWithBranch = true
+        markPrepared = true
+`,
+			testCases: []testCaseArgs{
+				{
+					name:       "no marker where git keeps comments",
+					args:       ".git/COMMIT_MSG",
+					rawMessage: "# git comments\n",
 					branch:     "FEAT-5",
 					coauthors:  "",
 				},
@@ -244,7 +370,9 @@ Co-authored-by: Mal Reynolds <mal@serentiy.com>
 				t.Errorf("getting worktree: %v", err)
 				return
 			}
-			_, err = w.Commit("empty root commit", &git.CommitOptions{})
+			_, err = w.Commit("empty root commit", &git.CommitOptions{
+				Author: &object.Signature{Name: "Mal Reynolds", Email: "mal@serenity.com", When: time.Now()},
+			})
 			if err != nil {
 				t.Errorf("creating root commit: %v", err)
 				return
@@ -284,3 +412,752 @@ Co-authored-by: Mal Reynolds <mal@serentiy.com>
 		})
 	}
 }
+
+func Test_appendBuiltOn(t *testing.T) {
+	r, _ := git.Init(memory.NewStorage(), memfs.New())
+	w, _ := r.Worktree()
+	commit := func(name string) plumbing.Hash {
+		f, _ := w.Filesystem.Create(name)
+		_ = f.Close()
+		_, _ = w.Add(name)
+		h, err := w.Commit(name, &git.CommitOptions{Author: &object.Signature{Name: "Mal Reynolds", Email: "mal@serenity.com", When: time.Now()}})
+		if err != nil {
+			t.Fatalf("committing: %v", err)
+		}
+		return h
+	}
+
+	o := NewOptions(r)
+	o.BuiltOn = BuiltOnDescribe
+	o.CommitMessageBytes = []byte("[FEAT-1] do something awesome\n")
+	assert.NoError(t, o.appendBuiltOn())
+	assert.Equal(t, "[FEAT-1] do something awesome\n", string(o.CommitMessageBytes), "nothing to build on yet")
+
+	_, _ = r.CreateTag("v1.4.2", commit("a"), nil)
+	head := commit("b")
+
+	assert.NoError(t, o.appendBuiltOn())
+	assert.Contains(t, string(o.CommitMessageBytes), "\n\nBuilt-on: v1.4.2-1-g"+head.String()[:7]+"\n")
+
+	o.BuiltOn = BuiltOnTag
+	o.CommitMessageBytes = []byte("[FEAT-1] do something awesome\n")
+	assert.NoError(t, o.appendBuiltOn())
+	assert.Contains(t, string(o.CommitMessageBytes), "\n\nBuilt-on: v1.4.2\n")
+
+	before := string(o.CommitMessageBytes)
+	assert.NoError(t, o.appendBuiltOn())
+	assert.Equal(t, before, string(o.CommitMessageBytes), "keeps an existing stamp")
+}
+
+func Test_prefixBranchName(t *testing.T) {
+	r, _ := git.Init(memory.NewStorage(), memfs.New())
+	o := NewOptions(r)
+	o.setDefaultOptions()
+
+	name, err := o.prefixBranchName()
+	assert.NoError(t, err)
+	assert.Equal(t, "master", name, "a branch without commits yet")
+
+	o.InitialBranch = "main"
+	for prefix, want := range map[FirstCommitPrefix]string{
+		FirstCommitBranch:        "master",
+		FirstCommitSkip:          "",
+		FirstCommitDefaultBranch: "main",
+	} {
+		o.FirstCommitPrefix = prefix
+		name, err := o.prefixBranchName()
+		assert.NoError(t, err)
+		assert.Equal(t, want, name, string(prefix))
+	}
+	o.FirstCommitPrefix = FirstCommitBranch
+
+	w, _ := r.Worktree()
+	f, _ := w.Filesystem.Create("a.txt")
+	_ = f.Close()
+	_, _ = w.Add("a.txt")
+	head, _ := w.Commit("first", &git.CommitOptions{Author: &object.Signature{Name: "Mal Reynolds", Email: "mal@serenity.com", When: time.Now()}})
+	_ = r.Storer.SetReference(plumbing.NewHashReference(plumbing.HEAD, head))
+
+	for prefix, want := range map[DetachedHeadPrefix]string{
+		DetachedSkip:     "",
+		DetachedShortSha: head.String()[:7],
+		DetachedLiteral:  "detached",
+	} {
+		o.DetachedHeadPrefix = prefix
+		name, err := o.prefixBranchName()
+		assert.NoError(t, err)
+		assert.Equal(t, want, name, string(prefix))
+	}
+}
+
+func Test_appendTopic(t *testing.T) {
+	r, _ := git.Init(memory.NewStorage(), memfs.New())
+	_ = r.Storer.SetReference(plumbing.NewSymbolicReference(plumbing.HEAD, "refs/heads/feature/FEAT-1"))
+	o := NewOptions(r)
+	o.setDefaultOptions()
+
+	o.Topic = TopicBranch
+	o.CommitMessageBytes = []byte("[FEAT-1] do something awesome\n")
+	assert.NoError(t, o.appendTopic())
+	assert.Contains(t, string(o.CommitMessageBytes), "[FEAT-1] do something awesome\n\nTopic: feature/FEAT-1\n")
+
+	before := string(o.CommitMessageBytes)
+	assert.NoError(t, o.appendTopic())
+	assert.Equal(t, before, string(o.CommitMessageBytes), "keeps an existing topic")
+
+	w, _ := r.Worktree()
+	f, _ := w.Filesystem.Create("a.txt")
+	_ = f.Close()
+	_, _ = w.Add("a.txt")
+	_, err := w.Commit("first\n\nTopic: login-stack\n", &git.CommitOptions{Author: &object.Signature{Name: "Mal Reynolds", Email: "mal@serenity.com", When: time.Now()}})
+	assert.NoError(t, err)
+
+	o.Topic = TopicInherit
+	o.CommitMessageBytes = []byte("[FEAT-1] do something else\n")
+	assert.NoError(t, o.appendTopic())
+	assert.Contains(t, string(o.CommitMessageBytes), "[FEAT-1] do something else\n\nTopic: login-stack\n")
+
+	o.PrefixWithBranchExclusions = []string{"feature/FEAT-1"}
+	o.CommitMessageBytes = []byte("do something else\n")
+	assert.NoError(t, o.appendTopic())
+	assert.Equal(t, "do something else\n", string(o.CommitMessageBytes), "no topic on excluded branches")
+}
+
+func Test_prependBranchNamePlacement(t *testing.T) {
+	r, _ := git.Init(memory.NewStorage(), memfs.New())
+	_ = r.Storer.SetReference(plumbing.NewSymbolicReference(plumbing.HEAD, "refs/heads/FEAT-1"))
+	o := NewOptions(r)
+	o.setDefaultOptions()
+
+	tests := []struct {
+		placement PrefixPlacement
+		msg       string
+		want      string
+	}{
+		{placement: PrefixAtStart, msg: "feat(auth): add login", want: "[FEAT-1] feat(auth): add login\n\n"},
+		{placement: PrefixAfterType, msg: "feat(auth): add login", want: "feat(auth): [FEAT-1] add login\n\n"},
+		{placement: PrefixAfterType, msg: "add login", want: "[FEAT-1] add login\n\n"},
+		{placement: PrefixAfterType, msg: "fix!: [FEAT-1] add login", want: "fix!: [FEAT-1] add login\n\n"},
+		{placement: PrefixAtStart, msg: "fix: [FEAT-1] add login", want: "fix: [FEAT-1] add login\n\n"},
+	}
+	for _, tt := range tests {
+		o.PrefixPlacement = tt.placement
+		o.CommitMessageBytes = []byte(tt.msg)
+		assert.NoError(t, o.prependBranchName())
+		assert.Equal(t, tt.want, string(o.CommitMessageBytes), string(tt.placement)+": "+tt.msg)
+	}
+}
+
+func Test_addPairingCoauthors(t *testing.T) {
+	root := t.TempDir()
+	r, err := git.PlainInit(root, false)
+	if err != nil {
+		t.Fatalf("init: %v", err)
+	}
+	_ = ioutil.WriteFile(filepath.Join(root, ".pairing"), []byte("Zoe Washburne <zoe@serenity.com>\nRiver Tam <river@serenity.com>\n"), 0644)
+
+	o := NewOptions(r)
+	o.setDefaultOptions()
+	o.CoauthorsMarkupBytes = []byte("Co-authored-by: Zoe Washburne <ZOE@serenity.com>\n")
+	assert.NoError(t, o.addPairingCoauthors())
+	assert.Equal(t, "Co-authored-by: Zoe Washburne <ZOE@serenity.com>\n", string(o.CoauthorsMarkupBytes), "no providers by default")
+
+	o.PairingProviders = []string{pairing.FileProvider}
+	assert.NoError(t, o.addPairingCoauthors())
+	assert.Equal(t, "Co-authored-by: Zoe Washburne <ZOE@serenity.com>\nCo-authored-by: River Tam <river@serenity.com>", string(o.CoauthorsMarkupBytes))
+}
+
+func Test_assignCoauthorRoles(t *testing.T) {
+	roles, err := parseCoauthorRoles([]string{"Reviewed-by ZOE@serenity.com", "paired-with"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"zoe@serenity.com": "Reviewed-by", "": "Paired-with"}, roles)
+	_, err = parseCoauthorRoles([]string{"Approved-by zoe@serenity.com"})
+	assert.Error(t, err)
+
+	o := NewOptions(nil)
+	o.setDefaultOptions()
+	o.CoauthorsMarkupBytes = []byte("Co-authored-by: Zoe Washburne <zoe@serenity.com>\nTested-by: Wash <wash@serenity.com>\nCo-authored-by: River Tam <river@serenity.com>\n")
+	o.assignCoauthorRoles()
+	assert.Equal(t, "Co-authored-by: Zoe Washburne <zoe@serenity.com>\nTested-by: Wash <wash@serenity.com>\nCo-authored-by: River Tam <river@serenity.com>\n", string(o.CoauthorsMarkupBytes), "no roles by default")
+
+	o.CoauthorRoles = roles
+	o.assignCoauthorRoles()
+	assert.Equal(t, "Reviewed-by: Zoe Washburne <zoe@serenity.com>\nTested-by: Wash <wash@serenity.com>\nPaired-with: River Tam <river@serenity.com>", string(o.CoauthorsMarkupBytes))
+
+	o.CoauthorsMarkupBytes = []byte("Co-authored-by: River Tam <river@serenity.com>")
+	o.SessionCoauthorRole = "Co-authored-by"
+	o.assignCoauthorRoles()
+	assert.Equal(t, "Co-authored-by: River Tam <river@serenity.com>", string(o.CoauthorsMarkupBytes), "the session's role wins over the default")
+
+	o.CommitMessageBytes = []byte("do something\n\nReviewed-by: Zoe Washburne <zoe@serenity.com>\n")
+	o.CoauthorsMarkupBytes = []byte("Reviewed-by: Zoe Washburne <zoe@serenity.com>")
+	assert.NoError(t, o.appendCoauthorMarkup())
+	assert.Equal(t, "do something\n\nReviewed-by: Zoe Washburne <zoe@serenity.com>\n\n", string(o.CommitMessageBytes), "credited once when amending")
+}
+
+func Test_describeChanges(t *testing.T) {
+	before := []byte("fix login\n\nCo-authored-by: Jayne Cobb <jayne@serenity.com>\n\n# git comments\n")
+	after := []byte("[FEAT-1] fix login\n\nCo-authored-by: Zoe Washburne <zoe@serenity.com>\nCo-authored-by: River Tam <river@serenity.com>\nSigned-off-by: Mal Reynolds <mal@serenity.com>\n\n# git comments\n")
+	assert.Equal(t, []string{"added prefix [FEAT-1]", "added 2 co-authors", "added Signed-off-by", "removed 1 co-author"}, describeChanges(before, after, "[%s]"))
+
+	assert.Empty(t, describeChanges(before, before, "[%s]"))
+	assert.Equal(t, []string{"edited the body"}, describeChanges([]byte("fix login\n"), []byte("fix login\n\nbecause it was broken\n"), "[%s]"))
+}
+
+func Test_annotateChanges(t *testing.T) {
+	o := NewOptions(nil)
+	o.setDefaultOptions()
+	o.originalMessageBytes = []byte("fix login\n\n# git comments\n")
+
+	o.CommitMessageBytes = []byte("[FEAT-1] fix login\n\n# git comments\n")
+	assert.NoError(t, o.annotateChanges())
+	assert.Equal(t, "[FEAT-1] fix login\n\n# go-githooks: added prefix [FEAT-1]\n\n# git comments\n", string(o.CommitMessageBytes))
+
+	o.originalMessageBytes = []byte("fix login\n")
+	o.CommitMessageBytes = []byte("[FEAT-1] fix login\n")
+	assert.NoError(t, o.annotateChanges())
+	assert.Equal(t, "[FEAT-1] fix login\n", string(o.CommitMessageBytes), "git keeps comments in messages without any")
+}
+
+func Test_applySubjectTemplate(t *testing.T) {
+	r, _ := git.Init(memory.NewStorage(), memfs.New())
+	w, _ := r.Worktree()
+	for _, p := range []string{"pkg/auth/login.go", "pkg/auth/token.go", "README.md"} {
+		_ = util.WriteFile(w.Filesystem, p, []byte(p), 0644)
+		_, _ = w.Add(p)
+	}
+	o := NewOptions(r)
+	o.setDefaultOptions()
+	o.SubjectTemplate = "feat({primaryPackage}): "
+
+	o.CommitMessageBytes = []byte("\n# Please enter the commit message for your changes.\n")
+	assert.NoError(t, o.applySubjectTemplate())
+	assert.Equal(t, "feat(auth): \n\n# Please enter the commit message for your changes.\n", string(o.CommitMessageBytes))
+
+	o.CommitMessageBytes = []byte("fix typo\n")
+	assert.NoError(t, o.applySubjectTemplate())
+	assert.Equal(t, "fix typo\n", string(o.CommitMessageBytes), "keeps an existing subject")
+
+	o.PrefixWithBranchTemplate = "[%s] {languagesTouched}"
+	template, err := o.expandStagedVars(o.PrefixWithBranchTemplate)
+	assert.NoError(t, err)
+	assert.Equal(t, "[%s] Go,Markdown", template)
+}
+
+func Test_applyRootCommitTemplate(t *testing.T) {
+	r, _ := git.Init(memory.NewStorage(), memfs.New())
+	w, _ := r.Worktree()
+	_ = util.WriteFile(w.Filesystem, "README.md", []byte("# serenity\n"), 0644)
+	_, _ = w.Add("README.md")
+	o := NewOptions(r)
+	o.setDefaultOptions()
+	o.RootCommitTemplate = "chore: initial commit"
+	o.SubjectTemplate = "feat: "
+	o.MarkPrepared = false
+
+	o.CommitMessageBytes = []byte("\n# Please enter the commit message for your changes.\n")
+	for _, t := range o.transformers(ReplayDefault) {
+		_ = t.run()
+	}
+	assert.Equal(t, "chore: initial commit\n\n# Please enter the commit message for your changes.\n", string(o.CommitMessageBytes))
+
+	_, _ = w.Commit("chore: initial commit", &git.CommitOptions{Author: &object.Signature{Name: "Mal Reynolds", Email: "mal@serenity.com", When: time.Now()}})
+	o.CommitMessageBytes = []byte("\n# Please enter the commit message for your changes.\n")
+	for _, t := range o.transformers(ReplayDefault) {
+		_ = t.run()
+	}
+	assert.Equal(t, "feat: \n\n# Please enter the commit message for your changes.\n", string(o.CommitMessageBytes), "only for the first commit")
+}
+
+func Test_runTransformerBudget(t *testing.T) {
+	r, _ := git.Init(memory.NewStorage(), memfs.New())
+	o := NewOptions(r)
+	o.setDefaultOptions()
+	o.Budget.Limit = time.Nanosecond
+	time.Sleep(time.Millisecond)
+
+	ran := []string{}
+	for _, tr := range []transformer{
+		{name: "branch-prefix", run: func() error { ran = append(ran, "branch-prefix"); return nil }},
+		{name: "built-on", priority: budget.Low, run: func() error { ran = append(ran, "built-on"); return nil }},
+		{name: "scripts", priority: budget.Normal, run: func() error { ran = append(ran, "scripts"); return nil }},
+	} {
+		assert.NoError(t, o.runTransformer(tr))
+	}
+	assert.Equal(t, []string{"branch-prefix"}, ran, "required steps run over the budget")
+	assert.Equal(t, []string{"built-on", "scripts"}, o.Budget.Skipped)
+}
+
+func Test_checkCoauthorExpiry(t *testing.T) {
+	r, _ := git.Init(memory.NewStorage(), memfs.New())
+	o := NewOptions(r)
+	o.setDefaultOptions()
+	o.CoauthorsTTL = 4 * time.Hour
+	o.MobSessionFile = filepath.Join(t.TempDir(), "mob-session.json")
+	o.CoauthorsMarkupBytes = []byte("Co-authored-by: Zoe Washburne <zoe@serenity.com>\n")
+
+	o.CommitMessageBytes = []byte("fix login\n\n# Please enter the commit message for your changes.\n")
+	assert.NoError(t, o.checkCoauthorExpiry())
+	assert.NotContains(t, string(o.CommitMessageBytes), "still accurate", "a new mob is fresh")
+
+	old := time.Now().Add(-5 * time.Hour).UTC().Format(time.RFC3339)
+	_ = ioutil.WriteFile(o.MobSessionFile, []byte(`{"coauthors":["zoe@serenity.com"],"since":"`+old+`"}`), 0644)
+	assert.NoError(t, o.checkCoauthorExpiry())
+	assert.Contains(t, string(o.CommitMessageBytes), "# go-githooks: the mob (Zoe Washburne) has not changed for 5h0m0s; is it still accurate?\n")
+}
+
+func Test_rememberedBranchPrefix(t *testing.T) {
+	r, _ := git.Init(memory.NewStorage(), memfs.New())
+	_ = r.Storer.SetReference(plumbing.NewSymbolicReference(plumbing.HEAD, "refs/heads/feature/FEAT-1"))
+	o := NewOptions(r)
+	o.setDefaultOptions()
+	o.RememberBranchPrefix = true
+
+	name, err := o.prefixBranchName()
+	assert.NoError(t, err)
+	assert.Equal(t, "feature/FEAT-1", name)
+
+	cfg, _ := r.Config()
+	assert.Equal(t, "feature/FEAT-1", cfg.Raw.Section("branch").Subsection("feature/FEAT-1").Option("githooksTicket"))
+
+	// as 'git branch -m feature/FEAT-1 feature/better-name' would
+	cfg.Raw.Section("branch").RemoveSubsection("feature/FEAT-1")
+	assert.NoError(t, storeBranchTicket(r, cfg, "feature/better-name", "feature/FEAT-1"))
+	_ = r.Storer.SetReference(plumbing.NewSymbolicReference(plumbing.HEAD, "refs/heads/feature/better-name"))
+
+	name, err = o.prefixBranchName()
+	assert.NoError(t, err)
+	assert.Equal(t, "feature/FEAT-1", name, "a renamed branch keeps the prefix it was first committed with")
+
+	o.RememberBranchPrefix = false
+	name, _ = o.prefixBranchName()
+	assert.Equal(t, "feature/better-name", name)
+}
+
+func TestBuild(t *testing.T) {
+	r, _ := git.Init(memory.NewStorage(), memfs.New())
+	_ = r.Storer.SetReference(plumbing.NewSymbolicReference(plumbing.HEAD, "refs/heads/feature/FEAT-1"))
+
+	_, err := Build(r, WithBranchPrefix("[no branch]"))
+	assert.EqualError(t, err, "branch prefix template '[no branch]' must have exactly one %s for the branch")
+	assert.Equal(t, exitcode.Config, exitcode.Of(err))
+	_, err = Build(r, WithCoauthorProvider(nil))
+	assert.EqualError(t, err, "no coauthor provider given")
+
+	configured := func(*git.Repository) (*config.Config, error) {
+		cfg := config.NewConfig()
+		err := cfg.Unmarshal([]byte("[go-githooks \"prepare-commit-message\"]\n    prefixWithBranchTemplate = (%s)\n    markPrepared = true\n"))
+		return cfg, err
+	}
+	mob := CoauthorProviderFunc(func() ([]byte, error) {
+		return []byte("Co-authored-by: Zoe Washburne <zoe@serenity.com>\n"), nil
+	})
+	o, err := Build(r, WithConfigSource(configured), WithBranchPrefix("%s:"), WithCoauthorProvider(mob))
+	assert.NoError(t, err)
+
+	assert.NoError(t, o.Prepare([]string{"COMMIT_EDITMSG", "message"}))
+	assert.True(t, o.PrefixWithBranch)
+	assert.Equal(t, "%s:", o.PrefixWithBranchTemplate, "options win over config")
+	assert.True(t, o.MarkPrepared, "config is read from the given source")
+
+	o.CommitMessageBytes = []byte("do something awesome\n")
+	assert.NoError(t, o.readCoauthorsMessage())
+	assert.NoError(t, o.Execute())
+	assert.Equal(t, "feature/FEAT-1: do something awesome\n\nCo-authored-by: Zoe Washburne <zoe@serenity.com>\n\n", string(o.CommitMessageBytes))
+}
+
+func Test_appendSquashCoauthors(t *testing.T) {
+	r, _ := git.Init(memory.NewStorage(), memfs.New())
+	cfg, _ := r.Config()
+	cfg.User.Name = "Mal Reynolds"
+	cfg.User.Email = "mal@serenity.com"
+	_ = r.SetConfig(cfg)
+
+	squashMsg := `Squashed commit of the following:
+
+commit 1b3c0e6a5d2f4e8a9b7c6d5e4f3a2b1c0d9e8f7a
+Author: Zoe Washburne <zoe@serenity.com>
+Date:   Mon Jan 4 10:00:00 2021 -0700
+
+    fix the landing gear
+
+    Co-authored-by: Hoban Washburne <wash@serenity.com>
+    Co-authored-by: Mal Reynolds <mal@serenity.com>
+
+commit 2c4d1f7b6e3a5f9b0c8d7e6f5a4b3c2d1e0f9a8b
+Author: Kaylee Frye <kaylee@serenity.com>
+Date:   Mon Jan 4 09:00:00 2021 -0700
+
+    tune the engine
+
+    Co-authored-by: Zoe Washburne <zoe@serenity.com>
+
+Co-authored-by: Kaylee Frye <kaylee@serenity.com>
+
+# Please enter the commit message for your changes.
+`
+
+	o := NewOptions(r)
+	o.setDefaultOptions()
+	o.CommitMessageBytes = []byte(squashMsg)
+	assert.NoError(t, o.appendSquashCoauthors())
+
+	trailers := message.Trailers(o.CommitMessageBytes)
+	got := make([]string, 0)
+	for _, t := range trailers {
+		got = append(got, t.String())
+	}
+	assert.Equal(t, []string{
+		"Co-authored-by: Kaylee Frye <kaylee@serenity.com>",
+		"Co-authored-by: Zoe Washburne <zoe@serenity.com>",
+		"Co-authored-by: Hoban Washburne <wash@serenity.com>",
+	}, got, "squashed authors and coauthors, once each, without the committer")
+	assert.True(t, strings.HasSuffix(string(o.CommitMessageBytes), "# Please enter the commit message for your changes.\n"))
+}
+
+func Test_amendedCommit(t *testing.T) {
+	r, _ := git.Init(memory.NewStorage(), memfs.New())
+	_ = r.Storer.SetReference(plumbing.NewSymbolicReference(plumbing.HEAD, "refs/heads/feature/ABC-1"))
+	w, _ := r.Worktree()
+	f, _ := w.Filesystem.Create("a.txt")
+	_ = f.Close()
+	_, _ = w.Add("a.txt")
+	head, _ := w.Commit("[feature/ABC-1] fix login\n\nwith a body\n", &git.CommitOptions{Author: &object.Signature{Name: "Mal Reynolds", Email: "mal@serenity.com", When: time.Now()}})
+	// amended on a branch for another ticket
+	_ = r.Storer.SetReference(plumbing.NewHashReference("refs/heads/feature/ABC-2", head))
+	_ = r.Storer.SetReference(plumbing.NewSymbolicReference(plumbing.HEAD, "refs/heads/feature/ABC-2"))
+
+	o := NewOptions(r)
+	o.setDefaultOptions()
+	o.Source = CommitSource
+	o.CommitObject = head.String()
+
+	name, err := o.prefixBranchName()
+	assert.NoError(t, err)
+	assert.Equal(t, "feature/ABC-1", name, "keeps the amended commit's ticket")
+
+	expanded, err := o.expandStagedVars("{amendedTicket}: {amendedSubject}")
+	assert.NoError(t, err)
+	assert.Equal(t, "feature/ABC-1: [feature/ABC-1] fix login", expanded)
+
+	o.AmendPrefix = AmendBranch
+	name, err = o.prefixBranchName()
+	assert.NoError(t, err)
+	assert.Equal(t, "feature/ABC-2", name)
+
+	o.AmendPrefix = AmendOriginal
+	o.CommitObject = "0123456789012345678901234567890123456789"
+	o.amended = nil
+	name, err = o.prefixBranchName()
+	assert.NoError(t, err)
+	assert.Equal(t, "feature/ABC-2", name, "falls back to the branch when the commit cannot be read")
+}
+
+func Test_checkBranch(t *testing.T) {
+	r, _ := git.Init(memory.NewStorage(), memfs.New())
+	_ = r.Storer.SetReference(plumbing.NewSymbolicReference(plumbing.HEAD, "refs/heads/ABC-1-login"))
+	w, _ := r.Worktree()
+	head, _ := w.Commit("ABC-1 fix login\n", &git.CommitOptions{Author: &object.Signature{Name: "Mal Reynolds", Email: "mal@serenity.com", When: time.Now()}})
+
+	var pulls string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(pulls))
+	}))
+	defer srv.Close()
+
+	check := func(mode BranchCheck, upstream string) string {
+		o := NewOptions(r)
+		o.setDefaultOptions()
+		o.BranchCheck = mode
+		o.Remote = &vcshost.Remote{Host: "github.com", Org: "serenity", Repo: "crew", Provider: vcshost.GitHub, RemoteName: "origin", Upstream: upstream}
+		o.VCSAPI = vcshost.API{BaseURL: srv.URL}
+		o.CommitMessageBytes = []byte("\n# Please enter the commit message\n")
+		assert.NoError(t, o.checkBranch())
+		return string(o.CommitMessageBytes)
+	}
+
+	assert.Contains(t, check(BranchCheckRemote, ""), "# go-githooks: 'ABC-1-login' is not on origin; is it the branch you meant?")
+	assert.Contains(t, check(BranchCheckRemote, "origin/ABC-1-login"), "is gone from origin")
+
+	_ = r.Storer.SetReference(plumbing.NewHashReference("refs/remotes/origin/ABC-1-login", head))
+	assert.NotContains(t, check(BranchCheckRemote, "origin/ABC-1-login"), "go-githooks:")
+
+	pulls = `[{"number": 12, "state": "open", "merged_at": null}]`
+	assert.NotContains(t, check(BranchCheckPull, "origin/ABC-1-login"), "go-githooks:")
+	pulls = `[{"number": 12, "state": "closed", "merged_at": "2021-06-01T12:00:00Z"}]`
+	assert.Contains(t, check(BranchCheckPull, "origin/ABC-1-login"), "# go-githooks: the pull request from 'ABC-1-login' (#12) is already merged; this commit will not land with it")
+}
+
+func Test_branchCheckPullNeedsApiUrlAndToken(t *testing.T) {
+	r, _ := git.Init(memory.NewStorage(), memfs.New())
+	configured := func(text string) func(*git.Repository) (*config.Config, error) {
+		return func(*git.Repository) (*config.Config, error) {
+			cfg := config.NewConfig()
+			return cfg, cfg.Unmarshal([]byte(text))
+		}
+	}
+
+	o, err := Build(r, WithConfigSource(configured("[go-githooks \"prepare-commit-message\"]\n    branchCheck = pr\n[go-githooks \"vcs\"]\n    token = s3cr3t\n")))
+	assert.NoError(t, err)
+	assert.NoError(t, o.Prepare([]string{"COMMIT_EDITMSG", "message"}))
+	assert.Equal(t, BranchCheckRemote, o.BranchCheck, "no apiUrl of the user's choosing")
+	assert.Equal(t, "", o.VCSAPI.Token)
+
+	o, err = Build(r, WithConfigSource(configured("[go-githooks \"prepare-commit-message\"]\n    branchCheck = pr\n[go-githooks \"vcs\"]\n    apiUrl = https://api.github.com\n    token = s3cr3t\n")))
+	assert.NoError(t, err)
+	assert.NoError(t, o.Prepare([]string{"COMMIT_EDITMSG", "message"}))
+	assert.Equal(t, BranchCheckPull, o.BranchCheck)
+	assert.Equal(t, "s3cr3t", o.VCSAPI.Token)
+}
+
+func Test_appendMergeTickets(t *testing.T) {
+	dir, err := ioutil.TempDir("", "prepare-commit-msg")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	r, err := git.PlainInit(dir, false)
+	assert.NoError(t, err)
+	w, _ := r.Worktree()
+	sig := &object.Signature{Name: "Mal Reynolds", Email: "mal@serenity.com", When: time.Now()}
+	base, _ := w.Commit("initial commit\n", &git.CommitOptions{Author: sig})
+	_, _ = w.Commit("[ABC-1] fix login\n", &git.CommitOptions{Author: sig})
+	tip, _ := w.Commit("[ABC-2] add logout\n", &git.CommitOptions{Author: sig})
+	_ = r.Storer.SetReference(plumbing.NewHashReference("refs/heads/master", base))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, ".git", "MERGE_HEAD"), []byte(tip.String()+"\n"), 0644))
+
+	o := NewOptions(r)
+	o.setDefaultOptions()
+	o.Source = MergeSource
+	o.CommitMessageBytes = []byte("Merge branch 'feature'\n\n# Please enter a commit message\n")
+	assert.NoError(t, o.appendMergeTickets())
+	assert.Equal(t, "Merge branch 'feature'\n\nTickets:\n- ABC-1 fix login\n- ABC-2 add logout\n\n# Please enter a commit message\n", string(o.CommitMessageBytes))
+}
+
+func Test_prefixSkip(t *testing.T) {
+	r, _ := git.Init(memory.NewStorage(), memfs.New())
+	w, _ := r.Worktree()
+	_ = r.Storer.SetReference(plumbing.NewSymbolicReference(plumbing.HEAD, "refs/heads/FEAT-1"))
+	stage := func(path string) {
+		_ = util.WriteFile(w.Filesystem, path, []byte("hi"), 0644)
+		_, _ = w.Add(path)
+	}
+	stage("docs/guide.md")
+
+	rules, err := parsePrefixSkipRules([]string{"source=merge|squash", "source=message paths=docs/|*.md", "author=*[bot]@*"})
+	assert.NoError(t, err)
+	_, err = parsePrefixSkipRules([]string{"sauce=merge"})
+	assert.Error(t, err)
+
+	tests := []struct {
+		name   string
+		source CommitMessageSource
+		author string
+		stage  string
+		want   string
+	}{
+		{name: "merge", source: MergeSource, want: "source=merge|squash"},
+		{name: "docs only, with -m", source: MessageSource, want: "source=message paths=docs/|*.md"},
+		{name: "docs only, in the editor", source: EmptySource, want: ""},
+		{name: "a bot", source: EmptySource, author: "dependabot[bot]@users.noreply.github.com", want: "author=*[bot]@*"},
+		{name: "docs and code, with -m", source: MessageSource, stage: "main.go", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.stage != "" {
+				stage(tt.stage)
+			}
+			os.Setenv("GIT_AUTHOR_EMAIL", tt.author)
+			defer os.Unsetenv("GIT_AUTHOR_EMAIL")
+
+			o := NewOptions(r)
+			o.setDefaultOptions()
+			o.PrefixSkip = rules
+			o.Source = tt.source
+			o.CommitMessageBytes = []byte("update the guide\n")
+			got, err := o.prefixSkipped("FEAT-1")
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+
+			assert.NoError(t, o.prependBranchName())
+			assert.Equal(t, tt.want == "", strings.HasPrefix(string(o.CommitMessageBytes), "[FEAT-1]"))
+		})
+	}
+}
+
+func Test_messageEncoding(t *testing.T) {
+	dir, err := ioutil.TempDir("", "prepare-commit-msg")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	r, _ := git.Init(memory.NewStorage(), memfs.New())
+	_ = r.Storer.SetReference(plumbing.NewSymbolicReference(plumbing.HEAD, "refs/heads/FEAT-1"))
+
+	o := NewOptions(r)
+	o.setDefaultOptions()
+	o.CommitMessageFile = filepath.Join(dir, "COMMIT_EDITMSG")
+	assert.NoError(t, ioutil.WriteFile(o.CommitMessageFile, []byte("\xEF\xBB\xBFadd login\r\n\r\n\r\n\r\n"), 0644))
+	assert.NoError(t, o.readCommitMessageFromDisk())
+	assert.Equal(t, "add login\n\n", string(o.CommitMessageBytes))
+
+	assert.NoError(t, o.prependBranchName())
+	assert.Equal(t, "[FEAT-1] add login\r\n\r\n", string(o.formattedMessage()))
+	o.LineEndings = message.LFLineEndings
+	assert.Equal(t, "[FEAT-1] add login\n\n", string(o.formattedMessage()))
+	o.KeepBOM = true
+	assert.Equal(t, "\xEF\xBB\xBF[FEAT-1] add login\n\n", string(o.formattedMessage()))
+}
+
+// verboseMessage is what 'git commit -v' offers for a large change: an empty
+// message, git's comments, and about 100KB of diff below the scissors line
+func verboseMessage() []byte {
+	var b bytes.Buffer
+	b.WriteString("\n# Please enter the commit message for your changes. Lines starting\n# with '#' will be ignored, and an empty message aborts the commit.\n#\n")
+	b.WriteString("# On branch feature/ABC-1\n# ------------------------ >8 ------------------------\n# Do not modify or remove the line above.\n")
+	b.WriteString("diff --git a/service.go b/service.go\n--- a/service.go\n+++ b/service.go\n@@ -1,2000 +1,2000 @@\n")
+	for b.Len() < 100*1024 {
+		b.WriteString("+\treturn fmt.Errorf(\"could not read the session: %v\", err)\n")
+	}
+	return b.Bytes()
+}
+
+func benchmarkTransformer(b *testing.B, configure func(o *PrepareCommitMsgOptions), run func(o *PrepareCommitMsgOptions) error) {
+	r, _ := git.Init(memory.NewStorage(), memfs.New())
+	_ = r.Storer.SetReference(plumbing.NewSymbolicReference(plumbing.HEAD, "refs/heads/feature/ABC-1"))
+	o := NewOptions(r)
+	o.setDefaultOptions()
+	configure(o)
+	msg := verboseMessage()
+
+	b.ReportAllocs()
+	b.SetBytes(int64(len(msg)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		o.CommitMessageBytes = msg
+		if err := run(o); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkPrependBranchName(b *testing.B) {
+	benchmarkTransformer(b, func(o *PrepareCommitMsgOptions) {}, (*PrepareCommitMsgOptions).prependBranchName)
+}
+
+func BenchmarkPrependBranchNameAfterType(b *testing.B) {
+	benchmarkTransformer(b, func(o *PrepareCommitMsgOptions) {
+		o.PrefixPlacement = PrefixAfterType
+	}, func(o *PrepareCommitMsgOptions) error {
+		o.CommitMessageBytes = append([]byte("feat(auth): "), o.CommitMessageBytes...)
+		return o.prependBranchName()
+	})
+}
+
+func BenchmarkAppendCoauthorMarkup(b *testing.B) {
+	benchmarkTransformer(b, func(o *PrepareCommitMsgOptions) {
+		o.CoauthorsMarkupBytes = []byte("Co-authored-by: Zoe Washburne <zoe@serenity.com>\nCo-authored-by: Kaylee Frye <kaylee@serenity.com>\n")
+	}, (*PrepareCommitMsgOptions).appendCoauthorMarkup)
+}
+
+// BenchmarkAlreadyPrefixed is the path most commits take once the message has its
+// prefix, e.g. when the editor was aborted and git offers it again: nothing to change,
+// so the message should not be copied
+func BenchmarkAlreadyPrefixed(b *testing.B) {
+	prefixed := join([]byte("[feature/ABC-1] fix login"), nl, nl, bytes.TrimSpace(verboseMessage()), nl, nl)
+	benchmarkTransformer(b, func(o *PrepareCommitMsgOptions) {}, func(o *PrepareCommitMsgOptions) error {
+		o.CommitMessageBytes = prefixed
+		if err := o.prependBranchName(); err != nil {
+			return err
+		}
+		if &o.CommitMessageBytes[0] != &prefixed[0] {
+			b.Fatal("the message was copied")
+		}
+		return nil
+	})
+}
+
+func Test_appendAnnotations(t *testing.T) {
+	r, _ := git.Init(memory.NewStorage(), memfs.New())
+	w, _ := r.Worktree()
+	_ = r.Storer.SetReference(plumbing.NewSymbolicReference(plumbing.HEAD, "refs/heads/FEAT-1"))
+	_ = util.WriteFile(w.Filesystem, "db/migrate/001_users.sql", []byte("create table users;"), 0644)
+	_, _ = w.Add("db/migrate/001_users.sql")
+
+	annotations, err := parseAnnotations([]string{"X-Risk: high when paths=db/migrate/", "X-Risk: low", "X-Files: {filesChanged}", "X-Release: yes when branch=release/*"})
+	assert.NoError(t, err)
+	_, err = parseAnnotations([]string{"X-Risk: high when sauce=merge"})
+	assert.Error(t, err)
+	_, err = parseAnnotations([]string{"no key here"})
+	assert.Error(t, err)
+
+	o := NewOptions(r)
+	o.setDefaultOptions()
+	o.Annotations = annotations
+	o.CommitMessageBytes = []byte("add users\n\nX-Files: 7\n")
+	assert.NoError(t, o.appendAnnotations())
+	assert.Equal(t, "add users\n\nX-Files: 7\nX-Risk: high\n\n", string(o.CommitMessageBytes))
+}
+
+func Test_stripMergePrefix(t *testing.T) {
+	r, _ := git.Init(memory.NewStorage(), memfs.New())
+	_ = r.Storer.SetReference(plumbing.NewSymbolicReference(plumbing.HEAD, "refs/heads/main"))
+
+	tests := []struct {
+		name     string
+		branches []string
+		message  string
+		want     string
+	}{
+		{name: "into main", branches: []string{"main", "release/*"}, message: "[ABC-12] add login\n", want: "add login\n\nRefs: ABC-12\n\n"},
+		{name: "ticket already referenced", branches: []string{"main"}, message: "[ABC-12] add login\n\nRefs: ABC-12\n", want: "add login\n\nRefs: ABC-12\n"},
+		{name: "no prefix", branches: []string{"main"}, message: "add login\n", want: "add login\n"},
+		{name: "into another branch", branches: []string{"release/*"}, message: "[ABC-12] add login\n", want: "[ABC-12] add login\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := NewOptions(r)
+			o.setDefaultOptions()
+			o.MergeStripPrefix = tt.branches
+			o.Source = MergeSource
+			o.CommitMessageBytes = []byte(tt.message)
+			assert.NoError(t, o.stripMergePrefix())
+			assert.Equal(t, tt.want, string(o.CommitMessageBytes))
+		})
+	}
+}
+
+func Test_addPathCoauthors(t *testing.T) {
+	r, _ := git.Init(memory.NewStorage(), memfs.New())
+	w, _ := r.Worktree()
+	_ = util.WriteFile(w.Filesystem, "payments/card.go", []byte("package payments"), 0644)
+	_, _ = w.Add("payments/card.go")
+
+	o := NewOptions(r)
+	o.setDefaultOptions()
+	o.PathCoauthors, _ = pairing.ParseOwners([]string{"payments/ = Zoe Washburne <zoe@serenity.com>, River Tam <river@serenity.com>", "web/ = Kaylee Frye <kaylee@serenity.com>"})
+	o.CoauthorsMarkupBytes = []byte("Co-authored-by: Zoe Washburne <ZOE@serenity.com>\n")
+	assert.NoError(t, o.addPathCoauthors())
+	assert.Equal(t, "Co-authored-by: Zoe Washburne <ZOE@serenity.com>\nCo-authored-by: River Tam <river@serenity.com>", string(o.CoauthorsMarkupBytes), "merged with the session's coauthors, without repeats")
+}
+
+func Test_prependBranchNameUnicode(t *testing.T) {
+	r, _ := git.Init(memory.NewStorage(), memfs.New())
+	_ = r.Storer.SetReference(plumbing.NewSymbolicReference(plumbing.HEAD, "refs/heads/função/ABC-1"))
+	o := NewOptions(r)
+	o.setDefaultOptions()
+
+	tests := []struct {
+		name   string
+		branch branchname.Options
+		msg    string
+		want   string
+	}{
+		{name: "composed", branch: branchname.Options{Form: branchname.NFC}, msg: "add login", want: "[função/ABC-1] add login\n\n"},
+		{name: "typed composed on another machine", branch: branchname.Options{Form: branchname.NFD}, msg: "[função/ABC-1] add login", want: "[função/ABC-1] add login\n\n"},
+		{name: "transliterated", branch: branchname.Options{Form: branchname.NFC, Transliterate: true}, msg: "add login", want: "[funcao/ABC-1] add login\n\n"},
+		{name: "shortened", branch: branchname.Options{Form: branchname.NFC, MaxLength: 4}, msg: "add login", want: "[funç] add login\n\n"},
+	}
+	for _, tt := range tests {
+		o.BranchName = tt.branch
+		o.CommitMessageBytes = []byte(tt.msg)
+		assert.NoError(t, o.prependBranchName())
+		assert.Equal(t, tt.want, string(o.CommitMessageBytes), tt.name)
+	}
+}
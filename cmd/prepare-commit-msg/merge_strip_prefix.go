@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"github.com/davidalpert/go-githooks/pkg/message"
+	"github.com/davidalpert/go-githooks/pkg/repostate"
+	"regexp"
+	"strings"
+)
+
+// stripMergePrefix takes the branch prefix off the subject of a merge or squash into
+// one of the mergeStripPrefix branches, e.g. main or release/*, to keep mainline
+// history clean; the tickets the prefix named are kept in a Refs trailer
+func (o *PrepareCommitMsgOptions) stripMergePrefix() error {
+	state, err := repostate.Detect(o.Repo)
+	if err != nil {
+		return err
+	}
+	if !matchesAnyGlob(o.MergeStripPrefix, state.Branch) {
+		return nil
+	}
+	template, err := o.expandStagedVars(o.PrefixWithBranchTemplate)
+	if err != nil {
+		return err
+	}
+
+	subject := message.Subject(o.CommitMessageBytes)
+	found := message.FindPrefix(subject, template)
+	stripped := message.StripPrefix(subject, template)
+	if found == nil || stripped == "" || stripped == subject {
+		return nil
+	}
+	o.CommitMessageBytes = []byte(strings.Replace(string(o.CommitMessageBytes), subject, stripped, 1))
+
+	ticketRe, err := regexp.Compile(o.TicketPattern)
+	if err != nil {
+		return fmt.Errorf("ticketPattern '%s' is not a valid regular expression: %v", o.TicketPattern, err)
+	}
+	trailers := message.Trailers(o.CommitMessageBytes)
+	for _, ticket := range ticketRe.FindAllString(found[len(found)-1], -1) {
+		referenced := false
+		for _, t := range trailers {
+			referenced = referenced || strings.Contains(t.Value, ticket)
+		}
+		if !referenced {
+			o.CommitMessageBytes = message.AppendTrailer(o.CommitMessageBytes, "Refs: "+ticket)
+		}
+	}
+	return nil
+}
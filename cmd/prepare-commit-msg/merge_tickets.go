@@ -0,0 +1,43 @@
+package main
+
+import (
+	"fmt"
+	"github.com/davidalpert/go-githooks/pkg/changes"
+	"github.com/davidalpert/go-githooks/pkg/message"
+	"github.com/davidalpert/go-githooks/pkg/push"
+	"github.com/davidalpert/go-githooks/pkg/repostate"
+	"github.com/go-git/go-git/v5/plumbing"
+	"regexp"
+)
+
+// appendMergeTickets lists, in MERGE_MSG, the tickets worked on by the commits being
+// merged, i.e. those MERGE_HEAD has which HEAD does not
+func (o *PrepareCommitMsgOptions) appendMergeTickets() error {
+	heads := repostate.MergeHeads(o.Repo)
+	if len(heads) == 0 {
+		return nil
+	}
+	ticketRe, err := regexp.Compile(o.TicketPattern)
+	if err != nil {
+		return fmt.Errorf("ticketPattern '%s' is not a valid regular expression: %v", o.TicketPattern, err)
+	}
+	state, err := repostate.Detect(o.Repo)
+	if err != nil {
+		return err
+	}
+
+	summary := changes.Summary{}
+	for _, h := range heads {
+		commits, err := push.Range(o.Repo, h, []plumbing.Hash{state.Head}, push.DefaultLimit)
+		if err != nil {
+			return err
+		}
+		s := changes.Summarize(commits, ticketRe, o.PrefixWithBranchTemplate)
+		summary.Tickets = append(summary.Tickets, s.Tickets...)
+	}
+	if len(summary.Tickets) == 0 {
+		return nil
+	}
+	o.CommitMessageBytes = message.AppendParagraph(o.CommitMessageBytes, summary.Lines()...)
+	return nil
+}
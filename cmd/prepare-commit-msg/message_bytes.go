@@ -0,0 +1,50 @@
+package main
+
+import (
+	"bytes"
+	"regexp"
+)
+
+/*
+ * With 'git commit -v' the message file carries the whole diff below the scissors
+ * line, easily hundreds of KB. Transformers slice the message rather than convert it
+ * to a string, build their result with one allocation in join, and leave the message
+ * as it is when there is nothing to change; see the benchmarks in main_test.go.
+ */
+
+var coauthorLineRe = regexp.MustCompile(`(?im)^co-authored-by: [^>]+>`)
+
+// join concatenates parts into a single new allocation of exactly their length
+func join(parts ...[]byte) []byte {
+	n := 0
+	for _, p := range parts {
+		n += len(p)
+	}
+	b := make([]byte, 0, n)
+	for _, p := range parts {
+		b = append(b, p...)
+	}
+	return b
+}
+
+// isNormalized reports whether msg is already trimmed followed by "\n\n", the shape
+// prependBranchName leaves it in, so that it need not be rebuilt
+func isNormalized(msg, trimmed []byte) bool {
+	return len(msg) == len(trimmed)+2 && bytes.HasPrefix(msg, trimmed) && bytes.HasSuffix(msg, []byte("\n\n"))
+}
+
+// hasLinePrefixFold reports whether a line of msg starts with prefix, ignoring case;
+// it is much cheaper than a case-insensitive multiline regexp over a large diff
+func hasLinePrefixFold(msg []byte, prefix string) bool {
+	for len(msg) > 0 {
+		if len(msg) >= len(prefix) && bytes.EqualFold(msg[:len(prefix)], []byte(prefix)) {
+			return true
+		}
+		i := bytes.IndexByte(msg, '\n')
+		if i < 0 {
+			break
+		}
+		msg = msg[i+1:]
+	}
+	return false
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"github.com/davidalpert/go-githooks/pkg/exitcode"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"strings"
+)
+
+// Option sets up PrepareCommitMsgOptions in code, e.g. in tests or when embedding
+// the hook; options are checked by Build and win over env vars and git config
+type Option func(*PrepareCommitMsgOptions) error
+
+// CoauthorProvider lists the current coauthors as Co-authored-by trailers
+type CoauthorProvider interface {
+	CoauthorMarkup() ([]byte, error)
+}
+
+// CoauthorProviderFunc adapts a function to a CoauthorProvider
+type CoauthorProviderFunc func() ([]byte, error)
+
+func (f CoauthorProviderFunc) CoauthorMarkup() ([]byte, error) {
+	return f()
+}
+
+// GitMob lists the coauthors with 'git mob-print'; it is used unless another
+// provider is given
+var GitMob CoauthorProvider = CoauthorProviderFunc(func() ([]byte, error) {
+	markup, err := execAndCaptureOutput("list mob coauthors", "git", "mob-print")
+	return []byte(markup), err
+})
+
+// ConfigSource reads the config the options are overridden from; by default the
+// repo's merged config, as gitconfig.Load reads it
+type ConfigSource func(repo *git.Repository) (*config.Config, error)
+
+// Build creates the options for repo with their defaults and then opts, returning the
+// error of the first option which is not valid
+func Build(repo *git.Repository, opts ...Option) (*PrepareCommitMsgOptions, error) {
+	o := NewOptions(repo)
+	o.setDefaultOptions()
+	for _, opt := range opts {
+		if err := opt(o); err != nil {
+			return nil, exitcode.Wrap(exitcode.Config, err)
+		}
+	}
+	o.options = opts
+	return o, nil
+}
+
+// WithBranchPrefix prefixes messages with the branch name rendered through template,
+// which takes the branch as its one %s
+func WithBranchPrefix(template string) Option {
+	return func(o *PrepareCommitMsgOptions) error {
+		if strings.Count(strings.Replace(template, "%%", "", -1), "%s") != 1 {
+			return fmt.Errorf("branch prefix template '%s' must have exactly one %%s for the branch", template)
+		}
+		o.PrefixWithBranch = true
+		o.PrefixWithBranchTemplate = template
+		return nil
+	}
+}
+
+// WithBranchPrefixExclusions leaves commits on these branches without a prefix
+func WithBranchPrefixExclusions(branches ...string) Option {
+	return func(o *PrepareCommitMsgOptions) error {
+		o.PrefixWithBranchExclusions = branches
+		return nil
+	}
+}
+
+// WithCoauthorProvider lists the coauthors with p instead of 'git mob-print'
+func WithCoauthorProvider(p CoauthorProvider) Option {
+	return func(o *PrepareCommitMsgOptions) error {
+		if p == nil {
+			return fmt.Errorf("no coauthor provider given")
+		}
+		o.coauthorProvider = p
+		return nil
+	}
+}
+
+// WithConfigSource reads config from s instead of the repo's merged config
+func WithConfigSource(s ConfigSource) Option {
+	return func(o *PrepareCommitMsgOptions) error {
+		if s == nil {
+			return fmt.Errorf("no config source given")
+		}
+		o.configSource = s
+		return nil
+	}
+}
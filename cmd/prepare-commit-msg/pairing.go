@@ -0,0 +1,63 @@
+package main
+
+import (
+	"bytes"
+	"github.com/davidalpert/go-githooks/pkg/message"
+	"github.com/davidalpert/go-githooks/pkg/pairing"
+	"github.com/go-git/go-git/v5/config"
+	"strings"
+)
+
+// addPairingCoauthors adds whoever the pairing providers name to the coauthors from
+// git-mob, skipping anyone git-mob already listed
+func (o *PrepareCommitMsgOptions) addPairingCoauthors() error {
+	if len(o.PairingProviders) == 0 {
+		return nil
+	}
+	w, err := o.Repo.Worktree()
+	if err != nil {
+		// a bare repo has no session files
+		return nil
+	}
+
+	found, err := pairing.Coauthors(pairing.Options{
+		Root:             w.Filesystem.Root(),
+		Providers:        o.PairingProviders,
+		File:             o.PairingFile,
+		LiveShareSession: o.PairingLiveShareSession,
+		MaxAge:           o.PairingMaxAge,
+		Self:             o.selfEmail(),
+	})
+	if err != nil {
+		return err
+	}
+	o.addCoauthors(found)
+	return nil
+}
+
+// selfEmail is the user's own email, never credited as a coauthor
+func (o *PrepareCommitMsgOptions) selfEmail() string {
+	if cfg, err := o.Repo.ConfigScoped(config.GlobalScope); err == nil {
+		return cfg.User.Email
+	}
+	return ""
+}
+
+// addCoauthors adds found to the coauthors, skipping anyone already listed
+func (o *PrepareCommitMsgOptions) addCoauthors(found []message.Coauthor) {
+	listed := map[string]bool{}
+	for _, c := range message.Credits(o.CoauthorsMarkupBytes) {
+		listed[strings.ToLower(c.Email)] = true
+	}
+	markup := bytes.TrimSpace(o.CoauthorsMarkupBytes)
+	for _, c := range found {
+		if listed[strings.ToLower(c.Email)] {
+			continue
+		}
+		if len(markup) > 0 {
+			markup = append(markup, nl...)
+		}
+		markup = append(markup, []byte(c.String())...)
+	}
+	o.CoauthorsMarkupBytes = markup
+}
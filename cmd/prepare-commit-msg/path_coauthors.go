@@ -0,0 +1,54 @@
+package main
+
+import (
+	"github.com/davidalpert/go-githooks/pkg/pairing"
+	"github.com/davidalpert/go-githooks/pkg/staged"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// addPathCoauthors credits the owners of the staged paths, from pathCoauthors and the
+// codeowners file, alongside the session's coauthors
+func (o *PrepareCommitMsgOptions) addPathCoauthors() error {
+	if len(o.PathCoauthors) == 0 && o.CodeOwnersFile == "" {
+		return nil
+	}
+	w, err := o.Repo.Worktree()
+	if err != nil {
+		// a bare repo has nothing staged
+		return nil
+	}
+
+	owners := pairing.Owners{}
+	if o.CodeOwnersFile != "" {
+		p := o.CodeOwnersFile
+		if !filepath.IsAbs(p) {
+			p = filepath.Join(w.Filesystem.Root(), p)
+		}
+		if owners, err = pairing.ReadCodeowners(p); err != nil {
+			return err
+		}
+	}
+	// config is more specific than CODEOWNERS, so its rules come last and win
+	owners = append(owners, o.PathCoauthors...)
+
+	files, err := staged.Files(o.Repo)
+	if err != nil {
+		return err
+	}
+	paths := make([]string, 0, len(files))
+	for _, f := range files {
+		paths = append(paths, f.Path)
+	}
+	self := strings.ToLower(o.selfEmail())
+	found := owners.For(paths, o.PathCoauthorRotation, time.Now())
+	credited := found[:0]
+	for _, c := range found {
+		if strings.ToLower(c.Email) != self {
+			credited = append(credited, c)
+		}
+	}
+	o.addCoauthors(credited)
+	return nil
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"bytes"
+	"github.com/davidalpert/go-githooks/pkg/message"
+	"strings"
+)
+
+// PrefixPlacement chooses where the branch prefix goes in a conventional commit subject
+type PrefixPlacement string
+
+const (
+	PrefixAtStart   PrefixPlacement = "start"      // [JIRA-123] feat: subject
+	PrefixAfterType PrefixPlacement = "after-type" // feat(scope): [JIRA-123] subject
+)
+
+func PrefixPlacementFromString(s string) PrefixPlacement {
+	if PrefixPlacement(strings.ToLower(s)) == PrefixAfterType {
+		return PrefixAfterType
+	}
+	return PrefixAtStart
+}
+
+// hasBranchPrefix is true when msg already starts with prefix, in either slot
+func hasBranchPrefix(msg []byte, prefix string) bool {
+	return bytes.HasPrefix(msg, []byte(prefix)) || bytes.HasPrefix(msg[message.ConventionalHeaderLen(msg):], []byte(prefix))
+}
+
+// placeBranchPrefix returns the pieces of msg with prefix inserted in the configured
+// slot, for join; a message without a conventional header yet gets the prefix at the start
+func (o *PrepareCommitMsgOptions) placeBranchPrefix(msg []byte, prefix string) [][]byte {
+	if n := message.ConventionalHeaderLen(msg); o.PrefixPlacement == PrefixAfterType && n > 0 {
+		return [][]byte{msg[:n], []byte(prefix), space, msg[n:]}
+	}
+	return [][]byte{[]byte(prefix), space, msg}
+}
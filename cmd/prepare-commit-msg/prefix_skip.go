@@ -0,0 +1,158 @@
+package main
+
+import (
+	"fmt"
+	"github.com/davidalpert/go-githooks/pkg/staged"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing/format/gitignore"
+	"os"
+	"regexp"
+	"strings"
+)
+
+/*
+ * prefixSkip rules leave the branch prefix off commits which prefixBranchExclusions,
+ * being by branch only, cannot single out. prefixSkip is a list of rules, e.g. a YAML
+ * list in .githooks.yml; each rule is space-separated conditions, all of which must
+ * hold, and a condition holds when any of its |-separated values does:
+ *
+ *   source=merge|squash            the commit message source, or 'empty' for none
+ *   paths=docs/|*.md               every staged file matches one of these .gitignore-style patterns
+ *   branch=release/*               the branch; * matches anything
+ *   author=*[bot]@*                GIT_AUTHOR_EMAIL, or user.email
+ *   committer=*@ci.serenity.com    GIT_COMMITTER_EMAIL, or user.email
+ *
+ * e.g. 'source=message paths=docs/' skips docs-only commits made with -m.
+ */
+
+var prefixSkipKeys = []string{"source", "paths", "branch", "author", "committer"}
+
+// PrefixSkipRule is one prefixSkip entry
+type PrefixSkipRule struct {
+	text       string
+	conditions map[string][]string
+}
+
+func parsePrefixSkipRules(entries []string) ([]PrefixSkipRule, error) {
+	rules := make([]PrefixSkipRule, 0, len(entries))
+	for _, e := range entries {
+		conditions, err := parseConditions(e)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse prefixSkip '%s': %v", e, err)
+		}
+		if len(conditions) > 0 {
+			rules = append(rules, PrefixSkipRule{text: strings.TrimSpace(e), conditions: conditions})
+		}
+	}
+	return rules, nil
+}
+
+// parseConditions reads space-separated key=value[|value] conditions
+func parseConditions(s string) (map[string][]string, error) {
+	conditions := map[string][]string{}
+	for _, term := range strings.Fields(s) {
+		parts := strings.SplitN(term, "=", 2)
+		if len(parts) != 2 || parts[1] == "" || !stringInSlice(prefixSkipKeys, parts[0]) {
+			return nil, fmt.Errorf("expected conditions like %s=<value>[|<value>]", strings.Join(prefixSkipKeys, "=..., "))
+		}
+		conditions[parts[0]] = append(conditions[parts[0]], strings.Split(parts[1], "|")...)
+	}
+	return conditions, nil
+}
+
+// prefixSkipped returns the first prefixSkip rule this commit meets, or ""; the
+// index is only read when a rule gets as far as its paths condition
+func (o *PrepareCommitMsgOptions) prefixSkipped(branch string) (string, error) {
+	var files []staged.File
+	for _, r := range o.PrefixSkip {
+		met, err := o.conditionsMet(r.conditions, branch, &files)
+		if err != nil {
+			return "", err
+		}
+		if met {
+			return r.text, nil
+		}
+	}
+	return "", nil
+}
+
+// conditionsMet reports whether this commit meets every condition; files caches the
+// staged files across calls, read only once a paths condition needs them
+func (o *PrepareCommitMsgOptions) conditionsMet(conditions map[string][]string, branch string, files *[]staged.File) (bool, error) {
+	for _, key := range prefixSkipKeys {
+		values, ok := conditions[key]
+		if !ok {
+			continue
+		}
+		met := false
+		switch key {
+		case "source":
+			source := o.Source.String()
+			if o.Source == EmptySource {
+				source = "empty"
+			}
+			met = stringInSlice(values, source)
+		case "paths":
+			if *files == nil {
+				var err error
+				if *files, err = staged.Files(o.Repo); err != nil {
+					return false, err
+				}
+			}
+			met = allPathsMatch(*files, values)
+		case "branch":
+			met = matchesAnyGlob(values, branch)
+		case "author":
+			met = matchesAnyGlob(values, o.identityEmail("GIT_AUTHOR_EMAIL"))
+		case "committer":
+			met = matchesAnyGlob(values, o.identityEmail("GIT_COMMITTER_EMAIL"))
+		}
+		if !met {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// identityEmail is the email git commits with, from env or else user.email
+func (o *PrepareCommitMsgOptions) identityEmail(env string) string {
+	if e := os.Getenv(env); e != "" {
+		return e
+	}
+	if cfg, err := o.Repo.ConfigScoped(config.SystemScope); err == nil {
+		return cfg.User.Email
+	}
+	return ""
+}
+
+// allPathsMatch reports whether there are staged files and each matches a pattern
+func allPathsMatch(files []staged.File, patterns []string) bool {
+	ps := make([]gitignore.Pattern, 0, len(patterns))
+	for _, p := range patterns {
+		ps = append(ps, gitignore.ParsePattern(p, nil))
+	}
+	for _, f := range files {
+		matched := false
+		for _, p := range ps {
+			if p.Match(strings.Split(f.Path, "/"), false) == gitignore.Exclude {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return len(files) > 0
+}
+
+// matchesAnyGlob matches s against patterns where * stands for anything
+func matchesAnyGlob(patterns []string, s string) bool {
+	for _, p := range patterns {
+		re := "^" + strings.Replace(regexp.QuoteMeta(p), `\*`, ".*", -1) + "$"
+		if ok, _ := regexp.MatchString("(?i)"+re, s); ok {
+			return true
+		}
+	}
+	return false
+}
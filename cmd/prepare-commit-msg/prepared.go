@@ -0,0 +1,60 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/davidalpert/go-githooks/pkg/message"
+	"strings"
+)
+
+// preparedMarker starts the comment recording the hash of a message this hook has
+// already prepared; git strips it with the other comments when the commit is made
+const preparedMarker = "# go-githooks: prepared "
+
+// contentHash identifies what the message says, ignoring git comments and whitespace
+func contentHash(msg []byte) string {
+	content, _ := message.SplitComments(msg)
+	sum := sha256.Sum256(bytes.TrimSpace(content))
+	return hex.EncodeToString(sum[:6])
+}
+
+// alreadyPrepared reports whether the message carries a marker matching its content,
+// e.g. when the editor was aborted and the prepared message is offered again; the
+// transformers then have nothing left to do
+func (o *PrepareCommitMsgOptions) alreadyPrepared() bool {
+	_, comments := message.SplitComments(o.CommitMessageBytes)
+	for _, line := range strings.Split(string(comments), "\n") {
+		if strings.HasPrefix(line, preparedMarker) {
+			return strings.TrimSpace(strings.TrimPrefix(line, preparedMarker)) == contentHash(o.CommitMessageBytes)
+		}
+	}
+	return false
+}
+
+// markPrepared records the content hash; only messages which already have git
+// comments get one, since those are the messages git strips comments from, and
+// not when commit.cleanup keeps comments in the recorded message
+func (o *PrepareCommitMsgOptions) markPrepared() error {
+	if o.CleanupMode != message.CleanupDefault && o.CleanupMode != message.CleanupStrip {
+		return nil
+	}
+	content, comments := message.SplitComments(o.CommitMessageBytes)
+	if len(comments) == 0 {
+		return nil
+	}
+
+	kept := make([]string, 0)
+	for _, line := range strings.SplitAfter(string(comments), "\n") {
+		if !strings.HasPrefix(line, preparedMarker) {
+			kept = append(kept, line)
+		}
+	}
+
+	var b bytes.Buffer
+	b.Write(content)
+	b.WriteString(preparedMarker + contentHash(o.CommitMessageBytes) + "\n")
+	b.WriteString(strings.Join(kept, ""))
+	o.CommitMessageBytes = b.Bytes()
+	return nil
+}
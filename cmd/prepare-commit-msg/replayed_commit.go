@@ -0,0 +1,91 @@
+package main
+
+import (
+	"github.com/davidalpert/go-githooks/pkg/message"
+	"github.com/go-git/go-git/v5/plumbing"
+	"regexp"
+	"strings"
+)
+
+// ReplayedCommitKind identifies messages which git generated from an existing commit
+type ReplayedCommitKind int
+
+const (
+	NotReplayed ReplayedCommitKind = iota
+	Revert                         // created by git revert
+	CherryPick                     // created by git cherry-pick
+)
+
+// ReplayBehavior controls how a replayed commit message is transformed
+type ReplayBehavior string
+
+const (
+	ReplaySkip    ReplayBehavior = "skip"    // leave the message untouched
+	ReplayRefs    ReplayBehavior = "refs"    // keep the subject, carry the original ticket into a Refs: trailer
+	ReplayDefault ReplayBehavior = "default" // transform the message like any other
+)
+
+var (
+	revertSubjectRe    = regexp.MustCompile(`^Revert "(.*)"$`)
+	cherryPickedFromRe = regexp.MustCompile(`(?m)^\(cherry picked from commit [0-9a-f]+\)$`)
+	cherryPickHeadRef  = plumbing.ReferenceName("CHERRY_PICK_HEAD")
+	revertHeadRef      = plumbing.ReferenceName("REVERT_HEAD")
+)
+
+func ReplayBehaviorFromString(s string) ReplayBehavior {
+	switch ReplayBehavior(strings.ToLower(s)) {
+	case ReplaySkip:
+		return ReplaySkip
+	case ReplayDefault:
+		return ReplayDefault
+	}
+	return ReplayRefs
+}
+
+func (o *PrepareCommitMsgOptions) detectReplayedCommit() ReplayedCommitKind {
+	subject := message.Subject(o.CommitMessageBytes)
+	if revertSubjectRe.MatchString(subject) || o.hasReference(revertHeadRef) {
+		return Revert
+	}
+	if cherryPickedFromRe.Match(o.CommitMessageBytes) || o.hasReference(cherryPickHeadRef) {
+		return CherryPick
+	}
+	return NotReplayed
+}
+
+func (o *PrepareCommitMsgOptions) replayBehavior(kind ReplayedCommitKind) ReplayBehavior {
+	switch kind {
+	case Revert:
+		return o.RevertBehavior
+	case CherryPick:
+		return o.CherryPickBehavior
+	}
+	return ReplayDefault
+}
+
+func (o *PrepareCommitMsgOptions) hasReference(name plumbing.ReferenceName) bool {
+	if o.Repo == nil {
+		return false
+	}
+	_, err := o.Repo.Reference(name, false)
+	return err == nil
+}
+
+// originalTicket finds the branch prefix that the replayed commit's subject was created with
+func (o *PrepareCommitMsgOptions) originalTicket() string {
+	subject := message.Subject(o.CommitMessageBytes)
+	if m := revertSubjectRe.FindStringSubmatch(subject); len(m) > 1 {
+		subject = m[1]
+	}
+
+	if m := message.FindPrefix(subject, o.PrefixWithBranchTemplate); len(m) > 1 {
+		return m[1]
+	}
+	return ""
+}
+
+func (o *PrepareCommitMsgOptions) appendRefsTrailer() {
+	if ticket := o.originalTicket(); ticket != "" {
+		o.CommitMessageBytes = message.AppendTrailer(o.CommitMessageBytes, "Refs: "+ticket)
+	}
+}
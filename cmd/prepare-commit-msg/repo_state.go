@@ -0,0 +1,60 @@
+package main
+
+import (
+	"github.com/davidalpert/go-githooks/pkg/repostate"
+	"strings"
+)
+
+// DetachedHeadPrefix chooses what stands in for the branch name on a detached HEAD
+type DetachedHeadPrefix string
+
+const (
+	DetachedSkip     DetachedHeadPrefix = "skip"     // leave the message without a prefix
+	DetachedShortSha DetachedHeadPrefix = "sha"      // the abbreviated hash of HEAD
+	DetachedLiteral  DetachedHeadPrefix = "detached" // the word 'detached'
+)
+
+func DetachedHeadPrefixFromString(s string) DetachedHeadPrefix {
+	switch DetachedHeadPrefix(strings.ToLower(s)) {
+	case DetachedShortSha:
+		return DetachedShortSha
+	case DetachedLiteral:
+		return DetachedLiteral
+	}
+	return DetachedSkip
+}
+
+// prefixBranchName finds the branch to prefix the message with: the current branch
+// (before its first commit, as FirstCommitPrefix says), the branch a rebase or bisect started from, or the
+// configured stand-in on a detached HEAD; "" means no prefix. A reused message keeps its own prefix
+// as AmendPrefix says.
+func (o *PrepareCommitMsgOptions) prefixBranchName() (string, error) {
+	if o.AmendPrefix == AmendOriginal {
+		if ticket := o.amendedTicket(); ticket != "" {
+			return ticket, nil
+		}
+	}
+	state, err := repostate.Detect(o.Repo)
+	if err != nil {
+		return "", err
+	}
+	if state.Unborn {
+		if state.Branch = o.firstCommitBranchName(state.Branch); state.Branch == "" {
+			return "", nil
+		}
+	}
+	if state.Branch != "" && o.RememberBranchPrefix {
+		return o.rememberedBranchPrefix(state.Branch)
+	}
+	if state.Branch != "" {
+		return state.Branch, nil
+	}
+
+	switch o.DetachedHeadPrefix {
+	case DetachedShortSha:
+		return state.ShortHead(), nil
+	case DetachedLiteral:
+		return string(DetachedLiteral), nil
+	}
+	return "", nil
+}
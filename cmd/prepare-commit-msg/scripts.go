@@ -0,0 +1,36 @@
+package main
+
+import (
+	"github.com/davidalpert/go-githooks/pkg/scripts"
+)
+
+// runScripts passes the message through the repo's .githooks/prepare-commit-msg.d
+// scripts, if the user has enabled them
+func (o *PrepareCommitMsgOptions) runScripts() error {
+	w, err := o.Repo.Worktree()
+	if err != nil {
+		// a bare repo has nowhere to keep scripts
+		return nil
+	}
+	root := w.Filesystem.Root()
+
+	found, err := scripts.Discover(root, "prepare-commit-msg")
+	if err != nil || len(found) == 0 {
+		return err
+	}
+
+	args := []string{o.CommitMessageFile}
+	if o.Source != EmptySource {
+		args = append(args, o.Source.String())
+	}
+	if o.CommitObject != "" {
+		args = append(args, o.CommitObject)
+	}
+
+	msg, err := scripts.Pipe(root, "prepare-commit-msg", found, o.CommitMessageBytes, args...)
+	if err != nil {
+		return err
+	}
+	o.CommitMessageBytes = msg
+	return nil
+}
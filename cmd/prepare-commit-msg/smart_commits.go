@@ -0,0 +1,29 @@
+package main
+
+import (
+	"fmt"
+	"github.com/davidalpert/go-githooks/pkg/message"
+	"github.com/davidalpert/go-githooks/pkg/repostate"
+	"github.com/davidalpert/go-githooks/pkg/smartcommit"
+)
+
+// convertSmartCommits turns '@time 2h' style directives into Jira smart commit
+// commands for the issue key the subject names, else the one in the branch name
+func (o *PrepareCommitMsgOptions) convertSmartCommits() error {
+	key := smartcommit.FindKey(message.Subject(o.CommitMessageBytes))
+	if key == "" {
+		state, err := repostate.Detect(o.Repo)
+		if err != nil {
+			return err
+		}
+		key = smartcommit.FindKey(state.Branch)
+	}
+	if key == "" {
+		if smartcommit.HasDirectives(o.CommitMessageBytes) {
+			fmt.Println("could not find a Jira issue key in the subject or branch for the smart commit directives")
+		}
+		return nil
+	}
+	o.CommitMessageBytes = smartcommit.Convert(o.CommitMessageBytes, key)
+	return nil
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"bytes"
+	"github.com/davidalpert/go-githooks/pkg/message"
+	"github.com/go-git/go-git/v5/config"
+	"regexp"
+	"strings"
+)
+
+var (
+	squashAuthorRe = regexp.MustCompile(`(?m)^Author:[ \t]*([^<\n]*?)[ \t]*<([^>\n]+)>`)
+	squashIndentRe = regexp.MustCompile(`(?m)^[ \t]+`)
+)
+
+// squashedCoauthors lists everyone who wrote or co-wrote the commits git merge --squash
+// listed in the message, i.e. their 'Author:' lines and the Co-authored-by trailers
+// of their indented messages, in order and each once
+func squashedCoauthors(msg []byte) []message.Coauthor {
+	content, _ := message.SplitComments(msg)
+	found := make([]message.Coauthor, 0)
+	for _, entry := range bytes.Split(content, []byte("\ncommit "))[1:] {
+		if m := squashAuthorRe.FindSubmatch(entry); m != nil {
+			found = append(found, message.Coauthor{Name: string(m[1]), Email: strings.TrimSpace(string(m[2]))})
+		}
+		found = append(found, message.Coauthors(squashIndentRe.ReplaceAll(entry, empty))...)
+	}
+	return found
+}
+
+// appendSquashCoauthors keeps the attribution a squash merge otherwise drops: the
+// authors and coauthors of the squashed commits become Co-authored-by trailers,
+// leaving out whoever is committing and anyone the message already credits
+func (o *PrepareCommitMsgOptions) appendSquashCoauthors() error {
+	seen := map[string]bool{}
+	if cfg, err := o.Repo.ConfigScoped(config.GlobalScope); err == nil && cfg.User.Email != "" {
+		_, email := o.Mailmap.Map(cfg.User.Name, cfg.User.Email)
+		seen[strings.ToLower(email)] = true
+	}
+	for _, t := range message.Trailers(o.CommitMessageBytes) {
+		for _, c := range message.Coauthors([]byte(t.String())) {
+			seen[strings.ToLower(o.Mailmap.Coauthor(c).Email)] = true
+		}
+	}
+
+	for _, c := range squashedCoauthors(o.CommitMessageBytes) {
+		c = o.Mailmap.Coauthor(c)
+		if seen[strings.ToLower(c.Email)] {
+			continue
+		}
+		seen[strings.ToLower(c.Email)] = true
+		o.CommitMessageBytes = message.AppendTrailer(o.CommitMessageBytes, c.String())
+	}
+	return nil
+}
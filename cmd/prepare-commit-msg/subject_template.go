@@ -0,0 +1,63 @@
+package main
+
+import (
+	"bytes"
+	"github.com/davidalpert/go-githooks/pkg/message"
+	"github.com/davidalpert/go-githooks/pkg/staged"
+)
+
+// expandStagedVars fills in the staged summary variables, e.g. {primaryPackage}, and
+// the amended commit's, in template; the index is only analysed once, and only when a
+// template needs it
+func (o *PrepareCommitMsgOptions) expandStagedVars(template string) (string, error) {
+	template = o.expandAmendedVars(template)
+	if !staged.UsesVars(template) {
+		return template, nil
+	}
+	if o.stagedSummary == nil {
+		s, err := staged.Summarize(o.Repo, o.ScopeMap)
+		if err != nil {
+			return template, err
+		}
+		o.stagedSummary = &s
+	}
+	return o.stagedSummary.Expand(template), nil
+}
+
+// applySubjectTemplate starts an empty message with the rendered subject template,
+// e.g. 'feat({primaryPackage}): '; messages with a subject are left alone
+func (o *PrepareCommitMsgOptions) applySubjectTemplate() error {
+	if o.RootCommitTemplate != "" && o.isFirstCommit() {
+		return nil
+	}
+	return o.startMessageWith(o.SubjectTemplate)
+}
+
+// startMessageWith starts an empty message with the rendered template
+func (o *PrepareCommitMsgOptions) startMessageWith(template string) error {
+	if message.Effective(o.CommitMessageBytes, o.PrefixWithBranchTemplate) != "" {
+		return nil
+	}
+
+	subject, err := o.expandStagedVars(template)
+	if err != nil {
+		return err
+	}
+
+	content, comments := message.SplitComments(o.CommitMessageBytes)
+	var b bytes.Buffer
+	b.WriteString(subject)
+	b.Write(nl)
+	if trimmed := bytes.TrimSpace(content); len(trimmed) > 0 {
+		// keep any trailers which were already there
+		b.Write(nl)
+		b.Write(trimmed)
+		b.Write(nl)
+	}
+	if len(comments) > 0 {
+		b.Write(nl)
+		b.Write(comments)
+	}
+	o.CommitMessageBytes = b.Bytes()
+	return nil
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"github.com/davidalpert/go-githooks/pkg/message"
+	"github.com/davidalpert/go-githooks/pkg/repostate"
+	"github.com/davidalpert/go-githooks/pkg/stack"
+	"strings"
+)
+
+// TopicSource chooses where the Topic trailer of a stacked change comes from
+type TopicSource string
+
+const (
+	TopicOff     TopicSource = "off"
+	TopicBranch  TopicSource = "branch"  // the current branch, e.g. feature/FEAT-1
+	TopicInherit TopicSource = "inherit" // the Topic of the commit below, so a stack keeps one topic
+)
+
+func TopicSourceFromString(s string) TopicSource {
+	switch TopicSource(strings.ToLower(s)) {
+	case TopicBranch:
+		return TopicBranch
+	case TopicInherit:
+		return TopicInherit
+	}
+	return TopicOff
+}
+
+// appendTopic adds a Topic trailer unless the message already has one; branches in
+// the prefix exclusions (e.g. main) are not the topic of a stack and get nothing
+func (o *PrepareCommitMsgOptions) appendTopic() error {
+	if stack.Parse(o.CommitMessageBytes).Topic() != "" {
+		return nil
+	}
+
+	topic, err := o.topic()
+	if err != nil || topic == "" {
+		return err
+	}
+	o.CommitMessageBytes = message.AppendTrailer(o.CommitMessageBytes, stack.TopicKey+": "+topic)
+	return nil
+}
+
+func (o *PrepareCommitMsgOptions) topic() (string, error) {
+	state, err := repostate.Detect(o.Repo)
+	if err != nil {
+		return "", err
+	}
+	if state.Branch == "" || stringInSlice(o.PrefixWithBranchExclusions, state.Branch) {
+		return "", nil
+	}
+
+	if o.Topic == TopicBranch {
+		return state.Branch, nil
+	}
+
+	if state.Unborn {
+		return "", nil
+	}
+	parent, err := o.Repo.CommitObject(state.Head)
+	if err != nil {
+		return "", fmt.Errorf("could not read HEAD: %v", err)
+	}
+	return stack.Parse([]byte(parent.Message)).Topic(), nil
+}
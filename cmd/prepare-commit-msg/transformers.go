@@ -0,0 +1,120 @@
+package main
+
+import (
+	"github.com/davidalpert/go-githooks/pkg/budget"
+	"github.com/davidalpert/go-githooks/pkg/bypass"
+	"github.com/davidalpert/go-githooks/pkg/message"
+	"time"
+)
+
+// transformer is a single named step applied to the commit message
+type transformer struct {
+	name        string
+	description string
+	run         func() error
+	// optional steps have a priority, and are skipped when the latency budget runs out
+	priority budget.Priority
+}
+
+// transformers lists the enabled transformations in the order they are applied
+func (o *PrepareCommitMsgOptions) transformers(replayBehavior ReplayBehavior) []transformer {
+	ts := make([]transformer, 0)
+
+	if o.RootCommitTemplate != "" && replayBehavior != ReplayRefs {
+		ts = append(ts, transformer{name: "root-commit-template", description: "starting the first commit's message from the template", run: o.applyRootCommitTemplate})
+	}
+
+	if o.SubjectTemplate != "" && replayBehavior != ReplayRefs {
+		ts = append(ts, transformer{name: "subject-template", description: "starting the subject from the template", run: o.applySubjectTemplate})
+	}
+
+	if o.BranchCheck != BranchCheckOff && replayBehavior != ReplayRefs {
+		ts = append(ts, transformer{name: "branch-check", description: "checking the branch is not stale", run: o.checkBranch, priority: budget.Low})
+	}
+
+	if o.PrefixWithBranch && replayBehavior != ReplayRefs {
+		ts = append(ts, transformer{name: "branch-prefix", description: "prefixing branch name", run: o.prependBranchName})
+	}
+
+	if o.SmartCommits {
+		ts = append(ts, transformer{name: "smart-commits", description: "converting directives to Jira smart commits", run: o.convertSmartCommits})
+	}
+
+	if len(o.MergeStripPrefix) > 0 && (o.Source == MergeSource || o.Source == SquashSource) {
+		ts = append(ts, transformer{name: "merge-strip-prefix", description: "taking the branch prefix off the merge subject", run: o.stripMergePrefix})
+	}
+
+	if o.MergeTickets && o.Source == MergeSource {
+		ts = append(ts, transformer{name: "merge-tickets", description: "listing the merged tickets", run: o.appendMergeTickets, priority: budget.Normal})
+	}
+
+	if len(o.CoauthorsMarkupBytes) > 0 {
+		ts = append(ts, transformer{name: "coauthors", description: "appending coauthors", run: o.appendCoauthorMarkup})
+	}
+
+	if len(o.CoauthorsMarkupBytes) > 0 && o.CoauthorsTTL > 0 {
+		ts = append(ts, transformer{name: "coauthor-expiry", description: "checking how old the mob is", run: o.checkCoauthorExpiry, priority: budget.Low})
+	}
+
+	if o.SquashCoauthors && o.Source == SquashSource {
+		ts = append(ts, transformer{name: "squash-coauthors", description: "crediting the squashed commits' authors", run: o.appendSquashCoauthors, priority: budget.Normal})
+	}
+
+	if replayBehavior == ReplayRefs {
+		ts = append(ts, transformer{name: "refs-trailer", description: "adding Refs: trailer", run: func() error {
+			o.appendRefsTrailer()
+			return nil
+		}})
+	}
+
+	if o.BuiltOn != BuiltOnOff {
+		ts = append(ts, transformer{name: "built-on", description: "adding Built-on trailer", run: o.appendBuiltOn, priority: budget.Low})
+	}
+
+	if o.Topic != TopicOff {
+		ts = append(ts, transformer{name: "topic", description: "adding Topic trailer", run: o.appendTopic, priority: budget.Low})
+	}
+
+	if len(o.Annotations) > 0 && replayBehavior != ReplayRefs {
+		ts = append(ts, transformer{name: "annotations", description: "adding annotation trailers", run: o.appendAnnotations, priority: budget.Normal})
+	}
+
+	if o.ScriptsEnabled {
+		ts = append(ts, transformer{name: "scripts", description: "running .githooks/prepare-commit-msg.d scripts", run: o.runScripts, priority: budget.Normal})
+	}
+
+	if o.Bypass != nil {
+		ts = append(ts, transformer{name: "bypass-trailer", description: "adding Hook-Bypass trailer", run: func() error {
+			o.CommitMessageBytes = message.AppendTrailer(o.CommitMessageBytes, bypass.Trailer+": "+o.Bypass.Reason)
+			return nil
+		}})
+	}
+
+	if o.SignOff {
+		ts = append(ts, transformer{name: "sign-off", description: "adding Signed-off-by", run: o.appendSignOff})
+	}
+
+	if o.WarnOnEmptyMessage {
+		ts = append(ts, transformer{name: "empty-message-warning", description: "checking for an empty message", run: o.warnOnEmptyMessage, priority: budget.Low})
+	}
+
+	if o.AnnotateChanges {
+		ts = append(ts, transformer{name: "annotate-changes", description: "describing the changes made", run: o.annotateChanges, priority: budget.Low})
+	}
+
+	if o.MarkPrepared {
+		ts = append(ts, transformer{name: "prepared-marker", description: "marking the message as prepared", run: o.markPrepared})
+	}
+
+	return ts
+}
+
+func (o *PrepareCommitMsgOptions) runTransformer(t transformer) error {
+	if t.priority != 0 && !o.Budget.Allows(t.name, t.priority) {
+		return nil
+	}
+	start := time.Now()
+	err := t.run()
+	o.Telemetry.Record(t.name, time.Since(start), err)
+	return err
+}
@@ -3,9 +3,15 @@ module github.com/davidalpert/go-githooks
 go 1.16
 
 require (
+	github.com/BurntSushi/toml v1.2.1
 	github.com/apex/log v1.9.0
 	github.com/approvals/go-approval-tests v0.0.0-20210131072903-38d0b0ec12b1
-	github.com/go-git/go-billy/v5 v5.3.1 // indirect
+	github.com/go-git/go-billy/v5 v5.3.1
 	github.com/go-git/go-git/v5 v5.4.2
-	github.com/stretchr/testify v1.7.0 // indirect
+	github.com/santhosh-tekuri/jsonschema/v5 v5.0.0
+	github.com/sergi/go-diff v1.1.0
+	github.com/stretchr/testify v1.7.0
+	golang.org/x/crypto v0.0.0-20210421170649-83a5a9bb288b
+	golang.org/x/text v0.3.3
+	gopkg.in/yaml.v3 v3.0.0-20200605160147-a5ece683394c
 )
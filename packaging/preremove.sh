@@ -0,0 +1,7 @@
+#!/bin/sh
+# only unset core.hooksPath when it still points at the hooks this package installed
+dir=/usr/local/libexec/go-githooks/hooks
+if [ "$(git config --system core.hooksPath)" = "$dir" ]; then
+  git config --system --unset core.hooksPath
+fi
+rm -rf "$dir"
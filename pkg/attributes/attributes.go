@@ -0,0 +1,193 @@
+package attributes
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/format/gitattributes"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/storage/filesystem"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+	"strings"
+)
+
+const gitattributesFile = ".gitattributes"
+
+// SkipAttribute marks paths which the hooks' file checks leave alone: 'githooks-skip'
+// for every check, or 'githooks-skip=todo-ticket,formatting' for only those rules
+const SkipAttribute = "githooks-skip"
+
+// Attributes answers which gitattributes apply to a path, e.g. filter=lfs or linguist-generated
+type Attributes struct {
+	// in ascending order of priority
+	stack []gitattributes.MatchAttribute
+}
+
+// FromIndex reads the .gitattributes files as they are staged, plus .git/info/attributes
+func FromIndex(repo *git.Repository) (*Attributes, error) {
+	idx, err := repo.Storer.Index()
+	if err != nil {
+		return nil, fmt.Errorf("could not read the index: %v", err)
+	}
+
+	files := map[string][]byte{}
+	for _, e := range idx.Entries {
+		if path.Base(e.Name) != gitattributesFile || e.Stage != 0 {
+			continue
+		}
+		blob, err := repo.BlobObject(e.Hash)
+		if err != nil {
+			return nil, fmt.Errorf("could not read staged '%s': %v", e.Name, err)
+		}
+		if files[e.Name], err = readBlob(blob); err != nil {
+			return nil, err
+		}
+	}
+
+	a, err := fromFiles(files)
+	if err != nil {
+		return nil, err
+	}
+	return a, a.addInfoAttributes(repo)
+}
+
+// FromTree reads the .gitattributes files committed in tree
+func FromTree(tree *object.Tree) (*Attributes, error) {
+	files := map[string][]byte{}
+	err := tree.Files().ForEach(func(f *object.File) error {
+		if path.Base(f.Name) != gitattributesFile {
+			return nil
+		}
+		contents, err := readBlob(&f.Blob)
+		files[f.Name] = contents
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not read .gitattributes: %v", err)
+	}
+	return fromFiles(files)
+}
+
+func fromFiles(files map[string][]byte) (*Attributes, error) {
+	// deeper files take priority over shallower ones
+	names := make([]string, 0, len(files))
+	for n := range files {
+		names = append(names, n)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		di, dj := strings.Count(names[i], "/"), strings.Count(names[j], "/")
+		if di != dj {
+			return di < dj
+		}
+		return names[i] < names[j]
+	})
+
+	a := &Attributes{}
+	for _, n := range names {
+		domain := []string{}
+		if dir := path.Dir(n); dir != "." {
+			domain = strings.Split(dir, "/")
+		}
+		attrs, err := gitattributes.ReadAttributes(bytes.NewReader(files[n]), domain, len(domain) == 0)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse '%s': %v", n, err)
+		}
+		a.stack = append(a.stack, attrs...)
+	}
+	return a, nil
+}
+
+func (a *Attributes) addInfoAttributes(repo *git.Repository) error {
+	s, ok := repo.Storer.(*filesystem.Storage)
+	if !ok {
+		return nil
+	}
+	f, err := s.Filesystem().Open(s.Filesystem().Join("info", "attributes"))
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	attrs, err := gitattributes.ReadAttributes(f, nil, true)
+	if err != nil {
+		return fmt.Errorf("could not parse .git/info/attributes: %v", err)
+	}
+	a.stack = append(a.stack, attrs...)
+	return nil
+}
+
+// Get returns the highest priority attribute name for p, or nil when it is unspecified
+func (a *Attributes) Get(p, name string) gitattributes.Attribute {
+	if a == nil {
+		return nil
+	}
+	parts := strings.Split(p, "/")
+	for i := len(a.stack) - 1; i >= 0; i-- {
+		m := a.stack[i]
+		if m.Pattern == nil || !m.Pattern.Match(parts) {
+			continue
+		}
+		for _, attr := range m.Attributes {
+			if attr.Name() == name {
+				return attr
+			}
+		}
+	}
+	return nil
+}
+
+// Value returns the value of name for p, or "" when it is set without a value, unset, or unspecified
+func (a *Attributes) Value(p, name string) string {
+	attr := a.Get(p, name)
+	if attr == nil || !attr.IsValueSet() {
+		return ""
+	}
+	return attr.Value()
+}
+
+// IsSet reports whether name is set for p, either on its own (name) or with a value other than false
+func (a *Attributes) IsSet(p, name string) bool {
+	attr := a.Get(p, name)
+	if attr == nil {
+		return false
+	}
+	if attr.IsValueSet() {
+		return attr.Value() != "false"
+	}
+	return attr.IsSet()
+}
+
+// Skips reports whether any of names, e.g. SkipAttribute or export-ignore, excludes p
+// from rule; set with a value other than true, an attribute only excludes p from the
+// comma-separated rules it lists
+func (a *Attributes) Skips(p, rule string, names ...string) bool {
+	for _, name := range names {
+		if !a.IsSet(p, name) {
+			continue
+		}
+		v := a.Value(p, name)
+		if v == "" || v == "true" {
+			return true
+		}
+		for _, r := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(r), rule) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func readBlob(blob *object.Blob) ([]byte, error) {
+	r, err := blob.Reader()
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return ioutil.ReadAll(r)
+}
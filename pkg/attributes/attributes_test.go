@@ -0,0 +1,52 @@
+package attributes
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestAttributes(t *testing.T) {
+	a, err := fromFiles(map[string][]byte{
+		".gitattributes": []byte(`
+*.png filter=lfs diff=lfs merge=lfs -text
+*.pb.go linguist-generated
+docs/** githooks-skip
+`),
+		"assets/.gitattributes": []byte(`
+small.png -filter
+`),
+		"vendor/.gitattributes": []byte(`
+*.go linguist-generated=false
+`),
+	})
+	assert.NoError(t, err)
+
+	assert.Equal(t, "lfs", a.Value("logo.png", "filter"))
+	assert.Equal(t, "lfs", a.Value("assets/big.png", "filter"))
+	assert.Equal(t, "", a.Value("assets/small.png", "filter"), "deeper .gitattributes wins")
+	assert.Equal(t, "", a.Value("main.go", "filter"))
+
+	assert.True(t, a.IsSet("api/api.pb.go", "linguist-generated"))
+	assert.False(t, a.IsSet("vendor/api.pb.go", "linguist-generated"))
+	assert.True(t, a.IsSet("docs/guide/intro.md", "githooks-skip"))
+	assert.False(t, a.IsSet("README.md", "githooks-skip"))
+}
+
+func TestSkips(t *testing.T) {
+	a, err := fromFiles(map[string][]byte{
+		".gitattributes": []byte(`
+docs/** githooks-skip
+fixtures/** githooks-skip=todo-ticket,formatting
+legacy/** githooks-skip=false
+site/** export-ignore
+`),
+	})
+	assert.NoError(t, err)
+
+	assert.True(t, a.Skips("docs/intro.md", "todo-ticket", SkipAttribute))
+	assert.True(t, a.Skips("fixtures/bad.json", "formatting", SkipAttribute))
+	assert.False(t, a.Skips("fixtures/bad.json", "config-syntax", SkipAttribute))
+	assert.False(t, a.Skips("legacy/old.go", "todo-ticket", SkipAttribute))
+	assert.False(t, a.Skips("site/index.html", "formatting", SkipAttribute))
+	assert.True(t, a.Skips("site/index.html", "formatting", SkipAttribute, "export-ignore"))
+}
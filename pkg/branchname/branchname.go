@@ -0,0 +1,141 @@
+package branchname
+
+import (
+	"fmt"
+	"golang.org/x/text/unicode/norm"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+/*
+ * Branch names can hold any unicode git allows in a ref, and may reach the hook in
+ * either normalization form: macOS file systems hand back decomposed names, so
+ * 'função/ABC-1' typed on one machine is read with its cedilla and tilde as separate
+ * combining marks on another. Before a branch name goes into a prefix it is
+ * normalized (NFC by default, which is what editors type), optionally transliterated
+ * to ASCII for tooling which chokes on unicode subjects, and optionally shortened
+ * without splitting a character in two.
+ */
+
+const (
+	NFC  = "nfc"
+	NFD  = "nfd"
+	None = "none"
+)
+
+// Options chooses how a branch name is prepared for a prefix
+type Options struct {
+	Form          string // nfc, nfd or none
+	Transliterate bool   // to ASCII
+	MaxLength     int    // in characters as a reader sees them; 0 for no limit
+}
+
+// ValidForm reports whether form is one of the normalization forms
+func ValidForm(form string) error {
+	switch form {
+	case NFC, NFD, None:
+		return nil
+	}
+	return fmt.Errorf("unknown normalization form '%s', expected one of: %s, %s, %s", form, NFC, NFD, None)
+}
+
+// Prepare normalizes, transliterates and shortens name as o asks
+func (o Options) Prepare(name string) string {
+	if o.Transliterate {
+		name = Transliterate(name)
+	} else {
+		name = Normalize(name, o.Form)
+	}
+	if o.MaxLength > 0 {
+		name = Truncate(name, o.MaxLength)
+	}
+	return name
+}
+
+// Normalize puts s in the given normalization form; an unknown form leaves it as is
+func Normalize(s, form string) string {
+	switch form {
+	case NFC:
+		return norm.NFC.String(s)
+	case NFD:
+		return norm.NFD.String(s)
+	}
+	return s
+}
+
+// ascii spells letters which do not decompose into an ASCII letter and accents
+var ascii = map[rune]string{
+	'ß': "ss", 'æ': "ae", 'Æ': "AE", 'œ': "oe", 'Œ': "OE", 'ø': "o", 'Ø': "O",
+	'ł': "l", 'Ł': "L", 'đ': "d", 'Đ': "D", 'ð': "d", 'Ð': "D", 'þ': "th", 'Þ': "TH",
+	'ı': "i", '–': "-", '—': "-", '‘': "'", '’': "'",
+}
+
+// Transliterate spells s in ASCII: accents are dropped, e.g. 'função' becomes
+// 'funcao', a few letters are spelled out, e.g. 'ß' as 'ss', and whatever has no
+// ASCII spelling is left out, along with a path segment it leaves empty
+func Transliterate(s string) string {
+	var b strings.Builder
+	for _, r := range norm.NFD.String(s) {
+		switch {
+		case r < utf8.RuneSelf:
+			b.WriteRune(r)
+		case unicode.Is(unicode.Mn, r):
+		case ascii[r] != "":
+			b.WriteString(ascii[r])
+		}
+	}
+	segments := make([]string, 0)
+	for _, seg := range strings.Split(b.String(), "/") {
+		if seg = strings.Trim(seg, "-_."); seg != "" {
+			segments = append(segments, seg)
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// Truncate keeps the first max characters of s as a reader sees them, keeping
+// accents, joined emoji and flags whole, and drops separators left at the end
+func Truncate(s string, max int) string {
+	end, n := 0, 0
+	for end < len(s) && n < max {
+		end += clusterLen(s[end:])
+		n++
+	}
+	if end == len(s) {
+		return s
+	}
+	return strings.TrimRight(s[:end], "-_./")
+}
+
+// clusterLen is the length in bytes of the character s starts with: a rune with the
+// marks, variation selectors and skin tones after it, runes joined to it by a zero
+// width joiner, or a pair of regional indicators
+func clusterLen(s string) int {
+	r, n := utf8.DecodeRuneInString(s)
+	if isRegionalIndicator(r) {
+		if next, m := utf8.DecodeRuneInString(s[n:]); isRegionalIndicator(next) {
+			n += m
+		}
+	}
+	for n < len(s) {
+		next, m := utf8.DecodeRuneInString(s[n:])
+		switch {
+		case unicode.In(next, unicode.Mn, unicode.Me, unicode.Mc), unicode.Is(unicode.Variation_Selector, next), next >= 0x1F3FB && next <= 0x1F3FF:
+			n += m
+		case next == '\u200d':
+			n += m
+			if n < len(s) {
+				_, j := utf8.DecodeRuneInString(s[n:])
+				n += j
+			}
+		default:
+			return n
+		}
+	}
+	return n
+}
+
+func isRegionalIndicator(r rune) bool {
+	return r >= 0x1F1E6 && r <= 0x1F1FF
+}
@@ -0,0 +1,51 @@
+package branchname
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	composed, decomposed := "função/ABC-1", "func\u0327a\u0303o/ABC-1"
+	assert.Equal(t, composed, Normalize(decomposed, NFC))
+	assert.Equal(t, decomposed, Normalize(composed, NFD))
+	assert.Equal(t, decomposed, Normalize(decomposed, None))
+	assert.NoError(t, ValidForm(NFD))
+	assert.Error(t, ValidForm("nfkc"))
+}
+
+func TestTransliterate(t *testing.T) {
+	tests := map[string]string{
+		"função/ABC-1":             "funcao/ABC-1",
+		"func\u0327a\u0303o/ABC-1": "funcao/ABC-1",
+		"straße/Ærø":               "strasse/AEro",
+		"功能/ABC-1":                 "ABC-1",
+		"feature/ABC-1":            "feature/ABC-1",
+	}
+	for in, want := range tests {
+		assert.Equal(t, want, Transliterate(in), in)
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		in   string
+		max  int
+		want string
+	}{
+		{"feature/ABC-1", 20, "feature/ABC-1"},
+		{"feature/ABC-1", 8, "feature"},
+		{"func\u0327a\u0303o", 4, "func\u0327"},
+		{"👩‍💻-work", 1, "👩‍💻"},
+		{"🇵🇹🇧🇷", 1, "🇵🇹"},
+		{"👍🏽ok", 1, "👍🏽"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, Truncate(tt.in, tt.max), tt.in)
+	}
+}
+
+func TestPrepare(t *testing.T) {
+	assert.Equal(t, "função", Options{Form: NFC, MaxLength: 6}.Prepare("func\u0327a\u0303o/ABC-1"))
+	assert.Equal(t, "funcao/ABC-1", Options{Form: NFC, Transliterate: true}.Prepare("função/ABC-1"))
+}
@@ -0,0 +1,107 @@
+package budget
+
+import (
+	"fmt"
+	"github.com/davidalpert/go-githooks/pkg/gitconfig"
+	"github.com/go-git/go-git/v5/config"
+	"strings"
+	"time"
+)
+
+/*
+ * A latency budget keeps slow, optional steps from dragging out a commit:
+ *
+ * [go-githooks "budget"]
+ *     prepare-commit-msg = 300ms
+ *     commit-msg = 2s
+ *     pre-commit = 5s
+ *
+ * Each optional step has a priority. As a hook uses up its budget it sheds them,
+ * lowest priority first: Low steps are skipped once a third of the budget is gone,
+ * Normal ones after two thirds, and High ones once it is exhausted. Steps a hook
+ * needs to do its job are not optional and always run. Without a budget nothing is
+ * skipped.
+ */
+
+// Priority ranks an optional step; the budget sheds Low steps first
+type Priority int
+
+const (
+	Low Priority = iota + 1
+	Normal
+	High
+)
+
+// Budget tracks one hook run against its latency budget
+type Budget struct {
+	Hook  string
+	Limit time.Duration // 0 for no budget
+
+	// Skipped lists the steps shed, in the order they were skipped
+	Skipped []string
+
+	start time.Time
+	now   func() time.Time
+}
+
+// New returns an unlimited Budget for hook which starts timing now
+func New(hook string) *Budget {
+	return &Budget{Hook: hook, start: time.Now(), now: time.Now}
+}
+
+// Configure reads hook's budget from the budget section of cfg
+func (b *Budget) Configure(cfg *config.Config) error {
+	s := gitconfig.GetString(cfg, "go-githooks", "budget", b.Hook, "")
+	if s == "" {
+		return nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return fmt.Errorf("could not parse the %s budget '%s' as a duration, e.g. 300ms", b.Hook, s)
+	}
+	b.Limit = d
+	return nil
+}
+
+// Elapsed is how long the hook has been running
+func (b *Budget) Elapsed() time.Duration {
+	return b.now().Sub(b.start)
+}
+
+// Remaining is what is left of the budget, at most fallback, or fallback when there
+// is no budget; it caps the timeouts of network calls so one slow server cannot use
+// more than that. It is never 0, which net/http takes as no timeout at all
+func (b *Budget) Remaining(fallback time.Duration) time.Duration {
+	if b == nil || b.Limit == 0 {
+		return fallback
+	}
+	left := b.Limit - b.Elapsed()
+	if left < time.Millisecond {
+		return time.Millisecond
+	}
+	if fallback > 0 && fallback < left {
+		return fallback
+	}
+	return left
+}
+
+// Allows reports whether an optional step of priority p may still run; when it may
+// not, the step is recorded as skipped
+func (b *Budget) Allows(name string, p Priority) bool {
+	if b == nil || b.Limit == 0 {
+		return true
+	}
+	if b.Elapsed() < b.Limit*time.Duration(p)/time.Duration(High) {
+		return true
+	}
+	b.Skipped = append(b.Skipped, name)
+	return false
+}
+
+// Note explains what was skipped to stay within the budget, or "" when nothing was
+func (b *Budget) Note() string {
+	if b == nil || len(b.Skipped) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("skipped %s to stay within the %s budget of %s (go-githooks.budget.%s)", strings.Join(b.Skipped, ", "), b.Hook, b.Limit, b.Hook)
+}
@@ -0,0 +1,49 @@
+package budget
+
+import (
+	"github.com/go-git/go-git/v5/config"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestBudget(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.Raw.Section("go-githooks").Subsection("budget").SetOption("pre-commit", "3s")
+	b := New("pre-commit")
+	assert.NoError(t, b.Configure(cfg))
+	assert.Equal(t, 3*time.Second, b.Limit)
+
+	now := b.start
+	b.now = func() time.Time { return now }
+	assert.True(t, b.Allows("todo-ticket", Low))
+	assert.Equal(t, 3*time.Second, b.Remaining(0))
+	assert.Equal(t, time.Second, b.Remaining(time.Second))
+
+	now = now.Add(1500 * time.Millisecond)
+	assert.False(t, b.Allows("todo-ticket", Low), "a third of the budget is gone")
+	assert.True(t, b.Allows("formatting", Normal))
+	assert.True(t, b.Allows("check", High))
+
+	now = now.Add(2 * time.Second)
+	assert.False(t, b.Allows("check", High), "the budget is exhausted")
+	assert.Equal(t, time.Millisecond, b.Remaining(time.Second))
+	assert.Equal(t, "skipped todo-ticket, check to stay within the pre-commit budget of 3s (go-githooks.budget.pre-commit)", b.Note())
+}
+
+func TestNoBudget(t *testing.T) {
+	b := New("prepare-commit-msg")
+	assert.NoError(t, b.Configure(config.NewConfig()))
+	b.now = func() time.Time { return b.start.Add(time.Hour) }
+	assert.True(t, b.Allows("built-on", Low))
+	assert.Equal(t, 5*time.Second, b.Remaining(5*time.Second))
+	assert.Equal(t, "", b.Note())
+
+	var unset *Budget
+	assert.True(t, unset.Allows("built-on", Low))
+	assert.Equal(t, "", unset.Note())
+
+	cfg := config.NewConfig()
+	cfg.Raw.Section("go-githooks").Subsection("budget").SetOption("prepare-commit-msg", "fast")
+	assert.Error(t, b.Configure(cfg))
+}
@@ -0,0 +1,185 @@
+package bypass
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/davidalpert/go-githooks/pkg/exitcode"
+	"github.com/davidalpert/go-githooks/pkg/fileio"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/storage/filesystem"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+/*
+ * A bypass lets someone get a fix out in an emergency without --no-verify: for a
+ * limited time the hooks report what they would have blocked and let it through,
+ * and every commit made meanwhile carries a Hook-Bypass trailer with the reason,
+ * so the history says why its checks were skipped.
+ *
+ * The token is signed with a key kept next to it, so it cannot be edited by hand
+ * (e.g. to extend it) or copied from another clone.
+ */
+
+// Trailer is added to every commit made while a bypass is active
+const Trailer = "Hook-Bypass"
+
+const (
+	tokenFile = "bypass.json"
+	keyFile   = "bypass.key"
+)
+
+// Out is where hooks report what they let through; replaced in tests
+var Out io.Writer = os.Stderr
+
+// Token is one recorded bypass
+type Token struct {
+	Reason    string    `json:"reason"`
+	User      string    `json:"user"`
+	Created   time.Time `json:"created"`
+	Expires   time.Time `json:"expires"`
+	Signature string    `json:"signature"`
+}
+
+// Dir is where repo keeps its bypass token, .git/go-githooks, or "" for repos
+// which are not stored on disk
+func Dir(repo *git.Repository) string {
+	if repo == nil {
+		return ""
+	}
+	if s, ok := repo.Storer.(*filesystem.Storage); ok {
+		return filepath.Join(s.Filesystem().Root(), "go-githooks")
+	}
+	return ""
+}
+
+// Grant records a bypass for reason lasting ttl from now, replacing any other
+func Grant(dir, reason, user string, ttl time.Duration, now time.Time) (Token, error) {
+	if strings.TrimSpace(reason) == "" {
+		return Token{}, fmt.Errorf("a bypass needs a reason")
+	}
+	if ttl <= 0 {
+		return Token{}, fmt.Errorf("a bypass needs a positive ttl, got %s", ttl)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return Token{}, fmt.Errorf("could not create '%s': %v", dir, err)
+	}
+	key, err := loadKey(dir, true)
+	if err != nil {
+		return Token{}, err
+	}
+
+	t := Token{
+		Reason:  strings.Join(strings.Fields(reason), " "),
+		User:    user,
+		Created: now.UTC().Truncate(time.Second),
+		Expires: now.Add(ttl).UTC().Truncate(time.Second),
+	}
+	t.Signature = t.sign(key)
+
+	data, err := json.MarshalIndent(t, "", "  ")
+	if err != nil {
+		return Token{}, err
+	}
+	p := filepath.Join(dir, tokenFile)
+	if err := fileio.WriteFile(p, append(data, '\n'), 0644); err != nil {
+		return Token{}, fmt.Errorf("could not write '%s': %v", p, err)
+	}
+	return t, nil
+}
+
+// Active returns the bypass in dir if it is signed and not yet expired, or nil;
+// an expired token is removed
+func Active(dir string, now time.Time) (*Token, error) {
+	if dir == "" {
+		return nil, nil
+	}
+	p := filepath.Join(dir, tokenFile)
+	data, err := fileio.ReadFile(p)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("could not read '%s': %v", p, err)
+	}
+
+	var t Token
+	if err := json.Unmarshal(data, &t); err != nil {
+		return nil, fmt.Errorf("could not parse '%s': %v", p, err)
+	}
+	key, err := loadKey(dir, false)
+	if err != nil {
+		return nil, err
+	}
+	if key == nil || !hmac.Equal([]byte(t.Signature), []byte(t.sign(key))) {
+		return nil, fmt.Errorf("the bypass in '%s' is not signed by this clone; run 'go-githooks bypass' again", p)
+	}
+	if !now.Before(t.Expires) {
+		_ = os.Remove(p)
+		return nil, nil
+	}
+	return &t, nil
+}
+
+// Revoke ends the bypass in dir early
+func Revoke(dir string) error {
+	err := os.Remove(filepath.Join(dir, tokenFile))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// Allow reports err as the failure hook would have ended with and returns nil while
+// a bypass is active in dir; otherwise err is returned as is
+func Allow(dir, hook string, err error) error {
+	if err == nil {
+		return nil
+	}
+	t, activeErr := Active(dir, time.Now())
+	if activeErr != nil {
+		fmt.Fprintf(Out, "go-githooks %s: %v\n", hook, activeErr)
+		return err
+	}
+	if t == nil {
+		return err
+	}
+	fmt.Fprintf(Out, "go-githooks %s: bypassed until %s (%s): would have failed (%s): %v\n",
+		hook, t.Expires.Local().Format("15:04"), t.Reason, exitcode.Of(err).Name(), err)
+	return nil
+}
+
+func (t Token) sign(key []byte) string {
+	mac := hmac.New(sha256.New, key)
+	fmt.Fprintf(mac, "%s\n%s\n%s\n%s", t.Reason, t.User, t.Created.Format(time.RFC3339), t.Expires.Format(time.RFC3339))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// loadKey reads the signing key of this clone, creating it when asked to
+func loadKey(dir string, create bool) ([]byte, error) {
+	p := filepath.Join(dir, keyFile)
+	data, err := fileio.ReadFile(p)
+	if err == nil {
+		return hex.DecodeString(strings.TrimSpace(string(data)))
+	} else if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("could not read '%s': %v", p, err)
+	}
+	if !create {
+		return nil, nil
+	}
+
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("could not create a signing key: %v", err)
+	}
+	if err := fileio.WriteFile(p, []byte(hex.EncodeToString(key)+"\n"), 0600); err != nil {
+		return nil, fmt.Errorf("could not write '%s': %v", p, err)
+	}
+	return key, nil
+}
@@ -0,0 +1,69 @@
+package bypass
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestGrant(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "go-githooks")
+	now := time.Now()
+
+	_, err := Grant(dir, " ", "mal@serenity.com", time.Hour, now)
+	assert.Error(t, err, "a reason is required")
+
+	granted, err := Grant(dir, "prod   down", "mal@serenity.com", time.Hour, now)
+	assert.NoError(t, err)
+	assert.Equal(t, "prod down", granted.Reason)
+
+	active, err := Active(dir, now.Add(time.Minute))
+	assert.NoError(t, err)
+	if assert.NotNil(t, active) {
+		assert.Equal(t, granted, *active)
+	}
+
+	active, err = Active(dir, now.Add(2*time.Hour))
+	assert.NoError(t, err)
+	assert.Nil(t, active, "expired")
+	assert.NoFileExists(t, filepath.Join(dir, tokenFile), "expired tokens are removed")
+}
+
+func TestActiveRejectsEditedTokens(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	_, err := Grant(dir, "prod down", "mal@serenity.com", time.Hour, now)
+	assert.NoError(t, err)
+
+	p := filepath.Join(dir, tokenFile)
+	data, _ := ioutil.ReadFile(p)
+	data = bytes.Replace(data, []byte(now.Add(time.Hour).UTC().Format("2006")), []byte(fmt.Sprint(now.Year()+1)), 1)
+	_ = ioutil.WriteFile(p, data, 0644)
+
+	active, err := Active(dir, now)
+	assert.Error(t, err)
+	assert.Nil(t, active)
+}
+
+func TestAllow(t *testing.T) {
+	var out bytes.Buffer
+	Out = &out
+	defer func() { Out = os.Stderr }()
+	dir := t.TempDir()
+	blocked := fmt.Errorf("blocked")
+
+	assert.Equal(t, blocked, Allow(dir, "pre-commit", blocked), "no bypass")
+
+	_, err := Grant(dir, "prod down", "mal@serenity.com", time.Hour, time.Now())
+	assert.NoError(t, err)
+	assert.NoError(t, Allow(dir, "pre-commit", blocked))
+	assert.Contains(t, out.String(), "(prod down): would have failed")
+
+	assert.NoError(t, Revoke(dir))
+	assert.Equal(t, blocked, Allow(dir, "pre-commit", blocked), "revoked")
+}
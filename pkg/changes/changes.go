@@ -0,0 +1,88 @@
+package changes
+
+import (
+	"fmt"
+	"github.com/davidalpert/go-githooks/pkg/message"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"regexp"
+	"strings"
+)
+
+// Ticket is one ticket a range of commits worked on
+type Ticket struct {
+	Key      string
+	Subjects []string // of the commits mentioning it, oldest first
+}
+
+// Summary is what a range of commits did: the tickets they worked on, in the order
+// they were first mentioned, and the subjects of the commits which name no ticket
+type Summary struct {
+	Tickets []Ticket
+	Other   []string
+}
+
+// Summarize groups commits, given newest first as push.Range returns them, by the
+// tickets ticketRe finds in their messages; prefixTemplate is the branch prefix
+// left off the subjects, e.g. [%s]. Merge commits are skipped
+func Summarize(commits []*object.Commit, ticketRe *regexp.Regexp, prefixTemplate string) Summary {
+	s := Summary{Tickets: make([]Ticket, 0), Other: make([]string, 0)}
+	index := map[string]int{}
+	for i := len(commits) - 1; i >= 0; i-- {
+		c := commits[i]
+		if c.NumParents() > 1 {
+			continue
+		}
+		msg := []byte(c.Message)
+		subject := message.Subject([]byte(message.Effective(msg, prefixTemplate)))
+		tickets := message.Tickets(msg, ticketRe)
+		if len(tickets) == 0 {
+			s.Other = append(s.Other, subject)
+			continue
+		}
+		for _, key := range tickets {
+			n, ok := index[key]
+			if !ok {
+				n = len(s.Tickets)
+				index[key] = n
+				s.Tickets = append(s.Tickets, Ticket{Key: key})
+			}
+			s.Tickets[n].Subjects = append(s.Tickets[n].Subjects, subject)
+		}
+	}
+	return s
+}
+
+// Keys are the tickets' keys
+func (s Summary) Keys() []string {
+	keys := make([]string, 0, len(s.Tickets))
+	for _, t := range s.Tickets {
+		keys = append(keys, t.Key)
+	}
+	return keys
+}
+
+// Lines lists each ticket with the subject of its first commit, then the other
+// commits, as '- ' items under a heading, ready to go in a message body
+func (s Summary) Lines() []string {
+	lines := make([]string, 0)
+	if len(s.Tickets) > 0 {
+		lines = append(lines, "Tickets:")
+		for _, t := range s.Tickets {
+			line := fmt.Sprintf("- %s %s", t.Key, strings.TrimSpace(strings.Replace(t.Subjects[0], t.Key, "", 1)))
+			if len(t.Subjects) > 1 {
+				line += fmt.Sprintf(" (%d commits)", len(t.Subjects))
+			}
+			lines = append(lines, strings.TrimSpace(line))
+		}
+	}
+	if len(s.Other) > 0 {
+		if len(lines) > 0 {
+			lines = append(lines, "")
+		}
+		lines = append(lines, "Other changes:")
+		for _, subject := range s.Other {
+			lines = append(lines, "- "+subject)
+		}
+	}
+	return lines
+}
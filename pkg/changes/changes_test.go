@@ -0,0 +1,32 @@
+package changes
+
+import (
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/stretchr/testify/assert"
+	"regexp"
+	"testing"
+)
+
+func TestSummarize(t *testing.T) {
+	commits := []*object.Commit{
+		{Message: "Merge branch 'ABC-3'\n", ParentHashes: []plumbing.Hash{{1}, {2}}},
+		{Message: "bump the go version\n"},
+		{Message: "[ABC-1] handle expired sessions\n"},
+		{Message: "[ABC-2] add logout\n"},
+		{Message: "[ABC-1] fix login\n\nCo-authored-by: Zoe Washburne <zoe@serenity.com>\n"},
+	}
+	s := Summarize(commits, regexp.MustCompile(`[A-Z]+-[0-9]+`), "[%s]")
+
+	assert.Equal(t, []string{"ABC-1", "ABC-2"}, s.Keys())
+	assert.Equal(t, []string{
+		"Tickets:",
+		"- ABC-1 fix login (2 commits)",
+		"- ABC-2 add logout",
+		"",
+		"Other changes:",
+		"- bump the go version",
+	}, s.Lines())
+
+	assert.Empty(t, Summarize(nil, regexp.MustCompile(`[A-Z]+-[0-9]+`), "").Lines())
+}
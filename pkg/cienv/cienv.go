@@ -0,0 +1,81 @@
+package cienv
+
+import (
+	"os"
+	"strings"
+	"time"
+)
+
+/*
+ * Automation which makes commits, such as a release bot, cannot answer prompts and
+ * has no mob to credit, so when hooks detect a CI environment they:
+ *
+ *   - never prompt, as with GIT_HOOKS_NONINTERACTIVE
+ *   - skip resolving coauthors from git-mob and the pairing file
+ *   - refuse unsigned policy files whenever policy trustedKeys are configured, as
+ *     with GIT_HOOKS_STRICT_POLICY
+ *   - wait at most NetworkTimeout on the network
+ *
+ * GIT_HOOKS_CI=0 turns this off on a CI runner, and GIT_HOOKS_CI=1 turns it on elsewhere.
+ */
+
+// Env overrides the detection either way
+const Env = "GIT_HOOKS_CI"
+
+// NetworkTimeout is the longest hooks wait on a request in CI
+const NetworkTimeout = 2 * time.Second
+
+// providers are the CI services recognized by an environment variable they set;
+// value is what it must be set to, or "" for anything
+var providers = []struct {
+	name, env, value string
+}{
+	{"GitHub Actions", "GITHUB_ACTIONS", "true"},
+	{"GitLab CI", "GITLAB_CI", ""},
+	{"Azure Pipelines", "TF_BUILD", ""},
+	{"Bitbucket Pipelines", "BITBUCKET_BUILD_NUMBER", ""},
+	{"Buildkite", "BUILDKITE", "true"},
+	{"CircleCI", "CIRCLECI", "true"},
+	{"Jenkins", "JENKINS_URL", ""},
+	{"TeamCity", "TEAMCITY_VERSION", ""},
+	{"Travis CI", "TRAVIS", "true"},
+	{"Drone", "DRONE", "true"},
+	{"AppVeyor", "APPVEYOR", ""},
+	{"AWS CodeBuild", "CODEBUILD_BUILD_ID", ""},
+}
+
+// Detect returns the name of the CI environment hooks run in, or ""
+func Detect() string {
+	return detect(os.Getenv)
+}
+
+// Detected reports whether hooks run in CI
+func Detected() bool {
+	return Detect() != ""
+}
+
+// Timeout shortens d to NetworkTimeout in CI
+func Timeout(d time.Duration) time.Duration {
+	if Detected() && (d <= 0 || d > NetworkTimeout) {
+		return NetworkTimeout
+	}
+	return d
+}
+
+func detect(getenv func(string) string) string {
+	switch strings.ToLower(getenv(Env)) {
+	case "0", "false", "no":
+		return ""
+	case "1", "true", "yes":
+		return Env
+	}
+	for _, p := range providers {
+		if v := getenv(p.env); v != "" && (p.value == "" || strings.ToLower(v) == p.value) {
+			return p.name
+		}
+	}
+	if v := strings.ToLower(getenv("CI")); v != "" && v != "0" && v != "false" {
+		return "CI"
+	}
+	return ""
+}
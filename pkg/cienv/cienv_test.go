@@ -0,0 +1,28 @@
+package cienv
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+		want string
+	}{
+		{name: "a workstation", env: map[string]string{}, want: ""},
+		{name: "github actions", env: map[string]string{"GITHUB_ACTIONS": "true", "CI": "true"}, want: "GitHub Actions"},
+		{name: "gitlab", env: map[string]string{"GITLAB_CI": "true"}, want: "GitLab CI"},
+		{name: "jenkins", env: map[string]string{"JENKINS_URL": "https://ci.serenity.com/"}, want: "Jenkins"},
+		{name: "any other ci", env: map[string]string{"CI": "1"}, want: "CI"},
+		{name: "CI=false", env: map[string]string{"CI": "false"}, want: ""},
+		{name: "turned off on a runner", env: map[string]string{"GITHUB_ACTIONS": "true", "GIT_HOOKS_CI": "0"}, want: ""},
+		{name: "turned on elsewhere", env: map[string]string{"GIT_HOOKS_CI": "1"}, want: "GIT_HOOKS_CI"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, detect(func(k string) string { return tt.env[k] }))
+		})
+	}
+}
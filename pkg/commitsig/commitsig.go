@@ -0,0 +1,156 @@
+package commitsig
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/davidalpert/go-githooks/pkg/fileio"
+	"github.com/davidalpert/go-githooks/pkg/policysig"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"io/ioutil"
+	"path"
+	"strings"
+)
+
+/*
+ * Branch protection which requires signed commits rejects a push only once it reaches
+ * the server; checking the pushed commits' signatures locally, against the same keys
+ * the team trusts, lets the pre-push hook say which commits to re-sign first.
+ *
+ * OpenPGP signatures are checked against an armored keyring, e.g. the output of
+ * 'gpg --export --armor <ids>'; SSH signatures against an allowed signers file in
+ * the format of git's gpg.ssh.allowedSignersFile: 'principals keytype key [comment]',
+ * where principals are comma-separated email patterns such as *@serenity.com.
+ */
+
+// Namespace is what git signs commits for with ssh-keygen -Y sign
+const Namespace = "git"
+
+// Signer is one line of an allowed signers file
+type Signer struct {
+	Principals []string // email patterns, e.g. *@serenity.com
+	Key        policysig.Key
+}
+
+// Verifier checks commit signatures against the trusted keys
+type Verifier struct {
+	Keyring string // armored OpenPGP public keys
+	Signers []Signer
+}
+
+// Load reads the armored keyring and the allowed signers file; either may be ""
+func Load(keyringPath, allowedSignersPath string) (*Verifier, error) {
+	v := &Verifier{}
+	if keyringPath != "" {
+		data, err := fileio.ReadFile(keyringPath)
+		if err != nil {
+			return nil, fmt.Errorf("could not read the signing keyring: %v", err)
+		}
+		v.Keyring = string(data)
+	}
+	if allowedSignersPath != "" {
+		data, err := fileio.ReadFile(allowedSignersPath)
+		if err != nil {
+			return nil, fmt.Errorf("could not read the allowed signers: %v", err)
+		}
+		if v.Signers, err = ParseAllowedSigners(string(data)); err != nil {
+			return nil, err
+		}
+	}
+	return v, nil
+}
+
+// ParseAllowedSigners reads the lines of an allowed signers file; options such as
+// namespaces="git" between the principals and the key are ignored
+func ParseAllowedSigners(data string) ([]Signer, error) {
+	signers := make([]Signer, 0)
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		for i := 1; i < len(fields)-1; i++ {
+			if strings.HasPrefix(fields[i], "ssh-") || strings.HasPrefix(fields[i], "ecdsa-") || strings.HasPrefix(fields[i], "sk-") {
+				k, err := policysig.ParseKey(fields[i] + " " + fields[i+1])
+				if err != nil {
+					return nil, fmt.Errorf("allowed signers line %d: %v", n, err)
+				}
+				signers = append(signers, Signer{Principals: strings.Split(fields[0], ","), Key: k})
+				break
+			}
+		}
+	}
+	return signers, scanner.Err()
+}
+
+// Verify returns nil when c carries a signature made by one of the trusted keys, which
+// must also be allowed to sign for the committer's email: an OpenPGP key through one of
+// its identities, an SSH key through its principals
+func (v *Verifier) Verify(c *object.Commit) error {
+	sig := strings.TrimSpace(c.PGPSignature)
+	switch {
+	case sig == "":
+		return fmt.Errorf("is not signed")
+	case strings.HasPrefix(sig, "-----BEGIN SSH SIGNATURE-----"):
+		return v.verifySSH(c, sig)
+	}
+
+	if v.Keyring == "" {
+		return fmt.Errorf("is signed with OpenPGP, but no signing keyring is configured to verify it")
+	}
+	entity, err := c.Verify(v.Keyring)
+	if err != nil {
+		return fmt.Errorf("has a signature no trusted key verifies: %v", err)
+	}
+	for _, id := range entity.Identities {
+		if id.UserId != nil && strings.EqualFold(id.UserId.Email, c.Committer.Email) {
+			return nil
+		}
+	}
+	return fmt.Errorf("is signed by a key which is not allowed to sign for %s", c.Committer.Email)
+}
+
+func (v *Verifier) verifySSH(c *object.Commit, sig string) error {
+	if len(v.Signers) == 0 {
+		return fmt.Errorf("is signed with ssh, but no allowed signers are configured to verify it")
+	}
+	encoded := &plumbing.MemoryObject{}
+	if err := c.EncodeWithoutSignature(encoded); err != nil {
+		return fmt.Errorf("could not read the signed commit: %v", err)
+	}
+	r, err := encoded.Reader()
+	if err != nil {
+		return fmt.Errorf("could not read the signed commit: %v", err)
+	}
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("could not read the signed commit: %v", err)
+	}
+
+	verified := false
+	for _, s := range v.Signers {
+		if s.Key.VerifySSH(data, []byte(sig), Namespace) != nil {
+			continue
+		}
+		verified = true
+		if s.allows(c.Committer.Email) {
+			return nil
+		}
+	}
+	if verified {
+		return fmt.Errorf("is signed by a key which is not allowed to sign for %s", c.Committer.Email)
+	}
+	return fmt.Errorf("has a signature no trusted key verifies")
+}
+
+// allows reports whether one of the signer's principals matches email
+func (s Signer) allows(email string) bool {
+	for _, p := range s.Principals {
+		if ok, _ := path.Match(strings.ToLower(p), strings.ToLower(email)); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,119 @@
+package commitsig
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha512"
+	"encoding/pem"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/armor"
+	"golang.org/x/crypto/ssh"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newCommit() *object.Commit {
+	sig := object.Signature{Name: "Mal Reynolds", Email: "mal@serenity.com", When: time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)}
+	return &object.Commit{Author: sig, Committer: sig, Message: "ABC-1 fix login\n", TreeHash: plumbing.NewHash("4b825dc642cb6eb9a060e54bf8d69288fbee4904")}
+}
+
+func payload(t *testing.T, c *object.Commit) []byte {
+	encoded := &plumbing.MemoryObject{}
+	assert.NoError(t, c.EncodeWithoutSignature(encoded))
+	r, _ := encoded.Reader()
+	data, _ := ioutil.ReadAll(r)
+	return data
+}
+
+// sshSign signs data the way ssh-keygen -Y sign -n git does
+func sshSign(t *testing.T, priv ed25519.PrivateKey, data []byte) string {
+	signer, err := ssh.NewSignerFromKey(priv)
+	assert.NoError(t, err)
+	sum := sha512.Sum512(data)
+	magic := [6]byte{'S', 'S', 'H', 'S', 'I', 'G'}
+	signed := ssh.Marshal(struct {
+		Magic         [6]byte
+		Namespace     string
+		Reserved      []byte
+		HashAlgorithm string
+		Hash          []byte
+	}{magic, Namespace, nil, "sha512", sum[:]})
+	s, err := signer.Sign(nil, signed)
+	assert.NoError(t, err)
+	blob := ssh.Marshal(struct {
+		Magic         [6]byte
+		Version       uint32
+		PublicKey     []byte
+		Namespace     string
+		Reserved      []byte
+		HashAlgorithm string
+		Signature     []byte
+	}{magic, 1, signer.PublicKey().Marshal(), Namespace, nil, "sha512", ssh.Marshal(s)})
+	return string(pem.EncodeToMemory(&pem.Block{Type: "SSH SIGNATURE", Bytes: blob}))
+}
+
+func TestVerifySSH(t *testing.T) {
+	pub, priv, _ := ed25519.GenerateKey(nil)
+	sshPub, _ := ssh.NewPublicKey(pub)
+	key := strings.TrimSpace(string(ssh.MarshalAuthorizedKey(sshPub)))
+
+	c := newCommit()
+	c.PGPSignature = sshSign(t, priv, payload(t, c))
+
+	signers, err := ParseAllowedSigners("# the crew\nmal@serenity.com,zoe@serenity.com namespaces=\"git\" " + key + " mal's laptop\n")
+	assert.NoError(t, err)
+	assert.NoError(t, (&Verifier{Signers: signers}).Verify(c))
+
+	signers, _ = ParseAllowedSigners("*@alliance.gov " + key + "\n")
+	assert.EqualError(t, (&Verifier{Signers: signers}).Verify(c), "is signed by a key which is not allowed to sign for mal@serenity.com")
+
+	other, _, _ := ed25519.GenerateKey(nil)
+	otherPub, _ := ssh.NewPublicKey(other)
+	signers, _ = ParseAllowedSigners("*@serenity.com " + string(ssh.MarshalAuthorizedKey(otherPub)))
+	assert.EqualError(t, (&Verifier{Signers: signers}).Verify(c), "has a signature no trusted key verifies")
+
+	c.Message = "ABC-1 fix login, tampered\n"
+	signers, _ = ParseAllowedSigners("*@serenity.com " + key + "\n")
+	assert.Error(t, (&Verifier{Signers: signers}).Verify(c))
+	assert.EqualError(t, (&Verifier{}).Verify(c), "is signed with ssh, but no allowed signers are configured to verify it")
+}
+
+func TestVerifyOpenPGP(t *testing.T) {
+	e, err := openpgp.NewEntity("Mal Reynolds", "", "mal@serenity.com", nil)
+	assert.NoError(t, err)
+	var keyring bytes.Buffer
+	w, _ := armor.Encode(&keyring, openpgp.PublicKeyType, nil)
+	assert.NoError(t, e.Serialize(w))
+	w.Close()
+
+	c := newCommit()
+	var sig bytes.Buffer
+	assert.NoError(t, openpgp.ArmoredDetachSign(&sig, e, bytes.NewReader(payload(t, c)), nil))
+	c.PGPSignature = sig.String()
+
+	v := &Verifier{Keyring: keyring.String()}
+	assert.NoError(t, v.Verify(c))
+
+	impostor := newCommit()
+	impostor.Committer.Email = "zoe@serenity.com"
+	sig.Reset()
+	assert.NoError(t, openpgp.ArmoredDetachSign(&sig, e, bytes.NewReader(payload(t, impostor)), nil))
+	impostor.PGPSignature = sig.String()
+	assert.EqualError(t, v.Verify(impostor), "is signed by a key which is not allowed to sign for zoe@serenity.com")
+
+	unsigned := newCommit()
+	assert.EqualError(t, v.Verify(unsigned), "is not signed")
+
+	stranger, _ := openpgp.NewEntity("Jubal Early", "", "jubal@bounty.com", nil)
+	var strangerRing bytes.Buffer
+	w, _ = armor.Encode(&strangerRing, openpgp.PublicKeyType, nil)
+	_ = stranger.Serialize(w)
+	w.Close()
+	assert.Contains(t, (&Verifier{Keyring: strangerRing.String()}).Verify(c).Error(), "has a signature no trusted key verifies")
+	assert.EqualError(t, (&Verifier{}).Verify(c), "is signed with OpenPGP, but no signing keyring is configured to verify it")
+}
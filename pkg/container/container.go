@@ -0,0 +1,96 @@
+package container
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// Engines are the container engines a runtime may name
+var Engines = []string{"docker", "podman"}
+
+// ErrUnavailable is returned when a runtime's engine is not installed or its image
+// can neither be found locally nor pulled, e.g. when offline
+var ErrUnavailable = errors.New("container runtime unavailable")
+
+// Runtime is where a command runs: on the host, or inside a container image with
+// the files it checks mounted read-only
+type Runtime struct {
+	Engine string // "" for the host
+	Image  string
+}
+
+// Parse reads a runtime like 'docker:golangci/golangci-lint:v1.55'; "" and 'host'
+// mean the host
+func Parse(s string) (Runtime, error) {
+	s = strings.TrimSpace(s)
+	if s == "" || s == "host" {
+		return Runtime{}, nil
+	}
+
+	parts := strings.SplitN(s, ":", 2)
+	engine := strings.ToLower(strings.TrimSpace(parts[0]))
+	known := false
+	for _, e := range Engines {
+		known = known || e == engine
+	}
+	if !known || len(parts) != 2 || strings.TrimSpace(parts[1]) == "" {
+		return Runtime{}, fmt.Errorf("unknown runtime '%s', expected 'host' or <%s>:<image>", s, strings.Join(Engines, "|"))
+	}
+	return Runtime{Engine: engine, Image: strings.TrimSpace(parts[1])}, nil
+}
+
+func (r Runtime) IsHost() bool {
+	return r.Engine == ""
+}
+
+func (r Runtime) String() string {
+	if r.IsHost() {
+		return "host"
+	}
+	return r.Engine + ":" + r.Image
+}
+
+// Ensure checks that the engine is installed and the image is present, pulling it
+// only when it is not so that a warm cache works offline
+func (r Runtime) Ensure() error {
+	if r.IsHost() {
+		return nil
+	}
+	if _, err := exec.LookPath(r.Engine); err != nil {
+		return fmt.Errorf("%w: %s is not installed", ErrUnavailable, r.Engine)
+	}
+	if exec.Command(r.Engine, "image", "inspect", r.Image).Run() == nil {
+		return nil
+	}
+	return r.Pull()
+}
+
+// Pull fetches the latest image for the runtime's tag
+func (r Runtime) Pull() error {
+	if r.IsHost() {
+		return nil
+	}
+	var out bytes.Buffer
+	cmd := exec.Command(r.Engine, "pull", "--quiet", r.Image)
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%w: could not pull %s: %v %s", ErrUnavailable, r.Image, err, strings.TrimSpace(out.String()))
+	}
+	return nil
+}
+
+// Command runs args in dir on the host or, in a container, with dir mounted
+// read-only as the working directory
+func (r Runtime) Command(dir string, args []string) *exec.Cmd {
+	if r.IsHost() {
+		cmd := exec.Command(args[0], args[1:]...)
+		cmd.Dir = dir
+		return cmd
+	}
+	run := []string{"run", "--rm", "--volume", dir + ":/src:ro", "--workdir", "/src", r.Image}
+	return exec.Command(r.Engine, append(run, args...)...)
+}
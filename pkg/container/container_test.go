@@ -0,0 +1,33 @@
+package container
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	r, err := Parse("docker:golangci/golangci-lint:v1.55")
+	assert.NoError(t, err)
+	assert.Equal(t, Runtime{Engine: "docker", Image: "golangci/golangci-lint:v1.55"}, r)
+	assert.Equal(t, "docker:golangci/golangci-lint:v1.55", r.String())
+
+	for _, host := range []string{"", "host"} {
+		r, err = Parse(host)
+		assert.NoError(t, err)
+		assert.True(t, r.IsHost())
+	}
+
+	_, err = Parse("lxc:ubuntu")
+	assert.Error(t, err)
+	_, err = Parse("docker:")
+	assert.Error(t, err)
+}
+
+func TestCommand(t *testing.T) {
+	r := Runtime{Engine: "podman", Image: "golang:1.16"}
+	assert.Equal(t, []string{"podman", "run", "--rm", "--volume", "/tmp/tree:/src:ro", "--workdir", "/src", "golang:1.16", "go", "vet", "./..."}, r.Command("/tmp/tree", []string{"go", "vet", "./..."}).Args)
+
+	cmd := Runtime{}.Command("/tmp/tree", []string{"go", "vet", "./..."})
+	assert.Equal(t, []string{"go", "vet", "./..."}, cmd.Args)
+	assert.Equal(t, "/tmp/tree", cmd.Dir)
+}
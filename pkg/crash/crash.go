@@ -0,0 +1,147 @@
+package crash
+
+import (
+	"fmt"
+	"github.com/davidalpert/go-githooks/pkg/exitcode"
+	"github.com/davidalpert/go-githooks/pkg/guilog"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/storage/filesystem"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Limit is how many reports are kept; older ones are removed as new ones are written
+const Limit = 10
+
+// IssuesURL is where crash reports are asked to be sent
+const IssuesURL = "https://github.com/davidalpert/go-githooks/issues"
+
+// sensitive matches the names of environment variables whose values are not written
+var sensitive = regexp.MustCompile(`(?i)(TOKEN|SECRET|PASSW|PASSPHRASE|KEY|AUTH|CREDENTIAL|COOKIE|SESSION|PRIVATE)`)
+
+// userinfo matches the user:password part of urls in any other value
+var userinfo = regexp.MustCompile(`://[^/@\s]+@`)
+
+// Dir is where the reports of repo are written, .git/go-githooks/crash, or a
+// directory under the system's temp directory for repos which are not stored on disk
+func Dir(repo *git.Repository) string {
+	if repo != nil {
+		if s, ok := repo.Storer.(*filesystem.Storage); ok {
+			return filepath.Join(s.Filesystem().Root(), "go-githooks", "crash")
+		}
+	}
+	return filepath.Join(os.TempDir(), "go-githooks-crash")
+}
+
+// Recover turns a panic in hook into an error, with a report of it written to dir;
+// hooks defer it in main after opening the repo. Since the message file is only ever
+// replaced whole, a crash leaves it as it was
+func Recover(dir, hook, version string) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	// a bad config value panics from where no error can be returned; it is not a bug
+	if err, ok := r.(error); ok && exitcode.Of(err) == exitcode.Config {
+		guilog.Stop()
+		fmt.Fprintf(os.Stderr, "%s: %v\n", hook, err)
+		os.Exit(int(exitcode.Config))
+	}
+
+	path, err := Write(dir, hook, version, r, debug.Stack(), time.Now())
+
+	guilog.Stop()
+	fmt.Fprintf(os.Stderr, "%s crashed, which is a bug in go-githooks: %v\n", hook, r)
+	if strings.HasSuffix(hook, "-msg") {
+		fmt.Fprintf(os.Stderr, "the commit message file was left as it was\n")
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "could not write a crash report: %v\n", err)
+	} else {
+		fmt.Fprintf(os.Stderr, "a report is in %s; please attach it to an issue at %s\n", path, IssuesURL)
+	}
+	os.Exit(int(exitcode.Internal))
+}
+
+// Write saves a report of the panic value r with its stack, the arguments and the
+// environment with secrets redacted, and returns its path
+func Write(dir, hook, version string, r interface{}, stack []byte, now time.Time) (string, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", fmt.Errorf("could not create '%s': %v", dir, err)
+	}
+	wd, _ := os.Getwd()
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "hook: %s\n", hook)
+	fmt.Fprintf(&b, "version: %s\n", version)
+	fmt.Fprintf(&b, "go: %s %s/%s\n", runtime.Version(), runtime.GOOS, runtime.GOARCH)
+	fmt.Fprintf(&b, "time: %s\n", now.Format(time.RFC3339))
+	fmt.Fprintf(&b, "dir: %s\n", wd)
+	fmt.Fprintf(&b, "args: %s\n", strings.Join(os.Args, " "))
+	fmt.Fprintf(&b, "\npanic: %v\n\n%s\n", r, stack)
+	b.WriteString("environment:\n")
+	for _, kv := range Environ(os.Environ()) {
+		fmt.Fprintf(&b, "    %s\n", kv)
+	}
+
+	path := filepath.Join(dir, fmt.Sprintf("%s-%s.txt", hook, now.Format("20060102-150405.000")))
+	if err := ioutil.WriteFile(path, []byte(b.String()), 0600); err != nil {
+		return "", fmt.Errorf("could not write '%s': %v", path, err)
+	}
+	prune(dir)
+	return path, nil
+}
+
+// Environ returns env sorted, with the values of secrets and the passwords in urls redacted
+func Environ(env []string) []string {
+	redacted := make([]string, 0, len(env))
+	for _, kv := range env {
+		parts := strings.SplitN(kv, "=", 2)
+		if len(parts) < 2 {
+			continue
+		}
+		redacted = append(redacted, parts[0]+"="+Redact(parts[0], parts[1]))
+	}
+	sort.Strings(redacted)
+	return redacted
+}
+
+// Redact returns value, or [redacted] when name says it holds a secret; the passwords
+// in urls are redacted either way
+func Redact(name, value string) string {
+	if sensitive.MatchString(name) {
+		return "[redacted]"
+	}
+	return userinfo.ReplaceAllString(value, "://[redacted]@")
+}
+
+// Latest is the path of the newest report in dir, or ""
+func Latest(dir string) string {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil || len(entries) == 0 {
+		return ""
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].ModTime().After(entries[j].ModTime()) })
+	return filepath.Join(dir, entries[0].Name())
+}
+
+// prune removes all but the newest Limit reports
+func prune(dir string) {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].ModTime().After(entries[j].ModTime()) })
+	for i, e := range entries {
+		if i >= Limit {
+			_ = os.Remove(filepath.Join(dir, e.Name()))
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package describe
+
+import (
+	"fmt"
+	"github.com/davidalpert/go-githooks/pkg/push"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"sort"
+)
+
+// maxWalk bounds how far back the nearest tag is searched for
+const maxWalk = 10000
+
+// ErrNoTags is returned when no tag is reachable from the commit
+var ErrNoTags = fmt.Errorf("no tags can describe this commit")
+
+// Description is where a commit sits relative to the nearest tag
+type Description struct {
+	Tag      string
+	Distance int // commits reachable from the commit but not from the tag
+	Hash     plumbing.Hash
+}
+
+// String renders the description the way 'git describe --tags' does: the tag alone
+// when the commit is tagged, otherwise <tag>-<distance>-g<abbreviated hash>
+func (d Description) String() string {
+	if d.Distance == 0 {
+		return d.Tag
+	}
+	return fmt.Sprintf("%s-%d-g%s", d.Tag, d.Distance, d.Hash.String()[:7])
+}
+
+// Describe finds the nearest tag (annotated or lightweight) reachable from hash
+func Describe(repo *git.Repository, hash plumbing.Hash) (Description, error) {
+	tags, err := tagsByCommit(repo)
+	if err != nil {
+		return Description{}, err
+	}
+	if len(tags) == 0 {
+		return Description{}, ErrNoTags
+	}
+
+	// walk newest first, like git rev-list, so the first tagged commit is the nearest
+	start, err := repo.CommitObject(hash)
+	if err != nil {
+		return Description{}, fmt.Errorf("could not read commit %s: %v", hash, err)
+	}
+	queue := []*object.Commit{start}
+	seen := map[plumbing.Hash]bool{hash: true}
+	for walked := 0; len(queue) > 0 && walked < maxWalk; walked++ {
+		sort.SliceStable(queue, func(i, j int) bool {
+			return queue[i].Committer.When.After(queue[j].Committer.When)
+		})
+		c := queue[0]
+		queue = queue[1:]
+
+		if names, ok := tags[c.Hash]; ok {
+			distance := 0
+			if c.Hash != hash {
+				commits, err := push.Range(repo, hash, []plumbing.Hash{c.Hash}, maxWalk)
+				if err != nil {
+					return Description{}, err
+				}
+				distance = len(commits)
+			}
+			return Description{Tag: names[0], Distance: distance, Hash: hash}, nil
+		}
+
+		for _, p := range c.ParentHashes {
+			if seen[p] {
+				continue
+			}
+			seen[p] = true
+			parent, err := repo.CommitObject(p)
+			if err != nil {
+				// a shallow clone ends here
+				continue
+			}
+			queue = append(queue, parent)
+		}
+	}
+	return Description{}, ErrNoTags
+}
+
+// tagsByCommit maps each tagged commit to its tag names, sorted so the choice between
+// several tags on one commit is stable
+func tagsByCommit(repo *git.Repository) (map[plumbing.Hash][]string, error) {
+	refs, err := repo.Tags()
+	if err != nil {
+		return nil, fmt.Errorf("could not list tags: %v", err)
+	}
+
+	tags := map[plumbing.Hash][]string{}
+	err = refs.ForEach(func(r *plumbing.Reference) error {
+		target := r.Hash()
+		if t, err := repo.TagObject(target); err == nil {
+			c, err := t.Commit()
+			if err != nil {
+				// tags of trees or blobs do not describe commits
+				return nil
+			}
+			target = c.Hash
+		}
+		tags[target] = append(tags[target], r.Name().Short())
+		return nil
+	})
+	for _, names := range tags {
+		sort.Strings(names)
+	}
+	return tags, err
+}
@@ -0,0 +1,50 @@
+package describe
+
+import (
+	"github.com/go-git/go-billy/v5/memfs"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/storage/memory"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestDescribe(t *testing.T) {
+	r, _ := git.Init(memory.NewStorage(), memfs.New())
+	w, _ := r.Worktree()
+	mal := &object.Signature{Name: "Mal Reynolds", Email: "mal@serenity.com", When: time.Now()}
+
+	commit := func(msg string, offset int) plumbing.Hash {
+		sig := *mal
+		sig.When = mal.When.Add(time.Duration(offset) * time.Minute)
+		f, _ := w.Filesystem.Create(msg)
+		_ = f.Close()
+		_, _ = w.Add(msg)
+		h, err := w.Commit(msg, &git.CommitOptions{Author: &sig})
+		if err != nil {
+			t.Fatalf("committing: %v", err)
+		}
+		return h
+	}
+
+	first := commit("first", 0)
+	if _, err := Describe(r, first); err != ErrNoTags {
+		t.Errorf("expected ErrNoTags, got %v", err)
+	}
+
+	_, _ = r.CreateTag("v1.4.1", first, nil)
+	second := commit("second", 1)
+	_, _ = r.CreateTag("v1.4.2", second, &git.CreateTagOptions{Tagger: mal, Message: "release"})
+
+	d, err := Describe(r, second)
+	assert.NoError(t, err)
+	assert.Equal(t, "v1.4.2", d.String())
+
+	commit("third", 2)
+	head := commit("fourth", 3)
+	d, err = Describe(r, head)
+	assert.NoError(t, err)
+	assert.Equal(t, "v1.4.2-2-g"+head.String()[:7], d.String())
+}
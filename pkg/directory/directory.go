@@ -0,0 +1,146 @@
+package directory
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"github.com/davidalpert/go-githooks/pkg/cienv"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+/*
+ * A Directory answers whether an email belongs to someone in the organisation. It is
+ * configured with a single spec:
+ *
+ *     csv:people.csv                      # a file with an email column (or emails in the first column)
+ *     scim:https://idp.example.com/scim/v2  # a SCIM 2.0 service, queried per email
+ *     command:ldap-has-email              # any command; exit 0 when the email (its last arg) is known
+ *
+ * The command form is how LDAP is supported, e.g. with a small ldapsearch wrapper.
+ */
+type Directory interface {
+	Contains(email string) (bool, error)
+}
+
+// Open returns the directory described by spec; token authenticates SCIM requests
+func Open(spec, token string) (Directory, error) {
+	kind := spec
+	target := ""
+	if i := strings.Index(spec, ":"); i >= 0 {
+		kind, target = spec[:i], spec[i+1:]
+	}
+	if target == "" {
+		return nil, fmt.Errorf("could not parse directory '%s', expected csv:<path>, scim:<url> or command:<command>", spec)
+	}
+
+	switch kind {
+	case "csv":
+		return openCSV(target)
+	case "scim":
+		return &scim{BaseURL: strings.TrimRight(target, "/"), Token: token, Client: &http.Client{Timeout: cienv.Timeout(5 * time.Second)}}, nil
+	case "command":
+		return command(target), nil
+	}
+	return nil, fmt.Errorf("could not parse directory '%s', expected csv:<path>, scim:<url> or command:<command>", spec)
+}
+
+type set map[string]bool
+
+func (s set) Contains(email string) (bool, error) {
+	return s[strings.ToLower(email)], nil
+}
+
+func openCSV(path string) (Directory, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read directory '%s': %v", path, err)
+	}
+	defer f.Close()
+	return ReadCSV(f)
+}
+
+// ReadCSV reads emails from the column headed "email", or from the first column
+// when there is no such header
+func ReadCSV(r io.Reader) (Directory, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	records, err := cr.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("could not parse directory: %v", err)
+	}
+
+	column := 0
+	if len(records) > 0 {
+		for i, h := range records[0] {
+			if strings.EqualFold(strings.TrimSpace(h), "email") {
+				column = i
+				records = records[1:]
+				break
+			}
+		}
+	}
+
+	s := set{}
+	for _, r := range records {
+		if column < len(r) && strings.Contains(r[column], "@") {
+			s[strings.ToLower(strings.TrimSpace(r[column]))] = true
+		}
+	}
+	return s, nil
+}
+
+type scim struct {
+	BaseURL string
+	Token   string
+	Client  *http.Client
+}
+
+func (d *scim) Contains(email string) (bool, error) {
+	filter := fmt.Sprintf(`emails.value eq "%s"`, strings.Replace(email, `"`, ``, -1))
+	req, err := http.NewRequest(http.MethodGet, d.BaseURL+"/Users?count=1&filter="+url.QueryEscape(filter), nil)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Accept", "application/scim+json")
+	if d.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+d.Token)
+	}
+
+	res, err := d.Client.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("could not query directory: %v", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("could not query directory: %s", res.Status)
+	}
+
+	var body struct {
+		TotalResults int `json:"totalResults"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+		return false, fmt.Errorf("could not parse directory response: %v", err)
+	}
+	return body.TotalResults > 0, nil
+}
+
+type command string
+
+func (c command) Contains(email string) (bool, error) {
+	cmd := exec.Command("sh", "-c", string(c)+` "$@"`, string(c), email)
+	cmd.Stderr = os.Stderr
+	err := cmd.Run()
+	if err == nil {
+		return true, nil
+	}
+	if _, ok := err.(*exec.ExitError); ok {
+		return false, nil
+	}
+	return false, fmt.Errorf("could not run directory command '%s': %v", string(c), err)
+}
@@ -0,0 +1,60 @@
+package directory
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestReadCSV(t *testing.T) {
+	d, err := ReadCSV(strings.NewReader("name,email\nMal Reynolds,mal@serenity.com\nZoe Washburne,Zoe@Serenity.com\n"))
+	assert.NoError(t, err)
+
+	for email, want := range map[string]bool{
+		"mal@serenity.com": true,
+		"zoe@serenity.com": true,
+		"mal@serentiy.com": false,
+	} {
+		got, err := d.Contains(email)
+		assert.NoError(t, err)
+		assert.Equal(t, want, got, email)
+	}
+}
+
+func TestSCIM(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer s3cret", r.Header.Get("Authorization"))
+		if r.URL.Query().Get("filter") == `emails.value eq "mal@serenity.com"` {
+			_, _ = w.Write([]byte(`{"totalResults": 1}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"totalResults": 0}`))
+	}))
+	defer server.Close()
+
+	d, err := Open("scim:"+server.URL+"/scim/v2", "s3cret")
+	assert.NoError(t, err)
+
+	found, err := d.Contains("mal@serenity.com")
+	assert.NoError(t, err)
+	assert.True(t, found)
+
+	found, err = d.Contains("mal@serentiy.com")
+	assert.NoError(t, err)
+	assert.False(t, found)
+}
+
+func TestCommand(t *testing.T) {
+	d, err := Open(`command:printf 'mal@serenity.com\n' | grep -qxF --`, "")
+	assert.NoError(t, err)
+
+	found, _ := d.Contains("mal@serenity.com")
+	assert.True(t, found)
+	found, _ = d.Contains("mal@serentiy.com")
+	assert.False(t, found)
+
+	_, err = Open("ldap://example.com", "")
+	assert.Error(t, err)
+}
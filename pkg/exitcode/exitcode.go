@@ -0,0 +1,97 @@
+package exitcode
+
+import (
+	"errors"
+	"os/exec"
+)
+
+/*
+ * Every go-githooks binary exits with one of these codes so that wrapper scripts and
+ * IDEs can tell "your commit breaks a rule" apart from "the hook is misconfigured" or
+ * "a tool the hook needs is not installed". The codes are part of the public contract:
+ * never renumber them, only add new ones.
+ */
+
+// Code is a process exit code
+type Code int
+
+const (
+	OK         Code = 0
+	Internal   Code = 1 // an unexpected failure, e.g. the repo could not be read
+	Usage      Code = 2 // wrong arguments, or not run inside a git repo
+	Config     Code = 3 // git config or a config file could not be read or is invalid
+	Violation  Code = 4 // the change breaks one of the repo's rules
+	Dependency Code = 5 // an external program the hook runs is not installed
+)
+
+// Category describes one exit code
+type Category struct {
+	Code        Code   `json:"code"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// Categories lists every exit code in order
+var Categories = []Category{
+	{OK, "ok", "the hook succeeded"},
+	{Internal, "internal", "an unexpected failure, e.g. the repo could not be read"},
+	{Usage, "usage", "wrong arguments, or not run inside a git repo"},
+	{Config, "config", "git config or a config file could not be read or is invalid"},
+	{Violation, "violation", "the change breaks one of the repo's rules; fix it or bypass with --no-verify"},
+	{Dependency, "dependency", "an external program the hook runs is not installed"},
+}
+
+// Name returns the category name of the code
+func (c Code) Name() string {
+	for _, cat := range Categories {
+		if cat.Code == c {
+			return cat.Name
+		}
+	}
+	return "unknown"
+}
+
+// Error tags an error with the exit code it should end the process with
+type Error struct {
+	Code Code
+	Err  error
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Wrap tags err with code; an error which already carries a code keeps it, so the
+// category assigned closest to the failure wins. A nil err stays nil.
+func Wrap(code Code, err error) error {
+	if err == nil {
+		return nil
+	}
+	var e *Error
+	if errors.As(err, &e) {
+		return err
+	}
+	if errors.Is(err, exec.ErrNotFound) {
+		code = Dependency
+	}
+	return &Error{Code: code, Err: err}
+}
+
+// Of returns the exit code for err: OK for nil, Internal for an untagged error
+func Of(err error) Code {
+	if err == nil {
+		return OK
+	}
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Code
+	}
+	if errors.Is(err, exec.ErrNotFound) {
+		return Dependency
+	}
+	return Internal
+}
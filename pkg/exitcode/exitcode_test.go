@@ -0,0 +1,27 @@
+package exitcode
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"os/exec"
+	"testing"
+)
+
+func TestOf(t *testing.T) {
+	assert.Equal(t, OK, Of(nil))
+	assert.Equal(t, Internal, Of(fmt.Errorf("boom")))
+	assert.Equal(t, Violation, Of(Wrap(Violation, fmt.Errorf("bad subject"))))
+	assert.Equal(t, Usage, Of(Wrap(Config, Wrap(Usage, fmt.Errorf("expected 1 arg")))), "the innermost code wins")
+	assert.Nil(t, Wrap(Config, nil))
+
+	_, err := exec.LookPath("go-githooks-does-not-exist")
+	assert.Equal(t, Dependency, Of(err))
+	assert.Equal(t, Dependency, Of(Wrap(Internal, err)))
+}
+
+func TestCategoriesAreNamed(t *testing.T) {
+	for _, c := range []Code{OK, Internal, Usage, Config, Violation, Dependency} {
+		assert.NotEqual(t, "unknown", c.Name())
+	}
+	assert.Equal(t, "unknown", Code(99).Name())
+}
@@ -0,0 +1,84 @@
+package fileio
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Attempts and Backoff bound the retries; a home directory on NFS or a synced
+// folder (OneDrive, Dropbox) can fail a read or write for a moment and then recover
+var (
+	Attempts = 4
+	Backoff  = 50 * time.Millisecond // doubled after each failed attempt
+)
+
+// sleep is replaced in tests
+var sleep = time.Sleep
+
+// ReadFile is ioutil.ReadFile, retried while the error may be transient; a missing
+// file or a permission problem is returned straight away, unwrapped
+func ReadFile(path string) ([]byte, error) {
+	var data []byte
+	err := retry(func() error {
+		var err error
+		data, err = ioutil.ReadFile(path)
+		return err
+	})
+	return data, err
+}
+
+// WriteFile is ioutil.WriteFile, retried while the error may be transient
+func WriteFile(path string, data []byte, perm os.FileMode) error {
+	return retry(func() error {
+		return ioutil.WriteFile(path, data, perm)
+	})
+}
+
+// ReplaceFile writes data to a file beside path and renames it over path, so that a
+// crash part way through leaves path as it was rather than half-written
+func ReplaceFile(path string, data []byte, perm os.FileMode) error {
+	if fi, err := os.Stat(path); err == nil {
+		perm = fi.Mode().Perm()
+	}
+	return retry(func() error {
+		f, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path)+".*")
+		if err != nil {
+			return err
+		}
+		defer os.Remove(f.Name())
+		_, err = f.Write(data)
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err == nil {
+			err = os.Chmod(f.Name(), perm)
+		}
+		if err != nil {
+			return err
+		}
+		return os.Rename(f.Name(), path)
+	})
+}
+
+func retry(f func() error) error {
+	wait := Backoff
+	var err error
+	for attempt := 1; attempt <= Attempts; attempt++ {
+		if err = f(); err == nil || !Transient(err) {
+			return err
+		}
+		if attempt < Attempts {
+			sleep(wait)
+			wait *= 2
+		}
+	}
+	return fmt.Errorf("gave up after %d attempts: %w", Attempts, err)
+}
+
+// Transient is false for errors retrying cannot fix
+func Transient(err error) bool {
+	return !os.IsNotExist(err) && !os.IsPermission(err) && !os.IsExist(err)
+}
@@ -0,0 +1,64 @@
+package fileio
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestRetry(t *testing.T) {
+	waits := make([]time.Duration, 0)
+	sleep = func(d time.Duration) { waits = append(waits, d) }
+	defer func() { sleep = time.Sleep }()
+
+	calls := 0
+	err := retry(func() error {
+		calls++
+		if calls < 3 {
+			return &os.PathError{Op: "write", Path: "msg", Err: syscall.EIO}
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, calls)
+	assert.Equal(t, []time.Duration{Backoff, 2 * Backoff}, waits)
+
+	calls = 0
+	err = retry(func() error {
+		calls++
+		return &os.PathError{Op: "write", Path: "msg", Err: syscall.ESTALE}
+	})
+	assert.Contains(t, err.Error(), "gave up after 4 attempts: write msg: ")
+	assert.True(t, errors.Is(err, syscall.ESTALE))
+	assert.Equal(t, Attempts, calls)
+}
+
+func TestReadFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	_, err := ReadFile(path)
+	assert.True(t, os.IsNotExist(err), "a missing file is not retried or wrapped")
+
+	assert.NoError(t, WriteFile(path, []byte("{}"), 0644))
+	data, err := ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "{}", string(data))
+}
+
+func TestReplaceFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "COMMIT_EDITMSG")
+	assert.NoError(t, WriteFile(path, []byte("draft\n"), 0600))
+
+	assert.NoError(t, ReplaceFile(path, []byte("ABC-1 fix login\n"), 0644))
+	data, _ := ReadFile(path)
+	assert.Equal(t, "ABC-1 fix login\n", string(data))
+	fi, _ := os.Stat(path)
+	assert.Equal(t, os.FileMode(0600), fi.Mode().Perm(), "the file keeps its mode")
+
+	entries, _ := os.ReadDir(dir)
+	assert.Len(t, entries, 1, "no temporary file is left behind")
+}
@@ -0,0 +1,346 @@
+package fixmemory
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/davidalpert/go-githooks/pkg/fileio"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/storage/filesystem"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+/*
+ * Fix memory is commit-msg's rerere: when a message is blocked and the next attempt
+ * passes with the subject changed the same way as before, e.g. 'fix login' to
+ * 'fix: login' each time conventional-header fails, commit-msg offers to record the
+ * change as an auto-fix, which it then offers for those violations like its own fixes.
+ * Each is a go-githooks.commit-message.autoFix value, the rules it fixes and either a
+ * regexp replaced in the subject or a change of the case of its first letter:
+ *
+ *     conventional-header: s/^fix /fix: /
+ *     subject-capitalized: capitalize
+ *
+ * The last blocked attempt and the changes seen since are kept, keyed by the rules
+ * which failed, in .git/go-githooks/fix-memory.json.
+ */
+
+// Window is how soon after a blocked attempt a passing one counts as its fix
+const Window = 30 * time.Minute
+
+// Threshold is how many times the same change is seen before it is offered
+const Threshold = 2
+
+// Fix is a change to the subject (without its branch prefix) for a set of rules
+type Fix struct {
+	Rules       []string // the rules which failed, sorted
+	Kind        string   // replace, capitalize or lowercase
+	Pattern     string   // replace: what to replace, a regexp
+	Replacement string
+}
+
+// Signature is the sorted, distinct rules, by which attempts and fixes are keyed
+func Signature(rules []string) []string {
+	seen := map[string]bool{}
+	sig := make([]string, 0, len(rules))
+	for _, r := range rules {
+		if r != "" && !seen[r] {
+			seen[r] = true
+			sig = append(sig, r)
+		}
+	}
+	sort.Strings(sig)
+	return sig
+}
+
+// String is the fix as an autoFix value, a replacement written s/<regexp>/<replacement>/
+// with the first of / | # ~ @ which neither contains
+func (f Fix) String() string {
+	if f.Kind != "replace" {
+		return fmt.Sprintf("%s: %s", strings.Join(f.Rules, ","), f.Kind)
+	}
+	d := "/"
+	for _, c := range []string{"/", "|", "#", "~", "@"} {
+		if !strings.Contains(f.Pattern+f.Replacement, c) {
+			d = c
+			break
+		}
+	}
+	return fmt.Sprintf("%s: s%s%s%s%s%s", strings.Join(f.Rules, ","), d, f.Pattern, d, f.Replacement, d)
+}
+
+// Parse reads an autoFix value
+func Parse(s string) (Fix, error) {
+	parts := strings.SplitN(s, ":", 2)
+	if len(parts) != 2 {
+		return Fix{}, fmt.Errorf("could not parse auto-fix '%s': expected '<rules>: capitalize|lowercase|s/<regexp>/<replacement>/'", s)
+	}
+	f := Fix{Rules: Signature(strings.Split(strings.Replace(parts[0], " ", "", -1), ","))}
+	if len(f.Rules) == 0 {
+		return Fix{}, fmt.Errorf("could not parse auto-fix '%s': no rules", s)
+	}
+	change := strings.TrimSpace(parts[1])
+	if change == "capitalize" || change == "lowercase" {
+		f.Kind = change
+		return f, nil
+	}
+	var sides []string
+	if len(change) > 1 && change[0] == 's' {
+		sides = strings.Split(change[2:], change[1:2])
+	}
+	if len(sides) != 3 || sides[2] != "" {
+		return Fix{}, fmt.Errorf("could not parse auto-fix '%s': expected capitalize, lowercase or s/<regexp>/<replacement>/", s)
+	}
+	f.Kind, f.Pattern, f.Replacement = "replace", sides[0], sides[1]
+	if _, err := regexp.Compile(f.Pattern); err != nil {
+		return Fix{}, fmt.Errorf("could not parse auto-fix '%s': %v", s, err)
+	}
+	return f, nil
+}
+
+// Fixes reports whether rule is one of the fix's rules
+func (f Fix) Fixes(rule string) bool {
+	for _, r := range f.Rules {
+		if r == rule {
+			return true
+		}
+	}
+	return false
+}
+
+// Applies reports whether every rule of the fix failed
+func (f Fix) Applies(failed []string) bool {
+	matched := map[string]bool{}
+	for _, r := range failed {
+		if f.Fixes(r) {
+			matched[r] = true
+		}
+	}
+	return len(f.Rules) > 0 && len(matched) == len(f.Rules)
+}
+
+// Apply changes subject the way the fix does
+func (f Fix) Apply(subject string) string {
+	switch f.Kind {
+	case "capitalize":
+		return mapFirst(subject, unicode.ToUpper)
+	case "lowercase":
+		return mapFirst(subject, unicode.ToLower)
+	}
+	re, err := regexp.Compile(f.Pattern)
+	if err != nil {
+		return subject
+	}
+	done := false
+	return re.ReplaceAllStringFunc(subject, func(m string) string {
+		if done {
+			return m
+		}
+		done = true
+		return f.Replacement
+	})
+}
+
+func mapFirst(s string, f func(rune) rune) string {
+	r, n := utf8.DecodeRuneInString(s)
+	if n == 0 {
+		return s
+	}
+	return string(f(r)) + s[n:]
+}
+
+// Derive works out the change from the subject of a blocked attempt to that of the
+// one which passed, anchored to the start or end of the subject where it touches
+// them; subjects which share less than half of their text were rewritten rather
+// than fixed, and give none
+func Derive(rules []string, before, after string) (Fix, bool) {
+	f := Fix{Rules: Signature(rules)}
+	if before == after || len(f.Rules) == 0 {
+		return f, false
+	}
+	if mapFirst(before, unicode.ToUpper) == after {
+		f.Kind = "capitalize"
+		return f, true
+	}
+	if mapFirst(before, unicode.ToLower) == after {
+		f.Kind = "lowercase"
+		return f, true
+	}
+
+	b, a := []rune(before), []rune(after)
+	p := 0
+	for p < len(b) && p < len(a) && b[p] == a[p] {
+		p++
+	}
+	s := 0
+	for s < len(b)-p && s < len(a)-p && b[len(b)-1-s] == a[len(a)-1-s] {
+		s++
+	}
+	longest := len(b)
+	if len(a) > longest {
+		longest = len(a)
+	}
+	if 2*(p+s) < longest {
+		return f, false
+	}
+	// a change inside the subject is widened to whole words, and takes the spaces
+	// around them, so that 'fix login' to 'fix: login' becomes '^fix ' to 'fix: '
+	// rather than inserting ':' after any 'fix', e.g. in 'fixed'
+	if p > 0 && s > 0 {
+		for p > 0 && !unicode.IsSpace(b[p-1]) {
+			p--
+		}
+		for s > 0 && !unicode.IsSpace(b[len(b)-s]) {
+			s--
+		}
+		if p > 0 {
+			p--
+		}
+		if s > 0 {
+			s--
+		}
+	}
+	removed, inserted := string(b[p:len(b)-s]), string(a[p:len(a)-s])
+
+	f.Kind, f.Replacement = "replace", inserted
+	switch {
+	case p == 0:
+		f.Pattern = "^" + regexp.QuoteMeta(removed)
+	case s == 0:
+		f.Pattern = regexp.QuoteMeta(removed) + "$"
+	case strings.TrimSpace(removed) != "":
+		f.Pattern = regexp.QuoteMeta(removed)
+	default:
+		return f, false
+	}
+	return f, true
+}
+
+// Attempt is a blocked message
+type Attempt struct {
+	Rules   []string  `json:"rules"`
+	Subject string    `json:"subject"`
+	At      time.Time `json:"at"`
+}
+
+// Seen counts a change made after a blocked attempt
+type Seen struct {
+	Fix      string `json:"fix"`
+	Count    int    `json:"count"`
+	Declined bool   `json:"declined,omitempty"`
+}
+
+// Store is the attempt history of a repo
+type Store struct {
+	Failed *Attempt `json:"failed,omitempty"`
+	Seen   []Seen   `json:"seen,omitempty"`
+
+	path string
+}
+
+// Path is where the store of repo is kept, or "" for repos not stored on disk
+func Path(repo *git.Repository) string {
+	if repo == nil {
+		return ""
+	}
+	if s, ok := repo.Storer.(*filesystem.Storage); ok {
+		return filepath.Join(s.Filesystem().Root(), "go-githooks", "fix-memory.json")
+	}
+	return ""
+}
+
+// Load reads the store at path; a missing file is empty
+func Load(path string) (*Store, error) {
+	s := &Store{path: path}
+	if path == "" {
+		return s, nil
+	}
+	data, err := fileio.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("could not read '%s': %v", path, err)
+	}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("could not parse '%s': %v", path, err)
+	}
+	return s, nil
+}
+
+// Save writes the store back where it was loaded from
+func (s *Store) Save() error {
+	if s == nil || s.path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("could not create '%s': %v", filepath.Dir(s.path), err)
+	}
+	if err := fileio.WriteFile(s.path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("could not write '%s': %v", s.path, err)
+	}
+	return nil
+}
+
+// Fail records a blocked attempt, replacing the previous one
+func (s *Store) Fail(rules []string, subject string, now time.Time) {
+	s.Failed = &Attempt{Rules: Signature(rules), Subject: subject, At: now.UTC()}
+}
+
+// Forget drops the blocked attempt, e.g. when it was fixed by accepting fixes
+func (s *Store) Forget() {
+	s.Failed = nil
+}
+
+// Pass compares a passing subject with the blocked attempt before it, counting the
+// change between them; it returns the change once it has been seen Threshold times
+// and not declined
+func (s *Store) Pass(subject string, now time.Time) (Fix, bool) {
+	failed := s.Failed
+	s.Failed = nil
+	if failed == nil || now.Sub(failed.At) > Window {
+		return Fix{}, false
+	}
+	f, ok := Derive(failed.Rules, failed.Subject, subject)
+	if !ok {
+		return Fix{}, false
+	}
+	key := f.String()
+	for i := range s.Seen {
+		if s.Seen[i].Fix == key {
+			s.Seen[i].Count++
+			return f, s.Seen[i].Count >= Threshold && !s.Seen[i].Declined
+		}
+	}
+	s.Seen = append(s.Seen, Seen{Fix: key, Count: 1})
+	return f, Threshold <= 1
+}
+
+// Decline stops the change being offered again
+func (s *Store) Decline(f Fix) {
+	for i := range s.Seen {
+		if s.Seen[i].Fix == f.String() {
+			s.Seen[i].Declined = true
+		}
+	}
+}
+
+// Recorded drops a change which is now an auto-fix
+func (s *Store) Recorded(f Fix) {
+	kept := make([]Seen, 0, len(s.Seen))
+	for _, seen := range s.Seen {
+		if seen.Fix != f.String() {
+			kept = append(kept, seen)
+		}
+	}
+	s.Seen = kept
+}
@@ -0,0 +1,92 @@
+package fixmemory
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDerive(t *testing.T) {
+	tests := []struct {
+		before, after string
+		want          string // "" when no fix is derived
+		check         string // a subject the fix is applied to
+		fixed         string
+	}{
+		{"fix login", "fix: login", "conventional-header: s/^fix /fix: /", "fix crash in the parser", "fix: crash in the parser"},
+		{"fix login", "fix: login", "conventional-header: s/^fix /fix: /", "fixed crash", "fixed crash"},
+		{"add login page", "Add login page", "conventional-header: capitalize", "remove it", "Remove it"},
+		{"Add login page.", "Add login page", "conventional-header: s/\\.$//", "Drop it.", "Drop it"},
+		{"login page", "feat: login page", "conventional-header: s/^/feat: /", "signup", "feat: signup"},
+		{"add the login page", "add the signup page", "conventional-header: s/ login / signup /", "move the login page", "move the signup page"},
+		{"fix login", "rewrite the whole thing", "", "", ""},
+		{"same", "same", "", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.before+" to "+tt.after, func(t *testing.T) {
+			f, ok := Derive([]string{"conventional-header"}, tt.before, tt.after)
+			if tt.want == "" {
+				assert.False(t, ok)
+				return
+			}
+			if assert.True(t, ok) {
+				assert.Equal(t, tt.want, f.String())
+				assert.Equal(t, tt.after, f.Apply(tt.before))
+				assert.Equal(t, tt.fixed, f.Apply(tt.check))
+			}
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	for _, s := range []string{"conventional-header: s/^fix /fix: /", "conventional-header,ticket-reference: capitalize", "ticket-reference: s|^docs/|docs: |"} {
+		f, err := Parse(s)
+		assert.NoError(t, err)
+		assert.Equal(t, s, f.String())
+	}
+	f, _ := Parse("ticket-reference, conventional-header: lowercase")
+	assert.Equal(t, []string{"conventional-header", "ticket-reference"}, f.Rules)
+	assert.True(t, f.Applies([]string{"ticket-reference", "dco-signoff", "conventional-header"}))
+	assert.False(t, f.Applies([]string{"ticket-reference"}))
+
+	for _, s := range []string{"capitalize", ": capitalize", "x: upper", "x: s/a/b", "x: s/(/b/"} {
+		_, err := Parse(s)
+		assert.Error(t, err, s)
+	}
+}
+
+func TestStore(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "fixmemory")
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "fix-memory.json")
+	now := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	s, err := Load(path)
+	assert.NoError(t, err)
+	s.Fail([]string{"conventional-header"}, "fix login", now)
+	_, offer := s.Pass("fix: login", now.Add(time.Minute))
+	assert.False(t, offer, "seen once")
+	assert.NoError(t, s.Save())
+
+	s, _ = Load(path)
+	assert.Nil(t, s.Failed)
+	_, offer = s.Pass("fix: crash", now)
+	assert.False(t, offer, "nothing was blocked")
+	s.Fail([]string{"conventional-header"}, "fix crash", now)
+	_, offer = s.Pass("fix: crash", now.Add(2*Window))
+	assert.False(t, offer, "too long after")
+	s.Fail([]string{"conventional-header"}, "fix crash", now)
+	f, offer := s.Pass("fix: crash", now.Add(time.Minute))
+	assert.True(t, offer)
+
+	s.Decline(f)
+	s.Fail([]string{"conventional-header"}, "fix typo", now)
+	_, offer = s.Pass("fix: typo", now.Add(time.Minute))
+	assert.False(t, offer, "declined")
+
+	s.Recorded(f)
+	assert.Empty(t, s.Seen)
+}
@@ -0,0 +1,55 @@
+package generated
+
+import (
+	"bytes"
+	"github.com/davidalpert/go-githooks/pkg/attributes"
+	"path"
+)
+
+// Attribute is the .gitattributes attribute GitHub's linguist uses to mark generated files
+const Attribute = "linguist-generated"
+
+// HeaderSize is how much of a file is searched for a marker; generators put it at the top
+const HeaderSize = 1024
+
+// DefaultMarkers are the comments common generators leave at the top of their output,
+// e.g. Go's '// Code generated by stringer; DO NOT EDIT.'
+var DefaultMarkers = []string{"Code generated", "@generated", "<auto-generated"}
+
+// Detector decides whether a file is generated, so checks meant for hand-written code
+// can leave it alone
+type Detector struct {
+	Attributes *attributes.Attributes
+	Paths      []string // globs, matched against the path, each parent directory and the file name
+	Markers    []string
+}
+
+// Is reports whether the file at p, starting with contents, is generated; an explicit
+// linguist-generated attribute wins, including -linguist-generated or =false
+func (d Detector) Is(p string, contents []byte) bool {
+	if attr := d.Attributes.Get(p, Attribute); attr != nil {
+		return d.Attributes.IsSet(p, Attribute)
+	}
+
+	for _, glob := range d.Paths {
+		if ok, _ := path.Match(glob, path.Base(p)); ok {
+			return true
+		}
+		for candidate := p; candidate != "." && candidate != "/"; candidate = path.Dir(candidate) {
+			if ok, _ := path.Match(glob, candidate); ok {
+				return true
+			}
+		}
+	}
+
+	header := contents
+	if len(header) > HeaderSize {
+		header = header[:HeaderSize]
+	}
+	for _, m := range d.Markers {
+		if m != "" && bytes.Contains(header, []byte(m)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,28 @@
+package generated
+
+import (
+	"github.com/davidalpert/go-githooks/pkg/attributes"
+	"github.com/go-git/go-billy/v5/memfs"
+	"github.com/go-git/go-billy/v5/util"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/storage/memory"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestIs(t *testing.T) {
+	r, _ := git.Init(memory.NewStorage(), memfs.New())
+	w, _ := r.Worktree()
+	_ = util.WriteFile(w.Filesystem, ".gitattributes", []byte("*.pb.go linguist-generated\nhand/*.go -linguist-generated\n"), 0644)
+	_, _ = w.Add(".gitattributes")
+	attrs, err := attributes.FromIndex(r)
+	assert.NoError(t, err)
+
+	d := Detector{Attributes: attrs, Paths: []string{"gen", "*.min.js"}, Markers: DefaultMarkers}
+	assert.True(t, d.Is("api/api.pb.go", []byte("package api\n")), "attribute")
+	assert.True(t, d.Is("gen/client/client.go", []byte("package client\n")), "parent directory glob")
+	assert.True(t, d.Is("static/app.min.js", nil), "file glob")
+	assert.True(t, d.Is("kind_string.go", []byte("// Code generated by \"stringer -type=Kind\"; DO NOT EDIT.\n\npackage kind\n")), "marker")
+	assert.False(t, d.Is("hand/kind_string.go", []byte("// Code generated by hand, honest\n")), "attribute unset wins over a marker")
+	assert.False(t, d.Is("main.go", []byte("package main\n")))
+}
@@ -0,0 +1,83 @@
+package gitconfig
+
+import (
+	"github.com/go-git/go-git/v5/config"
+	config2 "github.com/go-git/go-git/v5/plumbing/format/config"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// SafeEnv are the environment variables config values may always refer to; others
+// have to be listed in go-githooks.expandEnv, so shared config can not read secrets
+// out of the environment into e.g. a webhook url
+var SafeEnv = []string{"USER", "USERNAME", "LOGNAME", "GIT_HOOKS_ENV"}
+
+var envRefRe = regexp.MustCompile(`\$\$\{|\$\{([A-Za-z_][A-Za-z0-9_]*)(?::([-+])([^}]*))?\}`)
+
+// Expand replaces ${VAR} in v with the environment variable VAR when it is one of
+// allowed; ${VAR:-text} is text when VAR is unset or empty and ${VAR:+text} is text
+// only when it is set. $${ is a literal ${, and references to variables which are
+// not allowed are left as written
+func Expand(v string, allowed []string) string {
+	if !strings.Contains(v, "${") {
+		return v
+	}
+	return envRefRe.ReplaceAllStringFunc(v, func(ref string) string {
+		if ref == "$${" {
+			return "${"
+		}
+		m := envRefRe.FindStringSubmatch(ref)
+		if !containsFold(allowed, m[1]) {
+			return ref
+		}
+		value := os.Getenv(m[1])
+		switch m[2] {
+		case "-":
+			if value == "" {
+				return m[3]
+			}
+		case "+":
+			if value != "" {
+				return m[3]
+			}
+			return ""
+		}
+		return value
+	})
+}
+
+// expandable lists the variables values in c may refer to
+func expandable(c *config.Config) []string {
+	allowed := append([]string{}, SafeEnv...)
+	if c.Raw.HasSection("go-githooks") {
+		for _, v := range strings.Split(c.Raw.Section("go-githooks").Option("expandEnv"), ",") {
+			if v = strings.TrimSpace(v); v != "" {
+				allowed = append(allowed, v)
+			}
+		}
+	}
+	return allowed
+}
+
+// trustExpandEnv keeps go-githooks.expandEnv in merged only as the user's own repo
+// or global config sets it
+func trustExpandEnv(merged *config2.Config, trusted ...*config2.Config) {
+	s := merged.Section("go-githooks")
+	s.RemoveOption("expandEnv")
+	for _, t := range trusted {
+		if t != nil && t.HasSection("go-githooks") && t.Section("go-githooks").HasOption("expandEnv") {
+			s.SetOption("expandEnv", t.Section("go-githooks").Option("expandEnv"))
+			return
+		}
+	}
+}
+
+func containsFold(list []string, v string) bool {
+	for _, s := range list {
+		if strings.EqualFold(s, v) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,44 @@
+package gitconfig
+
+import (
+	"github.com/go-git/go-git/v5/config"
+	config2 "github.com/go-git/go-git/v5/plumbing/format/config"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"testing"
+)
+
+func TestExpand(t *testing.T) {
+	for k, v := range map[string]string{"GIT_HOOKS_ENV": "staging", "DEPLOY_TOKEN": "s3cr3t", "EMPTY": ""} {
+		defer os.Setenv(k, os.Getenv(k))
+		_ = os.Setenv(k, v)
+	}
+	allowed := append([]string{"EMPTY"}, SafeEnv...)
+
+	assert.Equal(t, "https://hooks.example.com/staging", Expand("https://hooks.example.com/${GIT_HOOKS_ENV}", allowed))
+	assert.Equal(t, "https://x/${DEPLOY_TOKEN}", Expand("https://x/${DEPLOY_TOKEN}", allowed), "variables which are not allowed are left as written")
+	assert.Equal(t, "prod", Expand("${EMPTY:-prod}", allowed))
+	assert.Equal(t, "staging", Expand("${GIT_HOOKS_ENV:-prod}", allowed))
+	assert.Equal(t, "[%s] (staging)", Expand("[%s]${GIT_HOOKS_ENV:+ (staging)}", allowed))
+	assert.Equal(t, "[%s]", Expand("[%s]${EMPTY:+ (set)}", allowed))
+	assert.Equal(t, "${GIT_HOOKS_ENV} costs $5", Expand("$${GIT_HOOKS_ENV} costs $5", allowed))
+}
+
+func TestExpandEnvIsTrustedFromUserConfigOnly(t *testing.T) {
+	defer os.Setenv("DEPLOY_TOKEN", os.Getenv("DEPLOY_TOKEN"))
+	_ = os.Setenv("DEPLOY_TOKEN", "s3cr3t")
+
+	shared := config2.New()
+	shared.SetOption("go-githooks", config2.NoSubsection, "expandEnv", "DEPLOY_TOKEN")
+	shared.SetOption("go-githooks", "notify", "webhook", "https://x/${DEPLOY_TOKEN}")
+	local := config2.New()
+
+	c := config.NewConfig()
+	c.Raw = MergeRaw(shared, local)
+	trustExpandEnv(c.Raw, local)
+	assert.Equal(t, "https://x/${DEPLOY_TOKEN}", GetString(c, "go-githooks", "notify", "webhook", ""))
+
+	local.SetOption("go-githooks", config2.NoSubsection, "expandEnv", "DEPLOY_TOKEN")
+	trustExpandEnv(c.Raw, local)
+	assert.Equal(t, "https://x/s3cr3t", GetString(c, "go-githooks", "notify", "webhook", ""))
+}
@@ -0,0 +1,120 @@
+package gitconfig
+
+import (
+	"fmt"
+	"github.com/davidalpert/go-githooks/pkg/exitcode"
+	"github.com/go-git/go-git/v5/config"
+	config2 "github.com/go-git/go-git/v5/plumbing/format/config"
+	"strconv"
+	"strings"
+)
+
+// GetString reads section.subsection.key from c, falling back to defaultValue when it is not set;
+// environment variables in the value are expanded as Expand describes
+func GetString(c *config.Config, section, subsection, key, defaultValue string) string {
+	//fmt.Printf("reading %s | %s | %s (default: %s)\n", section, subsection, key, defaultValue)
+	if !c.Raw.HasSection(section) {
+		//fmt.Printf("couldn't find section '%s'\n", section)
+		return defaultValue
+	}
+
+	s := c.Raw.Section(section)
+	var o config2.Options
+	if subsection == "" {
+		//fmt.Printf("no sub-section given\n")
+		o = s.Options
+	} else if s.HasSubsection(subsection) {
+		//fmt.Printf("has sub-section '%s'\n", subsection)
+		o = s.Subsection(subsection).Options
+	} else {
+		//fmt.Printf("no sub-section '%s'\n", subsection)
+		return defaultValue
+	}
+
+	if o.Has(key) {
+		//fmt.Printf("has key '%s'\n", key)
+		return Expand(o.Get(key), expandable(c))
+	}
+	//fmt.Printf("missing key '%s'\n", key)
+	return defaultValue
+}
+
+// GetBool reads section.subsection.key from c as a bool, falling back to defaultValue when it is not set
+func GetBool(c *config.Config, section, subsection, key string, defaultValue bool) bool {
+	v := GetString(c, section, subsection, key, "")
+	//fmt.Printf("(%s, %s, %s) got: %s\n", section, subsection, key, v)
+	if v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			// GetBool has no error to return; the hooks' crash.Recover reports it as a config error
+			panic(exitcode.Wrap(exitcode.Config, fmt.Errorf("failed parsing %s.%s '%s' as a bool: %v", section, key, v, err)))
+		}
+		return b
+	}
+	return defaultValue
+}
+
+// GetInt reads section.subsection.key from c as a whole number, falling back to defaultValue when it is not set
+func GetInt(c *config.Config, section, subsection, key string, defaultValue int) (int, error) {
+	v := GetString(c, section, subsection, key, "")
+	if v == "" {
+		return defaultValue, nil
+	}
+	i, err := strconv.Atoi(strings.TrimSpace(v))
+	if err != nil {
+		return defaultValue, fmt.Errorf("could not parse %s '%s' as a number: %v", key, v, err)
+	}
+	return i, nil
+}
+
+// GetSlice reads section.subsection.key from c as a list, falling back to defaultValues when it is not set;
+// see List for how the values are split
+func GetSlice(c *config.Config, section, subsection, key string, defaultValues []string) []string {
+	if v := List(GetAll(c, section, subsection, key)); len(v) > 0 {
+		return v
+	}
+	return defaultValues
+}
+
+// GetAll reads every value of a key repeated in section.subsection, in order, expanding
+// environment variables as GetString does
+func GetAll(c *config.Config, section, subsection, key string) []string {
+	if c == nil || c.Raw == nil || !c.Raw.HasSection(section) {
+		return nil
+	}
+
+	s := c.Raw.Section(section)
+	var o config2.Options
+	if subsection == "" {
+		o = s.Options
+	} else if s.HasSubsection(subsection) {
+		o = s.Subsection(subsection).Options
+	} else {
+		return nil
+	}
+
+	values := o.GetAll(key)
+	for i, v := range values {
+		values[i] = Expand(v, expandable(c))
+	}
+	return values
+}
+
+// List turns the values of a list option into its items: a single value is
+// comma-separated, while a key repeated once per item (as git does for e.g.
+// remote.<name>.fetch) takes each value whole, so items may contain commas
+func List(values []string) []string {
+	if len(values) == 1 {
+		if values[0] == "" {
+			return nil
+		}
+		return strings.Split(values[0], ",")
+	}
+	items := make([]string, 0, len(values))
+	for _, v := range values {
+		if v != "" {
+			items = append(items, v)
+		}
+	}
+	return items
+}
@@ -0,0 +1,156 @@
+package gitconfig
+
+import (
+	"fmt"
+	"github.com/davidalpert/go-githooks/pkg/policysig"
+	"github.com/davidalpert/go-githooks/pkg/remoteconfig"
+	"github.com/davidalpert/go-githooks/pkg/repoconfig"
+	"github.com/davidalpert/go-githooks/pkg/templates"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	config2 "github.com/go-git/go-git/v5/plumbing/format/config"
+	"os"
+	"time"
+)
+
+// Load reads the repo's config layered over the global config, the way git does:
+// every option from both files is kept and the repo's values win. Remote config
+// (go-githooks.configUrl) sits below both and .githooks.yml between them; imported
+// templates sit between the remote config and the global config. None of these
+// shared layers can set the UserOnly options.
+//
+// go-git's ConfigScoped(GlobalScope) merges the typed fields (user, core, ...) but
+// replaces the global raw sections with the repo's, dropping global go-githooks options.
+func Load(repo *git.Repository) (*config.Config, error) {
+	cfg, err := repo.ConfigScoped(config.GlobalScope)
+	if err != nil {
+		return nil, err
+	}
+
+	local, err := repo.Config()
+	if err != nil {
+		return nil, err
+	}
+	global, err := config.LoadConfig(config.GlobalScope)
+	if err != nil {
+		global = config.NewConfig()
+	}
+
+	// .githooks.yml is shared team policy: it overrides the user's global config but
+	// not the repo's own .git/config
+	policy := Policy()
+	var committed *config2.Config
+	if w, err := repo.Worktree(); err == nil {
+		if committed, err = repoconfig.Load(w.Filesystem, policy); err != nil {
+			fmt.Fprintf(os.Stderr, "go-githooks: ignoring %s: %v\n", repoconfig.File, err)
+			committed = nil
+		}
+	}
+
+	layers := make([]*config2.Config, 0)
+	for _, t := range templates.Layers() {
+		layers = append(layers, withoutUserOnly(t))
+	}
+	layers = append(layers, global.Raw, withoutUserOnly(committed), local.Raw)
+	cfg.Raw = MergeRaw(layers...)
+	trustExpandEnv(cfg.Raw, local.Raw, global.Raw)
+	if remote := loadRemote(cfg, policy); remote != nil {
+		cfg.Raw = MergeRaw(append([]*config2.Config{withoutUserOnly(remote)}, layers...)...)
+		trustExpandEnv(cfg.Raw, local.Raw, global.Raw)
+	}
+	return cfg, nil
+}
+
+// loadRemote reads the shared config named by go-githooks.configUrl, if any; a hook
+// should not fail because the server is unreachable, so problems are only reported
+func loadRemote(cfg *config.Config, policy *policysig.Policy) *config2.Config {
+	url := GetString(cfg, "go-githooks", "", "configUrl", "")
+	if url == "" {
+		return nil
+	}
+
+	src := remoteconfig.Source{
+		URL:       url,
+		PublicKey: GetString(cfg, "go-githooks", "", "configPublicKey", ""),
+		Policy:    policy,
+	}
+	if r := GetString(cfg, "go-githooks", "", "configRefresh", ""); r != "" {
+		d, err := time.ParseDuration(r)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "go-githooks: ignoring configRefresh '%s': %v\n", r, err)
+		}
+		src.Refresh = d
+	}
+
+	remote, err := src.Load(nil)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "go-githooks: ignoring remote config: %v\n", err)
+		return nil
+	}
+	return remote
+}
+
+// MergeRaw returns a new raw config holding the options of each layer in order,
+// so that a later layer's values win when read with GetString or GetSlice
+func MergeRaw(layers ...*config2.Config) *config2.Config {
+	merged := config2.New()
+	for _, layer := range layers {
+		if layer == nil {
+			continue
+		}
+		for _, s := range layer.Sections {
+			// a key set in a later layer replaces every value of it in earlier ones,
+			// so repeated keys only accumulate within one layer
+			for _, o := range s.Options {
+				merged.Section(s.Name).RemoveOption(o.Key)
+			}
+			for _, o := range s.Options {
+				merged.AddOption(s.Name, config2.NoSubsection, o.Key, o.Value)
+			}
+			for _, ss := range s.Subsections {
+				sub := merged.Section(s.Name).Subsection(ss.Name)
+				for _, o := range ss.Options {
+					sub.RemoveOption(o.Key)
+				}
+				for _, o := range ss.Options {
+					merged.AddOption(s.Name, ss.Name, o.Key, o.Value)
+				}
+			}
+		}
+	}
+	return merged
+}
+
+// Has reports whether section.subsection.key is set in c
+func Has(c *config.Config, section, subsection, key string) bool {
+	if c == nil || c.Raw == nil || !c.Raw.HasSection(section) {
+		return false
+	}
+	s := c.Raw.Section(section)
+	if subsection == "" {
+		return s.HasOption(key)
+	}
+	return s.HasSubsection(subsection) && s.Subsection(subsection).HasOption(key)
+}
+
+// Policy returns the keys trusted to sign policy bundles, read only from the system
+// and global config since a repo cannot vouch for its own policy; keys which cannot
+// be parsed trust nothing, rather than everything, until they are fixed
+func Policy() *policysig.Policy {
+	specs := make([]string, 0)
+	strict := false
+	for _, scope := range []config.Scope{config.SystemScope, config.GlobalScope} {
+		c, err := config.LoadConfig(scope)
+		if err != nil {
+			continue
+		}
+		specs = append(specs, GetAll(c, "go-githooks", "policy", "trustedKey")...)
+		strict = GetBool(c, "go-githooks", "policy", "strict", strict)
+	}
+	p, err := policysig.New(specs, strict)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "go-githooks: refusing all policy until the trusted keys are fixed: %v\n", err)
+		return &policysig.Policy{Strict: true}
+	}
+	return p
+}
@@ -0,0 +1,126 @@
+package gitconfig
+
+import (
+	"github.com/go-git/go-billy/v5/memfs"
+	"github.com/go-git/go-billy/v5/util"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	config2 "github.com/go-git/go-git/v5/plumbing/format/config"
+	"github.com/go-git/go-git/v5/storage/memory"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"testing"
+)
+
+func TestMergeRaw(t *testing.T) {
+	global := config2.New()
+	global.SetOption("go-githooks", "prepare-commit-message", "prefixWithBranch", "true")
+	global.SetOption("go-githooks", "prepare-commit-message", "prefixWithBranchTemplate", "%s:")
+	global.SetOption("go-githooks", config2.NoSubsection, "preset", "jira")
+
+	local := config2.New()
+	local.SetOption("go-githooks", "prepare-commit-message", "prefixWithBranch", "false")
+
+	c := config.NewConfig()
+	c.Raw = MergeRaw(global, local)
+
+	assert.False(t, GetBool(c, "go-githooks", "prepare-commit-message", "prefixWithBranch", true))
+	assert.Equal(t, "%s:", GetString(c, "go-githooks", "prepare-commit-message", "prefixWithBranchTemplate", "[%s]"))
+	assert.Equal(t, "jira", GetString(c, "go-githooks", "", "preset", ""))
+	assert.True(t, Has(c, "go-githooks", "", "preset"))
+	assert.False(t, Has(c, "go-githooks", "commit-message", "preset"))
+}
+
+func TestGetSliceRepeatedKeys(t *testing.T) {
+	global := config2.New()
+	global.SetOption("go-githooks", "pre-commit", "sizeExclusions", "vendor,node_modules")
+	global.AddOption("go-githooks", "pre-commit", "generatedPaths", "gen")
+
+	local := config2.New()
+	local.AddOption("go-githooks", "pre-commit", "generatedPaths", "api/*.{pb,grpc}.go")
+	local.AddOption("go-githooks", "pre-commit", "generatedPaths", "mocks")
+
+	c := config.NewConfig()
+	c.Raw = MergeRaw(global, local)
+
+	assert.Equal(t, []string{"vendor", "node_modules"}, GetSlice(c, "go-githooks", "pre-commit", "sizeExclusions", nil), "a single value is comma-separated")
+	assert.Equal(t, []string{"api/*.{pb,grpc}.go", "mocks"}, GetSlice(c, "go-githooks", "pre-commit", "generatedPaths", nil),
+		"a repeated key is one item per value, and replaces the values of earlier layers")
+	assert.Equal(t, []string{"x"}, GetSlice(c, "go-githooks", "pre-commit", "missing", []string{"x"}))
+	assert.Equal(t, []string{"api/*.{pb,grpc}.go", "mocks"}, GetAll(c, "go-githooks", "pre-commit", "generatedPaths"))
+}
+
+func TestLoadIgnoresUserOnlyOptionsFromGithooksYml(t *testing.T) {
+	defer os.Setenv("HOME", os.Getenv("HOME"))
+	_ = os.Setenv("HOME", t.TempDir())
+
+	r, _ := git.Init(memory.NewStorage(), memfs.New())
+	w, _ := r.Worktree()
+	yml := `post-checkout:
+  runCommands: true
+  promptBeforeRun: false
+  nvmrcCommand: touch /tmp/pwned
+pre-commit:
+  formatters: gofmt
+  sizeMaxFiles: 5
+commit-message:
+  coauthorDirectory: command:touch /tmp/pwned
+  ticketPattern: ABC-[0-9]+
+worklog:
+  command: touch /tmp/pwned
+  url: https://attacker.example
+  user: mal@serenity.com
+  token: env:JIRA_TOKEN
+mob:
+  handoffCommand: touch /tmp/pwned
+vcs:
+  apiUrl: https://attacker.example
+  token: env:GITHUB_TOKEN
+telemetry:
+  enabled: true
+`
+	if err := util.WriteFile(w.Filesystem, ".githooks.yml", []byte(yml), 0644); err != nil {
+		t.Fatalf("writing .githooks.yml: %v", err)
+	}
+
+	c, err := Load(r)
+	assert.NoError(t, err)
+	assert.False(t, GetBool(c, "go-githooks", "post-checkout", "runCommands", false))
+	assert.True(t, GetBool(c, "go-githooks", "post-checkout", "promptBeforeRun", true))
+	assert.Equal(t, "", GetString(c, "go-githooks", "post-checkout", "nvmrcCommand", ""))
+	assert.False(t, Has(c, "go-githooks", "pre-commit", "formatters"))
+	assert.False(t, Has(c, "go-githooks", "commit-message", "coauthorDirectory"))
+	assert.False(t, Has(c, "go-githooks", "worklog", "command"))
+	assert.False(t, Has(c, "go-githooks", "worklog", "url"))
+	assert.False(t, Has(c, "go-githooks", "worklog", "user"))
+	assert.False(t, Has(c, "go-githooks", "worklog", "token"))
+	assert.False(t, Has(c, "go-githooks", "mob", "handoffCommand"))
+	assert.False(t, Has(c, "go-githooks", "vcs", "apiUrl"))
+	assert.False(t, Has(c, "go-githooks", "vcs", "token"))
+	assert.False(t, Has(c, "go-githooks", "telemetry", "enabled"))
+
+	// the rest of the file is still team policy
+	assert.Equal(t, "5", GetString(c, "go-githooks", "pre-commit", "sizeMaxFiles", ""))
+	assert.Equal(t, "ABC-[0-9]+", GetString(c, "go-githooks", "commit-message", "ticketPattern", ""))
+
+	// and the user can still set them in the repo's own config
+	cfg, _ := r.Config()
+	cfg.Raw.SetOption("go-githooks", "post-checkout", "runCommands", "true")
+	cfg.Raw.SetOption("go-githooks", "commit-message", "coauthorDirectory", "command:ldap-lookup")
+	assert.NoError(t, r.SetConfig(cfg))
+	c, err = Load(r)
+	assert.NoError(t, err)
+	assert.True(t, GetBool(c, "go-githooks", "post-checkout", "runCommands", false))
+	assert.Equal(t, "command:ldap-lookup", GetString(c, "go-githooks", "commit-message", "coauthorDirectory", ""))
+}
+
+func TestIsUserOnly(t *testing.T) {
+	assert.True(t, IsUserOnly("go-githooks", "post-checkout", "toolVersionsCommand", "asdf install"))
+	assert.True(t, IsUserOnly("go-githooks", "post-checkout", "runcommands", "true"))
+	assert.True(t, IsUserOnly("core", "", "editor", "vim"))
+	assert.True(t, IsUserOnly("go-githooks", "commit-message", "coauthorDirectory", "Command:lookup"))
+	assert.True(t, IsUserOnly("go-githooks", "commit-message", "coauthorDirectory", "scim:https://idp.example.com/scim/v2"))
+	assert.False(t, IsUserOnly("go-githooks", "commit-message", "coauthorDirectory", "csv:people.csv"))
+	assert.True(t, IsUserOnly("go-githooks", "vcs", "token", "keyring:github"))
+	assert.False(t, IsUserOnly("go-githooks", "pre-commit", "sizeMaxFiles", "5"))
+}
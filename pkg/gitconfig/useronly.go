@@ -0,0 +1,81 @@
+package gitconfig
+
+import (
+	config2 "github.com/go-git/go-git/v5/plumbing/format/config"
+	"path"
+	"strings"
+)
+
+// UserOnlyOption is an option which runs a command on the user's machine, decides
+// whether one runs, names a server the user's tokens are sent to, or opts the user
+// in to telemetry; Subsection and Key are globs, and with Prefix set only values starting with it are user-only, e.g.
+// 'command:' directories but not 'csv:' ones
+type UserOnlyOption struct {
+	Section, Subsection, Key, Prefix string
+}
+
+// UserOnly are only read from the repo's .git/config and the global config, like
+// go-githooks.scripts.enabled: .githooks.yml, templates and remote config come from
+// whoever can push to the repo or serve its config, and cloning a repo must not be
+// enough to run its commands, to send the user's tokens elsewhere or to record
+// telemetry the user never asked for
+var UserOnly = []UserOnlyOption{
+	{Section: "core", Key: "editor"},
+	{Section: "go-githooks", Subsection: "post-checkout", Key: "runCommands"},
+	{Section: "go-githooks", Subsection: "post-checkout", Key: "promptBeforeRun"},
+	{Section: "go-githooks", Subsection: "post-checkout", Key: "*Command"},
+	{Section: "go-githooks", Subsection: "pre-commit", Key: "formatters"},
+	{Section: "go-githooks", Subsection: "pre-commit", Key: "imageOptimizers"},
+	{Section: "go-githooks", Subsection: "check.*", Key: "*"},
+	{Section: "go-githooks", Subsection: "commit-message", Key: "coauthorDirectory", Prefix: "command:"},
+	{Section: "go-githooks", Subsection: "commit-message", Key: "coauthorDirectory", Prefix: "scim:"},
+	{Section: "go-githooks", Subsection: "commit-message", Key: "coauthorDirectoryToken"},
+	{Section: "go-githooks", Subsection: "vcs", Key: "apiUrl"},
+	{Section: "go-githooks", Subsection: "vcs", Key: "token"},
+	{Section: "go-githooks", Subsection: "worklog", Key: "command"},
+	{Section: "go-githooks", Subsection: "worklog", Key: "url"},
+	{Section: "go-githooks", Subsection: "worklog", Key: "user"},
+	{Section: "go-githooks", Subsection: "worklog", Key: "token"},
+	{Section: "go-githooks", Subsection: "mob", Key: "handoffCommand"},
+	{Section: "go-githooks", Subsection: "telemetry", Key: "enabled"},
+}
+
+// IsUserOnly reports whether section.subsection.key set to value may only come from
+// the user's own config
+func IsUserOnly(section, subsection, key, value string) bool {
+	for _, o := range UserOnly {
+		if strings.EqualFold(o.Section, section) && matchFold(o.Subsection, subsection) && matchFold(o.Key, key) &&
+			strings.HasPrefix(strings.ToLower(strings.TrimSpace(value)), o.Prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// withoutUserOnly returns a copy of a shared layer without its user-only options
+func withoutUserOnly(layer *config2.Config) *config2.Config {
+	if layer == nil {
+		return nil
+	}
+	kept := config2.New()
+	for _, s := range layer.Sections {
+		for _, o := range s.Options {
+			if !IsUserOnly(s.Name, config2.NoSubsection, o.Key, o.Value) {
+				kept.AddOption(s.Name, config2.NoSubsection, o.Key, o.Value)
+			}
+		}
+		for _, ss := range s.Subsections {
+			for _, o := range ss.Options {
+				if !IsUserOnly(s.Name, ss.Name, o.Key, o.Value) {
+					kept.AddOption(s.Name, ss.Name, o.Key, o.Value)
+				}
+			}
+		}
+	}
+	return kept
+}
+
+func matchFold(glob, s string) bool {
+	ok, _ := path.Match(strings.ToLower(glob), strings.ToLower(s))
+	return ok
+}
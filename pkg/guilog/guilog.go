@@ -0,0 +1,170 @@
+package guilog
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/davidalpert/go-githooks/pkg/fileio"
+	"github.com/davidalpert/go-githooks/pkg/gitconfig"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/storage/filesystem"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// BlockLines is how many of the last lines of output are copied into the message
+// of a blocked commit
+const BlockLines = 15
+
+var active *capture
+
+type capture struct {
+	path           string
+	log            *os.File
+	stdout, stderr *os.File // as they were before Start
+	pipes          []*os.File
+	wg             sync.WaitGroup
+}
+
+// Enabled reports whether GIT_HOOKS_GUI_LOG or go-githooks.guiLog asks hooks to
+// keep their output for GUI clients, which often do not show it
+func Enabled(repo *git.Repository) bool {
+	if v := strings.ToLower(os.Getenv("GIT_HOOKS_GUI_LOG")); v != "" {
+		return v != "0" && v != "false"
+	}
+	cfg, err := gitconfig.Load(repo)
+	if err != nil {
+		return false
+	}
+	return gitconfig.GetBool(cfg, "go-githooks", "", "guiLog", false)
+}
+
+// Path is the log of the last hook run, .git/go-githooks/last-run.log, or "" for
+// repos which are not stored on disk
+func Path(repo *git.Repository) string {
+	if s, ok := repo.Storer.(*filesystem.Storage); ok {
+		return filepath.Join(s.Filesystem().Root(), "go-githooks", "last-run.log")
+	}
+	return ""
+}
+
+// Start mirrors everything hook writes to stdout and stderr into the log, replacing
+// the output of the hook run before it, when Enabled; Stop must be called before exiting
+func Start(repo *git.Repository, hook string) error {
+	path := Path(repo)
+	if active != nil || path == "" || !Enabled(repo) {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("could not create '%s': %v", filepath.Dir(path), err)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("could not create '%s': %v", path, err)
+	}
+	fmt.Fprintf(f, "%s %s %s\n", hook, strings.Join(os.Args[1:], " "), time.Now().Format(time.RFC3339))
+
+	c := &capture{path: path, log: f, stdout: os.Stdout, stderr: os.Stderr}
+	var mu sync.Mutex
+	mirror := func(to *os.File) (*os.File, error) {
+		r, w, err := os.Pipe()
+		if err != nil {
+			return nil, err
+		}
+		c.pipes = append(c.pipes, w)
+		c.wg.Add(1)
+		go func() {
+			defer c.wg.Done()
+			buf := make([]byte, 4096)
+			for {
+				n, err := r.Read(buf)
+				if n > 0 {
+					_, _ = to.Write(buf[:n])
+					mu.Lock()
+					_, _ = f.Write(buf[:n])
+					mu.Unlock()
+				}
+				if err != nil {
+					_ = r.Close()
+					return
+				}
+			}
+		}()
+		return w, nil
+	}
+
+	stdout, err := mirror(os.Stdout)
+	if err != nil {
+		_ = f.Close()
+		return fmt.Errorf("could not capture output: %v", err)
+	}
+	stderr, err := mirror(os.Stderr)
+	if err != nil {
+		_ = stdout.Close()
+		_ = f.Close()
+		return fmt.Errorf("could not capture output: %v", err)
+	}
+	os.Stdout, os.Stderr = stdout, stderr
+	active = c
+	return nil
+}
+
+// Stop restores stdout and stderr and finishes writing the log; it does nothing
+// when output is not being captured
+func Stop() {
+	c := active
+	if c == nil {
+		return
+	}
+	active = nil
+	os.Stdout, os.Stderr = c.stdout, c.stderr
+	for _, w := range c.pipes {
+		_ = w.Close()
+	}
+	c.wg.Wait()
+	_ = c.log.Close()
+}
+
+// Block stops capturing and, since GUI clients show the message file when a commit
+// fails more often than hook output, adds the end of the output to it as comments
+func Block(msgFile string, err error) error {
+	c := active
+	if c == nil || err == nil {
+		return nil
+	}
+	Stop()
+
+	logged, readErr := fileio.ReadFile(c.path)
+	if readErr != nil {
+		return readErr
+	}
+	msg, readErr := fileio.ReadFile(msgFile)
+	if readErr != nil {
+		return readErr
+	}
+	return fileio.ReplaceFile(msgFile, appendReason(msg, logged, err, c.path), 0644)
+}
+
+func appendReason(msg, logged []byte, err error, path string) []byte {
+	lines := make([]string, 0)
+	for _, l := range strings.Split(string(logged), "\n")[1:] {
+		if l = strings.TrimRight(l, " \t\r"); strings.TrimSpace(l) != "" {
+			lines = append(lines, l)
+		}
+	}
+	lines = append(lines, err.Error())
+	if len(lines) > BlockLines {
+		lines = lines[len(lines)-BlockLines:]
+	}
+
+	var b bytes.Buffer
+	b.Write(bytes.TrimRight(msg, "\n"))
+	b.WriteString("\n\n# go-githooks blocked this commit:\n")
+	for _, l := range lines {
+		b.WriteString("#   " + l + "\n")
+	}
+	fmt.Fprintf(&b, "# (the full output is in %s)\n", path)
+	return b.Bytes()
+}
@@ -0,0 +1,39 @@
+package guilog
+
+import (
+	"fmt"
+	"github.com/go-git/go-git/v5"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCapture(t *testing.T) {
+	dir := t.TempDir()
+	repo, _ := git.PlainInit(dir, false)
+	msgFile := filepath.Join(dir, ".git", "COMMIT_EDITMSG")
+	_ = ioutil.WriteFile(msgFile, []byte("add login\n"), 0644)
+
+	assert.NoError(t, Start(repo, "commit-msg"))
+	assert.Nil(t, active, "off unless asked for")
+
+	defer os.Unsetenv("GIT_HOOKS_GUI_LOG")
+	_ = os.Setenv("GIT_HOOKS_GUI_LOG", "1")
+	assert.NoError(t, Start(repo, "commit-msg"))
+	fmt.Println("error  ticket-reference  message  the message does not reference a ticket")
+	fmt.Fprintln(os.Stderr, "a warning")
+	assert.NoError(t, Block(msgFile, fmt.Errorf("the commit message does not meet this repo's rules")))
+	assert.Nil(t, active)
+
+	logged, _ := ioutil.ReadFile(Path(repo))
+	assert.Contains(t, string(logged), "the message does not reference a ticket\n")
+	assert.Contains(t, string(logged), "a warning\n")
+
+	msg, _ := ioutil.ReadFile(msgFile)
+	assert.Contains(t, string(msg), "add login\n\n# go-githooks blocked this commit:\n")
+	assert.Contains(t, string(msg), "#   error  ticket-reference  message  the message does not reference a ticket\n")
+	assert.Contains(t, string(msg), "#   a warning\n", "stdout and stderr may interleave either way")
+	assert.Contains(t, string(msg), "#   the commit message does not meet this repo's rules\n# (the full output is in "+Path(repo)+")\n")
+}
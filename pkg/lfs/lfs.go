@@ -0,0 +1,73 @@
+package lfs
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/davidalpert/go-githooks/pkg/attributes"
+	"github.com/davidalpert/go-githooks/pkg/rules"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"io/ioutil"
+)
+
+const (
+	// Rule is the name under which LFS violations are reported and configured
+	Rule = "lfs-pointer"
+
+	// pointers are small text files; anything larger is real content
+	maxPointerSize = 1024
+)
+
+var pointerHeader = []byte("version https://git-lfs.github.com/spec/v1")
+
+// IsPointer reports whether contents is a Git LFS pointer file
+func IsPointer(contents []byte) bool {
+	return len(contents) < maxPointerSize && bytes.HasPrefix(contents, pointerHeader)
+}
+
+// CheckBlob compares how path is stored against whether .gitattributes routes it through LFS
+// (filter=lfs); only blobs small enough to be pointers are read
+func CheckBlob(repo *git.Repository, attrs *attributes.Attributes, path string, hash plumbing.Hash) (*rules.Violation, error) {
+	blob, err := repo.BlobObject(hash)
+	if err != nil {
+		return nil, fmt.Errorf("could not read '%s': %v", path, err)
+	}
+
+	pointer := false
+	if blob.Size < maxPointerSize {
+		r, err := blob.Reader()
+		if err != nil {
+			return nil, fmt.Errorf("could not read '%s': %v", path, err)
+		}
+		contents, err := ioutil.ReadAll(r)
+		r.Close()
+		if err != nil {
+			return nil, fmt.Errorf("could not read '%s': %v", path, err)
+		}
+		pointer = IsPointer(contents)
+	}
+
+	return Check(attrs, path, pointer, blob.Size), nil
+}
+
+// Check reports a violation when path is stored differently from how .gitattributes says it should be
+func Check(attrs *attributes.Attributes, path string, pointer bool, size int64) *rules.Violation {
+	tracked := attrs.Value(path, "filter") == "lfs"
+	switch {
+	case tracked && !pointer:
+		return &rules.Violation{
+			Rule:     Rule,
+			Severity: rules.Error,
+			Location: path,
+			Message:  fmt.Sprintf("is tracked by LFS (filter=lfs) but %d bytes of content were added instead of a pointer; run 'git lfs install' and add it again", size),
+		}
+	case !tracked && pointer:
+		return &rules.Violation{
+			Rule:     Rule,
+			Severity: rules.Error,
+			Location: path,
+			Message:  "is an LFS pointer but .gitattributes does not track it with filter=lfs; run 'git lfs track' for it",
+		}
+	}
+	return nil
+}
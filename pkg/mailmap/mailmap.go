@@ -0,0 +1,142 @@
+package mailmap
+
+import (
+	"bufio"
+	"bytes"
+	"github.com/davidalpert/go-githooks/pkg/fileio"
+	"github.com/davidalpert/go-githooks/pkg/gitconfig"
+	"github.com/davidalpert/go-githooks/pkg/message"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// File is where git looks for a repo's mailmap
+const File = ".mailmap"
+
+// entry is one line of a mailmap; an empty field is not changed (proper) or
+// matches any value (commit)
+type entry struct {
+	properName, properEmail string
+	commitName, commitEmail string
+}
+
+// Mailmap maps the names and emails people commit with to their canonical
+// identity, like 'git log --use-mailmap'; a nil Mailmap maps nothing
+type Mailmap struct {
+	entries []entry
+}
+
+var identityRe = regexp.MustCompile(`\s*([^<#]*?)\s*<([^>]*)>`)
+
+// Parse reads the lines of a mailmap file:
+//
+//	Proper Name <commit@email>
+//	<proper@email> <commit@email>
+//	Proper Name <proper@email> <commit@email>
+//	Proper Name <proper@email> Commit Name <commit@email>
+func Parse(data []byte) *Mailmap {
+	m := &Mailmap{}
+	s := bufio.NewScanner(bytes.NewReader(data))
+	for s.Scan() {
+		line := s.Text()
+		if i := strings.Index(line, "#"); i >= 0 && !strings.Contains(line[:i], "<") {
+			continue
+		}
+		ids := identityRe.FindAllStringSubmatch(line, 2)
+		switch len(ids) {
+		case 1:
+			m.entries = append(m.entries, entry{properName: ids[0][1], commitEmail: ids[0][2]})
+		case 2:
+			m.entries = append(m.entries, entry{
+				properName: ids[0][1], properEmail: ids[0][2],
+				commitName: ids[1][1], commitEmail: ids[1][2],
+			})
+		}
+	}
+	return m
+}
+
+// Load reads the repo's .mailmap and the file named by mailmap.file in cfg, if
+// any; a repo without either gets an empty mailmap
+func Load(repo *git.Repository, cfg *config.Config) (*Mailmap, error) {
+	m := &Mailmap{}
+	paths := make([]string, 0)
+	if f := gitconfig.GetString(cfg, "mailmap", "", "file", ""); f != "" {
+		paths = append(paths, expandHome(f))
+	}
+	if w, err := repo.Worktree(); err == nil {
+		// entries in the repo's own file win, as in git
+		paths = append(paths, filepath.Join(w.Filesystem.Root(), File))
+	}
+
+	for _, p := range paths {
+		data, err := fileio.ReadFile(p)
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return m, err
+		}
+		m.entries = append(m.entries, Parse(data).entries...)
+	}
+	return m, nil
+}
+
+func expandHome(p string) string {
+	if strings.HasPrefix(p, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, p[2:])
+		}
+	}
+	return p
+}
+
+// Map returns the canonical name and email for an identity; the last matching
+// entry wins and entries naming the commit name are preferred, as in git
+func (m *Mailmap) Map(name, email string) (string, string) {
+	if m == nil {
+		return name, email
+	}
+	var match *entry
+	for i := range m.entries {
+		e := &m.entries[i]
+		if !strings.EqualFold(e.commitEmail, email) {
+			continue
+		}
+		if e.commitName != "" && !strings.EqualFold(e.commitName, name) {
+			continue
+		}
+		if match == nil || e.commitName != "" || match.commitName == "" {
+			match = e
+		}
+	}
+	if match == nil {
+		return name, email
+	}
+	if match.properName != "" {
+		name = match.properName
+	}
+	if match.properEmail != "" {
+		email = match.properEmail
+	}
+	return name, email
+}
+
+// Coauthor maps a Co-authored-by trailer to its canonical identity
+func (m *Mailmap) Coauthor(c message.Coauthor) message.Coauthor {
+	c.Name, c.Email = m.Map(c.Name, c.Email)
+	return c
+}
+
+// Identity maps a 'Name <email>' trailer value; other values are returned as is
+func (m *Mailmap) Identity(value string) string {
+	id := identityRe.FindStringSubmatch(value)
+	if id == nil || strings.TrimSpace(value) != strings.TrimSpace(id[0]) {
+		return value
+	}
+	name, email := m.Map(id[1], id[2])
+	return name + " <" + email + ">"
+}
@@ -0,0 +1,42 @@
+package mailmap
+
+import (
+	"github.com/davidalpert/go-githooks/pkg/message"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestMap(t *testing.T) {
+	m := Parse([]byte(`# crew of the Serenity
+Malcolm Reynolds <mal@serenity.com>
+<zoe@serenity.com> <zoe.alleyne@gmail.com>
+Kaylee Frye <kaylee@serenity.com> <kaylee@shiny.net>
+Hoban Washburne <wash@serenity.com> Wash <pilot@serenity.com>
+`))
+
+	tests := []struct {
+		name, email         string
+		wantName, wantEmail string
+	}{
+		{"Mal", "mal@serenity.com", "Malcolm Reynolds", "mal@serenity.com"},
+		{"Zoe", "ZOE.ALLEYNE@gmail.com", "Zoe", "zoe@serenity.com"},
+		{"kaylee", "kaylee@shiny.net", "Kaylee Frye", "kaylee@serenity.com"},
+		{"Wash", "pilot@serenity.com", "Hoban Washburne", "wash@serenity.com"},
+		{"Jayne", "pilot@serenity.com", "Jayne", "pilot@serenity.com"},
+		{"Inara", "inara@serenity.com", "Inara", "inara@serenity.com"},
+	}
+	for _, tt := range tests {
+		name, email := m.Map(tt.name, tt.email)
+		assert.Equal(t, tt.wantName, name, tt.email)
+		assert.Equal(t, tt.wantEmail, email, tt.email)
+	}
+
+	assert.Equal(t, "Kaylee Frye <kaylee@serenity.com>", m.Identity("kaylee <kaylee@shiny.net>"))
+	assert.Equal(t, "FEAT-1", m.Identity("FEAT-1"))
+	assert.Equal(t, message.Coauthor{Name: "Zoe", Email: "zoe@serenity.com"}, m.Coauthor(message.Coauthor{Name: "Zoe", Email: "zoe.alleyne@gmail.com"}))
+
+	var none *Mailmap
+	name, email := none.Map("Mal", "mal@serenity.com")
+	assert.Equal(t, "Mal", name)
+	assert.Equal(t, "mal@serenity.com", email)
+}
@@ -0,0 +1,70 @@
+package message
+
+import (
+	"bytes"
+	"strings"
+)
+
+// CleanupMode is how git tidies a message before recording it, as commit.cleanup
+// or 'git commit --cleanup' say
+type CleanupMode string
+
+const (
+	CleanupDefault    CleanupMode = "default"    // strip when the message was edited, whitespace otherwise
+	CleanupStrip      CleanupMode = "strip"      // drop comments, trailing whitespace and extra blank lines
+	CleanupWhitespace CleanupMode = "whitespace" // as strip, but keep the comments
+	CleanupVerbatim   CleanupMode = "verbatim"   // record the message as it is
+	CleanupScissors   CleanupMode = "scissors"   // as whitespace, but drop everything below the scissors line
+)
+
+func CleanupModeFromString(s string) CleanupMode {
+	switch m := CleanupMode(strings.ToLower(s)); m {
+	case CleanupStrip, CleanupWhitespace, CleanupVerbatim, CleanupScissors:
+		return m
+	}
+	return CleanupDefault
+}
+
+// ScissorsLine is the line below which git drops the rest of the message, e.g. the
+// diff 'git commit -v' shows
+func ScissorsLine(commentChar string) string {
+	return commentChar + " ------------------------ >8 ------------------------"
+}
+
+// Cleanup returns msg as git records it with mode; a default cleanup is taken to be
+// strip, since hooks mostly see messages written in an editor
+func Cleanup(msg []byte, mode CleanupMode, commentChar string) []byte {
+	if mode == CleanupVerbatim {
+		return msg
+	}
+	if commentChar == "" {
+		commentChar = "#"
+	}
+	if i := bytes.Index(msg, []byte(ScissorsLine(commentChar))); i >= 0 && (i == 0 || msg[i-1] == '\n') {
+		msg = msg[:i]
+	}
+
+	stripComments := mode == CleanupDefault || mode == CleanupStrip
+	lines := strings.Split(string(msg), "\n")
+	kept := make([]string, 0, len(lines))
+	blank := false
+	for _, l := range lines {
+		if stripComments && strings.HasPrefix(l, commentChar) {
+			continue
+		}
+		l = strings.TrimRight(l, " \t\r")
+		if l == "" {
+			blank = len(kept) > 0
+			continue
+		}
+		if blank {
+			kept = append(kept, "")
+			blank = false
+		}
+		kept = append(kept, l)
+	}
+	if len(kept) == 0 {
+		return empty
+	}
+	return []byte(strings.Join(kept, "\n") + "\n")
+}
@@ -0,0 +1,83 @@
+package message
+
+import (
+	"regexp"
+	"strings"
+)
+
+var coauthorRe = regexp.MustCompile(`(?im)^co-authored-by:[ \t]*([^<\n]*?)[ \t]*<([^>\n]+)>`)
+
+var creditRe = regexp.MustCompile(`(?im)^(co-authored-by|reviewed-by|tested-by|paired-with):[ \t]*([^<\n]*?)[ \t]*<([^>\n]+)>`)
+
+// The trailers which credit someone other than the author; a Coauthor without a
+// Role is credited as CoauthoredBy
+const (
+	CoauthoredBy = "Co-authored-by"
+	ReviewedBy   = "Reviewed-by"
+	TestedBy     = "Tested-by"
+	PairedWith   = "Paired-with"
+)
+
+// Roles are the trailers a coauthor can be credited with
+var Roles = []string{CoauthoredBy, ReviewedBy, TestedBy, PairedWith}
+
+// Coauthor is one Co-authored-by trailer, or another trailer crediting someone
+// in a Role
+type Coauthor struct {
+	Name  string
+	Email string
+	Role  string
+}
+
+// RoleFromString returns the role spelled as its trailer, ignoring case, or "" for
+// a role which is not one of Roles
+func RoleFromString(s string) string {
+	s = strings.TrimSuffix(strings.TrimSpace(s), ":")
+	for _, r := range Roles {
+		if strings.EqualFold(r, s) {
+			return r
+		}
+	}
+	return ""
+}
+
+func (c Coauthor) String() string {
+	role := c.Role
+	if role == "" {
+		role = CoauthoredBy
+	}
+	return role + ": " + c.Name + " <" + c.Email + ">"
+}
+
+// Domain returns the part of the email after the @, lower-cased
+func (c Coauthor) Domain() string {
+	if i := strings.LastIndex(c.Email, "@"); i >= 0 {
+		return strings.ToLower(c.Email[i+1:])
+	}
+	return ""
+}
+
+// Coauthors returns the Co-authored-by trailers of the message, ignoring git comments
+func Coauthors(msg []byte) []Coauthor {
+	content, _ := SplitComments(msg)
+	coauthors := make([]Coauthor, 0)
+	for _, m := range coauthorRe.FindAllSubmatch(content, -1) {
+		coauthors = append(coauthors, Coauthor{Name: string(m[1]), Email: strings.TrimSpace(string(m[2]))})
+	}
+	return coauthors
+}
+
+// Credits returns the trailers of the message crediting anyone in one of Roles,
+// ignoring git comments
+func Credits(msg []byte) []Coauthor {
+	content, _ := SplitComments(msg)
+	credits := make([]Coauthor, 0)
+	for _, m := range creditRe.FindAllSubmatch(content, -1) {
+		c := Coauthor{Name: string(m[2]), Email: strings.TrimSpace(string(m[3]))}
+		if role := RoleFromString(string(m[1])); role != CoauthoredBy {
+			c.Role = role
+		}
+		credits = append(credits, c)
+	}
+	return credits
+}
@@ -0,0 +1,85 @@
+package message
+
+import (
+	"bytes"
+	"strings"
+)
+
+/*
+ * Some editors write the message file with a UTF-8 byte order mark or with CRLF line
+ * endings, which throw off anything matching the start or the end of a line. Hooks
+ * Normalize the message as they read it, work on LF lines only, and Format it again
+ * as they write it back.
+ */
+
+var bom = []byte{0xEF, 0xBB, 0xBF}
+
+// LineEndings chooses how the lines of a message written back end
+type LineEndings string
+
+const (
+	PreserveLineEndings LineEndings = "preserve" // as they were read
+	LFLineEndings       LineEndings = "lf"
+	CRLFLineEndings     LineEndings = "crlf"
+)
+
+func LineEndingsFromString(s string) LineEndings {
+	switch e := LineEndings(strings.ToLower(s)); e {
+	case LFLineEndings, CRLFLineEndings:
+		return e
+	}
+	return PreserveLineEndings
+}
+
+// Encoding is how a message file was written
+type Encoding struct {
+	BOM  bool // started with a UTF-8 byte order mark
+	CRLF bool // had lines ending in CRLF
+}
+
+// Normalize strips a byte order mark, converts CRLF line endings to LF and collapses
+// trailing blank lines, returning msg itself when there is nothing to change
+func Normalize(msg []byte) ([]byte, Encoding) {
+	var e Encoding
+	if bytes.HasPrefix(msg, bom) {
+		e.BOM = true
+		msg = msg[len(bom):]
+	}
+	if bytes.Contains(msg, []byte("\r\n")) {
+		e.CRLF = true
+		msg = bytes.Replace(msg, []byte("\r\n"), []byte("\n"), -1)
+	}
+	return TrimTrailingBlankLines(msg), e
+}
+
+// Format writes a normalized msg back with endings, where preserve uses the line
+// endings e was read with, and with e's byte order mark when keepBOM is set
+func (e Encoding) Format(msg []byte, endings LineEndings, keepBOM bool) []byte {
+	msg = TrimTrailingBlankLines(msg)
+	if endings == CRLFLineEndings || (endings == PreserveLineEndings && e.CRLF) {
+		msg = bytes.Replace(msg, []byte("\n"), []byte("\r\n"), -1)
+	}
+	if keepBOM && e.BOM {
+		msg = append(append(make([]byte, 0, len(bom)+len(msg)), bom...), msg...)
+	}
+	return msg
+}
+
+// TrimTrailingBlankLines leaves at most one blank line, and no whitespace, after the
+// last line of msg with any text on it; a message below a scissors line is left as
+// it is, since trailing whitespace is part of the diff there
+func TrimTrailingBlankLines(msg []byte) []byte {
+	trimmed := bytes.TrimRight(msg, " \t\r\n")
+	if len(trimmed) == 0 || bytes.Contains(msg, []byte(" ------------------------ >8 ------------------------\n")) {
+		return msg
+	}
+	tail := msg[len(trimmed):]
+	n := bytes.Count(tail, []byte("\n"))
+	if n > 2 {
+		n = 2
+	}
+	if len(tail) == n {
+		return msg
+	}
+	return append(trimmed[:len(trimmed):len(trimmed)], bytes.Repeat([]byte("\n"), n)...)
+}
@@ -0,0 +1,227 @@
+package message
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+)
+
+var (
+	empty = []byte("")
+	nl    = []byte("\n")
+
+	trailerLineRe        = regexp.MustCompile(`^[A-Za-z0-9-]+: `)
+	conventionalHeaderRe = regexp.MustCompile(`^[a-z]+(\([^)]+\))?!?: `)
+)
+
+// SplitComments separates the message from the block of git comments which follows it
+func SplitComments(msg []byte) ([]byte, []byte) {
+	lines := bytes.SplitAfter(msg, nl)
+	pos := 0
+	for _, line := range lines {
+		if bytes.HasPrefix(line, []byte("#")) {
+			return msg[:pos], msg[pos:]
+		}
+		pos += len(line)
+	}
+	return msg, empty
+}
+
+// Subject returns the first line of the message
+func Subject(msg []byte) string {
+	content, _ := SplitComments(msg)
+	return strings.TrimSpace(string(bytes.SplitN(bytes.TrimSpace(content), nl, 2)[0]))
+}
+
+// Autosquash returns the "fixup!", "squash!" or "amend!" subject starts with, or "";
+// git commit --fixup and --squash copy the target's subject after it verbatim, which
+// is how git rebase --autosquash finds the commit to fold it into
+func Autosquash(subject string) string {
+	for _, marker := range []string{"fixup!", "squash!", "amend!"} {
+		if strings.HasPrefix(subject, marker+" ") {
+			return marker
+		}
+	}
+	return ""
+}
+
+// AppendTrailer adds trailer to the trailer block at the end of msg, starting a new
+// block when the last paragraph is not already made up of trailers
+func AppendTrailer(msg []byte, trailer string) []byte {
+	content, comments := SplitComments(msg)
+	content = bytes.TrimSpace(content)
+
+	if bytes.Contains(append(append(nl, content...), nl...), []byte("\n"+trailer+"\n")) {
+		return msg
+	}
+
+	separator := append(nl, nl...)
+	if EndsWithTrailerBlock(content) {
+		separator = nl
+	}
+
+	// an empty message keeps its first line free for the subject
+	updated := append(make([]byte, 0), content...)
+	updated = append(updated, separator...)
+	updated = append(updated, bytes.Join([][]byte{
+		[]byte(trailer), nl,
+		nl,
+		comments,
+	}, empty)...)
+
+	return updated
+}
+
+// InsertComment adds comment lines at the top of the block of git comments
+func InsertComment(msg []byte, lines ...string) []byte {
+	content, comments := SplitComments(msg)
+	content = bytes.TrimRight(content, "\n")
+
+	var b bytes.Buffer
+	b.Write(content)
+	b.Write(nl)
+	b.Write(nl)
+	for _, l := range lines {
+		b.WriteString("# " + l)
+		b.Write(nl)
+	}
+	if len(comments) > 0 {
+		b.Write(nl)
+		b.Write(comments)
+	}
+	return b.Bytes()
+}
+
+// AppendParagraph adds lines as a paragraph at the end of the body, before the
+// trailer block and the git comments
+func AppendParagraph(msg []byte, lines ...string) []byte {
+	content, comments := SplitComments(msg)
+	paragraphs := bytes.Split(bytes.TrimSpace(content), []byte("\n\n"))
+	var trailers []byte
+	if len(paragraphs) > 1 && isTrailerParagraph(paragraphs[len(paragraphs)-1]) {
+		trailers = paragraphs[len(paragraphs)-1]
+		paragraphs = paragraphs[:len(paragraphs)-1]
+	}
+	paragraphs = append(paragraphs, []byte(strings.Join(lines, "\n")))
+	if trailers != nil {
+		paragraphs = append(paragraphs, trailers)
+	}
+
+	var b bytes.Buffer
+	b.Write(bytes.Join(paragraphs, []byte("\n\n")))
+	b.Write(nl)
+	if len(comments) > 0 {
+		b.Write(nl)
+		b.Write(comments)
+	}
+	return b.Bytes()
+}
+
+// Tickets are the distinct matches of ticketRe in msg, leaving out git comments, in
+// the order they first appear
+func Tickets(msg []byte, ticketRe *regexp.Regexp) []string {
+	content, _ := SplitComments(msg)
+	tickets := make([]string, 0)
+	seen := map[string]bool{}
+	for _, t := range ticketRe.FindAllString(string(content), -1) {
+		if !seen[t] {
+			seen[t] = true
+			tickets = append(tickets, t)
+		}
+	}
+	return tickets
+}
+
+// EndsWithTrailerBlock reports whether the last paragraph of content (other than the subject) is made up of trailers
+func EndsWithTrailerBlock(content []byte) bool {
+	paragraphs := bytes.Split(content, []byte("\n\n"))
+	if len(paragraphs) < 2 {
+		return false
+	}
+	return isTrailerParagraph(paragraphs[len(paragraphs)-1])
+}
+
+func isTrailerParagraph(p []byte) bool {
+	lines := bytes.Split(bytes.TrimSpace(p), nl)
+	for _, line := range lines {
+		if !trailerLineRe.Match(line) {
+			return false
+		}
+	}
+	return len(lines) > 0
+}
+
+// PrefixRegexp matches a subject which starts with the prefix rendered from
+// template, capturing the value that was substituted for %s
+func PrefixRegexp(template string) (*regexp.Regexp, error) {
+	pattern := strings.Replace(regexp.QuoteMeta(template), "%s", `(\S+?)`, 1)
+	return regexp.Compile(`^` + pattern)
+}
+
+// SplitConventionalHeader splits subject into its conventional 'type(scope)!: '
+// header, which is "" when there is none, and the rest of the subject
+func SplitConventionalHeader(subject string) (string, string) {
+	header := conventionalHeaderRe.FindString(subject)
+	return header, subject[len(header):]
+}
+
+// ConventionalHeaderLen is the length of the 'type(scope)!: ' header msg starts with,
+// or 0 when there is none; unlike SplitConventionalHeader it copies nothing
+func ConventionalHeaderLen(msg []byte) int {
+	if loc := conventionalHeaderRe.FindIndex(msg); loc != nil {
+		return loc[1]
+	}
+	return 0
+}
+
+// FindPrefix matches the prefix rendered from template at the start of subject or
+// right after its conventional header, returning the submatches of PrefixRegexp
+func FindPrefix(subject, template string) []string {
+	re, err := PrefixRegexp(template)
+	if err != nil {
+		return nil
+	}
+	if m := re.FindStringSubmatch(subject); m != nil {
+		return m
+	}
+	_, rest := SplitConventionalHeader(subject)
+	return re.FindStringSubmatch(rest)
+}
+
+// StripPrefix removes the prefix rendered from template from the start of subject
+// or from right after its conventional header
+func StripPrefix(subject, template string) string {
+	re, err := PrefixRegexp(template)
+	if err != nil {
+		return subject
+	}
+	if re.MatchString(subject) {
+		return strings.TrimSpace(re.ReplaceAllString(subject, ""))
+	}
+	header, rest := SplitConventionalHeader(subject)
+	if header != "" && re.MatchString(rest) {
+		return header + strings.TrimSpace(re.ReplaceAllString(rest, ""))
+	}
+	return subject
+}
+
+// Effective returns what the author actually wrote: the message without git
+// comments, without a trailing block of trailers, and without a branch prefix
+// rendered from prefixTemplate. The first paragraph is the subject even when it
+// looks like a trailer, e.g. 'feat: add login', unless the message starts with a
+// blank line, as one with only trailers added to it does
+func Effective(msg []byte, prefixTemplate string) string {
+	content, _ := SplitComments(msg)
+	content = bytes.TrimRight(content, " \t\r\n")
+	paragraphs := bytes.Split(content, []byte("\n\n"))
+	lastIsSubject := len(paragraphs) == 1 && !bytes.HasPrefix(content, nl)
+	if !lastIsSubject && isTrailerParagraph(paragraphs[len(paragraphs)-1]) {
+		paragraphs = paragraphs[:len(paragraphs)-1]
+	}
+
+	effective := strings.TrimSpace(string(bytes.Join(paragraphs, []byte("\n\n"))))
+	if prefixTemplate != "" {
+		effective = StripPrefix(effective, prefixTemplate)
+	}
+	return effective
+}
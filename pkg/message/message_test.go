@@ -0,0 +1,145 @@
+package message
+
+import (
+	"github.com/stretchr/testify/assert"
+	"regexp"
+	"testing"
+)
+
+func TestEffective(t *testing.T) {
+	tests := []struct {
+		name string
+		msg  string
+		want string
+	}{
+		{name: "empty", msg: "", want: ""},
+		{name: "prefix only", msg: "[FEAT-1] \n\n", want: ""},
+		{name: "prefix and trailers", msg: "[FEAT-1] \n\nCo-authored-by: Mal Reynolds <mal@serenity.com>\n", want: ""},
+		{name: "trailers only", msg: "\n\nCo-authored-by: Mal Reynolds <mal@serenity.com>\n\n# git comments\n", want: ""},
+		{name: "subject", msg: "[FEAT-1] do something awesome\n\nCo-authored-by: Mal Reynolds <mal@serenity.com>\n", want: "do something awesome"},
+		{name: "subject without prefix", msg: "do something awesome\n# git comments\n", want: "do something awesome"},
+		{name: "prefix after conventional type", msg: "feat(auth): [FEAT-1] do something awesome\n", want: "feat(auth): do something awesome"},
+		{name: "trailers after a blank subject", msg: "\nCo-authored-by: Mal Reynolds <mal@serenity.com>\n", want: ""},
+		{name: "conventional subject", msg: "feat: add login\n", want: "feat: add login"},
+		{name: "subject like a trailer", msg: "Docs: x\n# git comments\n", want: "Docs: x"},
+		{name: "conventional subject and trailers", msg: "fix: typo\n\nCo-authored-by: Mal Reynolds <mal@serenity.com>\n", want: "fix: typo"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Effective([]byte(tt.msg), "[%s]"))
+		})
+	}
+}
+
+func TestStripPrefix(t *testing.T) {
+	assert.Equal(t, "feat: add login", StripPrefix("[FEAT-1] feat: add login", "[%s]"))
+	assert.Equal(t, "feat(auth)!: add login", StripPrefix("feat(auth)!: [FEAT-1] add login", "[%s]"))
+	assert.Equal(t, "add login [FEAT-1]", StripPrefix("add login [FEAT-1]", "[%s]"))
+	assert.Equal(t, []string{"[FEAT-1]", "FEAT-1"}, FindPrefix("fix: [FEAT-1] add login", "[%s]"))
+	assert.Nil(t, FindPrefix("fix: add login", "[%s]"))
+}
+
+func TestAutosquash(t *testing.T) {
+	assert.Equal(t, "fixup!", Autosquash("fixup! [FEAT-1] add login"))
+	assert.Equal(t, "squash!", Autosquash("squash! fixup! add login"))
+	assert.Empty(t, Autosquash("fixup!add login"))
+	assert.Empty(t, Autosquash("[FEAT-1] fixup! add login"))
+}
+
+func TestAppendTrailer(t *testing.T) {
+	assert.Equal(t, "do something\n\nRefs: FEAT-1\n\n", string(AppendTrailer([]byte("do something\n"), "Refs: FEAT-1")))
+	assert.Equal(t, "do something\n\nRefs: FEAT-1\nChange-Id: I1\n\n# comment\n", string(AppendTrailer([]byte("do something\n\nRefs: FEAT-1\n# comment\n"), "Change-Id: I1")))
+	assert.Equal(t, "do something\n\nRefs: FEAT-1\n", string(AppendTrailer([]byte("do something\n\nRefs: FEAT-1\n"), "Refs: FEAT-1")))
+}
+
+func TestInsertComment(t *testing.T) {
+	assert.Equal(t, "[FEAT-1] \n\n# note\n\n# git comments\n", string(InsertComment([]byte("[FEAT-1] \n\n# git comments\n"), "note")))
+	assert.Equal(t, "do something\n\n# note\n", string(InsertComment([]byte("do something\n"), "note")))
+}
+
+func TestAppendParagraph(t *testing.T) {
+	assert.Equal(t, "Merge branch 'ABC-1'\n\nTickets:\n- ABC-1\n\n# Conflicts:\n", string(AppendParagraph([]byte("Merge branch 'ABC-1'\n\n# Conflicts:\n"), "Tickets:", "- ABC-1")))
+	assert.Equal(t, "v1.2.0\n\nnotes\n\nSigned-off-by: Mal Reynolds <mal@serenity.com>\n", string(AppendParagraph([]byte("v1.2.0\n\nSigned-off-by: Mal Reynolds <mal@serenity.com>\n"), "notes")))
+}
+
+func TestTickets(t *testing.T) {
+	re := regexp.MustCompile(`[A-Z]+-[0-9]+`)
+	assert.Equal(t, []string{"ABC-1", "ABC-2"}, Tickets([]byte("ABC-1 fix login\n\nsee ABC-2 and ABC-1\n# not XYZ-3\n"), re))
+}
+
+func TestCoauthors(t *testing.T) {
+	msg := []byte("do something\n\nCo-authored-by: Mal Reynolds <mal@serenity.com>\nco-authored-by:Zoe Washburne <zoe@Serenity.com>\n\n# Co-authored-by: River Tam <river@serenity.com>\n")
+	coauthors := Coauthors(msg)
+	assert.Equal(t, []Coauthor{
+		{Name: "Mal Reynolds", Email: "mal@serenity.com"},
+		{Name: "Zoe Washburne", Email: "zoe@Serenity.com"},
+	}, coauthors)
+	assert.Equal(t, "serenity.com", coauthors[1].Domain())
+}
+
+func TestCredits(t *testing.T) {
+	msg := []byte("do something\n\nCo-authored-by: Mal Reynolds <mal@serenity.com>\nreviewed-by: Zoe Washburne <zoe@serenity.com>\nPaired-with: River Tam <river@serenity.com>\n\n# Tested-by: Wash <wash@serenity.com>\n")
+	credits := Credits(msg)
+	assert.Equal(t, []Coauthor{
+		{Name: "Mal Reynolds", Email: "mal@serenity.com"},
+		{Name: "Zoe Washburne", Email: "zoe@serenity.com", Role: ReviewedBy},
+		{Name: "River Tam", Email: "river@serenity.com", Role: PairedWith},
+	}, credits)
+	assert.Equal(t, "Reviewed-by: Zoe Washburne <zoe@serenity.com>", credits[1].String())
+	assert.Len(t, Coauthors(msg), 1, "only Co-authored-by trailers are coauthors")
+	assert.Equal(t, TestedBy, RoleFromString("tested-by"))
+	assert.Equal(t, "", RoleFromString("Signed-off-by"))
+}
+
+func TestTrailers(t *testing.T) {
+	msg := []byte("do something\n\nCo-authored-by: Mal Reynolds <mal@serenity.com>\nRefs: FEAT-1\nco-authored-by: Mal Reynolds <mal@serenity.com>\n\n# git comments\n")
+	assert.Equal(t, []Trailer{
+		{Key: "Co-authored-by", Value: "Mal Reynolds <mal@serenity.com>"},
+		{Key: "Refs", Value: "FEAT-1"},
+		{Key: "co-authored-by", Value: "Mal Reynolds <mal@serenity.com>"},
+	}, Trailers(msg))
+
+	assert.Equal(t, "do something\n\nCo-authored-by: Mal Reynolds <mal@serenity.com>\nRefs: FEAT-1\n\n# git comments\n", string(DedupeTrailers(msg)))
+	assert.Nil(t, Trailers([]byte("Refs: FEAT-1\n")), "a subject is never a trailer")
+}
+
+func TestCleanup(t *testing.T) {
+	msg := "\n\nfix login  \n\n\n\nwith a body\n# a comment\n\n" + ScissorsLine("#") + "\ndiff --git a/a.txt b/a.txt\n"
+	tests := []struct {
+		mode CleanupMode
+		want string
+	}{
+		{CleanupDefault, "fix login\n\nwith a body\n"},
+		{CleanupStrip, "fix login\n\nwith a body\n"},
+		{CleanupWhitespace, "fix login\n\nwith a body\n# a comment\n"},
+		{CleanupScissors, "fix login\n\nwith a body\n# a comment\n"},
+		{CleanupVerbatim, msg},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, string(Cleanup([]byte(msg), tt.mode, "#")), string(tt.mode))
+	}
+
+	assert.Equal(t, "fix login\n# not a comment\n", string(Cleanup([]byte("fix login\n; a comment\n# not a comment\n"), CleanupStrip, ";")))
+	assert.Equal(t, "", string(Cleanup([]byte("# only comments\n"), CleanupStrip, "#")))
+	assert.Equal(t, CleanupScissors, CleanupModeFromString("Scissors"))
+	assert.Equal(t, CleanupDefault, CleanupModeFromString(""))
+}
+
+func TestNormalize(t *testing.T) {
+	msg, e := Normalize([]byte("\xEF\xBB\xBFfix login\r\n\r\nwith a body\r\n\r\n\r\n  \r\n"))
+	assert.Equal(t, "fix login\n\nwith a body\n\n", string(msg))
+	assert.Equal(t, Encoding{BOM: true, CRLF: true}, e)
+
+	assert.Equal(t, "\xEF\xBB\xBFfix login\r\n\r\nwith a body\r\n\r\n", string(e.Format(msg, PreserveLineEndings, true)))
+	assert.Equal(t, "fix login\n\nwith a body\n\n", string(e.Format(msg, LFLineEndings, false)))
+
+	plain := []byte("fix login\n\n# a comment\n")
+	msg, e = Normalize(plain)
+	assert.Equal(t, Encoding{}, e)
+	assert.Equal(t, &plain[0], &msg[0], "nothing to change, nothing copied")
+	assert.Equal(t, "fix login\r\n\r\n# a comment\r\n", string(e.Format(msg, CRLFLineEndings, true)))
+
+	verbose := "fix login\n# " + ScissorsLine("#")[2:] + "\ndiff --git a/a.txt b/a.txt\n \n \n\n\n"
+	msg, _ = Normalize([]byte(verbose))
+	assert.Equal(t, verbose, string(msg), "the diff below the scissors is left as it is")
+}
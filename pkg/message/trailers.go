@@ -0,0 +1,72 @@
+package message
+
+import (
+	"bytes"
+	"strings"
+)
+
+// Trailer is one 'Key: value' line of the trailer block at the end of a message
+type Trailer struct {
+	Key   string
+	Value string
+}
+
+func (t Trailer) String() string {
+	return t.Key + ": " + t.Value
+}
+
+// Trailers returns the trailers in the last paragraph of msg, if it is a trailer block
+func Trailers(msg []byte) []Trailer {
+	content, _ := SplitComments(msg)
+	content = bytes.TrimSpace(content)
+	if !EndsWithTrailerBlock(content) {
+		return nil
+	}
+
+	paragraphs := bytes.Split(content, []byte("\n\n"))
+	trailers := make([]Trailer, 0)
+	for _, line := range bytes.Split(paragraphs[len(paragraphs)-1], nl) {
+		parts := strings.SplitN(string(line), ":", 2)
+		if len(parts) == 2 {
+			trailers = append(trailers, Trailer{Key: strings.TrimSpace(parts[0]), Value: strings.TrimSpace(parts[1])})
+		}
+	}
+	return trailers
+}
+
+// DedupeTrailers removes repeated lines from the trailer block at the end of msg,
+// keeping the first of each; keys compare case-insensitively
+func DedupeTrailers(msg []byte) []byte {
+	return DedupeTrailersBy(msg, func(t Trailer) string { return t.Value })
+}
+
+// DedupeTrailersBy is DedupeTrailers comparing values as normalized by value, e.g.
+// to treat two emails of the same person as one
+func DedupeTrailersBy(msg []byte, value func(Trailer) string) []byte {
+	content, comments := SplitComments(msg)
+	trimmed := bytes.TrimRight(content, "\n")
+	if !EndsWithTrailerBlock(trimmed) {
+		return msg
+	}
+
+	i := bytes.LastIndex(trimmed, []byte("\n\n"))
+	seen := map[string]bool{}
+	lines := make([][]byte, 0)
+	for _, line := range bytes.Split(trimmed[i+2:], nl) {
+		parts := strings.SplitN(string(line), ":", 2)
+		t := Trailer{Key: strings.TrimSpace(parts[0]), Value: strings.TrimSpace(parts[len(parts)-1])}
+		key := strings.ToLower(t.Key) + ":" + value(t)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		lines = append(lines, line)
+	}
+
+	var b bytes.Buffer
+	b.Write(trimmed[:i+2])
+	b.Write(bytes.Join(lines, nl))
+	b.Write(content[len(trimmed):])
+	b.Write(comments)
+	return b.Bytes()
+}
@@ -0,0 +1,74 @@
+package mobsession
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/davidalpert/go-githooks/pkg/fileio"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Session remembers the last set of coauthors seen and since when it has been the
+// same; git-mob does not record when the mob was last changed
+type Session struct {
+	Coauthors []string  `json:"coauthors"` // lower-cased emails, sorted
+	Since     time.Time `json:"since"`
+}
+
+// StorePath is where the session is kept; override with GIT_HOOKS_MOB_SESSION_FILE
+func StorePath() string {
+	if f := os.Getenv("GIT_HOOKS_MOB_SESSION_FILE"); f != "" {
+		return f
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		dir = "."
+	}
+	return filepath.Join(dir, "go-githooks", "mob-session.json")
+}
+
+// Touch records the coauthors of a commit made at now and returns since when the
+// mob has been made up of them
+func Touch(path string, emails []string, now time.Time) (time.Time, error) {
+	current := normalize(emails)
+
+	var s Session
+	data, err := fileio.ReadFile(path)
+	if err == nil {
+		if err := json.Unmarshal(data, &s); err != nil {
+			// a damaged file only costs the history of the current mob
+			s = Session{}
+		}
+	} else if !os.IsNotExist(err) {
+		return now, fmt.Errorf("could not read '%s': %v", path, err)
+	}
+
+	if strings.Join(s.Coauthors, ",") == strings.Join(current, ",") && !s.Since.IsZero() {
+		return s.Since, nil
+	}
+
+	s = Session{Coauthors: current, Since: now.UTC()}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return now, fmt.Errorf("could not create '%s': %v", filepath.Dir(path), err)
+	}
+	data, err = json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return now, err
+	}
+	if err := fileio.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return now, fmt.Errorf("could not write '%s': %v", path, err)
+	}
+	return s.Since, nil
+}
+
+func normalize(emails []string) []string {
+	n := make([]string, 0, len(emails))
+	for _, e := range emails {
+		n = append(n, strings.ToLower(strings.TrimSpace(e)))
+	}
+	sort.Strings(n)
+	return n
+}
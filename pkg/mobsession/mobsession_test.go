@@ -0,0 +1,52 @@
+package mobsession
+
+import (
+	"github.com/stretchr/testify/assert"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestTouch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "go-githooks", "mob-session.json")
+	start := time.Date(2021, 6, 1, 9, 0, 0, 0, time.UTC)
+
+	since, err := Touch(path, []string{"zoe@serenity.com", "wash@serenity.com"}, start)
+	assert.NoError(t, err)
+	assert.Equal(t, start, since)
+
+	since, err = Touch(path, []string{"WASH@serenity.com", "zoe@serenity.com"}, start.Add(3*time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, start, since, "the same mob in any order and case")
+
+	later := start.Add(4 * time.Hour)
+	since, err = Touch(path, []string{"river@serenity.com"}, later)
+	assert.NoError(t, err)
+	assert.Equal(t, later, since, "a new mob starts a new session")
+}
+
+func TestAdvance(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mob-rotation.json")
+	mob := []string{"mal@serenity.com", "Zoe@serenity.com", "wash@serenity.com"}
+
+	next, handoff, err := Advance(path, mob, "mal@serenity.com", 2)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, next)
+	assert.False(t, handoff, "the first of two commits")
+
+	next, handoff, _ = Advance(path, mob, "MAL@serenity.com", 2)
+	assert.Equal(t, 1, next)
+	assert.True(t, handoff, "the second commit hands off to the next in line")
+
+	next, handoff, _ = Advance(path, mob, "wash@serenity.com", 2)
+	assert.Equal(t, 2, next)
+	assert.False(t, handoff, "taking over out of turn starts a new turn")
+
+	next, handoff, _ = Advance(path, mob, "wash@serenity.com", 2)
+	assert.Equal(t, 0, next)
+	assert.True(t, handoff, "the last in line hands back to the first")
+
+	next, handoff, _ = Advance(path, mob, "jayne@serenity.com", 2)
+	assert.Equal(t, -1, next)
+	assert.False(t, handoff, "someone outside the mob")
+}
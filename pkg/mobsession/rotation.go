@@ -0,0 +1,72 @@
+package mobsession
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/davidalpert/go-githooks/pkg/fileio"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Rotation is whose turn it is at the keyboard, for a mob which hands off after a
+// number of commits rather than on a timer
+type Rotation struct {
+	Driver  string `json:"driver"`  // lower-cased email
+	Commits int    `json:"commits"` // made by the driver since they took over
+}
+
+// Advance records a commit by author in a mob of members (emails, in driving order)
+// handing off every so many commits. It returns the index of the member who drives
+// next and whether that is a handoff; a commit by someone other than the recorded
+// driver starts their turn, as when the mob handed off by hand. Commits by anyone
+// outside the mob are not counted
+func Advance(path string, members []string, author string, every int) (int, bool, error) {
+	author = strings.ToLower(strings.TrimSpace(author))
+	current := indexOf(members, author)
+	if current < 0 {
+		return -1, false, nil
+	}
+
+	var r Rotation
+	data, err := fileio.ReadFile(path)
+	if err == nil {
+		if err := json.Unmarshal(data, &r); err != nil {
+			r = Rotation{}
+		}
+	} else if !os.IsNotExist(err) {
+		return -1, false, fmt.Errorf("could not read '%s': %v", path, err)
+	}
+
+	if r.Driver != author {
+		r = Rotation{Driver: author}
+	}
+	r.Commits++
+
+	next := current
+	handoff := every > 0 && r.Commits >= every && len(members) > 1
+	if handoff {
+		next = (current + 1) % len(members)
+		r = Rotation{Driver: strings.ToLower(strings.TrimSpace(members[next]))}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return next, handoff, fmt.Errorf("could not create '%s': %v", filepath.Dir(path), err)
+	}
+	if data, err = json.MarshalIndent(r, "", "  "); err != nil {
+		return next, handoff, err
+	}
+	if err := fileio.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return next, handoff, fmt.Errorf("could not write '%s': %v", path, err)
+	}
+	return next, handoff, nil
+}
+
+func indexOf(members []string, email string) int {
+	for i, m := range members {
+		if strings.EqualFold(strings.TrimSpace(m), email) {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,137 @@
+package msgbackup
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/davidalpert/go-githooks/pkg/fileio"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/storage/filesystem"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Limit is how many backups are kept; older ones are removed as new ones are saved
+const Limit = 20
+
+// Backup is a commit message as it was before a hook rewrote it
+type Backup struct {
+	File    string    `json:"file"` // the message file it was read from
+	Hook    string    `json:"hook"`
+	SavedAt time.Time `json:"savedAt"`
+	Message string    `json:"message"`
+
+	path string
+}
+
+// Dir is where the backups of repo are kept, .git/go-githooks/msg-backups, or ""
+// for repos which are not stored on disk
+func Dir(repo *git.Repository) string {
+	if repo == nil {
+		return ""
+	}
+	if s, ok := repo.Storer.(*filesystem.Storage); ok {
+		return filepath.Join(s.Filesystem().Root(), "go-githooks", "msg-backups")
+	}
+	return ""
+}
+
+// Save keeps the current content of the message file before hook overwrites it;
+// missing or empty files, and content already saved last, are not kept again
+func Save(dir, hook, file string, now time.Time) error {
+	if dir == "" {
+		return nil
+	}
+	content, err := fileio.ReadFile(file)
+	if os.IsNotExist(err) || (err == nil && strings.TrimSpace(string(content)) == "") {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("could not read '%s': %v", file, err)
+	}
+	if last, err := Latest(dir); err == nil && last != nil && last.Message == string(content) {
+		return nil
+	}
+
+	abs, err := filepath.Abs(file)
+	if err != nil {
+		abs = file
+	}
+	data, err := json.MarshalIndent(Backup{File: abs, Hook: hook, SavedAt: now.UTC(), Message: string(content)}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("could not create '%s': %v", dir, err)
+	}
+	name := filepath.Join(dir, fmt.Sprintf("%d.json", now.UnixNano()))
+	if err := fileio.WriteFile(name, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("could not write '%s': %v", name, err)
+	}
+	return prune(dir)
+}
+
+// List returns the backups in dir, newest first
+func List(dir string) ([]Backup, error) {
+	entries, err := ioutil.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("could not list '%s': %v", dir, err)
+	}
+
+	backups := make([]Backup, 0, len(entries))
+	for _, e := range entries {
+		if e.IsDir() || filepath.Ext(e.Name()) != ".json" {
+			continue
+		}
+		p := filepath.Join(dir, e.Name())
+		data, err := fileio.ReadFile(p)
+		if err != nil {
+			return nil, fmt.Errorf("could not read '%s': %v", p, err)
+		}
+		var b Backup
+		if err := json.Unmarshal(data, &b); err != nil {
+			// a damaged backup is skipped rather than blocking the others
+			continue
+		}
+		b.path = p
+		backups = append(backups, b)
+	}
+	sort.SliceStable(backups, func(i, j int) bool {
+		return backups[i].SavedAt.After(backups[j].SavedAt)
+	})
+	return backups, nil
+}
+
+// Latest returns the newest backup in dir, or nil when there is none
+func Latest(dir string) (*Backup, error) {
+	backups, err := List(dir)
+	if err != nil || len(backups) == 0 {
+		return nil, err
+	}
+	return &backups[0], nil
+}
+
+// Remove deletes b, e.g. once it has been restored so the next undo goes further back
+func (b Backup) Remove() error {
+	if b.path == "" {
+		return nil
+	}
+	return os.Remove(b.path)
+}
+
+func prune(dir string) error {
+	backups, err := List(dir)
+	if err != nil {
+		return err
+	}
+	for i := Limit; i < len(backups); i++ {
+		if err := backups[i].Remove(); err != nil {
+			return fmt.Errorf("could not remove an old backup: %v", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package msgbackup
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSave(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "msg-backups")
+	file := filepath.Join(t.TempDir(), "COMMIT_EDITMSG")
+	now := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	assert.NoError(t, Save(dir, "commit-msg", file, now), "a missing message is not backed up")
+	b, err := Latest(dir)
+	assert.NoError(t, err)
+	assert.Nil(t, b)
+
+	_ = ioutil.WriteFile(file, []byte("first\n"), 0644)
+	assert.NoError(t, Save(dir, "prepare-commit-msg", file, now))
+	assert.NoError(t, Save(dir, "commit-msg", file, now.Add(time.Second)), "the same message again is not kept twice")
+	_ = ioutil.WriteFile(file, []byte("second\n"), 0644)
+	assert.NoError(t, Save(dir, "commit-msg", file, now.Add(time.Minute)))
+
+	backups, err := List(dir)
+	assert.NoError(t, err)
+	if assert.Len(t, backups, 2) {
+		assert.Equal(t, "second\n", backups[0].Message)
+		assert.Equal(t, "commit-msg", backups[0].Hook)
+		assert.Equal(t, file, backups[0].File)
+		assert.Equal(t, "first\n", backups[1].Message)
+	}
+
+	assert.NoError(t, backups[0].Remove())
+	b, _ = Latest(dir)
+	assert.Equal(t, "first\n", b.Message)
+
+	for i := 0; i < Limit+5; i++ {
+		_ = ioutil.WriteFile(file, []byte(fmt.Sprintf("message %d\n", i)), 0644)
+		assert.NoError(t, Save(dir, "commit-msg", file, now.Add(time.Duration(i)*time.Hour)))
+	}
+	backups, _ = List(dir)
+	assert.Len(t, backups, Limit)
+	assert.Equal(t, fmt.Sprintf("message %d\n", Limit+4), backups[0].Message)
+}
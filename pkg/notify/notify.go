@@ -0,0 +1,111 @@
+package notify
+
+import (
+	"fmt"
+	"github.com/davidalpert/go-githooks/pkg/cienv"
+	"github.com/davidalpert/go-githooks/pkg/gitconfig"
+	"github.com/go-git/go-git/v5/config"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+)
+
+/*
+ * Desktop notifications are opt-in:
+ *
+ * [go-githooks "notify"]
+ *     enabled = true
+ *     after = 10s
+ *
+ * A hook which has been running for longer than 'after' has probably been left in
+ * a background terminal, so it announces that it finished (pre-push) or that it
+ * blocked the commit. Quick hooks never notify; nothing is sent in CI.
+ */
+
+// DefaultAfter is how long a hook runs before it is worth a notification
+const DefaultAfter = 10 * time.Second
+
+// Notifier announces the outcome of one hook run
+type Notifier struct {
+	Hook      string
+	Enabled   bool
+	After     time.Duration
+	OnSuccess bool // also announce a run which passed, e.g. a slow pre-push gate
+
+	start time.Time
+	send  func(title, body string) error
+}
+
+// New returns a disabled Notifier for hook which starts timing now
+func New(hook string) *Notifier {
+	return &Notifier{Hook: hook, After: DefaultAfter, start: time.Now(), send: Send}
+}
+
+// Configure reads the notify section of cfg
+func (n *Notifier) Configure(cfg *config.Config) error {
+	n.Enabled = gitconfig.GetBool(cfg, "go-githooks", "notify", "enabled", n.Enabled)
+	if a := gitconfig.GetString(cfg, "go-githooks", "notify", "after", ""); a != "" {
+		d, err := time.ParseDuration(a)
+		if err != nil {
+			return fmt.Errorf("could not parse notify after '%s': %v", a, err)
+		}
+		n.After = d
+	}
+	return nil
+}
+
+// Done notifies about the run ending with err, when that is worth a notification;
+// failing to notify is never an error for the hook
+func (n *Notifier) Done(err error) {
+	if n == nil || !n.Enabled || cienv.Detected() || time.Since(n.start) < n.After {
+		return
+	}
+	if err == nil && !n.OnSuccess {
+		return
+	}
+
+	body := fmt.Sprintf("passed after %s", time.Since(n.start).Round(time.Second))
+	if err != nil {
+		body = fmt.Sprintf("blocked after %s: %v", time.Since(n.start).Round(time.Second), err)
+	}
+	_ = n.send("go-githooks "+n.Hook, body)
+}
+
+// Send shows a desktop notification with osascript on macOS, notify-send on Linux
+// and a toast on Windows
+func Send(title, body string) error {
+	cmd := Command(runtime.GOOS, title, body)
+	if cmd == nil {
+		return fmt.Errorf("desktop notifications are not supported on %s", runtime.GOOS)
+	}
+	return cmd.Run()
+}
+
+// Command builds the command which shows a notification on goos, or nil when
+// there is none
+func Command(goos, title, body string) *exec.Cmd {
+	switch goos {
+	case "darwin":
+		return exec.Command("osascript", "-e", fmt.Sprintf("display notification %s with title %s", appleScriptString(body), appleScriptString(title)))
+	case "linux", "freebsd", "openbsd", "netbsd":
+		return exec.Command("notify-send", "--app-name=go-githooks", title, body)
+	case "windows":
+		script := fmt.Sprintf(`[Windows.UI.Notifications.ToastNotificationManager, Windows.UI.Notifications, ContentType = WindowsRuntime] > $null
+$xml = [Windows.UI.Notifications.ToastNotificationManager]::GetTemplateContent([Windows.UI.Notifications.ToastTemplateType]::ToastText02)
+$text = $xml.GetElementsByTagName('text')
+$text.Item(0).AppendChild($xml.CreateTextNode(%s)) > $null
+$text.Item(1).AppendChild($xml.CreateTextNode(%s)) > $null
+[Windows.UI.Notifications.ToastNotificationManager]::CreateToastNotifier('go-githooks').Show([Windows.UI.Notifications.ToastNotification]::new($xml))`, powerShellString(title), powerShellString(body))
+		return exec.Command("powershell", "-NoProfile", "-NonInteractive", "-Command", script)
+	}
+	return nil
+}
+
+func appleScriptString(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+func powerShellString(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
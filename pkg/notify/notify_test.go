@@ -0,0 +1,54 @@
+package notify
+
+import (
+	"fmt"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestDone(t *testing.T) {
+	if ci, ok := os.LookupEnv("CI"); ok {
+		os.Unsetenv("CI")
+		defer os.Setenv("CI", ci)
+	}
+
+	sent := make([]string, 0)
+	n := New("pre-push")
+	n.send = func(title, body string) error {
+		sent = append(sent, title+": "+body)
+		return nil
+	}
+
+	n.Done(fmt.Errorf("tests failed"))
+	assert.Empty(t, sent, "disabled by default")
+
+	cfg := config.NewConfig()
+	assert.NoError(t, cfg.Unmarshal([]byte("[go-githooks \"notify\"]\n    enabled = true\n    after = 0s\n")))
+	assert.NoError(t, n.Configure(cfg))
+	assert.Equal(t, time.Duration(0), n.After)
+
+	n.Done(nil)
+	assert.Empty(t, sent, "only failures unless OnSuccess")
+
+	n.OnSuccess = true
+	n.Done(nil)
+	n.Done(fmt.Errorf("tests failed"))
+	if assert.Len(t, sent, 2) {
+		assert.Contains(t, sent[0], "go-githooks pre-push: passed after")
+		assert.Contains(t, sent[1], ": tests failed")
+	}
+
+	n.After = time.Hour
+	n.Done(fmt.Errorf("tests failed"))
+	assert.Len(t, sent, 2, "quick runs do not notify")
+}
+
+func TestCommand(t *testing.T) {
+	assert.Equal(t, []string{"osascript", "-e", `display notification "say \"hi\"" with title "go-githooks"`}, Command("darwin", "go-githooks", `say "hi"`).Args)
+	assert.Equal(t, []string{"notify-send", "--app-name=go-githooks", "go-githooks", "hi"}, Command("linux", "go-githooks", "hi").Args)
+	assert.Contains(t, Command("windows", "go-githooks", "it's done").Args[4], "'it''s done'")
+	assert.Nil(t, Command("plan9", "go-githooks", "hi"))
+}
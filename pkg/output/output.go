@@ -0,0 +1,68 @@
+package output
+
+import (
+	"fmt"
+	"github.com/apex/log"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// Plain reports whether GIT_HOOKS_PLAIN asks for linear plain text, without colors,
+// drawing characters, spinners or columns, for screen readers and captured logs
+func Plain() bool {
+	v := strings.ToLower(os.Getenv("GIT_HOOKS_PLAIN"))
+	return v != "" && v != "0" && v != "false"
+}
+
+// Columns writes each row with its cells aligned under each other, or in plain
+// mode as a single line with the non-empty cells separated by ' - '
+func Columns(w io.Writer, indent string, rows [][]string) error {
+	if Plain() {
+		for _, row := range rows {
+			cells := make([]string, 0, len(row))
+			for _, c := range row {
+				if c = strings.TrimSpace(c); c != "" {
+					cells = append(cells, c)
+				}
+			}
+			if _, err := fmt.Fprintln(w, strings.Join(cells, " - ")); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, row := range rows {
+		if _, err := fmt.Fprintf(tw, "%s%s\n", indent, strings.Join(row, "\t")); err != nil {
+			return err
+		}
+	}
+	return tw.Flush()
+}
+
+// ConfigureLog replaces the default log handler, which pads messages into columns,
+// with one writing a single unpadded line per entry when in plain mode
+func ConfigureLog() {
+	if Plain() {
+		log.SetHandler(log.HandlerFunc(plainLogLine))
+	}
+}
+
+func plainLogLine(e *log.Entry) error {
+	keys := make([]string, 0, len(e.Fields))
+	for k := range e.Fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	parts := []string{fmt.Sprintf("%s: %s", e.Level, e.Message)}
+	for _, k := range keys {
+		parts = append(parts, fmt.Sprintf("%s: %v", k, e.Fields[k]))
+	}
+	_, err := fmt.Fprintln(os.Stderr, strings.Join(parts, "; "))
+	return err
+}
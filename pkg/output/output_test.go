@@ -0,0 +1,28 @@
+package output
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"testing"
+)
+
+func TestColumns(t *testing.T) {
+	rows := [][]string{
+		{"0", "ok", "the hook passed"},
+		{"", "", "  nothing else to do"},
+		{"4", "violation", "a rule failed"},
+	}
+
+	defer os.Setenv("GIT_HOOKS_PLAIN", os.Getenv("GIT_HOOKS_PLAIN"))
+
+	os.Setenv("GIT_HOOKS_PLAIN", "")
+	var aligned bytes.Buffer
+	assert.NoError(t, Columns(&aligned, "  ", rows))
+	assert.Equal(t, "  0  ok         the hook passed\n                  nothing else to do\n  4  violation  a rule failed\n", aligned.String())
+
+	os.Setenv("GIT_HOOKS_PLAIN", "1")
+	var plain bytes.Buffer
+	assert.NoError(t, Columns(&plain, "  ", rows))
+	assert.Equal(t, "0 - ok - the hook passed\nnothing else to do\n4 - violation - a rule failed\n", plain.String())
+}
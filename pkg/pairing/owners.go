@@ -0,0 +1,155 @@
+package pairing
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"github.com/davidalpert/go-githooks/pkg/fileio"
+	"github.com/davidalpert/go-githooks/pkg/message"
+	"os"
+	"path"
+	"strings"
+	"time"
+)
+
+/*
+ * Path owners credit whoever looks after the paths a commit touches, e.g. the
+ * payments pair-partner of the week on every commit under payments/. Rules come from
+ * pathCoauthors entries, '<pattern> = Name <email>[, Name <email>...]', and from a
+ * CODEOWNERS file, whose owners are credited when they are emails; @user and
+ * @org/team handles have no email to credit.
+ *
+ * Patterns follow CODEOWNERS: one with a slash before its end is anchored to the
+ * root of the repo, one without matches a file or directory of that name anywhere,
+ * a trailing slash matches directories only, and the last rule matching a file wins.
+ * With weekly rotation a rule naming several people credits one of them each week.
+ */
+
+const (
+	RotateAll    = "all"
+	RotateWeekly = "weekly"
+)
+
+// OwnerRule credits Owners on commits touching files matching Pattern
+type OwnerRule struct {
+	Pattern string
+	Owners  []message.Coauthor
+}
+
+// Owners are the rules in the order they were read; later rules win
+type Owners []OwnerRule
+
+// ParseOwners reads '<pattern> = Name <email>[, ...]' entries, as listed in config
+func ParseOwners(entries []string) (Owners, error) {
+	owners := make(Owners, 0, len(entries))
+	for _, e := range entries {
+		parts := strings.SplitN(e, "=", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+			return nil, fmt.Errorf("could not parse path coauthors '%s': expected '<pattern> = Name <email>[, Name <email>...]'", e)
+		}
+		rule := OwnerRule{Pattern: strings.TrimSpace(parts[0])}
+		for _, person := range strings.Split(parts[1], ",") {
+			m := personRe.FindStringSubmatch(strings.TrimSpace(person))
+			if m == nil {
+				return nil, fmt.Errorf("could not parse path coauthors '%s': expected 'Name <email>', got '%s'", e, strings.TrimSpace(person))
+			}
+			c := message.Coauthor{Name: m[2], Email: strings.TrimSpace(m[3])}
+			if role := message.RoleFromString(m[1]); role != message.CoauthoredBy {
+				c.Role = role
+			}
+			rule.Owners = append(rule.Owners, c)
+		}
+		owners = append(owners, rule)
+	}
+	return owners, nil
+}
+
+// ReadCodeowners reads the rules of a CODEOWNERS file, keeping the owners given as
+// emails, named for their email until the mailmap knows better; a missing file has none
+func ReadCodeowners(p string) (Owners, error) {
+	b, err := fileio.ReadFile(p)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("could not read '%s': %v", p, err)
+	}
+
+	owners := make(Owners, 0)
+	scanner := bufio.NewScanner(bytes.NewReader(b))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		// a rule without emails still overrides the rules before it
+		rule := OwnerRule{Pattern: fields[0]}
+		for _, f := range fields[1:] {
+			if !strings.HasPrefix(f, "@") && strings.Contains(f, "@") {
+				rule.Owners = append(rule.Owners, message.Coauthor{Name: f[:strings.Index(f, "@")], Email: f})
+			}
+		}
+		owners = append(owners, rule)
+	}
+	return owners, scanner.Err()
+}
+
+// For returns the owners of paths once each, in the order their files were listed;
+// with RotateWeekly only one owner of each rule is credited, taking turns by week
+func (o Owners) For(paths []string, rotation string, now time.Time) []message.Coauthor {
+	year, week := now.ISOWeek()
+	seen := map[string]bool{}
+	credited := make([]message.Coauthor, 0)
+	for _, p := range paths {
+		rule := o.ruleFor(p)
+		if rule == nil || len(rule.Owners) == 0 {
+			continue
+		}
+		people := rule.Owners
+		if rotation == RotateWeekly {
+			i := (year*53 + week) % len(people)
+			people = people[i : i+1]
+		}
+		for _, c := range people {
+			if key := strings.ToLower(c.Email); !seen[key] {
+				seen[key] = true
+				credited = append(credited, c)
+			}
+		}
+	}
+	return credited
+}
+
+func (o Owners) ruleFor(p string) *OwnerRule {
+	for i := len(o) - 1; i >= 0; i-- {
+		if matchOwnerPattern(o[i].Pattern, p) {
+			return &o[i]
+		}
+	}
+	return nil
+}
+
+func matchOwnerPattern(pattern, p string) bool {
+	dirOnly := strings.HasSuffix(pattern, "/")
+	anchored := strings.Contains(strings.TrimSuffix(pattern, "/"), "/")
+	pattern = strings.Trim(pattern, "/")
+	if pattern == "" {
+		return false
+	}
+	for c := p; c != "." && c != "/" && c != ""; c = path.Dir(c) {
+		if dirOnly && c == p {
+			continue
+		}
+		target := c
+		if !anchored {
+			target = path.Base(c)
+		}
+		if ok, _ := path.Match(pattern, target); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,64 @@
+package pairing
+
+import (
+	"github.com/davidalpert/go-githooks/pkg/message"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestOwners(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "CODEOWNERS")
+	_ = ioutil.WriteFile(path, []byte("# owners\n*.md     docs@serenity.com\n/payments/ @serenity/payments kaylee@serenity.com\n/payments/legacy/\n"), 0644)
+	codeowners, err := ReadCodeowners(path)
+	assert.NoError(t, err)
+	configured, err := ParseOwners([]string{"payments/ = Zoe Washburne <zoe@serenity.com>, Tested-by: River Tam <river@serenity.com>"})
+	assert.NoError(t, err)
+	_, err = ParseOwners([]string{"payments/ = Zoe Washburne"})
+	assert.Error(t, err)
+	owners := append(codeowners, configured...)
+
+	now := time.Date(2026, 10, 12, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, []message.Coauthor{
+		{Name: "docs", Email: "docs@serenity.com"},
+	}, codeowners.For([]string{"web/README.md", "payments/legacy/card.go"}, RotateAll, now), "the last rule matching wins, even with no emails")
+	assert.Equal(t, []message.Coauthor{
+		{Name: "kaylee", Email: "kaylee@serenity.com"},
+	}, codeowners.For([]string{"payments/card.go"}, RotateAll, now))
+	assert.Equal(t, []message.Coauthor{
+		{Name: "Zoe Washburne", Email: "zoe@serenity.com"},
+		{Name: "River Tam", Email: "river@serenity.com", Role: message.TestedBy},
+	}, owners.For([]string{"payments/card.go", "payments/bank.go"}, RotateAll, now), "config wins, each person once")
+
+	thisWeek := owners.For([]string{"payments/card.go"}, RotateWeekly, now)
+	nextWeek := owners.For([]string{"payments/card.go"}, RotateWeekly, now.AddDate(0, 0, 7))
+	assert.Len(t, thisWeek, 1)
+	assert.Len(t, nextWeek, 1)
+	assert.NotEqual(t, thisWeek, nextWeek)
+
+	missing, err := ReadCodeowners(filepath.Join(t.TempDir(), "CODEOWNERS"))
+	assert.NoError(t, err)
+	assert.Empty(t, missing)
+}
+
+func TestMatchOwnerPattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		want    bool
+	}{
+		{"*", "a/b.go", true},
+		{"*.md", "docs/guide.md", true},
+		{"payments", "services/payments/card.go", true},
+		{"payments/", "payments", false},
+		{"/payments/", "services/payments/card.go", false},
+		{"services/payments", "services/payments/card.go", true},
+		{"docs/*.md", "docs/guide.md", true},
+		{"docs/*.md", "web/docs/guide.md", false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, matchOwnerPattern(tt.pattern, tt.path), "%s ~ %s", tt.pattern, tt.path)
+	}
+}
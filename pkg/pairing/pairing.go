@@ -0,0 +1,160 @@
+package pairing
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/davidalpert/go-githooks/pkg/fileio"
+	"github.com/davidalpert/go-githooks/pkg/message"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+/*
+ * Pairing providers infer who is working with you from the session you are in, so
+ * Co-authored-by trailers follow the pairing without running git-mob commands:
+ *
+ * - file: a './.pairing' file in the repo root, one 'Name <email>' per line; keep
+ *   it out of version control (e.g. in .git/info/exclude). A line can start with
+ *   the role they play this session, e.g. 'Reviewed-by: Zoe Washburne <zoe@serenity.com>'
+ * - liveshare: the peers of a VS Code Live Share session, as a JSON file like
+ *   {"peers": [{"name": "Zoe Washburne", "email": "zoe@serenity.com"}]}, e.g.
+ *   written by a task using the Live Share extension API
+ *
+ * A session file which has not been touched for longer than MaxAge is ignored, so
+ * a forgotten one does not credit yesterday's partner.
+ */
+
+const (
+	FileProvider      = "file"
+	LiveShareProvider = "liveshare"
+
+	DefaultFile             = ".pairing"
+	DefaultLiveShareSession = ".vscode/liveshare-peers.json"
+	DefaultMaxAge           = 12 * time.Hour
+)
+
+var personRe = regexp.MustCompile(`^(?i:(co-authored-by|reviewed-by|tested-by|paired-with):)?\s*([^<]*?)\s*<([^>]+)>\s*$`)
+
+// Options chooses the providers to ask and where their session files live
+type Options struct {
+	Root             string // the repo's working tree
+	Providers        []string
+	File             string
+	LiveShareSession string
+	MaxAge           time.Duration
+	Self             string // the user's own email, never a coauthor
+}
+
+// Coauthors asks each provider in turn, returning everyone they name once
+func Coauthors(o Options) ([]message.Coauthor, error) {
+	coauthors := make([]message.Coauthor, 0)
+	seen := map[string]bool{strings.ToLower(o.Self): true}
+	for _, p := range o.Providers {
+		var found []message.Coauthor
+		var err error
+		switch strings.TrimSpace(p) {
+		case FileProvider:
+			found, err = ReadFile(o.path(o.File, DefaultFile), o.MaxAge)
+		case LiveShareProvider:
+			found, err = ReadLiveShare(o.path(o.LiveShareSession, DefaultLiveShareSession), o.MaxAge)
+		default:
+			err = fmt.Errorf("unknown pairing provider '%s', expected one of: %s, %s", p, FileProvider, LiveShareProvider)
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range found {
+			if key := strings.ToLower(c.Email); !seen[key] {
+				seen[key] = true
+				coauthors = append(coauthors, c)
+			}
+		}
+	}
+	return coauthors, nil
+}
+
+func (o Options) path(p, defaultPath string) string {
+	if p == "" {
+		p = defaultPath
+	}
+	if filepath.IsAbs(p) {
+		return p
+	}
+	return filepath.Join(o.Root, p)
+}
+
+// ReadFile reads one 'Name <email>' per line, optionally after a role like
+// 'Tested-by:', skipping blank lines and # comments; a missing or stale file names nobody
+func ReadFile(path string, maxAge time.Duration) ([]message.Coauthor, error) {
+	b, err := readFresh(path, maxAge)
+	if err != nil || b == nil {
+		return nil, err
+	}
+
+	coauthors := make([]message.Coauthor, 0)
+	scanner := bufio.NewScanner(bytes.NewReader(b))
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		m := personRe.FindStringSubmatch(line)
+		if m == nil {
+			return nil, fmt.Errorf("%s:%d: expected 'Name <email>', got '%s'", path, n, line)
+		}
+		c := message.Coauthor{Name: m[2], Email: strings.TrimSpace(m[3])}
+		if role := message.RoleFromString(m[1]); role != message.CoauthoredBy {
+			c.Role = role
+		}
+		coauthors = append(coauthors, c)
+	}
+	return coauthors, scanner.Err()
+}
+
+// liveShareSession is the shape of the Live Share peers file
+type liveShareSession struct {
+	Peers []struct {
+		Name  string `json:"name"`
+		Email string `json:"email"`
+	} `json:"peers"`
+}
+
+// ReadLiveShare reads the peers of a Live Share session; peers who have not shared
+// an email cannot be credited and are skipped
+func ReadLiveShare(path string, maxAge time.Duration) ([]message.Coauthor, error) {
+	b, err := readFresh(path, maxAge)
+	if err != nil || b == nil {
+		return nil, err
+	}
+
+	var session liveShareSession
+	if err := json.Unmarshal(b, &session); err != nil {
+		return nil, fmt.Errorf("could not parse Live Share session '%s': %v", path, err)
+	}
+	coauthors := make([]message.Coauthor, 0)
+	for _, p := range session.Peers {
+		if p.Email != "" {
+			coauthors = append(coauthors, message.Coauthor{Name: p.Name, Email: p.Email})
+		}
+	}
+	return coauthors, nil
+}
+
+// readFresh returns nil for a file which does not exist or is older than maxAge
+func readFresh(path string, maxAge time.Duration) ([]byte, error) {
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if maxAge > 0 && time.Since(info.ModTime()) > maxAge {
+		return nil, nil
+	}
+	return fileio.ReadFile(path)
+}
@@ -0,0 +1,56 @@
+package pairing
+
+import (
+	"github.com/davidalpert/go-githooks/pkg/message"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCoauthors(t *testing.T) {
+	root := t.TempDir()
+	_ = ioutil.WriteFile(filepath.Join(root, ".pairing"), []byte("# today\nZoe Washburne <zoe@serenity.com>\nCo-authored-by: Mal Reynolds <mal@serenity.com>\n\n"), 0644)
+	_ = os.MkdirAll(filepath.Join(root, ".vscode"), 0755)
+	_ = ioutil.WriteFile(filepath.Join(root, ".vscode", "liveshare-peers.json"), []byte(`{"peers": [{"name": "Zoe Washburne", "email": "ZOE@serenity.com"}, {"name": "River Tam", "email": "river@serenity.com"}, {"name": "Guest"}]}`), 0644)
+
+	o := Options{Root: root, Providers: []string{FileProvider, LiveShareProvider}, MaxAge: DefaultMaxAge, Self: "mal@serenity.com"}
+	coauthors, err := Coauthors(o)
+	assert.NoError(t, err)
+	assert.Equal(t, []message.Coauthor{
+		{Name: "Zoe Washburne", Email: "zoe@serenity.com"},
+		{Name: "River Tam", Email: "river@serenity.com"},
+	}, coauthors)
+
+	old := time.Now().Add(-13 * time.Hour)
+	_ = os.Chtimes(filepath.Join(root, ".pairing"), old, old)
+	o.Providers = []string{FileProvider}
+	coauthors, err = Coauthors(o)
+	assert.NoError(t, err)
+	assert.Empty(t, coauthors, "a stale file names nobody")
+
+	o.Providers = []string{"tmux"}
+	_, err = Coauthors(o)
+	assert.Error(t, err)
+}
+
+func TestReadFileWithRoles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".pairing")
+	_ = ioutil.WriteFile(path, []byte("reviewed-by: Zoe Washburne <zoe@serenity.com>\nTested-by:River Tam <river@serenity.com>\nCo-authored-by: Wash <wash@serenity.com>\n"), 0644)
+	coauthors, err := ReadFile(path, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, []message.Coauthor{
+		{Name: "Zoe Washburne", Email: "zoe@serenity.com", Role: message.ReviewedBy},
+		{Name: "River Tam", Email: "river@serenity.com", Role: message.TestedBy},
+		{Name: "Wash", Email: "wash@serenity.com"},
+	}, coauthors)
+}
+
+func TestReadFileRejectsMalformedLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".pairing")
+	_ = ioutil.WriteFile(path, []byte("zoe\n"), 0644)
+	_, err := ReadFile(path, 0)
+	assert.EqualError(t, err, path+":1: expected 'Name <email>', got 'zoe'")
+}
@@ -0,0 +1,220 @@
+package pluginproto
+
+import (
+	"fmt"
+	"io"
+	"os/exec"
+	"time"
+)
+
+// CaseTimeout bounds each conformance case, so a plugin which never answers fails
+// the case rather than hanging the suite
+var CaseTimeout = 10 * time.Second
+
+// Starter starts a fresh instance of the plugin under test, returning the connection
+// to it and a function which closes its stdin and waits for it to exit
+type Starter func() (*Conn, func() error, error)
+
+// Command starts the plugin as a program, e.g. for a plugin's own tests:
+//
+//	for _, err := range pluginproto.Conformance(pluginproto.Command("./my-linter")) {
+//		t.Error(err)
+//	}
+func Command(name string, args ...string) Starter {
+	return func() (*Conn, func() error, error) {
+		cmd := exec.Command(name, args...)
+		stdin, err := cmd.StdinPipe()
+		if err != nil {
+			return nil, nil, err
+		}
+		stdout, err := cmd.StdoutPipe()
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := cmd.Start(); err != nil {
+			return nil, nil, err
+		}
+		return NewConn(stdout, stdin), func() error {
+			_ = stdin.Close()
+			done := make(chan error, 1)
+			go func() { done <- cmd.Wait() }()
+			select {
+			case err := <-done:
+				return err
+			case <-time.After(CaseTimeout):
+				_ = cmd.Process.Kill()
+				return fmt.Errorf("did not exit within %s of its stdin closing", CaseTimeout)
+			}
+		}, nil
+	}
+}
+
+// conformanceCase is one behavior every plugin needs, so hosts can rely on it
+type conformanceCase struct {
+	name     string
+	run      func(c *Conn) error
+	declines bool // the plugin may exit with an error
+}
+
+var conformanceCases = []conformanceCase{
+	{name: "answers the greeting with the newest common version", run: func(c *Conn) error {
+		_, err := greet(c, Range{Min: MinVersion, Max: Version + 1}, []string{Annotations, MessageRewrite})
+		return err
+	}},
+	{name: "declines a greeting without a common version", run: func(c *Conn) error {
+		if err := c.Send(Hello{Type: HelloType, Protocol: Range{Min: Version + 100, Max: Version + 100}, Capabilities: []string{}}); err != nil {
+			return err
+		}
+		f, err := c.Receive()
+		if err == io.EOF || err == nil && f.Type == ErrorType {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		return fmt.Errorf("expected an error frame or the plugin to exit, got a %s frame", f.Type)
+	}, declines: true},
+	{name: "ignores unknown fields and frame types", run: func(c *Conn) error {
+		if err := c.Send(map[string]interface{}{
+			"type": HelloType, "protocol": Range{Min: MinVersion, Max: Version}, "capabilities": []string{},
+			"x-from-the-future": map[string]string{"a": "b"},
+		}); err != nil {
+			return err
+		}
+		if _, err := expectHello(c, Range{Min: MinVersion, Max: Version}); err != nil {
+			return err
+		}
+		if err := c.Send(map[string]string{"type": "x-from-the-future", "id": "0"}); err != nil {
+			return err
+		}
+		if err := c.Send(map[string]interface{}{
+			"type": RequestType, "id": "1", "hook": "commit-msg", "args": []string{}, "message": "fix login\n",
+			"x-from-the-future": true,
+		}); err != nil {
+			return err
+		}
+		_, err := expectResult(c, "1", nil)
+		return err
+	}},
+	{name: "answers each request with a result for its id", run: func(c *Conn) error {
+		reply, err := greet(c, Range{Min: MinVersion, Max: Version}, []string{Annotations, MessageRewrite})
+		if err != nil {
+			return err
+		}
+		for _, id := range []string{"a", "b"} {
+			if err := c.Send(Request{Type: RequestType, ID: id, Hook: "commit-msg", Args: []string{}, Message: "fix login\n"}); err != nil {
+				return err
+			}
+			if _, err := expectResult(c, id, reply.Capabilities); err != nil {
+				return err
+			}
+		}
+		return nil
+	}},
+	{name: "only uses capabilities the host offered", run: func(c *Conn) error {
+		reply, err := greet(c, Range{Min: MinVersion, Max: Version}, []string{})
+		if err != nil {
+			return err
+		}
+		if len(reply.Capabilities) > 0 {
+			return fmt.Errorf("took up %v, which were not offered", reply.Capabilities)
+		}
+		if err := c.Send(Request{Type: RequestType, ID: "1", Hook: "commit-msg", Args: []string{}, Message: "fix login\n"}); err != nil {
+			return err
+		}
+		r, err := expectResult(c, "1", reply.Capabilities)
+		if err == nil && r.Message != nil {
+			err = fmt.Errorf("rewrote the message without %s", MessageRewrite)
+		}
+		return err
+	}},
+}
+
+// Conformance runs a fresh instance of the plugin through each case of the protocol
+// every plugin must handle, returning one error per case it fails, including exiting
+// with an error once its stdin is closed
+func Conformance(start Starter) []error {
+	problems := make([]error, 0)
+	for _, cc := range conformanceCases {
+		c, stop, err := start()
+		if err != nil {
+			return append(problems, fmt.Errorf("could not start the plugin: %v", err))
+		}
+		ran := make(chan error, 1)
+		go func(cc conformanceCase) { ran <- cc.run(c) }(cc)
+		select {
+		case err = <-ran:
+		case <-time.After(CaseTimeout):
+			err = fmt.Errorf("no answer within %s", CaseTimeout)
+		}
+		if serr := stop(); err == nil && serr != nil && !cc.declines {
+			err = fmt.Errorf("did not exit cleanly: %v", serr)
+		}
+		if err != nil {
+			problems = append(problems, fmt.Errorf("%s: %v", cc.name, err))
+		}
+	}
+	return problems
+}
+
+func greet(c *Conn, offered Range, capabilities []string) (HelloReply, error) {
+	if err := c.Send(Hello{Type: HelloType, Protocol: offered, Host: "conformance", Capabilities: capabilities}); err != nil {
+		return HelloReply{}, err
+	}
+	return expectHello(c, offered)
+}
+
+func expectHello(c *Conn, offered Range) (HelloReply, error) {
+	f, err := c.Receive()
+	if err != nil {
+		return HelloReply{}, fmt.Errorf("no answer to the greeting: %v", err)
+	}
+	if f.Type != HelloType {
+		return HelloReply{}, fmt.Errorf("expected a hello frame, got a %s frame", f.Type)
+	}
+	var reply HelloReply
+	if err := f.Decode(&reply); err != nil {
+		return reply, err
+	}
+	if reply.Protocol < offered.Min || reply.Protocol > offered.Max {
+		return reply, fmt.Errorf("chose protocol version %d, outside the offered %d to %d", reply.Protocol, offered.Min, offered.Max)
+	}
+	if reply.Capabilities == nil {
+		return reply, fmt.Errorf("sent no capabilities; send [] for none")
+	}
+	return reply, nil
+}
+
+// expectResult reads annotations, which are only allowed with that capability,
+// until the result for id
+func expectResult(c *Conn, id string, capabilities []string) (Result, error) {
+	for {
+		f, err := c.Receive()
+		if err != nil {
+			return Result{}, fmt.Errorf("no result for request %s: %v", id, err)
+		}
+		switch f.Type {
+		case AnnotationType:
+			if !Has(capabilities, Annotations) {
+				return Result{}, fmt.Errorf("sent an annotation without %s", Annotations)
+			}
+			var a Annotation
+			if err := f.Decode(&a); err != nil {
+				return Result{}, err
+			}
+			if a.ID != id {
+				return Result{}, fmt.Errorf("annotated request %s while answering %s", a.ID, id)
+			}
+		case ResultType:
+			var r Result
+			if err := f.Decode(&r); err != nil {
+				return r, err
+			}
+			if r.ID != id {
+				return r, fmt.Errorf("answered request %s while %s was asked", r.ID, id)
+			}
+			return r, nil
+		default:
+			return Result{}, fmt.Errorf("sent a %s frame while answering request %s", f.Type, id)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package pluginproto
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+)
+
+// Session is the host's side of a negotiated connection to a plugin
+type Session struct {
+	Conn  *Conn
+	Reply HelloReply // the version and capabilities agreed on
+}
+
+// Open greets the plugin on c with hello, whose Protocol defaults to every version
+// this package speaks, and checks its answer
+func Open(c *Conn, hello Hello) (*Session, error) {
+	hello.Type = HelloType
+	if hello.Protocol == (Range{}) {
+		hello.Protocol = Range{Min: MinVersion, Max: Version}
+	}
+	if hello.Capabilities == nil {
+		hello.Capabilities = []string{}
+	}
+	if err := c.Send(hello); err != nil {
+		return nil, fmt.Errorf("could not greet the plugin: %v", err)
+	}
+
+	for {
+		f, err := c.Receive()
+		if err != nil {
+			return nil, fmt.Errorf("the plugin did not answer the greeting: %v", err)
+		}
+		switch f.Type {
+		case HelloType:
+			var reply HelloReply
+			if err := f.Decode(&reply); err != nil {
+				return nil, err
+			}
+			if reply.Protocol < hello.Protocol.Min || reply.Protocol > hello.Protocol.Max {
+				return nil, fmt.Errorf("the plugin chose protocol version %d, outside %d to %d", reply.Protocol, hello.Protocol.Min, hello.Protocol.Max)
+			}
+			// a plugin cannot take up a capability it was not offered
+			reply.Capabilities = Common(hello.Capabilities, reply.Capabilities)
+			return &Session{Conn: c, Reply: reply}, nil
+		case ErrorType:
+			var e Error
+			_ = f.Decode(&e)
+			return nil, fmt.Errorf("the plugin declined: %s", e.Message)
+		}
+		// frames from a newer version are ignored
+	}
+}
+
+// Call sends req and streams each annotation to annotate as it arrives, returning
+// the plugin's result; a rewritten message is dropped unless MessageRewrite was agreed
+func (s *Session) Call(req Request, annotate func(Annotation)) (Result, error) {
+	req.Type = RequestType
+	if req.Args == nil {
+		req.Args = []string{}
+	}
+	if err := s.Conn.Send(req); err != nil {
+		return Result{}, fmt.Errorf("could not send the request: %v", err)
+	}
+
+	for {
+		f, err := s.Conn.Receive()
+		if err == io.EOF {
+			return Result{}, fmt.Errorf("the plugin exited before answering request %s", req.ID)
+		} else if err != nil {
+			return Result{}, err
+		}
+		switch f.Type {
+		case AnnotationType:
+			var a Annotation
+			if err := f.Decode(&a); err != nil {
+				return Result{}, err
+			}
+			if a.ID == req.ID && annotate != nil && Has(s.Reply.Capabilities, Annotations) {
+				annotate(a)
+			}
+		case ResultType:
+			var r Result
+			if err := f.Decode(&r); err != nil {
+				return Result{}, err
+			}
+			if r.ID != req.ID {
+				continue
+			}
+			if !Has(s.Reply.Capabilities, MessageRewrite) {
+				r.Message = nil
+			}
+			return r, nil
+		case ErrorType:
+			var e Error
+			_ = f.Decode(&e)
+			return Result{}, fmt.Errorf("the plugin failed: %s", e.Message)
+		}
+	}
+}
+
+// Exec runs cmd as a plugin for one request, showing its stderr
+func Exec(cmd *exec.Cmd, hello Hello, req Request, annotate func(Annotation)) (Result, error) {
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return Result{}, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return Result{}, err
+	}
+	if cmd.Stderr == nil {
+		cmd.Stderr = os.Stderr
+	}
+	if err := cmd.Start(); err != nil {
+		return Result{}, fmt.Errorf("could not start plugin %s: %v", cmd.Path, err)
+	}
+
+	var result Result
+	s, err := Open(NewConn(stdout, stdin), hello)
+	if err == nil {
+		result, err = s.Call(req, annotate)
+	}
+	_ = stdin.Close()
+	if werr := cmd.Wait(); err == nil && werr != nil {
+		err = fmt.Errorf("plugin %s failed: %v", cmd.Path, werr)
+	}
+	return result, err
+}
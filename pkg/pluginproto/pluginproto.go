@@ -0,0 +1,212 @@
+package pluginproto
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+)
+
+/*
+ * Plugins extend a hook as a separate program (or a WASM module run with WASI), talking
+ * to go-githooks over stdin and stdout in JSON Lines: one JSON object per line, each
+ * with a "type". Stderr is shown to the user as is.
+ *
+ *   host   {"type":"hello","protocol":{"min":1,"max":1},"host":"go-githooks","capabilities":["annotations","message"]}
+ *   plugin {"type":"hello","protocol":1,"name":"my-linter","capabilities":["annotations"]}
+ *   host   {"type":"request","id":"1","hook":"commit-msg","args":[".git/COMMIT_EDITMSG"],"message":"fix login\n"}
+ *   plugin {"type":"annotation","id":"1","rule":"my-linter","severity":"warning","location":"message","line":1,"message":"..."}
+ *   plugin {"type":"result","id":"1","ok":true}
+ *
+ * The host offers the range of protocol versions it speaks and the plugin answers with
+ * the highest one both speak, or an "error" frame when there is none. Capabilities are
+ * optional features used only when both sides list them. A plugin answers requests
+ * until its stdin is closed, streaming annotations as it finds them.
+ *
+ * So that either side can be upgraded first, both ignore fields and frame types they
+ * do not know; a change which old plugins or hosts could not ignore needs a new
+ * protocol version. Schema describes every frame of the current version, and
+ * Conformance checks that a plugin keeps to these rules.
+ */
+
+// Version is the newest protocol version; MinVersion the oldest still spoken
+const (
+	Version    = 1
+	MinVersion = 1
+)
+
+// The frame types of version 1
+const (
+	HelloType      = "hello"
+	RequestType    = "request"
+	AnnotationType = "annotation"
+	ResultType     = "result"
+	ErrorType      = "error"
+)
+
+// The capabilities of version 1
+const (
+	// Annotations lets the plugin stream annotations before its result
+	Annotations = "annotations"
+
+	// MessageRewrite lets the plugin's result replace the commit message
+	MessageRewrite = "message"
+)
+
+// Range is the protocol versions a host speaks, oldest first
+type Range struct {
+	Min int `json:"min"`
+	Max int `json:"max"`
+}
+
+// Hello opens a session from the host
+type Hello struct {
+	Type         string   `json:"type"`
+	Protocol     Range    `json:"protocol"`
+	Host         string   `json:"host,omitempty"`
+	HostVersion  string   `json:"hostVersion,omitempty"`
+	Capabilities []string `json:"capabilities"`
+}
+
+// HelloReply is the plugin's answer to Hello: the version and capabilities the
+// session uses
+type HelloReply struct {
+	Type         string   `json:"type"`
+	Protocol     int      `json:"protocol"`
+	Name         string   `json:"name,omitempty"`
+	Capabilities []string `json:"capabilities"`
+}
+
+// Request asks the plugin to run for a hook
+type Request struct {
+	Type    string   `json:"type"`
+	ID      string   `json:"id"`
+	Hook    string   `json:"hook"`
+	Args    []string `json:"args"`
+	Message string   `json:"message,omitempty"` // the commit message, for message hooks
+	Files   []string `json:"files,omitempty"`   // the staged paths, for pre-commit
+}
+
+// Annotation is one finding, streamed while the plugin works on request ID
+type Annotation struct {
+	Type     string `json:"type"`
+	ID       string `json:"id"`
+	Rule     string `json:"rule"`
+	Severity string `json:"severity"` // error | warning | info
+	Location string `json:"location,omitempty"`
+	Line     int    `json:"line,omitempty"`
+	Message  string `json:"message"`
+}
+
+// Result ends the plugin's answer to request ID
+type Result struct {
+	Type    string  `json:"type"`
+	ID      string  `json:"id"`
+	OK      bool    `json:"ok"`
+	Message *string `json:"message,omitempty"` // the rewritten commit message, with MessageRewrite
+}
+
+// Error tells the other side why the session cannot go on, e.g. when no protocol
+// version is spoken by both
+type Error struct {
+	Type    string `json:"type"`
+	ID      string `json:"id,omitempty"`
+	Message string `json:"message"`
+}
+
+// Negotiate picks the newest version in offered which is also in supported
+func Negotiate(offered, supported Range) (int, error) {
+	v := offered.Max
+	if supported.Max < v {
+		v = supported.Max
+	}
+	if v < offered.Min || v < supported.Min {
+		return 0, fmt.Errorf("no common protocol version: offered %d to %d, supported %d to %d", offered.Min, offered.Max, supported.Min, supported.Max)
+	}
+	return v, nil
+}
+
+// Common returns the capabilities in both a and b, sorted
+func Common(a, b []string) []string {
+	in := map[string]bool{}
+	for _, c := range a {
+		in[c] = true
+	}
+	common := make([]string, 0)
+	for _, c := range b {
+		if in[c] {
+			common = append(common, c)
+			in[c] = false
+		}
+	}
+	sort.Strings(common)
+	return common
+}
+
+// Has reports whether capabilities include c
+func Has(capabilities []string, c string) bool {
+	for _, have := range capabilities {
+		if have == c {
+			return true
+		}
+	}
+	return false
+}
+
+// Frame is one line received, decoded once its Type says what it holds
+type Frame struct {
+	Type string
+	Raw  json.RawMessage
+}
+
+// Decode unmarshals the frame into v, ignoring fields v does not have
+func (f Frame) Decode(v interface{}) error {
+	if err := json.Unmarshal(f.Raw, v); err != nil {
+		return fmt.Errorf("could not parse %s frame: %v", f.Type, err)
+	}
+	return nil
+}
+
+// Conn sends and receives frames over a plugin's stdin and stdout
+type Conn struct {
+	r *bufio.Reader
+	w io.Writer
+}
+
+func NewConn(r io.Reader, w io.Writer) *Conn {
+	return &Conn{r: bufio.NewReader(r), w: w}
+}
+
+// Send writes frame as one line
+func (c *Conn) Send(frame interface{}) error {
+	b, err := json.Marshal(frame)
+	if err != nil {
+		return err
+	}
+	_, err = c.w.Write(append(b, '\n'))
+	return err
+}
+
+// Receive reads the next frame, skipping blank lines; io.EOF means the other side
+// has closed the connection
+func (c *Conn) Receive() (Frame, error) {
+	for {
+		line, err := c.r.ReadBytes('\n')
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			if err != nil {
+				return Frame{}, err
+			}
+			continue
+		}
+		var head struct {
+			Type string `json:"type"`
+		}
+		if jerr := json.Unmarshal(line, &head); jerr != nil {
+			return Frame{}, fmt.Errorf("could not parse frame '%s': %v", line, jerr)
+		}
+		return Frame{Type: head.Type, Raw: json.RawMessage(line)}, nil
+	}
+}
@@ -0,0 +1,147 @@
+package pluginproto
+
+import (
+	"encoding/json"
+	"github.com/santhosh-tekuri/jsonschema/v5"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"strings"
+	"testing"
+)
+
+// pipe starts a Serve plugin in process, as a Starter
+func pipe(p Plugin, handle Handler) Starter {
+	return func() (*Conn, func() error, error) {
+		toPlugin, fromHost := io.Pipe()
+		toHost, fromPlugin := io.Pipe()
+		done := make(chan error, 1)
+		go func() {
+			err := Serve(toPlugin, fromPlugin, p, handle)
+			_ = fromPlugin.Close()
+			done <- err
+		}()
+		return NewConn(toHost, fromHost), func() error {
+			_ = fromHost.Close()
+			return <-done
+		}, nil
+	}
+}
+
+// shout annotates lower-case subjects and, when it may, capitalizes them
+func shout(s Session, req Request, annotate func(Annotation) error) (Result, error) {
+	if req.Message == "" || strings.ToUpper(req.Message[:1]) == req.Message[:1] {
+		return Result{OK: true}, nil
+	}
+	if err := annotate(Annotation{Rule: "shout", Severity: "warning", Location: "message", Line: 1, Message: "the subject is not capitalized"}); err != nil {
+		return Result{}, err
+	}
+	fixed := strings.ToUpper(req.Message[:1]) + req.Message[1:]
+	return Result{OK: true, Message: &fixed}, nil
+}
+
+func TestConformance(t *testing.T) {
+	assert.Empty(t, Conformance(pipe(Plugin{Name: "shout", Capabilities: []string{Annotations, MessageRewrite}}, shout)))
+	assert.Empty(t, Conformance(pipe(Plugin{Name: "quiet"}, shout)))
+
+	greedy := func() (*Conn, func() error, error) {
+		toPlugin, fromHost := io.Pipe()
+		toHost, fromPlugin := io.Pipe()
+		go func() {
+			// always claims every capability and never checks the version
+			c := NewConn(toPlugin, fromPlugin)
+			for f, err := c.Receive(); err == nil; f, err = c.Receive() {
+				switch f.Type {
+				case HelloType:
+					_ = c.Send(HelloReply{Type: HelloType, Protocol: Version, Capabilities: []string{Annotations, MessageRewrite}})
+				case RequestType:
+					var req Request
+					_ = f.Decode(&req)
+					_ = c.Send(Result{Type: ResultType, ID: req.ID, OK: true})
+				}
+			}
+			_ = fromPlugin.Close()
+		}()
+		return NewConn(toHost, fromHost), func() error { return fromHost.Close() }, nil
+	}
+	problems := Conformance(greedy)
+	if assert.Len(t, problems, 2) {
+		assert.Equal(t, "declines a greeting without a common version: expected an error frame or the plugin to exit, got a hello frame", problems[0].Error())
+		assert.Equal(t, "only uses capabilities the host offered: took up [annotations message], which were not offered", problems[1].Error())
+	}
+}
+
+func TestSession(t *testing.T) {
+	c, stop, _ := pipe(Plugin{Name: "shout", Capabilities: []string{Annotations, MessageRewrite}}, shout)()
+	s, err := Open(c, Hello{Host: "go-githooks", Capabilities: []string{Annotations, MessageRewrite, "x-future"}})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, HelloReply{Type: HelloType, Protocol: Version, Name: "shout", Capabilities: []string{Annotations, MessageRewrite}}, s.Reply)
+
+	var annotations []Annotation
+	r, err := s.Call(Request{ID: "1", Hook: "commit-msg", Message: "fix login\n"}, func(a Annotation) { annotations = append(annotations, a) })
+	assert.NoError(t, err)
+	if assert.Len(t, annotations, 1) {
+		assert.Equal(t, "the subject is not capitalized", annotations[0].Message)
+	}
+	if assert.NotNil(t, r.Message) {
+		assert.Equal(t, "Fix login\n", *r.Message)
+	}
+	assert.NoError(t, stop())
+
+	c, stop, _ = pipe(Plugin{Name: "shout", Capabilities: []string{Annotations, MessageRewrite}}, shout)()
+	s, _ = Open(c, Hello{Capabilities: []string{Annotations}})
+	r, err = s.Call(Request{ID: "1", Hook: "commit-msg", Message: "fix login\n"}, nil)
+	assert.NoError(t, err)
+	assert.Nil(t, r.Message, "the message is only rewritten when the host offers to take it")
+	assert.NoError(t, stop())
+
+	c, stop, _ = pipe(Plugin{Protocol: Range{Min: 2, Max: 3}}, shout)()
+	_, err = Open(c, Hello{})
+	assert.EqualError(t, err, "the plugin declined: no common protocol version: offered 1 to 1, supported 2 to 3")
+	assert.Error(t, stop())
+}
+
+func TestNegotiate(t *testing.T) {
+	v, err := Negotiate(Range{Min: 1, Max: 3}, Range{Min: 1, Max: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, v)
+	v, err = Negotiate(Range{Min: 2, Max: 2}, Range{Min: 1, Max: 5})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, v)
+	_, err = Negotiate(Range{Min: 1, Max: 1}, Range{Min: 2, Max: 3})
+	assert.Error(t, err)
+
+	assert.Equal(t, []string{"a", "c"}, Common([]string{"c", "a", "b"}, []string{"a", "c", "c", "d"}))
+}
+
+func TestSchema(t *testing.T) {
+	c := jsonschema.NewCompiler()
+	if err := c.AddResource("pluginproto.json", strings.NewReader(Schema)); err != nil {
+		t.Fatalf("loading the schema: %v", err)
+	}
+	schema, err := c.Compile("pluginproto.json")
+	if err != nil {
+		t.Fatalf("compiling the schema: %v", err)
+	}
+
+	message := "Fix login\n"
+	for _, frame := range []interface{}{
+		Hello{Type: HelloType, Protocol: Range{Min: 1, Max: 1}, Host: "go-githooks", Capabilities: []string{Annotations}},
+		HelloReply{Type: HelloType, Protocol: 1, Name: "shout", Capabilities: []string{}},
+		Request{Type: RequestType, ID: "1", Hook: "pre-commit", Args: []string{}, Files: []string{"main.go"}},
+		Annotation{Type: AnnotationType, ID: "1", Rule: "shout", Severity: "warning", Line: 1, Message: "the subject is not capitalized"},
+		Result{Type: ResultType, ID: "1", OK: true, Message: &message},
+		Error{Type: ErrorType, Message: "no common protocol version"},
+		map[string]string{"type": "x-from-the-future"},
+	} {
+		var doc interface{}
+		b, _ := json.Marshal(frame)
+		_ = json.Unmarshal(b, &doc)
+		assert.NoError(t, schema.Validate(doc), string(b))
+	}
+
+	var bad interface{}
+	_ = json.Unmarshal([]byte(`{"type": "annotation", "id": "1", "rule": "shout", "severity": "fatal", "message": "x"}`), &bad)
+	assert.Error(t, schema.Validate(bad))
+}
@@ -0,0 +1,105 @@
+package pluginproto
+
+// Schema is the JSON Schema of every frame in version 1 of the protocol. Frames may
+// carry fields it does not list, which older readers ignore
+const Schema = `{
+  "$schema": "https://json-schema.org/draft/2020-12/schema",
+  "$id": "https://github.com/davidalpert/go-githooks/pkg/pluginproto/v1.json",
+  "title": "go-githooks plugin protocol frame, version 1",
+  "type": "object",
+  "required": ["type"],
+  "properties": {
+    "type": {"type": "string"}
+  },
+  "oneOf": [
+    {"$ref": "#/$defs/hello"},
+    {"$ref": "#/$defs/helloReply"},
+    {"$ref": "#/$defs/request"},
+    {"$ref": "#/$defs/annotation"},
+    {"$ref": "#/$defs/result"},
+    {"$ref": "#/$defs/error"},
+    {"$ref": "#/$defs/unknown"}
+  ],
+  "$defs": {
+    "capabilities": {
+      "type": "array",
+      "items": {"type": "string"},
+      "uniqueItems": true
+    },
+    "hello": {
+      "description": "the host's greeting",
+      "properties": {
+        "type": {"const": "hello"},
+        "protocol": {
+          "type": "object",
+          "required": ["min", "max"],
+          "properties": {
+            "min": {"type": "integer", "minimum": 1},
+            "max": {"type": "integer", "minimum": 1}
+          }
+        },
+        "host": {"type": "string"},
+        "hostVersion": {"type": "string"},
+        "capabilities": {"$ref": "#/$defs/capabilities"}
+      },
+      "required": ["type", "protocol", "capabilities"]
+    },
+    "helloReply": {
+      "description": "the plugin's answer to the greeting",
+      "properties": {
+        "type": {"const": "hello"},
+        "protocol": {"type": "integer", "minimum": 1},
+        "name": {"type": "string"},
+        "capabilities": {"$ref": "#/$defs/capabilities"}
+      },
+      "required": ["type", "protocol", "capabilities"]
+    },
+    "request": {
+      "properties": {
+        "type": {"const": "request"},
+        "id": {"type": "string"},
+        "hook": {"type": "string"},
+        "args": {"type": "array", "items": {"type": "string"}},
+        "message": {"type": "string"},
+        "files": {"type": "array", "items": {"type": "string"}}
+      },
+      "required": ["type", "id", "hook", "args"]
+    },
+    "annotation": {
+      "properties": {
+        "type": {"const": "annotation"},
+        "id": {"type": "string"},
+        "rule": {"type": "string"},
+        "severity": {"enum": ["error", "warning", "info"]},
+        "location": {"type": "string"},
+        "line": {"type": "integer", "minimum": 1},
+        "message": {"type": "string"}
+      },
+      "required": ["type", "id", "rule", "severity", "message"]
+    },
+    "result": {
+      "properties": {
+        "type": {"const": "result"},
+        "id": {"type": "string"},
+        "ok": {"type": "boolean"},
+        "message": {"type": "string"}
+      },
+      "required": ["type", "id", "ok"]
+    },
+    "error": {
+      "properties": {
+        "type": {"const": "error"},
+        "id": {"type": "string"},
+        "message": {"type": "string"}
+      },
+      "required": ["type", "message"]
+    },
+    "unknown": {
+      "description": "a frame from a newer version, which readers ignore",
+      "properties": {
+        "type": {"not": {"enum": ["hello", "request", "annotation", "result", "error"]}}
+      }
+    }
+  }
+}
+`
@@ -0,0 +1,87 @@
+package pluginproto
+
+import (
+	"errors"
+	"io"
+)
+
+// Plugin describes a plugin written in Go, for Serve
+type Plugin struct {
+	Name         string
+	Protocol     Range    // defaults to every version this package speaks
+	Capabilities []string // those it can use if the host offers them
+}
+
+// Handler answers one request; annotate sends an annotation for it, and does
+// nothing unless the host agreed to Annotations
+type Handler func(s Session, req Request, annotate func(Annotation) error) (Result, error)
+
+// Serve is the plugin's side of the protocol: it answers the host's greeting on in
+// and out, then each request with handle until in is closed
+func Serve(in io.Reader, out io.Writer, p Plugin, handle Handler) error {
+	c := NewConn(in, out)
+	if p.Protocol == (Range{}) {
+		p.Protocol = Range{Min: MinVersion, Max: Version}
+	}
+	if p.Capabilities == nil {
+		p.Capabilities = []string{}
+	}
+
+	var s *Session
+	for {
+		f, err := c.Receive()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		switch {
+		case f.Type == HelloType && s == nil:
+			var hello Hello
+			if err := f.Decode(&hello); err != nil {
+				return err
+			}
+			v, err := Negotiate(hello.Protocol, p.Protocol)
+			if err != nil {
+				_ = c.Send(Error{Type: ErrorType, Message: err.Error()})
+				return err
+			}
+			s = &Session{Conn: c, Reply: HelloReply{Type: HelloType, Protocol: v, Name: p.Name, Capabilities: Common(hello.Capabilities, p.Capabilities)}}
+			if err := c.Send(s.Reply); err != nil {
+				return err
+			}
+		case f.Type == RequestType && s != nil:
+			var req Request
+			if err := f.Decode(&req); err != nil {
+				return err
+			}
+			annotate := func(a Annotation) error {
+				if !Has(s.Reply.Capabilities, Annotations) {
+					return nil
+				}
+				a.Type, a.ID = AnnotationType, req.ID
+				return c.Send(a)
+			}
+			r, err := handle(*s, req, annotate)
+			if err != nil {
+				if serr := c.Send(Error{Type: ErrorType, ID: req.ID, Message: err.Error()}); serr != nil {
+					return serr
+				}
+				continue
+			}
+			r.Type, r.ID = ResultType, req.ID
+			if !Has(s.Reply.Capabilities, MessageRewrite) {
+				r.Message = nil
+			}
+			if err := c.Send(r); err != nil {
+				return err
+			}
+		case f.Type == RequestType:
+			err := errors.New("a request came before the greeting")
+			_ = c.Send(Error{Type: ErrorType, Message: err.Error()})
+			return err
+		}
+		// frames from a newer version are ignored
+	}
+}
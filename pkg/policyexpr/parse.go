@@ -0,0 +1,331 @@
+package policyexpr
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// the syntax tree of a compiled expression
+type node interface{}
+
+type (
+	literal struct{ v interface{} }
+	ident   struct{ name string }
+	unary   struct {
+		op string
+		x  node
+	}
+	binary struct {
+		op   string
+		x, y node
+	}
+	cond  struct{ c, t, f node }
+	list  struct{ items []node }
+	field struct {
+		x    node
+		name string
+	}
+	index struct{ x, i node }
+	call  struct {
+		target node // nil for a global function such as size(x)
+		fn     string
+		args   []node
+	}
+)
+
+type token struct {
+	kind string // "ident", "int", "string", or the operator itself
+	text string
+	v    interface{}
+	pos  int
+}
+
+var operators = []string{"&&", "||", "==", "!=", "<=", ">=", "!", "<", ">", "+", "-", "*", "/", "%", "(", ")", "[", "]", ".", ",", "?", ":"}
+
+func lex(src string) ([]token, error) {
+	tokens := make([]token, 0)
+	for i := 0; i < len(src); {
+		c := rune(src[i])
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case c == '_' || unicode.IsLetter(c):
+			j := i
+			for j < len(src) && (src[j] == '_' || unicode.IsLetter(rune(src[j])) || unicode.IsDigit(rune(src[j]))) {
+				j++
+			}
+			tokens = append(tokens, token{kind: "ident", text: src[i:j], pos: i})
+			i = j
+		case unicode.IsDigit(c):
+			j := i
+			for j < len(src) && unicode.IsDigit(rune(src[j])) {
+				j++
+			}
+			n, err := strconv.ParseInt(src[i:j], 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("col %d: %v", i+1, err)
+			}
+			tokens = append(tokens, token{kind: "int", text: src[i:j], v: n, pos: i})
+			i = j
+		case c == '"' || c == '\'':
+			s, n, err := lexString(src[i:])
+			if err != nil {
+				return nil, fmt.Errorf("col %d: %v", i+1, err)
+			}
+			tokens = append(tokens, token{kind: "string", text: src[i : i+n], v: s, pos: i})
+			i += n
+		default:
+			op := ""
+			for _, o := range operators {
+				if strings.HasPrefix(src[i:], o) {
+					op = o
+					break
+				}
+			}
+			if op == "" {
+				return nil, fmt.Errorf("col %d: unexpected '%c'", i+1, c)
+			}
+			tokens = append(tokens, token{kind: op, text: op, pos: i})
+			i += len(op)
+		}
+	}
+	return append(tokens, token{kind: "end", pos: len(src)}), nil
+}
+
+// lexString reads the quoted string src starts with, returning its value and length
+func lexString(src string) (string, int, error) {
+	quote := src[0]
+	var b strings.Builder
+	for i := 1; i < len(src); i++ {
+		switch c := src[i]; {
+		case c == quote:
+			return b.String(), i + 1, nil
+		case c == '\\' && i+1 < len(src):
+			i++
+			switch src[i] {
+			case 'n':
+				b.WriteByte('\n')
+			case 't':
+				b.WriteByte('\t')
+			default:
+				b.WriteByte(src[i])
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", 0, fmt.Errorf("unterminated string")
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+	depth  int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != "end" {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) accept(kind string) bool {
+	if p.peek().kind == kind {
+		p.next()
+		return true
+	}
+	return false
+}
+
+func (p *parser) expect(kind string) error {
+	if t := p.next(); t.kind != kind {
+		return p.unexpected(t)
+	}
+	return nil
+}
+
+func (p *parser) unexpected(t token) error {
+	if t.kind == "end" {
+		return fmt.Errorf("col %d: unexpected end of expression", t.pos+1)
+	}
+	return fmt.Errorf("col %d: unexpected '%s'", t.pos+1, t.text)
+}
+
+// expr parses a conditional, the lowest precedence: c ? t : f
+func (p *parser) expr() (node, error) {
+	if p.depth++; p.depth > MaxDepth {
+		return nil, fmt.Errorf("col %d: nested more than %d deep", p.peek().pos+1, MaxDepth)
+	}
+	defer func() { p.depth-- }()
+
+	c, err := p.binary(0)
+	if err != nil || !p.accept("?") {
+		return c, err
+	}
+	t, err := p.expr()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect(":"); err != nil {
+		return nil, err
+	}
+	f, err := p.expr()
+	if err != nil {
+		return nil, err
+	}
+	return cond{c, t, f}, nil
+}
+
+// precedence lists the binary operators from the loosest binding to the tightest
+var precedence = [][]string{
+	{"||"},
+	{"&&"},
+	{"==", "!=", "<", "<=", ">", ">=", "in"},
+	{"+", "-"},
+	{"*", "/", "%"},
+}
+
+func (p *parser) binary(level int) (node, error) {
+	if level == len(precedence) {
+		return p.unary()
+	}
+	x, err := p.binary(level + 1)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t := p.peek()
+		op := ""
+		for _, o := range precedence[level] {
+			if t.kind == o || (t.kind == "ident" && t.text == o) {
+				op = o
+			}
+		}
+		if op == "" {
+			return x, nil
+		}
+		p.next()
+		y, err := p.binary(level + 1)
+		if err != nil {
+			return nil, err
+		}
+		x = binary{op, x, y}
+	}
+}
+
+func (p *parser) unary() (node, error) {
+	if t := p.peek(); t.kind == "!" || t.kind == "-" {
+		p.next()
+		x, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return unary{t.kind, x}, nil
+	}
+	return p.postfix()
+}
+
+func (p *parser) postfix() (node, error) {
+	x, err := p.primary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		switch {
+		case p.accept("."):
+			t := p.next()
+			if t.kind != "ident" {
+				return nil, p.unexpected(t)
+			}
+			if !p.accept("(") {
+				x = field{x, t.text}
+				continue
+			}
+			args, err := p.args(")")
+			if err != nil {
+				return nil, err
+			}
+			x = call{x, t.text, args}
+		case p.accept("["):
+			i, err := p.expr()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect("]"); err != nil {
+				return nil, err
+			}
+			x = index{x, i}
+		default:
+			return x, nil
+		}
+	}
+}
+
+func (p *parser) primary() (node, error) {
+	t := p.next()
+	switch t.kind {
+	case "int", "string":
+		return literal{t.v}, nil
+	case "(":
+		x, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+		return x, p.expect(")")
+	case "[":
+		items, err := p.args("]")
+		if err != nil {
+			return nil, err
+		}
+		return list{items}, nil
+	case "ident":
+		switch t.text {
+		case "true":
+			return literal{true}, nil
+		case "false":
+			return literal{false}, nil
+		case "null":
+			return literal{nil}, nil
+		case "in":
+			return nil, p.unexpected(t)
+		}
+		if !p.accept("(") {
+			return ident{t.text}, nil
+		}
+		args, err := p.args(")")
+		if err != nil {
+			return nil, err
+		}
+		return call{nil, t.text, args}, nil
+	}
+	return nil, p.unexpected(t)
+}
+
+// args parses a comma-separated list of expressions up to and including end
+func (p *parser) args(end string) ([]node, error) {
+	args := make([]node, 0)
+	if p.accept(end) {
+		return args, nil
+	}
+	for {
+		a, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, a)
+		if p.accept(end) {
+			return args, nil
+		}
+		if err := p.expect(","); err != nil {
+			return nil, err
+		}
+	}
+}
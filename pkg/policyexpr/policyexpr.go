@@ -0,0 +1,571 @@
+package policyexpr
+
+import (
+	"fmt"
+	"github.com/go-git/go-git/v5/config"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+/*
+ * Policies which no built-in rule covers can be written as expressions over the
+ * commit, in a subset of CEL (https://github.com/google/cel-spec) evaluated in-process:
+ *
+ * [go-githooks "policy-rules"]
+ *     no-wip-on-main = !(branch == 'main' && message.subject.startsWith('WIP'))
+ *     migrations-need-ticket = !files.exists(f, f.path.startsWith('db/migrate/')) || size(message.tickets) > 0
+ *
+ * Each key names a rule, reported like a built-in one when its expression is false;
+ * its severity (default: error) is set in [go-githooks "rules"].
+ *
+ * Supported: bool, int, string, list and map values; null; && || ! ?: == != < <= > >=
+ * + - * / % and in; x.field, x[i], has(x.field); size(), startsWith(), endsWith(),
+ * contains(), matches() (RE2), lowerAscii(), upperAscii(), trim(); and the exists,
+ * all, exists_one, filter and map macros over lists. Expressions are bounded to
+ * MaxLength characters and MaxSteps evaluation steps, so a policy cannot hang a hook.
+ */
+
+const (
+	MaxLength        = 4096   // characters in an expression
+	MaxDepth         = 64     // nesting of parentheses and sub-expressions
+	MaxSteps         = 100000 // nodes evaluated, counting every turn of a macro
+	MaxPatternLength = 1024   // characters in a matches() pattern
+)
+
+// Program is a compiled expression
+type Program struct {
+	Source string
+	root   node
+}
+
+// Compile parses src
+func Compile(src string) (*Program, error) {
+	if utf8.RuneCountInString(src) > MaxLength {
+		return nil, fmt.Errorf("the expression is longer than %d characters", MaxLength)
+	}
+	tokens, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	root, err := p.expr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != "end" {
+		return nil, p.unexpected(t)
+	}
+	return &Program{Source: src, root: root}, nil
+}
+
+// Eval evaluates the program over vars, whose values are bools, ints, strings, nil,
+// []interface{} and map[string]interface{} of the same
+func (p *Program) Eval(vars map[string]interface{}) (interface{}, error) {
+	e := &evaluator{patterns: map[string]*regexp.Regexp{}}
+	return e.eval(p.root, &scope{vars: vars})
+}
+
+// Holds evaluates the program over vars, which must give a bool
+func (p *Program) Holds(vars map[string]interface{}) (bool, error) {
+	v, err := p.Eval(vars)
+	if err != nil {
+		return false, err
+	}
+	b, ok := v.(bool)
+	if !ok {
+		return false, fmt.Errorf("the expression gives %s, not bool", typeName(v))
+	}
+	return b, nil
+}
+
+// scope resolves identifiers: a macro's variable, then the enclosing scopes, then vars
+type scope struct {
+	name   string
+	value  interface{}
+	parent *scope
+	vars   map[string]interface{}
+}
+
+func (s *scope) lookup(name string) (interface{}, bool) {
+	for ; s != nil; s = s.parent {
+		if s.parent == nil {
+			v, ok := s.vars[name]
+			return normalize(v), ok
+		}
+		if s.name == name {
+			return s.value, true
+		}
+	}
+	return nil, false
+}
+
+type evaluator struct {
+	steps    int
+	patterns map[string]*regexp.Regexp
+}
+
+func (e *evaluator) eval(n node, s *scope) (interface{}, error) {
+	if e.steps++; e.steps > MaxSteps {
+		return nil, fmt.Errorf("gave up after %d evaluation steps", MaxSteps)
+	}
+	switch n := n.(type) {
+	case literal:
+		return n.v, nil
+	case ident:
+		v, ok := s.lookup(n.name)
+		if !ok {
+			return nil, fmt.Errorf("undeclared reference to '%s'", n.name)
+		}
+		return v, nil
+	case list:
+		items := make([]interface{}, 0, len(n.items))
+		for _, i := range n.items {
+			v, err := e.eval(i, s)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, v)
+		}
+		return items, nil
+	case field:
+		x, err := e.eval(n.x, s)
+		if err != nil {
+			return nil, err
+		}
+		m, ok := x.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%s has no field '%s'", typeName(x), n.name)
+		}
+		v, ok := m[n.name]
+		if !ok {
+			return nil, fmt.Errorf("no such key: '%s'", n.name)
+		}
+		return normalize(v), nil
+	case index:
+		return e.index(n, s)
+	case unary:
+		x, err := e.eval(n.x, s)
+		if err != nil {
+			return nil, err
+		}
+		if b, ok := x.(bool); ok && n.op == "!" {
+			return !b, nil
+		}
+		if i, ok := x.(int64); ok && n.op == "-" {
+			return -i, nil
+		}
+		return nil, fmt.Errorf("no such overload: %s%s", n.op, typeName(x))
+	case binary:
+		return e.binary(n, s)
+	case cond:
+		c, err := e.bool(n.c, s)
+		if err != nil {
+			return nil, err
+		}
+		if c {
+			return e.eval(n.t, s)
+		}
+		return e.eval(n.f, s)
+	case call:
+		return e.call(n, s)
+	}
+	return nil, fmt.Errorf("cannot evaluate %T", n)
+}
+
+func (e *evaluator) bool(n node, s *scope) (bool, error) {
+	v, err := e.eval(n, s)
+	if err != nil {
+		return false, err
+	}
+	b, ok := v.(bool)
+	if !ok {
+		return false, fmt.Errorf("expected bool, got %s", typeName(v))
+	}
+	return b, nil
+}
+
+func (e *evaluator) index(n index, s *scope) (interface{}, error) {
+	x, err := e.eval(n.x, s)
+	if err != nil {
+		return nil, err
+	}
+	i, err := e.eval(n.i, s)
+	if err != nil {
+		return nil, err
+	}
+	switch x := x.(type) {
+	case []interface{}:
+		pos, ok := i.(int64)
+		if !ok {
+			return nil, fmt.Errorf("list index must be int, got %s", typeName(i))
+		}
+		if pos < 0 || pos >= int64(len(x)) {
+			return nil, fmt.Errorf("index %d out of range [0, %d)", pos, len(x))
+		}
+		return normalize(x[pos]), nil
+	case map[string]interface{}:
+		key, ok := i.(string)
+		if !ok {
+			return nil, fmt.Errorf("map key must be string, got %s", typeName(i))
+		}
+		v, ok := x[key]
+		if !ok {
+			return nil, fmt.Errorf("no such key: '%s'", key)
+		}
+		return normalize(v), nil
+	}
+	return nil, fmt.Errorf("cannot index %s", typeName(x))
+}
+
+func (e *evaluator) binary(n binary, s *scope) (interface{}, error) {
+	switch n.op {
+	case "&&", "||":
+		x, err := e.bool(n.x, s)
+		if err != nil || x == (n.op == "||") {
+			return x, err
+		}
+		return e.bool(n.y, s)
+	}
+
+	x, err := e.eval(n.x, s)
+	if err != nil {
+		return nil, err
+	}
+	y, err := e.eval(n.y, s)
+	if err != nil {
+		return nil, err
+	}
+	switch n.op {
+	case "==":
+		return reflect.DeepEqual(x, y), nil
+	case "!=":
+		return !reflect.DeepEqual(x, y), nil
+	case "in":
+		switch y := y.(type) {
+		case []interface{}:
+			for _, item := range y {
+				if reflect.DeepEqual(x, normalize(item)) {
+					return true, nil
+				}
+			}
+			return false, nil
+		case map[string]interface{}:
+			key, ok := x.(string)
+			_, found := y[key]
+			return ok && found, nil
+		}
+	case "+":
+		switch x := x.(type) {
+		case string:
+			if y, ok := y.(string); ok {
+				return x + y, nil
+			}
+		case []interface{}:
+			if y, ok := y.([]interface{}); ok {
+				return append(append([]interface{}{}, x...), y...), nil
+			}
+		}
+	}
+
+	if a, ok := x.(int64); ok {
+		if b, ok := y.(int64); ok {
+			return arithmetic(n.op, a, b)
+		}
+	}
+	if a, ok := x.(string); ok {
+		if b, ok := y.(string); ok {
+			if c, ok := compare(n.op, strings.Compare(a, b)); ok {
+				return c, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("no such overload: %s %s %s", typeName(x), n.op, typeName(y))
+}
+
+func arithmetic(op string, a, b int64) (interface{}, error) {
+	switch op {
+	case "+":
+		return a + b, nil
+	case "-":
+		return a - b, nil
+	case "*":
+		return a * b, nil
+	case "/", "%":
+		if b == 0 {
+			return nil, fmt.Errorf("division by zero")
+		}
+		if op == "/" {
+			return a / b, nil
+		}
+		return a % b, nil
+	}
+	c := 0
+	if a < b {
+		c = -1
+	} else if a > b {
+		c = 1
+	}
+	if v, ok := compare(op, c); ok {
+		return v, nil
+	}
+	return nil, fmt.Errorf("no such overload: int %s int", op)
+}
+
+// compare applies a relational operator to the result of a three-way comparison
+func compare(op string, c int) (bool, bool) {
+	switch op {
+	case "<":
+		return c < 0, true
+	case "<=":
+		return c <= 0, true
+	case ">":
+		return c > 0, true
+	case ">=":
+		return c >= 0, true
+	}
+	return false, false
+}
+
+// macros take a variable name and an expression evaluated with it bound to each item
+var macros = map[string]bool{"exists": true, "all": true, "exists_one": true, "filter": true, "map": true}
+
+func (e *evaluator) call(n call, s *scope) (interface{}, error) {
+	if n.target == nil && n.fn == "has" {
+		return e.has(n, s)
+	}
+	if n.target != nil && macros[n.fn] {
+		return e.macro(n, s)
+	}
+
+	args := make([]interface{}, 0, len(n.args)+1)
+	if n.target != nil {
+		t, err := e.eval(n.target, s)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, t)
+	}
+	for _, a := range n.args {
+		v, err := e.eval(a, s)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, v)
+	}
+	return e.function(n.fn, args)
+}
+
+// function applies fn to args, where a method's target is the first argument
+func (e *evaluator) function(fn string, args []interface{}) (interface{}, error) {
+	if fn == "size" && len(args) == 1 {
+		switch x := args[0].(type) {
+		case string:
+			return int64(utf8.RuneCountInString(x)), nil
+		case []interface{}:
+			return int64(len(x)), nil
+		case map[string]interface{}:
+			return int64(len(x)), nil
+		}
+	}
+
+	strs := make([]string, 0, len(args))
+	for _, a := range args {
+		str, ok := a.(string)
+		if !ok {
+			break
+		}
+		strs = append(strs, str)
+	}
+	if len(strs) == len(args) {
+		switch {
+		case fn == "startsWith" && len(strs) == 2:
+			return strings.HasPrefix(strs[0], strs[1]), nil
+		case fn == "endsWith" && len(strs) == 2:
+			return strings.HasSuffix(strs[0], strs[1]), nil
+		case fn == "contains" && len(strs) == 2:
+			return strings.Contains(strs[0], strs[1]), nil
+		case fn == "matches" && len(strs) == 2:
+			re, err := e.pattern(strs[1])
+			if err != nil {
+				return nil, err
+			}
+			return re.MatchString(strs[0]), nil
+		case fn == "lowerAscii" && len(strs) == 1:
+			return strings.ToLower(strs[0]), nil
+		case fn == "upperAscii" && len(strs) == 1:
+			return strings.ToUpper(strs[0]), nil
+		case fn == "trim" && len(strs) == 1:
+			return strings.TrimSpace(strs[0]), nil
+		}
+	}
+
+	types := make([]string, 0, len(args))
+	for _, a := range args {
+		types = append(types, typeName(a))
+	}
+	return nil, fmt.Errorf("no such function: %s(%s)", fn, strings.Join(types, ", "))
+}
+
+func (e *evaluator) pattern(p string) (*regexp.Regexp, error) {
+	if re, ok := e.patterns[p]; ok {
+		return re, nil
+	}
+	if len(p) > MaxPatternLength {
+		return nil, fmt.Errorf("the pattern is longer than %d characters", MaxPatternLength)
+	}
+	re, err := regexp.Compile(p)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern '%s': %v", p, err)
+	}
+	e.patterns[p] = re
+	return re, nil
+}
+
+// has(x.field) tests for a key rather than failing when it is missing
+func (e *evaluator) has(n call, s *scope) (interface{}, error) {
+	if len(n.args) != 1 {
+		return nil, fmt.Errorf("has() takes one argument")
+	}
+	f, ok := n.args[0].(field)
+	if !ok {
+		return nil, fmt.Errorf("has() takes a field selection, e.g. has(message.trailers.topic)")
+	}
+	x, err := e.eval(f.x, s)
+	if err != nil {
+		return nil, err
+	}
+	m, ok := x.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%s has no fields", typeName(x))
+	}
+	_, found := m[f.name]
+	return found, nil
+}
+
+func (e *evaluator) macro(n call, s *scope) (interface{}, error) {
+	if len(n.args) != 2 {
+		return nil, fmt.Errorf("%s() takes a variable and an expression", n.fn)
+	}
+	v, ok := n.args[0].(ident)
+	if !ok {
+		return nil, fmt.Errorf("the first argument of %s() must be a variable name", n.fn)
+	}
+	t, err := e.eval(n.target, s)
+	if err != nil {
+		return nil, err
+	}
+	var items []interface{}
+	switch t := t.(type) {
+	case []interface{}:
+		items = t
+	case map[string]interface{}:
+		// like CEL, macros over a map range over its keys
+		for k := range t {
+			items = append(items, k)
+		}
+		sort.Slice(items, func(i, j int) bool { return items[i].(string) < items[j].(string) })
+	default:
+		return nil, fmt.Errorf("cannot range over %s", typeName(t))
+	}
+
+	matched := 0
+	results := make([]interface{}, 0)
+	for _, item := range items {
+		inner := &scope{name: v.name, value: normalize(item), parent: s}
+		if n.fn == "map" {
+			r, err := e.eval(n.args[1], inner)
+			if err != nil {
+				return nil, err
+			}
+			results = append(results, r)
+			continue
+		}
+		ok, err := e.bool(n.args[1], inner)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case n.fn == "exists" && ok:
+			return true, nil
+		case n.fn == "all" && !ok:
+			return false, nil
+		case ok:
+			matched++
+			results = append(results, normalize(item))
+		}
+	}
+	switch n.fn {
+	case "exists":
+		return false, nil
+	case "all":
+		return true, nil
+	case "exists_one":
+		return matched == 1, nil
+	}
+	return results, nil
+}
+
+// normalize converts the Go values callers commonly pass to the ones expressions use
+func normalize(v interface{}) interface{} {
+	switch v := v.(type) {
+	case int:
+		return int64(v)
+	case []string:
+		items := make([]interface{}, 0, len(v))
+		for _, s := range v {
+			items = append(items, s)
+		}
+		return items
+	case map[string]string:
+		m := make(map[string]interface{}, len(v))
+		for k, s := range v {
+			m[k] = s
+		}
+		return m
+	}
+	return v
+}
+
+func typeName(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "bool"
+	case int64:
+		return "int"
+	case string:
+		return "string"
+	case []interface{}:
+		return "list"
+	case map[string]interface{}:
+		return "map"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+// Rule is one of the repo's own rules, named by its key in [go-githooks "policy-rules"]
+type Rule struct {
+	Name    string
+	Program *Program
+}
+
+// RulesFromConfig compiles the [go-githooks "policy-rules"] section, in the order
+// the rules are configured
+func RulesFromConfig(c *config.Config) ([]Rule, error) {
+	rules := make([]Rule, 0)
+	if c == nil || !c.Raw.HasSection("go-githooks") || !c.Raw.Section("go-githooks").HasSubsection("policy-rules") {
+		return rules, nil
+	}
+	for _, o := range c.Raw.Section("go-githooks").Subsection("policy-rules").Options {
+		p, err := Compile(o.Value)
+		if err != nil {
+			return nil, fmt.Errorf("policy rule '%s': %v", o.Key, err)
+		}
+		rules = append(rules, Rule{Name: strings.ToLower(o.Key), Program: p})
+	}
+	return rules, nil
+}
@@ -0,0 +1,102 @@
+package policyexpr
+
+import (
+	"github.com/go-git/go-git/v5/config"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestHolds(t *testing.T) {
+	vars := map[string]interface{}{
+		"branch": "main",
+		"message": map[string]interface{}{
+			"subject":  "WIP add login",
+			"tickets":  []string{},
+			"trailers": map[string]interface{}{"topic": []string{"auth"}},
+		},
+		"files": []interface{}{
+			map[string]interface{}{"path": "db/migrate/001_users.sql", "added": true},
+			map[string]interface{}{"path": "README.md", "added": false},
+		},
+		"author": map[string]interface{}{"name": "Mal Reynolds", "email": "mal@serenity.com"},
+	}
+	tests := []struct {
+		expr    string
+		want    bool
+		wantErr string
+	}{
+		{expr: `!(branch == 'main' && message.subject.startsWith("WIP"))`, want: false},
+		{expr: `!files.exists(f, f.path.startsWith('db/migrate/')) || size(message.tickets) > 0`, want: false},
+		{expr: `files.all(f, f.path.matches('^[a-z_/0-9.A-Z]+$'))`, want: true},
+		{expr: `files.exists_one(f, f.added)`, want: true},
+		{expr: `size(files.filter(f, !f.added)) == 1 && files.map(f, f.path)[1] == 'README.md'`, want: true},
+		{expr: `author.email.endsWith('@serenity.com') && 'auth' in message.trailers.topic`, want: true},
+		{expr: `has(message.trailers.topic) && !has(message.trailers.refs)`, want: true},
+		{expr: `'topic' in message.trailers ? 1 + 2 * 3 == 7 : false`, want: true},
+		{expr: `branch in ['main', 'master'] && message.subject.lowerAscii().contains('wip')`, want: true},
+		{expr: `branch.size() - 10 < -5 && 7 % 4 == 3`, want: true},
+		{expr: `message.trailers.refs == null`, wantErr: "no such key: 'refs'"},
+		{expr: `size(branch) + 'x'`, wantErr: "no such overload: int + string"},
+		{expr: `size(branch)`, wantErr: "the expression gives int, not bool"},
+		{expr: `commit.subject == ''`, wantErr: "undeclared reference to 'commit'"},
+		{expr: `branch.startsWith(1)`, wantErr: "no such function: startsWith(string, int)"},
+		{expr: `1 / 0 == 0`, wantErr: "division by zero"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			p, err := Compile(tt.expr)
+			if !assert.NoError(t, err) {
+				return
+			}
+			got, err := p.Holds(vars)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestLimits(t *testing.T) {
+	_, err := Compile(`branch == 'main' &&`)
+	assert.EqualError(t, err, "col 20: unexpected end of expression")
+	_, err = Compile(`branch = 'main'`)
+	assert.EqualError(t, err, "col 8: unexpected '='")
+	_, err = Compile(strings.Repeat("(", MaxDepth+1) + "true" + strings.Repeat(")", MaxDepth+1))
+	assert.Error(t, err)
+	_, err = Compile(strings.Repeat("a", MaxLength+1))
+	assert.Error(t, err)
+
+	// every turn of the nested macros counts, so this gives up instead of running for long
+	p, err := Compile(`xs.all(a, xs.all(b, xs.all(c, a + b + c >= 0)))`)
+	assert.NoError(t, err)
+	xs := make([]interface{}, 0)
+	for i := 0; i < 100; i++ {
+		xs = append(xs, i)
+	}
+	_, err = p.Holds(map[string]interface{}{"xs": xs})
+	assert.EqualError(t, err, "gave up after 100000 evaluation steps")
+}
+
+func TestRulesFromConfig(t *testing.T) {
+	cfg := config.NewConfig()
+	assert.NoError(t, cfg.Unmarshal([]byte(`
+[go-githooks "policy-rules"]
+    no-wip-on-main = !(branch == 'main' && message.subject.startsWith('WIP'))
+`)))
+	rules, err := RulesFromConfig(cfg)
+	assert.NoError(t, err)
+	if assert.Len(t, rules, 1) {
+		assert.Equal(t, "no-wip-on-main", rules[0].Name)
+	}
+
+	assert.NoError(t, cfg.Unmarshal([]byte(`
+[go-githooks "policy-rules"]
+    broken = branch ==
+`)))
+	_, err = RulesFromConfig(cfg)
+	assert.EqualError(t, err, "policy rule 'broken': col 10: unexpected end of expression")
+}
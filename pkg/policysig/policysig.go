@@ -0,0 +1,286 @@
+package policysig
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"github.com/davidalpert/go-githooks/pkg/cienv"
+	"golang.org/x/crypto/blake2b"
+	"golang.org/x/crypto/ssh"
+	"os"
+	"sort"
+	"strings"
+)
+
+/*
+ * Policy bundles (remote config, .githooks.yml and the files they extend) can carry a
+ * detached signature next to them, <file>.minisig or <file>.sig, made with either
+ *
+ *     minisign -Sm githooks.yml
+ *     ssh-keygen -Y sign -n go-githooks -f ~/.ssh/id_ed25519 githooks.yml
+ *
+ * The keys trusted to sign policy are only read from the user's global (or system)
+ * config, never from the repo, which is what the signature vouches for:
+ *
+ * [go-githooks "policy"]
+ *     trustedKey = RWQf6LRCGA9i53mlYecO4IzT51TGPpvWucNSCh1CBM0QTaLn73Y7GFO3   # minisign
+ *     trustedKey = ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAI... platform@example.com
+ *     strict = true     # refuse unsigned policy; also GIT_HOOKS_STRICT_POLICY=1 or --strict-policy
+ *
+ * Without strict, unsigned policy is still used, but a signature which does not
+ * verify always refuses the file.
+ */
+
+// Namespace is what ssh signatures of policy must be made for, with ssh-keygen -Y sign -n
+const Namespace = "go-githooks"
+
+// StrictEnv turns on strict mode for one run, e.g. in CI
+const StrictEnv = "GIT_HOOKS_STRICT_POLICY"
+
+// Extensions are the detached signature files looked for next to a policy file, in order
+var Extensions = []string{".minisig", ".sig"}
+
+// Key is a public key trusted to sign policy
+type Key struct {
+	Spec    string
+	ed25519 ed25519.PublicKey
+	keyID   []byte // minisign's, to tell its keys apart
+	ssh     ssh.PublicKey
+}
+
+// Policy holds the keys trusted to sign policy bundles
+type Policy struct {
+	Keys   []Key
+	Strict bool
+}
+
+// New returns the policy trusting the keys in specs; GIT_HOOKS_STRICT_POLICY makes it
+// strict, as does running in CI with trusted keys to verify signatures with
+func New(specs []string, strict bool) (*Policy, error) {
+	p := &Policy{Strict: strict || os.Getenv(StrictEnv) != "" || (cienv.Detected() && len(specs) > 0)}
+	for _, spec := range specs {
+		k, err := ParseKey(spec)
+		if err != nil {
+			return nil, err
+		}
+		p.Keys = append(p.Keys, k)
+	}
+	return p, nil
+}
+
+// Enabled reports whether policy files need their signatures looked for
+func (p *Policy) Enabled() bool {
+	return p != nil && (p.Strict || len(p.Keys) > 0)
+}
+
+// Fingerprint identifies the keys and mode, so that a copy verified under one policy
+// is not trusted under another
+func (p *Policy) Fingerprint() string {
+	if !p.Enabled() {
+		return ""
+	}
+	specs := make([]string, 0, len(p.Keys))
+	for _, k := range p.Keys {
+		specs = append(specs, k.Spec)
+	}
+	sort.Strings(specs)
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%v %s", p.Strict, strings.Join(specs, "\n"))))
+	return hex.EncodeToString(sum[:8])
+}
+
+// ParseKey reads a minisign public key (with or without its comment line), an
+// OpenSSH public key, or a base64 ed25519 key
+func ParseKey(spec string) (Key, error) {
+	spec = strings.TrimSpace(spec)
+	k := Key{Spec: spec}
+	if strings.HasPrefix(spec, "ssh-") || strings.HasPrefix(spec, "ecdsa-") || strings.HasPrefix(spec, "sk-") {
+		pub, _, _, _, err := ssh.ParseAuthorizedKey([]byte(spec))
+		if err != nil {
+			return k, fmt.Errorf("could not parse trusted ssh key: %v", err)
+		}
+		k.ssh = pub
+		return k, nil
+	}
+
+	lines := strings.Split(spec, "\n")
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[len(lines)-1]))
+	switch {
+	case err != nil:
+		return k, fmt.Errorf("could not parse trusted key '%s': %v", spec, err)
+	case len(raw) == 42 && string(raw[:2]) == "Ed":
+		k.keyID, k.ed25519 = raw[2:10], ed25519.PublicKey(raw[10:])
+	case len(raw) == ed25519.PublicKeySize:
+		k.ed25519 = ed25519.PublicKey(raw)
+	default:
+		return k, fmt.Errorf("could not parse trusted key '%s': expected a minisign, ssh or ed25519 public key", spec)
+	}
+	return k, nil
+}
+
+// Check decides whether the policy file name may be used: sig is its detached
+// signature, or nil when it has none
+func (p *Policy) Check(name string, data, sig []byte) error {
+	if !p.Enabled() {
+		return nil
+	}
+	if sig == nil {
+		if p.Strict {
+			return fmt.Errorf("refusing unsigned policy '%s' in strict mode", name)
+		}
+		return nil
+	}
+	if len(p.Keys) == 0 {
+		return fmt.Errorf("refusing policy '%s': it is signed, but no trusted keys are configured to verify it", name)
+	}
+
+	var err error
+	for _, k := range p.Keys {
+		if err = k.Verify(data, sig); err == nil {
+			return nil
+		}
+	}
+	if len(p.Keys) > 1 {
+		err = fmt.Errorf("no trusted key verifies it")
+	}
+	return fmt.Errorf("refusing policy '%s': %v", name, err)
+}
+
+// Verify checks sig, a minisign, ssh or base64 ed25519 signature of data, against k
+func (k Key) Verify(data, sig []byte) error {
+	trimmed := bytes.TrimSpace(sig)
+	switch {
+	case bytes.HasPrefix(trimmed, []byte("untrusted comment:")):
+		return k.verifyMinisign(data, trimmed)
+	case bytes.HasPrefix(trimmed, []byte("-----BEGIN SSH SIGNATURE-----")):
+		return k.VerifySSH(data, trimmed, Namespace)
+	}
+	if k.ed25519 == nil {
+		return fmt.Errorf("the key is an ssh key, but the signature is not an ssh signature")
+	}
+	raw, err := base64.StdEncoding.DecodeString(string(trimmed))
+	if err != nil {
+		return fmt.Errorf("could not decode signature: %v", err)
+	}
+	if !ed25519.Verify(k.ed25519, data, raw) {
+		return fmt.Errorf("signature does not match")
+	}
+	return nil
+}
+
+func (k Key) verifyMinisign(data, sig []byte) error {
+	if k.ed25519 == nil {
+		return fmt.Errorf("the signature is a minisign signature, but the key is not")
+	}
+	lines := strings.Split(strings.Replace(string(sig), "\r\n", "\n", -1), "\n")
+	if len(lines) < 4 || !strings.HasPrefix(lines[2], "trusted comment: ") {
+		return fmt.Errorf("could not parse minisign signature")
+	}
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[1]))
+	if err != nil || len(raw) != 74 {
+		return fmt.Errorf("could not parse minisign signature")
+	}
+	algorithm, keyID, signature := string(raw[:2]), raw[2:10], raw[10:]
+	if k.keyID != nil && !bytes.Equal(keyID, k.keyID) {
+		return fmt.Errorf("signed with another key (%X)", reverse(keyID))
+	}
+
+	signed := data
+	switch algorithm {
+	case "Ed":
+	case "ED":
+		// the default since minisign 0.10: the signature is over the file's BLAKE2b hash
+		sum := blake2b.Sum512(data)
+		signed = sum[:]
+	default:
+		return fmt.Errorf("unsupported minisign algorithm '%s'", algorithm)
+	}
+	if !ed25519.Verify(k.ed25519, signed, signature) {
+		return fmt.Errorf("signature does not match")
+	}
+
+	// the trusted comment is signed too, so it cannot be swapped for another
+	global, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[3]))
+	if err != nil || !ed25519.Verify(k.ed25519, append(append([]byte{}, signature...), strings.TrimPrefix(lines[2], "trusted comment: ")...), global) {
+		return fmt.Errorf("the trusted comment's signature does not match")
+	}
+	return nil
+}
+
+// sshsig is the signature blob ssh-keygen -Y sign writes, from PROTOCOL.sshsig
+type sshsig struct {
+	Magic         [6]byte
+	Version       uint32
+	PublicKey     []byte
+	Namespace     string
+	Reserved      []byte
+	HashAlgorithm string
+	Signature     []byte
+}
+
+// VerifySSH checks sig, made with ssh-keygen -Y sign -n namespace, of data against
+// k; git signs commits for the namespace 'git'
+func (k Key) VerifySSH(data, sig []byte, namespace string) error {
+	if k.ssh == nil {
+		return fmt.Errorf("the signature is an ssh signature, but the key is not")
+	}
+	block, _ := pem.Decode(sig)
+	if block == nil || block.Type != "SSH SIGNATURE" {
+		return fmt.Errorf("could not parse ssh signature")
+	}
+	var s sshsig
+	if err := ssh.Unmarshal(block.Bytes, &s); err != nil || string(s.Magic[:]) != "SSHSIG" || s.Version != 1 {
+		return fmt.Errorf("could not parse ssh signature")
+	}
+	signer, err := ssh.ParsePublicKey(s.PublicKey)
+	if err != nil {
+		return fmt.Errorf("could not parse the ssh signature's key: %v", err)
+	}
+	if !bytes.Equal(signer.Marshal(), k.ssh.Marshal()) {
+		return fmt.Errorf("signed with another key (%s)", ssh.FingerprintSHA256(signer))
+	}
+	if s.Namespace != namespace {
+		return fmt.Errorf("signed for namespace '%s', expected '%s'", s.Namespace, namespace)
+	}
+
+	var digest []byte
+	switch s.HashAlgorithm {
+	case "sha256":
+		sum := sha256.Sum256(data)
+		digest = sum[:]
+	case "sha512":
+		sum := sha512.Sum512(data)
+		digest = sum[:]
+	default:
+		return fmt.Errorf("unsupported ssh signature hash '%s'", s.HashAlgorithm)
+	}
+	signed := ssh.Marshal(struct {
+		Magic         [6]byte
+		Namespace     string
+		Reserved      []byte
+		HashAlgorithm string
+		Hash          []byte
+	}{s.Magic, s.Namespace, s.Reserved, s.HashAlgorithm, digest})
+
+	var signature ssh.Signature
+	if err := ssh.Unmarshal(s.Signature, &signature); err != nil {
+		return fmt.Errorf("could not parse ssh signature: %v", err)
+	}
+	if err := k.ssh.Verify(signed, &signature); err != nil {
+		return fmt.Errorf("signature does not match")
+	}
+	return nil
+}
+
+// reverse shows a minisign key id the way minisign prints it
+func reverse(b []byte) []byte {
+	r := make([]byte, len(b))
+	for i := range b {
+		r[len(b)-1-i] = b[i]
+	}
+	return r
+}
@@ -0,0 +1,87 @@
+package policysig
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/blake2b"
+	"io/ioutil"
+	"testing"
+)
+
+// minisign signs data the way minisign -S does, returning the public key and the signature
+func minisign(t *testing.T, data []byte, algorithm string) (string, []byte) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	assert.NoError(t, err)
+	keyID := []byte{1, 2, 3, 4, 5, 6, 7, 8}
+
+	signed := data
+	if algorithm == "ED" {
+		sum := blake2b.Sum512(data)
+		signed = sum[:]
+	}
+	signature := ed25519.Sign(priv, signed)
+	trusted := "timestamp:1700000000\tfile:githooks.toml"
+	global := ed25519.Sign(priv, append(append([]byte{}, signature...), trusted...))
+
+	key := base64.StdEncoding.EncodeToString(append(append([]byte("Ed"), keyID...), pub...))
+	sig := fmt.Sprintf("untrusted comment: signature from minisign secret key\n%s\ntrusted comment: %s\n%s\n",
+		base64.StdEncoding.EncodeToString(append(append([]byte(algorithm), keyID...), signature...)),
+		trusted,
+		base64.StdEncoding.EncodeToString(global))
+	return "untrusted comment: minisign public key 0807060504030201\n" + key, []byte(sig)
+}
+
+func TestMinisign(t *testing.T) {
+	data := []byte("preset = \"conventional\"\n")
+	for _, algorithm := range []string{"Ed", "ED"} {
+		spec, sig := minisign(t, data, algorithm)
+		k, err := ParseKey(spec)
+		assert.NoError(t, err)
+		assert.NoError(t, k.Verify(data, sig), algorithm)
+		assert.EqualError(t, k.Verify([]byte("preset = \"none\"\n"), sig), "signature does not match", algorithm)
+
+		other, _ := minisign(t, data, algorithm)
+		k, _ = ParseKey(other)
+		assert.EqualError(t, k.Verify(data, sig), "signature does not match", algorithm)
+	}
+}
+
+func TestSSH(t *testing.T) {
+	data, _ := ioutil.ReadFile("testdata/githooks.toml")
+	sig, _ := ioutil.ReadFile("testdata/githooks.toml.sig")
+	pub, _ := ioutil.ReadFile("testdata/trusted.pub")
+
+	k, err := ParseKey(string(pub))
+	assert.NoError(t, err)
+	assert.NoError(t, k.Verify(data, sig))
+	assert.EqualError(t, k.Verify(append(data, '\n'), sig), "signature does not match")
+
+	other, err := ParseKey("ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIOMqqnkVzrm0SdG6UOoqKLsabgH5C9okWi0dh2l9GKJl")
+	assert.NoError(t, err)
+	assert.Contains(t, other.Verify(data, sig).Error(), "signed with another key")
+}
+
+func TestCheck(t *testing.T) {
+	data := []byte("preset = \"conventional\"\n")
+	spec, sig := minisign(t, data, "ED")
+	k, _ := ParseKey(spec)
+
+	assert.NoError(t, (*Policy)(nil).Check("githooks.toml", data, nil), "no policy")
+	assert.NoError(t, (&Policy{Keys: []Key{k}}).Check("githooks.toml", data, nil), "unsigned is allowed unless strict")
+	assert.EqualError(t, (&Policy{Keys: []Key{k}, Strict: true}).Check("githooks.toml", data, nil), "refusing unsigned policy 'githooks.toml' in strict mode")
+	assert.NoError(t, (&Policy{Keys: []Key{k}, Strict: true}).Check("githooks.toml", data, sig))
+	assert.EqualError(t, (&Policy{Keys: []Key{k}}).Check("githooks.toml", []byte("tampered"), sig), "refusing policy 'githooks.toml': signature does not match")
+	assert.Error(t, (&Policy{Strict: true}).Check("githooks.toml", data, sig), "signed, but nothing to verify it with")
+}
+
+func TestParseKey(t *testing.T) {
+	_, err := ParseKey("not a key")
+	assert.Error(t, err)
+	_, err = ParseKey("ssh-ed25519 AAAA")
+	assert.Error(t, err)
+	k, err := ParseKey(base64.StdEncoding.EncodeToString(make([]byte, ed25519.PublicKeySize)))
+	assert.NoError(t, err)
+	assert.Nil(t, k.keyID)
+}
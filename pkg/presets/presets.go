@@ -0,0 +1,116 @@
+package presets
+
+import (
+	"fmt"
+	"github.com/davidalpert/go-githooks/pkg/gitconfig"
+	"github.com/go-git/go-git/v5/config"
+	"sort"
+	"strings"
+)
+
+/*
+ * A preset is a named bundle of go-githooks options, selected with a single key:
+ *
+ * [go-githooks]
+ *     preset = jira
+ *
+ * (or 'go-githooks init --preset jira'). Presets only fill in options which are not
+ * set explicitly, so any option in the bundle can still be overridden on its own.
+ * Several presets can be combined as a comma-separated list; earlier ones win.
+ */
+type Preset struct {
+	Name        string
+	Description string
+	Options     []Option
+}
+
+// Option is a single go-githooks.<subsection>.<key> setting
+type Option struct {
+	Subsection string
+	Key        string
+	Value      string
+}
+
+var presets = map[string]Preset{
+	"conventional": {
+		Name:        "conventional",
+		Description: "Conventional Commits headers (type(scope): subject), no branch prefix",
+		Options: []Option{
+			{"prepare-commit-message", "prefixWithBranch", "false"},
+			{"rules", "conventional-header", "error"},
+			{"rules", "empty-message", "error"},
+		},
+	},
+	"jira": {
+		Name:        "jira",
+		Description: "prefix messages with the Jira ticket from the branch name and require a ticket reference",
+		Options: []Option{
+			{"prepare-commit-message", "prefixWithBranch", "true"},
+			{"prepare-commit-message", "prefixWithBranchTemplate", "[%s]"},
+			{"prepare-commit-message", "prefixBranchExclusions", "main,master,develop"},
+			{"rules", "ticket-reference", "error"},
+			{"rules", "empty-message", "error"},
+		},
+	},
+	"mob": {
+		Name:        "mob",
+		Description: "mob/pair programming: branch prefix, co-authors from git-mob or a .pairing file (kept through squashes), and a reminder while the message is empty",
+		Options: []Option{
+			{"prepare-commit-message", "prefixWithBranch", "true"},
+			{"pairing", "providers", "file"},
+			{"prepare-commit-message", "warnOnEmptyMessage", "true"},
+			{"rules", "empty-message", "warning"},
+			{"post-rewrite", "trailerPolicy", "merge"},
+		},
+	},
+	"oss-dco": {
+		Name:        "oss-dco",
+		Description: "open source projects using the Developer Certificate of Origin: Signed-off-by on every commit",
+		Options: []Option{
+			{"prepare-commit-message", "prefixWithBranch", "false"},
+			{"prepare-commit-message", "signOff", "true"},
+			{"rules", "dco-signoff", "error"},
+			{"post-rewrite", "trailerPolicy", "preserve"},
+		},
+	},
+}
+
+// Get returns the named preset
+func Get(name string) (Preset, error) {
+	p, ok := presets[strings.TrimSpace(name)]
+	if !ok {
+		return Preset{}, fmt.Errorf("unknown preset '%s', expected one of: %s", name, strings.Join(Names(), ", "))
+	}
+	return p, nil
+}
+
+// Names lists the available presets
+func Names() []string {
+	names := make([]string, 0, len(presets))
+	for n := range presets {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Apply fills in the options of the presets selected by go-githooks.preset
+// wherever c does not already set them
+func Apply(c *config.Config) error {
+	selected := gitconfig.GetSlice(c, "go-githooks", "", "preset", []string{})
+	for _, name := range selected {
+		if strings.TrimSpace(name) == "" {
+			continue
+		}
+		p, err := Get(name)
+		if err != nil {
+			return err
+		}
+		for _, o := range p.Options {
+			if !gitconfig.Has(c, "go-githooks", o.Subsection, o.Key) {
+				c.Raw.SetOption("go-githooks", o.Subsection, o.Key, o.Value)
+			}
+		}
+	}
+	return nil
+}
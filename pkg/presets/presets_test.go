@@ -0,0 +1,36 @@
+package presets
+
+import (
+	"github.com/davidalpert/go-githooks/pkg/gitconfig"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestApply(t *testing.T) {
+	c := config.NewConfig()
+	err := c.Unmarshal([]byte(`
+[go-githooks]
+    preset = jira
+[go-githooks "prepare-commit-message"]
+    prefixWithBranchTemplate = %s:
+`))
+	if err != nil {
+		t.Fatalf("unmarshalling sample config: %v", err)
+	}
+
+	assert.NoError(t, Apply(c))
+	assert.True(t, gitconfig.GetBool(c, "go-githooks", "prepare-commit-message", "prefixWithBranch", false))
+	assert.Equal(t, "%s:", gitconfig.GetString(c, "go-githooks", "prepare-commit-message", "prefixWithBranchTemplate", ""))
+	assert.Equal(t, "error", gitconfig.GetString(c, "go-githooks", "rules", "ticket-reference", ""))
+}
+
+func TestApplyUnknownPreset(t *testing.T) {
+	c := config.NewConfig()
+	_ = c.Unmarshal([]byte(`
+[go-githooks]
+    preset = gitflow
+`))
+
+	assert.Error(t, Apply(c))
+}
@@ -0,0 +1,113 @@
+package prompt
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/davidalpert/go-githooks/pkg/cienv"
+	"io"
+	"os"
+	"strings"
+)
+
+/*
+ * git does not connect a hook's stdin to the terminal, so hooks which ask the user
+ * anything read from /dev/tty instead. Nothing is asked when there is no terminal, in
+ * CI (see cienv), or when GIT_HOOKS_NONINTERACTIVE is set; hooks then behave as if
+ * the question had been declined.
+ */
+
+// Prompter asks questions on In and Out
+type Prompter struct {
+	In  *bufio.Reader
+	Out io.Writer
+
+	close func() error
+}
+
+// Choice is one answer to Choose, picked by typing its Key
+type Choice struct {
+	Key         string
+	Description string
+}
+
+// New asks questions on in and out, e.g. in tests
+func New(in io.Reader, out io.Writer) *Prompter {
+	return &Prompter{In: bufio.NewReader(in), Out: out}
+}
+
+// Terminal returns a Prompter on the controlling terminal, or nil when the hook
+// should not ask anything
+func Terminal() *Prompter {
+	if os.Getenv("GIT_HOOKS_NONINTERACTIVE") != "" || cienv.Detected() {
+		return nil
+	}
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return nil
+	}
+	p := New(tty, tty)
+	p.close = tty.Close
+	return p
+}
+
+// TTY returns the terminal file backing p, for handing to a child process such as an editor
+func (p *Prompter) TTY() (*os.File, bool) {
+	if p == nil {
+		return nil, false
+	}
+	f, ok := p.Out.(*os.File)
+	return f, ok
+}
+
+func (p *Prompter) Close() error {
+	if p == nil || p.close == nil {
+		return nil
+	}
+	return p.close()
+}
+
+// Line asks question and returns the trimmed answer
+func (p *Prompter) Line(question string) string {
+	if p == nil {
+		return ""
+	}
+	fmt.Fprintf(p.Out, "%s ", question)
+	answer, _ := p.In.ReadString('\n')
+	return strings.TrimSpace(answer)
+}
+
+// Confirm asks a yes/no question; anything but yes is no
+func (p *Prompter) Confirm(question string) bool {
+	answer := strings.ToLower(p.Line(fmt.Sprintf("%s [y/N]", question)))
+	return answer == "y" || answer == "yes"
+}
+
+// Choose asks until one of choices is picked; it returns "" when input runs out
+func (p *Prompter) Choose(question string, choices []Choice) string {
+	if p == nil {
+		return ""
+	}
+	keys := make([]string, 0, len(choices))
+	for _, c := range choices {
+		keys = append(keys, c.Key)
+	}
+
+	for {
+		fmt.Fprintf(p.Out, "%s\n", question)
+		for _, c := range choices {
+			fmt.Fprintf(p.Out, "  [%s] %s\n", c.Key, c.Description)
+		}
+		fmt.Fprintf(p.Out, "choice (%s): ", strings.Join(keys, "/"))
+
+		answer, err := p.In.ReadString('\n')
+		answer = strings.ToLower(strings.TrimSpace(answer))
+		for _, c := range choices {
+			if answer == c.Key {
+				return c.Key
+			}
+		}
+		if err != nil {
+			return ""
+		}
+	}
+}
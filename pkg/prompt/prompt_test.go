@@ -0,0 +1,26 @@
+package prompt
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestChoose(t *testing.T) {
+	out := &bytes.Buffer{}
+	p := New(strings.NewReader("x\nB\n"), out)
+
+	choice := p.Choose("what now?", []Choice{{"a", "accept"}, {"b", "bypass"}})
+	assert.Equal(t, "b", choice)
+	assert.Equal(t, 2, strings.Count(out.String(), "what now?"), "asks again after an unknown answer")
+
+	assert.Equal(t, "", p.Choose("what now?", []Choice{{"a", "accept"}}), "gives up when input runs out")
+}
+
+func TestNilPrompterDeclines(t *testing.T) {
+	var p *Prompter
+	assert.False(t, p.Confirm("run it?"))
+	assert.Equal(t, "", p.Line("why?"))
+	assert.NoError(t, p.Close())
+}
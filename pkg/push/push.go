@@ -0,0 +1,166 @@
+package push
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"io"
+	"sort"
+	"strings"
+)
+
+// DefaultLimit bounds how many commits are walked for a single pushed ref
+const DefaultLimit = 1000
+
+// Update is one line of what git passes to the pre-push hook on stdin:
+//
+//	<local ref> SP <local sha1> SP <remote ref> SP <remote sha1> LF
+type Update struct {
+	LocalRef  plumbing.ReferenceName
+	LocalSha  plumbing.Hash
+	RemoteRef plumbing.ReferenceName
+	RemoteSha plumbing.Hash
+}
+
+// IsDelete reports whether the update deletes the remote ref
+func (u Update) IsDelete() bool {
+	return u.LocalSha.IsZero()
+}
+
+// IsNewRef reports whether the remote ref does not exist yet
+func (u Update) IsNewRef() bool {
+	return u.RemoteSha.IsZero()
+}
+
+func ParseUpdates(r io.Reader) ([]Update, error) {
+	updates := make([]Update, 0)
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		if line == "" {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 4 {
+			return nil, fmt.Errorf("could not parse pre-push line '%s'", line)
+		}
+		updates = append(updates, Update{
+			LocalRef:  plumbing.ReferenceName(fields[0]),
+			LocalSha:  plumbing.NewHash(fields[1]),
+			RemoteRef: plumbing.ReferenceName(fields[2]),
+			RemoteSha: plumbing.NewHash(fields[3]),
+		})
+	}
+	return updates, s.Err()
+}
+
+// Commits returns the commits the update sends to the remote, newest first: those
+// reachable from the local sha but not from the remote sha (or, for a new remote
+// ref, from any of the remote's tracking refs). At most limit commits are returned.
+func (u Update) Commits(repo *git.Repository, remoteName string, limit int) ([]*object.Commit, error) {
+	if u.IsDelete() {
+		return nil, nil
+	}
+
+	known := make([]plumbing.Hash, 0)
+	if !u.IsNewRef() {
+		known = append(known, u.RemoteSha)
+	} else {
+		refs, err := repo.References()
+		if err != nil {
+			return nil, err
+		}
+		prefix := "refs/remotes/" + remoteName + "/"
+		_ = refs.ForEach(func(r *plumbing.Reference) error {
+			if r.Type() == plumbing.HashReference && strings.HasPrefix(r.Name().String(), prefix) {
+				known = append(known, r.Hash())
+			}
+			return nil
+		})
+	}
+
+	return Range(repo, u.LocalSha, known, limit)
+}
+
+// Range returns the commits reachable from tip but not from any of exclude, newest
+// first, walking both sides together by commit time the way git rev-list does so
+// that only the part of history that differs is visited
+func Range(repo *git.Repository, tip plumbing.Hash, exclude []plumbing.Hash, limit int) ([]*object.Commit, error) {
+	const (
+		included = 1
+		excluded = 2
+	)
+
+	flags := map[plumbing.Hash]int{}
+	queue := make([]*object.Commit, 0)
+	push := func(h plumbing.Hash, flag int) error {
+		if flags[h]&flag != 0 {
+			return nil
+		}
+		seen := flags[h] != 0
+		flags[h] |= flag
+		if seen {
+			return nil
+		}
+		c, err := repo.CommitObject(h)
+		if err == plumbing.ErrObjectNotFound && flag == excluded {
+			// the remote has commits we have not fetched
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("could not read commit %s: %v", h, err)
+		}
+		queue = append(queue, c)
+		return nil
+	}
+
+	if err := push(tip, included); err != nil {
+		return nil, err
+	}
+	for _, h := range exclude {
+		if err := push(h, excluded); err != nil {
+			return nil, err
+		}
+	}
+
+	commits := make([]*object.Commit, 0)
+	for len(queue) > 0 && len(commits) < limit {
+		// stop once everything left to walk is already on the remote
+		pending := false
+		for _, c := range queue {
+			if flags[c.Hash]&excluded == 0 {
+				pending = true
+				break
+			}
+		}
+		if !pending {
+			break
+		}
+
+		sort.SliceStable(queue, func(i, j int) bool {
+			return queue[i].Committer.When.After(queue[j].Committer.When)
+		})
+		c := queue[0]
+		queue = queue[1:]
+
+		flag := flags[c.Hash]
+		if flag&excluded == 0 {
+			commits = append(commits, c)
+		}
+		for _, p := range c.ParentHashes {
+			if err := push(p, flag); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// a commit may have been reached from tip before it was found to be on the remote
+	result := make([]*object.Commit, 0, len(commits))
+	for _, c := range commits {
+		if flags[c.Hash]&excluded == 0 {
+			result = append(result, c)
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,76 @@
+package push
+
+import (
+	"github.com/go-git/go-billy/v5/memfs"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/storage/memory"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+	"time"
+)
+
+func commitChain(t *testing.T, r *git.Repository, subjects ...string) []plumbing.Hash {
+	w, _ := r.Worktree()
+	when := time.Date(2021, 6, 1, 9, 0, 0, 0, time.UTC)
+	hashes := make([]plumbing.Hash, 0, len(subjects))
+	for i, s := range subjects {
+		h, err := w.Commit(s, &git.CommitOptions{
+			Author: &object.Signature{Name: "Mal Reynolds", Email: "mal@serenity.com", When: when.Add(time.Duration(i) * time.Minute)},
+		})
+		if err != nil {
+			t.Fatalf("committing %s: %v", s, err)
+		}
+		hashes = append(hashes, h)
+	}
+	return hashes
+}
+
+func subjects(commits []*object.Commit) []string {
+	s := make([]string, 0, len(commits))
+	for _, c := range commits {
+		s = append(s, strings.TrimSpace(c.Message))
+	}
+	return s
+}
+
+func TestUpdateCommits(t *testing.T) {
+	r, _ := git.Init(memory.NewStorage(), memfs.New())
+	h := commitChain(t, r, "root", "a", "b", "c", "d")
+
+	existing := Update{LocalSha: h[4], RemoteSha: h[2]}
+	commits, err := existing.Commits(r, "origin", DefaultLimit)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"d", "c"}, subjects(commits))
+
+	_ = r.Storer.SetReference(plumbing.NewHashReference("refs/remotes/origin/main", h[1]))
+	newRef := Update{LocalSha: h[4], RemoteSha: plumbing.ZeroHash}
+	commits, err = newRef.Commits(r, "origin", DefaultLimit)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"d", "c", "b"}, subjects(commits))
+
+	commits, err = newRef.Commits(r, "origin", 2)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"d", "c"}, subjects(commits))
+
+	deleted := Update{LocalSha: plumbing.ZeroHash, RemoteSha: h[2]}
+	commits, err = deleted.Commits(r, "origin", DefaultLimit)
+	assert.NoError(t, err)
+	assert.Empty(t, commits)
+}
+
+func TestParseUpdates(t *testing.T) {
+	updates, err := ParseUpdates(strings.NewReader(`refs/heads/feat-1 67890abcdef0123456789abcdef0123456789abc refs/heads/feat-1 0000000000000000000000000000000000000000
+`))
+	assert.NoError(t, err)
+	if assert.Len(t, updates, 1) {
+		assert.Equal(t, plumbing.ReferenceName("refs/heads/feat-1"), updates[0].LocalRef)
+		assert.True(t, updates[0].IsNewRef())
+		assert.False(t, updates[0].IsDelete())
+	}
+
+	_, err = ParseUpdates(strings.NewReader("refs/heads/feat-1 abc\n"))
+	assert.Error(t, err)
+}
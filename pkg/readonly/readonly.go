@@ -0,0 +1,87 @@
+package readonly
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/davidalpert/go-githooks/pkg/exitcode"
+	"io"
+	"os"
+	"strings"
+)
+
+/*
+ * Read-only mode lets a team trial a policy on real work before enforcing it: with
+ * GIT_HOOKS_READONLY=1 every hook still runs its checks and transformations, but
+ * reports what it would have changed or blocked on stderr instead of editing the
+ * message, restaging files, rewriting commits or failing.
+ */
+
+// Out is where reports go; replaced in tests
+var Out io.Writer = os.Stderr
+
+// Enabled reports whether GIT_HOOKS_READONLY is set
+func Enabled() bool {
+	v := strings.ToLower(os.Getenv("GIT_HOOKS_READONLY"))
+	return v != "" && v != "0" && v != "false"
+}
+
+// Report writes one line saying what hook would have done
+func Report(hook, format string, args ...interface{}) {
+	fmt.Fprintf(Out, "go-githooks (read-only) %s: would have %s\n", hook, fmt.Sprintf(format, args...))
+}
+
+// Allow reports err as the failure hook would have ended with and returns nil, so
+// the commit or push goes ahead; outside read-only mode err is returned as is
+func Allow(hook string, err error) error {
+	if err == nil || !Enabled() {
+		return err
+	}
+	Report(hook, "failed (%s): %v", exitcode.Of(err).Name(), err)
+	return nil
+}
+
+// Changes reports the lines of what which would have been removed and added
+func Changes(hook, what string, before, after []byte) {
+	if bytes.Equal(before, after) {
+		return
+	}
+	Report(hook, "changed %s:", what)
+	removed, added := lineDiff(before, after)
+	for _, l := range removed {
+		fmt.Fprintf(Out, "  - %s\n", l)
+	}
+	for _, l := range added {
+		fmt.Fprintf(Out, "  + %s\n", l)
+	}
+}
+
+// lineDiff is a coarse diff: the lines only in before and the lines only in after,
+// which is enough to show what a hook adds to or removes from a message
+func lineDiff(before, after []byte) ([]string, []string) {
+	count := func(b []byte) map[string]int {
+		m := map[string]int{}
+		for _, l := range strings.Split(string(b), "\n") {
+			m[l]++
+		}
+		return m
+	}
+	inBefore, inAfter := count(before), count(after)
+
+	removed := make([]string, 0)
+	for _, l := range strings.Split(string(before), "\n") {
+		if inAfter[l] > 0 {
+			inAfter[l]--
+		} else if strings.TrimSpace(l) != "" {
+			removed = append(removed, l)
+		}
+	}
+	added := make([]string, 0)
+	for _, l := range strings.Split(string(after), "\n") {
+		if inBefore[l] > 0 {
+			inBefore[l]--
+		} else if strings.TrimSpace(l) != "" {
+			added = append(added, l)
+		}
+	}
+	return removed, added
+}
@@ -0,0 +1,43 @@
+package readonly
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/davidalpert/go-githooks/pkg/exitcode"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"testing"
+)
+
+func TestAllow(t *testing.T) {
+	var out bytes.Buffer
+	Out = &out
+	defer func() { Out = os.Stderr }()
+	defer os.Setenv("GIT_HOOKS_READONLY", os.Getenv("GIT_HOOKS_READONLY"))
+
+	blocked := exitcode.Wrap(exitcode.Violation, fmt.Errorf("the message breaks the rules"))
+	os.Setenv("GIT_HOOKS_READONLY", "")
+	assert.Equal(t, blocked, Allow("commit-msg", blocked))
+	assert.Empty(t, out.String())
+
+	os.Setenv("GIT_HOOKS_READONLY", "1")
+	assert.NoError(t, Allow("commit-msg", blocked))
+	assert.Equal(t, "go-githooks (read-only) commit-msg: would have failed (violation): the message breaks the rules\n", out.String())
+}
+
+func TestChanges(t *testing.T) {
+	var out bytes.Buffer
+	Out = &out
+	defer func() { Out = os.Stderr }()
+
+	Changes("prepare-commit-msg", "the message", []byte("fix login\n\n# comment\n"), []byte("[FEAT-1] fix login\n\nCo-authored-by: Zoe <zoe@serenity.com>\n\n# comment\n"))
+	assert.Equal(t, `go-githooks (read-only) prepare-commit-msg: would have changed the message:
+  - fix login
+  + [FEAT-1] fix login
+  + Co-authored-by: Zoe <zoe@serenity.com>
+`, out.String())
+
+	out.Reset()
+	Changes("prepare-commit-msg", "the message", []byte("same\n"), []byte("same\n"))
+	assert.Empty(t, out.String())
+}
@@ -0,0 +1,367 @@
+package remoteconfig
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/BurntSushi/toml"
+	"github.com/davidalpert/go-githooks/pkg/cienv"
+	"github.com/davidalpert/go-githooks/pkg/fileio"
+	"github.com/davidalpert/go-githooks/pkg/policysig"
+	config2 "github.com/go-git/go-git/v5/plumbing/format/config"
+	"gopkg.in/yaml.v3"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+/*
+ * Remote config lets a platform team publish hook policy once and have every repo
+ * pick it up, without committing config to each of them:
+ *
+ * [go-githooks]
+ *     configUrl = https://example.com/githooks.toml
+ *     configPublicKey = <base64 ed25519 public key>   # optional; requires <configUrl>.sig
+ *     configRefresh = 24h                             # optional; how long a fetched copy is used
+ *
+ * Its signature is also checked against the trusted policy keys, see pkg/policysig.
+ *
+ * The file is TOML (or YAML, when the url ends in .yml or .yaml): top-level keys are [go-githooks] options and each table is a
+ * [go-githooks "<table>"] subsection, e.g.
+ *
+ *     preset = "conventional"
+ *
+ *     [rules]
+ *     dco-signoff = "error"
+ *
+ * It is cached locally and re-fetched (with If-None-Match) once it is older than
+ * configRefresh. It sits below the global and repo config, so either can override it.
+ */
+
+// DefaultRefresh is how long a fetched copy is used before asking the server again
+const DefaultRefresh = 24 * time.Hour
+
+// Source describes where the shared config comes from
+type Source struct {
+	URL       string
+	PublicKey string
+	Refresh   time.Duration
+	Policy    *policysig.Policy // the keys trusted to sign policy; nil trusts any
+
+	// Dir holds the cached copies; CacheDir() when empty
+	Dir string
+}
+
+type meta struct {
+	URL       string    `json:"url"`
+	PublicKey string    `json:"publicKey,omitempty"`
+	Policy    string    `json:"policy,omitempty"`
+	ETag      string    `json:"etag,omitempty"`
+	FetchedAt time.Time `json:"fetchedAt"`
+}
+
+// CacheDir is where fetched copies are kept; override with GIT_HOOKS_CACHE_DIR
+func CacheDir() string {
+	if d := os.Getenv("GIT_HOOKS_CACHE_DIR"); d != "" {
+		return d
+	}
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = "."
+	}
+	return filepath.Join(dir, "go-githooks", "remote-config")
+}
+
+func (s Source) cachePath() string {
+	dir := s.Dir
+	if dir == "" {
+		dir = CacheDir()
+	}
+	sum := sha256.Sum256([]byte(s.URL))
+	return filepath.Join(dir, hex.EncodeToString(sum[:8])+".toml")
+}
+
+func (s Source) metaPath() string {
+	return strings.TrimSuffix(s.cachePath(), ".toml") + ".json"
+}
+
+func (s Source) readMeta() (meta, bool) {
+	m := meta{}
+	data, err := fileio.ReadFile(s.metaPath())
+	if err != nil || json.Unmarshal(data, &m) != nil {
+		return meta{}, false
+	}
+	// a copy fetched for another key was not verified against this one
+	if m.URL != s.URL || m.PublicKey != s.PublicKey || m.Policy != s.Policy.Fingerprint() {
+		return meta{}, false
+	}
+	if _, err := os.Stat(s.cachePath()); err != nil {
+		return meta{}, false
+	}
+	return m, true
+}
+
+func (s Source) writeMeta(m meta) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := fileio.WriteFile(s.metaPath(), append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("could not write '%s': %v", s.metaPath(), err)
+	}
+	return nil
+}
+
+// Load returns the cached config, fetching it first when there is no cached copy or
+// it is older than Refresh; when the server cannot be reached a stale copy is used
+func (s Source) Load(client *http.Client) (*config2.Config, error) {
+	data, err := s.Bytes(client)
+	if err != nil {
+		return nil, err
+	}
+	values, err := Decode(s.URL, data)
+	if err != nil {
+		return nil, err
+	}
+	return FromMap(values)
+}
+
+// Bytes returns the cached file the way Load does, without parsing it
+func (s Source) Bytes(client *http.Client) ([]byte, error) {
+	m, cached := s.readMeta()
+	refresh := s.Refresh
+	if refresh <= 0 {
+		refresh = DefaultRefresh
+	}
+
+	if !cached || time.Since(m.FetchedAt) > refresh {
+		if _, err := s.Fetch(client); err != nil {
+			if !cached {
+				return nil, err
+			}
+			fmt.Fprintf(os.Stderr, "go-githooks: using cached config from %s: %v\n", m.FetchedAt.Format(time.RFC3339), err)
+		}
+	}
+
+	data, err := fileio.ReadFile(s.cachePath())
+	if err != nil {
+		return nil, fmt.Errorf("could not read '%s': %v", s.cachePath(), err)
+	}
+	return data, nil
+}
+
+// Fetch downloads the config into the cache unless the server reports (by ETag) that
+// the cached copy is current; it reports whether the cached copy changed
+func (s Source) Fetch(client *http.Client) (bool, error) {
+	if client == nil {
+		client = &http.Client{Timeout: cienv.Timeout(5 * time.Second)}
+	}
+	m, cached := s.readMeta()
+
+	req, err := http.NewRequest(http.MethodGet, s.URL, nil)
+	if err != nil {
+		return false, fmt.Errorf("could not fetch '%s': %v", s.URL, err)
+	}
+	if cached && m.ETag != "" {
+		req.Header.Set("If-None-Match", m.ETag)
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("could not fetch '%s': %v", s.URL, err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusNotModified && cached {
+		m.FetchedAt = time.Now().UTC()
+		return false, s.writeMeta(m)
+	}
+	if res.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("could not fetch '%s': %s", s.URL, res.Status)
+	}
+	data, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return false, fmt.Errorf("could not fetch '%s': %v", s.URL, err)
+	}
+
+	if s.PublicKey != "" {
+		sig, err := s.fetchSignature(client)
+		if err != nil {
+			return false, err
+		}
+		if err := Verify(data, sig, s.PublicKey); err != nil {
+			return false, fmt.Errorf("refusing config from '%s': %v", s.URL, err)
+		}
+	}
+	if s.Policy.Enabled() {
+		sig, err := FetchDetachedSignature(client, s.URL)
+		if err != nil {
+			return false, err
+		}
+		if err := s.Policy.Check(s.URL, data, sig); err != nil {
+			return false, err
+		}
+	}
+	if _, err := Decode(s.URL, data); err != nil {
+		return false, fmt.Errorf("refusing config from '%s': %v", s.URL, err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.cachePath()), 0755); err != nil {
+		return false, fmt.Errorf("could not create '%s': %v", filepath.Dir(s.cachePath()), err)
+	}
+	if err := fileio.WriteFile(s.cachePath(), data, 0644); err != nil {
+		return false, fmt.Errorf("could not write '%s': %v", s.cachePath(), err)
+	}
+	return true, s.writeMeta(meta{
+		URL:       s.URL,
+		PublicKey: s.PublicKey,
+		Policy:    s.Policy.Fingerprint(),
+		ETag:      res.Header.Get("ETag"),
+		FetchedAt: time.Now().UTC(),
+	})
+}
+
+func (s Source) fetchSignature(client *http.Client) ([]byte, error) {
+	res, err := client.Get(s.URL + ".sig")
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch signature '%s.sig': %v", s.URL, err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("could not fetch signature '%s.sig': %s", s.URL, res.Status)
+	}
+	return ioutil.ReadAll(res.Body)
+}
+
+// FetchDetachedSignature fetches the first of the policy signatures next to url
+// which exists, or returns nil when there is none
+func FetchDetachedSignature(client *http.Client, url string) ([]byte, error) {
+	if client == nil {
+		client = &http.Client{Timeout: cienv.Timeout(5 * time.Second)}
+	}
+	for _, ext := range policysig.Extensions {
+		res, err := client.Get(url + ext)
+		if err != nil {
+			return nil, fmt.Errorf("could not fetch signature '%s%s': %v", url, ext, err)
+		}
+		data, err := ioutil.ReadAll(res.Body)
+		res.Body.Close()
+		switch {
+		case res.StatusCode == http.StatusNotFound:
+			continue
+		case res.StatusCode != http.StatusOK:
+			return nil, fmt.Errorf("could not fetch signature '%s%s': %s", url, ext, res.Status)
+		case err != nil:
+			return nil, fmt.Errorf("could not fetch signature '%s%s': %v", url, ext, err)
+		}
+		return data, nil
+	}
+	return nil, nil
+}
+
+// Verify checks a base64-encoded ed25519 signature of data against a base64-encoded public key
+func Verify(data, signature []byte, publicKey string) error {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(publicKey))
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return fmt.Errorf("configPublicKey is not a base64 ed25519 public key")
+	}
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(signature)))
+	if err != nil {
+		return fmt.Errorf("could not decode signature: %v", err)
+	}
+	if !ed25519.Verify(ed25519.PublicKey(key), data, sig) {
+		return fmt.Errorf("signature does not match")
+	}
+	return nil
+}
+
+// Parse reads a TOML config file into go-githooks options
+func Parse(data []byte) (*config2.Config, error) {
+	values, err := Decode("githooks.toml", data)
+	if err != nil {
+		return nil, err
+	}
+	return FromMap(values)
+}
+
+// Decode reads a config file as YAML when name ends in .yml or .yaml, as TOML otherwise
+func Decode(name string, data []byte) (map[string]interface{}, error) {
+	values := map[string]interface{}{}
+	ext := strings.ToLower(path.Ext(name))
+	if ext == ".yml" || ext == ".yaml" {
+		if err := yaml.Unmarshal(data, &values); err != nil {
+			return nil, fmt.Errorf("could not parse config: %v", err)
+		}
+	} else if _, err := toml.Decode(string(data), &values); err != nil {
+		return nil, fmt.Errorf("could not parse config: %v", err)
+	}
+	if values == nil {
+		values = map[string]interface{}{}
+	}
+	return values, nil
+}
+
+// FromMap turns decoded config into go-githooks options: top-level values are
+// [go-githooks] options and each table is a [go-githooks "<table>"] subsection
+func FromMap(values map[string]interface{}) (*config2.Config, error) {
+	c := config2.New()
+	for _, key := range sortedKeys(values) {
+		if values[key] == nil {
+			continue
+		}
+		table, ok := values[key].(map[string]interface{})
+		if !ok {
+			v, err := optionValue(key, values[key])
+			if err != nil {
+				return nil, err
+			}
+			c.AddOption("go-githooks", config2.NoSubsection, key, v)
+			continue
+		}
+		for _, option := range sortedKeys(table) {
+			if table[option] == nil {
+				continue
+			}
+			v, err := optionValue(key+"."+option, table[option])
+			if err != nil {
+				return nil, err
+			}
+			c.AddOption("go-githooks", key, option, v)
+		}
+	}
+	return c, nil
+}
+
+func optionValue(key string, value interface{}) (string, error) {
+	switch v := value.(type) {
+	case []interface{}:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			s, err := optionValue(key, item)
+			if err != nil {
+				return "", err
+			}
+			items = append(items, s)
+		}
+		return strings.Join(items, ","), nil
+	case map[string]interface{}:
+		return "", fmt.Errorf("could not parse config: '%s' nests tables more than one level deep", key)
+	}
+	return fmt.Sprint(value), nil
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,83 @@
+package remoteconfig
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+const policy = `
+preset = "conventional"
+
+[rules]
+dco-signoff = "error"
+
+[pre-commit]
+jsonSchemas = ["config/*.yml=schemas/config.json", "*.json=schemas/any.json"]
+`
+
+func TestParse(t *testing.T) {
+	c, err := Parse([]byte(policy))
+	assert.NoError(t, err)
+
+	s := c.Section("go-githooks")
+	assert.Equal(t, "conventional", s.Option("preset"))
+	assert.Equal(t, "error", s.Subsection("rules").Option("dco-signoff"))
+	assert.Equal(t, "config/*.yml=schemas/config.json,*.json=schemas/any.json", s.Subsection("pre-commit").Option("jsonSchemas"))
+
+	_, err = Parse([]byte("[a.b]\nc = 1\n"))
+	assert.Error(t, err)
+}
+
+func TestFetch(t *testing.T) {
+	public, private, _ := ed25519.GenerateKey(nil)
+	signature := base64.StdEncoding.EncodeToString(ed25519.Sign(private, []byte(policy)))
+
+	requests, notModified := 0, 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/githooks.toml":
+			requests++
+			if r.Header.Get("If-None-Match") == `"v1"` {
+				notModified++
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			w.Header().Set("ETag", `"v1"`)
+			_, _ = w.Write([]byte(policy))
+		case "/githooks.toml.sig":
+			_, _ = w.Write([]byte(signature))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	src := Source{URL: server.URL + "/githooks.toml", PublicKey: base64.StdEncoding.EncodeToString(public), Dir: t.TempDir()}
+
+	changed, err := src.Fetch(server.Client())
+	assert.NoError(t, err)
+	assert.True(t, changed)
+
+	changed, err = src.Fetch(server.Client())
+	assert.NoError(t, err)
+	assert.False(t, changed)
+	assert.Equal(t, 1, notModified)
+
+	c, err := src.Load(server.Client())
+	assert.NoError(t, err)
+	assert.Equal(t, "conventional", c.Section("go-githooks").Option("preset"))
+	assert.Equal(t, 2, requests, "a fresh cached copy is used without asking the server")
+
+	otherKey, _, _ := ed25519.GenerateKey(nil)
+	untrusted := Source{URL: src.URL, PublicKey: base64.StdEncoding.EncodeToString(otherKey), Dir: t.TempDir()}
+	_, err = untrusted.Fetch(server.Client())
+	assert.Error(t, err)
+
+	missing := Source{URL: server.URL + "/missing.toml", Dir: t.TempDir()}
+	_, err = missing.Load(server.Client())
+	assert.Error(t, err)
+}
@@ -0,0 +1,205 @@
+package repoconfig
+
+import (
+	"fmt"
+	"github.com/davidalpert/go-githooks/pkg/fileio"
+	"github.com/davidalpert/go-githooks/pkg/policysig"
+	"github.com/davidalpert/go-githooks/pkg/remoteconfig"
+	"github.com/go-git/go-billy/v5"
+	"github.com/go-git/go-billy/v5/util"
+	config2 "github.com/go-git/go-git/v5/plumbing/format/config"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+/*
+ * .githooks.yml is hook policy committed to the repo itself, so that everyone who
+ * clones it gets the same rules. It uses the same layout as remote config: top-level
+ * keys are [go-githooks] options and each map is a [go-githooks "<map>"] subsection.
+ *
+ * A file can inherit from one or more base files, the way ESLint configs do:
+ *
+ *     extends:
+ *       - https://example.com/org-githooks.yml   # fetched and cached like configUrl
+ *       - ../shared/githooks.yml                 # relative to the extending file
+ *
+ *     rules:
+ *       dco-signoff: error
+ *       ticket-reference: ~                      # drop what a base file set
+ *
+ * Bases are merged in order, then the extending file on top: maps merge key by key,
+ * any other value (including lists) replaces the inherited one, and a null value
+ * removes it.
+ *
+ * Each file is checked against the trusted policy keys with the detached signature
+ * next to it, e.g. .githooks.yml.minisig; see pkg/policysig.
+ */
+
+// File is the name of the config file at the root of the worktree
+const File = ".githooks.yml"
+
+// maxDepth bounds extends chains so a cycle through URLs cannot recurse forever
+const maxDepth = 10
+
+// Load reads .githooks.yml from the root of fs with everything it extends merged in,
+// refusing any file policy does not accept; it returns nil when there is no such file
+func Load(fs billy.Filesystem, policy *policysig.Policy) (*config2.Config, error) {
+	values, err := loadRepoFile(fs, policy, File, 0)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return remoteconfig.FromMap(values)
+}
+
+func loadRepoFile(fs billy.Filesystem, policy *policysig.Policy, name string, depth int) (map[string]interface{}, error) {
+	data, err := util.ReadFile(fs, name)
+	if err != nil {
+		if os.IsNotExist(err) && depth == 0 {
+			return nil, err
+		}
+		return nil, fmt.Errorf("could not read '%s': %v", name, err)
+	}
+	if err := checkSignature(policy, name, data, func(sig string) ([]byte, error) { return util.ReadFile(fs, sig) }); err != nil {
+		return nil, err
+	}
+	return resolve(policy, name, data, depth, func(ref string) (map[string]interface{}, error) {
+		return loadRepoFile(fs, policy, path.Join(path.Dir(name), ref), depth+1)
+	})
+}
+
+func loadLocalFile(policy *policysig.Policy, name string, depth int) (map[string]interface{}, error) {
+	data, err := fileio.ReadFile(name)
+	if err != nil {
+		return nil, fmt.Errorf("could not read '%s': %v", name, err)
+	}
+	if err := checkSignature(policy, name, data, fileio.ReadFile); err != nil {
+		return nil, err
+	}
+	return resolve(policy, name, data, depth, func(ref string) (map[string]interface{}, error) {
+		return loadLocalFile(policy, filepath.Join(filepath.Dir(name), ref), depth+1)
+	})
+}
+
+// checkSignature finds the detached signature of the file name with read, and
+// checks the file against policy
+func checkSignature(policy *policysig.Policy, name string, data []byte, read func(string) ([]byte, error)) error {
+	if !policy.Enabled() {
+		return nil
+	}
+	var sig []byte
+	for _, ext := range policysig.Extensions {
+		b, err := read(name + ext)
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return fmt.Errorf("could not read '%s%s': %v", name, ext, err)
+		}
+		sig = b
+		break
+	}
+	return policy.Check(name, data, sig)
+}
+
+func loadURL(policy *policysig.Policy, u string, depth int) (map[string]interface{}, error) {
+	// the source checks the signature as it fetches
+	data, err := remoteconfig.Source{URL: u, Policy: policy}.Bytes(nil)
+	if err != nil {
+		return nil, err
+	}
+	return resolve(policy, u, data, depth, func(ref string) (map[string]interface{}, error) {
+		base, err := url.Parse(u)
+		if err != nil {
+			return nil, err
+		}
+		r, err := base.Parse(ref)
+		if err != nil {
+			return nil, fmt.Errorf("'%s': could not resolve extends '%s': %v", u, ref, err)
+		}
+		return loadURL(policy, r.String(), depth+1)
+	})
+}
+
+// resolve decodes one file and merges it over the files it extends; relative refers
+// to a path relative to that file, anything else is a URL or an absolute path
+func resolve(policy *policysig.Policy, name string, data []byte, depth int, relative func(string) (map[string]interface{}, error)) (map[string]interface{}, error) {
+	if depth > maxDepth {
+		return nil, fmt.Errorf("'%s' is more than %d levels of extends deep; is there a cycle?", name, maxDepth)
+	}
+
+	values, err := remoteconfig.Decode(name, data)
+	if err != nil {
+		return nil, fmt.Errorf("'%s': %v", name, err)
+	}
+
+	refs, err := extendsOf(values)
+	if err != nil {
+		return nil, fmt.Errorf("'%s': %v", name, err)
+	}
+	delete(values, "extends")
+
+	merged := map[string]interface{}{}
+	for _, ref := range refs {
+		var base map[string]interface{}
+		switch {
+		case strings.HasPrefix(ref, "https://") || strings.HasPrefix(ref, "http://"):
+			base, err = loadURL(policy, ref, depth+1)
+		case filepath.IsAbs(ref):
+			base, err = loadLocalFile(policy, ref, depth+1)
+		default:
+			base, err = relative(ref)
+		}
+		if err != nil {
+			return nil, err
+		}
+		merged = Merge(merged, base)
+	}
+	return Merge(merged, values), nil
+}
+
+func extendsOf(values map[string]interface{}) ([]string, error) {
+	switch e := values["extends"].(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return []string{e}, nil
+	case []interface{}:
+		refs := make([]string, 0, len(e))
+		for _, r := range e {
+			s, ok := r.(string)
+			if !ok {
+				return nil, fmt.Errorf("extends must list paths or urls, got %v", r)
+			}
+			refs = append(refs, s)
+		}
+		return refs, nil
+	}
+	return nil, fmt.Errorf("extends must be a path, a url or a list of them")
+}
+
+// Merge returns base with override deep-merged on top: maps merge key by key, other
+// values replace the inherited one, and a nil value removes it
+func Merge(base, override map[string]interface{}) map[string]interface{} {
+	merged := map[string]interface{}{}
+	for k, v := range base {
+		merged[k] = v
+	}
+	for k, v := range override {
+		if v == nil {
+			delete(merged, k)
+			continue
+		}
+		baseMap, baseIsMap := merged[k].(map[string]interface{})
+		overrideMap, overrideIsMap := v.(map[string]interface{})
+		if baseIsMap && overrideIsMap {
+			merged[k] = Merge(baseMap, overrideMap)
+		} else {
+			merged[k] = v
+		}
+	}
+	return merged
+}
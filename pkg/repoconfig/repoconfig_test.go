@@ -0,0 +1,98 @@
+package repoconfig
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"github.com/davidalpert/go-githooks/pkg/policysig"
+	"github.com/go-git/go-billy/v5/memfs"
+	"github.com/go-git/go-billy/v5/util"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+func TestLoadExtends(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/org/githooks.yml":
+			_, _ = w.Write([]byte("extends: base.yml\nrules:\n  dco-signoff: error\n  ticket-reference: error\ncommit-message:\n  conventionalTypes: [feat, fix]\n"))
+		case "/org/base.yml":
+			_, _ = w.Write([]byte("preset: conventional\nrules:\n  empty-message: error\n"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	os.Setenv("GIT_HOOKS_CACHE_DIR", t.TempDir())
+	defer os.Unsetenv("GIT_HOOKS_CACHE_DIR")
+
+	fs := memfs.New()
+	_ = util.WriteFile(fs, ".githooks/team.yml", []byte("commit-message:\n  ticketPattern: 'SER-[0-9]+'\n"), 0644)
+	_ = util.WriteFile(fs, File, []byte(`
+extends:
+  - `+server.URL+`/org/githooks.yml
+  - .githooks/team.yml
+rules:
+  ticket-reference: ~
+  empty-message: warning
+commit-message:
+  conventionalTypes: [feat, fix, chore]
+`), 0644)
+
+	c, err := Load(fs, nil)
+	assert.NoError(t, err)
+
+	s := c.Section("go-githooks")
+	assert.Equal(t, "conventional", s.Option("preset"), "inherited through two levels of extends")
+	assert.Equal(t, "error", s.Subsection("rules").Option("dco-signoff"))
+	assert.Equal(t, "warning", s.Subsection("rules").Option("empty-message"), "the extending file wins")
+	assert.False(t, s.Subsection("rules").HasOption("ticket-reference"), "null removes an inherited value")
+	assert.Equal(t, "feat,fix,chore", s.Subsection("commit-message").Option("conventionalTypes"), "lists replace rather than append")
+	assert.Equal(t, "SER-[0-9]+", s.Subsection("commit-message").Option("ticketPattern"))
+}
+
+func TestLoadMissing(t *testing.T) {
+	c, err := Load(memfs.New(), nil)
+	assert.NoError(t, err)
+	assert.Nil(t, c)
+
+	fs := memfs.New()
+	_ = util.WriteFile(fs, File, []byte("extends: missing.yml\n"), 0644)
+	_, err = Load(fs, nil)
+	assert.Error(t, err)
+
+	_ = util.WriteFile(fs, File, []byte("extends: .githooks.yml\n"), 0644)
+	_, err = Load(fs, nil)
+	assert.Error(t, err, "a cycle is reported rather than followed forever")
+}
+
+func TestLoadSigned(t *testing.T) {
+	pub, priv, _ := ed25519.GenerateKey(nil)
+	policy, err := policysig.New([]string{base64.StdEncoding.EncodeToString(pub)}, true)
+	assert.NoError(t, err)
+	sign := func(data string) []byte {
+		return []byte(base64.StdEncoding.EncodeToString(ed25519.Sign(priv, []byte(data))))
+	}
+
+	fs := memfs.New()
+	base := "rules:\n  dco-signoff: error\n"
+	root := "extends: .githooks/base.yml\nrules:\n  empty-message: error\n"
+	_ = util.WriteFile(fs, File, []byte(root), 0644)
+	_ = util.WriteFile(fs, File+".sig", sign(root), 0644)
+	_ = util.WriteFile(fs, ".githooks/base.yml", []byte(base), 0644)
+
+	_, err = Load(fs, policy)
+	assert.EqualError(t, err, "refusing unsigned policy '.githooks/base.yml' in strict mode", "every file it extends is checked too")
+
+	_ = util.WriteFile(fs, ".githooks/base.yml.sig", sign(base), 0644)
+	c, err := Load(fs, policy)
+	assert.NoError(t, err)
+	assert.Equal(t, "error", c.Section("go-githooks").Subsection("rules").Option("dco-signoff"))
+
+	_ = util.WriteFile(fs, File, []byte(root+"  ticket-reference: error\n"), 0644)
+	_, err = Load(fs, policy)
+	assert.EqualError(t, err, "refusing policy '.githooks.yml': signature does not match")
+}
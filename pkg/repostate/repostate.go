@@ -0,0 +1,123 @@
+package repostate
+
+import (
+	"github.com/go-git/go-billy/v5"
+	"github.com/go-git/go-billy/v5/util"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/storage/filesystem"
+	"strings"
+)
+
+// State describes the situations in which HEAD does not simply name a branch
+type State struct {
+	Bare      bool
+	Unborn    bool // on a branch with no commits yet
+	Detached  bool // HEAD is a commit rather than a branch
+	Rebasing  bool
+	Bisecting bool
+
+	// Branch is the branch HEAD is on or, while detached for a rebase or bisect,
+	// the branch that was checked out when it started; empty otherwise
+	Branch string
+	Head   plumbing.Hash // zero when Unborn
+}
+
+// Detect reads the state from HEAD and the files git keeps in the git directory
+// while a rebase or bisect is in progress
+func Detect(repo *git.Repository) (State, error) {
+	s := State{}
+	if _, err := repo.Worktree(); err == git.ErrIsBareRepository {
+		s.Bare = true
+	}
+
+	ref, err := repo.Storer.Reference(plumbing.HEAD)
+	if err != nil {
+		return s, err
+	}
+	if ref.Type() == plumbing.SymbolicReference {
+		s.Branch = ref.Target().Short()
+		resolved, err := repo.Reference(ref.Target(), true)
+		if err == plumbing.ErrReferenceNotFound {
+			s.Unborn = true
+			return s, nil
+		} else if err != nil {
+			return s, err
+		}
+		s.Head = resolved.Hash()
+		return s, nil
+	}
+
+	s.Detached = true
+	s.Head = ref.Hash()
+
+	dotGit := gitDir(repo)
+	if dotGit == nil {
+		return s, nil
+	}
+	for _, f := range []string{"rebase-merge/head-name", "rebase-apply/head-name"} {
+		if name, ok := readRef(dotGit, f); ok {
+			s.Rebasing = true
+			s.Branch = name
+			return s, nil
+		}
+	}
+	if name, ok := readRef(dotGit, "BISECT_START"); ok {
+		s.Bisecting = true
+		s.Branch = name
+	}
+	return s, nil
+}
+
+// ShortHead is the abbreviated hash of HEAD, as git prints it
+func (s State) ShortHead() string {
+	if s.Head.IsZero() {
+		return ""
+	}
+	return s.Head.String()[:7]
+}
+
+// gitDir is the .git directory, or nil for repos which are not stored on disk
+func gitDir(repo *git.Repository) billy.Filesystem {
+	if fs, ok := repo.Storer.(*filesystem.Storage); ok {
+		return fs.Filesystem()
+	}
+	return nil
+}
+
+// readRef reads a branch name from one of git's state files, which hold either a
+// full ref (refs/heads/feature) or, for BISECT_START, a branch name or commit
+func readRef(fs billy.Filesystem, name string) (string, bool) {
+	b, err := util.ReadFile(fs, name)
+	if err != nil {
+		return "", false
+	}
+	v := strings.TrimSpace(string(b))
+	if v == "" || v == "detached HEAD" {
+		return "", true
+	}
+	if plumbing.IsHash(v) {
+		return "", true
+	}
+	return plumbing.ReferenceName(v).Short(), true
+}
+
+// MergeHeads are the commits being merged while a merge waits to be committed, from
+// MERGE_HEAD (one line each, more than one for an octopus merge); nil otherwise
+func MergeHeads(repo *git.Repository) []plumbing.Hash {
+	dotGit := gitDir(repo)
+	if dotGit == nil {
+		return nil
+	}
+	b, err := util.ReadFile(dotGit, "MERGE_HEAD")
+	if err != nil {
+		return nil
+	}
+	heads := make([]plumbing.Hash, 0)
+	for _, l := range strings.Fields(string(b)) {
+		if plumbing.IsHash(l) {
+			heads = append(heads, plumbing.NewHash(l))
+		}
+	}
+	return heads
+}
@@ -0,0 +1,57 @@
+package repostate
+
+import (
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDetect(t *testing.T) {
+	root := t.TempDir()
+	r, err := git.PlainInit(root, false)
+	if err != nil {
+		t.Fatalf("init: %v", err)
+	}
+
+	s, err := Detect(r)
+	assert.NoError(t, err)
+	assert.Equal(t, State{Unborn: true, Branch: "master"}, s)
+
+	w, _ := r.Worktree()
+	_ = ioutil.WriteFile(filepath.Join(root, "a.txt"), []byte("a\n"), 0644)
+	_, _ = w.Add("a.txt")
+	head, err := w.Commit("first", &git.CommitOptions{Author: &object.Signature{Name: "Mal Reynolds", Email: "mal@serenity.com", When: time.Now()}})
+	if err != nil {
+		t.Fatalf("committing: %v", err)
+	}
+
+	s, _ = Detect(r)
+	assert.Equal(t, State{Branch: "master", Head: head}, s)
+
+	_ = r.Storer.SetReference(plumbing.NewHashReference(plumbing.HEAD, head))
+	s, _ = Detect(r)
+	assert.Equal(t, State{Detached: true, Head: head}, s)
+	assert.Equal(t, head.String()[:7], s.ShortHead())
+
+	_ = os.MkdirAll(filepath.Join(root, ".git", "rebase-merge"), 0755)
+	_ = ioutil.WriteFile(filepath.Join(root, ".git", "rebase-merge", "head-name"), []byte("refs/heads/feature/FEAT-1\n"), 0644)
+	s, _ = Detect(r)
+	assert.Equal(t, State{Detached: true, Rebasing: true, Branch: "feature/FEAT-1", Head: head}, s)
+
+	_ = os.RemoveAll(filepath.Join(root, ".git", "rebase-merge"))
+	_ = ioutil.WriteFile(filepath.Join(root, ".git", "BISECT_START"), []byte("FEAT-2\n"), 0644)
+	s, _ = Detect(r)
+	assert.Equal(t, State{Detached: true, Bisecting: true, Branch: "FEAT-2", Head: head}, s)
+
+	bare, _ := git.PlainInit(t.TempDir(), true)
+	s, err = Detect(bare)
+	assert.NoError(t, err)
+	assert.True(t, s.Bare)
+	assert.True(t, s.Unborn)
+}
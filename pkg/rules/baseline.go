@@ -0,0 +1,131 @@
+package rules
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/davidalpert/go-githooks/pkg/fileio"
+	"github.com/go-git/go-billy/v5"
+	"github.com/go-git/go-billy/v5/util"
+	"os"
+	"sort"
+)
+
+// BaselineFile is kept at the root of the repo and committed alongside it
+const BaselineFile = ".githooks-baseline.json"
+
+// Baseline captures existing violations so that legacy repos only fail on new ones
+type Baseline struct {
+	Violations []BaselineEntry `json:"violations"`
+
+	fingerprints map[string]bool
+}
+
+type BaselineEntry struct {
+	Rule        string `json:"rule"`
+	Location    string `json:"location,omitempty"`
+	Message     string `json:"message"`
+	Fingerprint string `json:"fingerprint"`
+}
+
+// RecordEnv names a baseline file hooks add the violations they report to, as
+// 'go-githooks baseline' asks them to
+const RecordEnv = "GIT_HOOKS_RECORD_BASELINE"
+
+// NewBaseline captures the given violations
+func NewBaseline(violations []Violation) *Baseline {
+	b := &Baseline{Violations: make([]BaselineEntry, 0, len(violations))}
+	b.Add(violations)
+	return b
+}
+
+// Add captures the violations not in the baseline yet, returning how many it added
+func (b *Baseline) Add(violations []Violation) int {
+	added := 0
+	for _, v := range violations {
+		if b.Contains(v) {
+			continue
+		}
+		b.Violations = append(b.Violations, BaselineEntry{
+			Rule:        v.Rule,
+			Location:    v.Location,
+			Message:     v.Message,
+			Fingerprint: v.Fingerprint(),
+		})
+		b.fingerprints[v.Fingerprint()] = true
+		added++
+	}
+	sort.Slice(b.Violations, func(i, j int) bool {
+		return b.Violations[i].Fingerprint < b.Violations[j].Fingerprint
+	})
+	return added
+}
+
+// Record adds the violations r reported to the baseline file named by RecordEnv,
+// when it is set
+func Record(r Result) error {
+	path := os.Getenv(RecordEnv)
+	if path == "" {
+		return nil
+	}
+	b, err := LoadBaseline(path)
+	if err != nil {
+		return err
+	}
+	b.Add(r.Reported)
+	return b.Write(path)
+}
+
+// LoadBaseline reads a baseline file; a missing file is an empty baseline
+func LoadBaseline(path string) (*Baseline, error) {
+	data, err := fileio.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &Baseline{}, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("could not read '%s': %v", path, err)
+	}
+	return ParseBaseline(path, data)
+}
+
+// LoadWorktreeBaseline reads the baseline file at the root of a worktree
+func LoadWorktreeBaseline(fs billy.Filesystem) (*Baseline, error) {
+	data, err := util.ReadFile(fs, BaselineFile)
+	if os.IsNotExist(err) {
+		return &Baseline{}, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("could not read '%s': %v", BaselineFile, err)
+	}
+	return ParseBaseline(BaselineFile, data)
+}
+
+func ParseBaseline(path string, data []byte) (*Baseline, error) {
+	b := &Baseline{}
+	if err := json.Unmarshal(data, b); err != nil {
+		return nil, fmt.Errorf("could not parse '%s': %v", path, err)
+	}
+	return b, nil
+}
+
+func (b *Baseline) Write(path string) error {
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := fileio.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("could not write '%s': %v", path, err)
+	}
+	return nil
+}
+
+// Contains reports whether v was captured in the baseline
+func (b *Baseline) Contains(v Violation) bool {
+	if b == nil {
+		return false
+	}
+	if b.fingerprints == nil {
+		b.fingerprints = make(map[string]bool, len(b.Violations))
+		for _, e := range b.Violations {
+			b.fingerprints[e.Fingerprint] = true
+		}
+	}
+	return b.fingerprints[v.Fingerprint()]
+}
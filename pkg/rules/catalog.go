@@ -0,0 +1,59 @@
+package rules
+
+// Entry describes one rule a hook checks, for 'explain' and editor integrations
+type Entry struct {
+	Rule        string   `json:"rule"`
+	Hook        string   `json:"hook"`
+	Default     Severity `json:"-"`
+	Description string   `json:"description"`
+}
+
+// Catalog lists every rule by hook; a rule checked by two hooks appears for each
+var Catalog = []Entry{
+	{"empty-message", "commit-msg", Off, "the message has a subject, not only a branch prefix and trailers"},
+	{"conventional-header", "commit-msg", Off, "the subject is 'type(scope)!: description' with one of conventionalTypes"},
+	{"conventional-scope", "commit-msg", Off, "the scope in 'type(scope): description' is one of the staged paths' scopes, from the scope map"},
+	{"conventional-type", "commit-msg", Off, "the type in 'type(scope): description' fits the kinds of staged files, e.g. 'docs' only for documentation"},
+	{"ticket-reference", "commit-msg", Off, "the message references a ticket matching ticketPattern"},
+	{"dco-signoff", "commit-msg", Off, "the message is signed off by user.name and user.email"},
+	{"link-domain", "commit-msg", Off, "links only go to allowedLinkDomains and none go to blockedLinkDomains"},
+	{"link-resolves", "commit-msg", Off, "links answer a HEAD request; skipped when GIT_HOOKS_OFFLINE is set"},
+	{"coauthor-email", "commit-msg", Off, "Co-authored-by emails are at one of coauthorDomains and/or in coauthorDirectory"},
+	{"sensitive-content", "commit-msg", Off, "the message has no credentials, sensitive terms or internal hostnames"},
+	{"stack-metadata", "commit-msg", Off, "at most one single-word Topic; Depends-On is a Change-Id, hash or url"},
+	{"closing-keyword", "commit-msg", Off, "Closes/Fixes/Resolves are only used on the branches and with the references their closing-keyword section allows"},
+	{"smart-commit", "commit-msg", Off, "Jira smart commit commands follow an issue key and are well formed, e.g. '#time 1d 2h', and no '@time' directive is left unconverted"},
+	{"duplicate-subject", "commit-msg", Warning, "the subject does not repeat one of the last duplicateSubjectDepth subjects on the branch, except those matching duplicateSubjectAllow"},
+	{"config-syntax", "pre-commit", Error, "staged .json, .yaml/.yml and .toml files parse"},
+	{"json-schema", "pre-commit", Error, "staged files match the schema mapped to them"},
+	{"lfs-pointer", "pre-commit", Error, "files with filter=lfs are staged as LFS pointers, and only they are"},
+	{"commit-size", "pre-commit", Off, "staged changes are within sizeMaxFiles and sizeMaxInsertions"},
+	{"commit-size-limit", "pre-commit", Error, "staged changes are within sizeBlockFiles and sizeBlockInsertions"},
+	{"lockfile", "pre-commit", Error, "a staged manifest, e.g. go.mod or package.json, is staged with its lockfile"},
+	{"lockfile-only", "pre-commit", Warning, "a staged lockfile is staged with its manifest"},
+	{"image-budget", "pre-commit", Error, "staged images are within imageMaxSize and imageMaxDimensions"},
+	{"todo-ticket", "pre-commit", Off, "TODO and FIXME comments on added lines reference a ticket matching the todo ticketPattern"},
+	{"formatting", "pre-commit", Off, "staged files are formatted by their formatter"},
+	{"formatter-missing", "pre-commit", Warning, "a formatter for staged files is installed"},
+	{"script", "pre-commit", Error, "the repo's .githooks/pre-commit.d/* scripts pass"},
+	{"check", "pre-commit", Error, "each configured check's command succeeds over the staged tree"},
+	{"check-runtime", "pre-commit", Warning, "each check ran in its container rather than falling back to the host"},
+	{"identity", "pre-commit", Error, "the author's email fits the identity policy for the repo's remotes"},
+	{"lfs-pointer", "pre-push", Error, "files with filter=lfs are pushed as LFS pointers, and only they are"},
+	{"stack-metadata", "pre-push", Warning, "pushed commits share one Topic and only depend on changes below them"},
+	{"merge-from-main", "pre-push", Off, "pushed branches do not merge one of mainBranches in; rebase onto it instead"},
+	{"commit-age", "pre-push", Off, "pushed commits were committed within maxCommitAge"},
+	{"signed-commits", "pre-push", Off, "pushed commits carry a signature one of the trusted keys verifies"},
+	{"fixup-commits", "pre-push", Warning, "no fixup!, squash! or amend! commits are pushed to shared branches un-squashed"},
+}
+
+// Lookup returns the catalog entries for rule, one per hook which checks it
+func Lookup(rule string) []Entry {
+	found := make([]Entry, 0)
+	for _, i := range Catalog {
+		if i.Rule == rule {
+			found = append(found, i)
+		}
+	}
+	return found
+}
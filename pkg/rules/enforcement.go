@@ -0,0 +1,176 @@
+package rules
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/davidalpert/go-githooks/pkg/fileio"
+	"github.com/davidalpert/go-githooks/pkg/gitconfig"
+	"github.com/go-git/go-git/v5/config"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/*
+ * Progressive enforcement eases rules onto an active team instead of blocking
+ * everyone on the day they are switched on:
+ *
+ * [go-githooks "enforcement"]
+ *     adoptionDate = 2026-11-01   # errors in commits authored before this only warn
+ *     graceWarnings = 3           # from then on, warn each author this many times per rule before blocking
+ *
+ * The warnings each author has had are counted per rule in the repo's local store,
+ * .git/go-githooks/enforcement.json, so every clone grants its own grace.
+ */
+
+// EnforcementFile holds the warnings given to each author, per rule
+const EnforcementFile = "enforcement.json"
+
+// Enforcement relaxes errors to warnings for authors still adopting the rules
+type Enforcement struct {
+	AdoptionDate  time.Time `json:"-"`
+	GraceWarnings int       `json:"-"`
+
+	// Warned counts the warnings given instead of errors, by author email then rule
+	Warned map[string]map[string]int `json:"warned"`
+
+	path    string
+	changed bool
+}
+
+// EnforcementFromConfig reads the [go-githooks "enforcement"] section and the warnings
+// already given from dir; nil means errors always block
+func EnforcementFromConfig(c *config.Config, dir string) (*Enforcement, error) {
+	date := gitconfig.GetString(c, "go-githooks", "enforcement", "adoptionDate", "")
+	grace := gitconfig.GetString(c, "go-githooks", "enforcement", "graceWarnings", "")
+	if date == "" && grace == "" {
+		return nil, nil
+	}
+
+	e := &Enforcement{Warned: map[string]map[string]int{}}
+	var err error
+	if date != "" {
+		if e.AdoptionDate, err = time.ParseInLocation("2006-01-02", date, time.Local); err != nil {
+			return nil, fmt.Errorf("could not parse enforcement adoptionDate '%s', expected YYYY-MM-DD: %v", date, err)
+		}
+	}
+	if grace != "" {
+		if e.GraceWarnings, err = strconv.Atoi(grace); err != nil || e.GraceWarnings < 0 {
+			return nil, fmt.Errorf("could not parse enforcement graceWarnings '%s' as a number of warnings", grace)
+		}
+	}
+	if e.GraceWarnings == 0 || dir == "" {
+		return e, nil
+	}
+
+	e.path = filepath.Join(dir, EnforcementFile)
+	data, err := fileio.ReadFile(e.path)
+	if os.IsNotExist(err) {
+		return e, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("could not read '%s': %v", e.path, err)
+	}
+	if err := json.Unmarshal(data, e); err != nil {
+		return nil, fmt.Errorf("could not parse '%s': %v", e.path, err)
+	}
+	if e.Warned == nil {
+		e.Warned = map[string]map[string]int{}
+	}
+	return e, nil
+}
+
+// Apply turns the errors in r into warnings while author is adopting the rules: for
+// commits authored before the adoption date, then for each rule's first GraceWarnings
+// runs which would have blocked them
+func (e *Enforcement) Apply(r Result, author string, authored time.Time) Result {
+	if e == nil {
+		return r
+	}
+	author = strings.ToLower(author)
+	// the warning each rule gets in this run, 0 once its grace is used up; a run warns
+	// once per rule however many times it is broken
+	given := map[string]int{}
+	relaxed := make([]Violation, 0, len(r.Reported))
+	for _, v := range r.Reported {
+		if v.Severity != Error {
+			relaxed = append(relaxed, v)
+			continue
+		}
+		if !e.AdoptionDate.IsZero() && authored.Before(e.AdoptionDate) {
+			v.Severity = Warning
+			v.Message += fmt.Sprintf(" (blocks commits from %s)", e.AdoptionDate.Format("2006-01-02"))
+			relaxed = append(relaxed, v)
+			continue
+		}
+		if e.GraceWarnings == 0 || author == "" {
+			relaxed = append(relaxed, v)
+			continue
+		}
+		n, ok := given[v.Rule]
+		if !ok && e.warned(author, v.Rule) < e.GraceWarnings {
+			e.warn(author, v.Rule)
+			n = e.warned(author, v.Rule)
+		}
+		given[v.Rule] = n
+		if n > 0 {
+			v.Severity = Warning
+			v.Message += fmt.Sprintf(" (warning %d of %d, then this blocks)", n, e.GraceWarnings)
+		}
+		relaxed = append(relaxed, v)
+	}
+	r.Reported = relaxed
+	return r
+}
+
+// AuthorDate reads GIT_AUTHOR_DATE in the formats git accepts most often: its
+// internal '@<unix seconds> <zone>', RFC 2822 and ISO 8601; otherwise it is now
+func AuthorDate(s string, now time.Time) time.Time {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return now
+	}
+	if fields := strings.Fields(strings.TrimPrefix(s, "@")); len(fields) > 0 {
+		if secs, err := strconv.ParseInt(fields[0], 10, 64); err == nil && len(fields[0]) > 8 {
+			return time.Unix(secs, 0)
+		}
+	}
+	for _, layout := range []string{time.RFC3339, time.RFC1123Z, "Mon, 2 Jan 2006 15:04:05 -0700", "2006-01-02 15:04:05 -0700", "2006-01-02T15:04:05"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t
+		}
+	}
+	return now
+}
+
+func (e *Enforcement) warned(author, rule string) int {
+	return e.Warned[author][strings.ToLower(rule)]
+}
+
+func (e *Enforcement) warn(author, rule string) {
+	if e.Warned[author] == nil {
+		e.Warned[author] = map[string]int{}
+	}
+	e.Warned[author][strings.ToLower(rule)]++
+	e.changed = true
+}
+
+// Save records the warnings given by Apply
+func (e *Enforcement) Save() error {
+	if e == nil || !e.changed || e.path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(e, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(e.path), 0755); err != nil {
+		return fmt.Errorf("could not create '%s': %v", filepath.Dir(e.path), err)
+	}
+	if err := fileio.WriteFile(e.path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("could not write '%s': %v", e.path, err)
+	}
+	e.changed = false
+	return nil
+}
@@ -0,0 +1,50 @@
+package rules
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// PathSeverity sets a rule's severity for the paths matching Glob
+type PathSeverity struct {
+	Glob     string
+	Severity Severity
+}
+
+// PathSeverities lets one rule be strict in some parts of a repo and loose in others,
+// e.g. prototypes=off,internal/core=error
+type PathSeverities []PathSeverity
+
+// ParsePathSeverities reads 'glob=severity' items
+func ParsePathSeverities(items []string) (PathSeverities, error) {
+	p := make(PathSeverities, 0, len(items))
+	for _, item := range items {
+		parts := strings.SplitN(item, "=", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+			return nil, fmt.Errorf("could not parse '%s' as glob=severity", item)
+		}
+		s, err := SeverityFromString(strings.TrimSpace(parts[1]))
+		if err != nil {
+			return nil, fmt.Errorf("path '%s': %v", parts[0], err)
+		}
+		p = append(p, PathSeverity{Glob: strings.Trim(strings.TrimSpace(parts[0]), "/"), Severity: s})
+	}
+	return p, nil
+}
+
+// For returns the severity of the last entry matching p, or ok false when none does;
+// a glob matches the path itself or any of its parent directories, so 'prototypes'
+// covers everything under prototypes/
+func (ps PathSeverities) For(p string) (Severity, bool) {
+	found, ok := Off, false
+	for _, s := range ps {
+		for candidate := p; candidate != "." && candidate != "/"; candidate = path.Dir(candidate) {
+			if m, _ := path.Match(s.Glob, candidate); m {
+				found, ok = s.Severity, true
+				break
+			}
+		}
+	}
+	return found, ok
+}
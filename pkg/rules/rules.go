@@ -0,0 +1,171 @@
+package rules
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"github.com/go-git/go-git/v5/config"
+	"io"
+	"strings"
+)
+
+/*
+ * Every lint/check rule reports Violations at its own default Severity; the
+ * severity of any rule can be raised, lowered, or switched off per repo:
+ *
+ * [go-githooks "rules"]
+ *     subject-max-length = warning
+ *     lfs-pointer = off
+ *
+ * Only violations at Error severity (and not captured in the baseline) fail a hook.
+ */
+type Severity int
+
+const (
+	Off Severity = iota
+	Info
+	Warning
+	Error
+)
+
+func SeverityFromString(s string) (Severity, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "off", "none":
+		return Off, nil
+	case "info":
+		return Info, nil
+	case "warning", "warn":
+		return Warning, nil
+	case "error":
+		return Error, nil
+	}
+	return Off, fmt.Errorf("unknown severity '%s', expected one of: error, warning, info, off", s)
+}
+
+func (s Severity) String() string {
+	switch s {
+	case Info:
+		return "info"
+	case Warning:
+		return "warning"
+	case Error:
+		return "error"
+	}
+	return "off"
+}
+
+// Violation is a single problem found by a rule
+type Violation struct {
+	Rule     string
+	Severity Severity
+	Location string // a file path, commit sha, or "message"
+	Message  string
+
+	// Fix rewrites the content the violation was found in so that it no longer
+	// applies; nil when the rule cannot fix it automatically
+	Fix func(content []byte) []byte
+
+	// PathScoped is set when Severity was configured for the path at Location,
+	// which takes precedence over the severity configured for the whole rule
+	PathScoped bool
+}
+
+// Fingerprint identifies a violation independently of its severity, for matching against a baseline
+func (v Violation) Fingerprint() string {
+	sum := sha1.Sum([]byte(strings.Join([]string{v.Rule, v.Location, v.Message}, "\x00")))
+	return hex.EncodeToString(sum[:])
+}
+
+func (v Violation) String() string {
+	if v.Location == "" {
+		return fmt.Sprintf("%s [%s] %s", v.Severity, v.Rule, v.Message)
+	}
+	return fmt.Sprintf("%s [%s] %s: %s", v.Severity, v.Rule, v.Location, v.Message)
+}
+
+// Severities holds the per-rule severity overrides configured for a repo
+type Severities map[string]Severity
+
+// SeveritiesFromConfig reads the [go-githooks "rules"] section
+func SeveritiesFromConfig(c *config.Config) (Severities, error) {
+	severities := Severities{}
+	if c == nil || !c.Raw.HasSection("go-githooks") || !c.Raw.Section("go-githooks").HasSubsection("rules") {
+		return severities, nil
+	}
+
+	for _, o := range c.Raw.Section("go-githooks").Subsection("rules").Options {
+		s, err := SeverityFromString(o.Value)
+		if err != nil {
+			return nil, fmt.Errorf("rule '%s': %v", o.Key, err)
+		}
+		severities[strings.ToLower(o.Key)] = s
+	}
+	return severities, nil
+}
+
+// Apply returns v with its severity replaced by any configured override
+func (s Severities) Apply(v Violation) Violation {
+	if v.PathScoped {
+		return v
+	}
+	if override, ok := s[strings.ToLower(v.Rule)]; ok {
+		v.Severity = override
+	}
+	return v
+}
+
+// For returns the severity configured for rule, or defaultSeverity; rules which are
+// expensive to check (e.g. over the network) use it to skip work when switched off
+func (s Severities) For(rule string, defaultSeverity Severity) Severity {
+	if override, ok := s[strings.ToLower(rule)]; ok {
+		return override
+	}
+	return defaultSeverity
+}
+
+// Result is the outcome of evaluating violations against severities and a baseline
+type Result struct {
+	Reported  []Violation
+	Baselined []Violation
+}
+
+// Evaluate applies configured severities, drops rules which are switched off, and
+// sets aside violations already captured in the baseline
+func Evaluate(violations []Violation, severities Severities, baseline *Baseline) Result {
+	r := Result{}
+	for _, v := range violations {
+		v = severities.Apply(v)
+		if v.Severity == Off {
+			continue
+		}
+		if baseline.Contains(v) {
+			r.Baselined = append(r.Baselined, v)
+			continue
+		}
+		r.Reported = append(r.Reported, v)
+	}
+	return r
+}
+
+// Failed reports whether any reported violation is an error
+func (r Result) Failed() bool {
+	for _, v := range r.Reported {
+		if v.Severity == Error {
+			return true
+		}
+	}
+	return false
+}
+
+func (r Result) Print(w io.Writer) {
+	for _, v := range r.Reported {
+		if v.Fix != nil {
+			fmt.Fprintf(w, "%s (fixable)\n", v)
+		} else {
+			fmt.Fprintf(w, "%s\n", v)
+		}
+	}
+	if len(r.Baselined) > 0 {
+		fmt.Fprintf(w, "(%d existing violations ignored by %s)\n", len(r.Baselined), BaselineFile)
+	}
+}
@@ -0,0 +1,176 @@
+package rules
+
+import (
+	"fmt"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/storage/memory"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestEvaluate(t *testing.T) {
+	r, _ := git.Init(memory.NewStorage(), nil)
+	cfg, _ := r.Config()
+	err := cfg.Unmarshal([]byte(`
+[go-githooks "rules"]
+    subject-max-length = warning
+    trailing-period = off
+`))
+	if err != nil {
+		t.Fatalf("unmarshalling sample config: %v", err)
+	}
+
+	severities, err := SeveritiesFromConfig(cfg)
+	if err != nil {
+		t.Fatalf("reading severities: %v", err)
+	}
+
+	legacy := Violation{Rule: "lfs-pointer", Severity: Error, Location: "assets/big.bin", Message: "should be an LFS pointer"}
+	baseline := NewBaseline([]Violation{legacy})
+
+	result := Evaluate([]Violation{
+		{Rule: "subject-max-length", Severity: Error, Location: "message", Message: "subject is 93 characters"},
+		{Rule: "trailing-period", Severity: Error, Location: "message", Message: "subject ends with a period"},
+		legacy,
+	}, severities, baseline)
+
+	assert.Len(t, result.Reported, 1)
+	assert.Equal(t, Warning, result.Reported[0].Severity)
+	assert.Len(t, result.Baselined, 1)
+	assert.False(t, result.Failed())
+}
+
+func TestBaselineRoundTrip(t *testing.T) {
+	dir, err := ioutil.TempDir("", "baseline")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, BaselineFile)
+
+	missing, err := LoadBaseline(path)
+	assert.NoError(t, err)
+	assert.Empty(t, missing.Violations)
+
+	v := Violation{Rule: "lfs-pointer", Severity: Error, Location: "assets/big.bin", Message: "should be an LFS pointer"}
+	assert.NoError(t, NewBaseline([]Violation{v}).Write(path))
+
+	loaded, err := LoadBaseline(path)
+	assert.NoError(t, err)
+	assert.True(t, loaded.Contains(v))
+	v.Location = "assets/other.bin"
+	assert.False(t, loaded.Contains(v))
+}
+
+func TestSeverityFromString(t *testing.T) {
+	s, err := SeverityFromString("Warning")
+	assert.NoError(t, err)
+	assert.Equal(t, Warning, s)
+
+	_, err = SeverityFromString("fatal")
+	assert.Error(t, err)
+}
+
+func TestPathSeverities(t *testing.T) {
+	paths, err := ParsePathSeverities([]string{"prototypes=off", "internal/*=error", "internal/legacy=warning"})
+	assert.NoError(t, err)
+
+	s, ok := paths.For("prototypes/spike/main.go")
+	assert.True(t, ok)
+	assert.Equal(t, Off, s)
+	s, _ = paths.For("internal/core/main.go")
+	assert.Equal(t, Error, s)
+	s, _ = paths.For("internal/legacy/main.go")
+	assert.Equal(t, Warning, s, "the last match wins")
+	_, ok = paths.For("cmd/main.go")
+	assert.False(t, ok)
+
+	_, err = ParsePathSeverities([]string{"prototypes"})
+	assert.Error(t, err)
+
+	scoped := Violation{Rule: "todo-ticket", Severity: Warning, PathScoped: true}
+	assert.Equal(t, Warning, Severities{"todo-ticket": Error}.Apply(scoped).Severity)
+}
+
+func TestEnforcement(t *testing.T) {
+	r, _ := git.Init(memory.NewStorage(), nil)
+	cfg, _ := r.Config()
+	err := cfg.Unmarshal([]byte(`
+[go-githooks "enforcement"]
+    adoptionDate = 2026-11-01
+    graceWarnings = 2
+`))
+	if err != nil {
+		t.Fatalf("unmarshalling sample config: %v", err)
+	}
+	dir := t.TempDir()
+	e, err := EnforcementFromConfig(cfg, dir)
+	if err != nil {
+		t.Fatalf("reading enforcement: %v", err)
+	}
+
+	result := func() Result {
+		return Result{Reported: []Violation{
+			{Rule: "ticket-reference", Severity: Error, Location: "message", Message: "no ticket"},
+			{Rule: "ticket-reference", Severity: Error, Location: "message", Message: "still no ticket"},
+			{Rule: "link-domain", Severity: Warning, Location: "message", Message: "blocked link"},
+		}}
+	}
+	before := time.Date(2026, 10, 1, 12, 0, 0, 0, time.Local)
+	after := time.Date(2026, 11, 2, 12, 0, 0, 0, time.Local)
+
+	relaxed := e.Apply(result(), "mal@serenity.com", before)
+	assert.False(t, relaxed.Failed())
+	assert.Contains(t, relaxed.Reported[0].Message, "blocks commits from 2026-11-01")
+
+	// after the adoption date each author gets their grace warnings, once per run
+	for i := 1; i <= 2; i++ {
+		relaxed = e.Apply(result(), "Mal@Serenity.com", after)
+		assert.False(t, relaxed.Failed())
+		assert.Contains(t, relaxed.Reported[0].Message, fmt.Sprintf("warning %d of 2", i))
+	}
+	assert.True(t, e.Apply(result(), "mal@serenity.com", after).Failed())
+	assert.False(t, e.Apply(result(), "zoe@serenity.com", after).Failed())
+
+	// the warnings given are kept for the next run
+	assert.NoError(t, e.Save())
+	reloaded, err := EnforcementFromConfig(cfg, dir)
+	if err != nil {
+		t.Fatalf("reading enforcement again: %v", err)
+	}
+	assert.True(t, reloaded.Apply(result(), "mal@serenity.com", after).Failed())
+	assert.Equal(t, 1, reloaded.Warned["zoe@serenity.com"]["ticket-reference"])
+
+	var none *Enforcement
+	assert.True(t, none.Apply(result(), "mal@serenity.com", before).Failed())
+}
+
+func TestAuthorDate(t *testing.T) {
+	now := time.Now()
+	assert.Equal(t, now, AuthorDate("", now))
+	assert.Equal(t, int64(1790000000), AuthorDate("@1790000000 +0200", now).Unix())
+	assert.Equal(t, 2026, AuthorDate("2026-11-02T12:00:00Z", now).Year())
+	assert.Equal(t, 2026, AuthorDate("Mon, 2 Nov 2026 12:00:00 +0000", now).Year())
+}
+
+func TestRecord(t *testing.T) {
+	path := filepath.Join(t.TempDir(), BaselineFile)
+	legacy := Violation{Rule: "lfs-pointer", Severity: Error, Location: "assets/big.bin", Message: "should be an LFS pointer"}
+	assert.NoError(t, Record(Result{Reported: []Violation{legacy}}))
+	assert.NoFileExists(t, path, "nothing is recorded unless asked")
+
+	os.Setenv(RecordEnv, path)
+	defer os.Unsetenv(RecordEnv)
+	assert.NoError(t, Record(Result{Reported: []Violation{legacy}}))
+	other := Violation{Rule: "todo-comment", Severity: Warning, Location: "main.go:12", Message: "TODO without a ticket"}
+	assert.NoError(t, Record(Result{Reported: []Violation{legacy, other}}))
+
+	baseline, err := LoadBaseline(path)
+	assert.NoError(t, err)
+	assert.Len(t, baseline.Violations, 2, "each violation once")
+	assert.True(t, baseline.Contains(other))
+}
@@ -0,0 +1,115 @@
+package scripts
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Dir holds a directory of scripts per hook, e.g. .githooks/prepare-commit-msg.d/
+const Dir = ".githooks"
+
+// EnabledKey turns scripts on; it is only read from the user's own git config since
+// a cloned repo must not be able to run its scripts on the user's machine by itself
+const EnabledKey = "go-githooks.scripts.enabled"
+
+// Script is an executable file a repo provides to extend a hook
+type Script struct {
+	Name string
+	Path string
+}
+
+// Enabled reports whether go-githooks.scripts.enabled is true in the repo's
+// .git/config or the global config, ignoring shared config (.githooks.yml, configUrl)
+func Enabled(repo *git.Repository) bool {
+	scopes := make([]*config.Config, 0, 2)
+	if local, err := repo.Config(); err == nil {
+		scopes = append(scopes, local)
+	}
+	if global, err := config.LoadConfig(config.GlobalScope); err == nil {
+		scopes = append(scopes, global)
+	}
+
+	for _, c := range scopes {
+		s := c.Raw.Section("go-githooks").Subsection("scripts")
+		if !s.HasOption("enabled") {
+			continue
+		}
+		b, err := strconv.ParseBool(s.Option("enabled"))
+		return err == nil && b
+	}
+	return false
+}
+
+// Discover lists the executable files in <root>/.githooks/<hook>.d in lexical order;
+// a missing directory has no scripts
+func Discover(root, hook string) ([]Script, error) {
+	dir := filepath.Join(root, Dir, hook+".d")
+	entries, err := ioutil.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("could not list %s: %v", dir, err)
+	}
+
+	found := make([]Script, 0, len(entries))
+	for _, e := range entries {
+		if e.IsDir() || strings.HasPrefix(e.Name(), ".") || e.Mode()&0111 == 0 {
+			continue
+		}
+		found = append(found, Script{Name: e.Name(), Path: filepath.Join(dir, e.Name())})
+	}
+	sort.Slice(found, func(i, j int) bool {
+		return found[i].Name < found[j].Name
+	})
+	return found, nil
+}
+
+// Run runs each script in the repo root with the hook's args, showing its output;
+// the first failure stops it
+func Run(root, hook string, found []Script, args ...string) error {
+	for _, s := range found {
+		cmd := command(root, hook, s, args)
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("%s/%s.d/%s failed: %v", Dir, hook, s.Name, err)
+		}
+	}
+	return nil
+}
+
+// Pipe runs each script in the repo root with input on stdin and the hook's args,
+// passing what it writes to stdout on to the next one; a script which writes nothing
+// passes its input on unchanged, so checks need not echo it. The first failure stops it
+func Pipe(root, hook string, found []Script, input []byte, args ...string) ([]byte, error) {
+	for _, s := range found {
+		var stdout, stderr bytes.Buffer
+		cmd := command(root, hook, s, args)
+		cmd.Stdin = bytes.NewReader(input)
+		cmd.Stdout = &stdout
+		cmd.Stderr = &stderr
+		if err := cmd.Run(); err != nil {
+			return input, fmt.Errorf("%s/%s.d/%s failed: %v %s", Dir, hook, s.Name, err, strings.TrimSpace(stderr.String()))
+		}
+		if stdout.Len() > 0 {
+			input = stdout.Bytes()
+		}
+	}
+	return input, nil
+}
+
+func command(root, hook string, s Script, args []string) *exec.Cmd {
+	cmd := exec.Command(s.Path, args...)
+	cmd.Dir = root
+	cmd.Env = append(os.Environ(), "GIT_HOOKS_HOOK="+hook)
+	return cmd
+}
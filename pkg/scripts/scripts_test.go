@@ -0,0 +1,67 @@
+package scripts
+
+import (
+	"github.com/go-git/go-git/v5"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeScript(t *testing.T, root, hook, name, body string, mode os.FileMode) {
+	dir := filepath.Join(root, Dir, hook+".d")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatalf("creating %s: %v", dir, err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, name), []byte("#!/bin/sh\n"+body+"\n"), mode); err != nil {
+		t.Fatalf("writing %s: %v", name, err)
+	}
+}
+
+func TestPipe(t *testing.T) {
+	root := t.TempDir()
+	writeScript(t, root, "prepare-commit-msg", "20-shout", "tr a-z A-Z", 0755)
+	writeScript(t, root, "prepare-commit-msg", "10-hook-name", `sed "s/^/[$GIT_HOOKS_HOOK $1] /"`, 0755)
+	writeScript(t, root, "prepare-commit-msg", "30-not-executable", "exit 1", 0644)
+	writeScript(t, root, "prepare-commit-msg", ".hidden", "exit 1", 0755)
+	writeScript(t, root, "prepare-commit-msg", "15-check", "grep -q login", 0755)
+
+	found, err := Discover(root, "prepare-commit-msg")
+	assert.NoError(t, err)
+	if assert.Len(t, found, 3) {
+		assert.Equal(t, "10-hook-name", found[0].Name)
+		assert.Equal(t, "15-check", found[1].Name)
+		assert.Equal(t, "20-shout", found[2].Name)
+	}
+
+	out, err := Pipe(root, "prepare-commit-msg", found, []byte("fix login\n"), "message")
+	assert.NoError(t, err)
+	assert.Equal(t, "[PREPARE-COMMIT-MSG MESSAGE] FIX LOGIN\n", string(out))
+
+	writeScript(t, root, "prepare-commit-msg", "17-fail", "echo nope >&2; exit 3", 0755)
+	found, _ = Discover(root, "prepare-commit-msg")
+	out, err = Pipe(root, "prepare-commit-msg", found, []byte("fix login\n"))
+	assert.EqualError(t, err, ".githooks/prepare-commit-msg.d/17-fail failed: exit status 3 nope")
+	assert.Equal(t, "[prepare-commit-msg ] fix login\n", string(out), "keeps the output of the scripts before the failure")
+
+	found, err = Discover(root, "commit-msg")
+	assert.NoError(t, err)
+	assert.Empty(t, found)
+}
+
+func TestEnabled(t *testing.T) {
+	repo, err := git.PlainInit(t.TempDir(), false)
+	if err != nil {
+		t.Fatalf("init: %v", err)
+	}
+	cfg, _ := repo.Config()
+
+	cfg.Raw.Section("go-githooks").Subsection("scripts").SetOption("enabled", "false")
+	_ = repo.SetConfig(cfg)
+	assert.False(t, Enabled(repo))
+
+	cfg.Raw.Section("go-githooks").Subsection("scripts").SetOption("enabled", "true")
+	_ = repo.SetConfig(cfg)
+	assert.True(t, Enabled(repo))
+}
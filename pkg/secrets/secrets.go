@@ -0,0 +1,131 @@
+package secrets
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+/*
+ * Config values which hold secrets (tracker tokens, webhook URLs) may reference
+ * the secret instead of storing it in plaintext in a gitconfig file:
+ *
+ *   env:JIRA_TOKEN          read from the JIRA_TOKEN environment variable
+ *   keyring:jira-token      read from the OS keychain (macOS Keychain, linux secret-tool)
+ *   age:<base64>            decrypted with the age identity in AgeIdentityFile()
+ *
+ * anything else is treated as a literal value.
+ */
+
+const (
+	EnvScheme     = "env:"
+	KeyringScheme = "keyring:"
+	AgeScheme     = "age:"
+
+	// KeyringService is the service name under which secrets are stored in the OS keychain
+	KeyringService = "go-githooks"
+)
+
+// IsReference reports whether v refers to a secret rather than holding a literal value
+func IsReference(v string) bool {
+	return strings.HasPrefix(v, EnvScheme) || strings.HasPrefix(v, KeyringScheme) || strings.HasPrefix(v, AgeScheme)
+}
+
+// Resolve returns the secret referenced by v, or v itself when it is not a reference
+func Resolve(v string) (string, error) {
+	switch {
+	case strings.HasPrefix(v, EnvScheme):
+		name := strings.TrimPrefix(v, EnvScheme)
+		s := os.Getenv(name)
+		if s == "" {
+			return "", fmt.Errorf("environment variable '%s' is not set", name)
+		}
+		return s, nil
+	case strings.HasPrefix(v, KeyringScheme):
+		return keyringGet(strings.TrimPrefix(v, KeyringScheme))
+	case strings.HasPrefix(v, AgeScheme):
+		return ageDecrypt(strings.TrimPrefix(v, AgeScheme))
+	}
+	return v, nil
+}
+
+// SetKeyring stores a secret in the OS keychain and returns the reference to put in config
+func SetKeyring(name, secret string) (string, error) {
+	stdin, args, err := keyringStoreCommand(runtime.GOOS, name, secret)
+	if err != nil {
+		return "", err
+	}
+	if _, err := run("store secret in the keyring", stdin, args[0], args[1:]...); err != nil {
+		return "", err
+	}
+	return KeyringScheme + name, nil
+}
+
+// keyringStoreCommand is the command storing a secret on goos, which reads the secret
+// from stdin so that it never shows in the process list
+func keyringStoreCommand(goos, name, secret string) (string, []string, error) {
+	switch goos {
+	case "darwin":
+		// a trailing -w without a value asks for the password, then asks again to confirm it
+		return secret + "\n" + secret + "\n", []string{"security", "add-generic-password", "-U", "-s", KeyringService, "-a", name, "-w"}, nil
+	case "linux":
+		return secret, []string{"secret-tool", "store", "--label", KeyringService + " " + name, "service", KeyringService, "account", name}, nil
+	}
+	return "", nil, fmt.Errorf("no keyring support on %s", goos)
+}
+
+// EncryptAge encrypts a secret to the given age recipient and returns the reference to put in config
+func EncryptAge(recipient, secret string) (string, error) {
+	out, err := run("encrypt secret with age", secret, "age", "--encrypt", "--recipient", recipient)
+	if err != nil {
+		return "", err
+	}
+	return AgeScheme + base64.StdEncoding.EncodeToString([]byte(out)), nil
+}
+
+// AgeIdentityFile is the age identity used to decrypt 'age:' references; override with GIT_HOOKS_AGE_IDENTITY
+func AgeIdentityFile() string {
+	if f := os.Getenv("GIT_HOOKS_AGE_IDENTITY"); f != "" {
+		return f
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		dir = "."
+	}
+	return filepath.Join(dir, "go-githooks", "age-identity.txt")
+}
+
+func keyringGet(name string) (string, error) {
+	switch runtime.GOOS {
+	case "darwin":
+		return run("read secret from keychain", "", "security", "find-generic-password", "-s", KeyringService, "-a", name, "-w")
+	case "linux":
+		return run("read secret with secret-tool", "", "secret-tool", "lookup", "service", KeyringService, "account", name)
+	}
+	return "", fmt.Errorf("no keyring support on %s", runtime.GOOS)
+}
+
+func ageDecrypt(encoded string) (string, error) {
+	ciphertext, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", fmt.Errorf("could not decode age secret: %v", err)
+	}
+	return run("decrypt secret with age", string(ciphertext), "age", "--decrypt", "--identity", AgeIdentityFile())
+}
+
+func run(cmdDescription, stdin string, cmdName string, arg ...string) (string, error) {
+	cmd := exec.Command(cmdName, arg...)
+	cmd.Stdin = strings.NewReader(stdin)
+	var out, errOut bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &errOut
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("%s failed: %v %s", cmdDescription, err, strings.TrimSpace(errOut.String()))
+	}
+	return strings.TrimSpace(out.String()), nil
+}
@@ -0,0 +1,52 @@
+package secrets
+
+import (
+	"github.com/stretchr/testify/assert"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestResolve(t *testing.T) {
+	os.Setenv("GO_GITHOOKS_TEST_TOKEN", "s3cr3t")
+	defer os.Unsetenv("GO_GITHOOKS_TEST_TOKEN")
+
+	tests := []struct {
+		name    string
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{name: "literal", value: "https://hooks.example.com/abc", want: "https://hooks.example.com/abc"},
+		{name: "env", value: "env:GO_GITHOOKS_TEST_TOKEN", want: "s3cr3t"},
+		{name: "missing env", value: "env:GO_GITHOOKS_TEST_MISSING", wantErr: true},
+		{name: "bad age payload", value: "age:not base64!", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Resolve(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Resolve() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestIsReference(t *testing.T) {
+	assert.True(t, IsReference("keyring:jira"))
+	assert.True(t, IsReference("env:JIRA_TOKEN"))
+	assert.False(t, IsReference("[%s]"))
+}
+
+func TestKeyringStoreCommand(t *testing.T) {
+	for _, goos := range []string{"darwin", "linux"} {
+		stdin, args, err := keyringStoreCommand(goos, "jira-token", "s3cr3t")
+		assert.NoError(t, err)
+		assert.Contains(t, stdin, "s3cr3t", goos)
+		assert.NotContains(t, strings.Join(args, " "), "s3cr3t", "%s: the secret is not on the command line", goos)
+	}
+	_, _, err := keyringStoreCommand("plan9", "jira-token", "s3cr3t")
+	assert.Error(t, err)
+}
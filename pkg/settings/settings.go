@@ -0,0 +1,298 @@
+package settings
+
+import (
+	"fmt"
+	"github.com/davidalpert/go-githooks/pkg/rules"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Kind is the type of value a setting holds, which decides how it is validated
+type Kind string
+
+const (
+	String   Kind = "string"
+	Bool     Kind = "bool"
+	Int      Kind = "int"
+	Duration Kind = "duration"
+	Date     Kind = "date"     // YYYY-MM-DD
+	Enum     Kind = "enum"     // one of Values
+	List     Kind = "list"     // comma-separated, or the key repeated
+	Multi    Kind = "multi"    // the key repeated once per value
+	Severity Kind = "severity" // error | warning | info | off
+	Pattern  Kind = "pattern"  // a regular expression
+)
+
+// Setting is one key in a [go-githooks "<section>"] section
+type Setting struct {
+	Section string // the subsection, "" for [go-githooks] itself
+	Key     string
+	Kind    Kind
+	Values  []string // the choices of an Enum
+	Default string
+	Doc     string
+}
+
+// Name is the setting's name for git config, e.g. go-githooks.pre-push.commitLimit
+func (s Setting) Name() string {
+	if s.Section == "" {
+		return "go-githooks." + s.Key
+	}
+	return "go-githooks." + s.Section + "." + s.Key
+}
+
+// Validate checks that v is a value the hooks can read for s; "" unsets it
+func (s Setting) Validate(v string) error {
+	if v == "" {
+		return nil
+	}
+	var err error
+	switch s.Kind {
+	case Bool:
+		_, err = strconv.ParseBool(v)
+	case Int:
+		_, err = strconv.Atoi(v)
+	case Duration:
+		_, err = time.ParseDuration(v)
+	case Date:
+		_, err = time.Parse("2006-01-02", v)
+	case Severity:
+		_, err = rules.SeverityFromString(v)
+	case Pattern:
+		_, err = regexp.Compile(v)
+	case Enum:
+		for _, allowed := range s.Values {
+			if v == allowed {
+				return nil
+			}
+		}
+		err = fmt.Errorf("expected one of: %s", strings.Join(s.Values, ", "))
+	}
+	if err != nil {
+		return fmt.Errorf("'%s' is not a valid %s for %s: %v", v, s.Kind, s.Name(), err)
+	}
+	return nil
+}
+
+// Type describes the values s takes, e.g. 'off | describe | tag'
+func (s Setting) Type() string {
+	switch s.Kind {
+	case Enum:
+		return strings.Join(s.Values, " | ")
+	case Severity:
+		return "error | warning | info | off"
+	case Bool:
+		return "true | false"
+	}
+	return string(s.Kind)
+}
+
+// All lists every setting, with the severity of each rule in the catalog, sorted by
+// section then key
+func All() []Setting {
+	all := make([]Setting, 0, len(known)+len(rules.Catalog))
+	all = append(all, known...)
+	seen := map[string]bool{}
+	for _, e := range rules.Catalog {
+		if seen[e.Rule] {
+			continue
+		}
+		seen[e.Rule] = true
+		all = append(all, Setting{Section: "rules", Key: e.Rule, Kind: Severity, Default: e.Default.String(), Doc: fmt.Sprintf("%s (%s)", e.Description, e.Hook)})
+	}
+	sort.SliceStable(all, func(i, j int) bool {
+		if all[i].Section != all[j].Section {
+			return all[i].Section < all[j].Section
+		}
+		return all[i].Key < all[j].Key
+	})
+	return all
+}
+
+// Sections lists the sections of All, in order
+func Sections() []string {
+	sections := make([]string, 0)
+	for _, s := range All() {
+		if len(sections) == 0 || sections[len(sections)-1] != s.Section {
+			sections = append(sections, s.Section)
+		}
+	}
+	return sections
+}
+
+// Lookup finds a setting by its git config name, ignoring the case of the section
+// and key as git does
+func Lookup(name string) (Setting, bool) {
+	for _, s := range All() {
+		if strings.EqualFold(s.Name(), name) {
+			return s, true
+		}
+	}
+	return Setting{}, false
+}
+
+var replay = []string{"skip", "refs", "default"}
+
+// known are the settings besides rule severities; the hooks' help has the long form
+var known = []Setting{
+	{Key: "preset", Kind: List, Doc: "one or more of: conventional, jira, mob, oss-dco (see: go-githooks init)"},
+	{Key: "mailmap", Kind: Bool, Default: "true", Doc: "use the repo's .mailmap (and mailmap.file) for coauthors and the sign-off"},
+	{Key: "guiLog", Kind: Bool, Default: "false", Doc: "keep each hook's output in .git/go-githooks/last-run.log for GUI clients"},
+	{Key: "updateCheck", Kind: Bool, Default: "true", Doc: "let hooks look for a newer release once a day, in the background, to mention on the next go-githooks command"},
+	{Key: "configUrl", Kind: String, Doc: "hook policy published at a url (see: go-githooks install --config-url)"},
+	{Key: "configPublicKey", Kind: String, Doc: "the key the published policy is signed with"},
+	{Key: "configRefresh", Kind: Duration, Default: "24h", Doc: "how often the published policy is fetched again"},
+	{Key: "templateRegistry", Kind: String, Doc: "the index of shared templates to add by name (see: go-githooks templates add)"},
+	{Section: "policy", Key: "trustedKey", Kind: Multi, Doc: "a minisign or ssh public key trusted to sign policy files; only read from ~/.gitconfig or the system config"},
+	{Section: "policy", Key: "strict", Kind: Bool, Default: "false", Doc: "refuse policy files without a signature from a trusted key"},
+	{Key: "expandEnv", Kind: List, Doc: "variables besides USER, USERNAME, LOGNAME and GIT_HOOKS_ENV which values may refer to; only read from .git/config or ~/.gitconfig"},
+
+	{Section: "prepare-commit-message", Key: "prefixWithBranch", Kind: Bool, Default: "false", Doc: "prefix the message with the branch name"},
+	{Section: "prepare-commit-message", Key: "prefixWithBranchTemplate", Kind: String, Default: "[%s]", Doc: "the prefix, with %s for the branch; may use {host}, {org}, {repo}, {upstream}, {scope}, {inferredType} and the other template variables"},
+	{Section: "prepare-commit-message", Key: "prefixBranchExclusions", Kind: List, Default: "main,develop", Doc: "branches which are never prefixed"},
+	{Section: "prepare-commit-message", Key: "annotations", Kind: List, Doc: "machine-readable trailers, each 'Key: value [when <conditions>]', e.g. X-Risk: high when paths=db/migrate/"},
+	{Section: "prepare-commit-message", Key: "prefixSkip", Kind: List, Doc: "rules for commits to leave unprefixed, e.g. source=merge|squash or paths=docs/ author=*[bot]@*"},
+	{Section: "prepare-commit-message", Key: "prefixPlacement", Kind: Enum, Values: []string{"start", "after-type"}, Default: "start", Doc: "'[%s] feat: subject' or 'feat(scope): [%s] subject'"},
+	{Section: "prepare-commit-message", Key: "subjectTemplate", Kind: String, Doc: "start an empty message with this, e.g. 'feat({scope}): '"},
+	{Section: "prepare-commit-message", Key: "rootCommitTemplate", Kind: String, Doc: "start the empty message of a repo's first commit with this instead, e.g. 'chore: initial commit'"},
+	{Section: "prepare-commit-message", Key: "firstCommitPrefix", Kind: Enum, Values: []string{"branch", "skip", "default-branch"}, Default: "branch", Doc: "prefix a repo's first commit with the branch HEAD names, nothing, or init.defaultBranch"},
+	{Section: "prepare-commit-message", Key: "amendPrefix", Kind: Enum, Values: []string{"original", "branch"}, Default: "original", Doc: "keep the prefix of the commit whose message -c, -C or --amend reuse, or prefix with the current branch"},
+	{Section: "prepare-commit-message", Key: "branchCheck", Kind: Enum, Values: []string{"off", "remote", "pr"}, Default: "off", Doc: "warn when the branch is not on the remote, or its pull request is already merged or closed"},
+	{Section: "prepare-commit-message", Key: "detachedHeadPrefix", Kind: Enum, Values: []string{"skip", "sha", "detached"}, Default: "skip", Doc: "what to prefix with on a detached HEAD"},
+	{Section: "prepare-commit-message", Key: "rememberBranchPrefix", Kind: Bool, Default: "false", Doc: "keep a branch's ticket in branch.<name>.githooksTicket so a renamed branch keeps it"},
+	{Section: "prepare-commit-message", Key: "branchNormalization", Kind: Enum, Values: []string{"nfc", "nfd", "none"}, Default: "nfc", Doc: "the unicode normalization form of the branch name in the prefix"},
+	{Section: "prepare-commit-message", Key: "branchTransliterate", Kind: Bool, Default: "false", Doc: "spell the branch name in the prefix in ASCII"},
+	{Section: "prepare-commit-message", Key: "branchMaxLength", Kind: Int, Default: "0", Doc: "shorten the branch name in the prefix to this many characters, never splitting one"},
+	{Section: "prepare-commit-message", Key: "revertBehavior", Kind: Enum, Values: replay, Default: "refs", Doc: "how reverted commits' messages are prepared"},
+	{Section: "prepare-commit-message", Key: "cherryPickBehavior", Kind: Enum, Values: replay, Default: "refs", Doc: "how cherry-picked commits' messages are prepared"},
+	{Section: "prepare-commit-message", Key: "createChangeId", Kind: Bool, Default: "false", Doc: "still read by commit-msg; see commit-message.createChangeId"},
+	{Section: "prepare-commit-message", Key: "changeIdRemotes", Kind: List, Doc: "still read by commit-msg; see commit-message.changeIdRemotes"},
+	{Section: "prepare-commit-message", Key: "builtOn", Kind: Enum, Values: []string{"off", "describe", "tag"}, Default: "off", Doc: "add a Built-on trailer naming the nearest tag"},
+	{Section: "prepare-commit-message", Key: "topic", Kind: Enum, Values: []string{"off", "branch", "inherit"}, Default: "off", Doc: "add a Topic trailer for stacked diffs, from the branch or the commit below"},
+	{Section: "prepare-commit-message", Key: "warnOnEmptyMessage", Kind: Bool, Default: "false", Doc: "add a warning comment while the message has nothing but a prefix and trailers"},
+	{Section: "prepare-commit-message", Key: "signOff", Kind: Bool, Default: "false", Doc: "add a Signed-off-by trailer for user.name and user.email"},
+	{Section: "prepare-commit-message", Key: "markPrepared", Kind: Bool, Default: "false", Doc: "leave a message offered again (e.g. after an aborted editor) as is; only while commit.cleanup strips comments"},
+	{Section: "prepare-commit-message", Key: "annotateChanges", Kind: Bool, Default: "false", Doc: "add a comment saying what the hook changed"},
+	{Section: "prepare-commit-message", Key: "coauthorsTTL", Kind: Duration, Doc: "ask whether the mob is still accurate once it has not changed for this long"},
+	{Section: "prepare-commit-message", Key: "squashCoauthors", Kind: Bool, Default: "true", Doc: "after git merge --squash, credit the squashed commits' authors and coauthors"},
+	{Section: "prepare-commit-message", Key: "smartCommits", Kind: Bool, Default: "false", Doc: "turn '@time 2h' and the like into Jira smart commit commands"},
+	{Section: "prepare-commit-message", Key: "mergeTickets", Kind: Bool, Default: "false", Doc: "list the tickets of the commits being merged in MERGE_MSG"},
+	{Section: "prepare-commit-message", Key: "mergeStripPrefix", Kind: List, Default: "", Doc: "target branches whose merge and squash subjects have the branch prefix taken off, its tickets kept in a Refs trailer"},
+
+	{Section: "commit-message", Key: "conventionalTypes", Kind: List, Default: "feat,fix,docs,style,refactor,perf,test,build,ci,chore,revert", Doc: "the types conventional-header allows"},
+	{Section: "commit-message", Key: "ticketPattern", Kind: Pattern, Default: "[A-Z][A-Z0-9]+-[0-9]+", Doc: "what ticket-reference looks for"},
+	{Section: "commit-message", Key: "allowedLinkDomains", Kind: List, Doc: "the only domains links may go to; a domain also allows its subdomains"},
+	{Section: "commit-message", Key: "blockedLinkDomains", Kind: List, Doc: "domains links may not go to"},
+	{Section: "commit-message", Key: "linkTimeout", Kind: Duration, Default: "3s", Doc: "how long link-resolves waits for each link"},
+	{Section: "commit-message", Key: "interactiveFixes", Kind: Bool, Default: "true", Doc: "on a terminal, offer to fix, edit or bypass instead of failing"},
+	{Section: "commit-message", Key: "learnFixes", Kind: Bool, Default: "true", Doc: "offer to record a change made the same way to blocked subjects as an auto-fix"},
+	{Section: "commit-message", Key: "autoFix", Kind: Multi, Doc: "a recorded fix offered for its rules, e.g. conventional-header: s/^fix /fix: / or <rules>: capitalize"},
+	{Section: "commit-message", Key: "previewMessage", Kind: Bool, Default: "false", Doc: "print the message as git will record it, cleaned up as commit.cleanup says, to stderr"},
+	{Section: "commit-message", Key: "lineEndings", Kind: Enum, Values: []string{"preserve", "lf", "crlf"}, Default: "preserve", Doc: "how the lines of a message the hooks rewrite end"},
+	{Section: "commit-message", Key: "keepByteOrderMark", Kind: Bool, Default: "false", Doc: "write a byte order mark the editor added back to a rewritten message"},
+	{Section: "commit-message", Key: "duplicateSubjectDepth", Kind: Int, Default: "10", Doc: "how many commits back duplicate-subject looks (0: off)"},
+	{Section: "commit-message", Key: "duplicateSubjectAllow", Kind: List, Default: "Merge *,fixup! *,squash! *,amend! *", Doc: "subjects which may repeat; * matches anything"},
+	{Section: "commit-message", Key: "coauthorDomains", Kind: List, Doc: "the domains coauthor emails are at"},
+	{Section: "commit-message", Key: "coauthorDirectory", Kind: String, Doc: "csv:<file>, scim:<url> or command:<command> listing the org's people; command: and scim: only from .git/config or ~/.gitconfig"},
+	{Section: "commit-message", Key: "coauthorDirectoryToken", Kind: String, Doc: "bearer token for scim, see 'go-githooks secret'; only read from .git/config or ~/.gitconfig"},
+	{Section: "commit-message", Key: "redactMode", Kind: Enum, Values: []string{"block", "mask"}, Default: "block", Doc: "block, or replace sensitive content with [REDACTED]"},
+	{Section: "commit-message", Key: "redactCredentials", Kind: Bool, Default: "true", Doc: "look for access keys, tokens, private keys and password=... assignments"},
+	{Section: "commit-message", Key: "redactTerms", Kind: List, Doc: "e.g. customer names, matched as whole words"},
+	{Section: "commit-message", Key: "redactDomains", Kind: List, Doc: "internal hostnames, including subdomains"},
+	{Section: "commit-message", Key: "redactPattern", Kind: Multi, Doc: "a regular expression; repeat the key for more than one"},
+	{Section: "commit-message", Key: "createChangeId", Kind: Bool, Default: "false", Doc: "add a Gerrit Change-Id trailer when missing, as Gerrit's hook does"},
+	{Section: "commit-message", Key: "changeIdRemotes", Kind: List, Doc: "only add Change-Ids for repos with one of these remotes, by name or url host (*.acme.com)"},
+
+	{Section: "pre-commit", Key: "jsonSchemas", Kind: List, Doc: "glob=schema: validate staged files against a JSON Schema"},
+	{Section: "pre-commit", Key: "sizeMaxFiles", Kind: Int, Default: "30", Doc: "commit-size suggests splitting beyond this many files"},
+	{Section: "pre-commit", Key: "sizeMaxInsertions", Kind: Int, Default: "500", Doc: "commit-size suggests splitting beyond this many insertions"},
+	{Section: "pre-commit", Key: "sizeBlockFiles", Kind: Int, Default: "0", Doc: "commit-size-limit blocks beyond this many files (0: no limit)"},
+	{Section: "pre-commit", Key: "sizeBlockInsertions", Kind: Int, Default: "0", Doc: "commit-size-limit blocks beyond this many insertions (0: no limit)"},
+	{Section: "pre-commit", Key: "sizeExclusions", Kind: List, Default: "vendor,node_modules,*.lock,go.sum", Doc: "globs for paths left out of the size"},
+	{Section: "pre-commit", Key: "generatedPaths", Kind: List, Doc: "globs for generated paths, besides linguist-generated in .gitattributes"},
+	{Section: "pre-commit", Key: "generatedMarkers", Kind: List, Default: "Code generated,@generated,<auto-generated", Doc: "text near the top of generated files"},
+	{Section: "pre-commit", Key: "skipAttributes", Kind: List, Default: "githooks-skip", Doc: ".gitattributes attributes whose paths every file check skips; 'githooks-skip=todo-ticket' skips only the rules listed"},
+	{Section: "pre-commit", Key: "formatters", Kind: List, Default: "goimports,gofmt,prettier,black,rustfmt", Doc: "the first one on PATH for each extension is used; only read from .git/config or ~/.gitconfig"},
+	{Section: "pre-commit", Key: "formatMode", Kind: Enum, Values: []string{"check", "fix"}, Default: "check", Doc: "fix formats and re-stages fully staged files"},
+	{Section: "pre-commit", Key: "lockfiles", Kind: List, Default: "go,npm,yarn,pnpm,cargo,bundler,composer,poetry,pipenv", Doc: "ecosystems whose manifest and lockfile are staged together ('none' for none)"},
+	{Section: "pre-commit", Key: "imageMaxSize", Kind: String, Default: "1MB", Doc: "image-budget blocks staged images larger than this"},
+	{Section: "pre-commit", Key: "imageMaxDimensions", Kind: String, Doc: "e.g. 4096x4096"},
+	{Section: "pre-commit", Key: "imageOptimizers", Kind: List, Doc: "any of pngquant,oxipng,jpegoptim,svgo; only read from .git/config or ~/.gitconfig"},
+	{Section: "pre-commit", Key: "imageMode", Kind: Enum, Values: []string{"check", "fix"}, Default: "check", Doc: "fix optimizes and re-stages fully staged images"},
+
+	{Section: "pre-push", Key: "commitLimit", Kind: Int, Default: "1000", Doc: "the most commits checked per pushed ref"},
+	{Section: "pre-push", Key: "mainBranches", Kind: List, Default: "main,master", Doc: "branches merge-from-main treats as main"},
+	{Section: "pre-push", Key: "maxCommitAge", Kind: Duration, Default: "720h", Doc: "how old a pushed commit may be before commit-age flags it"},
+	{Section: "pre-push", Key: "sharedBranches", Kind: List, Doc: "branch globs fixup-commits warns about pushing to (default: mainBranches)"},
+	{Section: "pre-push", Key: "signingKeyring", Kind: String, Doc: "armored OpenPGP public keys signed-commits trusts"},
+	{Section: "pre-push", Key: "sshAllowedSigners", Kind: String, Doc: "an allowed signers file of the SSH keys signed-commits trusts"},
+	{Section: "pre-push", Key: "allowUnsignedMerges", Kind: Bool, Default: "false", Doc: "signed-commits skips unsigned merges"},
+
+	{Section: "post-rewrite", Key: "trailerPolicy", Kind: Enum, Values: []string{"off", "dedupe", "preserve", "merge"}, Default: "off", Doc: "how trailers of amended and rebased commits are reconciled"},
+	{Section: "post-rewrite", Key: "trailerKeys", Kind: List, Default: "Co-authored-by,Signed-off-by", Doc: "the trailers preserve and merge restore"},
+
+	{Section: "worklog", Key: "enabled", Kind: Bool, Default: "false", Doc: "post-commit logs each commit to the issues it references"},
+	{Section: "worklog", Key: "provider", Kind: Enum, Values: []string{"jira", "github", "gitlab", "command"}, Doc: "the tracker to log to"},
+	{Section: "worklog", Key: "url", Kind: String, Doc: "jira: the site; github and gitlab: the repo's or project's API url; only read from .git/config or ~/.gitconfig"},
+	{Section: "worklog", Key: "user", Kind: String, Doc: "jira cloud: the account the token belongs to; only read from .git/config or ~/.gitconfig"},
+	{Section: "worklog", Key: "token", Kind: String, Doc: "the tracker token, see 'go-githooks secret'; only read from .git/config or ~/.gitconfig"},
+	{Section: "worklog", Key: "command", Kind: String, Doc: "command provider: run with the issue and its entries as JSON on stdin; only read from .git/config or ~/.gitconfig"},
+	{Section: "worklog", Key: "template", Kind: String, Default: "{shortSha} {subject} (on {branch})", Doc: "the comment logged; also {sha}, {author}, {issue} and {time}"},
+	{Section: "worklog", Key: "ticketPattern", Kind: Pattern, Default: "[A-Z][A-Z0-9]+-[0-9]+", Doc: "the issues a commit references, e.g. #[0-9]+ for github and gitlab"},
+	{Section: "worklog", Key: "mode", Kind: Enum, Values: []string{"comment", "time"}, Default: "comment", Doc: "time only logs commits which record time, e.g. 'ABC-123 #time 2h'"},
+	{Section: "worklog", Key: "async", Kind: Bool, Default: "true", Doc: "false waits for the tracker before the commit returns"},
+	{Section: "worklog", Key: "delay", Kind: Duration, Default: "2s", Doc: "how long a background flush waits for more commits to batch"},
+
+	{Section: "post-checkout", Key: "runCommands", Kind: Bool, Default: "false", Doc: "run the tool commands rather than only printing them; only read from .git/config or ~/.gitconfig"},
+	{Section: "post-checkout", Key: "promptBeforeRun", Kind: Bool, Default: "true", Doc: "ask before running each command; only read from .git/config or ~/.gitconfig"},
+
+	{Section: "todo", Key: "keywords", Kind: List, Default: "TODO,FIXME", Doc: "todo-ticket flags these in comments on added lines"},
+	{Section: "todo", Key: "ticketPattern", Kind: Pattern, Default: "[A-Z][A-Z0-9]+-[0-9]+|#[0-9]+", Doc: "unless the line also matches this"},
+	{Section: "todo", Key: "paths", Kind: List, Doc: "glob=severity: todo-ticket's severity under those paths"},
+
+	{Section: "scope", Key: "map", Kind: List, Doc: "path prefix=scope, e.g. services/billing=billing"},
+
+	{Section: "pairing", Key: "providers", Kind: List, Doc: "file | liveshare: add the coauthors of the current pairing session"},
+	{Section: "pairing", Key: "file", Kind: String, Default: ".pairing", Doc: "one '[Role:] Name <email>' per line"},
+	{Section: "pairing", Key: "liveShareSession", Kind: String, Default: ".vscode/liveshare-peers.json", Doc: "the Live Share peers file"},
+	{Section: "pairing", Key: "maxAge", Kind: Duration, Default: "12h", Doc: "session files untouched for longer are ignored"},
+	{Section: "pairing", Key: "pathCoauthors", Kind: Multi, Doc: "'<pattern> = Name <email>[, ...]': credit them on commits touching matching paths"},
+	{Section: "pairing", Key: "codeowners", Kind: String, Doc: "a CODEOWNERS file whose email owners are credited on the paths they own"},
+	{Section: "pairing", Key: "pathRotation", Kind: Enum, Values: []string{"all", "weekly"}, Default: "all", Doc: "credit every owner of a path, or one in turn each week"},
+	{Section: "pairing", Key: "role", Kind: Multi, Doc: "'Reviewed-by <email>' for one person or 'Paired-with' for everyone else"},
+
+	{Section: "mob", Key: "rotate", Kind: Bool, Default: "false", Doc: "hand the keyboard to the next member of the mob after each turn"},
+	{Section: "mob", Key: "member", Kind: Multi, Doc: "'Name <email>', in driving order (default: the commit's author, then its coauthors)"},
+	{Section: "mob", Key: "every", Kind: Int, Default: "1", Doc: "the commits each driver makes in a turn"},
+	{Section: "mob", Key: "session", Kind: Enum, Values: []string{"pairing", "git-mob"}, Default: "pairing", Doc: "where the next driver's coauthors are written"},
+	{Section: "mob", Key: "switchIdentity", Kind: Bool, Default: "false", Doc: "commit as the next driver from this repo, for a mob sharing one keyboard"},
+	{Section: "mob", Key: "handoffCommand", Kind: String, Doc: "e.g. git push && say done; run once confirmed, with GIT_HOOKS_MOB_DRIVER set; only read from .git/config or ~/.gitconfig"},
+
+	{Section: "vcs", Key: "remote", Kind: List, Default: "origin,upstream", Doc: "remotes tried, in order, for {host}, {org}, {repo} and {provider}"},
+	{Section: "vcs", Key: "providerHosts", Kind: List, Doc: "e.g. code.corp.internal=gitlab for self-hosted servers"},
+	{Section: "vcs", Key: "host", Kind: String, Doc: "override what the remote url says"},
+	{Section: "vcs", Key: "org", Kind: String, Doc: "override what the remote url says"},
+	{Section: "vcs", Key: "repo", Kind: String, Doc: "override what the remote url says"},
+	{Section: "vcs", Key: "provider", Kind: Enum, Values: []string{"github", "gitlab", "bitbucket", "gerrit"}, Doc: "override the provider guessed from the host"},
+	{Section: "vcs", Key: "apiUrl", Kind: String, Doc: "the provider's REST API; only read from .git/config or ~/.gitconfig"},
+	{Section: "vcs", Key: "token", Kind: String, Doc: "for the provider's API; may be a secret reference; only read from .git/config or ~/.gitconfig"},
+
+	{Section: "enforcement", Key: "adoptionDate", Kind: Date, Doc: "errors in commits authored before this only warn"},
+	{Section: "enforcement", Key: "graceWarnings", Kind: Int, Default: "0", Doc: "then warn each author this many times per rule before blocking"},
+
+	{Section: "identity", Key: "policy", Kind: Multi, Doc: "remote=email globs, e.g. github.com/acme/*=*@acme.com"},
+	{Section: "identity", Key: "name", Kind: String, Doc: "the user.name offered when a commit breaks a policy"},
+	{Section: "identity", Key: "email", Kind: String, Doc: "the user.email offered when a commit breaks a policy"},
+
+	{Section: "budget", Key: "prepare-commit-msg", Kind: Duration, Doc: "e.g. 300ms: skip optional steps, lowest priority first, as the hook uses this up"},
+	{Section: "budget", Key: "commit-msg", Kind: Duration, Doc: "e.g. 2s: skip duplicate-subject, link-resolves, then the coauthor directory as the hook uses this up"},
+	{Section: "budget", Key: "pre-commit", Kind: Duration, Doc: "e.g. 5s: skip todo-ticket, then formatting as the hook uses this up"},
+	{Section: "bypass", Key: "maxTTL", Kind: Duration, Default: "24h", Doc: "the longest 'go-githooks bypass' may last"},
+	{Section: "notify", Key: "enabled", Kind: Bool, Default: "false", Doc: "a desktop notification when a slow hook finishes"},
+	{Section: "notify", Key: "after", Kind: Duration, Default: "10s", Doc: "how slow a hook must be to notify"},
+	{Section: "scripts", Key: "enabled", Kind: Bool, Default: "false", Doc: "run the repo's .githooks/<hook>.d/* scripts; only read from .git/config or ~/.gitconfig"},
+	{Section: "telemetry", Key: "enabled", Kind: Bool, Default: "false", Doc: "opt in to local, anonymous usage stats; only read from .git/config or ~/.gitconfig"},
+}
@@ -0,0 +1,27 @@
+package settings
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	limit, _ := Lookup("go-githooks.pre-push.commitlimit")
+	assert.Equal(t, "go-githooks.pre-push.commitLimit", limit.Name())
+	assert.NoError(t, limit.Validate("800"))
+	assert.NoError(t, limit.Validate(""))
+	assert.Error(t, limit.Validate("lots"))
+
+	placement, _ := Lookup("go-githooks.prepare-commit-message.prefixPlacement")
+	assert.NoError(t, placement.Validate("after-type"))
+	assert.EqualError(t, placement.Validate("end"), "'end' is not a valid enum for go-githooks.prepare-commit-message.prefixPlacement: expected one of: start, after-type")
+
+	rule, ok := Lookup("go-githooks.rules.duplicate-subject")
+	assert.True(t, ok)
+	assert.Equal(t, "warning", rule.Default)
+	assert.NoError(t, rule.Validate("off"))
+	assert.Error(t, rule.Validate("loud"))
+
+	_, ok = Lookup("go-githooks.nope.nothing")
+	assert.False(t, ok)
+}
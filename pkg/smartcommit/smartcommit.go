@@ -0,0 +1,167 @@
+package smartcommit
+
+import (
+	"fmt"
+	"github.com/davidalpert/go-githooks/pkg/message"
+	"regexp"
+	"strings"
+)
+
+// KeyPattern matches a Jira issue key, e.g. ABC-123
+const KeyPattern = `[A-Z][A-Z0-9_]+-[0-9]+`
+
+var (
+	keyRe        = regexp.MustCompile(`\b` + KeyPattern + `\b`)
+	directiveRe  = regexp.MustCompile(`(^|\s)@(time|comment|transition)\b`)
+	transitionRe = regexp.MustCompile(`@transition\s+(\S+)`)
+	keywordRe    = regexp.MustCompile(`@(time|comment)\b`)
+	// a line Jira reads commands from: one or more issue keys, then the commands
+	commandLineRe = regexp.MustCompile(`^\s*((?:` + KeyPattern + `\s+)+)(#[A-Za-z].*)$`)
+	commandRe     = regexp.MustCompile(`(?:^|\s)#([A-Za-z][A-Za-z0-9_-]*)`)
+	strayRe       = regexp.MustCompile(`(?:^|\s)#(time|comment)\b`)
+	durationRe    = regexp.MustCompile(`^(\d+(\.\d+)?[wdhm]\s*)+(\s|$)`)
+)
+
+// FindKey returns the first Jira issue key in s, or ""
+func FindKey(s string) string {
+	return keyRe.FindString(s)
+}
+
+// HasDirectives reports whether msg has any directive for Convert
+func HasDirectives(msg []byte) bool {
+	content, _ := message.SplitComments(msg)
+	for _, l := range strings.Split(string(content), "\n") {
+		if directiveRe.MatchString(l) {
+			return true
+		}
+	}
+	return false
+}
+
+// Convert rewrites the directives '@time 2h', '@comment text' and '@transition done'
+// into the smart commit commands 'KEY #time 2h', 'KEY #comment text' and 'KEY #done',
+// which Jira only reads after an issue key; typing # at the start of a line would
+// make git drop it as a comment. Directives in the subject move to the body.
+func Convert(msg []byte, key string) []byte {
+	if key == "" {
+		return msg
+	}
+	content, comments := message.SplitComments(msg)
+	lines := strings.Split(string(content), "\n")
+	out := make([]string, 0, len(lines))
+	moved := make([]string, 0)
+	for i, l := range lines {
+		text, command := splitDirective(l, key)
+		switch {
+		case command == "":
+			out = append(out, l)
+		case i == 0 && strings.TrimSpace(text) != "":
+			out = append(out, text)
+			moved = append(moved, command)
+		case strings.TrimSpace(text) != "":
+			out = append(out, text, command)
+		default:
+			out = append(out, command)
+		}
+	}
+	if len(moved) > 0 {
+		rest := out[1:]
+		out = append([]string{out[0], ""}, moved...)
+		switch {
+		case len(rest) == 0 || (len(rest) == 1 && rest[0] == ""):
+			out = append(out, "")
+		case rest[0] == "":
+			out = append(out, rest...)
+		default:
+			out = append(append(out, ""), rest...)
+		}
+	}
+	return append([]byte(strings.Join(out, "\n")), comments...)
+}
+
+// splitDirective returns the text of l before its first directive, and the directives
+// as one command line for key; the command is "" when l has no directive
+func splitDirective(l, key string) (string, string) {
+	loc := directiveRe.FindStringIndex(l)
+	if loc == nil {
+		return l, ""
+	}
+	directives := strings.TrimSpace(l[loc[0]:])
+	directives = transitionRe.ReplaceAllString(directives, "#$1")
+	directives = keywordRe.ReplaceAllString(directives, "#$1")
+	return strings.TrimRight(l[:loc[0]], " \t"), key + " " + directives
+}
+
+// Validate reports smart commit commands Jira would ignore or reject, and directives
+// left unconverted, e.g. for want of an issue key
+func Validate(msg []byte) []string {
+	content, _ := message.SplitComments(msg)
+	problems := make([]string, 0)
+	for _, l := range strings.Split(string(content), "\n") {
+		if directiveRe.MatchString(l) {
+			problems = append(problems, fmt.Sprintf("'%s' was not turned into a smart commit command; write it as e.g. 'ABC-123 #time 2h', or name the ticket in the branch or subject", strings.TrimSpace(l)))
+			continue
+		}
+		m := commandLineRe.FindStringSubmatch(l)
+		if m == nil {
+			if c := strayRe.FindStringSubmatch(l); c != nil {
+				problems = append(problems, fmt.Sprintf("'#%s' in '%s' is only read after an issue key, e.g. ABC-123 #%s ...", c[1], strings.TrimSpace(l), c[1]))
+			}
+			continue
+		}
+		for _, c := range splitCommands(m[2]) {
+			switch {
+			case c.name == "time" && !durationRe.MatchString(c.args):
+				problems = append(problems, fmt.Sprintf("#time needs a duration such as 1w 2d 4h 30m, not '%s'", c.args))
+			case c.name == "comment" && c.args == "":
+				problems = append(problems, "#comment needs the text of the comment")
+			}
+		}
+	}
+	return problems
+}
+
+// TimeSpent returns the duration of the first '#time' command for key in msg, or ""
+func TimeSpent(msg []byte, key string) string {
+	content, _ := message.SplitComments(msg)
+	for _, l := range strings.Split(string(content), "\n") {
+		m := commandLineRe.FindStringSubmatch(l)
+		if m == nil || !containsKey(m[1], key) {
+			continue
+		}
+		for _, c := range splitCommands(m[2]) {
+			if d := durationRe.FindString(c.args); c.name == "time" && d != "" {
+				return strings.TrimSpace(d)
+			}
+		}
+	}
+	return ""
+}
+
+func containsKey(keys, key string) bool {
+	for _, k := range strings.Fields(keys) {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+type command struct {
+	name string // lower-cased, without the #
+	args string
+}
+
+// splitCommands splits the commands after the issue keys of a command line
+func splitCommands(commands string) []command {
+	locs := commandRe.FindAllStringSubmatchIndex(commands, -1)
+	out := make([]command, 0, len(locs))
+	for i, loc := range locs {
+		end := len(commands)
+		if i+1 < len(locs) {
+			end = locs[i+1][0]
+		}
+		out = append(out, command{name: strings.ToLower(commands[loc[2]:loc[3]]), args: strings.TrimSpace(commands[loc[1]:end])})
+	}
+	return out
+}
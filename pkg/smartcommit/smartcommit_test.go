@@ -0,0 +1,66 @@
+package smartcommit
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestConvert(t *testing.T) {
+	tests := []struct {
+		name string
+		msg  string
+		want string
+	}{
+		{
+			name: "directive lines in the body",
+			msg:  "fix login\n\n@time 2h\n@transition done fixed the redirect\n",
+			want: "fix login\n\nABC-123 #time 2h\nABC-123 #done fixed the redirect\n",
+		},
+		{
+			name: "directives in the subject move to the body",
+			msg:  "fix login @time 1d 2h @comment redirect loop\n",
+			want: "fix login\n\nABC-123 #time 1d 2h #comment redirect loop\n",
+		},
+		{
+			name: "directives in the subject before a body",
+			msg:  "fix login @transition in-review\n\nthe redirect looped\n# Please enter the commit message\n",
+			want: "fix login\n\nABC-123 #in-review\n\nthe redirect looped\n# Please enter the commit message\n",
+		},
+		{
+			name: "emails and other @ words are left alone",
+			msg:  "thank @timothy and mal@serenity.com\n",
+			want: "thank @timothy and mal@serenity.com\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, string(Convert([]byte(tt.msg), "ABC-123")))
+		})
+	}
+
+	assert.Equal(t, "fix login @time 2h\n", string(Convert([]byte("fix login @time 2h\n"), "")))
+}
+
+func TestValidate(t *testing.T) {
+	assert.Empty(t, Validate([]byte("fix login\n\nABC-123 #time 1w 2d 4h 30m #comment fixed\nABC-1 DEF-2 #resolve\nsee #42\n")))
+
+	problems := Validate([]byte("fix login @time 2h\n\nABC-123 #time soon\nABC-123 #comment\nspent ages #time 2h\n"))
+	assert.Len(t, problems, 4)
+	assert.Contains(t, problems[0], "not turned into a smart commit")
+	assert.Contains(t, problems[1], "needs a duration")
+	assert.Contains(t, problems[2], "#comment needs")
+	assert.Contains(t, problems[3], "only read after an issue key")
+}
+
+func TestFindKey(t *testing.T) {
+	assert.Equal(t, "ABC-123", FindKey("feature/ABC-123-login"))
+	assert.Equal(t, "", FindKey("main"))
+}
+
+func TestTimeSpent(t *testing.T) {
+	msg := []byte("fix login\n\nABC-1 DEF-2 #time 1h 30m #comment paired\nABC-3 #comment no time\n")
+	assert.Equal(t, "1h 30m", TimeSpent(msg, "ABC-1"))
+	assert.Equal(t, "1h 30m", TimeSpent(msg, "DEF-2"))
+	assert.Equal(t, "", TimeSpent(msg, "ABC-3"))
+	assert.Equal(t, "", TimeSpent([]byte("ABC-1 #time soon\n"), "ABC-1"))
+}
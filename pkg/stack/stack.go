@@ -0,0 +1,136 @@
+package stack
+
+import (
+	"fmt"
+	"github.com/davidalpert/go-githooks/pkg/message"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// trailer keys used by stacked-diff tools (Gerrit, Zuul, jj) to group and order changes
+const (
+	TopicKey     = "Topic"
+	DependsOnKey = "Depends-On"
+	ChangeIdKey  = "Change-Id"
+)
+
+var (
+	changeIdRe = regexp.MustCompile(`^I[0-9a-f]{40}$`)
+	commitRe   = regexp.MustCompile(`^[0-9a-f]{7,40}$`)
+)
+
+// Metadata is the stack trailers of one commit message
+type Metadata struct {
+	Topics    []string
+	DependsOn []string
+	ChangeId  string
+}
+
+// Parse reads the stack trailers from msg; keys compare case-insensitively
+func Parse(msg []byte) Metadata {
+	m := Metadata{}
+	for _, t := range message.Trailers(msg) {
+		switch {
+		case strings.EqualFold(t.Key, TopicKey):
+			m.Topics = append(m.Topics, t.Value)
+		case strings.EqualFold(t.Key, DependsOnKey):
+			m.DependsOn = append(m.DependsOn, t.Value)
+		case strings.EqualFold(t.Key, ChangeIdKey):
+			m.ChangeId = t.Value
+		}
+	}
+	return m
+}
+
+// Topic is the commit's topic, or "" when it has none
+func (m Metadata) Topic() string {
+	if len(m.Topics) == 0 {
+		return ""
+	}
+	return m.Topics[0]
+}
+
+// Validate reports trailers which stack tools would reject or misread
+func (m Metadata) Validate() []string {
+	problems := make([]string, 0)
+	if len(m.Topics) > 1 {
+		problems = append(problems, fmt.Sprintf("has %d %s trailers; a commit belongs to one topic", len(m.Topics), TopicKey))
+	}
+	for _, t := range m.Topics {
+		if t == "" || strings.ContainsAny(t, " \t") {
+			problems = append(problems, fmt.Sprintf("%s '%s' should be a single word, e.g. the branch name", TopicKey, t))
+		}
+	}
+	for _, d := range m.DependsOn {
+		if !ValidReference(d) {
+			problems = append(problems, fmt.Sprintf("%s '%s' is not a Change-Id, commit hash or change url", DependsOnKey, d))
+		}
+	}
+	return problems
+}
+
+// ValidReference accepts what Depends-On may point at: a Change-Id, a commit hash
+// or the url of a change, possibly in another repo
+func ValidReference(v string) bool {
+	if changeIdRe.MatchString(v) || commitRe.MatchString(v) {
+		return true
+	}
+	u, err := url.Parse(v)
+	return err == nil && (u.Scheme == "https" || u.Scheme == "http") && u.Host != ""
+}
+
+// Problem is an inconsistency between the commits of a stack
+type Problem struct {
+	Commit  string
+	Message string
+}
+
+// Check looks for inconsistencies across a stack of commits, given newest first as
+// pre-push lists them: commits with different topics, Change-Ids used twice, and
+// changes which depend on a change stacked on top of them
+func Check(commits []*object.Commit) []Problem {
+	problems := make([]Problem, 0)
+	metadata := make([]Metadata, len(commits))
+	position := map[string]int{} // Change-Id and hash to index; a higher index is older
+	for i, c := range commits {
+		metadata[i] = Parse([]byte(c.Message))
+		if id := metadata[i].ChangeId; id != "" {
+			if j, ok := position[id]; ok {
+				problems = append(problems, Problem{Commit: c.Hash.String(), Message: fmt.Sprintf("uses %s %s, as %s does", ChangeIdKey, id, commits[j].Hash.String()[:7])})
+			} else {
+				position[id] = i
+			}
+		}
+		position[c.Hash.String()] = i
+	}
+
+	topic := ""
+	for i := len(commits) - 1; i >= 0; i-- {
+		if t := metadata[i].Topic(); t != "" {
+			topic = t
+			break
+		}
+	}
+
+	for i, c := range commits {
+		if topic != "" && metadata[i].Topic() != topic {
+			problems = append(problems, Problem{Commit: c.Hash.String(), Message: fmt.Sprintf("has %s '%s' but the stack is '%s'", TopicKey, metadata[i].Topic(), topic)})
+		}
+		for _, d := range metadata[i].DependsOn {
+			j, ok := position[d]
+			if !ok {
+				for h, k := range position {
+					if commitRe.MatchString(d) && strings.HasPrefix(h, d) {
+						j, ok = k, true
+					}
+				}
+			}
+			if ok && j <= i {
+				problems = append(problems, Problem{Commit: c.Hash.String(), Message: fmt.Sprintf("depends on %s, which is stacked on top of it", d)})
+			}
+		}
+	}
+	return problems
+}
@@ -0,0 +1,47 @@
+package stack
+
+import (
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+const (
+	changeA = "I0000000000000000000000000000000000000001"
+	changeB = "I0000000000000000000000000000000000000002"
+)
+
+func TestValidate(t *testing.T) {
+	m := Parse([]byte("fix login\n\nTopic: login\nDepends-On: " + changeA + "\nDepends-On: https://review.example.com/c/1234\nChange-Id: " + changeB + "\n"))
+	assert.Equal(t, Metadata{Topics: []string{"login"}, DependsOn: []string{changeA, "https://review.example.com/c/1234"}, ChangeId: changeB}, m)
+	assert.Empty(t, m.Validate())
+
+	m = Parse([]byte("fix login\n\nTopic: login stack\ntopic: auth\nDepends-On: the other one\n"))
+	assert.Len(t, m.Validate(), 3)
+}
+
+func TestCheck(t *testing.T) {
+	commit := func(n byte, msg string) *object.Commit {
+		return &object.Commit{Hash: plumbing.Hash{n}, Message: msg}
+	}
+
+	// newest first, as pushed
+	consistent := []*object.Commit{
+		commit(2, "second\n\nTopic: login\nDepends-On: "+changeA+"\nChange-Id: "+changeB+"\n"),
+		commit(1, "first\n\nTopic: login\nChange-Id: "+changeA+"\n"),
+	}
+	assert.Empty(t, Check(consistent))
+
+	inconsistent := []*object.Commit{
+		commit(2, "second\n\nTopic: auth\nChange-Id: "+changeA+"\n"),
+		commit(1, "first\n\nTopic: login\nDepends-On: "+changeA+"\nChange-Id: "+changeB+"\n"),
+	}
+	problems := Check(inconsistent)
+	assert.Len(t, problems, 2)
+	assert.Contains(t, problems[0].Message, "has Topic 'auth' but the stack is 'login'")
+	assert.Contains(t, problems[1].Message, "stacked on top of it")
+
+	assert.Len(t, Check([]*object.Commit{commit(2, "b\n\nChange-Id: "+changeA+"\n"), commit(1, "a\n\nChange-Id: "+changeA+"\n")}), 1)
+	assert.Empty(t, Check([]*object.Commit{commit(1, "no metadata")}))
+}
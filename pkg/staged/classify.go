@@ -0,0 +1,84 @@
+package staged
+
+import (
+	"path"
+	"strings"
+)
+
+// The kinds of file Classify tells apart, named after the conventional commit type
+// a change to only that kind of file would have
+const (
+	DocsKind  = "docs"
+	TestKind  = "test"
+	BuildKind = "build"
+	CIKind    = "ci"
+	CodeKind  = "feat"
+)
+
+var (
+	docExtensions = map[string]bool{".md": true, ".markdown": true, ".rst": true, ".adoc": true, ".txt": true}
+	docNames      = map[string]bool{"readme": true, "license": true, "changelog": true, "contributing": true, "authors": true, "notice": true}
+	docDirs       = map[string]bool{"doc": true, "docs": true, "documentation": true}
+
+	buildNames = map[string]bool{
+		"makefile": true, "dockerfile": true, "go.mod": true, "go.sum": true, "package.json": true,
+		"package-lock.json": true, "yarn.lock": true, "pnpm-lock.yaml": true, "cargo.toml": true,
+		"cargo.lock": true, "pom.xml": true, "build.gradle": true, "settings.gradle": true,
+		"cmakelists.txt": true, "requirements.txt": true, "pyproject.toml": true, "setup.py": true,
+		"gemfile": true, "gemfile.lock": true, ".goreleaser.yml": true, ".goreleaser.yaml": true,
+	}
+	buildExtensions = map[string]bool{".mk": true, ".gradle": true, ".cmake": true, ".bazel": true, ".bzl": true}
+
+	ciNames    = map[string]bool{".gitlab-ci.yml": true, ".travis.yml": true, "jenkinsfile": true, "azure-pipelines.yml": true, "bitbucket-pipelines.yml": true}
+	ciPrefixes = []string{".github/workflows/", ".circleci/", ".buildkite/"}
+)
+
+// Kind guesses from its path what kind of file p is: docs, a test, part of the build
+// or of CI, else code
+func Kind(p string) string {
+	base := strings.ToLower(path.Base(p))
+	for _, prefix := range ciPrefixes {
+		if strings.HasPrefix(p, prefix) {
+			return CIKind
+		}
+	}
+	if ciNames[base] {
+		return CIKind
+	}
+	if buildNames[base] || buildExtensions[path.Ext(base)] || strings.HasPrefix(base, "dockerfile.") {
+		return BuildKind
+	}
+	if IsTest(p) {
+		return TestKind
+	}
+	if docExtensions[path.Ext(base)] || docNames[strings.TrimSuffix(base, path.Ext(base))] {
+		return DocsKind
+	}
+	for _, dir := range strings.Split(path.Dir(p), "/") {
+		if docDirs[dir] {
+			return DocsKind
+		}
+	}
+	return CodeKind
+}
+
+// Classify infers the conventional commit type of a change to files: docs, test,
+// ci or build when every file is of that kind (build also covering CI files), else
+// feat for any change to code; "" when nothing is staged
+func Classify(files []File) string {
+	kinds := map[string]bool{}
+	for _, f := range files {
+		kinds[Kind(f.Path)] = true
+	}
+	switch {
+	case len(kinds) == 0:
+		return ""
+	case len(kinds) == 1:
+		for k := range kinds {
+			return k
+		}
+	case !kinds[CodeKind] && !kinds[DocsKind] && !kinds[TestKind]:
+		return BuildKind
+	}
+	return CodeKind
+}
@@ -0,0 +1,60 @@
+package staged
+
+import (
+	"path"
+	"sort"
+	"strings"
+)
+
+// ScopeMap maps path prefixes to conventional-commit scopes for monorepos, e.g.
+// services/billing=billing; paths it does not cover are scoped by their top-level
+// directory
+type ScopeMap map[string]string
+
+// ParseScopeMap reads 'path=scope' entries, as listed in config
+func ParseScopeMap(entries []string) ScopeMap {
+	m := ScopeMap{}
+	for _, e := range entries {
+		parts := strings.SplitN(e, "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		prefix := strings.Trim(strings.TrimSpace(parts[0]), "/")
+		if scope := strings.TrimSpace(parts[1]); prefix != "" && scope != "" {
+			m[prefix] = scope
+		}
+	}
+	return m
+}
+
+// ScopeOf returns the scope of the longest prefix in m covering p, or the top-level
+// directory of p; files at the top level have no scope
+func (m ScopeMap) ScopeOf(p string) string {
+	longest := ""
+	for prefix := range m {
+		if (p == prefix || strings.HasPrefix(p, prefix+"/")) && len(prefix) > len(longest) {
+			longest = prefix
+		}
+	}
+	if longest != "" {
+		return m[longest]
+	}
+	if d := path.Dir(p); d != "." {
+		return strings.Split(d, "/")[0]
+	}
+	return ""
+}
+
+// Scopes lists the scopes of files, sorted and without repeats
+func (m ScopeMap) Scopes(files []File) []string {
+	seen := map[string]bool{}
+	scopes := make([]string, 0)
+	for _, f := range files {
+		if s := m.ScopeOf(f.Path); s != "" && !seen[s] {
+			seen[s] = true
+			scopes = append(scopes, s)
+		}
+	}
+	sort.Strings(scopes)
+	return scopes
+}
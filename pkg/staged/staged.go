@@ -0,0 +1,192 @@
+package staged
+
+import (
+	"fmt"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/format/index"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// File is a file whose content in the index differs from HEAD
+type File struct {
+	Path  string
+	Hash  plumbing.Hash
+	Mode  filemode.FileMode
+	Added bool // not in HEAD at all
+}
+
+// Files lists the files added or modified in the index compared to HEAD; files
+// staged for deletion are not included since they have no content to inspect, nor
+// are submodules, whose commits are in another repo
+func Files(repo *git.Repository) ([]File, error) {
+	idx, err := repo.Storer.Index()
+	if err != nil {
+		return nil, fmt.Errorf("could not read the index: %v", err)
+	}
+
+	tree, err := headTree(repo)
+	if err != nil {
+		return nil, err
+	}
+
+	files := make([]File, 0)
+	for _, e := range idx.Entries {
+		if e.Stage != 0 || e.Mode == filemode.Submodule {
+			// unmerged paths are left to the merge
+			continue
+		}
+
+		f := File{Path: e.Name, Hash: e.Hash, Mode: e.Mode}
+		if tree == nil {
+			f.Added = true
+		} else if te, err := tree.FindEntry(e.Name); err != nil {
+			f.Added = true
+		} else if te.Hash == e.Hash && te.Mode == e.Mode {
+			continue
+		}
+		files = append(files, f)
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].Path < files[j].Path
+	})
+	return files, nil
+}
+
+// Nothing reports whether the index matches HEAD, like 'git diff --cached --quiet';
+// hooks check it before any per-file work so empty commits and amends which only
+// reword the message stay cheap. It stops at the first difference it finds.
+func Nothing(repo *git.Repository) (bool, error) {
+	idx, err := repo.Storer.Index()
+	if err != nil {
+		return false, fmt.Errorf("could not read the index: %v", err)
+	}
+
+	tree, err := headTree(repo)
+	if err != nil {
+		return false, err
+	}
+	if tree == nil {
+		return len(idx.Entries) == 0, nil
+	}
+
+	byPath := make(map[string]*index.Entry, len(idx.Entries))
+	for _, e := range idx.Entries {
+		if e.Stage != 0 {
+			// an unresolved merge always has something to commit
+			return false, nil
+		}
+		byPath[e.Name] = e
+	}
+
+	walker := object.NewTreeWalker(tree, true, nil)
+	defer walker.Close()
+
+	inHead := 0
+	for {
+		name, te, err := walker.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return false, fmt.Errorf("could not read HEAD tree: %v", err)
+		}
+		if te.Mode == filemode.Dir {
+			continue
+		}
+		inHead++
+		if e, ok := byPath[name]; !ok || e.Hash != te.Hash || e.Mode != te.Mode {
+			return false, nil
+		}
+	}
+	// anything left over was added
+	return inHead == len(byPath), nil
+}
+
+// Export writes the whole staged tree, not only what changed, into dir so tools
+// can run over exactly what will be committed; submodules are left out
+func Export(repo *git.Repository, dir string) error {
+	idx, err := repo.Storer.Index()
+	if err != nil {
+		return fmt.Errorf("could not read the index: %v", err)
+	}
+
+	for _, e := range idx.Entries {
+		if e.Stage != 0 || e.Mode == filemode.Submodule {
+			continue
+		}
+		f := File{Path: e.Name, Hash: e.Hash, Mode: e.Mode}
+		contents, err := f.Contents(repo)
+		if err != nil {
+			return err
+		}
+
+		target := filepath.Join(dir, filepath.FromSlash(e.Name))
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+		if e.Mode == filemode.Symlink {
+			err = os.Symlink(string(contents), target)
+		} else {
+			perm := os.FileMode(0644)
+			if e.Mode == filemode.Executable {
+				perm = 0755
+			}
+			err = ioutil.WriteFile(target, contents, perm)
+		}
+		if err != nil {
+			return fmt.Errorf("could not export staged '%s': %v", e.Name, err)
+		}
+	}
+	return nil
+}
+
+// Contents reads the staged content of f (which may differ from the working tree)
+func (f File) Contents(repo *git.Repository) ([]byte, error) {
+	blob, err := repo.BlobObject(f.Hash)
+	if err != nil {
+		return nil, fmt.Errorf("could not read staged '%s': %v", f.Path, err)
+	}
+	r, err := blob.Reader()
+	if err != nil {
+		return nil, fmt.Errorf("could not read staged '%s': %v", f.Path, err)
+	}
+	defer r.Close()
+	return ioutil.ReadAll(r)
+}
+
+// Head reads at most the first n bytes of the file's staged contents
+func (f File) Head(repo *git.Repository, n int64) ([]byte, error) {
+	blob, err := repo.BlobObject(f.Hash)
+	if err != nil {
+		return nil, fmt.Errorf("could not read staged '%s': %v", f.Path, err)
+	}
+	r, err := blob.Reader()
+	if err != nil {
+		return nil, fmt.Errorf("could not read staged '%s': %v", f.Path, err)
+	}
+	defer r.Close()
+	return ioutil.ReadAll(io.LimitReader(r, n))
+}
+
+// headTree returns nil when the repo has no commits yet
+func headTree(repo *git.Repository) (*object.Tree, error) {
+	head, err := repo.Head()
+	if err == plumbing.ErrReferenceNotFound {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("could not resolve HEAD: %v", err)
+	}
+
+	commit, err := repo.CommitObject(head.Hash())
+	if err != nil {
+		return nil, fmt.Errorf("could not read HEAD commit: %v", err)
+	}
+	return commit.Tree()
+}
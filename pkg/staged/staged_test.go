@@ -0,0 +1,117 @@
+package staged
+
+import (
+	"github.com/go-git/go-billy/v5/memfs"
+	"github.com/go-git/go-billy/v5/util"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/format/index"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/storage/memory"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFiles(t *testing.T) {
+	r, _ := git.Init(memory.NewStorage(), memfs.New())
+	w, _ := r.Worktree()
+
+	stage := func(path, contents string) {
+		if err := util.WriteFile(w.Filesystem, path, []byte(contents), 0644); err != nil {
+			t.Fatalf("writing %s: %v", path, err)
+		}
+		if _, err := w.Add(path); err != nil {
+			t.Fatalf("adding %s: %v", path, err)
+		}
+	}
+
+	stage("unchanged.txt", "same\n")
+	stage("modified.txt", "before\n")
+
+	files, err := Files(r)
+	assert.NoError(t, err)
+	assert.Len(t, files, 2, "everything is added before the first commit")
+
+	_, err = w.Commit("root", &git.CommitOptions{
+		Author: &object.Signature{Name: "Mal Reynolds", Email: "mal@serenity.com", When: time.Now()},
+	})
+	assert.NoError(t, err)
+
+	stage("modified.txt", "after\n")
+	stage("dir/added.txt", "new\n")
+	// changed in the working tree but not staged
+	_ = util.WriteFile(w.Filesystem, "unchanged.txt", []byte("not staged\n"), 0644)
+
+	files, err = Files(r)
+	assert.NoError(t, err)
+	if assert.Len(t, files, 2) {
+		assert.Equal(t, "dir/added.txt", files[0].Path)
+		assert.True(t, files[0].Added)
+		assert.Equal(t, "modified.txt", files[1].Path)
+		assert.False(t, files[1].Added)
+
+		contents, err := files[1].Contents(r)
+		assert.NoError(t, err)
+		assert.Equal(t, "after\n", string(contents))
+	}
+
+	// a submodule's commit is not in this repo, so there is nothing to read
+	idx, _ := r.Storer.Index()
+	idx.Entries = append(idx.Entries, &index.Entry{Name: "sub", Hash: plumbing.NewHash("0123456789abcdef0123456789abcdef01234567"), Mode: filemode.Submodule})
+	assert.NoError(t, r.Storer.SetIndex(idx))
+	files, err = Files(r)
+	assert.NoError(t, err)
+	assert.Len(t, files, 2, "submodules are left out")
+}
+
+func TestNothing(t *testing.T) {
+	r, _ := git.Init(memory.NewStorage(), memfs.New())
+	w, _ := r.Worktree()
+
+	nothing := func() bool {
+		n, err := Nothing(r)
+		assert.NoError(t, err)
+		return n
+	}
+
+	assert.True(t, nothing(), "nothing in a new repo")
+	_ = util.WriteFile(w.Filesystem, "dir/a.txt", []byte("a\n"), 0644)
+	_, _ = w.Add("dir/a.txt")
+	assert.False(t, nothing(), "added before the first commit")
+
+	_, err := w.Commit("root", &git.CommitOptions{
+		Author: &object.Signature{Name: "Mal Reynolds", Email: "mal@serenity.com", When: time.Now()},
+	})
+	assert.NoError(t, err)
+	assert.True(t, nothing(), "nothing after committing")
+
+	_ = util.WriteFile(w.Filesystem, "dir/a.txt", []byte("changed but not staged\n"), 0644)
+	assert.True(t, nothing(), "working tree changes are not staged")
+
+	_, _ = w.Add("dir/a.txt")
+	assert.False(t, nothing(), "modified")
+
+	_, _ = w.Remove("dir/a.txt")
+	assert.False(t, nothing(), "deleted")
+}
+
+func TestExport(t *testing.T) {
+	r, _ := git.Init(memory.NewStorage(), memfs.New())
+	w, _ := r.Worktree()
+	_ = util.WriteFile(w.Filesystem, "dir/staged.txt", []byte("staged\n"), 0644)
+	_, _ = w.Add("dir/staged.txt")
+	_ = util.WriteFile(w.Filesystem, "dir/staged.txt", []byte("not staged\n"), 0644)
+	_ = util.WriteFile(w.Filesystem, "untracked.txt", []byte("untracked\n"), 0644)
+
+	dir := t.TempDir()
+	assert.NoError(t, Export(r, dir))
+
+	contents, err := ioutil.ReadFile(filepath.Join(dir, "dir", "staged.txt"))
+	assert.NoError(t, err)
+	assert.Equal(t, "staged\n", string(contents))
+	assert.NoFileExists(t, filepath.Join(dir, "untracked.txt"))
+}
@@ -0,0 +1,134 @@
+package staged
+
+import (
+	"github.com/go-git/go-git/v5"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Summary describes what is staged, for use in message templates
+type Summary struct {
+	FilesChanged     int
+	PrimaryPackage   string   // base name of the directory with the most staged files
+	LanguagesTouched []string // sorted
+	TestsTouched     bool
+	Scopes           []string // sorted; see ScopeMap
+	InferredType     string   // see Classify
+}
+
+// languages maps file extensions to the name used in LanguagesTouched
+var languages = map[string]string{
+	".c": "C", ".h": "C", ".cc": "C++", ".cpp": "C++", ".hpp": "C++", ".cs": "C#",
+	".css": "CSS", ".go": "Go", ".html": "HTML", ".java": "Java", ".js": "JavaScript",
+	".jsx": "JavaScript", ".json": "JSON", ".kt": "Kotlin", ".md": "Markdown", ".php": "PHP",
+	".py": "Python", ".rb": "Ruby", ".rs": "Rust", ".sh": "Shell", ".sql": "SQL",
+	".swift": "Swift", ".ts": "TypeScript", ".tsx": "TypeScript", ".yaml": "YAML", ".yml": "YAML",
+}
+
+// Summarize describes the files added or modified in the index compared to HEAD,
+// scoping them with scopes (which may be nil)
+func Summarize(repo *git.Repository, scopes ScopeMap) (Summary, error) {
+	files, err := Files(repo)
+	if err != nil {
+		return Summary{}, err
+	}
+	return summarize(files, scopes), nil
+}
+
+func summarize(files []File, scopes ScopeMap) Summary {
+	s := Summary{FilesChanged: len(files), LanguagesTouched: []string{}, Scopes: scopes.Scopes(files), InferredType: Classify(files)}
+	dirs := map[string]int{}
+	langs := map[string]bool{}
+	for _, f := range files {
+		if d := path.Dir(f.Path); d != "." {
+			dirs[d]++
+		}
+		if l, ok := languages[strings.ToLower(path.Ext(f.Path))]; ok {
+			langs[l] = true
+		}
+		if IsTest(f.Path) {
+			s.TestsTouched = true
+		}
+	}
+
+	// ties go to the first directory alphabetically so the result is stable
+	most := ""
+	for d, n := range dirs {
+		if n > dirs[most] || (n == dirs[most] && d < most) {
+			most = d
+		}
+	}
+	if most != "" {
+		s.PrimaryPackage = path.Base(most)
+	}
+
+	for l := range langs {
+		s.LanguagesTouched = append(s.LanguagesTouched, l)
+	}
+	sort.Strings(s.LanguagesTouched)
+	return s
+}
+
+// IsTest guesses from its path whether p holds tests, following the common
+// conventions of the languages above
+func IsTest(p string) bool {
+	for _, dir := range strings.Split(path.Dir(p), "/") {
+		switch dir {
+		case "test", "tests", "__tests__", "spec", "testdata":
+			return true
+		}
+	}
+	base := path.Base(p)
+	ext := path.Ext(base)
+	name := strings.TrimSuffix(base, ext)
+	return strings.HasSuffix(name, "_test") || strings.HasPrefix(name, "test_") ||
+		strings.HasSuffix(name, ".test") || strings.HasSuffix(name, ".spec") ||
+		strings.HasSuffix(name, "Test") || strings.HasSuffix(name, "Tests")
+}
+
+// Scope is the one scope of everything staged, or "" when the staged files span
+// several scopes or none
+func (s Summary) Scope() string {
+	if len(s.Scopes) == 1 {
+		return s.Scopes[0]
+	}
+	return ""
+}
+
+// Vars are the template variables describing what is staged
+func (s Summary) Vars() map[string]string {
+	return map[string]string{
+		"filesChanged":     strconv.Itoa(s.FilesChanged),
+		"primaryPackage":   s.PrimaryPackage,
+		"languagesTouched": strings.Join(s.LanguagesTouched, ","),
+		"testsTouched":     strconv.FormatBool(s.TestsTouched),
+		"scope":            s.Scope(),
+		"inferredType":     s.InferredType,
+	}
+}
+
+// Expand replaces {filesChanged}, {primaryPackage}, {languagesTouched},
+// {testsTouched}, {scope} and {inferredType} in template; a scope left empty, as when only top-level
+// files are staged, is dropped so 'feat({scope}): ' renders as 'feat: '
+func (s Summary) Expand(template string) string {
+	if !UsesVars(template) {
+		return template
+	}
+	for k, v := range s.Vars() {
+		template = strings.Replace(template, "{"+k+"}", v, -1)
+	}
+	return strings.Replace(template, "()", "", -1)
+}
+
+// UsesVars reports whether template refers to any of the staged summary
+// variables, so hooks only analyse the index when a template needs it
+func UsesVars(template string) bool {
+	for k := range (Summary{}).Vars() {
+		if strings.Contains(template, "{"+k+"}") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,91 @@
+package staged
+
+import (
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestSummarize(t *testing.T) {
+	s := summarize([]File{
+		{Path: "pkg/auth/login.go"},
+		{Path: "pkg/auth/login_test.go"},
+		{Path: "web/app.ts"},
+		{Path: "README.md"},
+	}, nil)
+	assert.Equal(t, Summary{
+		FilesChanged:     4,
+		PrimaryPackage:   "auth",
+		LanguagesTouched: []string{"Go", "Markdown", "TypeScript"},
+		TestsTouched:     true,
+		Scopes:           []string{"pkg", "web"},
+		InferredType:     "feat",
+	}, s)
+
+	assert.Equal(t, "feat(auth): 4 files", s.Expand("feat({primaryPackage}): {filesChanged} files"))
+	assert.Equal(t, "[%s]", s.Expand("[%s]"), "templates without variables are left alone")
+
+	top := summarize([]File{{Path: "Makefile"}}, nil)
+	assert.Equal(t, "", top.PrimaryPackage)
+	assert.False(t, top.TestsTouched)
+	assert.Equal(t, "chore: ", top.Expand("chore({primaryPackage}): "))
+}
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		paths []string
+		want  string
+	}{
+		{paths: []string{"README.md", "docs/setup/install.html"}, want: "docs"},
+		{paths: []string{"pkg/auth/login_test.go", "pkg/auth/testdata/user.json"}, want: "test"},
+		{paths: []string{"Makefile", "go.mod", "go.sum"}, want: "build"},
+		{paths: []string{".github/workflows/ci.yml"}, want: "ci"},
+		{paths: []string{".github/workflows/ci.yml", "Makefile"}, want: "build"},
+		{paths: []string{"pkg/auth/login.go", "README.md"}, want: "feat"},
+		{paths: []string{}, want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.paths, ","), func(t *testing.T) {
+			files := make([]File, 0, len(tt.paths))
+			for _, p := range tt.paths {
+				files = append(files, File{Path: p})
+			}
+			assert.Equal(t, tt.want, Classify(files))
+		})
+	}
+}
+
+func TestScopes(t *testing.T) {
+	m := ParseScopeMap([]string{"services/billing=billing", "services/billing/ui/=billing-ui", "web=frontend", "broken"})
+	assert.Equal(t, ScopeMap{"services/billing": "billing", "services/billing/ui": "billing-ui", "web": "frontend"}, m)
+
+	assert.Equal(t, "billing", m.ScopeOf("services/billing/invoice.go"))
+	assert.Equal(t, "billing-ui", m.ScopeOf("services/billing/ui/form.ts"), "the longest prefix wins")
+	assert.Equal(t, "services", m.ScopeOf("services/shipping/label.go"), "unmapped paths use the top-level directory")
+	assert.Equal(t, "frontend", m.ScopeOf("web/app.ts"))
+	assert.Equal(t, "", m.ScopeOf("go.mod"))
+	assert.Equal(t, "webhooks", m.ScopeOf("webhooks/handler.go"), "prefixes match whole directories")
+
+	one := summarize([]File{{Path: "services/billing/a.go"}, {Path: "services/billing/b.go"}, {Path: "go.mod"}}, m)
+	assert.Equal(t, "feat(billing): ", one.Expand("feat({scope}): "))
+
+	two := summarize([]File{{Path: "services/billing/a.go"}, {Path: "web/app.ts"}}, m)
+	assert.Equal(t, []string{"billing", "frontend"}, two.Scopes)
+	assert.Equal(t, "feat: ", two.Expand("feat({scope}): "), "no single scope to fill in")
+}
+
+func TestIsTest(t *testing.T) {
+	for p, want := range map[string]bool{
+		"pkg/auth/login_test.go": true,
+		"tests/conftest.py":      true,
+		"src/test_login.py":      true,
+		"web/app.spec.ts":        true,
+		"web/__tests__/app.js":   true,
+		"src/LoginTest.java":     true,
+		"pkg/auth/login.go":      false,
+		"docs/testing-guide.md":  false,
+		"src/contest/contest.py": false,
+	} {
+		assert.Equal(t, want, IsTest(p), p)
+	}
+}
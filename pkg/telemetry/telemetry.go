@@ -0,0 +1,179 @@
+package telemetry
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/davidalpert/go-githooks/pkg/fileio"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+/*
+ * Telemetry is strictly opt-in and never leaves the machine on its own: when enabled
+ * with
+ *
+ * [go-githooks "telemetry"]
+ *     enabled = true
+ *
+ * each hook adds the name, duration and outcome of every transformer or rule check it
+ * runs to a local summary file. Nothing about the repo, branch or message is recorded. Teams
+ * can choose to share the summary written by 'go-githooks telemetry export'.
+ */
+
+// Stats aggregates every run of a single transformer
+type Stats struct {
+	Runs     int   `json:"runs"`
+	Failures int   `json:"failures"`
+	TotalMs  int64 `json:"totalMs"`
+	MaxMs    int64 `json:"maxMs"`
+}
+
+// Summary holds the aggregated stats per hook, per transformer
+type Summary struct {
+	Since time.Time                    `json:"since"`
+	Hooks map[string]map[string]*Stats `json:"hooks"`
+}
+
+// StorePath is where the local summary is kept; override with GIT_HOOKS_TELEMETRY_FILE
+func StorePath() string {
+	if f := os.Getenv("GIT_HOOKS_TELEMETRY_FILE"); f != "" {
+		return f
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		dir = "."
+	}
+	return filepath.Join(dir, "go-githooks", "telemetry.json")
+}
+
+// Load reads a summary; a missing file is an empty summary
+func Load(path string) (*Summary, error) {
+	s := &Summary{Since: time.Now().UTC(), Hooks: map[string]map[string]*Stats{}}
+	data, err := fileio.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("could not read '%s': %v", path, err)
+	}
+
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("could not parse '%s': %v", path, err)
+	}
+	if s.Hooks == nil {
+		s.Hooks = map[string]map[string]*Stats{}
+	}
+	return s, nil
+}
+
+func (s *Summary) Write(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("could not create '%s': %v", filepath.Dir(path), err)
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := fileio.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("could not write '%s': %v", path, err)
+	}
+	return nil
+}
+
+// Lines renders the summary as one line per hook and transformer
+func (s *Summary) Lines() []string {
+	lines := make([]string, 0)
+	for hook, transformers := range s.Hooks {
+		for name, st := range transformers {
+			avg := int64(0)
+			if st.Runs > 0 {
+				avg = st.TotalMs / int64(st.Runs)
+			}
+			lines = append(lines, fmt.Sprintf("%s %s: %d runs, %d failures, avg %dms, max %dms", hook, name, st.Runs, st.Failures, avg, st.MaxMs))
+		}
+	}
+	sort.Strings(lines)
+	return lines
+}
+
+func (s *Summary) add(hook, name string, st *Stats) {
+	if s.Hooks[hook] == nil {
+		s.Hooks[hook] = map[string]*Stats{}
+	}
+	agg := s.Hooks[hook][name]
+	if agg == nil {
+		agg = &Stats{}
+		s.Hooks[hook][name] = agg
+	}
+	agg.Runs += st.Runs
+	agg.Failures += st.Failures
+	agg.TotalMs += st.TotalMs
+	if st.MaxMs > agg.MaxMs {
+		agg.MaxMs = st.MaxMs
+	}
+}
+
+// Recorder collects stats during a single hook run; a nil Recorder records nothing
+type Recorder struct {
+	hook    string
+	path    string
+	pending map[string]*Stats
+}
+
+// NewRecorder returns nil unless telemetry has been enabled
+func NewRecorder(hook string, enabled bool) *Recorder {
+	if !enabled {
+		return nil
+	}
+	return &Recorder{
+		hook:    hook,
+		path:    StorePath(),
+		pending: map[string]*Stats{},
+	}
+}
+
+func (r *Recorder) Record(name string, d time.Duration, err error) {
+	r.record(name, d, err != nil)
+}
+
+// RecordCheck records a rule check, which counts as a failure when it found violations
+func (r *Recorder) RecordCheck(name string, d time.Duration, violations int) {
+	r.record(name, d, violations > 0)
+}
+
+func (r *Recorder) record(name string, d time.Duration, failed bool) {
+	if r == nil {
+		return
+	}
+	st := r.pending[name]
+	if st == nil {
+		st = &Stats{}
+		r.pending[name] = st
+	}
+	ms := d.Milliseconds()
+	st.Runs++
+	st.TotalMs += ms
+	if ms > st.MaxMs {
+		st.MaxMs = ms
+	}
+	if failed {
+		st.Failures++
+	}
+}
+
+// Flush merges what was recorded into the local summary
+func (r *Recorder) Flush() error {
+	if r == nil || len(r.pending) == 0 {
+		return nil
+	}
+	s, err := Load(r.path)
+	if err != nil {
+		return err
+	}
+	for name, st := range r.pending {
+		s.add(r.hook, name, st)
+	}
+	r.pending = map[string]*Stats{}
+	return s.Write(r.path)
+}
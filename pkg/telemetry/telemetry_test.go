@@ -0,0 +1,69 @@
+package telemetry
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRecorderDisabled(t *testing.T) {
+	r := NewRecorder("prepare-commit-msg", false)
+	assert.Nil(t, r)
+
+	r.Record("branch-prefix", time.Millisecond, nil)
+	assert.NoError(t, r.Flush())
+}
+
+func TestRecorderFlushAggregates(t *testing.T) {
+	dir, err := ioutil.TempDir("", "telemetry")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "telemetry.json")
+	os.Setenv("GIT_HOOKS_TELEMETRY_FILE", path)
+	defer os.Unsetenv("GIT_HOOKS_TELEMETRY_FILE")
+
+	for i := 0; i < 2; i++ {
+		r := NewRecorder("prepare-commit-msg", true)
+		r.Record("branch-prefix", 3*time.Millisecond, nil)
+		r.Record("coauthors", 5*time.Millisecond, fmt.Errorf("git mob-print failed"))
+		assert.NoError(t, r.Flush())
+	}
+
+	s, err := Load(path)
+	assert.NoError(t, err)
+	assert.Equal(t, &Stats{Runs: 2, Failures: 0, TotalMs: 6, MaxMs: 3}, s.Hooks["prepare-commit-msg"]["branch-prefix"])
+	assert.Equal(t, &Stats{Runs: 2, Failures: 2, TotalMs: 10, MaxMs: 5}, s.Hooks["prepare-commit-msg"]["coauthors"])
+	assert.Equal(t, []string{
+		"prepare-commit-msg branch-prefix: 2 runs, 0 failures, avg 3ms, max 3ms",
+		"prepare-commit-msg coauthors: 2 runs, 2 failures, avg 5ms, max 5ms",
+	}, s.Lines())
+}
+
+func TestRecorderCountsChecksWithViolationsAsFailures(t *testing.T) {
+	dir, err := ioutil.TempDir("", "telemetry")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "telemetry.json")
+	os.Setenv("GIT_HOOKS_TELEMETRY_FILE", path)
+	defer os.Unsetenv("GIT_HOOKS_TELEMETRY_FILE")
+
+	r := NewRecorder("commit-msg", true)
+	r.RecordCheck("empty-message", time.Millisecond, 0)
+	r.RecordCheck("conventional-header", time.Millisecond, 2)
+	assert.NoError(t, r.Flush())
+
+	s, err := Load(path)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, s.Hooks["commit-msg"]["empty-message"].Failures)
+	assert.Equal(t, 1, s.Hooks["commit-msg"]["conventional-header"].Failures)
+}
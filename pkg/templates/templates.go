@@ -0,0 +1,424 @@
+package templates
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/davidalpert/go-githooks/pkg/cienv"
+	"github.com/davidalpert/go-githooks/pkg/fileio"
+	"github.com/davidalpert/go-githooks/pkg/remoteconfig"
+	config2 "github.com/go-git/go-git/v5/plumbing/format/config"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+/*
+ * Templates are shared bundles of go-githooks options, e.g. a team's prefix and body
+ * templates and rule severities, imported into the user's config with
+ *
+ *   go-githooks templates add <url|name>
+ *
+ * A template is a file in the remote config layout, which may describe itself in a
+ * [template] table:
+ *
+ *     [template]
+ *     name = "angular"
+ *     description = "Angular commit conventions"
+ *
+ *     [prepare-commit-message]
+ *     subjectTemplate = "feat({scope}): "
+ *
+ *     [rules]
+ *     conventional-header = "error"
+ *
+ * It can be fetched from a url, read from a git repo (githooks-template.toml at its
+ * root, or the file after a #, e.g. https://example.com/conventions.git#angular.toml),
+ * or named in a registry index (go-githooks.templateRegistry), a file of tables:
+ *
+ *     [angular]
+ *     url = "https://example.com/conventions.git#angular.toml"
+ *     description = "Angular commit conventions"
+ *
+ * Imported templates are kept in Dir() and sit below the user's global config, so it
+ * can still override any option; a later import wins over an earlier one.
+ */
+
+// DefaultFiles are the files read from the root of a git repo, the first which exists
+var DefaultFiles = []string{"githooks-template.toml", "githooks-template.yml", "githooks-template.yaml"}
+
+var validName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// Template is an imported template
+type Template struct {
+	Name        string    `json:"name"`
+	Description string    `json:"description,omitempty"`
+	Source      string    `json:"source"`
+	File        string    `json:"file"` // its copy in Dir()
+	Added       time.Time `json:"added"`
+}
+
+// Entry is a template named by a registry index
+type Entry struct {
+	Name        string
+	URL         string
+	Description string
+}
+
+// Dir is where imported templates are kept; override with GIT_HOOKS_TEMPLATES_DIR
+func Dir() string {
+	if d := os.Getenv("GIT_HOOKS_TEMPLATES_DIR"); d != "" {
+		return d
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		dir = "."
+	}
+	return filepath.Join(dir, "go-githooks", "templates")
+}
+
+func indexPath() string {
+	return filepath.Join(Dir(), "index.json")
+}
+
+// List returns the imported templates in the order they were added
+func List() ([]Template, error) {
+	list := make([]Template, 0)
+	data, err := fileio.ReadFile(indexPath())
+	if os.IsNotExist(err) {
+		return list, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("could not read '%s': %v", indexPath(), err)
+	}
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("could not parse '%s': %v", indexPath(), err)
+	}
+	return list, nil
+}
+
+func writeList(list []Template) error {
+	data, err := json.MarshalIndent(list, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := fileio.WriteFile(indexPath(), append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("could not write '%s': %v", indexPath(), err)
+	}
+	return nil
+}
+
+// Get returns the imported template with the given name
+func Get(name string) (Template, bool, error) {
+	list, err := List()
+	if err != nil {
+		return Template{}, false, err
+	}
+	for _, t := range list {
+		if t.Name == name {
+			return t, true, nil
+		}
+	}
+	return Template{}, false, nil
+}
+
+// Parse reads a template file, named for its format, into its description and options
+func Parse(name string, data []byte) (Template, *config2.Config, error) {
+	values, err := remoteconfig.Decode(name, data)
+	if err != nil {
+		return Template{}, nil, err
+	}
+	var t Template
+	if meta, ok := values["template"].(map[string]interface{}); ok {
+		t.Name, _ = meta["name"].(string)
+		t.Description, _ = meta["description"].(string)
+		delete(values, "template")
+	}
+	options, err := remoteconfig.FromMap(values)
+	if err != nil {
+		return Template{}, nil, err
+	}
+	return t, options, nil
+}
+
+// Read fetches the template file at source, a url, a git repo (with an optional
+// #file) or a local file, returning the name of the file read and its contents
+func Read(client *http.Client, source string) (string, []byte, error) {
+	repo, file := source, ""
+	if i := strings.LastIndex(source, "#"); i >= 0 {
+		repo, file = source[:i], source[i+1:]
+	}
+	switch {
+	case isGitRepo(repo):
+		return readFromRepo(repo, file)
+	case strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://"):
+		data, err := fetch(client, source)
+		return source, data, err
+	}
+	data, err := fileio.ReadFile(source)
+	if err != nil {
+		return "", nil, fmt.Errorf("could not read '%s': %v", source, err)
+	}
+	return source, data, nil
+}
+
+// isGitRepo reports whether source names a git repo rather than a file
+func isGitRepo(source string) bool {
+	if strings.HasSuffix(source, ".git") || strings.HasPrefix(source, "git@") ||
+		strings.HasPrefix(source, "git://") || strings.HasPrefix(source, "ssh://") {
+		return true
+	}
+	info, err := os.Stat(source)
+	return err == nil && info.IsDir()
+}
+
+func readFromRepo(repo, file string) (string, []byte, error) {
+	tmp, err := ioutil.TempDir("", "go-githooks-template-")
+	if err != nil {
+		return "", nil, err
+	}
+	defer os.RemoveAll(tmp)
+
+	out, err := exec.Command("git", "clone", "--quiet", "--depth", "1", repo, tmp).CombinedOutput()
+	if err != nil {
+		return "", nil, fmt.Errorf("could not clone '%s': %v: %s", repo, err, strings.TrimSpace(string(out)))
+	}
+	candidates := DefaultFiles
+	if file != "" {
+		candidates = []string{file}
+	}
+	for _, f := range candidates {
+		data, err := fileio.ReadFile(filepath.Join(tmp, filepath.FromSlash(f)))
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return "", nil, fmt.Errorf("could not read '%s' in '%s': %v", f, repo, err)
+		}
+		return f, data, nil
+	}
+	return "", nil, fmt.Errorf("'%s' has no %s", repo, strings.Join(candidates, " or "))
+}
+
+func fetch(client *http.Client, url string) ([]byte, error) {
+	if client == nil {
+		client = &http.Client{Timeout: cienv.Timeout(10 * time.Second)}
+	}
+	res, err := client.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch '%s': %v", url, err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("could not fetch '%s': %s", url, res.Status)
+	}
+	data, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch '%s': %v", url, err)
+	}
+	return data, nil
+}
+
+// Registry reads the registry index at url, a url or local file, sorted by name
+func Registry(client *http.Client, url string) ([]Entry, error) {
+	if url == "" {
+		return nil, fmt.Errorf("no template registry is configured; set go-githooks.templateRegistry")
+	}
+	name, data, err := Read(client, url)
+	if err != nil {
+		return nil, err
+	}
+	values, err := remoteconfig.Decode(name, data)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse the registry '%s': %v", url, err)
+	}
+	entries := make([]Entry, 0, len(values))
+	for n, v := range values {
+		table, ok := v.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		e := Entry{Name: n}
+		e.URL, _ = table["url"].(string)
+		e.Description, _ = table["description"].(string)
+		if e.URL != "" {
+			entries = append(entries, e)
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name < entries[j].Name })
+	return entries, nil
+}
+
+// IsName reports whether source is a bare registry name rather than a url or path
+func IsName(source string) bool {
+	return validName.MatchString(source) && !strings.ContainsAny(source, "/\\:#") &&
+		path.Ext(source) == "" && !isGitRepo(source)
+}
+
+// Resolve fetches the template source names: a registry entry when it is a bare
+// name, otherwise a url or path; the name defaults to the template's own, the
+// registry's, or the last element of the source
+func Resolve(client *http.Client, source, registry string) (Template, []byte, error) {
+	t := Template{Source: source}
+	if IsName(source) {
+		entries, err := Registry(client, registry)
+		if err != nil {
+			return t, nil, err
+		}
+		found := false
+		for _, e := range entries {
+			if e.Name == source {
+				t = Template{Name: e.Name, Description: e.Description, Source: e.URL}
+				found = true
+			}
+		}
+		if !found {
+			return t, nil, fmt.Errorf("the registry '%s' has no template '%s'", registry, source)
+		}
+	}
+
+	// a local path is kept absolute, to show where it came from wherever it is listed
+	if repo := strings.SplitN(t.Source, "#", 2)[0]; !strings.Contains(repo, "://") && !strings.HasPrefix(repo, "git@") {
+		if abs, err := filepath.Abs(repo); err == nil {
+			t.Source = abs + strings.TrimPrefix(t.Source, repo)
+		}
+	}
+	file, data, err := Read(client, t.Source)
+	if err != nil {
+		return t, nil, err
+	}
+	meta, _, err := Parse(file, data)
+	if err != nil {
+		return t, nil, fmt.Errorf("could not parse '%s': %v", t.Source, err)
+	}
+	if meta.Name != "" {
+		t.Name = meta.Name
+	}
+	if meta.Description != "" {
+		t.Description = meta.Description
+	}
+	if t.Name == "" {
+		t.Name = nameOf(t.Source)
+	}
+	t.File = t.Name + fileExt(file)
+	return t, data, nil
+}
+
+// nameOf derives a name from the last element of a source, e.g. conventions from
+// https://example.com/conventions.git
+func nameOf(source string) string {
+	repo, file := source, ""
+	if i := strings.LastIndex(source, "#"); i >= 0 {
+		repo, file = source[:i], source[i+1:]
+	}
+	base := path.Base(filepath.ToSlash(repo))
+	if file != "" {
+		base = path.Base(file)
+	}
+	base = strings.TrimSuffix(base, path.Ext(base))
+	return strings.TrimPrefix(base, "githooks-")
+}
+
+func fileExt(name string) string {
+	if ext := strings.ToLower(path.Ext(name)); ext == ".yml" || ext == ".yaml" {
+		return ext
+	}
+	return ".toml"
+}
+
+// Install keeps a copy of the template's file and adds it to the list, replacing an
+// imported template of the same name
+func Install(t Template, data []byte) error {
+	if !validName.MatchString(t.Name) {
+		return fmt.Errorf("invalid template name '%s': use letters, digits, '.', '_' and '-'", t.Name)
+	}
+	t.File = t.Name + fileExt(t.File)
+	if _, _, err := Parse(t.File, data); err != nil {
+		return fmt.Errorf("could not parse '%s': %v", t.Source, err)
+	}
+	list, err := List()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(Dir(), 0755); err != nil {
+		return fmt.Errorf("could not create '%s': %v", Dir(), err)
+	}
+	kept := make([]Template, 0, len(list)+1)
+	for _, existing := range list {
+		if existing.Name == t.Name {
+			if existing.File != t.File {
+				os.Remove(filepath.Join(Dir(), existing.File))
+			}
+			continue
+		}
+		kept = append(kept, existing)
+	}
+	if err := fileio.WriteFile(filepath.Join(Dir(), t.File), data, 0644); err != nil {
+		return fmt.Errorf("could not write '%s': %v", filepath.Join(Dir(), t.File), err)
+	}
+	if t.Added.IsZero() {
+		t.Added = time.Now().UTC()
+	}
+	return writeList(append(kept, t))
+}
+
+// Remove deletes an imported template
+func Remove(name string) error {
+	list, err := List()
+	if err != nil {
+		return err
+	}
+	kept := make([]Template, 0, len(list))
+	var removed *Template
+	for i, t := range list {
+		if t.Name == name {
+			removed = &list[i]
+			continue
+		}
+		kept = append(kept, t)
+	}
+	if removed == nil {
+		return fmt.Errorf("no template '%s' is installed", name)
+	}
+	if err := os.Remove(filepath.Join(Dir(), removed.File)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("could not remove '%s': %v", removed.File, err)
+	}
+	return writeList(kept)
+}
+
+// Options reads the options of an imported template
+func Options(t Template) (*config2.Config, error) {
+	data, err := fileio.ReadFile(filepath.Join(Dir(), t.File))
+	if err != nil {
+		return nil, fmt.Errorf("could not read template '%s': %v", t.Name, err)
+	}
+	_, options, err := Parse(t.File, data)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse template '%s': %v", t.Name, err)
+	}
+	return options, nil
+}
+
+// Layers returns the options of each imported template, earliest first, to merge
+// below the user's config; templates which cannot be read are reported and skipped
+func Layers() []*config2.Config {
+	list, err := List()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "go-githooks: ignoring templates: %v\n", err)
+		return nil
+	}
+	layers := make([]*config2.Config, 0, len(list))
+	for _, t := range list {
+		options, err := Options(t)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "go-githooks: ignoring %v\n", err)
+			continue
+		}
+		layers = append(layers, options)
+	}
+	return layers
+}
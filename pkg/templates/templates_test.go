@@ -0,0 +1,161 @@
+package templates
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+const angular = `
+[template]
+name = "angular"
+description = "Angular commit conventions"
+
+[prepare-commit-message]
+subjectTemplate = "feat({scope}): "
+
+[rules]
+conventional-header = "error"
+`
+
+func useDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "templates")
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Setenv("GIT_HOOKS_TEMPLATES_DIR", dir)
+	t.Cleanup(func() {
+		os.Unsetenv("GIT_HOOKS_TEMPLATES_DIR")
+		os.RemoveAll(dir)
+	})
+	return dir
+}
+
+func TestParse(t *testing.T) {
+	meta, options, err := Parse("angular.toml", []byte(angular))
+	assert.NoError(t, err)
+	assert.Equal(t, "angular", meta.Name)
+	assert.Equal(t, "Angular commit conventions", meta.Description)
+	s := options.Section("go-githooks")
+	assert.False(t, s.HasSubsection("template"))
+	assert.Equal(t, "feat({scope}): ", s.Subsection("prepare-commit-message").Option("subjectTemplate"))
+	assert.Equal(t, "error", s.Subsection("rules").Option("conventional-header"))
+}
+
+func TestResolve(t *testing.T) {
+	dir := useDir(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/index.toml":
+			_, _ = w.Write([]byte("[angular]\nurl = \"" + "http://" + r.Host + "/angular.toml\"\ndescription = \"from the registry\"\n\n[dco]\nurl = \"http://" + r.Host + "/dco.yml\"\n"))
+		case "/angular.toml":
+			_, _ = w.Write([]byte(angular))
+		case "/dco.yml":
+			_, _ = w.Write([]byte("rules:\n  dco-signoff: error\n"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	registry := server.URL + "/index.toml"
+
+	entries, err := Registry(nil, registry)
+	assert.NoError(t, err)
+	if assert.Len(t, entries, 2) {
+		assert.Equal(t, "angular", entries[0].Name)
+		assert.Equal(t, "dco", entries[1].Name)
+	}
+
+	tmpl, data, err := Resolve(nil, "angular", registry)
+	assert.NoError(t, err)
+	assert.Equal(t, "angular", tmpl.Name)
+	assert.Equal(t, "Angular commit conventions", tmpl.Description, "the template's own description wins")
+	assert.Equal(t, server.URL+"/angular.toml", tmpl.Source)
+
+	tmpl, _, err = Resolve(nil, "dco", registry)
+	assert.NoError(t, err)
+	assert.Equal(t, "dco.yml", tmpl.File)
+
+	_, _, err = Resolve(nil, "nope", registry)
+	assert.Error(t, err)
+	_, _, err = Resolve(nil, "angular", "")
+	assert.Error(t, err, "a name needs a registry")
+
+	tmpl, _, err = Resolve(nil, server.URL+"/dco.yml", "")
+	assert.NoError(t, err)
+	assert.Equal(t, "dco", tmpl.Name, "named for the url without a [template] name")
+
+	tmpl, data, err = Resolve(nil, "angular", registry)
+	assert.NoError(t, err)
+	assert.NoError(t, Install(tmpl, data))
+	assert.FileExists(t, filepath.Join(dir, "angular.toml"))
+}
+
+func TestResolveFromRepo(t *testing.T) {
+	useDir(t)
+	repo, err := ioutil.TempDir("", "template-repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(repo)
+	_ = ioutil.WriteFile(filepath.Join(repo, "githooks-template.toml"), []byte(angular), 0644)
+	_ = ioutil.WriteFile(filepath.Join(repo, "mob.toml"), []byte("[pairing]\nproviders = \"file\"\n"), 0644)
+	for _, args := range [][]string{{"init", "-q"}, {"add", "."}, {"-c", "user.name=t", "-c", "user.email=t@example.com", "commit", "-qm", "templates"}} {
+		if out, err := exec.Command("git", append([]string{"-C", repo}, args...)...).CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v: %s", args, err, out)
+		}
+	}
+
+	tmpl, _, err := Resolve(nil, repo, "")
+	assert.NoError(t, err)
+	assert.Equal(t, "angular", tmpl.Name)
+
+	tmpl, data, err := Resolve(nil, repo+"#mob.toml", "")
+	assert.NoError(t, err)
+	assert.Equal(t, "mob", tmpl.Name)
+	_, options, _ := Parse(tmpl.File, data)
+	assert.Equal(t, "file", options.Section("go-githooks").Subsection("pairing").Option("providers"))
+
+	_, _, err = Resolve(nil, repo+"#missing.toml", "")
+	assert.Error(t, err)
+}
+
+func TestInstallAndRemove(t *testing.T) {
+	dir := useDir(t)
+	assert.Empty(t, Layers())
+
+	assert.NoError(t, Install(Template{Name: "angular", Source: "a", File: "angular.toml"}, []byte(angular)))
+	assert.NoError(t, Install(Template{Name: "strict", Source: "b", File: "strict.yml"}, []byte("rules:\n  conventional-header: warning\n")))
+	assert.Error(t, Install(Template{Name: "../escape", Source: "c"}, []byte(angular)))
+	assert.Error(t, Install(Template{Name: "broken", Source: "d"}, []byte("[a.b]\nc = 1\n")))
+
+	list, err := List()
+	assert.NoError(t, err)
+	if assert.Len(t, list, 2) {
+		assert.Equal(t, "angular", list[0].Name)
+		assert.Equal(t, "strict", list[1].Name)
+	}
+	layers := Layers()
+	if assert.Len(t, layers, 2) {
+		assert.Equal(t, "warning", layers[1].Section("go-githooks").Subsection("rules").Option("conventional-header"))
+	}
+
+	// replacing keeps a single copy, in its new format
+	assert.NoError(t, Install(Template{Name: "angular", Source: "e", File: "angular.yml"}, []byte("rules:\n  empty-message: error\n")))
+	list, _ = List()
+	assert.Len(t, list, 2)
+	assert.NoFileExists(t, filepath.Join(dir, "angular.toml"))
+
+	assert.NoError(t, Remove("angular"))
+	assert.NoFileExists(t, filepath.Join(dir, "angular.yml"))
+	assert.Error(t, Remove("angular"))
+	list, _ = List()
+	if assert.Len(t, list, 1) {
+		assert.Equal(t, "strict", list[0].Name)
+	}
+}
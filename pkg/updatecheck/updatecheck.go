@@ -0,0 +1,258 @@
+package updatecheck
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/davidalpert/go-githooks/pkg/cienv"
+	"github.com/davidalpert/go-githooks/pkg/fileio"
+	"github.com/davidalpert/go-githooks/pkg/gitconfig"
+	"github.com/go-git/go-git/v5"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/*
+ * Hooks look for a newer release at most once a day, in a process of their own so
+ * that a commit never waits on the network, and never print about it: the next
+ * go-githooks command run by hand shows a one-line notice instead. Turn it off with
+ *
+ * [go-githooks]
+ *     updateCheck = false
+ *
+ * It is also skipped while GIT_HOOKS_OFFLINE is set, and in CI.
+ */
+
+// ReleasesURL is where the latest release is looked up
+const ReleasesURL = "https://api.github.com/repos/davidalpert/go-githooks/releases/latest"
+
+// Interval is how long to wait between checks
+const Interval = 24 * time.Hour
+
+// RunEnv is set on the hook started in the background to do the check
+const RunEnv = "GIT_HOOKS_UPDATE_CHECK"
+
+// State is what the last check found
+type State struct {
+	Checked  time.Time `json:"checked"`
+	Latest   string    `json:"latest,omitempty"`
+	URL      string    `json:"url,omitempty"`
+	Notified string    `json:"notified,omitempty"` // the release the notice was last shown for
+}
+
+// StatePath is where the state is kept; override with GIT_HOOKS_UPDATE_CHECK_FILE
+func StatePath() string {
+	if f := os.Getenv("GIT_HOOKS_UPDATE_CHECK_FILE"); f != "" {
+		return f
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		dir = "."
+	}
+	return filepath.Join(dir, "go-githooks", "update-check.json")
+}
+
+// Load reads the state; a missing file has never been checked
+func Load(path string) (State, error) {
+	var s State
+	data, err := fileio.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	} else if err != nil {
+		return s, fmt.Errorf("could not read '%s': %v", path, err)
+	}
+	if err := json.Unmarshal(data, &s); err != nil {
+		return s, fmt.Errorf("could not parse '%s': %v", path, err)
+	}
+	return s, nil
+}
+
+func (s State) Write(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("could not create '%s': %v", filepath.Dir(path), err)
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := fileio.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("could not write '%s': %v", path, err)
+	}
+	return nil
+}
+
+// Due reports whether the last check was at least Interval before now
+func (s State) Due(now time.Time) bool {
+	return now.Sub(s.Checked) >= Interval
+}
+
+// Notice is the line to show when the latest release is newer than current and has
+// not been shown yet, or ""
+func (s State) Notice(current string) string {
+	if s.Latest == "" || s.Latest == s.Notified || !Newer(s.Latest, current) {
+		return ""
+	}
+	url := s.URL
+	if url == "" {
+		url = "https://github.com/davidalpert/go-githooks/releases"
+	}
+	return fmt.Sprintf("go-githooks %s is available (you have %s): %s", s.Latest, current, url)
+}
+
+// Newer reports whether version a is above b; versions which are not dotted
+// numbers, like the n/a of a local build, are never newer nor older
+func Newer(a, b string) bool {
+	pa, okA := parseVersion(a)
+	pb, okB := parseVersion(b)
+	if !okA || !okB {
+		return false
+	}
+	for i := 0; i < len(pa) || i < len(pb); i++ {
+		var x, y int
+		if i < len(pa) {
+			x = pa[i]
+		}
+		if i < len(pb) {
+			y = pb[i]
+		}
+		if x != y {
+			return x > y
+		}
+	}
+	return false
+}
+
+// parseVersion reads v1.2.3, ignoring a pre-release or build suffix
+func parseVersion(v string) ([]int, bool) {
+	v = strings.TrimPrefix(strings.TrimSpace(v), "v")
+	if i := strings.IndexAny(v, "-+"); i >= 0 {
+		v = v[:i]
+	}
+	parts := strings.Split(v, ".")
+	nums := make([]int, 0, len(parts))
+	for _, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil {
+			return nil, false
+		}
+		nums = append(nums, n)
+	}
+	return nums, true
+}
+
+// Fetch asks url, a GitHub latest release endpoint, for the latest release's tag
+// and page
+func Fetch(client *http.Client, url string) (tag, page string, err error) {
+	if client == nil {
+		client = &http.Client{Timeout: 5 * time.Second}
+	}
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return "", "", fmt.Errorf("could not fetch '%s': %v", url, err)
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	res, err := client.Do(req)
+	if err != nil {
+		return "", "", fmt.Errorf("could not fetch '%s': %v", url, err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return "", "", fmt.Errorf("could not fetch '%s': %s", url, res.Status)
+	}
+	data, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return "", "", fmt.Errorf("could not fetch '%s': %v", url, err)
+	}
+	var release struct {
+		TagName string `json:"tag_name"`
+		HTMLURL string `json:"html_url"`
+	}
+	if err := json.Unmarshal(data, &release); err != nil {
+		return "", "", fmt.Errorf("could not parse the release from '%s': %v", url, err)
+	}
+	return release.TagName, release.HTMLURL, nil
+}
+
+// Enabled reports whether go-githooks.updateCheck allows checking, GIT_HOOKS_OFFLINE
+// is not set, and hooks are not running in CI
+func Enabled(repo *git.Repository) bool {
+	if os.Getenv("GIT_HOOKS_OFFLINE") != "" || cienv.Detected() {
+		return false
+	}
+	cfg, err := gitconfig.Load(repo)
+	if err != nil {
+		return false
+	}
+	return gitconfig.GetBool(cfg, "go-githooks", "", "updateCheck", true)
+}
+
+// Start is called by hooks: when a check is due it starts this hook again with
+// RunEnv set, without waiting for it, and prints nothing either way
+func Start(repo *git.Repository, version string) {
+	if _, ok := parseVersion(version); !ok || os.Getenv(RunEnv) != "" {
+		return
+	}
+	path := StatePath()
+	s, err := Load(path)
+	if err != nil || !s.Due(time.Now()) || !Enabled(repo) {
+		return
+	}
+	// claimed before starting, so that the other hooks of the same commit skip it
+	s.Checked = time.Now().UTC()
+	if s.Write(path) != nil {
+		return
+	}
+	self, err := os.Executable()
+	if err != nil {
+		return
+	}
+	cmd := exec.Command(self)
+	cmd.Env = append(os.Environ(), RunEnv+"=1")
+	cmd.Start()
+}
+
+// Requested reports whether this process was started by Start, in which case the
+// hook calls Run instead of doing its work
+func Requested() bool {
+	return os.Getenv(RunEnv) != ""
+}
+
+// Run looks up the latest release and records it
+func Run() error {
+	tag, page, err := Fetch(nil, ReleasesURL)
+	if err != nil {
+		return err
+	}
+	path := StatePath()
+	s, err := Load(path)
+	if err != nil {
+		return err
+	}
+	s.Checked, s.Latest, s.URL = time.Now().UTC(), tag, page
+	return s.Write(path)
+}
+
+// ShowNotice prints the notice for a newer release to stderr once per release; for
+// the commands run by hand, never for hooks
+func ShowNotice(version string) {
+	if os.Getenv("GIT_HOOKS_NONINTERACTIVE") != "" || cienv.Detected() {
+		return
+	}
+	path := StatePath()
+	s, err := Load(path)
+	if err != nil {
+		return
+	}
+	notice := s.Notice(version)
+	if notice == "" {
+		return
+	}
+	fmt.Fprintln(os.Stderr, notice)
+	s.Notified = s.Latest
+	s.Write(path)
+}
@@ -0,0 +1,54 @@
+package updatecheck
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestState(t *testing.T) {
+	now := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+	assert.True(t, State{}.Due(now))
+	assert.False(t, State{Checked: now.Add(-23 * time.Hour)}.Due(now))
+	assert.True(t, State{Checked: now.Add(-25 * time.Hour)}.Due(now))
+
+	assert.True(t, Newer("v1.10.0", "1.9.3"))
+	assert.True(t, Newer("v2", "v1.9"))
+	assert.False(t, Newer("v1.2.0", "v1.2"))
+	assert.False(t, Newer("v1.2.0-rc1", "v1.2.0"))
+	assert.False(t, Newer("v1.2.0", "n/a"))
+
+	s := State{Latest: "v1.3.0", URL: "https://github.com/davidalpert/go-githooks/releases/tag/v1.3.0"}
+	assert.Equal(t, "go-githooks v1.3.0 is available (you have v1.2.0): https://github.com/davidalpert/go-githooks/releases/tag/v1.3.0", s.Notice("v1.2.0"))
+	assert.Empty(t, s.Notice("v1.3.0"))
+	s.Notified = "v1.3.0"
+	assert.Empty(t, s.Notice("v1.2.0"))
+
+	path := filepath.Join(t.TempDir(), "update-check.json")
+	loaded, err := Load(path)
+	assert.NoError(t, err)
+	assert.True(t, loaded.Checked.IsZero())
+	s.Checked = now
+	assert.NoError(t, s.Write(path))
+	loaded, err = Load(path)
+	assert.NoError(t, err)
+	assert.Equal(t, s, loaded)
+}
+
+func TestFetch(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"tag_name": "v1.3.0", "html_url": "https://github.com/davidalpert/go-githooks/releases/tag/v1.3.0"}`))
+	}))
+	defer srv.Close()
+
+	tag, page, err := Fetch(srv.Client(), srv.URL)
+	assert.NoError(t, err)
+	assert.Equal(t, "v1.3.0", tag)
+	assert.Equal(t, "https://github.com/davidalpert/go-githooks/releases/tag/v1.3.0", page)
+
+	_, _, err = Fetch(srv.Client(), srv.URL+"/missing\x7f")
+	assert.Error(t, err)
+}
@@ -0,0 +1,142 @@
+package vcshost
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/davidalpert/go-githooks/pkg/cienv"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// PullState is what became of the pull (or merge) request opened from a branch
+type PullState string
+
+const (
+	PullOpen   PullState = "open"
+	PullMerged PullState = "merged"
+	PullClosed PullState = "closed" // without being merged
+)
+
+// Pull is the newest pull request opened from a branch
+type Pull struct {
+	Number int
+	State  PullState
+	URL    string
+}
+
+// API looks up pull requests with the provider's REST API; BaseURL defaults to the
+// one the remote's host serves, e.g. https://api.github.com
+type API struct {
+	BaseURL string
+	Token   string
+	Client  *http.Client
+}
+
+// APIURL guesses where the remote's provider serves its REST API: api.github.com,
+// /api/v3 on GitHub Enterprise and /api/v4 on GitLab
+func (r *Remote) APIURL() string {
+	switch {
+	case r == nil || r.Host == "":
+		return ""
+	case r.Provider == GitHub && strings.EqualFold(r.Host, "github.com"):
+		return "https://api.github.com"
+	case r.Provider == GitHub:
+		return "https://" + r.Host + "/api/v3"
+	case r.Provider == GitLab:
+		return "https://" + r.Host + "/api/v4"
+	}
+	return ""
+}
+
+// RemoteBranchExists reports whether the remote-tracking branch <remote>/<branch>
+// was there at the last fetch; it does not ask the remote itself
+func RemoteBranchExists(repo *git.Repository, remote, branch string) bool {
+	if repo == nil || remote == "" || branch == "" {
+		return false
+	}
+	_, err := repo.Reference(plumbing.NewRemoteReferenceName(remote, branch), false)
+	return err == nil
+}
+
+// PullForBranch finds the newest pull request opened from branch on r, or nil when
+// there is none; only GitHub and GitLab are supported
+func (a API) PullForBranch(r *Remote, branch string) (*Pull, error) {
+	base := strings.TrimRight(a.BaseURL, "/")
+	if base == "" {
+		base = r.APIURL()
+	}
+	client := a.Client
+	if client == nil {
+		client = &http.Client{Timeout: cienv.Timeout(5 * time.Second)}
+	}
+
+	switch r.Provider {
+	case GitHub:
+		var pulls []struct {
+			Number   int     `json:"number"`
+			State    string  `json:"state"`
+			MergedAt *string `json:"merged_at"`
+			URL      string  `json:"html_url"`
+		}
+		owner := r.Org
+		if i := strings.LastIndex(owner, "/"); i >= 0 {
+			owner = owner[i+1:]
+		}
+		u := fmt.Sprintf("%s/repos/%s/%s/pulls?state=all&sort=created&direction=desc&head=%s", base, r.Org, r.Repo, url.QueryEscape(owner+":"+branch))
+		if err := a.get(client, u, "Authorization", "Bearer "+a.Token, &pulls); err != nil || len(pulls) == 0 {
+			return nil, err
+		}
+		p := &Pull{Number: pulls[0].Number, State: PullOpen, URL: pulls[0].URL}
+		if pulls[0].MergedAt != nil {
+			p.State = PullMerged
+		} else if pulls[0].State == "closed" {
+			p.State = PullClosed
+		}
+		return p, nil
+	case GitLab:
+		var mrs []struct {
+			IID   int    `json:"iid"`
+			State string `json:"state"`
+			URL   string `json:"web_url"`
+		}
+		u := fmt.Sprintf("%s/projects/%s/merge_requests?state=all&order_by=created_at&sort=desc&source_branch=%s", base, url.PathEscape(r.Org+"/"+r.Repo), url.QueryEscape(branch))
+		if err := a.get(client, u, "PRIVATE-TOKEN", a.Token, &mrs); err != nil || len(mrs) == 0 {
+			return nil, err
+		}
+		p := &Pull{Number: mrs[0].IID, State: PullOpen, URL: mrs[0].URL}
+		switch mrs[0].State {
+		case "merged":
+			p.State = PullMerged
+		case "closed":
+			p.State = PullClosed
+		}
+		return p, nil
+	}
+	return nil, fmt.Errorf("looking up pull requests is not supported on '%s'", r.Host)
+}
+
+func (a API) get(client *http.Client, u, authHeader, authValue string, into interface{}) error {
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return fmt.Errorf("could not build request: %v", err)
+	}
+	if a.Token != "" {
+		req.Header.Set(authHeader, authValue)
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("could not reach '%s': %v", u, err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode >= 400 {
+		return fmt.Errorf("'%s' returned %s", u, res.Status)
+	}
+	if err := json.NewDecoder(res.Body).Decode(into); err != nil {
+		return fmt.Errorf("could not parse the response of '%s': %v", u, err)
+	}
+	return nil
+}
@@ -0,0 +1,223 @@
+package vcshost
+
+import (
+	"fmt"
+	"github.com/davidalpert/go-githooks/pkg/gitconfig"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Provider is the kind of server hosting a remote, used to pick provider specific
+// behavior such as issue links or protected branch lookups
+type Provider string
+
+const (
+	Unknown   Provider = ""
+	GitHub    Provider = "github"
+	GitLab    Provider = "gitlab"
+	Bitbucket Provider = "bitbucket"
+	Gerrit    Provider = "gerrit"
+)
+
+// gerritSSHPort is the port Gerrit serves ssh on unless configured otherwise
+const gerritSSHPort = "29418"
+
+var (
+	// git@github.com:org/repo.git, the scp-like syntax git accepts for ssh
+	scpLikeRe = regexp.MustCompile(`^(?:[^@/]+@)?([^:/]+):(.+)$`)
+
+	// DefaultRemotes are tried in order when no remote is configured
+	DefaultRemotes = []string{"origin", "upstream"}
+)
+
+func ProviderFromString(s string) Provider {
+	switch Provider(strings.ToLower(s)) {
+	case GitHub, GitLab, Bitbucket, Gerrit:
+		return Provider(strings.ToLower(s))
+	}
+	return Unknown
+}
+
+// Remote is where a git remote is hosted
+type Remote struct {
+	Name     string
+	URL      string
+	Host     string
+	Org      string // may hold several segments, e.g. GitLab subgroups
+	Repo     string
+	Provider Provider
+
+	// where the checked-out branch goes, filled in by Track
+	Upstream      string // e.g. upstream/main, the branch's upstream
+	RemoteName    string // the remote the branch tracks, else the one the repo is hosted at
+	DefaultBranch string // e.g. main, the branch the remote's HEAD points at
+}
+
+// Parse splits a remote url (https, ssh or scp-like) into host, org and repo, and
+// guesses the provider from the host
+func Parse(rawURL string) (Remote, error) {
+	r := Remote{URL: rawURL}
+
+	var port, p string
+	if m := scpLikeRe.FindStringSubmatch(rawURL); m != nil && !strings.Contains(rawURL, "://") {
+		r.Host, p = m[1], m[2]
+	} else {
+		u, err := url.Parse(rawURL)
+		if err != nil || u.Host == "" {
+			return r, fmt.Errorf("could not parse remote url '%s'", rawURL)
+		}
+		r.Host, port, p = u.Hostname(), u.Port(), u.Path
+	}
+
+	p = strings.TrimSuffix(strings.Trim(p, "/"), ".git")
+	if port == gerritSSHPort {
+		r.Provider = Gerrit
+	} else {
+		r.Provider = providerForHost(r.Host)
+	}
+	if r.Provider == Gerrit {
+		// authenticated http urls to gerrit are prefixed with /a/
+		p = strings.TrimPrefix(p, "a/")
+	}
+
+	i := strings.LastIndex(p, "/")
+	if i < 0 {
+		r.Repo = p
+	} else {
+		r.Org, r.Repo = p[:i], p[i+1:]
+	}
+	if r.Repo == "" {
+		return r, fmt.Errorf("could not find a repo in remote url '%s'", rawURL)
+	}
+	return r, nil
+}
+
+// providerForHost recognizes the hosted services and self-hosted servers which are
+// named after their product, e.g. gitlab.example.com
+func providerForHost(host string) Provider {
+	host = strings.ToLower(host)
+	for _, p := range []Provider{GitHub, GitLab, Bitbucket, Gerrit} {
+		if strings.Contains(host, string(p)) {
+			return p
+		}
+	}
+	return Unknown
+}
+
+// Detect finds the remote the repo is hosted at, applying overrides from the
+// [go-githooks "vcs"] config section for setups the remote urls do not describe;
+// it returns nil when the repo has no usable remote and nothing is configured
+func Detect(cfg *config.Config) *Remote {
+	remotes := gitconfig.GetSlice(cfg, "go-githooks", "vcs", "remote", DefaultRemotes)
+
+	var r *Remote
+	for _, name := range remotes {
+		rc, ok := cfg.Remotes[strings.TrimSpace(name)]
+		if !ok || len(rc.URLs) == 0 {
+			continue
+		}
+		parsed, err := Parse(rc.URLs[0])
+		if err != nil {
+			continue
+		}
+		parsed.Name = rc.Name
+		r = &parsed
+		break
+	}
+	if r == nil {
+		r = &Remote{}
+	}
+
+	for _, mapping := range gitconfig.GetSlice(cfg, "go-githooks", "vcs", "providerHosts", []string{}) {
+		parts := strings.SplitN(mapping, "=", 2)
+		if len(parts) == 2 && strings.EqualFold(strings.TrimSpace(parts[0]), r.Host) {
+			r.Provider = ProviderFromString(strings.TrimSpace(parts[1]))
+		}
+	}
+
+	r.Host = gitconfig.GetString(cfg, "go-githooks", "vcs", "host", r.Host)
+	r.Org = gitconfig.GetString(cfg, "go-githooks", "vcs", "org", r.Org)
+	r.Repo = gitconfig.GetString(cfg, "go-githooks", "vcs", "repo", r.Repo)
+	if p := gitconfig.GetString(cfg, "go-githooks", "vcs", "provider", ""); p != "" {
+		r.Provider = ProviderFromString(p)
+	}
+
+	if r.Host == "" && r.Repo == "" {
+		return nil
+	}
+	return r
+}
+
+// Track fills in the checked-out branch's upstream and the remote it tracks, from its
+// branch.<name>.remote and .merge config, and the default branch from the symbolic
+// ref <remote>/HEAD (see: git remote set-head). On a fork this tells a branch
+// tracking upstream from one tracking origin
+func (r *Remote) Track(repo *git.Repository, cfg *config.Config) *Remote {
+	if repo == nil || cfg == nil {
+		return r
+	}
+	t := Remote{}
+	if r != nil {
+		t = *r
+	}
+	t.RemoteName = t.Name
+
+	if head, err := repo.Head(); err == nil && head.Name().IsBranch() {
+		if b, ok := cfg.Branches[head.Name().Short()]; ok && b.Remote != "" && b.Merge != "" {
+			if b.Remote == "." {
+				t.Upstream = b.Merge.Short()
+			} else {
+				t.Upstream = b.Remote + "/" + b.Merge.Short()
+				t.RemoteName = b.Remote
+			}
+		}
+	}
+
+	for _, name := range []string{t.RemoteName, "origin"} {
+		if name == "" {
+			continue
+		}
+		ref, err := repo.Storer.Reference(plumbing.NewRemoteHEADReferenceName(name))
+		if err == nil && ref.Type() == plumbing.SymbolicReference {
+			t.DefaultBranch = strings.TrimPrefix(ref.Target().String(), "refs/remotes/"+name+"/")
+			break
+		}
+	}
+
+	if r == nil && t.Upstream == "" && t.RemoteName == "" && t.DefaultBranch == "" {
+		return nil
+	}
+	return &t
+}
+
+// Vars are the template variables describing the remote
+func (r *Remote) Vars() map[string]string {
+	if r == nil {
+		return map[string]string{}
+	}
+	return map[string]string{
+		"host":          r.Host,
+		"org":           r.Org,
+		"repo":          r.Repo,
+		"provider":      string(r.Provider),
+		"upstream":      r.Upstream,
+		"remoteName":    r.RemoteName,
+		"defaultBranch": r.DefaultBranch,
+	}
+}
+
+// Expand replaces {host}, {org}, {repo}, {provider} and, once tracked, {upstream},
+// {remoteName} and {defaultBranch} in template; placeholders without a value are
+// left in place so a missing remote is noticed
+func (r *Remote) Expand(template string) string {
+	for k, v := range r.Vars() {
+		if v != "" {
+			template = strings.Replace(template, "{"+k+"}", v, -1)
+		}
+	}
+	return template
+}
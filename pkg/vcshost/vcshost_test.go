@@ -0,0 +1,187 @@
+package vcshost
+
+import (
+	"github.com/go-git/go-billy/v5/memfs"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/storage/memory"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		url  string
+		want Remote
+	}{
+		{url: "git@github.com:davidalpert/go-githooks.git", want: Remote{Host: "github.com", Org: "davidalpert", Repo: "go-githooks", Provider: GitHub}},
+		{url: "https://github.com/davidalpert/go-githooks", want: Remote{Host: "github.com", Org: "davidalpert", Repo: "go-githooks", Provider: GitHub}},
+		{url: "ssh://git@gitlab.example.com:2222/group/subgroup/app.git", want: Remote{Host: "gitlab.example.com", Org: "group/subgroup", Repo: "app", Provider: GitLab}},
+		{url: "https://mal@bitbucket.org/serenity/cargo.git", want: Remote{Host: "bitbucket.org", Org: "serenity", Repo: "cargo", Provider: Bitbucket}},
+		{url: "ssh://mal@review.example.com:29418/platform/tools", want: Remote{Host: "review.example.com", Org: "platform", Repo: "tools", Provider: Gerrit}},
+		{url: "https://gerrit.example.com/a/platform/tools", want: Remote{Host: "gerrit.example.com", Org: "platform", Repo: "tools", Provider: Gerrit}},
+		{url: "https://git.example.com/tools.git", want: Remote{Host: "git.example.com", Repo: "tools", Provider: Unknown}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			got, err := Parse(tt.url)
+			assert.NoError(t, err)
+			tt.want.URL = tt.url
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	_, err := Parse("not a url")
+	assert.Error(t, err)
+}
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name       string
+		configText string
+		want       *Remote
+	}{
+		{
+			name:       "no remotes",
+			configText: ``,
+			want:       nil,
+		},
+		{
+			name: "falls back to upstream",
+			configText: `
+[remote "upstream"]
+    url = git@github.com:davidalpert/go-githooks.git
+`,
+			want: &Remote{Name: "upstream", URL: "git@github.com:davidalpert/go-githooks.git", Host: "github.com", Org: "davidalpert", Repo: "go-githooks", Provider: GitHub},
+		},
+		{
+			name: "overrides for a self-hosted server",
+			configText: `
+[remote "origin"]
+    url = https://code.corp.internal/platform/tools.git
+[go-githooks "vcs"]
+    providerHosts = code.corp.internal=gitlab
+    org = infra
+`,
+			want: &Remote{Name: "origin", URL: "https://code.corp.internal/platform/tools.git", Host: "code.corp.internal", Org: "infra", Repo: "tools", Provider: GitLab},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, _ := git.Init(memory.NewStorage(), memfs.New())
+			cfg, _ := r.Config()
+			if err := cfg.Unmarshal([]byte(tt.configText)); err != nil {
+				t.Fatalf("unmarshalling sample config: %v", err)
+			}
+
+			assert.Equal(t, tt.want, Detect(cfg))
+		})
+	}
+}
+
+func TestExpand(t *testing.T) {
+	r := &Remote{Host: "github.com", Org: "davidalpert", Repo: "go-githooks"}
+	assert.Equal(t, "[davidalpert/go-githooks#%s]", r.Expand("[{org}/{repo}#%s]"))
+	assert.Equal(t, "{provider}:%s", r.Expand("{provider}:%s"))
+
+	var none *Remote
+	assert.Equal(t, "[{repo}] %s", none.Expand("[{repo}] %s"))
+}
+
+func TestTrack(t *testing.T) {
+	repo, _ := git.Init(memory.NewStorage(), memfs.New())
+	w, _ := repo.Worktree()
+	head, err := w.Commit("first", &git.CommitOptions{Author: &object.Signature{Name: "Mal Reynolds", Email: "mal@serenity.com", When: time.Now()}})
+	if err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+	_ = repo.Storer.SetReference(plumbing.NewHashReference("refs/remotes/upstream/main", head))
+	_ = repo.Storer.SetReference(plumbing.NewSymbolicReference("refs/remotes/upstream/HEAD", "refs/remotes/upstream/main"))
+
+	cfg, _ := repo.Config()
+	if err := cfg.Unmarshal([]byte(`
+[remote "origin"]
+    url = git@github.com:mal/go-githooks.git
+[remote "upstream"]
+    url = git@github.com:davidalpert/go-githooks.git
+[branch "master"]
+    remote = upstream
+    merge = refs/heads/main
+`)); err != nil {
+		t.Fatalf("unmarshalling sample config: %v", err)
+	}
+
+	r := Detect(cfg).Track(repo, cfg)
+	assert.Equal(t, "mal", r.Org, "still hosted at origin")
+	assert.Equal(t, "upstream/main", r.Upstream)
+	assert.Equal(t, "upstream", r.RemoteName)
+	assert.Equal(t, "main", r.DefaultBranch)
+	assert.Equal(t, "[upstream:main] %s", r.Expand("[{remoteName}:{defaultBranch}] %s"))
+
+	delete(cfg.Branches, "master")
+	r = Detect(cfg).Track(repo, cfg)
+	assert.Equal(t, "", r.Upstream)
+	assert.Equal(t, "origin", r.RemoteName, "an untracked branch goes to the repo's remote")
+	assert.Equal(t, "{defaultBranch}", r.Expand("{defaultBranch}"), "origin has no HEAD")
+}
+
+func TestPullForBranch(t *testing.T) {
+	tests := []struct {
+		name     string
+		provider Provider
+		body     string
+		want     *Pull
+		wantPath string
+	}{
+		{
+			name:     "github merged",
+			provider: GitHub,
+			body:     `[{"number": 12, "state": "closed", "merged_at": "2021-06-01T12:00:00Z", "html_url": "https://github.com/serenity/crew/pull/12"}]`,
+			want:     &Pull{Number: 12, State: PullMerged, URL: "https://github.com/serenity/crew/pull/12"},
+			wantPath: "/repos/serenity/crew/pulls?state=all&sort=created&direction=desc&head=serenity%3AABC-1-login",
+		},
+		{
+			name:     "github closed",
+			provider: GitHub,
+			body:     `[{"number": 12, "state": "closed", "merged_at": null}]`,
+			want:     &Pull{Number: 12, State: PullClosed},
+		},
+		{
+			name:     "github none",
+			provider: GitHub,
+			body:     `[]`,
+		},
+		{
+			name:     "gitlab open",
+			provider: GitLab,
+			body:     `[{"iid": 3, "state": "opened", "web_url": "https://gitlab.com/serenity/crew/-/merge_requests/3"}]`,
+			want:     &Pull{Number: 3, State: PullOpen, URL: "https://gitlab.com/serenity/crew/-/merge_requests/3"},
+			wantPath: "/projects/serenity%2Fcrew/merge_requests?state=all&order_by=created_at&sort=desc&source_branch=ABC-1-login",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var path string
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				path = r.URL.RequestURI()
+				w.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+
+			r := &Remote{Host: "example.com", Org: "serenity", Repo: "crew", Provider: tt.provider}
+			got, err := API{BaseURL: srv.URL}.PullForBranch(r, "ABC-1-login")
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+			if tt.wantPath != "" {
+				assert.Equal(t, tt.wantPath, path)
+			}
+		})
+	}
+
+	_, err := API{}.PullForBranch(&Remote{Host: "bitbucket.org", Provider: Bitbucket}, "main")
+	assert.EqualError(t, err, "looking up pull requests is not supported on 'bitbucket.org'")
+}
@@ -0,0 +1,186 @@
+package worklog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/davidalpert/go-githooks/pkg/cienv"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+/*
+ * A Provider posts the entries for one issue to a tracker:
+ *
+ *     jira     a comment per batch, or a worklog per commit which records time;
+ *              url is the site, e.g. https://example.atlassian.net
+ *     github   a comment per batch; url is the repo's API, e.g. https://api.github.com/repos/owner/repo
+ *     gitlab   a note per batch, with a /spend for the time recorded; url is the
+ *              project's API, e.g. https://gitlab.com/api/v4/projects/42
+ *     command  any command, given the issue and its entries as JSON on stdin
+ */
+type Provider interface {
+	Log(issue string, entries []Entry) error
+}
+
+// Config describes the provider to open
+type Config struct {
+	Provider string
+	URL      string
+	User     string // for jira cloud, which takes the user and an API token
+	Token    string
+	Command  string
+}
+
+// Open returns the provider c describes
+func Open(c Config) (Provider, error) {
+	client := &http.Client{Timeout: cienv.Timeout(10 * time.Second)}
+	base := strings.TrimRight(c.URL, "/")
+	switch c.Provider {
+	case "jira", "github", "gitlab":
+		if base == "" {
+			return nil, fmt.Errorf("the %s worklog provider needs a url", c.Provider)
+		}
+	}
+
+	switch c.Provider {
+	case "jira":
+		return &jira{BaseURL: base, User: c.User, Token: c.Token, Client: client}, nil
+	case "github":
+		return &github{BaseURL: base, Token: c.Token, Client: client}, nil
+	case "gitlab":
+		return &gitlab{BaseURL: base, Token: c.Token, Client: client}, nil
+	case "command":
+		if c.Command == "" {
+			return nil, fmt.Errorf("the command worklog provider needs a command")
+		}
+		return command(c.Command), nil
+	}
+	return nil, fmt.Errorf("unknown worklog provider '%s', expected jira, github, gitlab or command", c.Provider)
+}
+
+// comments joins the comments of a batch into one
+func comments(entries []Entry) string {
+	lines := make([]string, 0, len(entries))
+	for _, e := range entries {
+		lines = append(lines, e.Comment)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func post(client *http.Client, url string, body interface{}, auth func(*http.Request)) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	auth(req)
+
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("%s", res.Status)
+	}
+	return nil
+}
+
+type jira struct {
+	BaseURL string
+	User    string
+	Token   string
+	Client  *http.Client
+}
+
+func (j *jira) auth(req *http.Request) {
+	switch {
+	case j.User != "":
+		req.SetBasicAuth(j.User, j.Token)
+	case j.Token != "":
+		// a personal access token on Jira server
+		req.Header.Set("Authorization", "Bearer "+j.Token)
+	}
+}
+
+func (j *jira) Log(issue string, entries []Entry) error {
+	plain := make([]Entry, 0, len(entries))
+	for _, e := range entries {
+		if e.TimeSpent == "" {
+			plain = append(plain, e)
+			continue
+		}
+		body := map[string]string{"timeSpent": e.TimeSpent, "comment": e.Comment}
+		if err := post(j.Client, fmt.Sprintf("%s/rest/api/2/issue/%s/worklog", j.BaseURL, issue), body, j.auth); err != nil {
+			return err
+		}
+	}
+	if len(plain) == 0 {
+		return nil
+	}
+	return post(j.Client, fmt.Sprintf("%s/rest/api/2/issue/%s/comment", j.BaseURL, issue), map[string]string{"body": comments(plain)}, j.auth)
+}
+
+type github struct {
+	BaseURL string
+	Token   string
+	Client  *http.Client
+}
+
+func (g *github) Log(issue string, entries []Entry) error {
+	return post(g.Client, fmt.Sprintf("%s/issues/%s/comments", g.BaseURL, strings.TrimPrefix(issue, "#")), map[string]string{"body": comments(entries)}, func(req *http.Request) {
+		if g.Token != "" {
+			req.Header.Set("Authorization", "Bearer "+g.Token)
+		}
+	})
+}
+
+type gitlab struct {
+	BaseURL string
+	Token   string
+	Client  *http.Client
+}
+
+func (g *gitlab) Log(issue string, entries []Entry) error {
+	body := comments(entries)
+	for _, e := range entries {
+		if e.TimeSpent != "" {
+			// a quick action, which gitlab runs and removes from the note
+			body += "\n/spend " + e.TimeSpent
+		}
+	}
+	return post(g.Client, fmt.Sprintf("%s/issues/%s/notes", g.BaseURL, strings.TrimPrefix(issue, "#")), map[string]string{"body": body}, func(req *http.Request) {
+		if g.Token != "" {
+			req.Header.Set("PRIVATE-TOKEN", g.Token)
+		}
+	})
+}
+
+type command string
+
+func (c command) Log(issue string, entries []Entry) error {
+	data, err := json.Marshal(struct {
+		Issue   string  `json:"issue"`
+		Entries []Entry `json:"entries"`
+	}{issue, entries})
+	if err != nil {
+		return err
+	}
+	cmd := exec.Command("sh", "-c", string(c))
+	cmd.Stdin = bytes.NewReader(data)
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("'%s' failed: %v", c, err)
+	}
+	return nil
+}
@@ -0,0 +1,223 @@
+package worklog
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/davidalpert/go-githooks/pkg/fileio"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+/*
+ * Work-logging is opt-in per repo: when enabled, the post-commit hook queues an
+ * entry for every issue a commit references, and a background flush posts the
+ * queued entries to the tracker, one batch per issue. A commit which records time
+ * with a smart commit command (ABC-123 #time 2h) logs that time as well.
+ *
+ * The queue lives in .git/go-githooks/worklog.jsonl, so commits made offline, or
+ * while the tracker is down, are logged by the next flush which succeeds.
+ */
+
+// DefaultTemplate is the comment logged for a commit
+const DefaultTemplate = "{shortSha} {subject} (on {branch})"
+
+// MaxAttempts is how many flushes try an entry before it is dropped
+const MaxAttempts = 5
+
+const (
+	queueFile = "worklog.jsonl"
+	lockFile  = "worklog.lock"
+	// a lock older than this was left by a flush which died
+	staleLock = 10 * time.Minute
+)
+
+// Entry is one commit to log against one issue
+type Entry struct {
+	Issue     string    `json:"issue"`
+	Sha       string    `json:"sha"`
+	Subject   string    `json:"subject"`
+	Branch    string    `json:"branch"`
+	Author    string    `json:"author"`
+	When      time.Time `json:"when"`
+	Comment   string    `json:"comment"`
+	TimeSpent string    `json:"timeSpent,omitempty"`
+	Attempts  int       `json:"attempts,omitempty"`
+}
+
+// ShortSha is the abbreviated commit hash
+func (e Entry) ShortSha() string {
+	if len(e.Sha) > 7 {
+		return e.Sha[:7]
+	}
+	return e.Sha
+}
+
+// Expand replaces {sha}, {shortSha}, {subject}, {branch}, {author}, {issue} and
+// {time} in template with the entry's values
+func (e Entry) Expand(template string) string {
+	return strings.NewReplacer(
+		"{sha}", e.Sha,
+		"{shortSha}", e.ShortSha(),
+		"{subject}", e.Subject,
+		"{branch}", e.Branch,
+		"{author}", e.Author,
+		"{issue}", e.Issue,
+		"{time}", e.TimeSpent,
+	).Replace(template)
+}
+
+// Queue holds the entries waiting to be logged
+type Queue struct {
+	Dir string
+}
+
+func (q Queue) path() string {
+	return filepath.Join(q.Dir, queueFile)
+}
+
+// Append adds entries to the queue; it does not wait for a flush in progress
+func (q Queue) Append(entries ...Entry) error {
+	if err := os.MkdirAll(q.Dir, 0755); err != nil {
+		return fmt.Errorf("could not create '%s': %v", q.Dir, err)
+	}
+	var buf bytes.Buffer
+	for _, e := range entries {
+		line, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		buf.Write(append(line, '\n'))
+	}
+
+	f, err := os.OpenFile(q.path(), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("could not open the worklog queue: %v", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(buf.Bytes()); err != nil {
+		return fmt.Errorf("could not write the worklog queue: %v", err)
+	}
+	return nil
+}
+
+// Pending returns the queued entries
+func (q Queue) Pending() ([]Entry, error) {
+	return readEntries(q.path())
+}
+
+// Flush logs the queued entries with p, one batch per issue, and keeps those which
+// failed for the next flush. It returns how many entries were logged; when another
+// flush holds the queue it returns 0 and no error, since that flush will pick up
+// the entries queued meanwhile.
+func (q Queue) Flush(p Provider) (int, error) {
+	locked, err := q.lock()
+	if err != nil || !locked {
+		return 0, err
+	}
+	defer os.Remove(filepath.Join(q.Dir, lockFile))
+
+	sent := 0
+	failures := make([]string, 0)
+	retry := make([]Entry, 0)
+	for {
+		// take everything queued so far; commits made meanwhile start a new queue
+		taken := q.path() + ".sending"
+		if err := os.Rename(q.path(), taken); os.IsNotExist(err) {
+			break
+		} else if err != nil {
+			return sent, fmt.Errorf("could not take the worklog queue: %v", err)
+		}
+		entries, err := readEntries(taken)
+		if err != nil {
+			return sent, err
+		}
+
+		for _, batch := range byIssue(entries) {
+			if err := p.Log(batch[0].Issue, batch); err != nil {
+				failures = append(failures, fmt.Sprintf("%s: %v", batch[0].Issue, err))
+				for _, e := range batch {
+					if e.Attempts++; e.Attempts < MaxAttempts {
+						retry = append(retry, e)
+					}
+				}
+				continue
+			}
+			sent += len(batch)
+		}
+		_ = os.Remove(taken)
+	}
+
+	if len(retry) > 0 {
+		if err := q.Append(retry...); err != nil {
+			return sent, err
+		}
+	}
+	if len(failures) > 0 {
+		return sent, fmt.Errorf("could not log to %s", strings.Join(failures, "; "))
+	}
+	return sent, nil
+}
+
+// lock reports whether this flush owns the queue
+func (q Queue) lock() (bool, error) {
+	if err := os.MkdirAll(q.Dir, 0755); err != nil {
+		return false, fmt.Errorf("could not create '%s': %v", q.Dir, err)
+	}
+	path := filepath.Join(q.Dir, lockFile)
+	if fi, err := os.Stat(path); err == nil && time.Since(fi.ModTime()) > staleLock {
+		_ = os.Remove(path)
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if os.IsExist(err) {
+		return false, nil
+	} else if err != nil {
+		return false, fmt.Errorf("could not lock the worklog queue: %v", err)
+	}
+	fmt.Fprintf(f, "%d\n", os.Getpid())
+	return true, f.Close()
+}
+
+func readEntries(path string) ([]Entry, error) {
+	data, err := fileio.ReadFile(path)
+	if os.IsNotExist(err) {
+		return []Entry{}, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("could not read '%s': %v", path, err)
+	}
+
+	entries := make([]Entry, 0)
+	s := bufio.NewScanner(bytes.NewReader(data))
+	s.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for s.Scan() {
+		if len(bytes.TrimSpace(s.Bytes())) == 0 {
+			continue
+		}
+		var e Entry
+		if err := json.Unmarshal(s.Bytes(), &e); err != nil {
+			// a line cut short by a crash; the rest of the queue is still good
+			continue
+		}
+		entries = append(entries, e)
+	}
+	return entries, s.Err()
+}
+
+// byIssue groups entries by issue, in the order the issues were first queued
+func byIssue(entries []Entry) [][]Entry {
+	index := map[string]int{}
+	batches := make([][]Entry, 0)
+	for _, e := range entries {
+		i, ok := index[e.Issue]
+		if !ok {
+			i = len(batches)
+			index[e.Issue] = i
+			batches = append(batches, nil)
+		}
+		batches[i] = append(batches[i], e)
+	}
+	return batches
+}
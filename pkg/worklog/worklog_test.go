@@ -0,0 +1,141 @@
+package worklog
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+type fakeProvider struct {
+	logged map[string][]Entry
+	fail   map[string]bool
+}
+
+func (p *fakeProvider) Log(issue string, entries []Entry) error {
+	if p.fail[issue] {
+		return fmt.Errorf("503 Service Unavailable")
+	}
+	p.logged[issue] = append(p.logged[issue], entries...)
+	return nil
+}
+
+func TestExpand(t *testing.T) {
+	e := Entry{Issue: "ABC-123", Sha: "0123456789abcdef", Subject: "fix login", Branch: "feature/ABC-123", Author: "Mal Reynolds", TimeSpent: "2h"}
+	assert.Equal(t, "0123456 fix login (on feature/ABC-123)", e.Expand(DefaultTemplate))
+	assert.Equal(t, "ABC-123: Mal Reynolds spent 2h in 0123456789abcdef", e.Expand("{issue}: {author} spent {time} in {sha}"))
+}
+
+func TestFlush(t *testing.T) {
+	dir, err := ioutil.TempDir("", "worklog")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	q := Queue{Dir: dir}
+
+	assert.NoError(t, q.Append(Entry{Issue: "ABC-1", Sha: "a"}, Entry{Issue: "ABC-2", Sha: "b"}))
+	assert.NoError(t, q.Append(Entry{Issue: "ABC-1", Sha: "c"}))
+
+	p := &fakeProvider{logged: map[string][]Entry{}, fail: map[string]bool{"ABC-2": true}}
+	sent, err := q.Flush(p)
+	assert.EqualError(t, err, "could not log to ABC-2: 503 Service Unavailable")
+	assert.Equal(t, 2, sent)
+	assert.Len(t, p.logged["ABC-1"], 2, "one batch per issue")
+
+	pending, err := q.Pending()
+	assert.NoError(t, err)
+	if assert.Len(t, pending, 1) {
+		assert.Equal(t, "b", pending[0].Sha)
+		assert.Equal(t, 1, pending[0].Attempts)
+	}
+
+	p.fail = nil
+	sent, err = q.Flush(p)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, sent)
+	pending, _ = q.Pending()
+	assert.Empty(t, pending)
+}
+
+func TestFlushDropsAfterMaxAttempts(t *testing.T) {
+	dir, err := ioutil.TempDir("", "worklog")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	q := Queue{Dir: dir}
+
+	assert.NoError(t, q.Append(Entry{Issue: "ABC-1", Attempts: MaxAttempts - 1}))
+	_, err = q.Flush(&fakeProvider{fail: map[string]bool{"ABC-1": true}})
+	assert.Error(t, err)
+	pending, _ := q.Pending()
+	assert.Empty(t, pending)
+}
+
+func TestFlushLocked(t *testing.T) {
+	dir, err := ioutil.TempDir("", "worklog")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	q := Queue{Dir: dir}
+
+	assert.NoError(t, q.Append(Entry{Issue: "ABC-1"}))
+	assert.NoError(t, ioutil.WriteFile(dir+"/"+lockFile, []byte("1\n"), 0644))
+	sent, err := q.Flush(&fakeProvider{logged: map[string][]Entry{}})
+	assert.NoError(t, err)
+	assert.Equal(t, 0, sent)
+	pending, _ := q.Pending()
+	assert.Len(t, pending, 1)
+}
+
+func TestJira(t *testing.T) {
+	requests := map[string]map[string]string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pass, _ := r.BasicAuth()
+		assert.Equal(t, "mal@serenity.com", user)
+		assert.Equal(t, "s3cret", pass)
+		body := map[string]string{}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		requests[r.URL.Path] = body
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	p, err := Open(Config{Provider: "jira", URL: server.URL + "/", User: "mal@serenity.com", Token: "s3cret"})
+	assert.NoError(t, err)
+	assert.NoError(t, p.Log("ABC-1", []Entry{{Comment: "one"}, {Comment: "two", TimeSpent: "2h"}, {Comment: "three"}}))
+
+	assert.Equal(t, map[string]map[string]string{
+		"/rest/api/2/issue/ABC-1/comment": {"body": "one\nthree"},
+		"/rest/api/2/issue/ABC-1/worklog": {"timeSpent": "2h", "comment": "two"},
+	}, requests)
+}
+
+func TestGitLab(t *testing.T) {
+	var path string
+	body := map[string]string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "s3cret", r.Header.Get("PRIVATE-TOKEN"))
+		path = r.URL.Path
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	p, err := Open(Config{Provider: "gitlab", URL: server.URL + "/api/v4/projects/42", Token: "s3cret"})
+	assert.NoError(t, err)
+	assert.EqualError(t, p.Log("#7", []Entry{{Comment: "one", TimeSpent: "30m"}}), "401 Unauthorized")
+	assert.Equal(t, "/api/v4/projects/42/issues/7/notes", path)
+	assert.Equal(t, "one\n/spend 30m", body["body"])
+}
+
+func TestOpen(t *testing.T) {
+	for _, c := range []Config{
+		{Provider: "jira"},
+		{Provider: "command"},
+		{Provider: "youtrack", URL: "https://example.com"},
+	} {
+		_, err := Open(c)
+		assert.Error(t, err, c.Provider)
+	}
+}